@@ -0,0 +1,10 @@
+// Package api embeds the OpenAPI description of the AI Gap Finder service.
+// The Go types in gapfinder/types are checked against it in tests.
+package api
+
+import _ "embed"
+
+// OpenAPI is the service's OpenAPI 3 document in YAML
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
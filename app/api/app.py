@@ -1,12 +1,43 @@
+import json
+import math
+import os
 import time
-from fastapi import FastAPI, HTTPException
-from app.utils.logger import setup_logging, get_logger
+import uuid
+from datetime import datetime, timezone
+from typing import List, Optional
+from fastapi import (
+    FastAPI, File, Form, HTTPException, Header, Query, Request, UploadFile, WebSocket,
+    WebSocketDisconnect, status
+)
+from fastapi.middleware.gzip import GZipMiddleware
+from fastapi.responses import JSONResponse, Response, StreamingResponse
+from pydantic import ValidationError
+from starlette.routing import Match
+from app.utils.logger import setup_logging, get_logger, request_id_var
 from app.schema.models import (
-    AnalyzeRequest, TopicRequest, AnalyzeResponse, TopicResponse,
-    HealthResponse
+    AnalyzeRequest, TopicRequest, AnalyzeResponse, TopicResponse, FieldEnum, AnalysisMode,
+    HealthResponse, FieldsResponse, ModelsResponse, BatchAnalyzeRequest, BatchAnalyzeResponse, Job, ProgressMessage,
+    TopicResultsPage, DOIRequest, ArxivRequest, PMIDRequest, CompareRequest, ComparisonResponse,
+    DeduplicateRequest, DeduplicateResponse, HypothesesRequest, HypothesesResponse, ReviewRequest, ReviewResponse,
+    CitationsRequest, CitationsResponse, TrendsRequest, TrendsResponse, ResearchGroupsRequest,
+    ResearchGroupsResponse, FundingRequest, FundingResponse,
+    NoveltyRequest, NoveltyResponse, RelatedWorkRequest, RelatedWorkResponse,
+    ProposalRequest, ProposalResponse, PeerReviewResponse, MethodsRequest, MethodsResponse,
+    DatasetsRequest, DatasetsResponse, RefineRequest, UsageResponse, CostEstimate, AnalysesResponse, Analysis
+)
+from app.service.analysis import (
+    analyze_text, analyze_topic, analyze_batch, analyze_topic_stream, analyze_pdf, analyze_doi,
+    analyze_arxiv, analyze_pmid, compare_papers, deduplicate_gaps, generate_hypotheses,
+    generate_review, suggest_citations, analyze_trends, recommend_methods, suggest_datasets,
+    find_research_groups, match_funding, check_novelty,
+    get_related_work, analyze_proposal, simulate_review, refine_analysis, estimate_topic_cost, estimate_abstract_cost, PDFError, PaperNotFoundError, MissingAbstractError
 )
-from app.service.analysis import analyze_text, analyze_topic
 from app.core.config import get_settings
+from app.service.history import analysis_history
+from app.service.jobs import JobStoreFullError, current_job_id, job_store
+from app.service.pages import paginate_topic, result_pages
+from app.utils.idempotency import idempotency_cache
+from app.utils import usage, webhook
 
 setup_logging()
 logger = get_logger(__name__)
@@ -19,30 +50,526 @@ def create_app() -> FastAPI:
         description="AI Gap Finder - Microservice for scientific research gap analysis"
     )
 
+    # Topic responses can be large; compress them for clients that accept gzip
+    app.add_middleware(GZipMiddleware, minimum_size=1000)
+
+    def endpoint_of(request: Request) -> Optional[str]:
+        """Method and path pattern of the route serving request, if any"""
+        for route in app.router.routes:
+            match, _ = route.matches(request.scope)
+            if match == Match.FULL:
+                return f"{request.method} {route.path}"
+        return None
+
+    # Added before request_id_middleware so that refused requests get an
+    # X-Request-ID too
+    @app.middleware("http")
+    async def quota_middleware(request: Request, call_next):
+        endpoint = endpoint_of(request)
+        if endpoint is None or endpoint in usage.UNCOUNTED_ENDPOINTS:
+            return await call_next(request)
+        caller = usage.usage_tracker.caller_key(request.headers, request.client and request.client.host)
+        retry_after = usage.usage_tracker.record(caller, endpoint)
+        if retry_after is not None:
+            return JSONResponse(
+                status_code=429,
+                content={"detail": "Quota exceeded, try again later"},
+                headers={"Retry-After": str(math.ceil(retry_after))}
+            )
+        return await call_next(request)
+
+    @app.middleware("http")
+    async def request_id_middleware(request: Request, call_next):
+        # Reuse the caller's ID so failures can be traced across services
+        request_id = request.headers.get("X-Request-ID") or str(uuid.uuid4())
+        token = request_id_var.set(request_id)
+        try:
+            response = await call_next(request)
+        finally:
+            request_id_var.reset(token)
+        response.headers["X-Request-ID"] = request_id
+        return response
+
     @app.post("/analyze", response_model=AnalyzeResponse)
-    async def analyze(request: AnalyzeRequest):
+    async def analyze(request: AnalyzeRequest, idempotency_key: Optional[str] = Header(None)):
         start_time = time.time()
-        try:
+
+        key = idempotency_key and f"analyze:{idempotency_key}"
+
+        async def run():
             result = await analyze_text(request)
-            processing_time = round(time.time() - start_time, 2)
-            result['processing_time'] = processing_time
+            result['processing_time'] = round(time.time() - start_time, 2)
+            analysis_history.add_abstract(request.title, request.field or FieldEnum.GENERAL, result, key)
             return result
+
+        try:
+            return await idempotency_cache.run(key, run)
         except Exception as e:
             logger.error(f"Error during /analyze: {str(e)}")
             raise HTTPException(status_code=500, detail="An error occurred during analysis.")
 
-    @app.post("/topic", response_model=TopicResponse)
-    async def analyze_topic_route(request: TopicRequest):
+    @app.post("/analyze/estimate", response_model=CostEstimate)
+    async def estimate_abstract(request: AnalyzeRequest):
         start_time = time.time()
         try:
-            result = await analyze_topic(request)
+            result = estimate_abstract_cost(request)
+        except Exception as e:
+            logger.error(f"Error during /analyze/estimate: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during cost estimation.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/analyze/pdf", response_model=AnalyzeResponse)
+    async def analyze_pdf_route(
+        file: UploadFile = File(..., description="Paper PDF"),
+        title: Optional[str] = Form(None, description="Defaults to the PDF's title or file name"),
+        field: FieldEnum = Form(FieldEnum.GENERAL),
+        authors: Optional[List[str]] = Form(None),
+        mode: AnalysisMode = Form(AnalysisMode.ABSTRACT)
+    ):
+        start_time = time.time()
+        pdf_bytes = await file.read()
+        try:
+            result = await analyze_pdf(pdf_bytes, file.filename or "", title, field, authors, mode)
+        except PDFError as e:
+            raise HTTPException(status_code=status.HTTP_422_UNPROCESSABLE_ENTITY, detail=str(e))
+        except Exception as e:
+            logger.error(f"Error during /analyze/pdf: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during analysis.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        analysis_history.add_abstract(title or os.path.splitext(file.filename or "")[0] or "Untitled", field, result)
+        return result
+
+    @app.post("/analyze/doi", response_model=AnalyzeResponse)
+    async def analyze_doi_route(request: DOIRequest):
+        start_time = time.time()
+        try:
+            result = await analyze_doi(request)
+        except PaperNotFoundError as e:
+            raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail=str(e))
+        except MissingAbstractError as e:
+            raise HTTPException(status_code=status.HTTP_422_UNPROCESSABLE_ENTITY, detail=str(e))
+        except Exception as e:
+            logger.error(f"Error during /analyze/doi: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during analysis.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        analysis_history.add_abstract(result['paper']['title'], request.field or FieldEnum.GENERAL, result)
+        return result
+
+    @app.post("/analyze/pmid", response_model=AnalyzeResponse)
+    async def analyze_pmid_route(request: PMIDRequest):
+        start_time = time.time()
+        try:
+            result = await analyze_pmid(request)
+        except PaperNotFoundError as e:
+            raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail=str(e))
+        except MissingAbstractError as e:
+            raise HTTPException(status_code=status.HTTP_422_UNPROCESSABLE_ENTITY, detail=str(e))
+        except Exception as e:
+            logger.error(f"Error during /analyze/pmid: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during analysis.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        analysis_history.add_abstract(result['paper']['title'], request.field or FieldEnum.GENERAL, result)
+        return result
+
+    @app.post("/analyze/arxiv", response_model=AnalyzeResponse)
+    async def analyze_arxiv_route(request: ArxivRequest):
+        start_time = time.time()
+        try:
+            result = await analyze_arxiv(request)
+        except PaperNotFoundError as e:
+            raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail=str(e))
+        except (MissingAbstractError, PDFError) as e:
+            raise HTTPException(status_code=status.HTTP_422_UNPROCESSABLE_ENTITY, detail=str(e))
+        except Exception as e:
+            logger.error(f"Error during /analyze/arxiv: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during analysis.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        analysis_history.add_abstract(result['paper']['title'], request.field or FieldEnum.GENERAL, result)
+        return result
+
+    @app.post("/analyze/peer-review", response_model=PeerReviewResponse)
+    async def peer_review(request: AnalyzeRequest):
+        start_time = time.time()
+        try:
+            result = await simulate_review(request)
+        except Exception as e:
+            logger.error(f"Error during /analyze/peer-review: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during peer review simulation.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/analyze/refine", response_model=AnalyzeResponse)
+    async def refine(request: RefineRequest):
+        start_time = time.time()
+        try:
+            result = await refine_analysis(request)
+        except Exception as e:
+            logger.error(f"Error during /analyze/refine: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during refinement.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/compare", response_model=ComparisonResponse)
+    async def compare(request: CompareRequest):
+        start_time = time.time()
+        try:
+            result = await compare_papers(request)
+        except Exception as e:
+            logger.error(f"Error during /compare: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during comparison.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/gaps/deduplicate", response_model=DeduplicateResponse)
+    async def deduplicate(request: DeduplicateRequest):
+        start_time = time.time()
+        try:
+            result = await deduplicate_gaps(request)
+        except Exception as e:
+            logger.error(f"Error during /gaps/deduplicate: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during deduplication.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/gaps/citations", response_model=CitationsResponse)
+    async def citations(request: CitationsRequest):
+        start_time = time.time()
+        try:
+            result = await suggest_citations(request)
+        except Exception as e:
+            logger.error(f"Error during /gaps/citations: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during citation suggestion.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/gaps/related", response_model=RelatedWorkResponse)
+    async def related_work(request: RelatedWorkRequest):
+        start_time = time.time()
+        try:
+            result = await get_related_work(request)
+        except Exception as e:
+            logger.error(f"Error during /gaps/related: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during related work retrieval.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/gaps/datasets", response_model=DatasetsResponse)
+    async def datasets(request: DatasetsRequest):
+        start_time = time.time()
+        try:
+            result = await suggest_datasets(request)
+        except Exception as e:
+            logger.error(f"Error during /gaps/datasets: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during dataset suggestion.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/gaps/groups", response_model=ResearchGroupsResponse)
+    async def research_groups(request: ResearchGroupsRequest):
+        start_time = time.time()
+        try:
+            result = await find_research_groups(request)
+        except Exception as e:
+            logger.error(f"Error during /gaps/groups: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred while finding research groups.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/gaps/funding", response_model=FundingResponse)
+    async def funding(request: FundingRequest):
+        start_time = time.time()
+        try:
+            result = await match_funding(request)
+        except Exception as e:
+            logger.error(f"Error during /gaps/funding: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during funding matching.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/hypotheses", response_model=HypothesesResponse)
+    async def hypotheses(request: HypothesesRequest):
+        start_time = time.time()
+        try:
+            result = await generate_hypotheses(request)
+        except Exception as e:
+            logger.error(f"Error during /hypotheses: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during hypothesis generation.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/hypotheses/methods", response_model=MethodsResponse)
+    async def methods(request: MethodsRequest):
+        start_time = time.time()
+        try:
+            result = await recommend_methods(request)
+        except Exception as e:
+            logger.error(f"Error during /hypotheses/methods: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during methodology recommendation.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/ideas/novelty", response_model=NoveltyResponse)
+    async def novelty(request: NoveltyRequest):
+        start_time = time.time()
+        try:
+            result = await check_novelty(request)
+        except Exception as e:
+            logger.error(f"Error during /ideas/novelty: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during novelty checking.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/ideas/proposal", response_model=ProposalResponse)
+    async def proposal(request: ProposalRequest):
+        start_time = time.time()
+        try:
+            result = await analyze_proposal(request)
+        except Exception as e:
+            logger.error(f"Error during /ideas/proposal: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during proposal analysis.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/review", response_model=ReviewResponse)
+    async def review(request: ReviewRequest):
+        start_time = time.time()
+        try:
+            result = await generate_review(request)
+        except Exception as e:
+            logger.error(f"Error during /review: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during review generation.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/analyze/batch", response_model=BatchAnalyzeResponse)
+    async def analyze_batch_route(request: BatchAnalyzeRequest, idempotency_key: Optional[str] = Header(None)):
+        start_time = time.time()
+
+        key = idempotency_key and f"batch:{idempotency_key}"
+
+        async def run():
+            results = await analyze_batch(request.requests)
+            for item in results:
+                if item["result"] is not None:
+                    item_request = request.requests[item["index"]]
+                    analysis_history.add_abstract(
+                        item_request.title, item_request.field or FieldEnum.GENERAL, item["result"], key
+                    )
+            return results
+
+        try:
+            results = await idempotency_cache.run(key, run)
             processing_time = round(time.time() - start_time, 2)
-            result['processing_time'] = processing_time
+            return {"results": results, "processing_time": processing_time}
+        except Exception as e:
+            logger.error(f"Error during /analyze/batch: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during batch analysis.")
+
+    @app.post("/topic", response_model=TopicResponse)
+    async def analyze_topic_route(request: TopicRequest, idempotency_key: Optional[str] = Header(None)):
+        start_time = time.time()
+
+        key = idempotency_key and f"topic:{idempotency_key}"
+
+        async def run():
+            result = await analyze_topic(request)
+            result['processing_time'] = round(time.time() - start_time, 2)
+            analysis_history.add_topic(request.topic, request.field or FieldEnum.GENERAL, result, key)
             return result
+
+        try:
+            result = await idempotency_cache.run(key, run)
+            return paginate_topic(result, request.page_size)
         except Exception as e:
             logger.error(f"Error during /topic: {str(e)}")
             raise HTTPException(status_code=500, detail="An error occurred during topic analysis.")
 
+    @app.post("/topic/estimate", response_model=CostEstimate)
+    async def estimate_topic(request: TopicRequest):
+        start_time = time.time()
+        try:
+            result = await estimate_topic_cost(request)
+        except Exception as e:
+            logger.error(f"Error during /topic/estimate: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during cost estimation.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.post("/topic/trends", response_model=TrendsResponse)
+    async def topic_trends(request: TrendsRequest):
+        start_time = time.time()
+        try:
+            result = await analyze_trends(request)
+        except Exception as e:
+            logger.error(f"Error during /topic/trends: {str(e)}")
+            raise HTTPException(status_code=500, detail="An error occurred during trend analysis.")
+        result['processing_time'] = round(time.time() - start_time, 2)
+        return result
+
+    @app.get("/topic/results", response_model=TopicResultsPage)
+    async def topic_results_page(cursor: str = Query(..., description="Cursor from a previous response")):
+        page = result_pages.page(cursor)
+        if page is None:
+            raise HTTPException(status_code=404, detail="Cursor not found or expired")
+        results, next_cursor = page
+        return {"individual_results": results, "next_cursor": next_cursor}
+
+    @app.post("/topic/stream")
+    async def stream_topic_route(request: TopicRequest):
+        start_time = time.time()
+
+        async def events():
+            try:
+                async for event, data in analyze_topic_stream(request):
+                    if event == "summary":
+                        data["processing_time"] = round(time.time() - start_time, 2)
+                    yield f"event: {event}\ndata: {json.dumps(data)}\n\n"
+            except Exception as e:
+                logger.error(f"Error during /topic/stream: {str(e)}")
+                detail = json.dumps({"detail": "An error occurred during topic analysis."})
+                yield f"event: error\ndata: {detail}\n\n"
+
+        return StreamingResponse(events(), media_type="text/event-stream", headers={"Cache-Control": "no-cache"})
+
+    @app.websocket("/topic/ws")
+    async def topic_progress_ws(websocket: WebSocket):
+        # The client sends a TopicRequest, then receives ProgressMessages
+        # until the result or an error
+        await websocket.accept()
+        start_time = time.time()
+
+        async def send(**message):
+            await websocket.send_json(ProgressMessage(**message).model_dump(mode="json"))
+
+        try:
+            request = TopicRequest.model_validate(await websocket.receive_json())
+        except ValidationError as e:
+            error = e.errors()[0]
+            await send(type="error", detail=f"{'.'.join(str(p) for p in error['loc'])}: {error['msg']}")
+            await websocket.close(code=1008)
+            return
+        except ValueError:
+            await send(type="error", detail="Invalid topic request.")
+            await websocket.close(code=1008)
+            return
+        except WebSocketDisconnect:
+            return
+
+        try:
+            results = []
+            async for event, data in analyze_topic_stream(request, progress=True):
+                if event == "progress":
+                    await send(type="progress", progress=data)
+                elif event == "result":
+                    results.append(data)
+                elif event == "summary":
+                    data["individual_results"] = results
+                    data["processing_time"] = round(time.time() - start_time, 2)
+                    await send(type="result", result=data)
+        except WebSocketDisconnect:
+            logger.info("Client left /topic/ws before the analysis finished")
+            return
+        except Exception as e:
+            logger.error(f"Error during /topic/ws: {str(e)}")
+            await send(type="error", detail="An error occurred during topic analysis.")
+        await websocket.close()
+
+    @app.post("/topic/jobs", response_model=Job, status_code=status.HTTP_202_ACCEPTED)
+    async def submit_topic_job(request: TopicRequest, idempotency_key: Optional[str] = Header(None),
+                               callback_url: Optional[str] = Header(None)):
+        on_done = None
+        if callback_url:
+            error = webhook.check_callback_url(callback_url, settings.webhook_secret, settings.webhook_allow_private)
+            if error:
+                raise HTTPException(
+                    status_code=status.HTTP_422_UNPROCESSABLE_ENTITY,
+                    detail=[{"loc": ["header", "callback-url"], "msg": error, "type": "value_error"}],
+                )
+            request_id = request_id_var.get()
+
+            async def on_done(job):
+                await webhook.deliver(callback_url, job, settings.webhook_secret, request_id,
+                                      allow_private=settings.webhook_allow_private)
+
+        key = idempotency_key and f"topic-job:{idempotency_key}"
+
+        async def submit():
+            async def run():
+                start_time = time.time()
+                result = await analyze_topic(request)
+                result['processing_time'] = round(time.time() - start_time, 2)
+                analysis_history.add_topic(request.topic, request.field or FieldEnum.GENERAL, result,
+                                           key, current_job_id.get())
+                return paginate_topic(result, request.page_size)
+            return job_store.submit(run, on_done)
+
+        try:
+            job = await idempotency_cache.run(key, submit)
+        except JobStoreFullError:
+            raise HTTPException(status_code=503, detail="Too many jobs are running, try again later")
+        # A repeated submission gets the job's current state
+        return job_store.get(job["job_id"]) or job
+
+    @app.get("/jobs/{job_id}", response_model=Job)
+    async def get_job(job_id: str):
+        job = job_store.get(job_id)
+        if job is None:
+            raise HTTPException(status_code=404, detail="Job not found")
+        return job
+
+    @app.post("/jobs/{job_id}/cancel", response_model=Job)
+    async def cancel_job(job_id: str):
+        job = job_store.cancel(job_id)
+        if job is None:
+            raise HTTPException(status_code=404, detail="Job not found")
+        return job
+
+    @app.get("/analyses", response_model=AnalysesResponse)
+    async def list_analyses(
+        topic: Optional[str] = None,
+        field: Optional[FieldEnum] = None,
+        since: Optional[datetime] = None,
+        until: Optional[datetime] = None,
+        limit: int = Query(50, ge=1, le=100)
+    ):
+        # Times without a zone are taken to be UTC, like the kept ones
+        since, until = (t.replace(tzinfo=timezone.utc) if t and t.tzinfo is None else t for t in (since, until))
+        return {"analyses": analysis_history.list(topic, field, since, until, limit)}
+
+    @app.get("/analyses/{analysis_id}", response_model=Analysis)
+    async def get_analysis(analysis_id: str):
+        analysis = analysis_history.get(analysis_id)
+        if analysis is None:
+            raise HTTPException(status_code=404, detail="Analysis not found")
+        return analysis
+
+    @app.delete("/analyses/{analysis_id}", status_code=204, response_class=Response)
+    async def delete_analysis(analysis_id: str):
+        origin = analysis_history.delete(analysis_id)
+        if origin is None:
+            raise HTTPException(status_code=404, detail="Analysis not found")
+        # The result is also held by its request's idempotency key and the
+        # job it ran in; pages of a paged topic result expire on their own
+        if origin["idempotency_key"]:
+            idempotency_cache.forget(origin["idempotency_key"])
+        if origin["job_id"]:
+            job_store.delete(origin["job_id"])
+        return Response(status_code=204)
+
+    @app.get("/fields", response_model=FieldsResponse)
+    async def list_fields():
+        return FieldsResponse(fields=list(FieldEnum))
+
+    @app.get("/models", response_model=ModelsResponse)
+    async def list_models():
+        return ModelsResponse(models=settings.available_models, default_model=settings.openai_model)
+
+    @app.get("/usage", response_model=UsageResponse)
+    async def get_usage(request: Request):
+        caller = usage.usage_tracker.caller_key(request.headers, request.client and request.client.host)
+        return usage.usage_tracker.report(caller)
+
     @app.get("/health", response_model=HealthResponse)
     async def health_check():
         return HealthResponse(status="healthy", version=settings.version, timestamp=str(time.time()))
@@ -2,7 +2,7 @@
 
 import os
 import yaml
-from typing import List, Optional
+from typing import Dict, List, Optional
 from pydantic import Field
 from pydantic_settings import BaseSettings
 from functools import lru_cache
@@ -22,9 +22,18 @@ class Settings(BaseSettings):
     # OpenAI settings
     openai_api_key: Optional[str] = Field(None, env="OPENAI_API_KEY")
     openai_model: str = "gpt-4"
+    openai_models: List[str] = []  # further models requests may choose
     openai_temperature: float = 0.7
     openai_max_tokens: int = 2000
     openai_timeout: int = 30
+    # Prices of models' tokens in US dollars per million, for cost estimates
+    model_pricing: Dict[str, Dict[str, float]] = {
+        "gpt-4": {"input": 30, "output": 60},
+        "gpt-4-turbo": {"input": 10, "output": 30},
+        "gpt-4o": {"input": 2.5, "output": 10},
+        "gpt-4o-mini": {"input": 0.15, "output": 0.6},
+        "gpt-3.5-turbo": {"input": 0.5, "output": 1.5},
+    }
     
     # PDF settings
     pdf_max_file_size: int = 10485760  # 10MB
@@ -38,9 +47,45 @@ class Settings(BaseSettings):
     # ArXiv settings
     arxiv_base_url: str = "http://export.arxiv.org/api/query"
     arxiv_max_results: int = 10
+    arxiv_pdf_url: str = "https://arxiv.org/pdf"
+
+    # PubMed settings
+    pubmed_base_url: str = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils"
+    ncbi_api_key: Optional[str] = Field(None, env="NCBI_API_KEY")
+
+    # CrossRef settings
+    crossref_base_url: str = "https://api.crossref.org/works"
+    crossref_mailto: Optional[str] = Field(None, env="CROSSREF_MAILTO")
+
+    # Funding settings: feeds of open funding calls, by agency; RSS, or the
+    # search API of the EU Funding & Tenders portal for URLs under /search-api/
+    funding_feeds: Dict[str, str] = {
+        "NSF": "https://www.nsf.gov/rss/rss_www_funding_pgm_annc_inf.xml",
+        "NIH": "https://grants.nih.gov/grants/guide/newsfeed/fundingopps.xml",
+        "EU": "https://api.tech.ec.europa.eu/search-api/prod/rest/search?apiKey=SEDIA&text=***&pageSize=100&pageNumber=1",
+    }
+
+    # Quota settings: requests each caller may make per window (in seconds);
+    # 0 means unlimited. Callers are told apart by the comma-separated API
+    # keys in quota_keys, or else by address.
+    quota_limit: int = 0
+    quota_window: int = 3600
+    quota_keys: Optional[str] = Field(None, env="QUOTA_KEYS")
+
+    # Webhook settings: the secret callbacks of finished jobs are signed
+    # with; jobs can't have callbacks without it
+    webhook_secret: Optional[str] = Field(None, env="WEBHOOK_SECRET")
+    # Whether callbacks may go to localhost and private addresses, which are
+    # refused so that callers can't reach the service's own network
+    webhook_allow_private: bool = Field(False, env="WEBHOOK_ALLOW_PRIVATE")
     
     model_config = {"env_file": ".env", "case_sensitive": False}
 
+    @property
+    def available_models(self) -> List[str]:
+        """Models requests may choose, the default first"""
+        return [self.openai_model] + [m for m in self.openai_models if m != self.openai_model]
+
 
 def load_config_from_yaml(config_path: str = "config.yaml") -> dict:
     """Load configuration from YAML file"""
@@ -64,6 +109,10 @@ def get_settings() -> Settings:
         pdf_config = yaml_config.get('pdf', {})
         embedding_config = yaml_config.get('embedding', {})
         arxiv_config = yaml_config.get('arxiv', {})
+        crossref_config = yaml_config.get('crossref', {})
+        pubmed_config = yaml_config.get('pubmed', {})
+        funding_config = yaml_config.get('funding', {})
+        quota_config = yaml_config.get('quota', {})
         logging_config = yaml_config.get('logging', {})
         
         # Map YAML keys to Settings attributes
@@ -74,9 +123,11 @@ def get_settings() -> Settings:
             'port': app_config.get('port'),
             'debug': app_config.get('debug'),
             'openai_model': llm_config.get('model'),
+            'openai_models': llm_config.get('models'),
             'openai_temperature': llm_config.get('temperature'),
             'openai_max_tokens': llm_config.get('max_tokens'),
             'openai_timeout': llm_config.get('timeout'),
+            'model_pricing': llm_config.get('pricing'),
             'pdf_max_file_size': pdf_config.get('max_file_size'),
             'pdf_allowed_extensions': pdf_config.get('allowed_extensions'),
             'embedding_model': embedding_config.get('model'),
@@ -84,6 +135,13 @@ def get_settings() -> Settings:
             'embedding_chunk_overlap': embedding_config.get('chunk_overlap'),
             'arxiv_base_url': arxiv_config.get('base_url'),
             'arxiv_max_results': arxiv_config.get('max_results'),
+            'arxiv_pdf_url': arxiv_config.get('pdf_url'),
+            'crossref_base_url': crossref_config.get('base_url'),
+            'crossref_mailto': crossref_config.get('mailto'),
+            'pubmed_base_url': pubmed_config.get('base_url'),
+            'funding_feeds': funding_config.get('feeds'),
+            'quota_limit': quota_config.get('limit'),
+            'quota_window': quota_config.get('window'),
             'log_level': logging_config.get('level'),
         })
         
@@ -8,7 +8,7 @@ Title: {title}
 Abstract: {abstract}
 Field: {field}
 {authors_info}
-
+{full_text_info}{language_info}{instructions_info}
 Please analyze this research and provide:
 
 1. KEY FINDINGS: List 3-5 main findings or contributions from this work.
@@ -58,12 +58,33 @@ Format your response as valid JSON with the following structure:
 Be specific, actionable, and avoid generic statements. Focus on gaps that could lead to meaningful research contributions.
 """
 
+FULL_TEXT_INFO = """
+Full text (methods, results and discussion):
+{sections}
+
+Base the analysis on the full text as well as the abstract. The methods,
+results and discussion reveal methodological gaps and limitations that the
+abstract leaves out.
+"""
+
+INSTRUCTIONS_INFO = """
+Additional instructions from the researcher:
+{instructions}
+"""
+
+LANGUAGE_INFO = """
+The input is written in the language with ISO 639-1 code "{language}". {output}
+"""
+
+ENGLISH_OUTPUT = "Write the analysis in English."
+
+TRANSLATED_OUTPUT = "Write the analysis in that language, but keep the JSON keys and gap types in English."
+
 TOPIC_ANALYSIS_PROMPT = """
-You are analyzing multiple research papers on the topic: {topic} in the field of {field}.
+You are analyzing multiple research papers on the topic: {topic} {field_info}.
 
 Here are the papers to analyze:
-{papers_info}
-
+{papers_info}{language_info}{surveys_info}
 Please provide:
 
 1. COMMON GAPS: Identify gaps that appear across multiple papers or are systematic in the field.
@@ -71,7 +92,7 @@ Please provide:
 2. INDIVIDUAL PAPER GAPS: For each paper, identify specific gaps.
 
 3. RESEARCH DIRECTIONS: Suggest overall research directions for this topic area.
-
+{intersection_info}
 Format your response as valid JSON:
 {{
   "common_gaps": [
@@ -95,10 +116,493 @@ Format your response as valid JSON:
       ]
     }}
   ],
-  "suggested_research_directions": ["direction1", "direction2", ...]
+  "suggested_research_directions": ["direction1", "direction2", ...]{intersection_format}
+}}
+"""
+
+SURVEYS_INFO = """
+Papers marked as surveys review other work. Use them as background only: base the common gaps on the primary studies, not on the surveys' observations about the state of the literature, such as calls for more research or for standard benchmarks.
+"""
+
+CROSS_FIELD_INFO = (
+    "across the fields of {fields}. The papers come from each of these fields, and the most valuable "
+    "gaps lie where the fields meet: questions that none of them addresses alone, methods of one field "
+    "not yet applied to the problems of another, and findings that have not crossed between them"
+)
+
+INTERSECTION_GAPS_INFO = """
+4. INTERSECTION GAPS: Identify gaps at the intersection of {fields} that an analysis of any one of these fields would miss.
+"""
+
+INTERSECTION_GAPS_FORMAT = """,
+  "intersection_gaps": [
+    {
+      "gap_description": "description",
+      "confidence_score": 0.7,
+      "gap_type": "conceptual",
+      "potential_impact": "impact across the fields"
+    }
+  ]"""
+
+COMPARISON_PROMPT = """
+You are a research assistant comparing two scientific papers in the field of {field}.
+
+Paper A:
+{paper_a}
+
+Paper B:
+{paper_b}
+
+Please compare the papers and provide:
+
+1. OVERLAPPING FINDINGS: Findings or contributions both papers support.
+
+2. CONFLICTING CONCLUSIONS: Points on which the papers disagree. For each, state the
+   topic and the position of each paper.
+
+3. GAPS UNIQUE TO PAPER A: Research gaps in Paper A that Paper B addresses or doesn't share.
+
+4. GAPS UNIQUE TO PAPER B: Research gaps in Paper B that Paper A addresses or doesn't share.
+
+Format your response as valid JSON:
+{{
+  "overlapping_findings": ["finding1", "finding2", ...],
+  "conflicting_conclusions": [
+    {{
+      "topic": "what the papers disagree on",
+      "paper_a_position": "conclusion of Paper A",
+      "paper_b_position": "conclusion of Paper B"
+    }}
+  ],
+  "gaps_unique_to_a": [
+    {{
+      "gap_description": "description",
+      "confidence_score": 0.8,
+      "gap_type": "methodological",
+      "potential_impact": "impact description"
+    }}
+  ],
+  "gaps_unique_to_b": [
+    {{
+      "gap_description": "description",
+      "confidence_score": 0.8,
+      "gap_type": "empirical",
+      "potential_impact": "impact description"
+    }}
+  ]
+}}
+
+Be specific, and only report conflicts the papers actually support.
+"""
+
+DEDUPLICATION_PROMPT = """
+You are a research assistant consolidating research gaps identified across several paper analyses.
+
+Each gap below is labelled [analysis.gap] with its analysis and gap number:
+{gaps}
+
+Please group gaps that describe the same underlying problem, even if they are worded
+differently. For each group, write one canonical gap that covers all of its members,
+and list the members by their analysis and gap numbers. A gap unlike any other forms
+a group of its own. Every gap belongs to exactly one group.
+
+Format your response as valid JSON:
+{{
+  "clusters": [
+    {{
+      "gap": {{
+        "gap_description": "canonical description",
+        "confidence_score": 0.8,
+        "gap_type": "methodological",
+        "potential_impact": "impact description"
+      }},
+      "members": [{{"analysis": 0, "gap": 1}}, {{"analysis": 2, "gap": 0}}]
+    }}
+  ]
+}}
+"""
+
+HYPOTHESIS_GENERATION_PROMPT = """
+You are a research assistant proposing research hypotheses in the field of {field}.
+
+The following research gaps have been identified:
+{gaps}
+
+Please generate 1-2 novel research hypotheses for each gap. For each hypothesis:
+   - The hypothesis statement
+   - Rationale for the hypothesis, naming the gap it addresses
+   - Feasibility score (0.0-1.0)
+   - Required research methods
+
+Format your response as valid JSON:
+{{
+  "hypotheses": [
+    {{
+      "hypothesis": "hypothesis statement",
+      "rationale": "rationale",
+      "feasibility_score": 0.7,
+      "required_methods": ["method1", "method2"]
+    }}
+  ]
+}}
+
+Make each hypothesis specific and testable.
+"""
+
+METHODS_PROMPT = """
+You are a research methodologist in the field of {field} planning how to test a research hypothesis.
+
+Hypothesis: {hypothesis}
+{rationale_info}{methods_info}
+Please expand the required methods into 2-3 concrete experimental designs that could test the
+hypothesis, the most suitable first. For each design, provide:
+   - A name, such as "randomized controlled trial"
+   - How the design tests the hypothesis, including its conditions and measures
+   - The methods it uses
+   - Sample-size guidance, such as the sample needed for adequate statistical power and the
+     assumptions behind it
+   - Its limitations
+
+Then name publicly available datasets that could serve as a starting point, with what they hold
+and their URL if you are certain of it.
+
+Format your response as valid JSON:
+{{
+  "designs": [
+    {{
+      "name": "design name",
+      "description": "how the design tests the hypothesis",
+      "methods": ["method1", "method2"],
+      "sample_size": "sample-size guidance",
+      "limitations": ["limitation1", "limitation2"]
+    }}
+  ],
+  "datasets": [
+    {{
+      "name": "dataset name",
+      "description": "what the dataset holds and why it suits the work",
+      "url": "https://..."
+    }}
+  ]
+}}
+
+Be specific enough that a researcher could start planning the study from your answer.
+"""
+
+REVIEW_PROMPT = """
+You are a research assistant drafting a literature review on the topic: {topic} in the field of {field}.
+
+Papers, numbered for citation:
+{papers_info}
+
+Gaps common to the papers:
+{common_gaps}
+
+Please write a draft literature review organized in sections:
+
+1. INTRODUCTION: Introduce the topic and the scope of the review.
+
+2. THEMES: One section per major theme of the papers, discussing what they found and how they relate.
+
+3. RESEARCH GAPS: Discuss the gaps the papers leave open.
+
+4. CONCLUSION: Summarize the state of the topic and directions for future work.
+
+Cite papers by their number in square brackets, such as [2], and list the numbers each section cites.
+
+Format your response as valid JSON:
+{{
+  "title": "review title",
+  "sections": [
+    {{
+      "kind": "theme",
+      "heading": "section heading",
+      "paragraphs": ["paragraph citing [1] and [3]", ...],
+      "citations": [1, 3]
+    }}
+  ]
+}}
+
+The kind of each section is introduction, theme, gaps or conclusion. Only cite the numbered papers.
+"""
+
+CITATION_PROMPT = """
+You are a research assistant helping a researcher write about a research gap in the field of {field}.
+
+Research gap: {gap}
+
+Candidate papers:
+{papers_info}
+
+Please choose up to {max_citations} of the candidate papers the researcher should cite when
+writing about this gap, most relevant first. For each, explain in one sentence how it relates
+to the gap: evidence of the gap, prior attempts to address it, or methods that could close it.
+
+Format your response as valid JSON:
+{{
+  "citations": [
+    {{
+      "paper": 2,
+      "reason": "how the paper relates to the gap"
+    }}
+  ]
+}}
+
+Only choose from the numbered candidates, and leave out papers unrelated to the gap.
+"""
+
+NOVELTY_PROMPT = """
+You are a research assistant checking whether a proposed research idea in the field of {field} is novel.
+
+Proposed idea: {idea}
+
+Related papers:
+{papers_info}
+
+Please judge whether these papers already address the idea. Answer with a status: "addressed"
+if the idea has already been carried out, "partially_addressed" if parts of it have, or "open"
+if it has not. Score its novelty between 0 (already addressed) and 1 (entirely new), summarize
+in two or three sentences what the papers cover and what remains open, and list the papers
+supporting your verdict, most relevant first, with one sentence on how each relates to the idea.
+
+Format your response as valid JSON:
+{{
+  "status": "partially_addressed",
+  "novelty_score": 0.5,
+  "summary": "what the papers cover and what remains open",
+  "papers": [
+    {{
+      "paper": 2,
+      "reason": "how the paper relates to the idea"
+    }}
+  ]
+}}
+
+Only list numbered papers, and leave out papers unrelated to the idea.
+"""
+
+PEER_REVIEW_PROMPT = """
+You are an experienced peer reviewer in the field of {field} reviewing a manuscript submitted for publication.
+
+Title: {title}
+{authors_info}Abstract: {abstract}
+{full_text_info}
+A prior analysis of the manuscript found:
+{analysis_info}{language_info}{instructions_info}
+Please review the manuscript as you would for a journal, building on the analysis:
+
+1. SUMMARY: Summarize the manuscript and its merits in two or three sentences.
+
+2. CRITIQUES: Critique the manuscript's soundness, novelty, baselines, clarity and significance.
+   For each critique, give the aspect, whether it is major (must be addressed before
+   acceptance) or minor, and a specific comment.
+
+3. MISSING BASELINES: List the methods or prior results the manuscript should compare against
+   but doesn't.
+
+4. RECOMMENDATION: Recommend accept, minor_revision, major_revision or reject.
+
+Format your response as valid JSON:
+{{
+  "summary": "summary of the manuscript and its merits",
+  "critiques": [
+    {{
+      "aspect": "soundness",
+      "severity": "major",
+      "comment": "specific comment"
+    }}
+  ],
+  "missing_baselines": ["baseline1", "baseline2", ...],
+  "recommendation": "major_revision"
+}}
+
+Be specific and constructive, as a reviewer helping the authors improve the manuscript.
+"""
+
+REVIEW_FULL_TEXT_INFO = """
+Full text (methods, results and discussion):
+{sections}
+"""
+
+ENGLISH_REVIEW_OUTPUT = "Write the review in English."
+
+TRANSLATED_REVIEW_OUTPUT = (
+    "Write the review in that language, but keep the JSON keys, aspects, severities and recommendation in English."
+)
+
+PROPOSAL_PROMPT = """
+You are an experienced grant reviewer in the field of {field} assessing a draft research proposal.
+
+Proposal:
+{proposal}
+{gaps_info}
+Related papers:
+{papers_info}
+
+Please assess how well the proposal addresses the {gaps_kind}.
+For each gap, score between 0 and 1 how fully the proposal addresses it and comment in one sentence.
+Score the proposal's overall alignment with the gaps between 0 and 1. Then list the proposal's
+claims of novelty that the related papers contradict, each with the conflicting papers and one
+sentence on how each conflicts. Finally, summarize your assessment in two or three sentences.
+
+Format your response as valid JSON:
+{{
+  "alignment_score": 0.7,
+  "gaps": [
+    {{
+      "gap_description": "the gap{gap_hint}",
+      "coverage": 0.8,
+      "comment": "how the proposal addresses the gap"
+    }}
+  ],
+  "novelty_conflicts": [
+    {{
+      "claim": "the proposal's claim of novelty",
+      "papers": [
+        {{
+          "paper": 2,
+          "reason": "how the paper contradicts the claim"
+        }}
+      ]
+    }}
+  ],
+  "summary": "overall assessment"
+}}
+
+Only cite numbered papers, and leave out claims no related paper contradicts.
+"""
+
+FUNDING_PROMPT = """
+You are a research assistant helping a researcher in the field of {field} find funding for open research gaps.
+
+Research gaps:
+{gaps}
+
+Open funding calls:
+{calls_info}
+
+Please choose up to {max_matches} of the funding calls that would fund work on the research gaps.
+For each, list the gaps it would fund, score its relevance between 0 and 1, and explain in one
+sentence why it fits.
+
+Format your response as valid JSON:
+{{
+  "matches": [
+    {{
+      "call": 3,
+      "gaps": [1, 2],
+      "relevance_score": 0.8,
+      "reason": "why the call fits the gaps"
+    }}
+  ]
 }}
+
+Only choose from the numbered calls and gaps, and leave out calls that fit none of the gaps.
 """
 
+DATASETS_PROMPT = """
+You are a research data librarian in the field of {field}.
+{subject_info}
+Please suggest up to {max_datasets} publicly available datasets suitable for this work, the most
+suitable first. For each dataset, provide its name, what it holds and why it suits the work, the
+URL it can be downloaded or requested from, and the license it is published under.
+
+Format your response as valid JSON:
+{{
+  "datasets": [
+    {{
+      "name": "dataset name",
+      "description": "what the dataset holds and why it suits the work",
+      "url": "https://...",
+      "license": "CC BY 4.0"
+    }}
+  ]
+}}
+
+Only suggest datasets you are certain exist, with their real URL. Leave the license empty if you
+don't know it.
+"""
+
+RELATED_WORK_PROMPT = """
+You are a research assistant helping a researcher verify that a research gap in the field of {field} is real.
+
+Research gap: {gap}
+
+Candidate papers:
+{papers_info}
+
+Please choose up to {max_papers} of the candidate papers closest to the research gap. For each,
+score its similarity to the gap between 0 and 1, tell whether it already addresses the gap,
+and explain in one sentence how it relates to the gap.
+
+Format your response as valid JSON:
+{{
+  "papers": [
+    {{
+      "paper": 2,
+      "similarity": 0.8,
+      "addresses_gap": false,
+      "reason": "how the paper relates to the gap"
+    }}
+  ]
+}}
+
+Only choose from the numbered candidates, and leave out papers unrelated to the gap.
+"""
+
+GROUPS_PROMPT = """
+You are a research assistant mapping who works on the open problems of the topic: {topic} in the field of {field}.
+
+Research gaps:
+{gaps}
+
+Papers:
+{papers_info}
+
+For each research gap, list the papers working near it: papers that address the gap, raise it,
+or develop methods or data that could close it.
+
+Format your response as valid JSON:
+{{
+  "gaps": [
+    {{
+      "gap": 1,
+      "papers": [2, 5]
+    }}
+  ]
+}}
+
+Only use the numbered gaps and papers, and leave out papers unrelated to a gap.
+"""
+
+TRENDS_PROMPT = """
+You are analyzing how the research gaps on the topic: {topic} in the field of {field} have evolved over the years.
+
+Here are the papers, numbered and oldest first:
+{papers_info}
+
+Please identify the research gaps these papers raise, as limitations of their own work or as open
+problems they point out. Merge gaps raised by several papers into one, and list the numbers of every
+paper raising it, so that it shows which gaps persist, which are being closed and which are new.
+
+Format your response as valid JSON:
+{{
+  "gaps": [
+    {{
+      "gap_description": "description",
+      "gap_type": "methodological",
+      "papers": [1, 4]
+    }}
+  ]
+}}
+
+The gap type is one of methodological, theoretical, empirical, technical, conceptual, data, population or systematic. Only use the numbered papers.
+"""
+
+PAPER_INFO = """Title: {title}
+{authors_info}
+Abstract: {abstract}"""
+
 HYPOTHESIS_REFINEMENT_PROMPT = """
 Given the research context and identified gaps, refine and expand the following hypotheses to make them more specific, testable, and impactful:
 
@@ -119,3 +623,38 @@ Please refine each hypothesis to:
 
 Return refined hypotheses in JSON format with the same structure as the input.
 """
+
+REFINE_PROMPT = """
+You are a research assistant refining an analysis of a scientific paper in the field of {field} with the researcher who asked for it.
+{paper_info}
+Your previous analysis:
+{previous}
+The researcher's feedback:
+{feedback}
+
+Update the analysis following the feedback. Drop the gaps and other items the researcher rejects, and when they ask for a deeper look at an item, expand it with more specific gaps, hypotheses or directions. Keep everything the feedback doesn't concern as it was, worded the same way.
+
+Format your response as valid JSON with the structure of the previous analysis:
+{{
+  "key_findings": ["finding1", "finding2", ...],
+  "gaps": [
+    {{
+      "gap_description": "description",
+      "confidence_score": 0.8,
+      "gap_type": "methodological",
+      "potential_impact": "impact description"
+    }}
+  ],
+  "limitations": ["limitation1", "limitation2", ...],
+  "methodology_gaps": ["gap1", "gap2", ...],
+  "suggested_hypotheses": [
+    {{
+      "hypothesis": "hypothesis statement",
+      "rationale": "rationale",
+      "feasibility_score": 0.7,
+      "required_methods": ["method1", "method2"]
+    }}
+  ],
+  "future_directions": ["direction1", "direction2", ...]
+}}
+"""
@@ -1,8 +1,10 @@
 """Pydantic models for API requests and responses"""
 
+import re
 from typing import List, Optional, Dict, Any
-from pydantic import BaseModel, Field, validator
+from pydantic import BaseModel, Field, root_validator, validator
 from enum import Enum
+from app.core.config import get_settings
 
 
 class FieldEnum(str, Enum):
@@ -17,6 +19,49 @@ class FieldEnum(str, Enum):
     GENERAL = "general"
 
 
+class AnalysisMode(str, Enum):
+    """What part of a paper is analyzed"""
+    ABSTRACT = "abstract"
+    FULL_TEXT = "full_text"
+
+
+class SurveyHandling(str, Enum):
+    """How a topic analysis treats survey and review papers"""
+    INCLUDE = "include"
+    EXCLUDE = "exclude"
+    DOWNWEIGHT = "downweight"
+
+
+MAX_TEMPERATURE = 2
+MAX_RESULTS_LIMIT = 50
+MAX_INSTRUCTIONS_LENGTH = 1000
+
+
+def _check_model(model: Optional[str]) -> Optional[str]:
+    """Reject a model the service doesn't offer"""
+    available = get_settings().available_models
+    if model and model not in available:
+        raise ValueError(f"Unknown model {model!r}, want one of {', '.join(available)}")
+    return model
+
+
+_LANGUAGE = re.compile(r"^[a-z]{2}$")
+
+
+def _check_language(language: Optional[str]) -> Optional[str]:
+    """Reject a language that isn't an ISO 639-1 code"""
+    if language and not _LANGUAGE.match(language):
+        raise ValueError('Language must be an ISO 639-1 code such as de or zh')
+    return language
+
+
+def _check_translation(values: Dict[str, Any]) -> Dict[str, Any]:
+    """Reject translate_output without a language to translate to"""
+    if values.get('translate_output') and not values.get('language'):
+        raise ValueError('translate_output requires language')
+    return values
+
+
 class AnalyzeRequest(BaseModel):
     """Request model for abstract/text analysis"""
     title: str = Field(..., description="Title of the research paper")
@@ -27,6 +72,37 @@ class AnalyzeRequest(BaseModel):
     )
     authors: Optional[List[str]] = Field(None, description="List of authors")
     keywords: Optional[List[str]] = Field(None, description="Keywords related to the research")
+    full_text: Optional[str] = Field(None, description="Full text of the paper, analyzed in full_text mode")
+    mode: AnalysisMode = Field(
+        AnalysisMode.ABSTRACT,
+        description="full_text also analyzes the methods, results and discussion in full_text"
+    )
+    model: Optional[str] = Field(None, description="Model to analyze with, one of those listed by /models")
+    temperature: Optional[float] = Field(
+        None,
+        description="Sampling temperature; defaults to the service's",
+        ge=0,
+        le=MAX_TEMPERATURE
+    )
+    language: Optional[str] = Field(
+        None,
+        description="ISO 639-1 code of the language the input is written in, such as de or zh; defaults to English"
+    )
+    translate_output: bool = Field(False, description="Write the analysis in language instead of English")
+    min_confidence: Optional[float] = Field(
+        None,
+        description="Leave out gaps with a lower confidence_score",
+        ge=0,
+        le=1
+    )
+    max_gaps: Optional[int] = Field(None, description="Return at most this many gaps", ge=1, le=MAX_RESULTS_LIMIT)
+    max_hypotheses: Optional[int] = Field(None, description="Return at most this many suggested hypotheses", ge=1, le=MAX_RESULTS_LIMIT)
+    max_findings: Optional[int] = Field(None, description="Return at most this many key findings", ge=1, le=MAX_RESULTS_LIMIT)
+    instructions: Optional[str] = Field(
+        None,
+        description="Additional instructions steering the analysis, such as 'focus on reproducibility gaps'",
+        max_length=MAX_INSTRUCTIONS_LENGTH
+    )
     
     @validator('abstract')
     def abstract_must_not_be_empty(cls, v):
@@ -40,6 +116,28 @@ class AnalyzeRequest(BaseModel):
             raise ValueError('Title cannot be empty')
         return v
 
+    @validator('model')
+    def model_must_be_available(cls, v):
+        return _check_model(v)
+
+    @validator('language')
+    def language_must_be_iso_code(cls, v):
+        return _check_language(v)
+
+    @root_validator(skip_on_failure=True)
+    def translation_requires_language(cls, values):
+        return _check_translation(values)
+
+    @root_validator(skip_on_failure=True)
+    def full_text_required_in_full_text_mode(cls, values):
+        if values.get('mode') == AnalysisMode.FULL_TEXT and not (values.get('full_text') or '').strip():
+            raise ValueError('full_text is required in full_text mode')
+        return values
+
+
+MAX_PAGE_SIZE = 100
+MAX_CROSS_FIELDS = 4
+
 
 class TopicRequest(BaseModel):
     """Request model for topic-based gap analysis"""
@@ -54,6 +152,41 @@ class TopicRequest(BaseModel):
         ge=1,
         le=50
     )
+    page_size: Optional[int] = Field(
+        None,
+        description="Return individual results in pages of this size",
+        ge=1,
+        le=MAX_PAGE_SIZE
+    )
+    model: Optional[str] = Field(None, description="Model to analyze with, one of those listed by /models")
+    temperature: Optional[float] = Field(
+        None,
+        description="Sampling temperature; defaults to the service's",
+        ge=0,
+        le=MAX_TEMPERATURE
+    )
+    language: Optional[str] = Field(
+        None,
+        description="ISO 639-1 code of the language the input is written in, such as de or zh; defaults to English"
+    )
+    translate_output: bool = Field(False, description="Write the analysis in language instead of English")
+    min_confidence: Optional[float] = Field(
+        None,
+        description="Leave out gaps with a lower confidence_score",
+        ge=0,
+        le=1
+    )
+    max_gaps: Optional[int] = Field(None, description="Return at most this many common gaps, and gaps per paper", ge=1, le=MAX_RESULTS_LIMIT)
+    cross_fields: Optional[List[FieldEnum]] = Field(
+        None,
+        description="Analyze the topic across these distinct fields instead of field, reporting the gaps at their intersection",
+        min_items=2,
+        max_items=MAX_CROSS_FIELDS
+    )
+    surveys: SurveyHandling = Field(
+        SurveyHandling.INCLUDE,
+        description="exclude leaves survey and review papers out; downweight keeps them as background to the primary studies"
+    )
     
     @validator('topic')
     def topic_must_not_be_empty(cls, v):
@@ -61,6 +194,38 @@ class TopicRequest(BaseModel):
             raise ValueError('Topic cannot be empty')
         return v
 
+    @validator('cross_fields')
+    def cross_fields_must_be_distinct(cls, v):
+        if v is not None and len(set(v)) != len(v):
+            raise ValueError('Cross fields must be distinct')
+        return v
+
+    @validator('model')
+    def model_must_be_available(cls, v):
+        return _check_model(v)
+
+    @validator('language')
+    def language_must_be_iso_code(cls, v):
+        return _check_language(v)
+
+    @root_validator(skip_on_failure=True)
+    def translation_requires_language(cls, values):
+        return _check_translation(values)
+
+
+class Citation(BaseModel):
+    """Paper worth citing when writing about a research gap"""
+    title: str = Field(..., description="Title of the paper")
+    authors: List[str] = Field(..., description="Authors of the paper")
+    url: Optional[str] = Field(None, description="URL to the paper")
+    reason: str = Field(..., description="How the paper relates to the gap")
+
+
+class AuthorGroup(BaseModel):
+    """Authors linked by co-authorship, and their papers near a research gap"""
+    authors: List[str] = Field(..., description="Authors linked by co-authorship")
+    papers: List[str] = Field(..., description="Titles of the group's papers near the gap")
+
 
 class ResearchGap(BaseModel):
     """Individual research gap model"""
@@ -68,6 +233,14 @@ class ResearchGap(BaseModel):
     confidence_score: float = Field(..., description="Confidence score (0-1)", ge=0, le=1)
     gap_type: str = Field(..., description="Type of gap (methodological, theoretical, empirical, etc.)")
     potential_impact: str = Field(..., description="Potential impact of addressing this gap")
+    supporting_citations: Optional[List[Citation]] = Field(
+        None,
+        description="Papers to cite about this gap, as suggested by /gaps/citations"
+    )
+    research_groups: Optional[List[AuthorGroup]] = Field(
+        None,
+        description="Groups of co-authors working near this gap, as found by /gaps/groups"
+    )
 
 
 class Hypothesis(BaseModel):
@@ -78,6 +251,17 @@ class Hypothesis(BaseModel):
     required_methods: Optional[List[str]] = Field(None, description="Suggested research methods")
 
 
+class PaperInfo(BaseModel):
+    """Metadata of a paper looked up by identifier"""
+    source: str = Field(..., description="Where the metadata came from, e.g. crossref")
+    id: str = Field(..., description="Identifier the paper was looked up by")
+    title: str = Field(..., description="Title of the paper")
+    authors: List[str] = Field(..., description="List of authors")
+    abstract: str = Field(..., description="Abstract that was analyzed")
+    url: Optional[str] = Field(None, description="Link to the paper")
+    year: Optional[int] = Field(None, description="Publication year, if the source says")
+
+
 class AnalyzeResponse(BaseModel):
     """Response model for analysis results"""
     key_findings: List[str] = Field(..., description="Key findings from the research")
@@ -87,6 +271,463 @@ class AnalyzeResponse(BaseModel):
     methodology_gaps: List[str] = Field(..., description="Gaps in methodology")
     future_directions: List[str] = Field(..., description="Suggested future research directions")
     processing_time: float = Field(..., description="Processing time in seconds")
+    paper: Optional[PaperInfo] = Field(None, description="Paper metadata, for analyses by identifier")
+
+
+MAX_FEEDBACK_LENGTH = 2000
+
+
+class RefineRequest(BaseModel):
+    """Request model for refining a previous analysis with the researcher's feedback"""
+    previous: AnalyzeResponse = Field(..., description="Analysis to refine")
+    feedback: str = Field(
+        ...,
+        description="What to change, such as 'gap 2 is out of scope' or 'expand on the sampling gap'",
+        max_length=MAX_FEEDBACK_LENGTH
+    )
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific refinement"
+    )
+
+    @validator('feedback')
+    def feedback_must_not_be_empty(cls, v):
+        if not v.strip():
+            raise ValueError('Feedback cannot be empty')
+        return v
+
+    @validator('previous')
+    def previous_must_not_be_empty(cls, v):
+        if not (v.key_findings or v.gaps or v.suggested_hypotheses or v.limitations
+                or v.methodology_gaps or v.future_directions):
+            raise ValueError('previous must hold the analysis to refine')
+        return v
+
+
+_DOI_PREFIX = re.compile(r"^(?:https?://(?:dx\.)?doi\.org/|doi:)", re.IGNORECASE)
+_DOI = re.compile(r"^10\.\d{4,9}/\S+$")
+
+
+class DOIRequest(BaseModel):
+    """Request model for analysis of a paper by DOI"""
+    doi: str = Field(..., description="DOI of the paper, optionally as a doi.org URL")
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific analysis"
+    )
+
+    @validator('doi')
+    def doi_must_be_valid(cls, v):
+        doi = _DOI_PREFIX.sub('', v.strip())
+        if not _DOI.match(doi):
+            raise ValueError('DOI must look like 10.1234/abc')
+        return doi
+
+
+_PMID_PREFIX = re.compile(r"^(?:https?://pubmed\.ncbi\.nlm\.nih\.gov/|pmid:\s*)", re.IGNORECASE)
+_PMID = re.compile(r"^\d{1,9}$")
+
+
+class PMIDRequest(BaseModel):
+    """Request model for analysis of a paper by PubMed ID"""
+    pmid: str = Field(..., description="PubMed ID of the paper, optionally as a pubmed.ncbi.nlm.nih.gov URL")
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific analysis"
+    )
+
+    @validator('pmid')
+    def pmid_must_be_valid(cls, v):
+        pmid = _PMID_PREFIX.sub('', v.strip()).rstrip('/')
+        if not _PMID.match(pmid):
+            raise ValueError('PubMed ID must be a number such as 31452104')
+        return pmid
+
+
+_ARXIV_PREFIX = re.compile(r"^(?:https?://(?:www\.)?arxiv\.org/(?:abs|pdf)/|arxiv:)", re.IGNORECASE)
+_ARXIV_ID = re.compile(r"^(?:\d{4}\.\d{4,5}|[a-z][a-z.-]*/\d{7})(?:v\d+)?$")
+
+
+class ArxivRequest(BaseModel):
+    """Request model for analysis of a paper by arXiv ID"""
+    arxiv_id: str = Field(..., description="arXiv ID of the paper, optionally as an arxiv.org URL")
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific analysis"
+    )
+    full_text: bool = Field(
+        False,
+        description="Download the paper's PDF and analyze its full text, not just the abstract"
+    )
+
+    @validator('arxiv_id')
+    def arxiv_id_must_be_valid(cls, v):
+        arxiv_id = _ARXIV_PREFIX.sub('', v.strip())
+        if arxiv_id.endswith('.pdf'):
+            arxiv_id = arxiv_id[:-len('.pdf')]
+        if not _ARXIV_ID.match(arxiv_id):
+            raise ValueError('arXiv ID must look like 2101.00001 or hep-th/9901001')
+        return arxiv_id
+
+
+class CompareRequest(BaseModel):
+    """Request model for comparison of two papers"""
+    paper_a: AnalyzeRequest = Field(..., description="First paper")
+    paper_b: AnalyzeRequest = Field(..., description="Second paper")
+
+
+class ConclusionConflict(BaseModel):
+    """A point two papers reach different conclusions on"""
+    topic: str = Field(..., description="What the papers disagree on")
+    paper_a_position: str = Field(..., description="Conclusion of the first paper")
+    paper_b_position: str = Field(..., description="Conclusion of the second paper")
+
+
+class ComparisonResponse(BaseModel):
+    """Response model for comparison of two papers"""
+    overlapping_findings: List[str] = Field(..., description="Findings both papers support")
+    conflicting_conclusions: List[ConclusionConflict] = Field(..., description="Points the papers disagree on")
+    gaps_unique_to_a: List[ResearchGap] = Field(..., description="Gaps of the first paper only")
+    gaps_unique_to_b: List[ResearchGap] = Field(..., description="Gaps of the second paper only")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+class ReviewAspect(str, Enum):
+    """Aspect of a paper a simulated reviewer critiques"""
+    SOUNDNESS = "soundness"
+    NOVELTY = "novelty"
+    BASELINES = "baselines"
+    CLARITY = "clarity"
+    SIGNIFICANCE = "significance"
+
+
+class CritiqueSeverity(str, Enum):
+    """Whether a critique must be addressed before acceptance"""
+    MINOR = "minor"
+    MAJOR = "major"
+
+
+class ReviewRecommendation(str, Enum):
+    """Decision a simulated reviewer recommends"""
+    ACCEPT = "accept"
+    MINOR_REVISION = "minor_revision"
+    MAJOR_REVISION = "major_revision"
+    REJECT = "reject"
+
+
+class Critique(BaseModel):
+    """A reviewer's comment on one aspect of a paper"""
+    aspect: ReviewAspect = Field(..., description="Aspect of the paper critiqued")
+    severity: CritiqueSeverity = Field(..., description="Whether the critique must be addressed before acceptance")
+    comment: str = Field(..., description="The critique")
+
+
+class PeerReviewResponse(BaseModel):
+    """Response model for a simulated peer review"""
+    recommendation: ReviewRecommendation = Field(..., description="Recommended decision")
+    summary: str = Field(..., description="The reviewer's summary of the paper and its merits")
+    critiques: List[Critique] = Field(..., description="Critiques, major ones first")
+    missing_baselines: List[str] = Field(..., description="Methods or prior results the paper should compare against")
+    gaps: List[ResearchGap] = Field(..., description="The gaps the analysis found, which the critiques build on")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_DEDUPLICATE_ANALYSES = 100
+
+
+class DeduplicateRequest(BaseModel):
+    """Request model for clustering the gaps of several analyses"""
+    analyses: List[List[ResearchGap]] = Field(
+        ...,
+        description="Gaps of each analysis",
+        min_length=1,
+        max_length=MAX_DEDUPLICATE_ANALYSES
+    )
+
+    @validator('analyses')
+    def analyses_must_hold_gaps(cls, v):
+        if not any(v):
+            raise ValueError('must hold at least one gap')
+        return v
+
+
+class GapRef(BaseModel):
+    """Reference to a gap of a DeduplicateRequest"""
+    analysis: int = Field(..., description="Position of the analysis in the request")
+    gap: int = Field(..., description="Position of the gap in its analysis")
+
+
+class GapCluster(BaseModel):
+    """Gaps describing the same problem, with a canonical gap covering them"""
+    gap: ResearchGap = Field(..., description="Canonical gap of the cluster")
+    members: List[GapRef] = Field(..., description="Gaps in the cluster")
+
+
+class DeduplicateResponse(BaseModel):
+    """Response model for gap deduplication"""
+    clusters: List[GapCluster] = Field(..., description="Clusters covering every gap of the request once")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_HYPOTHESIS_GAPS = 50
+
+
+class HypothesesRequest(BaseModel):
+    """Request model for generating hypotheses from research gaps"""
+    gaps: List[ResearchGap] = Field(
+        ...,
+        description="Gaps to generate hypotheses for",
+        min_length=1,
+        max_length=MAX_HYPOTHESIS_GAPS
+    )
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific hypotheses"
+    )
+
+    @validator('gaps')
+    def gaps_must_be_described(cls, v):
+        if any(not gap.gap_description.strip() for gap in v):
+            raise ValueError('gap_description must not be empty')
+        return v
+
+
+class HypothesesResponse(BaseModel):
+    """Response model for hypothesis generation"""
+    hypotheses: List[Hypothesis] = Field(..., description="Generated hypotheses")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+class MethodsRequest(BaseModel):
+    """Request model for recommending methods to test a hypothesis with"""
+    hypothesis: Hypothesis = Field(..., description="Hypothesis to test")
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific methods"
+    )
+
+    @validator('hypothesis')
+    def hypothesis_must_not_be_empty(cls, v):
+        if not v.hypothesis.strip():
+            raise ValueError('hypothesis must not be empty')
+        return v
+
+
+class ExperimentalDesign(BaseModel):
+    """A concrete way to test a hypothesis"""
+    name: str = Field(..., description="Name of the design, such as randomized controlled trial")
+    description: str = Field(..., description="How the design tests the hypothesis")
+    methods: List[str] = Field(..., description="The methods the design uses")
+    sample_size: str = Field(..., description="Sample-size guidance, such as the sample needed for adequate power")
+    limitations: List[str] = Field(..., description="Limitations of the design")
+
+
+class Dataset(BaseModel):
+    """A dataset to start work on a gap or hypothesis from"""
+    name: str = Field(..., description="Name of the dataset")
+    description: str = Field(..., description="What the dataset holds and why it suits the work")
+    url: Optional[str] = Field(None, description="Link to the dataset")
+    license: Optional[str] = Field(None, description="License the dataset is published under, such as CC BY 4.0")
+
+
+class MethodsResponse(BaseModel):
+    """Response model for methodology recommendation"""
+    designs: List[ExperimentalDesign] = Field(..., description="Experimental designs, the most suitable first")
+    datasets: List[Dataset] = Field(..., description="Candidate datasets to start from")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_CITATIONS = 10
+
+
+class CitationsRequest(BaseModel):
+    """Request model for citation suggestions for a research gap"""
+    gap: ResearchGap = Field(..., description="Gap to suggest citations for")
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific suggestions"
+    )
+    max_citations: Optional[int] = Field(
+        5,
+        description="Maximum number of citations to suggest",
+        ge=1,
+        le=MAX_CITATIONS
+    )
+
+    @validator('gap')
+    def gap_must_be_described(cls, v):
+        if not v.gap_description.strip():
+            raise ValueError('gap_description must not be empty')
+        return v
+
+
+class CitationsResponse(BaseModel):
+    """Response model for citation suggestions"""
+    citations: List[Citation] = Field(..., description="Suggested citations, most relevant first")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_DATASETS = 10
+
+
+class DatasetsRequest(BaseModel):
+    """Request model for suggesting datasets for a gap or a hypothesis"""
+    gap: Optional[ResearchGap] = Field(None, description="Gap to investigate")
+    hypothesis: Optional[Hypothesis] = Field(None, description="Hypothesis to test")
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific suggestions"
+    )
+    max_datasets: Optional[int] = Field(
+        5,
+        description="Maximum number of datasets to suggest",
+        ge=1,
+        le=MAX_DATASETS
+    )
+
+    @validator('gap')
+    def gap_must_be_described(cls, v):
+        if v is not None and not v.gap_description.strip():
+            raise ValueError('gap_description must not be empty')
+        return v
+
+    @validator('hypothesis')
+    def hypothesis_must_not_be_empty(cls, v):
+        if v is not None and not v.hypothesis.strip():
+            raise ValueError('hypothesis must not be empty')
+        return v
+
+    @root_validator(skip_on_failure=True)
+    def exactly_one_subject(cls, values):
+        if (values.get('gap') is None) == (values.get('hypothesis') is None):
+            raise ValueError('exactly one of gap and hypothesis must be set')
+        return values
+
+
+class DatasetsResponse(BaseModel):
+    """Response model for dataset suggestions"""
+    datasets: List[Dataset] = Field(..., description="Suggested datasets, the most suitable first; each has a URL")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_RELATED_PAPERS = 20
+
+
+class RelatedWorkRequest(BaseModel):
+    """Request model for the existing papers closest to a research gap"""
+    gap: ResearchGap = Field(..., description="Gap to find related work for")
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific retrieval"
+    )
+    max_papers: Optional[int] = Field(
+        5,
+        description="Maximum number of papers to return",
+        ge=1,
+        le=MAX_RELATED_PAPERS
+    )
+
+    @validator('gap')
+    def gap_must_be_described(cls, v):
+        if not v.gap_description.strip():
+            raise ValueError('gap_description must not be empty')
+        return v
+
+
+class RelatedPaper(BaseModel):
+    """Existing paper close to a research gap"""
+    title: str = Field(..., description="Title of the paper")
+    authors: List[str] = Field(..., description="Authors of the paper")
+    url: Optional[str] = Field(None, description="URL to the paper")
+    year: Optional[int] = Field(None, description="Publication year, if the source says")
+    similarity: float = Field(..., description="How close the paper is to the gap", ge=0.0, le=1.0)
+    addresses_gap: bool = Field(..., description="Whether the paper already addresses the gap")
+    reason: str = Field(..., description="How the paper relates to the gap")
+
+
+class RelatedWorkResponse(BaseModel):
+    """Response model for related work"""
+    papers: List[RelatedPaper] = Field(..., description="Related papers, most similar first")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_FUNDING_GAPS = 20
+MAX_MATCHES = 20
+
+
+class FundingRequest(BaseModel):
+    """Request model for matching research gaps with open funding calls"""
+    gaps: List[ResearchGap] = Field(
+        ...,
+        description="Gaps to find funding for",
+        min_length=1,
+        max_length=MAX_FUNDING_GAPS
+    )
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific matching"
+    )
+    max_matches: Optional[int] = Field(
+        10,
+        description="Maximum number of funding calls to return",
+        ge=1,
+        le=MAX_MATCHES
+    )
+
+    @validator('gaps')
+    def gaps_must_be_described(cls, v):
+        if any(not gap.gap_description.strip() for gap in v):
+            raise ValueError('gap_description must not be empty')
+        return v
+
+
+class FundingCall(BaseModel):
+    """Open call for grant proposals"""
+    agency: str = Field(..., description="Agency of the feed the call came from, such as NSF")
+    title: str = Field(..., description="Title of the call")
+    url: str = Field(..., description="URL to the call")
+    summary: Optional[str] = Field(None, description="Summary of the call")
+
+
+class FundingMatch(BaseModel):
+    """Funding call that fits some of the requested gaps"""
+    call: FundingCall = Field(..., description="The matching call")
+    gaps: List[int] = Field(..., description="Indexes, from 0, of the requested gaps the call would fund")
+    relevance_score: float = Field(..., description="Relevance of the call", ge=0.0, le=1.0)
+    reason: str = Field(..., description="Why the call fits the gaps")
+
+
+class FundingResponse(BaseModel):
+    """Response model for funding matches"""
+    matches: List[FundingMatch] = Field(..., description="Matching calls, most relevant first")
+    calls_searched: int = Field(..., description="Number of open calls read from the feeds")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_BATCH_SIZE = 100
+
+
+class BatchAnalyzeRequest(BaseModel):
+    """Request model for analyzing several abstracts in one call"""
+    requests: List[AnalyzeRequest] = Field(
+        ...,
+        description="Abstracts to analyze",
+        min_length=1,
+        max_length=MAX_BATCH_SIZE
+    )
+
+
+class BatchItemResult(BaseModel):
+    """Result of one abstract in a batch; exactly one of result and error is set"""
+    index: int = Field(..., description="Position of the abstract in the request")
+    result: Optional[AnalyzeResponse] = Field(None, description="Analysis result")
+    error: Optional[str] = Field(None, description="Why the analysis failed")
+
+
+class BatchAnalyzeResponse(BaseModel):
+    """Response model for batch analysis"""
+    results: List[BatchItemResult] = Field(..., description="One result per abstract, in request order")
+    processing_time: float = Field(..., description="Processing time in seconds")
 
 
 class TopicAnalysisResult(BaseModel):
@@ -96,6 +737,7 @@ class TopicAnalysisResult(BaseModel):
     abstract: Optional[str] = Field(None, description="Abstract of the paper")
     gaps: List[ResearchGap] = Field(..., description="Identified gaps in this paper")
     url: Optional[str] = Field(None, description="URL to the paper")
+    year: Optional[int] = Field(None, description="Publication year, if the source says")
 
 
 class TopicResponse(BaseModel):
@@ -106,6 +748,380 @@ class TopicResponse(BaseModel):
     individual_results: List[TopicAnalysisResult] = Field(..., description="Results for individual papers")
     suggested_research_directions: List[str] = Field(..., description="Overall research directions")
     processing_time: float = Field(..., description="Processing time in seconds")
+    next_cursor: Optional[str] = Field(None, description="Cursor of the next page of individual results")
+    intersection_gaps: Optional[List[ResearchGap]] = Field(
+        None,
+        description="Gaps at the intersection of the request's cross fields, if set"
+    )
+
+
+class ReviewRequest(BaseModel):
+    """Request model for drafting a literature review of a topic's papers"""
+    topic: str = Field(..., description="Topic of the review")
+    papers: List[TopicAnalysisResult] = Field(
+        ...,
+        description="Papers to review, cited by their position starting at 1",
+        min_length=1,
+        max_length=50
+    )
+    common_gaps: List[ResearchGap] = Field([], description="Gaps common to the papers")
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific review"
+    )
+
+    @validator('topic')
+    def topic_must_not_be_empty(cls, v):
+        if not v.strip():
+            raise ValueError('Topic cannot be empty')
+        return v
+
+
+class ReviewSectionKind(str, Enum):
+    """What a section of a literature review covers"""
+    INTRODUCTION = "introduction"
+    THEME = "theme"
+    GAPS = "gaps"
+    CONCLUSION = "conclusion"
+
+
+class ReviewSection(BaseModel):
+    """Section of a literature review draft"""
+    kind: ReviewSectionKind = Field(..., description="What the section covers")
+    heading: str = Field(..., description="Heading of the section")
+    paragraphs: List[str] = Field(..., description="Paragraphs, citing papers as [n]")
+    citations: List[int] = Field(..., description="Numbers of the papers the section cites")
+
+
+class ReviewReference(BaseModel):
+    """Paper cited by a literature review draft"""
+    number: int = Field(..., description="Citation number, the paper's position in the request starting at 1")
+    title: str = Field(..., description="Title of the paper")
+    authors: List[str] = Field(..., description="Authors of the paper")
+    url: Optional[str] = Field(None, description="URL to the paper")
+
+
+class ReviewResponse(BaseModel):
+    """Response model for a literature review draft"""
+    title: str = Field(..., description="Title of the review")
+    sections: List[ReviewSection] = Field(..., description="Sections in reading order")
+    references: List[ReviewReference] = Field(..., description="Cited papers in citation number order")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_GROUP_GAPS = 50
+
+
+class ResearchGroupsRequest(BaseModel):
+    """Request model for finding the research groups working near a topic's gaps"""
+    topic: str = Field(..., description="Research topic")
+    papers: List[TopicAnalysisResult] = Field(
+        ...,
+        description="Papers whose authors are grouped",
+        min_length=1,
+        max_length=50
+    )
+    common_gaps: List[ResearchGap] = Field(
+        ...,
+        description="Gaps to find research groups for",
+        min_length=1,
+        max_length=MAX_GROUP_GAPS
+    )
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific analysis"
+    )
+
+    @validator('topic')
+    def topic_must_not_be_empty(cls, v):
+        if not v.strip():
+            raise ValueError('Topic cannot be empty')
+        return v
+
+    @validator('common_gaps')
+    def gaps_must_be_described(cls, v):
+        if any(not gap.gap_description.strip() for gap in v):
+            raise ValueError('gap_description must not be empty')
+        return v
+
+
+class ResearchGroupsResponse(BaseModel):
+    """Response model for the research groups working near a topic's gaps"""
+    common_gaps: List[ResearchGap] = Field(
+        ...,
+        description="The requested gaps in order, with their research_groups, largest first"
+    )
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_IDEA_LENGTH = 2000
+
+
+class NoveltyRequest(BaseModel):
+    """Request model for checking whether a research idea is novel"""
+    idea: str = Field(
+        ...,
+        description="Description of the proposed research idea",
+        max_length=MAX_IDEA_LENGTH
+    )
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific checking"
+    )
+    max_papers: Optional[int] = Field(
+        10,
+        description="Number of related papers to search for",
+        ge=1,
+        le=50
+    )
+
+    @validator('idea')
+    def idea_must_not_be_empty(cls, v):
+        if not v.strip():
+            raise ValueError('Idea cannot be empty')
+        return v
+
+
+class NoveltyStatus(str, Enum):
+    """Whether the literature already addresses an idea"""
+    ADDRESSED = "addressed"
+    PARTIALLY_ADDRESSED = "partially_addressed"
+    OPEN = "open"
+
+
+class NoveltyResponse(BaseModel):
+    """Response model for a novelty check"""
+    status: NoveltyStatus = Field(..., description="Whether the idea is already addressed")
+    novelty_score: float = Field(
+        ...,
+        description="From 0, already addressed, to 1, entirely new",
+        ge=0.0,
+        le=1.0
+    )
+    summary: str = Field(..., description="What the literature already covers and what remains open")
+    supporting_papers: List[Citation] = Field(
+        ...,
+        description="Papers supporting the verdict, most relevant first"
+    )
+    papers_searched: int = Field(..., description="Number of related papers found")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_PROPOSAL_LENGTH = 20000
+MAX_PROPOSAL_GAPS = 50
+
+
+class ProposalRequest(BaseModel):
+    """Request model for checking how well a grant proposal addresses known gaps"""
+    proposal: str = Field(
+        ...,
+        description="Text of the draft proposal",
+        max_length=MAX_PROPOSAL_LENGTH
+    )
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific analysis"
+    )
+    topic: Optional[str] = Field(
+        None,
+        description="Keywords to search existing work with; defaults to the proposal's first line"
+    )
+    gaps: Optional[List[ResearchGap]] = Field(
+        None,
+        description="Known gaps to score the proposal against; defaults to the gaps raised by the related papers",
+        max_length=MAX_PROPOSAL_GAPS
+    )
+    max_papers: Optional[int] = Field(
+        10,
+        description="Number of related papers to search for",
+        ge=1,
+        le=50
+    )
+
+    @validator('proposal')
+    def proposal_must_not_be_empty(cls, v):
+        if not v.strip():
+            raise ValueError('Proposal cannot be empty')
+        return v
+
+    @validator('gaps')
+    def gaps_must_be_described(cls, v):
+        if v and any(not gap.gap_description.strip() for gap in v):
+            raise ValueError('gap_description must not be empty')
+        return v
+
+
+class GapAlignment(BaseModel):
+    """How fully a proposal addresses a known gap"""
+    gap_description: str = Field(..., description="Description of the gap")
+    coverage: float = Field(..., description="How fully the proposal addresses the gap", ge=0.0, le=1.0)
+    comment: str = Field(..., description="How the proposal addresses the gap")
+
+
+class NoveltyConflict(BaseModel):
+    """Claim of novelty of a proposal that existing papers contradict"""
+    claim: str = Field(..., description="Claim of novelty made by the proposal")
+    papers: List[Citation] = Field(..., description="Existing papers the claim conflicts with, each with how")
+
+
+class ProposalResponse(BaseModel):
+    """Response model for proposal alignment"""
+    alignment_score: float = Field(
+        ...,
+        description="How well the proposal addresses the known gaps",
+        ge=0.0,
+        le=1.0
+    )
+    gaps: List[GapAlignment] = Field(..., description="The known gaps, with how fully the proposal addresses each")
+    novelty_conflicts: List[NoveltyConflict] = Field(..., description="Claims of novelty existing work contradicts")
+    summary: str = Field(..., description="Overall assessment")
+    papers_searched: int = Field(..., description="Number of related papers found")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+MAX_YEARS_BACK = 20
+
+
+class TrendsRequest(BaseModel):
+    """Request model for following a topic's gaps over the publication years"""
+    topic: str = Field(..., description="Research topic or keywords")
+    years_back: Optional[int] = Field(
+        5,
+        description="Number of publication years to cover, counting the current one",
+        ge=1,
+        le=MAX_YEARS_BACK
+    )
+    max_papers: Optional[int] = Field(
+        30,
+        description="Number of papers to search for, of which those published in the covered years are analyzed",
+        ge=1,
+        le=50
+    )
+    field: Optional[FieldEnum] = Field(
+        FieldEnum.GENERAL,
+        description="Research field for context-specific analysis"
+    )
+
+    @validator('topic')
+    def topic_must_not_be_empty(cls, v):
+        if not v.strip():
+            raise ValueError('Topic cannot be empty')
+        return v
+
+
+class TrendStatus(str, Enum):
+    """Whether papers still raise a gap"""
+    EMERGING = "emerging"
+    PERSISTENT = "persistent"
+    CLOSING = "closing"
+
+
+class GapTypeCount(BaseModel):
+    """Number of gaps of a type"""
+    gap_type: str = Field(..., description="Type of gap")
+    count: int = Field(..., description="Number of gaps of the type")
+
+
+class TrendYear(BaseModel):
+    """Papers and gap types of a publication year"""
+    year: int = Field(..., description="Publication year")
+    paper_count: int = Field(..., description="Number of analyzed papers published in the year")
+    gap_types: List[GapTypeCount] = Field(..., description="Number of gaps of each type raised in the year, most frequent first")
+
+
+class GapTrend(BaseModel):
+    """Gap and the years of the papers raising it"""
+    gap_description: str = Field(..., description="Description of the gap")
+    gap_type: str = Field(..., description="Type of gap")
+    years: List[int] = Field(..., description="Years of the papers raising the gap, in ascending order")
+    status: TrendStatus = Field(..., description="Whether papers still raise the gap")
+
+
+class TrendsResponse(BaseModel):
+    """Response model for a topic's gap trends"""
+    topic: str = Field(..., description="Research topic")
+    from_year: int = Field(..., description="First covered year")
+    to_year: int = Field(..., description="Last covered year")
+    papers_analyzed: int = Field(..., description="Number of papers analyzed")
+    years: List[TrendYear] = Field(..., description="Every covered year in ascending order, including those without papers")
+    gaps: List[GapTrend] = Field(..., description="Gaps raised by the papers")
+    processing_time: float = Field(..., description="Processing time in seconds")
+
+
+class TopicResultsPage(BaseModel):
+    """A page of the individual results of a topic analysis"""
+    individual_results: List[TopicAnalysisResult] = Field(..., description="Results for individual papers")
+    next_cursor: Optional[str] = Field(None, description="Cursor of the next page, if any")
+
+
+class JobStatus(str, Enum):
+    """Background job status"""
+    PENDING = "pending"
+    RUNNING = "running"
+    SUCCEEDED = "succeeded"
+    FAILED = "failed"
+    CANCELLED = "cancelled"
+
+
+class Job(BaseModel):
+    """Background topic analysis"""
+    job_id: str = Field(..., description="Job identifier")
+    status: JobStatus = Field(..., description="Job status")
+    created_at: str = Field(..., description="Submission time (ISO 8601)")
+    updated_at: str = Field(..., description="Time of the last status change (ISO 8601)")
+    result: Optional[TopicResponse] = Field(None, description="Result, once the job succeeded")
+    error: Optional[str] = Field(None, description="Why the job failed")
+
+
+class AnalysisKind(str, Enum):
+    """What a kept analysis was of"""
+    ABSTRACT = "abstract"
+    TOPIC = "topic"
+
+
+class AnalysisSummary(BaseModel):
+    """Kept analysis, without its result"""
+    analysis_id: str = Field(..., description="Analysis identifier")
+    kind: AnalysisKind = Field(..., description="What was analyzed")
+    title: str = Field(..., description="Title of the paper, or the topic")
+    field: FieldEnum = Field(..., description="Research field")
+    created_at: str = Field(..., description="Time of the analysis (ISO 8601)")
+
+
+class AnalysesResponse(BaseModel):
+    """Kept analyses, newest first"""
+    analyses: List[AnalysisSummary] = Field(..., description="Summaries of the analyses")
+
+
+class Analysis(AnalysisSummary):
+    """Kept analysis with its result"""
+    result: Optional[AnalyzeResponse] = Field(None, description="Result of an abstract analysis")
+    topic_result: Optional[TopicResponse] = Field(None, description="Result of a topic analysis, with all its individual results")
+
+
+class ProgressStage(str, Enum):
+    """Stage of a paper in a topic analysis"""
+    FETCHED = "fetched"
+    ANALYZING = "analyzing"
+    DONE = "done"
+    FAILED = "failed"
+
+
+class TopicProgress(BaseModel):
+    """Progress of one paper in a topic analysis"""
+    paper_title: str = Field(..., description="Paper title")
+    index: int = Field(..., description="Position of the paper among those found")
+    total: int = Field(..., description="Number of papers found")
+    stage: ProgressStage = Field(..., description="Stage the paper reached")
+    result: Optional[TopicAnalysisResult] = Field(None, description="Analysis, once the paper is done")
+
+
+class ProgressMessage(BaseModel):
+    """Message sent on the /topic/ws WebSocket"""
+    type: str = Field(..., description="progress, result or error")
+    progress: Optional[TopicProgress] = Field(None, description="Set on progress messages")
+    result: Optional[TopicResponse] = Field(None, description="Set on the final result message")
+    detail: Optional[str] = Field(None, description="Set on error messages")
 
 
 class EmbeddingRequest(BaseModel):
@@ -121,6 +1137,50 @@ class EmbeddingResponse(BaseModel):
     model_used: str = Field(..., description="Embedding model used")
 
 
+class FieldsResponse(BaseModel):
+    """Research fields accepted by the service"""
+    fields: List[FieldEnum] = Field(..., description="Accepted values of the field parameter")
+
+
+class ModelsResponse(BaseModel):
+    """Models requests may choose"""
+    models: List[str] = Field(..., description="Accepted values of the model parameter")
+    default_model: str = Field(..., description="Model used when a request doesn't set one")
+
+
+class CostEstimate(BaseModel):
+    """Expected cost of an abstract or topic analysis; token counts and duration are approximate"""
+    model: Optional[str] = Field(None, description="Model the estimate is for")
+    papers: int = Field(..., description="Papers the analysis would cover")
+    llm_calls: int = Field(..., description="LLM calls the analysis would make")
+    input_tokens: int = Field(..., description="Tokens of the prompts")
+    output_tokens: int = Field(..., description="Tokens of the replies")
+    cost_usd: Optional[float] = Field(None, description="Price of the tokens in US dollars, if the model's price is known")
+    duration_seconds: float = Field(..., description="Expected time the analysis takes, in seconds")
+    processing_time: float = Field(..., description="Processing time of the estimate in seconds")
+
+
+class EndpointUsage(BaseModel):
+    """Requests a caller made to one endpoint"""
+    endpoint: str = Field(..., description="Method and path pattern, such as \"POST /analyze\"")
+    requests: int = Field(..., description="Requests counted")
+
+
+class Quota(BaseModel):
+    """Requests a caller may still make in the current window"""
+    limit: int = Field(..., description="Requests allowed per window")
+    remaining: int = Field(..., description="Requests left in the window")
+    reset_at: str = Field(..., description="When the next window starts (ISO 8601)")
+
+
+class UsageResponse(BaseModel):
+    """A caller's use of the service in the current quota window"""
+    requests: int = Field(..., description="Requests counted since the start of the window")
+    since: str = Field(..., description="Start of the window, or when the service started (ISO 8601)")
+    endpoints: List[EndpointUsage] = Field(..., description="Requests by endpoint, most used first")
+    quota: Optional[Quota] = Field(None, description="Left out if the service has no quota")
+
+
 class HealthResponse(BaseModel):
     """Health check response"""
     status: str = Field(..., description="Service status")
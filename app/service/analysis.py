@@ -1,84 +1,1279 @@
 """Analysis service for research gap detection"""
 
-from typing import Dict, Any, List
-from app.schema.models import AnalyzeRequest, TopicRequest
+import asyncio
+import os
+import re
+import time
+from typing import Dict, Any, List, AsyncIterator, Optional, Tuple
+from app.schema.models import (
+    AnalyzeRequest, TopicRequest, DOIRequest, ArxivRequest, PMIDRequest, CompareRequest, FieldEnum,
+    AnalysisMode, SurveyHandling, DeduplicateRequest, HypothesesRequest, ReviewRequest, ReviewSectionKind,
+    CitationsRequest, TrendsRequest, TrendStatus, ResearchGroupsRequest,
+    FundingRequest, NoveltyRequest, NoveltyStatus,
+    RelatedWorkRequest, ProposalRequest, MethodsRequest, RefineRequest, DatasetsRequest, ReviewAspect, CritiqueSeverity, ReviewRecommendation
+)
+from app.extract.pdf_extractor import pdf_extractor
 from app.service.llm_service import llm_service
-from app.service.arxiv_service import fetch_papers_by_topic
-from app.core.prompts import GAP_ANALYSIS_PROMPT, TOPIC_ANALYSIS_PROMPT
+from app.service.arxiv_service import arxiv_service, fetch_papers_by_topic
+from app.service.crossref_service import crossref_service
+from app.service.pubmed_service import pubmed_service
+from app.service.funding_service import funding_service
+from app.core.prompts import (
+    GAP_ANALYSIS_PROMPT, TOPIC_ANALYSIS_PROMPT, FULL_TEXT_INFO, COMPARISON_PROMPT, PAPER_INFO,
+    DEDUPLICATION_PROMPT, HYPOTHESIS_GENERATION_PROMPT, REVIEW_PROMPT, CITATION_PROMPT,
+    LANGUAGE_INFO, ENGLISH_OUTPUT, TRANSLATED_OUTPUT, INSTRUCTIONS_INFO, TRENDS_PROMPT,
+    GROUPS_PROMPT, FUNDING_PROMPT, NOVELTY_PROMPT, METHODS_PROMPT, DATASETS_PROMPT,
+    RELATED_WORK_PROMPT, PROPOSAL_PROMPT, PEER_REVIEW_PROMPT, REVIEW_FULL_TEXT_INFO,
+    ENGLISH_REVIEW_OUTPUT, TRANSLATED_REVIEW_OUTPUT, CROSS_FIELD_INFO, INTERSECTION_GAPS_INFO,
+    INTERSECTION_GAPS_FORMAT, SURVEYS_INFO, REFINE_PROMPT
+)
+from app.core.config import get_settings
 from app.utils.logger import get_logger
 
 logger = get_logger(__name__)
 
 
-async def analyze_text(request: AnalyzeRequest) -> Dict[str, Any]:
-    """Analyze a single text/abstract for research gaps"""
-    logger.info(f"Analyzing text: {request.title}")
-    
+# Characters of a paper's full text sent to the LLM in full_text mode
+FULL_TEXT_LIMIT = 24000
+
+# Section headings of a paper, with the sections analyzed in full_text mode
+_SECTION_HEADING = re.compile(
+    r"^[ \t]*(?:[0-9IVX]+(?:\.[0-9]+)*\.?[ \t]+)?"
+    r"(abstract|introduction|background|related work|materials and methods|methods|methodology"
+    r"|experimental setup|experiments|results|results and discussion|discussion|limitations"
+    r"|conclusions?|references|acknowledgements?|acknowledgments?|appendix)[ \t]*:?[ \t]*$",
+    re.IGNORECASE | re.MULTILINE
+)
+_ANALYZED_SECTIONS = {
+    "materials and methods", "methods", "methodology", "experimental setup", "experiments",
+    "results", "results and discussion", "discussion", "limitations"
+}
+
+
+def select_sections(text: str, limit: int = FULL_TEXT_LIMIT) -> str:
+    """Return the methods, results and discussion sections of a paper's text,
+    or the beginning of the text if no such headings are found"""
+    headings = list(_SECTION_HEADING.finditer(text))
+    sections = []
+    for i, heading in enumerate(headings):
+        if heading.group(1).lower() not in _ANALYZED_SECTIONS:
+            continue
+        end = headings[i + 1].start() if i + 1 < len(headings) else len(text)
+        sections.append(text[heading.start():end].strip())
+    selected = "\n\n".join(sections) or text.strip()
+    return selected[:limit]
+
+
+def _language_info(language: Optional[str], translate_output: bool) -> str:
+    """Tell the LLM the input's language and which language to answer in"""
+    if not language:
+        return ""
+    output = TRANSLATED_OUTPUT if translate_output else ENGLISH_OUTPUT
+    return LANGUAGE_INFO.format(language=language, output=output)
+
+
+def _filter_gaps(gaps: List[Dict[str, Any]], min_confidence: Optional[float]) -> List[Dict[str, Any]]:
+    """Keep the gaps with a confidence_score of at least min_confidence"""
+    if not min_confidence:
+        return gaps
+    return [gap for gap in gaps if gap.get("confidence_score", 0) >= min_confidence]
+
+
+def _abstract_prompt(request: AnalyzeRequest) -> str:
+    """Prompt analyzing an abstract sends, with the sections of its full text that fit"""
     # Prepare authors info
     authors_info = ""
     if request.authors:
         authors_info = f"Authors: {', '.join(request.authors)}"
+
+    full_text_info = ""
+    if request.mode == AnalysisMode.FULL_TEXT and request.full_text:
+        full_text_info = FULL_TEXT_INFO.format(sections=select_sections(request.full_text))
     
-    # Format prompt
-    prompt = GAP_ANALYSIS_PROMPT.format(
+    return GAP_ANALYSIS_PROMPT.format(
         title=request.title,
         abstract=request.abstract,
         field=request.field.value,
-        authors_info=authors_info
+        authors_info=authors_info,
+        full_text_info=full_text_info,
+        language_info=_language_info(request.language, request.translate_output),
+        instructions_info=INSTRUCTIONS_INFO.format(instructions=request.instructions) if request.instructions else ""
     )
-    
+
+
+async def analyze_text(request: AnalyzeRequest) -> Dict[str, Any]:
+    """Analyze a single text/abstract for research gaps"""
+    logger.info(f"Analyzing text: {request.title}")
+
     # Get analysis from LLM
-    result = await llm_service.analyze_with_prompt(prompt)
+    result = await llm_service.analyze_with_prompt(_abstract_prompt(request), request.model, request.temperature)
+    result["gaps"] = _filter_gaps(result.get("gaps", []), request.min_confidence)[:request.max_gaps]
+    result["suggested_hypotheses"] = result.get("suggested_hypotheses", [])[:request.max_hypotheses]
+    result["key_findings"] = result.get("key_findings", [])[:request.max_findings]
     
     logger.info("Text analysis completed")
     return result
 
 
-async def analyze_topic(request: TopicRequest) -> Dict[str, Any]:
-    """Analyze multiple papers for a given topic"""
-    logger.info(f"Analyzing topic: {request.topic}")
-    
-    # Fetch papers from arXiv
-    papers = await fetch_papers_by_topic(
-        request.topic,
-        max_results=request.max_papers
+def _paper_info(request: AnalyzeRequest) -> str:
+    """Describe a paper for a prompt"""
+    authors_info = f"Authors: {', '.join(request.authors)}" if request.authors else ""
+    return PAPER_INFO.format(title=request.title, authors_info=authors_info, abstract=request.abstract)
+
+
+async def simulate_review(request: AnalyzeRequest) -> Dict[str, Any]:
+    """Critique a paper the way a peer reviewer would.
+
+    The paper is analyzed as by analyze_text, and the critiques build on the
+    gaps and limitations found. Critiques of unknown aspects are dropped and
+    major ones are listed first; a recommendation the model gets wrong is
+    derived from them.
+    """
+    analysis = await analyze_text(request)
+    logger.info(f"Simulating peer review: {request.title}")
+    analysis_info = ""
+    if analysis.get("key_findings"):
+        analysis_info += f"Key findings: {'; '.join(analysis['key_findings'])}\n"
+    if analysis.get("limitations"):
+        analysis_info += f"Limitations: {'; '.join(analysis['limitations'])}\n"
+    if analysis.get("methodology_gaps"):
+        analysis_info += f"Methodology gaps: {'; '.join(analysis['methodology_gaps'])}\n"
+    for gap in analysis["gaps"]:
+        analysis_info += f"- Research gap ({gap.get('gap_type')}): {gap.get('gap_description')}\n"
+    language_info = ""
+    if request.language:
+        output = TRANSLATED_REVIEW_OUTPUT if request.translate_output else ENGLISH_REVIEW_OUTPUT
+        language_info = LANGUAGE_INFO.format(language=request.language, output=output)
+    full_text_info = ""
+    if request.mode == AnalysisMode.FULL_TEXT and request.full_text:
+        full_text_info = REVIEW_FULL_TEXT_INFO.format(sections=select_sections(request.full_text))
+    prompt = PEER_REVIEW_PROMPT.format(
+        field=request.field.value,
+        title=request.title,
+        authors_info=f"Authors: {', '.join(request.authors)}\n" if request.authors else "",
+        abstract=request.abstract,
+        full_text_info=full_text_info,
+        analysis_info=analysis_info,
+        language_info=language_info,
+        instructions_info=INSTRUCTIONS_INFO.format(instructions=request.instructions) if request.instructions else ""
     )
-    
+    result = await llm_service.analyze_with_prompt(prompt, request.model, request.temperature)
+
+    aspects = {aspect.value for aspect in ReviewAspect}
+    critiques = []
+    for critique in result.get("critiques") or []:
+        if critique.get("aspect") not in aspects or not (critique.get("comment") or "").strip():
+            continue
+        severity = critique.get("severity")
+        if severity != CritiqueSeverity.MAJOR.value:
+            severity = CritiqueSeverity.MINOR.value
+        critiques.append({"aspect": critique["aspect"], "severity": severity, "comment": critique["comment"]})
+    # List major critiques first
+    critiques.sort(key=lambda c: c["severity"] != CritiqueSeverity.MAJOR.value)
+    recommendation = result.get("recommendation")
+    if recommendation not in {r.value for r in ReviewRecommendation}:
+        major = critiques and critiques[0]["severity"] == CritiqueSeverity.MAJOR.value
+        recommendation = (ReviewRecommendation.MAJOR_REVISION if major else ReviewRecommendation.MINOR_REVISION).value
+    logger.info(f"Peer review simulation completed: {recommendation}")
+    return {
+        "recommendation": recommendation,
+        "summary": result.get("summary") or "",
+        "critiques": critiques,
+        "missing_baselines": result.get("missing_baselines") or [],
+        "gaps": analysis["gaps"]
+    }
+
+
+async def compare_papers(request: CompareRequest) -> Dict[str, Any]:
+    """Compare two papers' findings, conclusions and gaps"""
+    logger.info(f"Comparing papers: {request.paper_a.title} / {request.paper_b.title}")
+    # Papers of different fields are compared in general terms
+    field = request.paper_a.field if request.paper_a.field == request.paper_b.field else FieldEnum.GENERAL
+    prompt = COMPARISON_PROMPT.format(
+        field=field.value,
+        paper_a=_paper_info(request.paper_a),
+        paper_b=_paper_info(request.paper_b)
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+    for key in ("overlapping_findings", "conflicting_conclusions", "gaps_unique_to_a", "gaps_unique_to_b"):
+        result[key] = result.get(key) or []
+    logger.info("Paper comparison completed")
+    return result
+
+
+async def deduplicate_gaps(request: DeduplicateRequest) -> Dict[str, Any]:
+    """Cluster semantically similar gaps of several analyses.
+
+    Every gap of the request ends up in exactly one cluster: members the
+    model invents or repeats are dropped, and gaps it leaves out become
+    clusters of their own. A cluster's confidence is that of its most
+    confident member.
+    """
+    gaps = {
+        (a, g): gap
+        for a, analysis in enumerate(request.analyses)
+        for g, gap in enumerate(analysis)
+    }
+    logger.info(f"Deduplicating {len(gaps)} gaps of {len(request.analyses)} analyses")
+    prompt = DEDUPLICATION_PROMPT.format(gaps="\n".join(
+        f"[{a}.{g}] ({gap.gap_type}) {gap.gap_description}" for (a, g), gap in gaps.items()
+    ))
+    result = await llm_service.analyze_with_prompt(prompt)
+
+    clusters = []
+    seen = set()
+    for cluster in result.get("clusters") or []:
+        members = []
+        for member in cluster.get("members") or []:
+            ref = (member.get("analysis"), member.get("gap"))
+            if ref in gaps and ref not in seen:
+                seen.add(ref)
+                members.append(ref)
+        if not members:
+            continue
+        gap = dict(cluster.get("gap") or {})
+        first = gaps[members[0]]
+        for key in ("gap_description", "gap_type", "potential_impact"):
+            if not gap.get(key):
+                gap[key] = getattr(first, key)
+        gap["confidence_score"] = max(gaps[ref].confidence_score for ref in members)
+        clusters.append({"gap": gap, "members": members})
+    for ref, gap in gaps.items():
+        if ref not in seen:
+            clusters.append({"gap": gap.dict(), "members": [ref]})
+
+    for cluster in clusters:
+        cluster["members"] = [{"analysis": a, "gap": g} for a, g in cluster["members"]]
+    logger.info(f"Gap deduplication completed: {len(clusters)} clusters")
+    return {"clusters": clusters}
+
+
+async def generate_hypotheses(request: HypothesesRequest) -> Dict[str, Any]:
+    """Generate research hypotheses for a list of gaps"""
+    logger.info(f"Generating hypotheses for {len(request.gaps)} gaps")
+    gaps = []
+    for i, gap in enumerate(request.gaps, 1):
+        line = f"{i}. ({gap.gap_type}) {gap.gap_description}"
+        if gap.potential_impact:
+            line += f" Potential impact: {gap.potential_impact}"
+        gaps.append(line)
+    prompt = HYPOTHESIS_GENERATION_PROMPT.format(field=request.field.value, gaps="\n".join(gaps))
+    result = await llm_service.analyze_with_prompt(prompt)
+    result["hypotheses"] = result.get("hypotheses") or []
+    logger.info("Hypothesis generation completed")
+    return result
+
+
+async def recommend_methods(request: MethodsRequest) -> Dict[str, Any]:
+    """Expand a hypothesis' required methods into experimental designs.
+
+    Designs and datasets the model leaves unnamed are dropped, as are
+    dataset URLs that aren't web addresses.
+    """
+    hypothesis = request.hypothesis
+    logger.info(f"Recommending methods for: {hypothesis.hypothesis}")
+    prompt = METHODS_PROMPT.format(
+        field=request.field.value,
+        hypothesis=hypothesis.hypothesis,
+        rationale_info=f"Rationale: {hypothesis.rationale}\n" if hypothesis.rationale else "",
+        methods_info=f"Required methods: {', '.join(hypothesis.required_methods)}\n" if hypothesis.required_methods else ""
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+    designs = []
+    for design in result.get("designs") or []:
+        if (design.get("name") or "").strip():
+            designs.append({
+                "name": design["name"],
+                "description": design.get("description") or "",
+                "methods": design.get("methods") or [],
+                "sample_size": design.get("sample_size") or "",
+                "limitations": design.get("limitations") or []
+            })
+    datasets = []
+    for dataset in result.get("datasets") or []:
+        if (dataset.get("name") or "").strip():
+            url = dataset.get("url") or ""
+            datasets.append({
+                "name": dataset["name"],
+                "description": dataset.get("description") or "",
+                "url": url if url.startswith(("https://", "http://")) else None
+            })
+    logger.info(f"Methodology recommendation completed: {len(designs)} designs")
+    return {"designs": designs, "datasets": datasets}
+
+
+def _analysis_text(analysis) -> str:
+    """Write out an analysis for a prompt, one titled list per non-empty part"""
+    text = ""
+    if analysis.key_findings:
+        text += "Key findings:\n" + "".join(f"- {f}\n" for f in analysis.key_findings)
+    if analysis.gaps:
+        text += "Research gaps:\n" + "".join(
+            f"{i}. {g.gap_description} ({g.gap_type}, confidence {g.confidence_score}): {g.potential_impact}\n"
+            for i, g in enumerate(analysis.gaps, 1)
+        )
+    if analysis.limitations:
+        text += "Limitations:\n" + "".join(f"- {item}\n" for item in analysis.limitations)
+    if analysis.methodology_gaps:
+        text += "Methodology gaps:\n" + "".join(f"- {g}\n" for g in analysis.methodology_gaps)
+    if analysis.suggested_hypotheses:
+        text += "Suggested hypotheses:\n" + "".join(
+            f"{i}. {h.hypothesis} (feasibility {h.feasibility_score}): {h.rationale}\n"
+            for i, h in enumerate(analysis.suggested_hypotheses, 1)
+        )
+    if analysis.future_directions:
+        text += "Future directions:\n" + "".join(f"- {d}\n" for d in analysis.future_directions)
+    return text
+
+
+async def refine_analysis(request: RefineRequest) -> Dict[str, Any]:
+    """Update a previous analysis following the researcher's feedback.
+
+    Whatever the feedback doesn't concern is kept, and the paper metadata of
+    an analysis by identifier is carried over.
+    """
+    logger.info(f"Refining analysis with feedback: {request.feedback[:100]}")
+    previous = request.previous
+    paper_info = ""
+    if previous.paper:
+        paper_info = f"\nTitle: {previous.paper.title}\nAbstract: {previous.paper.abstract}\n"
+    prompt = REFINE_PROMPT.format(
+        field=request.field.value,
+        paper_info=paper_info,
+        previous=_analysis_text(previous),
+        feedback=request.feedback
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+    refined = {
+        key: result.get(key) or []
+        for key in ("key_findings", "gaps", "suggested_hypotheses", "limitations", "methodology_gaps", "future_directions")
+    }
+    refined["paper"] = previous.paper.dict() if previous.paper else None
+    logger.info(f"Refinement completed: {len(refined['gaps'])} gaps")
+    return refined
+
+
+async def generate_review(request: ReviewRequest) -> Dict[str, Any]:
+    """Draft a literature review of a topic's papers.
+
+    Papers are cited by their position in the request starting at 1;
+    citations of other numbers are dropped, and the cited papers are listed
+    as references. Sections of an unknown kind are treated as themes.
+    """
+    logger.info(f"Drafting review of {len(request.papers)} papers on: {request.topic}")
+    papers_info = []
+    for i, paper in enumerate(request.papers, 1):
+        info = (
+            f"[{i}] {paper.paper_title}\n"
+            f"Authors: {', '.join(paper.authors or [])}\n"
+            f"Abstract: {(paper.abstract or '')[:1000]}"
+        )
+        if paper.gaps:
+            info += f"\nGaps: {'; '.join(gap.gap_description for gap in paper.gaps)}"
+        papers_info.append(info)
+    common_gaps = "\n".join(f"- {gap.gap_description}" for gap in request.common_gaps)
+    prompt = REVIEW_PROMPT.format(
+        topic=request.topic,
+        field=request.field.value,
+        papers_info="\n\n".join(papers_info),
+        common_gaps=common_gaps or "None identified"
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+
+    kinds = {kind.value for kind in ReviewSectionKind}
+    cited = set()
+    sections = []
+    for section in result.get("sections") or []:
+        citations = [n for n in section.get("citations") or [] if isinstance(n, int) and 1 <= n <= len(request.papers)]
+        cited.update(citations)
+        sections.append({
+            "kind": section.get("kind") if section.get("kind") in kinds else ReviewSectionKind.THEME.value,
+            "heading": section.get("heading") or "",
+            "paragraphs": section.get("paragraphs") or [],
+            "citations": citations
+        })
+    references = [
+        {
+            "number": n,
+            "title": request.papers[n - 1].paper_title,
+            "authors": request.papers[n - 1].authors or [],
+            "url": request.papers[n - 1].url
+        }
+        for n in sorted(cited)
+    ]
+    logger.info(f"Review draft completed: {len(sections)} sections")
+    return {
+        "title": result.get("title") or f"Literature review: {request.topic}",
+        "sections": sections,
+        "references": references
+    }
+
+
+async def suggest_citations(request: CitationsRequest) -> Dict[str, Any]:
+    """Suggest papers to cite when writing about a research gap.
+
+    Papers are searched for with the gap's description, and the LLM chooses
+    among them, so every suggestion is a paper that exists.
+    """
+    gap = request.gap.gap_description
+    logger.info(f"Suggesting citations for gap: {gap}")
+    papers = await fetch_papers_by_topic(gap, 2 * request.max_citations)
     if not papers:
-        logger.warning(f"No papers found for topic: {request.topic}")
+        logger.warning(f"No candidate papers found for gap: {gap}")
+        return {"citations": []}
+
+    papers_info = "\n\n".join(
+        f"[{i}] {paper['title']}\n"
+        f"Authors: {', '.join(paper.get('authors') or [])}\n"
+        f"Abstract: {(paper.get('abstract') or '')[:500]}"
+        for i, paper in enumerate(papers, 1)
+    )
+    prompt = CITATION_PROMPT.format(
+        field=request.field.value,
+        gap=gap,
+        papers_info=papers_info,
+        max_citations=request.max_citations
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+
+    citations = []
+    chosen = set()
+    for citation in result.get("citations") or []:
+        n = citation.get("paper")
+        if not isinstance(n, int) or not 1 <= n <= len(papers) or n in chosen:
+            continue
+        chosen.add(n)
+        paper = papers[n - 1]
+        citations.append({
+            "title": paper["title"],
+            "authors": paper.get("authors") or [],
+            "url": paper.get("url"),
+            "reason": citation.get("reason") or ""
+        })
+    logger.info(f"Citation suggestion completed: {len(citations)} citations")
+    return {"citations": citations[:request.max_citations]}
+
+
+def _novelty_status(score: float) -> str:
+    """The status of an idea with a novelty score, splitting the scores in
+    thirds"""
+    if score < 1 / 3:
+        return NoveltyStatus.ADDRESSED.value
+    if score < 2 / 3:
+        return NoveltyStatus.PARTIALLY_ADDRESSED.value
+    return NoveltyStatus.OPEN.value
+
+
+async def check_novelty(request: NoveltyRequest) -> Dict[str, Any]:
+    """Check whether the literature already addresses a research idea.
+
+    Papers are searched for with the idea, and the LLM judges whether they
+    address it. A status the LLM gets wrong is derived from its novelty
+    score, and supporting papers are always papers the search found.
+    """
+    logger.info(f"Checking novelty of idea: {request.idea[:100]}")
+    papers = await fetch_papers_by_topic(request.idea, request.max_papers)
+    if not papers:
+        logger.warning("No related papers found for idea")
         return {
-            "topic": request.topic,
-            "papers_analyzed": 0,
-            "common_gaps": [],
-            "individual_results": [],
-            "suggested_research_directions": [
-                "No papers found for this topic. Try refining your search terms."
-            ]
+            "status": NoveltyStatus.OPEN.value,
+            "novelty_score": 1.0,
+            "summary": "No related papers were found.",
+            "supporting_papers": [],
+            "papers_searched": 0
         }
-    
-    # Format papers info for prompt
+
+    papers_info = []
+    for i, paper in enumerate(papers, 1):
+        year = _published_year(paper)
+        papers_info.append(
+            f"[{i}] {paper['title']}" + (f" ({year})" if year else "")
+            + f"\nAbstract: {(paper.get('abstract') or '')[:500]}"
+        )
+    prompt = NOVELTY_PROMPT.format(
+        field=request.field.value,
+        idea=request.idea,
+        papers_info="\n\n".join(papers_info)
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+
+    score = min(max(float(result.get("novelty_score") or 0), 0.0), 1.0)
+    status = result.get("status")
+    if status not in [s.value for s in NoveltyStatus]:
+        status = _novelty_status(score)
+    supporting = []
+    chosen = set()
+    for p in result.get("papers") or []:
+        n = p.get("paper")
+        if not isinstance(n, int) or not 1 <= n <= len(papers) or n in chosen:
+            continue
+        chosen.add(n)
+        paper = papers[n - 1]
+        supporting.append({
+            "title": paper["title"],
+            "authors": paper.get("authors") or [],
+            "url": paper.get("url"),
+            "reason": p.get("reason") or ""
+        })
+    logger.info(f"Novelty check completed: {status}")
+    return {
+        "status": status,
+        "novelty_score": score,
+        "summary": result.get("summary") or "",
+        "supporting_papers": supporting,
+        "papers_searched": len(papers)
+    }
+
+
+# Longest default search query, in characters, taken from a proposal's first
+# line
+MAX_PROPOSAL_QUERY = 200
+
+
+async def analyze_proposal(request: ProposalRequest) -> Dict[str, Any]:
+    """Check how well a draft grant proposal addresses known gaps.
+
+    Papers are searched for with the request's topic or the proposal's first
+    line, and the LLM scores the proposal against the request's gaps, or else
+    those the papers raise, and lists the claims of novelty the papers
+    contradict. A conflict is only reported with papers the search found.
+    """
+    query = (request.topic or "").strip() or request.proposal.strip().split("\n", 1)[0].strip()[:MAX_PROPOSAL_QUERY]
+    logger.info(f"Analyzing proposal, searching: {query}")
+    papers = await fetch_papers_by_topic(query, request.max_papers)
+    gaps = request.gaps or []
+    if not papers and not gaps:
+        logger.warning(f"No related papers or known gaps for proposal: {query}")
+        return {
+            "alignment_score": 0.0,
+            "gaps": [],
+            "novelty_conflicts": [],
+            "summary": "No related papers were found, and no known gaps were given.",
+            "papers_searched": 0
+        }
+
+    papers_info = []
+    for i, paper in enumerate(papers, 1):
+        year = _published_year(paper)
+        papers_info.append(
+            f"[{i}] {paper['title']}" + (f" ({year})" if year else "")
+            + f"\nAbstract: {(paper.get('abstract') or '')[:500]}"
+        )
+    gaps_info = ""
+    if gaps:
+        gaps_info = "\nKnown research gaps:\n" + "\n".join(
+            f"[{i}] {gap.gap_description}" for i, gap in enumerate(gaps, 1)
+        ) + "\n"
+    prompt = PROPOSAL_PROMPT.format(
+        field=request.field.value,
+        proposal=request.proposal,
+        gaps_info=gaps_info,
+        papers_info="\n\n".join(papers_info) or "None found.",
+        gaps_kind="known research gaps" if gaps else "research gaps the related papers raise",
+        gap_hint=", as written in the list" if gaps else ""
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+
+    alignments = []
+    for gap in result.get("gaps") or []:
+        if (gap.get("gap_description") or "").strip():
+            alignments.append({
+                "gap_description": gap["gap_description"],
+                "coverage": min(max(float(gap.get("coverage") or 0), 0.0), 1.0),
+                "comment": gap.get("comment") or ""
+            })
+    conflicts = []
+    for conflict in result.get("novelty_conflicts") or []:
+        cited = []
+        chosen = set()
+        for p in conflict.get("papers") or []:
+            n = p.get("paper")
+            if not isinstance(n, int) or not 1 <= n <= len(papers) or n in chosen:
+                continue
+            chosen.add(n)
+            paper = papers[n - 1]
+            cited.append({
+                "title": paper["title"],
+                "authors": paper.get("authors") or [],
+                "url": paper.get("url"),
+                "reason": p.get("reason") or ""
+            })
+        if (conflict.get("claim") or "").strip() and cited:
+            conflicts.append({"claim": conflict["claim"], "papers": cited})
+    logger.info(f"Proposal analysis completed: {len(conflicts)} novelty conflicts")
+    return {
+        "alignment_score": min(max(float(result.get("alignment_score") or 0), 0.0), 1.0),
+        "gaps": alignments,
+        "novelty_conflicts": conflicts,
+        "summary": result.get("summary") or "",
+        "papers_searched": len(papers)
+    }
+
+
+# Number of calls shown to the LLM, out of the often hundreds of open ones
+MAX_FUNDING_CANDIDATES = 40
+
+
+def _significant_words(text: str) -> set:
+    """The lowercased words of text long enough to carry meaning"""
+    return {w for w in re.findall(r"[^\W_]+", text.lower()) if len(w) >= 4}
+
+
+def _candidate_calls(calls: List[Dict[str, Any]], gaps: List[Any]) -> List[Dict[str, Any]]:
+    """The calls sharing words with the gaps' descriptions, those sharing the
+    most first"""
+    gap_words = set()
+    for gap in gaps:
+        gap_words |= _significant_words(gap.gap_description)
+    scored = []
+    for call in calls:
+        score = len(_significant_words(f"{call['title']} {call.get('summary') or ''}") & gap_words)
+        if score:
+            scored.append((score, call))
+    scored.sort(key=lambda s: -s[0])
+    return [call for _, call in scored[:MAX_FUNDING_CANDIDATES]]
+
+
+async def match_funding(request: FundingRequest) -> Dict[str, Any]:
+    """Match research gaps with open funding calls.
+
+    The calls sharing the most words with the gaps are shown to the LLM,
+    which picks those that would fund them; calls and gaps it invents are
+    dropped.
+    """
+    logger.info(f"Matching {len(request.gaps)} gaps with funding calls")
+    calls = await funding_service.open_calls()
+    candidates = _candidate_calls(calls, request.gaps)
+    if not candidates:
+        logger.warning(f"No candidate funding calls found among {len(calls)}")
+        return {"matches": [], "calls_searched": len(calls)}
+
+    gaps = "\n".join(
+        f"[{i}] {gap.gap_description}" + (f" Potential impact: {gap.potential_impact}" if gap.potential_impact else "")
+        for i, gap in enumerate(request.gaps, 1)
+    )
+    calls_info = "\n\n".join(
+        f"[{i}] {call['title']} ({call['agency']})" + (f"\nSummary: {call['summary'][:500]}" if call.get("summary") else "")
+        for i, call in enumerate(candidates, 1)
+    )
+    prompt = FUNDING_PROMPT.format(
+        field=request.field.value,
+        gaps=gaps,
+        calls_info=calls_info,
+        max_matches=request.max_matches
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+
+    matches = []
+    chosen = set()
+    for match in result.get("matches") or []:
+        n = match.get("call")
+        if not isinstance(n, int) or not 1 <= n <= len(candidates) or n in chosen:
+            continue
+        gap_indexes = sorted({
+            g - 1 for g in match.get("gaps") or [] if isinstance(g, int) and 1 <= g <= len(request.gaps)
+        })
+        if not gap_indexes:
+            continue
+        chosen.add(n)
+        matches.append({
+            "call": candidates[n - 1],
+            "gaps": gap_indexes,
+            "relevance_score": min(max(float(match.get("relevance_score") or 0), 0.0), 1.0),
+            "reason": match.get("reason") or ""
+        })
+    matches.sort(key=lambda m: -m["relevance_score"])
+    logger.info(f"Funding matching completed: {len(matches)} matches")
+    return {"matches": matches[:request.max_matches], "calls_searched": len(calls)}
+
+
+async def suggest_datasets(request: DatasetsRequest) -> Dict[str, Any]:
+    """Suggest public datasets to investigate a gap or test a hypothesis with.
+
+    A dataset is only suggested with a web address to get it from, and at
+    most once.
+    """
+    if request.gap is not None:
+        gap = request.gap
+        subject_info = f"\nA researcher wants to investigate the following research gap:\n({gap.gap_type}) {gap.gap_description}"
+        if gap.potential_impact:
+            subject_info += f"\nPotential impact: {gap.potential_impact}"
+        logger.info(f"Suggesting datasets for gap: {gap.gap_description}")
+    else:
+        hypothesis = request.hypothesis
+        subject_info = f"\nA researcher wants to test the following hypothesis:\n{hypothesis.hypothesis}"
+        if hypothesis.required_methods:
+            subject_info += f"\nRequired methods: {', '.join(hypothesis.required_methods)}"
+        logger.info(f"Suggesting datasets for hypothesis: {hypothesis.hypothesis}")
+    prompt = DATASETS_PROMPT.format(
+        field=request.field.value,
+        subject_info=subject_info + "\n",
+        max_datasets=request.max_datasets
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+
+    datasets = []
+    seen = set()
+    for dataset in result.get("datasets") or []:
+        name = (dataset.get("name") or "").strip().lower()
+        url = dataset.get("url") or ""
+        if not name or name in seen or not url.startswith(("https://", "http://")):
+            continue
+        seen.add(name)
+        datasets.append({
+            "name": dataset["name"],
+            "description": dataset.get("description") or "",
+            "url": url,
+            "license": dataset.get("license") or None
+        })
+    logger.info(f"Dataset suggestion completed: {len(datasets)} datasets")
+    return {"datasets": datasets[:request.max_datasets]}
+
+
+async def get_related_work(request: RelatedWorkRequest) -> Dict[str, Any]:
+    """Find the existing papers closest to a research gap.
+
+    Papers are searched for with the gap's description, and the LLM scores
+    how close each is and whether it already addresses the gap, so every
+    paper returned exists.
+    """
+    gap = request.gap.gap_description
+    logger.info(f"Finding related work for gap: {gap}")
+    papers = await fetch_papers_by_topic(gap, 2 * request.max_papers)
+    if not papers:
+        logger.warning(f"No related papers found for gap: {gap}")
+        return {"papers": []}
+
+    papers_info = []
+    for i, paper in enumerate(papers, 1):
+        year = _published_year(paper)
+        papers_info.append(
+            f"[{i}] {paper['title']}" + (f" ({year})" if year else "")
+            + f"\nAbstract: {(paper.get('abstract') or '')[:500]}"
+        )
+    prompt = RELATED_WORK_PROMPT.format(
+        field=request.field.value,
+        gap=gap,
+        papers_info="\n\n".join(papers_info),
+        max_papers=request.max_papers
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+
+    related = []
+    chosen = set()
+    for p in result.get("papers") or []:
+        n = p.get("paper")
+        if not isinstance(n, int) or not 1 <= n <= len(papers) or n in chosen:
+            continue
+        chosen.add(n)
+        paper = papers[n - 1]
+        related.append({
+            "title": paper["title"],
+            "authors": paper.get("authors") or [],
+            "url": paper.get("url"),
+            "year": _published_year(paper),
+            "similarity": min(max(float(p.get("similarity") or 0), 0.0), 1.0),
+            "addresses_gap": bool(p.get("addresses_gap")),
+            "reason": p.get("reason") or ""
+        })
+    related.sort(key=lambda p: -p["similarity"])
+    logger.info(f"Related work retrieval completed: {len(related)} papers")
+    return {"papers": related[:request.max_papers]}
+
+
+def _author_groups(papers: List[Any]) -> List[Dict[str, Any]]:
+    """Group the authors of papers by co-authorship, the group with the most
+    papers first"""
+    groups: List[Optional[Dict[str, Any]]] = []
+    group_of: Dict[str, int] = {}
+    for paper in papers:
+        authors = list(dict.fromkeys(a.strip() for a in paper.authors or [] if a.strip()))
+        if not authors:
+            continue
+        # Merge the groups of the paper's authors, oldest first, with the
+        # paper and its new authors
+        merged = {"authors": [], "papers": []}
+        for g in sorted({group_of[a] for a in authors if a in group_of}):
+            merged["authors"] += groups[g]["authors"]
+            merged["papers"] += groups[g]["papers"]
+            groups[g] = None
+        merged["authors"] += [a for a in authors if a not in merged["authors"]]
+        merged["papers"].append(paper.paper_title)
+        groups.append(merged)
+        for a in merged["authors"]:
+            group_of[a] = len(groups) - 1
+    return sorted((g for g in groups if g), key=lambda g: -len(g["papers"]))
+
+
+async def find_research_groups(request: ResearchGroupsRequest) -> Dict[str, Any]:
+    """Find the research groups working near a topic's common gaps.
+
+    The LLM picks the papers near each gap, and the authors of those papers
+    are grouped by co-authorship: authors of a shared paper, directly or
+    through other co-authors, form one group.
+    """
+    logger.info(f"Finding research groups for {len(request.common_gaps)} gaps on: {request.topic}")
+    gaps = "\n".join(f"[{i}] {gap.gap_description}" for i, gap in enumerate(request.common_gaps, 1))
+    papers_info = []
+    for i, paper in enumerate(request.papers, 1):
+        info = f"[{i}] {paper.paper_title}\nAbstract: {(paper.abstract or '')[:500]}"
+        if paper.gaps:
+            info += f"\nGaps: {'; '.join(gap.gap_description for gap in paper.gaps)}"
+        papers_info.append(info)
+    prompt = GROUPS_PROMPT.format(
+        topic=request.topic,
+        field=request.field.value,
+        gaps=gaps,
+        papers_info="\n\n".join(papers_info)
+    )
+    result = await llm_service.analyze_with_prompt(prompt)
+
+    near: Dict[int, set] = {}
+    for gap in result.get("gaps") or []:
+        g = gap.get("gap")
+        if not isinstance(g, int) or not 1 <= g <= len(request.common_gaps):
+            continue
+        near.setdefault(g - 1, set()).update(
+            n - 1 for n in gap.get("papers") or [] if isinstance(n, int) and 1 <= n <= len(request.papers)
+        )
+    common_gaps = []
+    for i, gap in enumerate(request.common_gaps):
+        gap = gap.dict()
+        gap["research_groups"] = _author_groups([request.papers[n] for n in sorted(near.get(i, ()))])
+        common_gaps.append(gap)
+    logger.info("Research group search completed")
+    return {"common_gaps": common_gaps}
+
+
+def _published_year(paper: Dict[str, Any]) -> Optional[int]:
+    """Year a paper was published, if its source says"""
+    match = re.match(r"(\d{4})-", paper.get("published") or "")
+    return int(match.group(1)) if match else None
+
+
+def _trend_status(years: List[int], first: int, latest: int) -> str:
+    """Whether a gap raised in years, in ascending order, is still raised by
+    the papers, published from first to latest"""
+    if years[-1] < latest:
+        return TrendStatus.CLOSING.value
+    if years[0] == latest and first < latest:
+        return TrendStatus.EMERGING.value
+    return TrendStatus.PERSISTENT.value
+
+
+async def analyze_trends(request: TrendsRequest) -> Dict[str, Any]:
+    """Follow the gaps raised by papers on a topic over the publication years.
+
+    Papers published in the covered years are numbered oldest first, and the
+    LLM lists the papers raising each gap. A gap still raised in the latest
+    year with papers is persistent, or emerging if no earlier paper raised
+    it; other gaps are closing.
+    """
+    to_year = time.gmtime().tm_year
+    from_year = to_year - request.years_back + 1
+    logger.info(f"Analyzing trends of {request.topic} since {from_year}")
+    found = await fetch_papers_by_topic(
+        request.topic, max_results=request.max_papers, years=(from_year, to_year)
+    )
+    papers = sorted(
+        (paper for paper in found if from_year <= (_published_year(paper) or 0) <= to_year),
+        key=lambda paper: paper["published"]
+    )
+
+    gaps = []
+    if not papers:
+        logger.warning(f"No dated papers found for topic: {request.topic}")
+    else:
+        papers_info = "\n\n".join(
+            f"[{i}] {_published_year(paper)}: {paper.get('title', '')}\n"
+            f"Abstract: {(paper.get('abstract') or '')[:500]}"
+            for i, paper in enumerate(papers, 1)
+        )
+        prompt = TRENDS_PROMPT.format(topic=request.topic, field=request.field.value, papers_info=papers_info)
+        result = await llm_service.analyze_with_prompt(prompt)
+
+        first, latest = _published_year(papers[0]), _published_year(papers[-1])
+        for gap in result.get("gaps") or []:
+            years = sorted({
+                _published_year(papers[n - 1]) for n in gap.get("papers") or []
+                if isinstance(n, int) and 1 <= n <= len(papers)
+            })
+            if not years or not (gap.get("gap_description") or "").strip():
+                continue
+            gaps.append({
+                "gap_description": gap["gap_description"],
+                "gap_type": (gap.get("gap_type") or "").strip().lower(),
+                "years": years,
+                "status": _trend_status(years, first, latest)
+            })
+
+    years = []
+    for year in range(from_year, to_year + 1):
+        counts: Dict[str, int] = {}
+        for gap in gaps:
+            if year in gap["years"]:
+                counts[gap["gap_type"]] = counts.get(gap["gap_type"], 0) + 1
+        years.append({
+            "year": year,
+            "paper_count": sum(1 for paper in papers if _published_year(paper) == year),
+            "gap_types": [
+                {"gap_type": gap_type, "count": count}
+                for gap_type, count in sorted(counts.items(), key=lambda item: (-item[1], item[0]))
+            ]
+        })
+    logger.info(f"Trend analysis completed: {len(gaps)} gaps in {len(papers)} papers")
+    return {
+        "topic": request.topic,
+        "from_year": from_year,
+        "to_year": to_year,
+        "papers_analyzed": len(papers),
+        "years": years,
+        "gaps": gaps
+    }
+
+
+# Characters of a PDF's text sent to the LLM; papers start with their
+# abstract and introduction, which is what the gap analysis prompt expects
+PDF_TEXT_LIMIT = 8000
+
+
+class PDFError(Exception):
+    """Raised when a PDF is too large or has no extractable text"""
+
+
+async def analyze_pdf(
+    pdf_bytes: bytes,
+    filename: str,
+    title: Optional[str] = None,
+    field: FieldEnum = FieldEnum.GENERAL,
+    authors: Optional[List[str]] = None,
+    mode: AnalysisMode = AnalysisMode.ABSTRACT
+) -> Dict[str, Any]:
+    """Extract the text of a paper PDF and analyze it for research gaps.
+
+    In full_text mode the methods, results and discussion are analyzed too,
+    otherwise only the beginning of the text.
+    """
+    logger.info(f"Analyzing PDF: {filename}")
+    if len(pdf_bytes) > pdf_extractor.max_file_size:
+        raise PDFError(f"PDF exceeds {pdf_extractor.max_file_size} bytes")
+
+    extracted = await asyncio.to_thread(pdf_extractor.extract_text, pdf_bytes)
+    text = extracted["text"].strip()
+    if not extracted["success"] or not text:
+        raise PDFError("Could not extract text from the PDF")
+
+    if not title:
+        metadata = await asyncio.to_thread(pdf_extractor.extract_metadata_only, pdf_bytes)
+        title = (metadata.get("title") or "").strip() or os.path.splitext(filename)[0] or "Untitled"
+
+    return await analyze_text(AnalyzeRequest(
+        title=title,
+        abstract=text[:PDF_TEXT_LIMIT],
+        field=field,
+        authors=authors,
+        full_text=text if mode == AnalysisMode.FULL_TEXT else None,
+        mode=mode
+    ))
+
+
+class PaperNotFoundError(Exception):
+    """Raised when no paper has the requested identifier"""
+
+
+class MissingAbstractError(Exception):
+    """Raised when a paper's metadata has no abstract to analyze"""
+
+
+async def analyze_doi(request: DOIRequest) -> Dict[str, Any]:
+    """Look up a paper on CrossRef by DOI and analyze its abstract"""
+    logger.info(f"Analyzing DOI: {request.doi}")
+    paper = await crossref_service.get_work(request.doi)
+    if paper is None:
+        raise PaperNotFoundError(f"DOI {request.doi} not found")
+    if not paper["abstract"]:
+        raise MissingAbstractError(f"CrossRef has no abstract for DOI {request.doi}")
+
+    result = await analyze_text(AnalyzeRequest(
+        title=paper["title"] or request.doi,
+        abstract=paper["abstract"],
+        field=request.field,
+        authors=paper["authors"] or None
+    ))
+    result["paper"] = {"source": "crossref", "id": request.doi, **paper}
+    return result
+
+
+async def analyze_pmid(request: PMIDRequest) -> Dict[str, Any]:
+    """Look up a paper on PubMed by ID and analyze its abstract"""
+    logger.info(f"Analyzing PMID: {request.pmid}")
+    paper = await pubmed_service.get_article(request.pmid)
+    if paper is None:
+        raise PaperNotFoundError(f"PMID {request.pmid} not found")
+    if not paper["abstract"]:
+        raise MissingAbstractError(f"PubMed has no abstract for PMID {request.pmid}")
+
+    result = await analyze_text(AnalyzeRequest(
+        title=paper["title"] or f"PMID {request.pmid}",
+        abstract=paper["abstract"],
+        field=request.field,
+        authors=paper["authors"] or None
+    ))
+    result["paper"] = {"source": "pubmed", "id": request.pmid, **paper}
+    return result
+
+
+async def analyze_arxiv(request: ArxivRequest) -> Dict[str, Any]:
+    """Look up a paper on arXiv by ID and analyze its abstract, or in
+    full_text mode its PDF"""
+    logger.info(f"Analyzing arXiv paper: {request.arxiv_id}")
+    paper = await arxiv_service.get_paper_by_id(request.arxiv_id)
+    if paper is None or not paper.get("title"):
+        raise PaperNotFoundError(f"arXiv paper {request.arxiv_id} not found")
+    if not paper.get("abstract"):
+        raise MissingAbstractError(f"arXiv has no abstract for {request.arxiv_id}")
+
+    full_text = None
+    if request.full_text:
+        pdf_bytes = await arxiv_service.download_pdf(request.arxiv_id)
+        if pdf_bytes is None:
+            raise PDFError(f"Could not download the PDF of arXiv paper {request.arxiv_id}")
+        extracted = await asyncio.to_thread(pdf_extractor.extract_text, pdf_bytes)
+        full_text = extracted["text"].strip()
+        if not extracted["success"] or not full_text:
+            raise PDFError("Could not extract text from the PDF")
+
+    result = await analyze_text(AnalyzeRequest(
+        title=paper["title"],
+        abstract=paper["abstract"],
+        field=request.field,
+        authors=paper.get("authors") or None,
+        full_text=full_text,
+        mode=AnalysisMode.FULL_TEXT if full_text else AnalysisMode.ABSTRACT
+    ))
+    result["paper"] = {
+        "source": "arxiv",
+        "id": request.arxiv_id,
+        "title": paper["title"],
+        "authors": paper.get("authors", []),
+        "abstract": paper["abstract"],
+        "url": paper.get("url"),
+        "year": _published_year(paper),
+    }
+    return result
+
+
+# Abstracts of a batch analyzed at the same time
+BATCH_CONCURRENCY = 5
+
+
+async def analyze_batch(requests: List[AnalyzeRequest]) -> List[Dict[str, Any]]:
+    """Analyze several abstracts concurrently, reporting failures per item"""
+    logger.info(f"Analyzing batch of {len(requests)} abstracts")
+    semaphore = asyncio.Semaphore(BATCH_CONCURRENCY)
+
+    async def analyze_item(index: int, request: AnalyzeRequest) -> Dict[str, Any]:
+        async with semaphore:
+            start_time = time.time()
+            try:
+                result = await analyze_text(request)
+            except Exception as e:
+                logger.error(f"Error analyzing batch item {index}: {str(e)}")
+                return {"index": index, "result": None, "error": "An error occurred during analysis."}
+            result["processing_time"] = round(time.time() - start_time, 2)
+            return {"index": index, "result": result, "error": None}
+
+    results = await asyncio.gather(*(analyze_item(i, r) for i, r in enumerate(requests)))
+    logger.info("Batch analysis completed")
+    return list(results)
+
+
+def _no_papers_response(topic: str) -> Dict[str, Any]:
+    return {
+        "topic": topic,
+        "papers_analyzed": 0,
+        "common_gaps": [],
+        "individual_results": [],
+        "suggested_research_directions": [
+            "No papers found for this topic. Try refining your search terms."
+        ]
+    }
+
+
+def _topic_prompt(request: TopicRequest, papers: List[Dict[str, Any]]) -> str:
+    # Surveys are marked for the model to keep them in the background
+    mark_surveys = request.surveys == SurveyHandling.DOWNWEIGHT and any(_is_survey(p) for p in papers)
     papers_info = ""
     for i, paper in enumerate(papers, 1):
+        mark = " (survey)" if mark_surveys and _is_survey(paper) else ""
         papers_info += f"""
-Paper {i}:
+Paper {i}{mark}:
 Title: {paper.get('title', 'Unknown')}
 Authors: {', '.join(paper.get('authors', ['Unknown']))}
 Abstract: {paper.get('abstract', 'No abstract available')[:1000]}...
 """
-    
-    # Format prompt
-    prompt = TOPIC_ANALYSIS_PROMPT.format(
+
+    field_info = f"in the field of {request.field.value}"
+    intersection_info = intersection_format = ""
+    if request.cross_fields:
+        fields = ", ".join(_field_name(f) for f in request.cross_fields)
+        field_info = CROSS_FIELD_INFO.format(fields=fields)
+        intersection_info = INTERSECTION_GAPS_INFO.format(fields=fields)
+        intersection_format = INTERSECTION_GAPS_FORMAT
+
+    return TOPIC_ANALYSIS_PROMPT.format(
         topic=request.topic,
-        field=request.field.value,
-        papers_info=papers_info
+        field_info=field_info,
+        papers_info=papers_info,
+        language_info=_language_info(request.language, request.translate_output),
+        surveys_info=SURVEYS_INFO if mark_surveys else "",
+        intersection_info=intersection_info,
+        intersection_format=intersection_format
+    )
+
+
+def _field_name(field: FieldEnum) -> str:
+    """Return a field's name as written in prompts and searches"""
+    return field.value.replace("_", " ")
+
+
+# Titles of survey and review papers, such as "A Survey of Graph Neural
+# Networks" or "Sleep and Memory: A Review"
+_SURVEY_TITLE = re.compile(r"\b(survey|review|overview|meta-analysis|tutorial|state of the art)\b", re.IGNORECASE)
+# Abstracts introducing a survey, such as "This survey covers..."
+_SURVEY_ABSTRACT = re.compile(
+    r"\b(this|our|the present) (survey|review|overview|meta-analysis)\b"
+    r"|\b(this|the present) (paper|article) (surveys|reviews)\b",
+    re.IGNORECASE
+)
+
+
+def _is_survey(paper: Dict[str, Any]) -> bool:
+    """Tell whether a paper looks like a survey of other work rather than a primary study"""
+    return bool(_SURVEY_TITLE.search(paper.get("title") or "") or _SURVEY_ABSTRACT.search(paper.get("abstract") or ""))
+
+
+async def _search_papers(query: str, n: int, surveys: SurveyHandling) -> List[Dict[str, Any]]:
+    """Search up to n papers; when excluding surveys, twice as many are
+    searched to make up for those left out"""
+    if surveys != SurveyHandling.EXCLUDE:
+        return await fetch_papers_by_topic(query, max_results=n)
+    papers = await fetch_papers_by_topic(query, max_results=2 * n)
+    return [p for p in papers if not _is_survey(p)][:n]
+
+
+async def _fetch_topic_papers(request: TopicRequest) -> List[Dict[str, Any]]:
+    """Fetch the papers of a topic analysis.
+
+    A cross-field request shares max_papers out between its fields, searching
+    the topic in each so that every field's literature is represented.
+    Papers found by more than one search are kept once.
+    """
+    if not request.cross_fields:
+        return await _search_papers(request.topic, request.max_papers, request.surveys)
+
+    papers: List[Dict[str, Any]] = []
+    seen = set()
+    share, extra = divmod(request.max_papers, len(request.cross_fields))
+    for i, field in enumerate(request.cross_fields):
+        # Earlier fields get the papers left over by an uneven split
+        n = share + (1 if i < extra else 0)
+        if n == 0:
+            continue
+        query = request.topic
+        if field != FieldEnum.GENERAL:
+            query += " " + _field_name(field)
+        for paper in await _search_papers(query, n, request.surveys):
+            key = (paper.get("url") or paper.get("title") or "").lower()
+            if key not in seen:
+                seen.add(key)
+                papers.append(paper)
+    return papers
+
+
+# Rules of thumb for cost estimates, shared with the Go server: a token is
+# about four characters of English, the model writes a few hundred tokens of
+# summary plus the gaps of each paper, and generates them at about 40 tokens
+# a second
+CHARS_PER_TOKEN = 4
+OUTPUT_TOKENS_BASE = 400
+OUTPUT_TOKENS_PER_PAPER = 150
+TOKENS_PER_SECOND = 40
+
+
+async def estimate_topic_cost(request: TopicRequest) -> Dict[str, Any]:
+    """Estimate what analyzing a topic would cost, without calling the LLM.
+
+    The papers are searched for, so that the prompt the analysis would send
+    can be measured, and the search time is part of the expected duration.
+    """
+    logger.info(f"Estimating cost of topic: {request.topic}")
+    start_time = time.time()
+    settings = get_settings()
+    papers = await _fetch_topic_papers(request)
+    model = request.model or settings.openai_model
+    estimate = {
+        "model": model,
+        "papers": len(papers),
+        "llm_calls": 0,
+        "input_tokens": 0,
+        "output_tokens": 0,
+        "cost_usd": None,
+    }
+    if papers:
+        _price(estimate, _topic_prompt(request, papers))
+    estimate["duration_seconds"] = round(
+        time.time() - start_time + estimate["output_tokens"] / TOKENS_PER_SECOND, 2
     )
+    return estimate
+
+
+def estimate_abstract_cost(request: AnalyzeRequest) -> Dict[str, Any]:
+    """Estimate what analyzing an abstract would cost, without calling the LLM"""
+    estimate = {"model": request.model or get_settings().openai_model, "papers": 1, "cost_usd": None}
+    _price(estimate, _abstract_prompt(request))
+    estimate["duration_seconds"] = round(estimate["output_tokens"] / TOKENS_PER_SECOND, 2)
+    return estimate
+
+
+def _price(estimate: Dict[str, Any], prompt: str) -> None:
+    """Set the tokens of an analysis of the estimate's papers that sends prompt
+    in a single LLM call, and their cost if the model's price is known"""
+    estimate["llm_calls"] = 1
+    estimate["input_tokens"] = -(-len(prompt) // CHARS_PER_TOKEN)
+    estimate["output_tokens"] = OUTPUT_TOKENS_BASE + OUTPUT_TOKENS_PER_PAPER * estimate["papers"]
+    price = get_settings().model_pricing.get(estimate["model"])
+    if price:
+        cost = (estimate["input_tokens"] * price["input"] + estimate["output_tokens"] * price["output"]) / 1e6
+        estimate["cost_usd"] = round(cost, 4)
+
+
+async def analyze_topic(request: TopicRequest) -> Dict[str, Any]:
+    """Analyze multiple papers for a given topic"""
+    logger.info(f"Analyzing topic: {request.topic}")
+    
+    # Fetch papers from arXiv
+    papers = await _fetch_topic_papers(request)
+    
+    if not papers:
+        logger.warning(f"No papers found for topic: {request.topic}")
+        return _no_papers_response(request.topic)
     
     # Get analysis from LLM
-    result = await llm_service.analyze_with_prompt(prompt)
+    result = await llm_service.analyze_with_prompt(
+        _topic_prompt(request, papers), request.model, request.temperature
+    )
     
     # Add metadata
     result["topic"] = request.topic
     result["papers_analyzed"] = len(papers)
+    result["common_gaps"] = _filter_gaps(result.get("common_gaps", []), request.min_confidence)[:request.max_gaps]
+    if request.cross_fields:
+        result["intersection_gaps"] = _filter_gaps(
+            result.get("intersection_gaps") or [], request.min_confidence
+        )[:request.max_gaps]
+    else:
+        result.pop("intersection_gaps", None)
     
     # Enrich individual results with paper metadata
     if "individual_results" in result:
@@ -87,11 +1282,117 @@ Abstract: {paper.get('abstract', 'No abstract available')[:1000]}...
                 individual_result["authors"] = papers[i].get("authors")
                 individual_result["abstract"] = papers[i].get("abstract", "")[:500]
                 individual_result["url"] = papers[i].get("url")
+            individual_result["gaps"] = _filter_gaps(
+                individual_result.get("gaps", []), request.min_confidence
+            )[:request.max_gaps]
     
     logger.info(f"Topic analysis completed for {len(papers)} papers")
     return result
 
 
+async def analyze_topic_stream(request: TopicRequest, progress: bool = False) -> AsyncIterator[Tuple[str, Dict[str, Any]]]:
+    """Analyze a topic paper by paper.
+
+    Yields ("result", individual result) for each paper as soon as its
+    analysis completes and finally ("summary", topic response) with the
+    common gaps and research directions, without individual results. Each
+    paper gets its own LLM call, besides the one for the topic as a whole, so
+    results arrive early at the cost of more calls than analyze_topic.
+
+    With progress set, ("progress", paper progress) events also report when
+    each paper was fetched, is being analyzed, and is done or failed.
+    """
+    logger.info(f"Streaming analysis of topic: {request.topic}")
+
+    papers = await _fetch_topic_papers(request)
+    if not papers:
+        logger.warning(f"No papers found for topic: {request.topic}")
+        yield "summary", _no_papers_response(request.topic)
+        return
+
+    def paper_progress(index: int, stage: str, result: Optional[Dict[str, Any]] = None) -> Dict[str, Any]:
+        return {
+            "paper_title": papers[index].get("title", "Unknown"),
+            "index": index,
+            "total": len(papers),
+            "stage": stage,
+            "result": result
+        }
+
+    if progress:
+        for index in range(len(papers)):
+            yield "progress", paper_progress(index, "fetched")
+
+    summary_task = asyncio.create_task(llm_service.analyze_with_prompt(
+        _topic_prompt(request, papers), request.model, request.temperature
+    ))
+    semaphore = asyncio.Semaphore(BATCH_CONCURRENCY)
+    # Papers report (index, stage, result) here as their analysis advances
+    updates: asyncio.Queue = asyncio.Queue()
+
+    async def analyze_paper(index: int, paper: Dict[str, Any]) -> None:
+        async with semaphore:
+            await updates.put((index, "analyzing", None))
+            try:
+                result = await analyze_text(AnalyzeRequest(
+                    title=paper.get("title") or "Unknown",
+                    abstract=paper.get("abstract") or "No abstract available",
+                    field=request.field,
+                    authors=paper.get("authors"),
+                    model=request.model,
+                    temperature=request.temperature,
+                    # The papers are in English, but the analysis is in the
+                    # caller's language if asked to
+                    language=request.language,
+                    translate_output=request.translate_output,
+                    min_confidence=request.min_confidence,
+                    max_gaps=request.max_gaps
+                ))
+            except Exception as e:
+                logger.error(f"Error analyzing paper {paper.get('title')}: {str(e)}")
+                await updates.put((index, "failed", None))
+                return
+            await updates.put((index, "done", {
+                "paper_title": paper.get("title", "Unknown"),
+                "authors": paper.get("authors"),
+                "abstract": paper.get("abstract", "")[:500],
+                "gaps": result.get("gaps", []),
+                "url": paper.get("url"),
+                "year": _published_year(paper)
+            }))
+
+    tasks = [asyncio.create_task(analyze_paper(i, p)) for i, p in enumerate(papers)]
+    try:
+        remaining = len(papers)
+        while remaining:
+            index, stage, result = await updates.get()
+            if stage != "analyzing":
+                remaining -= 1
+            if stage == "done":
+                yield "result", result
+            if progress:
+                yield "progress", paper_progress(index, stage, result)
+        summary = await summary_task
+    finally:
+        summary_task.cancel()
+        for task in tasks:
+            task.cancel()
+
+    logger.info(f"Streaming topic analysis completed for {len(papers)} papers")
+    result = {
+        "topic": request.topic,
+        "papers_analyzed": len(papers),
+        "common_gaps": _filter_gaps(summary.get("common_gaps", []), request.min_confidence)[:request.max_gaps],
+        "individual_results": [],
+        "suggested_research_directions": summary.get("suggested_research_directions", [])
+    }
+    if request.cross_fields:
+        result["intersection_gaps"] = _filter_gaps(
+            summary.get("intersection_gaps") or [], request.min_confidence
+        )[:request.max_gaps]
+    yield "summary", result
+
+
 async def validate_analysis_service() -> bool:
     """Validate that the analysis service is working"""
     try:
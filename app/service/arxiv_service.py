@@ -3,7 +3,7 @@
 import asyncio
 import aiohttp
 import xml.etree.ElementTree as ET
-from typing import List, Dict, Any, Optional
+from typing import List, Dict, Any, Optional, Tuple
 from urllib.parse import quote
 from app.core.config import get_settings
 from app.utils.logger import get_logger
@@ -22,10 +22,13 @@ class ArXivService:
         self, 
         query: str, 
         max_results: int = 10,
-        sort_by: str = "relevance"
+        sort_by: str = "relevance",
+        years: Optional[Tuple[int, int]] = None
     ) -> List[Dict[str, Any]]:
-        """Search for papers on arXiv"""
+        """Search for papers on arXiv, submitted in an inclusive range of years if given"""
         try:
+            if years:
+                query = f"{query} AND submittedDate:[{years[0]:04d}01010000 TO {years[1]:04d}12312359]"
             # Encode query
             encoded_query = quote(query)
             url = f"{self.base_url}?search_query=all:{encoded_query}&start=0&max_results={max_results}&sortBy={sort_by}"
@@ -133,6 +136,23 @@ class ArXivService:
             logger.error(f"Error fetching paper {arxiv_id}: {str(e)}")
             return None
 
+    async def download_pdf(self, arxiv_id: str) -> Optional[bytes]:
+        """Download the PDF of a paper by arXiv ID"""
+        try:
+            url = f"{self.settings.arxiv_pdf_url}/{arxiv_id}"
+
+            async with aiohttp.ClientSession() as session:
+                async with session.get(url) as response:
+                    if response.status != 200:
+                        logger.error(f"arXiv returned status {response.status} for PDF {arxiv_id}")
+                        return None
+
+                    return await response.read()
+
+        except Exception as e:
+            logger.error(f"Error downloading PDF {arxiv_id}: {str(e)}")
+            return None
+
 
 # Global instance
 arxiv_service = ArXivService()
@@ -140,10 +160,11 @@ arxiv_service = ArXivService()
 
 async def fetch_papers_by_topic(
     topic: str, 
-    max_results: int = 10
+    max_results: int = 10,
+    years: Optional[Tuple[int, int]] = None
 ) -> List[Dict[str, Any]]:
-    """Fetch papers by topic (convenience function)"""
-    return await arxiv_service.search_papers(topic, max_results)
+    """Fetch papers by topic, submitted in an inclusive range of years if given (convenience function)"""
+    return await arxiv_service.search_papers(topic, max_results, years=years)
 
 
 async def fetch_recent_papers_by_field(
@@ -0,0 +1,72 @@
+"""CrossRef service for looking up papers by DOI"""
+
+import html
+import re
+import aiohttp
+from typing import Dict, Any, Optional
+from urllib.parse import quote
+from app.core.config import get_settings
+from app.utils.logger import get_logger
+
+logger = get_logger(__name__)
+
+# CrossRef abstracts are JATS XML, often headed by an "Abstract" title
+_JATS_TITLE = re.compile(r"<jats:title>.*?</jats:title>", re.DOTALL)
+_TAG = re.compile(r"<[^>]+>")
+_SPACE_BEFORE_PUNCTUATION = re.compile(r"\s+([.,;:!?)])")
+
+
+class CrossRefService:
+    """Service for fetching paper metadata from CrossRef"""
+
+    def __init__(self):
+        self.settings = get_settings()
+        self.base_url = self.settings.crossref_base_url
+
+    async def get_work(self, doi: str) -> Optional[Dict[str, Any]]:
+        """Get the metadata of the paper with a DOI, or None if CrossRef
+        doesn't know it. Other failures raise."""
+        url = f"{self.base_url}/{quote(doi, safe='/')}"
+        params = {}
+        if self.settings.crossref_mailto:
+            params["mailto"] = self.settings.crossref_mailto
+
+        logger.info(f"Fetching DOI from CrossRef: {doi}")
+        async with aiohttp.ClientSession() as session:
+            async with session.get(url, params=params) as response:
+                if response.status == 404:
+                    return None
+                if response.status != 200:
+                    raise RuntimeError(f"CrossRef API returned status {response.status}")
+                data = await response.json()
+
+        return self._parse_work(data["message"])
+
+    def _parse_work(self, work: Dict[str, Any]) -> Dict[str, Any]:
+        """Parse a CrossRef work into a paper"""
+        authors = []
+        for author in work.get("author", []):
+            name = " ".join(part for part in (author.get("given"), author.get("family")) if part)
+            if name:
+                authors.append(name)
+        titles = work.get("title") or [""]
+        # Dates are given as [[year, month, day]], as far as they're known
+        date_parts = (work.get("issued") or {}).get("date-parts") or [[None]]
+        return {
+            "title": " ".join(titles[0].split()),
+            "abstract": strip_jats(work.get("abstract", "")),
+            "authors": authors,
+            "url": work.get("URL"),
+            "year": date_parts[0][0] if date_parts[0] else None,
+        }
+
+
+def strip_jats(text: str) -> str:
+    """Return the plain text of a JATS XML fragment"""
+    text = _TAG.sub(" ", _JATS_TITLE.sub("", text))
+    text = " ".join(html.unescape(text).split())
+    return _SPACE_BEFORE_PUNCTUATION.sub(r"\1", text)
+
+
+# Global instance
+crossref_service = CrossRefService()
@@ -0,0 +1,110 @@
+"""Funding service for reading open funding calls from agency feeds"""
+
+import json
+import aiohttp
+import xml.etree.ElementTree as ET
+from typing import Dict, Any, List, Optional
+from urllib.parse import urlparse
+from app.core.config import get_settings
+from app.service.crossref_service import strip_jats
+from app.utils.logger import get_logger
+
+logger = get_logger(__name__)
+
+# Asks the EU Funding & Tenders portal's search API for the calls for
+# proposals that are open or forthcoming, leaving out its closed calls,
+# tenders and news
+EU_PORTAL_QUERY = {"bool": {"must": [
+    {"terms": {"type": ["1", "2", "8"]}},
+    {"terms": {"status": ["31094501", "31094502"]}}
+]}}
+
+# Where the portal shows a call, by its lowercased identifier
+EU_TOPIC_URL = "https://ec.europa.eu/info/funding-tenders/opportunities/portal/screen/opportunities/topic-details/"
+
+
+def is_eu_portal(url: str) -> bool:
+    """Tell whether a feed URL is that of the EU Funding & Tenders portal's search API"""
+    return "/search-api/" in urlparse(url).path
+
+
+class FundingService:
+    """Service for listing open funding calls"""
+
+    def __init__(self):
+        self.settings = get_settings()
+        self.feeds = self.settings.funding_feeds
+
+    async def open_calls(self) -> List[Dict[str, Any]]:
+        """Get the calls of every feed. Agencies' feeds go down independently,
+        so a feed that fails is skipped unless they all do."""
+        calls = []
+        errors = []
+        async with aiohttp.ClientSession() as session:
+            for agency, url in self.feeds.items():
+                try:
+                    if is_eu_portal(url):
+                        calls.extend(await self._read_eu_portal(session, agency, url))
+                        continue
+                    async with session.get(url) as response:
+                        if response.status != 200:
+                            raise RuntimeError(f"{agency} funding feed returned status {response.status}")
+                        xml_content = await response.text()
+                    calls.extend(self._parse_feed(agency, xml_content))
+                except Exception as e:
+                    logger.warning(f"Error reading {agency} funding feed: {str(e)}")
+                    errors.append(e)
+        if self.feeds and len(errors) == len(self.feeds):
+            raise RuntimeError(f"Every funding feed failed: {errors[0]}")
+        return calls
+
+    async def _read_eu_portal(self, session: aiohttp.ClientSession, agency: str, url: str) -> List[Dict[str, Any]]:
+        """Search the EU Funding & Tenders portal for its open calls, with a
+        multipart POST as the portal's own pages do"""
+        form = aiohttp.FormData()
+        form.add_field("query", json.dumps(EU_PORTAL_QUERY), filename="blob", content_type="application/json")
+        form.add_field("languages", json.dumps(["en"]), filename="blob", content_type="application/json")
+        async with session.post(url, data=form) as response:
+            if response.status != 200:
+                raise RuntimeError(f"{agency} funding feed returned status {response.status}")
+            data = await response.json(content_type=None)
+        return self._parse_eu_portal(agency, data)
+
+    def _parse_eu_portal(self, agency: str, data: Dict[str, Any]) -> List[Dict[str, Any]]:
+        """Parse the portal's search results into funding calls"""
+        def first(values: Optional[List[str]]) -> str:
+            return values[0] if values else ""
+
+        calls = []
+        for result in data.get("results") or []:
+            metadata = result.get("metadata") or {}
+            title = " ".join((first(metadata.get("title")) or result.get("summary") or "").split())
+            if not title:
+                continue
+            identifier = first(metadata.get("identifier")).strip()
+            calls.append({
+                "agency": agency,
+                "title": title,
+                "url": EU_TOPIC_URL + identifier.lower() if identifier else (result.get("url") or "").strip(),
+                "summary": strip_jats(first(metadata.get("descriptionByte"))) or None,
+            })
+        return calls
+
+    def _parse_feed(self, agency: str, xml_content: str) -> List[Dict[str, Any]]:
+        """Parse an RSS feed into funding calls"""
+        calls = []
+        for item in ET.fromstring(xml_content).findall("channel/item"):
+            title = " ".join((item.findtext("title") or "").split())
+            if not title:
+                continue
+            calls.append({
+                "agency": agency,
+                "title": title,
+                "url": (item.findtext("link") or "").strip(),
+                "summary": strip_jats(item.findtext("description") or "") or None,
+            })
+        return calls
+
+
+# Global instance
+funding_service = FundingService()
@@ -0,0 +1,98 @@
+"""Kept results of abstract and topic analyses"""
+
+import copy
+import uuid
+from collections import OrderedDict
+from datetime import datetime, timezone
+from typing import Any, Dict, List, Optional
+from app.utils.logger import get_logger
+
+logger = get_logger(__name__)
+
+ABSTRACT = "abstract"
+TOPIC = "topic"
+
+SUMMARY_KEYS = ("analysis_id", "kind", "title", "field", "created_at")
+
+
+class AnalysisHistory:
+    """Keeps analyses so their results can be fetched again without re-running them.
+
+    Analyses are kept in memory, so they are lost when the service restarts,
+    and the oldest are forgotten once there are more than max_entries.
+    """
+
+    def __init__(self, max_entries: int = 1000):
+        self.max_entries = max_entries
+        self._analyses: "OrderedDict[str, Dict[str, Any]]" = OrderedDict()
+        # Where each analysis's result is also held: the idempotency key of
+        # its request and the job it ran in, if any
+        self._origins: Dict[str, Dict[str, Optional[str]]] = {}
+
+    def add_abstract(self, title: str, field: str, result: Dict[str, Any],
+                     idempotency_key: Optional[str] = None) -> str:
+        """Keep the analysis of an abstract and return its ID"""
+        return self._add(ABSTRACT, title, field, idempotency_key, None, result=result)
+
+    def add_topic(self, topic: str, field: str, result: Dict[str, Any],
+                  idempotency_key: Optional[str] = None, job_id: Optional[str] = None) -> str:
+        """Keep the analysis of a topic, with all its individual results, and return its ID"""
+        result = dict(result, next_cursor=None)
+        return self._add(TOPIC, topic, field, idempotency_key, job_id, topic_result=result)
+
+    def _add(self, kind: str, title: str, field: str, idempotency_key: Optional[str],
+             job_id: Optional[str], **results) -> str:
+        analysis_id = str(uuid.uuid4())
+        while len(self._analyses) >= self.max_entries:
+            oldest, _ = self._analyses.popitem(last=False)
+            self._origins.pop(oldest, None)
+        self._origins[analysis_id] = {"idempotency_key": idempotency_key, "job_id": job_id}
+        self._analyses[analysis_id] = {
+            "analysis_id": analysis_id,
+            "kind": kind,
+            "title": title,
+            "field": field,
+            "created_at": datetime.now(timezone.utc).isoformat(),
+            # Callers go on to change the results, e.g. to paginate them
+            **copy.deepcopy(results),
+        }
+        return analysis_id
+
+    def list(self, topic: Optional[str] = None, field: Optional[str] = None,
+             since: Optional[datetime] = None, until: Optional[datetime] = None,
+             limit: int = 50) -> List[Dict[str, Any]]:
+        """Return the summaries of the analyses matching every filter given, newest first"""
+        summaries = []
+        for analysis in reversed(self._analyses.values()):
+            if len(summaries) >= limit:
+                break
+            created_at = datetime.fromisoformat(analysis["created_at"])
+            if topic and topic.lower() not in analysis["title"].lower():
+                continue
+            if field and analysis["field"] != field:
+                continue
+            if since and created_at < since:
+                continue
+            if until and created_at >= until:
+                continue
+            summaries.append({k: analysis[k] for k in SUMMARY_KEYS})
+        return summaries
+
+    def get(self, analysis_id: str) -> Optional[Dict[str, Any]]:
+        """Return a kept analysis with its result, or None if it is unknown"""
+        analysis = self._analyses.get(analysis_id)
+        return dict(analysis) if analysis is not None else None
+
+    def delete(self, analysis_id: str) -> Optional[Dict[str, Optional[str]]]:
+        """Forget a kept analysis and its result.
+
+        Returns the idempotency key and job ID its result is also held under,
+        for the caller to forget too, or None if the analysis wasn't kept.
+        """
+        if self._analyses.pop(analysis_id, None) is None:
+            return None
+        return self._origins.pop(analysis_id)
+
+
+# Global instance
+analysis_history = AnalysisHistory()
@@ -0,0 +1,144 @@
+"""Background jobs for long-running analyses"""
+
+import asyncio
+import time
+import uuid
+from collections import OrderedDict
+from contextvars import ContextVar
+from datetime import datetime, timezone
+from typing import Any, Awaitable, Callable, Dict, Optional
+from app.utils.logger import get_logger
+
+logger = get_logger(__name__)
+
+PENDING = "pending"
+RUNNING = "running"
+SUCCEEDED = "succeeded"
+FAILED = "failed"
+CANCELLED = "cancelled"
+
+# The ID of the job the current task runs, if any
+current_job_id: ContextVar[Optional[str]] = ContextVar("current_job_id", default=None)
+
+
+def _now() -> str:
+    return datetime.now(timezone.utc).isoformat()
+
+
+class JobStoreFullError(Exception):
+    """Raised when a job is submitted while max_entries jobs are running"""
+
+
+class JobStore:
+    """Runs analyses in the background and keeps their results.
+
+    Jobs are kept in memory, so they are lost when the service restarts.
+    Finished jobs are forgotten after ttl_seconds, and the oldest finished
+    jobs once there are more than max_entries. Running jobs are never
+    forgotten, so submissions are refused while max_entries are running.
+    """
+
+    def __init__(self, ttl_seconds: int = 3600, max_entries: int = 1000):
+        self.ttl_seconds = ttl_seconds
+        self.max_entries = max_entries
+        self._jobs: "OrderedDict[str, Dict[str, Any]]" = OrderedDict()
+        self._finished_at: Dict[str, float] = {}
+        self._tasks: Dict[str, asyncio.Task] = {}
+
+    def submit(self, func: Callable[[], Awaitable[Dict[str, Any]]],
+               on_done: Optional[Callable[[Dict[str, Any]], Awaitable[Any]]] = None) -> Dict[str, Any]:
+        """Start func in the background and return its job.
+
+        on_done, if given, is awaited with the finished job once it has
+        succeeded, failed or been cancelled. Raises JobStoreFullError if every job kept is
+        still running.
+        """
+        self._evict()
+        if len(self._jobs) >= self.max_entries:
+            raise JobStoreFullError("too many jobs are running, try again later")
+        job_id = str(uuid.uuid4())
+        now = _now()
+        self._jobs[job_id] = {
+            "job_id": job_id,
+            "status": PENDING,
+            "created_at": now,
+            "updated_at": now,
+            "result": None,
+            "error": None,
+        }
+        self._tasks[job_id] = asyncio.create_task(self._run(job_id, func, on_done))
+        logger.info(f"Submitted job {job_id}")
+        return dict(self._jobs[job_id])
+
+    def get(self, job_id: str) -> Optional[Dict[str, Any]]:
+        """Return a snapshot of the job, or None if it is unknown"""
+        job = self._jobs.get(job_id)
+        return dict(job) if job is not None else None
+
+    def cancel(self, job_id: str) -> Optional[Dict[str, Any]]:
+        """Stop a job that is still pending or running and return a snapshot
+        of it, or None if it is unknown. Finished jobs are returned as they are.
+        """
+        task = self._tasks.get(job_id)
+        if task is not None and not task.done():
+            self._update(job_id, status=CANCELLED)
+            self._finished_at[job_id] = time.monotonic()
+            task.cancel()
+            logger.info(f"Cancelled job {job_id}")
+        return self.get(job_id)
+
+    def delete(self, job_id: str) -> bool:
+        """Forget a job, stopping it if it is still running, and return whether it was kept"""
+        kept = job_id in self._jobs
+        self._forget(job_id)
+        return kept
+
+    async def _run(self, job_id: str, func: Callable[[], Awaitable[Dict[str, Any]]],
+                   on_done: Optional[Callable[[Dict[str, Any]], Awaitable[Any]]] = None):
+        # The task runs in a copy of the context, so this is only seen by func
+        current_job_id.set(job_id)
+        self._update(job_id, status=RUNNING)
+        start_time = time.time()
+        try:
+            result = await func()
+        except asyncio.CancelledError:
+            # Cancelled by cancel, which set the status, or forgotten
+            pass
+        except Exception as e:
+            logger.error(f"Job {job_id} failed: {str(e)}")
+            self._update(job_id, status=FAILED, error="An error occurred during topic analysis.")
+        else:
+            result["processing_time"] = round(time.time() - start_time, 2)
+            self._update(job_id, status=SUCCEEDED, result=result)
+            logger.info(f"Job {job_id} succeeded")
+        finally:
+            self._finished_at[job_id] = time.monotonic()
+            self._tasks.pop(job_id, None)
+        job = self.get(job_id)
+        if on_done is not None and job is not None:
+            await on_done(job)
+
+    def _update(self, job_id: str, **fields):
+        job = self._jobs.get(job_id)
+        if job is not None:
+            job.update(fields, updated_at=_now())
+
+    def _evict(self):
+        now = time.monotonic()
+        expired = [k for k, finished in self._finished_at.items() if now - finished > self.ttl_seconds]
+        for k in expired:
+            self._forget(k)
+        finished = [k for k in self._jobs if k in self._finished_at]
+        for k in finished[:max(0, len(self._jobs) - self.max_entries + 1)]:
+            self._forget(k)
+
+    def _forget(self, job_id: str):
+        self._jobs.pop(job_id, None)
+        self._finished_at.pop(job_id, None)
+        task = self._tasks.pop(job_id, None)
+        if task is not None:
+            task.cancel()
+
+
+# Global instance
+job_store = JobStore()
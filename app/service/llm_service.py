@@ -34,16 +34,28 @@ class LLMService:
             )
         return self._client
     
-    async def analyze_with_prompt(self, prompt: str) -> Dict[str, Any]:
-        """Analyze text using LLM with given prompt"""
+    def _client_for(self, model: Optional[str], temperature: Optional[float]):
+        """Get the client, bound to a request's model and temperature if it sets them"""
+        overrides = {}
+        if model:
+            overrides["model"] = model
+        if temperature is not None:
+            overrides["temperature"] = temperature
+        return self.client.bind(**overrides) if overrides else self.client
+
+    async def analyze_with_prompt(
+        self, prompt: str, model: Optional[str] = None, temperature: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Analyze text using LLM with given prompt, optionally with another model or temperature"""
         try:
-            logger.info(f"Sending request to {self.settings.openai_model}")
+            logger.info(f"Sending request to {model or self.settings.openai_model}")
             
             # Create message
             message = HumanMessage(content=prompt)
             
             # Get response from LLM
-            response = await asyncio.to_thread(self.client.invoke, [message])
+            client = self._client_for(model, temperature)
+            response = await asyncio.to_thread(client.invoke, [message])
             
             # Parse JSON response
             response_text = response.content.strip()
@@ -0,0 +1,74 @@
+"""Cursor-based pagination of topic results"""
+
+import time
+import uuid
+from collections import OrderedDict
+from typing import Any, Dict, List, Optional, Tuple
+from app.utils.logger import get_logger
+
+logger = get_logger(__name__)
+
+Page = Tuple[List[Dict[str, Any]], Optional[str]]
+
+
+class ResultPages:
+    """Keeps the individual results of large topic analyses so they can be
+    fetched a page at a time.
+
+    A cursor names a stored result list and the offset of the next page.
+    Results are kept in memory for ttl_seconds, and the oldest are forgotten
+    once there are more than max_entries.
+    """
+
+    def __init__(self, ttl_seconds: int = 3600, max_entries: int = 1000):
+        self.ttl_seconds = ttl_seconds
+        self.max_entries = max_entries
+        self._results: "OrderedDict[str, Tuple[float, int, List[Dict[str, Any]]]]" = OrderedDict()
+
+    def paginate(self, results: List[Dict[str, Any]], page_size: int) -> Page:
+        """Return the first page of results and the cursor of the next, if any"""
+        if len(results) <= page_size:
+            return results, None
+        self._evict()
+        results_id = uuid.uuid4().hex
+        self._results[results_id] = (time.monotonic(), page_size, results)
+        return results[:page_size], f"{results_id}.{page_size}"
+
+    def page(self, cursor: str) -> Optional[Page]:
+        """Return the page a cursor points to and the cursor of the next, or
+        None if the cursor is invalid or expired"""
+        results_id, _, offset = cursor.partition(".")
+        entry = self._results.get(results_id)
+        if entry is None or not offset.isdigit():
+            return None
+        stored_at, page_size, results = entry
+        if time.monotonic() - stored_at > self.ttl_seconds:
+            return None
+        start = int(offset)
+        end = start + page_size
+        next_cursor = f"{results_id}.{end}" if end < len(results) else None
+        return results[start:end], next_cursor
+
+    def _evict(self):
+        now = time.monotonic()
+        expired = [k for k, (stored_at, _, _) in self._results.items() if now - stored_at > self.ttl_seconds]
+        for k in expired:
+            del self._results[k]
+        while len(self._results) >= self.max_entries:
+            self._results.popitem(last=False)
+
+
+def paginate_topic(result: Dict[str, Any], page_size: Optional[int]) -> Dict[str, Any]:
+    """Return a copy of a topic response holding only the first page of its
+    individual results, with the cursor of the next page"""
+    result = dict(result)
+    result["next_cursor"] = None
+    if page_size:
+        result["individual_results"], result["next_cursor"] = result_pages.paginate(
+            result.get("individual_results", []), page_size
+        )
+    return result
+
+
+# Global instance
+result_pages = ResultPages()
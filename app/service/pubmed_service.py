@@ -0,0 +1,68 @@
+"""PubMed service for looking up papers by PubMed ID"""
+
+import aiohttp
+import xml.etree.ElementTree as ET
+from typing import Dict, Any, Optional
+from app.core.config import get_settings
+from app.utils.logger import get_logger
+
+logger = get_logger(__name__)
+
+
+class PubMedService:
+    """Service for fetching paper metadata from PubMed through NCBI E-utilities"""
+
+    def __init__(self):
+        self.settings = get_settings()
+        self.base_url = self.settings.pubmed_base_url
+
+    async def get_article(self, pmid: str) -> Optional[Dict[str, Any]]:
+        """Get the metadata of the article with a PubMed ID, or None if
+        PubMed doesn't know it. Other failures raise."""
+        params = {"db": "pubmed", "id": pmid, "retmode": "xml"}
+        if self.settings.ncbi_api_key:
+            params["api_key"] = self.settings.ncbi_api_key
+
+        logger.info(f"Fetching PMID from PubMed: {pmid}")
+        async with aiohttp.ClientSession() as session:
+            async with session.get(f"{self.base_url}/efetch.fcgi", params=params) as response:
+                if response.status != 200:
+                    raise RuntimeError(f"PubMed API returned status {response.status}")
+                xml_content = await response.text()
+
+        return self._parse_article(pmid, xml_content)
+
+    def _parse_article(self, pmid: str, xml_content: str) -> Optional[Dict[str, Any]]:
+        """Parse an efetch response into a paper"""
+        article = ET.fromstring(xml_content).find("PubmedArticle/MedlineCitation/Article")
+        if article is None:
+            return None
+
+        # Structured abstracts have several labelled sections
+        sections = []
+        for text in article.findall("Abstract/AbstractText"):
+            content = " ".join("".join(text.itertext()).split())
+            label = text.get("Label")
+            sections.append(f"{label}: {content}" if label else content)
+
+        authors = []
+        for author in article.findall("AuthorList/Author"):
+            name = author.findtext("CollectiveName") or " ".join(
+                part for part in (author.findtext("ForeName"), author.findtext("LastName")) if part
+            )
+            if name:
+                authors.append(name)
+
+        title = article.find("ArticleTitle")
+        year = (article.findtext("Journal/JournalIssue/PubDate/Year") or "").strip()
+        return {
+            "title": " ".join("".join(title.itertext()).split()) if title is not None else "",
+            "abstract": " ".join(sections),
+            "authors": authors,
+            "url": f"https://pubmed.ncbi.nlm.nih.gov/{pmid}/",
+            "year": int(year) if year.isdigit() else None,
+        }
+
+
+# Global instance
+pubmed_service = PubMedService()
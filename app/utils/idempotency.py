@@ -0,0 +1,70 @@
+"""Idempotency-Key handling for POST endpoints"""
+
+import asyncio
+import time
+from collections import OrderedDict
+from typing import Any, Awaitable, Callable, Dict, Optional, Tuple
+from app.utils.logger import get_logger
+
+logger = get_logger(__name__)
+
+
+class IdempotencyCache:
+    """Remembers results of requests by Idempotency-Key.
+
+    A request repeated with the same key while the first one is still running
+    waits for that run instead of starting another analysis, and a repeat
+    after it finished gets the stored result. Failed runs are not stored so
+    that the client can retry them.
+    """
+
+    def __init__(self, ttl_seconds: int = 3600, max_entries: int = 1000):
+        self.ttl_seconds = ttl_seconds
+        self.max_entries = max_entries
+        self._entries: "OrderedDict[str, Tuple[float, asyncio.Future]]" = OrderedDict()
+
+    async def run(
+        self,
+        key: Optional[str],
+        func: Callable[[], Awaitable[Dict[str, Any]]]
+    ) -> Dict[str, Any]:
+        """Run func once per key and return its (shared) result"""
+        if not key:
+            return await func()
+
+        self._evict_expired()
+        entry = self._entries.get(key)
+        if entry is not None:
+            logger.info(f"Reusing result for idempotency key {key}")
+            return dict(await asyncio.shield(entry[1]))
+
+        future = asyncio.get_running_loop().create_future()
+        self._entries[key] = (time.monotonic(), future)
+        while len(self._entries) > self.max_entries:
+            self._entries.popitem(last=False)
+
+        try:
+            result = await func()
+        except Exception as e:
+            self._entries.pop(key, None)
+            future.set_exception(e)
+            # Nobody may be waiting on the future; don't warn about it
+            future.exception()
+            raise
+
+        future.set_result(result)
+        return dict(result)
+
+    def forget(self, key: str):
+        """Forget the result stored for key, e.g. once the analysis it holds is deleted"""
+        self._entries.pop(key, None)
+
+    def _evict_expired(self):
+        now = time.monotonic()
+        expired = [k for k, (created, _) in self._entries.items() if now - created > self.ttl_seconds]
+        for k in expired:
+            del self._entries[k]
+
+
+# Global instance
+idempotency_cache = IdempotencyCache()
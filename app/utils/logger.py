@@ -2,20 +2,33 @@
 
 import logging
 import sys
+from contextvars import ContextVar
 from app.core.config import get_settings
 
+# ID of the HTTP request being handled, set by the request ID middleware
+request_id_var: ContextVar[str] = ContextVar("request_id", default="-")
+
+
+class RequestIDFilter(logging.Filter):
+    """Add the current request ID to every log record"""
+
+    def filter(self, record: logging.LogRecord) -> bool:
+        record.request_id = request_id_var.get()
+        return True
+
 
 def setup_logging():
     """Setup application logging"""
     settings = get_settings()
     
+    handler = logging.StreamHandler(sys.stdout)
+    handler.addFilter(RequestIDFilter())
+    
     # Configure root logger
     logging.basicConfig(
         level=getattr(logging, settings.log_level.upper()),
-        format="%(asctime)s - %(name)s - %(levelname)s - %(message)s",
-        handlers=[
-            logging.StreamHandler(sys.stdout)
-        ]
+        format="%(asctime)s - %(name)s - %(levelname)s - [%(request_id)s] %(message)s",
+        handlers=[handler]
     )
     
     # Set specific logger levels
@@ -0,0 +1,106 @@
+"""Per-caller request counts and quotas"""
+
+import hashlib
+import time
+from collections import Counter
+from datetime import datetime, timezone
+from typing import Any, Dict, Iterable, Mapping, Optional
+
+from app.core.config import get_settings
+
+# Endpoints that neither count against a quota nor are refused once it is used
+# up, so callers can always check on themselves
+UNCOUNTED_ENDPOINTS = {"GET /usage", "GET /health"}
+
+
+def _timestamp(seconds: float) -> str:
+    return datetime.fromtimestamp(seconds, timezone.utc).isoformat()
+
+
+def _hash_key(key: str) -> str:
+    """Hash an API key or bearer token, so it isn't kept in memory"""
+    return hashlib.sha256(key.encode()).hexdigest()
+
+
+class UsageTracker:
+    """Counts requests by caller and endpoint in the current quota window.
+
+    With a quota each caller may make limit requests per window seconds.
+    Windows are aligned to multiples of window, e.g. to the hour, and counts
+    are forgotten each window whether or not there is a quota (limit 0).
+    Callers are told apart by address, or by their API key if it is one of
+    keys; any other key is ignored, so that a caller can't get a fresh quota
+    by making one up.
+    """
+
+    def __init__(self, limit: int = 0, window: int = 3600, keys: Iterable[str] = ()):
+        self.limit = limit
+        self.window = window
+        self._keys = {_hash_key(key) for key in keys}
+        self._since = time.time()
+        self._callers: Dict[str, Counter] = {}
+
+    @property
+    def has_quota(self) -> bool:
+        return self.limit > 0 and self.window > 0
+
+    def _roll(self, now: float):
+        """Start a new window, forgetting all counts, once now is outside the current one"""
+        if self.window <= 0:
+            return
+        start = now - now % self.window
+        if start != self._since:
+            self._since = start
+            self._callers.clear()
+
+    def record(self, caller: str, endpoint: str, now: Optional[float] = None) -> Optional[float]:
+        """Count a request, or return the seconds until the quota resets if it is used up"""
+        now = time.time() if now is None else now
+        self._roll(now)
+        counts = self._callers.setdefault(caller, Counter())
+        if self.has_quota and sum(counts.values()) >= self.limit:
+            return self._since + self.window - now
+        counts[endpoint] += 1
+        return None
+
+    def report(self, caller: str, now: Optional[float] = None) -> Dict[str, Any]:
+        """Return the usage of caller, shaped like UsageResponse"""
+        now = time.time() if now is None else now
+        self._roll(now)
+        counts = self._callers.get(caller, Counter())
+        requests = sum(counts.values())
+        usage = {
+            'requests': requests,
+            'since': _timestamp(self._since),
+            'endpoints': [
+                {'endpoint': endpoint, 'requests': n}
+                for endpoint, n in sorted(counts.items(), key=lambda item: (-item[1], item[0]))
+            ],
+        }
+        if self.has_quota:
+            usage['quota'] = {
+                'limit': self.limit,
+                'remaining': max(self.limit - requests, 0),
+                'reset_at': _timestamp(self._since + self.window),
+            }
+        return usage
+
+
+    def caller_key(self, headers: Mapping[str, str], host: Optional[str]) -> str:
+        """Identify a caller by API key or bearer token if it is a known key, or else by address"""
+        credential = headers.get("x-api-key") or ""
+        authorization = headers.get("authorization") or ""
+        if not credential and authorization.startswith("Bearer "):
+            credential = authorization[len("Bearer "):]
+        if credential and _hash_key(credential) in self._keys:
+            return "key:" + _hash_key(credential)
+        return f"addr:{host or 'unknown'}"
+
+
+# Global instance
+_settings = get_settings()
+usage_tracker = UsageTracker(
+    limit=_settings.quota_limit,
+    window=_settings.quota_window,
+    keys=[key.strip() for key in (_settings.quota_keys or "").split(",") if key.strip()],
+)
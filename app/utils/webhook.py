@@ -0,0 +1,118 @@
+"""Signed callbacks of finished jobs
+
+Callbacks carry an X-Gapfinder-Signature header of the form
+"t=<unix time>,v1=<hex HMAC-SHA256 of the time, a dot and the body>", made
+with the webhook secret, so receivers can tell them from forgeries. The Go
+package gapfinder/webhook verifies them.
+
+Unless allowed, callbacks to localhost and to loopback, private and
+link-local addresses are refused, so that callers can't use the service to
+reach its own network.
+"""
+
+import asyncio
+import hashlib
+import hmac
+import ipaddress
+import json
+import socket
+import time
+from typing import Any, Dict, Optional
+from urllib.parse import urlparse
+
+import aiohttp
+from aiohttp.abc import AbstractResolver
+
+from app.utils.logger import get_logger
+
+logger = get_logger(__name__)
+
+SIGNATURE_HEADER = "X-Gapfinder-Signature"
+
+# A callback is sent up to ATTEMPTS times, waiting BACKOFF seconds, then twice
+# that, and so on between attempts
+ATTEMPTS = 4
+BACKOFF = 2.0
+
+
+def sign(secret: str, body: bytes, timestamp: Optional[int] = None) -> str:
+    """Return the signature header value for body sent at timestamp"""
+    timestamp = int(time.time()) if timestamp is None else timestamp
+    mac = hmac.new(secret.encode(), f"{timestamp}.".encode() + body, hashlib.sha256)
+    return f"t={timestamp},v1={mac.hexdigest()}"
+
+
+def _is_private(address: str) -> bool:
+    """Tell whether an IP address is one callbacks mustn't reach"""
+    try:
+        ip = ipaddress.ip_address(address.split("%")[0])
+    except ValueError:
+        return False
+    if isinstance(ip, ipaddress.IPv6Address) and ip.ipv4_mapped:
+        ip = ip.ipv4_mapped
+    return ip.is_loopback or ip.is_private or ip.is_link_local or ip.is_unspecified
+
+
+def check_callback_url(url: str, secret: Optional[str], allow_private: bool = False) -> Optional[str]:
+    """Return why jobs can't be called back at url, or None if they can.
+
+    The addresses of host names can change, so deliver checks them again as
+    it connects.
+    """
+    if not secret:
+        return "callbacks are not enabled on this server"
+    parsed = urlparse(url)
+    if parsed.scheme not in ("http", "https") or not parsed.netloc:
+        return "must be an absolute http or https URL"
+    host = (parsed.hostname or "").rstrip(".").lower()
+    if not allow_private and (host == "localhost" or host.endswith(".localhost") or _is_private(host)):
+        return "must not be a loopback or private address"
+    return None
+
+
+class _PublicResolver(AbstractResolver):
+    """Resolves host names, refusing those with a loopback or private address"""
+
+    def __init__(self):
+        self._resolver = aiohttp.DefaultResolver()
+
+    async def resolve(self, host: str, port: int = 0, family: int = socket.AF_INET):
+        addresses = await self._resolver.resolve(host, port, family)
+        if any(_is_private(a["host"]) for a in addresses):
+            raise OSError(f"{host} has a loopback or private address")
+        return addresses
+
+    async def close(self):
+        await self._resolver.close()
+
+
+async def deliver(url: str, job: Dict[str, Any], secret: str, request_id: Optional[str] = None,
+                  backoff: float = BACKOFF, allow_private: bool = False) -> bool:
+    """POST a finished job to url, retrying failed attempts.
+
+    Each attempt is signed anew, so that retries aren't taken for replays.
+    Unless allow_private, host names resolving to loopback or private
+    addresses aren't connected to. Returns whether the callback was delivered.
+    """
+    body = json.dumps(job).encode()
+    for attempt in range(1, ATTEMPTS + 1):
+        headers = {"Content-Type": "application/json", SIGNATURE_HEADER: sign(secret, body)}
+        if request_id:
+            headers["X-Request-ID"] = request_id
+        try:
+            connector = None if allow_private else aiohttp.TCPConnector(resolver=_PublicResolver())
+            async with aiohttp.ClientSession(timeout=aiohttp.ClientTimeout(total=10), connector=connector) as session:
+                async with session.post(url, data=body, headers=headers) as response:
+                    if 200 <= response.status < 300:
+                        logger.info(f"Sent callback of job {job['job_id']} (attempt {attempt})")
+                        return True
+                    error = f"callback returned status {response.status}"
+        except Exception as e:
+            error = str(e)
+        if attempt == ATTEMPTS:
+            logger.error(f"Callback of job {job['job_id']} failed after {attempt} attempts: {error}")
+            return False
+        logger.warning(f"Callback of job {job['job_id']} failed (attempt {attempt}): {error}")
+        await asyncio.sleep(backoff)
+        backoff *= 2
+    return False
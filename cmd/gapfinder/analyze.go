@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func runAnalyze(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("analyze", "[--title TITLE] (--abstract TEXT | --abstract-file FILE | FILE | -) [flags]")
+	var req types.AnalyzeRequest
+	var abstractFile, authors, keywords string
+	var requireGap float64
+	var dryRun bool
+	fs.StringVar(&req.Title, "title", "", "title of the paper (default the first line of the abstract's file, if a blank line follows it)")
+	fs.StringVar(&req.Abstract, "abstract", "", "abstract to analyze")
+	fs.StringVar(&abstractFile, "abstract-file", "", "read the abstract from `file`, or from standard input if it is -")
+	a.fieldFlag(fs, &req.Field)
+	fs.StringVar(&authors, "authors", "", "comma-separated list of authors")
+	fs.StringVar(&keywords, "keywords", "", "comma-separated list of keywords")
+	modelFlags(fs, &req.Model, &req.Temperature)
+	languageFlags(fs, &req.Language, &req.TranslateOutput)
+	fs.Float64Var(&req.MinConfidence, "min-confidence", 0, "leave out gaps with a lower confidence score, from 0 to 1")
+	fs.IntVar(&req.MaxGaps, "max-gaps", 0, "return at most this many gaps (default no limit)")
+	fs.IntVar(&req.MaxHypotheses, "max-hypotheses", 0, "return at most this many hypotheses (default no limit)")
+	fs.IntVar(&req.MaxFindings, "max-findings", 0, "return at most this many key findings (default no limit)")
+	fs.StringVar(&req.Instructions, "instructions", "", "additional instructions, such as \"focus on reproducibility gaps\"")
+	dryRunFlag(fs, &dryRun)
+	fs.Float64Var(&requireGap, "require-gap", 0, fmt.Sprintf("exit with status %d unless a gap has at least this confidence score, from 0 to 1 (default never)", exitNoGaps))
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	// The abstract's file may also be given as an argument, so that
+	// "gapfinder analyze -" reads it from a pipe
+	switch {
+	case fs.NArg() > 1:
+		return usageError(fs, fmt.Errorf("unexpected arguments: %q", fs.Args()[1:]))
+	case fs.NArg() == 1 && abstractFile != "":
+		return usageError(fs, errors.New("--abstract-file and a file argument are mutually exclusive"))
+	case fs.NArg() == 1:
+		abstractFile = fs.Arg(0)
+	}
+	if requireGap < 0 || requireGap > 1 {
+		return usageError(fs, errors.New("--require-gap must be between 0 and 1"))
+	}
+
+	if abstractFile != "" {
+		if req.Abstract != "" {
+			return usageError(fs, errors.New("--abstract and --abstract-file are mutually exclusive"))
+		}
+		var data []byte
+		var err error
+		if abstractFile == "-" {
+			if isTerminal(a.stdin) {
+				fmt.Fprintln(a.stderr, "Reading the abstract from standard input; end it with Ctrl+D")
+			}
+			data, err = io.ReadAll(a.stdin)
+		} else {
+			data, err = os.ReadFile(abstractFile)
+		}
+		if err != nil {
+			return err
+		}
+		req.Abstract = string(data)
+		if req.Title == "" {
+			if req.Title, req.Abstract, err = splitTitle(req.Abstract); err != nil {
+				return usageError(fs, err)
+			}
+		}
+	}
+	req.Authors = splitList(authors)
+	req.Keywords = splitList(keywords)
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		// The estimate checks the request, and the service its model
+		est, err := c.EstimateAnalysis(ctx, req)
+		if err != nil {
+			return err
+		}
+		return a.print(&dryRunReport{Calls: []plannedCall{a.plan(http.MethodPost, "/analyze", req)}, Estimate: est})
+	}
+	result, err := c.AnalyzeAbstract(ctx, req)
+	if err != nil {
+		return err
+	}
+	a.keep(types.AnalysisAbstract, req.Title, req.Field, result)
+	if err := a.print(result); err != nil {
+		return err
+	}
+	if requireGap > 0 && !slices.ContainsFunc(result.Gaps, func(g types.ResearchGap) bool { return g.ConfidenceScore >= requireGap }) {
+		return fmt.Errorf("%w with a confidence score of at least %.2f", errNoGaps, requireGap)
+	}
+	return nil
+}
+
+// splitTitle splits text into its first non-blank line, the title, and the
+// rest, the abstract. The title must be followed by a blank line, so that
+// the first line of an abstract without one isn't taken for its title.
+func splitTitle(text string) (title, abstract string, err error) {
+	lines := strings.SplitN(strings.TrimSpace(text), "\n", 3)
+	if len(lines) < 3 || strings.TrimSpace(lines[1]) != "" || strings.TrimSpace(lines[2]) == "" {
+		return "", "", errors.New("the abstract's file doesn't start with a title and a blank line; give its title with --title")
+	}
+	return strings.TrimSpace(lines[0]), strings.TrimSpace(lines[2]), nil
+}
+
+// fieldFlag defines the --field flag, which defaults to the configured field
+func (a *app) fieldFlag(fs *flag.FlagSet, field *types.Field) {
+	names := make([]string, len(types.Fields))
+	for i, f := range types.Fields {
+		names[i] = string(f)
+	}
+	fs.StringVar((*string)(field), "field", string(a.field), "research field, one of "+strings.Join(names, ", "))
+}
+
+// modelFlags defines the --model and --temperature flags, which default to
+// the service's settings. temperature is left nil unless the flag is given.
+func modelFlags(fs *flag.FlagSet, model *string, temperature **float64) {
+	fs.StringVar(model, "model", "", "model to analyze with, one of those listed by the models command")
+	fs.Func("temperature", fmt.Sprintf("sampling temperature, 0 to %g (default the service's)", float64(types.MaxTemperature)), func(s string) error {
+		t, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*temperature = &t
+		return nil
+	})
+}
+
+// languageFlags defines the --language and --translate flags
+func languageFlags(fs *flag.FlagSet, language *string, translate *bool) {
+	fs.StringVar(language, "language", "", "ISO 639-1 code of the input's language, such as de (default English)")
+	fs.BoolVar(translate, "translate", false, "write the analysis in --language instead of English")
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(s string) []string {
+	return splitBy(s, ",")
+}
+
+// splitBy splits s around each sep, dropping empty items
+func splitBy(s, sep string) []string {
+	var items []string
+	for item := range strings.SplitSeq(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
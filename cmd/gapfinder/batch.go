@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"golang.org/x/time/rate"
+)
+
+// batchRecord is an abstract read from the batch command's input
+type batchRecord struct {
+	index int // position in the input, from 0
+	req   types.AnalyzeRequest
+	err   error // why the record couldn't be read
+}
+
+// batchResult is a line of the batch command's output. Exactly one of
+// Result and Error is set.
+type batchResult struct {
+	Index  int                    `json:"index"`
+	Title  string                 `json:"title"`
+	Result *types.AnalyzeResponse `json:"result,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+func runBatch(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("batch", "--input FILE [flags]")
+	var input, format, results, checkpoint, model string
+	var field types.Field
+	var t throttle
+	var dryRun bool
+	fs.StringVar(&input, "input", "", "JSONL or CSV `file` of abstracts to analyze, or - for standard input (required)")
+	fs.StringVar(&format, "format", "", "format of the input, jsonl or csv (default from the file's extension, else jsonl)")
+	fs.StringVar(&results, "results", "", "append the results to `file` as JSONL (default standard output)")
+	fs.StringVar(&checkpoint, "checkpoint", "", "list the records done in `file`, and skip those already listed, so an interrupted batch can be resumed")
+	t.flags(fs, 4)
+	a.fieldFlag(fs, &field)
+	fs.StringVar(&model, "model", "", "model to analyze with, for records that don't name one")
+	dryRunFlag(fs, &dryRun)
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if input == "" {
+		return usageError(fs, errors.New("--input is required"))
+	}
+	if err := t.check(); err != nil {
+		return usageError(fs, err)
+	}
+	if format == "" {
+		format = "jsonl"
+		if strings.EqualFold(filepath.Ext(input), ".csv") {
+			format = "csv"
+		}
+	}
+	if format != "jsonl" && format != "csv" {
+		return usageError(fs, fmt.Errorf("unknown --format %q, want jsonl or csv", format))
+	}
+
+	in := a.stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var done map[int]bool
+	if checkpoint != "" {
+		var err error
+		if done, err = readCheckpoint(checkpoint); err != nil {
+			return err
+		}
+	}
+	b := &batch{field: field, model: model, keep: a.keep}
+	if dryRun {
+		return a.dryRunBatch(ctx, b, t, in, format, input, done)
+	}
+
+	out := a.stdout
+	if results != "" {
+		f, err := os.OpenFile(results, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	var cp io.Writer
+	if checkpoint != "" {
+		f, err := os.OpenFile(checkpoint, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		cp = f
+	}
+
+	c, err := a.client(t.options()...)
+	if err != nil {
+		return err
+	}
+	b.client, b.out, b.checkpoint = c, json.NewEncoder(out), cp
+	ctx, b.cancel = context.WithCancelCause(ctx)
+	defer b.cancel(nil)
+
+	work := make(chan batchRecord)
+	var wg sync.WaitGroup
+	for range t.concurrency {
+		wg.Go(func() {
+			for rec := range work {
+				b.analyze(ctx, rec)
+			}
+		})
+	}
+	skipped := 0
+	readErr := readRecords(in, format, func(rec batchRecord) bool {
+		if done[rec.index] {
+			skipped++
+			return true
+		}
+		select {
+		case work <- rec:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	close(work)
+	wg.Wait()
+
+	fmt.Fprintf(a.stderr, "%d records analyzed, %d failed, %d skipped as done\n", b.analyzed, b.failed, skipped)
+	switch {
+	case context.Cause(ctx) != nil:
+		return context.Cause(ctx)
+	case readErr != nil:
+		return fmt.Errorf("error reading %s: %w", input, readErr)
+	case b.failed > 0:
+		return fmt.Errorf("%d records failed; run the batch again with the same --checkpoint to retry them", b.failed)
+	}
+	return nil
+}
+
+// throttle holds the flags of batch and watch that tune how hard a large run
+// presses the service
+type throttle struct {
+	concurrency int
+	qps         float64
+	retryBudget int
+}
+
+// flags defines the --concurrency, --qps and --retry-budget flags
+func (t *throttle) flags(fs *flag.FlagSet, concurrency int) {
+	fs.IntVar(&t.concurrency, "concurrency", concurrency, "number of abstracts analyzed at once")
+	fs.Float64Var(&t.qps, "qps", 0, "most requests sent to the service per second, retries included (default no limit)")
+	fs.IntVar(&t.retryBudget, "retry-budget", -1, "most retries of failed requests in the whole run, after which failures are not retried; -1 for no limit")
+}
+
+func (t *throttle) check() error {
+	switch {
+	case t.concurrency < 1:
+		return errors.New("--concurrency must be at least 1")
+	case t.qps < 0:
+		return errors.New("--qps must not be negative")
+	case t.retryBudget < -1:
+		return errors.New("--retry-budget must be -1 or more")
+	}
+	return nil
+}
+
+// options returns the client options that apply the flags
+func (t *throttle) options() []client.Option {
+	var opts []client.Option
+	if t.qps > 0 {
+		// No bursts, so that the service sees requests evenly spaced
+		opts = append(opts, client.WithRateLimit(rate.Limit(t.qps), 1))
+	}
+	if t.retryBudget >= 0 {
+		opts = append(opts, client.WithRetryBudget(t.retryBudget))
+	}
+	return opts
+}
+
+// batch analyzes the records of the batch command and writes their results
+type batch struct {
+	client *client.Client
+	field  types.Field
+	model  string
+	keep   func(kind, title string, field types.Field, result any)
+	cancel context.CancelCauseFunc // stops the batch when the results can't be written
+
+	mu         sync.Mutex
+	out        *json.Encoder
+	checkpoint io.Writer // nil if there is no checkpoint file
+	analyzed   int
+	failed     int
+}
+
+// request returns the request a record is analyzed with, with the batch's
+// field and model unless the record names its own
+func (b *batch) request(rec batchRecord) types.AnalyzeRequest {
+	req := rec.req
+	if req.Field == "" {
+		req.Field = b.field
+	}
+	if req.Model == "" {
+		req.Model = b.model
+	}
+	return req
+}
+
+func (b *batch) analyze(ctx context.Context, rec batchRecord) {
+	result := batchResult{Index: rec.index, Title: rec.req.Title}
+	if rec.err != nil {
+		result.Error = rec.err.Error()
+	} else {
+		req := b.request(rec)
+		resp, err := b.client.AnalyzeAbstract(ctx, req)
+		if ctx.Err() != nil {
+			// The batch was interrupted; the record is left for the next run
+			return
+		}
+		result.Result = resp
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			b.keep(types.AnalysisAbstract, req.Title, req.Field, resp)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.out.Encode(result); err != nil {
+		b.cancel(fmt.Errorf("error writing results: %w", err))
+		return
+	}
+	if result.Error != "" {
+		// Failed records aren't checkpointed, so the next run retries them
+		b.failed++
+		return
+	}
+	b.analyzed++
+	if b.checkpoint != nil {
+		if _, err := fmt.Fprintln(b.checkpoint, rec.index); err != nil {
+			b.cancel(fmt.Errorf("error writing checkpoint: %w", err))
+		}
+	}
+}
+
+// dryRunBatch checks the records of a batch, other than those done, and
+// prints the calls that would analyze them with what they are expected to
+// cost. Invalid records, including those the service refuses to estimate,
+// fail the dry run, as they would fail the batch.
+func (a *app) dryRunBatch(ctx context.Context, b *batch, t throttle, in io.Reader, format, input string, done map[int]bool) error {
+	c, err := a.client(t.options()...)
+	if err != nil {
+		return err
+	}
+	d := &dryRunReport{Calls: []plannedCall{}}
+	records := 0
+	var estimateErr error
+	readErr := readRecords(in, format, func(rec batchRecord) bool {
+		if done[rec.index] {
+			return true
+		}
+		records++
+		req := b.request(rec)
+		err := rec.err
+		if err == nil {
+			var est *types.CostEstimate
+			var verr *types.ValidationError
+			var apiErr *client.APIError
+			est, err = c.EstimateAnalysis(ctx, req)
+			switch {
+			case err == nil:
+				d.Estimate = addEstimate(d.Estimate, est)
+			case errors.As(err, &verr), errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity:
+				// Reported as an invalid record below
+			default:
+				estimateErr = err
+				return false
+			}
+		}
+		if err != nil {
+			d.Invalid = append(d.Invalid, invalidInput{Index: rec.index, Title: req.Title, Error: err.Error()})
+		} else {
+			d.Calls = append(d.Calls, a.plan(http.MethodPost, "/analyze", req))
+		}
+		return true
+	})
+	if estimateErr != nil {
+		return estimateErr
+	}
+	if d.Estimate != nil {
+		// Records are analyzed --concurrency at a time
+		d.Estimate.DurationSeconds /= float64(min(t.concurrency, len(d.Calls)))
+	}
+	if err := a.print(d); err != nil {
+		return err
+	}
+	switch {
+	case readErr != nil:
+		return fmt.Errorf("error reading %s: %w", input, readErr)
+	case len(d.Invalid) > 0:
+		return fmt.Errorf("%d of %d records are invalid", len(d.Invalid), records)
+	}
+	return nil
+}
+
+// readCheckpoint returns the indexes of the records a checkpoint file lists
+// as done. A missing file lists none.
+func readCheckpoint(name string) (map[int]bool, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	done := map[int]bool{}
+	for line := range strings.Lines(string(data)) {
+		// A line cut short by a crash is ignored, and its record redone
+		if i, err := strconv.Atoi(strings.TrimSpace(line)); err == nil {
+			done[i] = true
+		}
+	}
+	return done, nil
+}
+
+// readRecords calls yield with each record of r until it returns false.
+// Records that can't be decoded are passed on with err set, so that the
+// rest of the batch still runs.
+func readRecords(r io.Reader, format string, yield func(batchRecord) bool) error {
+	if format == "csv" {
+		return readCSV(r, yield)
+	}
+	return readJSONL(r, yield)
+}
+
+func readJSONL(r io.Reader, yield func(batchRecord) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	index := 0
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		rec := batchRecord{index: index}
+		if err := json.Unmarshal(line, &rec.req); err != nil {
+			rec.err = fmt.Errorf("invalid record: %w", err)
+		}
+		index++
+		if !yield(rec) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+// readCSV reads records from CSV with a header row naming the columns title,
+// abstract and optionally field, authors and keywords. Authors and keywords
+// are separated by semicolons.
+func readCSV(r io.Reader, yield func(batchRecord) bool) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("error reading the header row: %w", err)
+	}
+	column := map[string]int{}
+	for i, name := range header {
+		column[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"title", "abstract"} {
+		if _, ok := column[name]; !ok {
+			return fmt.Errorf("no %s column", name)
+		}
+	}
+	for index := 0; ; index++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		get := func(name string) string {
+			if i, ok := column[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		rec := batchRecord{index: index, req: types.AnalyzeRequest{
+			Title:    get("title"),
+			Abstract: get("abstract"),
+			Field:    types.Field(get("field")),
+			Authors:  splitBy(get("authors"), ";"),
+			Keywords: splitBy(get("keywords"), ";"),
+		}}
+		if !yield(rec) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"gopkg.in/yaml.v3"
+)
+
+// completeFiles is written by __complete, alone, when the shell should
+// complete file names
+const completeFiles = ":files"
+
+// completionScripts hook each shell's completion up to the hidden __complete
+// command, which writes the candidates for the last word one per line
+var completionScripts = map[string]string{
+	"bash": `_gapfinder() {
+    local cur=${COMP_WORDS[COMP_CWORD]} IFS=$'\n'
+    local out=($(gapfinder __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    if [[ ${out[0]} == ":files" ]]; then
+        COMPREPLY=($(compgen -f -- "$cur"))
+    else
+        COMPREPLY=($(compgen -W "${out[*]}" -- "$cur"))
+    fi
+}
+complete -F _gapfinder gapfinder
+`,
+	"zsh": `#compdef gapfinder
+_gapfinder() {
+    local -a out
+    out=("${(@f)$(gapfinder __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ $out[1] == ":files" ]]; then
+        _files
+    else
+        compadd -- $out
+    fi
+}
+compdef _gapfinder gapfinder
+`,
+	"fish": `function __gapfinder_complete
+    set -l out (gapfinder __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)
+    if test "$out[1]" = ":files"
+        __fish_complete_path (commandline -ct)
+    else
+        printf '%s\n' $out
+    end
+end
+complete -c gapfinder -f -a '(__gapfinder_complete)'
+`,
+}
+
+func runCompletion(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("completion", "bash | zsh | fish")
+	fs.Usage = func() {
+		fmt.Fprint(a.stderr, `Usage: gapfinder completion bash | zsh | fish
+
+Writes the shell's completion script. To enable completion, add to ~/.bashrc:
+
+	source <(gapfinder completion bash)
+
+to ~/.zshrc, after compinit:
+
+	source <(gapfinder completion zsh)
+
+or run:
+
+	gapfinder completion fish > ~/.config/fish/completions/gapfinder.fish
+`)
+	}
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	script, ok := completionScripts[fs.Arg(0)]
+	if fs.NArg() != 1 || !ok {
+		return usageError(fs, errors.New("the shell must be one of bash, zsh or fish"))
+	}
+	_, err := io.WriteString(a.stdout, script)
+	return err
+}
+
+// complete writes the completions of the last of words, the arguments of the
+// command line up to the cursor, one per line
+func (a *app) complete(ctx context.Context, globals *flag.FlagSet, words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur, words := words[len(words)-1], words[:len(words)-1]
+
+	// Find the command among the global flags and their values
+	fs := globals
+	var cmd *command
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if strings.HasPrefix(w, "-") {
+			if takesValue(fs, w) {
+				i++
+			}
+			continue
+		}
+		if cmd != nil {
+			continue
+		}
+		for _, c := range commands() {
+			if c.name == w {
+				cmd = &c
+				fs = a.flagsOf(c)
+			}
+		}
+		if cmd == nil {
+			// An unknown command has nothing to complete
+			return
+		}
+	}
+
+	var candidates []string
+	switch {
+	case len(words) > 0 && takesValue(fs, words[len(words)-1]):
+		f := fs.Lookup(strings.TrimLeft(words[len(words)-1], "-"))
+		candidates = a.flagValues(ctx, cmd, f)
+		if candidates == nil {
+			if name, _ := flag.UnquoteUsage(f); name == "file" {
+				fmt.Fprintln(a.stdout, completeFiles)
+			}
+			return
+		}
+	case strings.HasPrefix(cur, "-"):
+		fs.VisitAll(func(f *flag.Flag) { candidates = append(candidates, "--"+f.Name) })
+	case cmd == nil:
+		for _, c := range commands() {
+			candidates = append(candidates, c.name)
+		}
+	case cmd.name == "completion":
+		for shell := range completionScripts {
+			candidates = append(candidates, shell)
+		}
+		slices.Sort(candidates)
+	}
+	for _, c := range candidates {
+		if strings.HasPrefix(c, cur) {
+			fmt.Fprintln(a.stdout, c)
+		}
+	}
+}
+
+// flagsOf returns the flag set a command defines, recorded by newFlagSet.
+// The command returns as soon as its flags are defined, without running.
+func (a *app) flagsOf(cmd command) *flag.FlagSet {
+	defining := &app{field: a.field, stdin: a.stdin, stdout: io.Discard, stderr: io.Discard, defineOnly: true}
+	defining.flags = flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	cmd.run(context.Background(), defining, nil)
+	return defining.flags
+}
+
+// takesValue reports whether arg is a flag of fs whose value is the next
+// argument
+func takesValue(fs *flag.FlagSet, arg string) bool {
+	name := strings.TrimLeft(arg, "-")
+	if !strings.HasPrefix(arg, "-") || strings.Contains(name, "=") {
+		return false
+	}
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !b.IsBoolFlag()
+}
+
+// flagValues returns the values a flag accepts, or nil if they aren't a
+// known list
+func (a *app) flagValues(ctx context.Context, cmd *command, f *flag.Flag) []string {
+	switch f.Name {
+	case "field":
+		return a.fields(ctx)
+	case "output":
+		return outputFormats
+	case "log-level":
+		return logLevels
+	case "log-format":
+		return logFormats
+	case "profile":
+		return profiles()
+	case "surveys":
+		return []string{types.SurveysInclude, types.SurveysExclude, types.SurveysDownweight}
+	case "format":
+		switch cmd.name {
+		case "export":
+			return exportFormats
+		case "report":
+			return []string{reportMarkdown, reportHTML}
+		}
+		return []string{"jsonl", "csv"}
+	}
+	return nil
+}
+
+// fields returns the research fields the service accepts, or those this
+// build knows of if the service can't be reached quickly
+func (a *app) fields(ctx context.Context) []string {
+	fields := types.Fields
+	if c, err := a.client(); err == nil {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		if resp, err := c.ListFields(ctx); err == nil {
+			fields = resp.Fields
+		}
+	}
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = string(f)
+	}
+	return names
+}
+
+// profiles returns the names of the config file's profiles
+func profiles() []string {
+	path, err := configPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var file configFile
+	if yaml.Unmarshal(data, &file) != nil {
+		return nil
+	}
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"gopkg.in/yaml.v3"
+)
+
+// config holds the settings the flags default to. They are read from the
+// config file, overridden by those of the chosen profile, then by GAPFINDER_*
+// environment variables; flags override them all.
+type config struct {
+	URL       string        `yaml:"url"`
+	APIKey    string        `yaml:"api_key"`
+	Field     types.Field   `yaml:"field"`
+	Timeout   time.Duration `yaml:"timeout"`
+	History   string        `yaml:"history"`   // file analyses are kept in, or "off"
+	LogLevel  string        `yaml:"log_level"` // one of logLevels
+	LogFormat string        `yaml:"log_format"`
+}
+
+// configFile is the content of the config file: settings for every
+// deployment, and named profiles with the settings of each
+type configFile struct {
+	config         `yaml:",inline"`
+	DefaultProfile string            `yaml:"default_profile"`
+	Profiles       map[string]config `yaml:"profiles"`
+}
+
+// merge overrides the settings of c with those o sets
+func (c *config) merge(o config) {
+	c.URL = cmp.Or(o.URL, c.URL)
+	c.APIKey = cmp.Or(o.APIKey, c.APIKey)
+	c.Field = cmp.Or(o.Field, c.Field)
+	c.Timeout = cmp.Or(o.Timeout, c.Timeout)
+	c.History = cmp.Or(o.History, c.History)
+	c.LogLevel = cmp.Or(o.LogLevel, c.LogLevel)
+	c.LogFormat = cmp.Or(o.LogFormat, c.LogFormat)
+}
+
+// defaultConfig holds the settings used when neither the config file nor the
+// environment sets them
+var defaultConfig = config{
+	URL:       "http://localhost:8001",
+	Field:     types.FieldGeneral,
+	Timeout:   5 * time.Minute,
+	LogLevel:  logOff,
+	LogFormat: logText,
+}
+
+// configPath returns where the config file is read from: $GAPFINDER_CONFIG,
+// else gapfinder/config.yaml in the user's config directory, such as
+// ~/.config on Linux
+func configPath() (string, error) {
+	if path := os.Getenv("GAPFINDER_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gapfinder", "config.yaml"), nil
+}
+
+// loadConfig returns the settings of the config file, with those of a
+// profile, and the environment. An empty profile means $GAPFINDER_PROFILE,
+// else the file's default_profile, if any. A missing config file sets
+// nothing, and has no profiles.
+func loadConfig(profile string) (config, error) {
+	cfg := defaultConfig
+	path, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+	var file configFile
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return cfg, err
+	default:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		// Misspelled settings would otherwise be silently ignored
+		dec.KnownFields(true)
+		if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+			return cfg, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	cfg.merge(file.config)
+	if profile = cmp.Or(profile, os.Getenv("GAPFINDER_PROFILE"), file.DefaultProfile); profile != "" {
+		settings, ok := file.Profiles[profile]
+		if !ok {
+			return cfg, fmt.Errorf("no profile %q in %s", profile, path)
+		}
+		cfg.merge(settings)
+	}
+
+	for name, setting := range map[string]*string{
+		"GAPFINDER_URL":        &cfg.URL,
+		"GAPFINDER_API_KEY":    &cfg.APIKey,
+		"GAPFINDER_FIELD":      (*string)(&cfg.Field),
+		"GAPFINDER_HISTORY":    &cfg.History,
+		"GAPFINDER_LOG_LEVEL":  &cfg.LogLevel,
+		"GAPFINDER_LOG_FORMAT": &cfg.LogFormat,
+	} {
+		if v := os.Getenv(name); v != "" {
+			*setting = v
+		}
+	}
+	if v := os.Getenv("GAPFINDER_TIMEOUT"); v != "" {
+		if cfg.Timeout, err = time.ParseDuration(v); err != nil {
+			return cfg, fmt.Errorf("GAPFINDER_TIMEOUT: %w", err)
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// resultsDiff is how the gaps of two results of the same kind differ. Gaps
+// are the same if their descriptions are, ignoring case and spacing; those of
+// a topic are its common and intersection gaps.
+type resultsDiff struct {
+	Old           string              `json:"old"` // names of the files compared
+	New           string              `json:"new"`
+	Added         []types.ResearchGap `json:"added"`
+	Removed       []types.ResearchGap `json:"removed"`
+	Changed       []changedGap        `json:"changed"`
+	PapersAdded   []string            `json:"papers_added,omitempty"` // titles of the papers of a topic
+	PapersRemoved []string            `json:"papers_removed,omitempty"`
+}
+
+// changedGap is a gap whose confidence score changed
+type changedGap struct {
+	Gap           types.ResearchGap `json:"gap"` // as in the new results
+	OldConfidence float64           `json:"old_confidence"`
+}
+
+func runDiff(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("diff", "[flags] OLD NEW")
+	var minChange float64
+	fs.Float64Var(&minChange, "min-change", 0.01, "smallest change of confidence reported")
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return usageError(fs, errors.New("two result files are required, saved with --output json or from /analyses"))
+	}
+
+	var loaded [2]*results
+	for i, file := range fs.Args() {
+		r, err := a.loadResults(ctx, file, "")
+		if err != nil {
+			return err
+		}
+		loaded[i] = r
+	}
+	d, err := diffResults(loaded[0], loaded[1], minChange)
+	if err != nil {
+		return err
+	}
+	d.Old, d.New = fs.Arg(0), fs.Arg(1)
+	return a.print(d)
+}
+
+// diffResults compares the gaps, and papers, of two results
+func diffResults(from, to *results, minChange float64) (*resultsDiff, error) {
+	if (from.topic == nil) != (to.topic == nil) {
+		return nil, errors.New("the results of a topic can only be compared with those of a topic")
+	}
+	// Empty lists rather than nulls tell JSON readers nothing changed
+	d := &resultsDiff{Added: []types.ResearchGap{}, Removed: []types.ResearchGap{}, Changed: []changedGap{}}
+	oldGaps, newGaps := resultGaps(from), resultGaps(to)
+	before := map[string]types.ResearchGap{}
+	for _, g := range oldGaps {
+		before[gapKeyText(g)] = g
+	}
+	after := map[string]bool{}
+	for _, g := range newGaps {
+		key := gapKeyText(g)
+		after[key] = true
+		prev, ok := before[key]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, g)
+		case math.Abs(g.ConfidenceScore-prev.ConfidenceScore) >= minChange:
+			d.Changed = append(d.Changed, changedGap{Gap: g, OldConfidence: prev.ConfidenceScore})
+		}
+	}
+	for _, g := range oldGaps {
+		if !after[gapKeyText(g)] {
+			d.Removed = append(d.Removed, g)
+		}
+	}
+
+	if from.topic != nil {
+		// Papers are the same if their URLs are, or their titles if they have none
+		paperKey := func(p types.TopicAnalysisResult) string {
+			if p.URL != "" {
+				return p.URL
+			}
+			return strings.ToLower(p.PaperTitle)
+		}
+		oldPapers, newPapers := map[string]bool{}, map[string]bool{}
+		for _, p := range from.topic.IndividualResults {
+			oldPapers[paperKey(p)] = true
+		}
+		for _, p := range to.topic.IndividualResults {
+			newPapers[paperKey(p)] = true
+			if !oldPapers[paperKey(p)] {
+				d.PapersAdded = append(d.PapersAdded, p.PaperTitle)
+			}
+		}
+		for _, p := range from.topic.IndividualResults {
+			if !newPapers[paperKey(p)] {
+				d.PapersRemoved = append(d.PapersRemoved, p.PaperTitle)
+			}
+		}
+	}
+	return d, nil
+}
+
+// resultGaps returns the gaps of results that diff compares
+func resultGaps(r *results) []types.ResearchGap {
+	if r.topic != nil {
+		return slices.Concat(r.topic.CommonGaps, r.topic.IntersectionGaps)
+	}
+	return r.analysis.Gaps
+}
+
+// gapKeyText returns what identifies a gap across results: its description,
+// in lower case with spacing collapsed
+func gapKeyText(g types.ResearchGap) string {
+	return strings.Join(strings.Fields(strings.ToLower(g.GapDescription)), " ")
+}
+
+func printDiff(w io.Writer, d *resultsDiff) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", d.Old, d.New)
+	for _, g := range d.Added {
+		fmt.Fprintf(w, "+ %s (%s, confidence %.2f)\n", g.GapDescription, g.GapType, g.ConfidenceScore)
+	}
+	for _, g := range d.Removed {
+		fmt.Fprintf(w, "- %s (%s, confidence %.2f)\n", g.GapDescription, g.GapType, g.ConfidenceScore)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(w, "~ %s (%s, confidence %.2f -> %.2f)\n", c.Gap.GapDescription, c.Gap.GapType, c.OldConfidence, c.Gap.ConfidenceScore)
+	}
+	for _, title := range d.PapersAdded {
+		fmt.Fprintf(w, "+ paper: %s\n", title)
+	}
+	for _, title := range d.PapersRemoved {
+		fmt.Fprintf(w, "- paper: %s\n", title)
+	}
+	if len(d.Added)+len(d.Removed)+len(d.Changed)+len(d.PapersAdded)+len(d.PapersRemoved) == 0 {
+		fmt.Fprintln(w, "No differences.")
+	}
+}
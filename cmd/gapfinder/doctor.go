@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+)
+
+// Statuses of a doctor check
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip" // not run because an earlier check failed
+)
+
+// Latencies of /health above which doctor warns, and fails
+const (
+	slowLatency    = time.Second
+	tooSlowLatency = 5 * time.Second
+)
+
+// latencySamples is how many calls to /health latency is the median of
+const latencySamples = 5
+
+// doctorTimeout is the longest doctor waits for each call, unless --timeout
+// is shorter
+const doctorTimeout = 10 * time.Second
+
+// doctorReport is the outcome of "gapfinder doctor"
+type doctorReport struct {
+	URL    string        `json:"url"`
+	Checks []doctorCheck `json:"checks"`
+}
+
+// doctorCheck is the outcome of one check, with how to fix what it found
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // checkOK, checkWarn, checkFail or checkSkip
+	Detail string `json:"detail"`
+	Fix    string `json:"fix,omitempty"`
+}
+
+func runDoctor(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("doctor", "")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	timeout := doctorTimeout
+	if a.timeout > 0 {
+		timeout = min(a.timeout, timeout)
+	}
+	// Each problem should be reported at once rather than retried away
+	c, err := a.client(client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1}), client.WithTimeout(timeout))
+	if err != nil {
+		return err
+	}
+	rep := a.diagnose(ctx, c)
+	if err := a.print(rep); err != nil {
+		return err
+	}
+	failed := 0
+	for _, check := range rep.Checks {
+		if check.Status == checkFail {
+			failed++
+		}
+	}
+	switch failed {
+	case 0:
+		return nil
+	case 1:
+		return errors.New("1 check failed")
+	}
+	return fmt.Errorf("%d checks failed", failed)
+}
+
+// diagnose checks the config, then that the service can be reached, answers
+// requests sent with the configured headers, runs a compatible version and
+// answers quickly
+func (a *app) diagnose(ctx context.Context, c *client.Client) *doctorReport {
+	rep := &doctorReport{URL: a.baseURL}
+	add := func(name, status, detail, fix string) {
+		rep.Checks = append(rep.Checks, doctorCheck{Name: name, Status: status, Detail: detail, Fix: fix})
+	}
+
+	if path, err := configPath(); err != nil {
+		add("config", checkWarn, err.Error(), "Set GAPFINDER_CONFIG to the config file's path.")
+	} else if _, err := os.Stat(path); err != nil {
+		add("config", checkOK, "no config file at "+path+"; using flags, GAPFINDER_* variables and defaults", "")
+	} else {
+		add("config", checkOK, "read "+path, "")
+	}
+
+	start := time.Now()
+	health, err := c.HealthCheck(ctx)
+	if err != nil {
+		status, fix := connectivityFix(err)
+		add("connectivity", status, fmt.Sprintf("could not reach %s: %v", a.baseURL, err), fix)
+		for _, name := range []string{"access", "version", "latency"} {
+			add(name, checkSkip, "the service could not be reached", "")
+		}
+		return rep
+	}
+	first := time.Since(start)
+	if health.Status != "healthy" {
+		add("connectivity", checkWarn, fmt.Sprintf("reached %s, which reports itself %s", a.baseURL, health.Status),
+			"See the service's logs; an unhealthy service usually cannot reach its LLM provider.")
+	} else {
+		add("connectivity", checkOK, "reached "+a.baseURL, "")
+	}
+
+	add(a.checkAccess(ctx, c))
+	add(checkVersion(health.Version))
+
+	samples := []time.Duration{first}
+	for len(samples) < latencySamples && ctx.Err() == nil {
+		start := time.Now()
+		if _, err := c.HealthCheck(ctx); err != nil {
+			break
+		}
+		samples = append(samples, time.Since(start))
+	}
+	slices.Sort(samples)
+	median := samples[len(samples)/2].Round(10 * time.Microsecond)
+	detail := fmt.Sprintf("median of %d calls to /health: %v", len(samples), median)
+	switch {
+	case median > tooSlowLatency:
+		add("latency", checkFail, detail, "The network to the service is very slow; use a deployment closer to you, or check for a proxy or VPN in the way.")
+	case median > slowLatency:
+		add("latency", checkWarn, detail, "Analyses will be slow to start; use a deployment closer to you if there is one.")
+	default:
+		add("latency", checkOK, detail, "")
+	}
+	return rep
+}
+
+// connectivityFix returns how bad a failure to reach the service is, and what
+// usually fixes it
+func connectivityFix(err error) (status, fix string) {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var certErr *x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var apiErr *client.APIError
+	switch {
+	case errors.As(err, &dnsErr):
+		return checkFail, "The host name of the URL does not resolve; check --url, GAPFINDER_URL or the profile's url for typos."
+	case errors.As(err, &certErr), errors.As(err, &hostErr):
+		return checkFail, "The service's TLS certificate is not trusted; check the URL, or install your organization's CA certificate."
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return checkFail, "The service did not answer in time; check that a firewall or proxy isn't dropping the traffic."
+	case errors.As(err, &opErr):
+		return checkFail, "Nothing is listening at the URL; start the service (gapfinderd, or python main.py) or point --url, GAPFINDER_URL or the profile's url at it."
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		return checkFail, "Something other than the AI Gap Finder service answers at the URL; check its host, port and path prefix."
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= http.StatusInternalServerError:
+		return checkFail, "The service, or a proxy in front of it, is failing; see its logs."
+	}
+	return checkFail, "Check --url, GAPFINDER_URL or the profile's url."
+}
+
+// checkAccess checks that the service answers requests sent with the
+// configured headers, by asking for the caller's usage, which also shows
+// whether the quota is used up. The service itself accepts any API key, but a
+// gateway in front of it may not.
+func (a *app) checkAccess(ctx context.Context, c *client.Client) (name, status, detail, fix string) {
+	usage, err := c.GetUsage(ctx)
+	var apiErr *client.APIError
+	keyFix := "Set a valid key with --api-key, GAPFINDER_API_KEY or the api_key of the config file or profile."
+	if a.apiKey == "" {
+		keyFix = "No API key is set; " + strings.ToLower(keyFix[:1]) + keyFix[1:]
+	}
+	switch {
+	case errors.Is(err, client.ErrUnauthorized), errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden:
+		return "access", checkFail, fmt.Sprintf("the service refused the request: %v", err), keyFix
+	case errors.Is(err, client.ErrNotFound):
+		return "access", checkWarn, "the service has no /usage endpoint to check requests and quota with", "Upgrade the service to check the quota before running analyses."
+	case err != nil:
+		return "access", checkWarn, fmt.Sprintf("could not check requests and quota: %v", err), ""
+	}
+
+	detail = "server reachable with the configured headers"
+	q := usage.Quota
+	switch {
+	case q == nil:
+		return "access", checkOK, detail, ""
+	case q.Remaining == 0:
+		return "access", checkFail, fmt.Sprintf("%s, but the quota of %d requests is used up until %s", detail, q.Limit, q.ResetAt.Local().Format(time.DateTime)),
+			"Wait for the quota to reset, or ask the service's operators for a higher one."
+	case q.Remaining*10 < q.Limit:
+		return "access", checkWarn, fmt.Sprintf("%s; %d of %d requests left until %s", detail, q.Remaining, q.Limit, q.ResetAt.Local().Format(time.DateTime)),
+			"Large batches may run out of quota; spread them out or ask for a higher quota."
+	}
+	return "access", checkOK, fmt.Sprintf("%s; %d of %d requests left", detail, q.Remaining, q.Limit), ""
+}
+
+// checkVersion checks that the service's version has the CLI's major version,
+// whose API the CLI was built for
+func checkVersion(service string) (name, status, detail, fix string) {
+	if service == "" {
+		return "version", checkWarn, "the service does not report its version", ""
+	}
+	detail = fmt.Sprintf("service %s, CLI %s", service, version)
+	major, err := majorVersion(strings.TrimPrefix(service, "v"))
+	if err != nil {
+		return "version", checkWarn, detail + "; the service's version is not major.minor.patch", ""
+	}
+	cliMajor, _ := majorVersion(version)
+	switch {
+	case major == cliMajor:
+		return "version", checkOK, detail, ""
+	case major < cliMajor:
+		return "version", checkFail, detail, fmt.Sprintf("The service is older than the CLI; upgrade the service, or install a CLI of version %d.x.", major)
+	}
+	return "version", checkFail, detail, "The CLI is older than the service; upgrade it with go install github.com/aichain-lab/ai-gap-finder/cmd/gapfinder@latest."
+}
+
+// majorVersion returns the major number of a version such as "1.2.0"
+func majorVersion(v string) (int, error) {
+	major, _, _ := strings.Cut(v, ".")
+	return strconv.Atoi(major)
+}
+
+func printDoctor(w io.Writer, rep *doctorReport) {
+	for _, c := range rep.Checks {
+		fmt.Fprintf(w, "[%-4s] %-12s %s\n", c.Status, c.Name, c.Detail)
+		if c.Fix != "" {
+			fmt.Fprintf(w, "%20s%s\n", "", c.Fix)
+		}
+	}
+}
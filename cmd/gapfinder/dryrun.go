@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// dryRunReport is what --dry-run prints instead of running analyses: the
+// calls that would be made, the inputs that are invalid and what the valid
+// ones are expected to cost
+type dryRunReport struct {
+	Calls    []plannedCall       `json:"calls"`
+	Invalid  []invalidInput      `json:"invalid,omitempty"`
+	Estimate *types.CostEstimate `json:"estimate,omitempty"`
+}
+
+// plannedCall is a call to the service that a dry run didn't make
+type plannedCall struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   any    `json:"body"`
+}
+
+// invalidInput is a record of a batch that would fail without reaching the
+// service
+type invalidInput struct {
+	Index int    `json:"index"` // position in the input, from 0
+	Title string `json:"title"`
+	Error string `json:"error"`
+}
+
+// dryRunFlag defines the --dry-run flag of the commands that run analyses
+func dryRunFlag(fs *flag.FlagSet, dryRun *bool) {
+	fs.BoolVar(dryRun, "dry-run", false, "check the input and print what would be sent, with its expected cost, without analyzing anything")
+}
+
+// plan returns the call to an endpoint of the service that a dry run
+// reports instead of making
+func (a *app) plan(method, path string, body any) plannedCall {
+	return plannedCall{Method: method, URL: strings.TrimSuffix(a.baseURL, "/") + path, Body: body}
+}
+
+// addEstimate adds est to the estimate of a dry run's other analyses, total,
+// which is nil for the first. The cost is only known if every analysis's is.
+func addEstimate(total, est *types.CostEstimate) *types.CostEstimate {
+	if total == nil {
+		sum := *est
+		return &sum
+	}
+	if total.Model != est.Model {
+		total.Model = ""
+	}
+	total.Papers += est.Papers
+	total.LLMCalls += est.LLMCalls
+	total.InputTokens += est.InputTokens
+	total.OutputTokens += est.OutputTokens
+	if total.CostUSD != nil && est.CostUSD != nil {
+		cost := *total.CostUSD + *est.CostUSD
+		total.CostUSD = &cost
+	} else {
+		total.CostUSD = nil
+	}
+	total.DurationSeconds += est.DurationSeconds
+	total.ProcessingTime += est.ProcessingTime
+	return total
+}
+
+func printDryRun(w io.Writer, d *dryRunReport) {
+	for _, c := range d.Calls {
+		body, _ := json.MarshalIndent(c.Body, "  ", "  ")
+		fmt.Fprintf(w, "Would %s %s\n  %s\n", c.Method, c.URL, body)
+	}
+	for _, in := range d.Invalid {
+		fmt.Fprintf(w, "Invalid record %d (%s): %s\n", in.Index, in.Title, in.Error)
+	}
+	if d.Estimate != nil {
+		fmt.Fprintln(w)
+		printEstimate(w, d.Estimate)
+	}
+	fmt.Fprintln(w, "Dry run; nothing was analyzed.")
+}
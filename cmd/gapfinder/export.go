@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// Formats of export
+const (
+	exportBibTeX   = "bibtex"
+	exportCSV      = "csv"
+	exportMarkdown = "markdown"
+)
+
+var exportFormats = []string{exportBibTeX, exportCSV, exportMarkdown}
+
+func runExport(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("export", "[--file FILE | --analysis ID] [--format FORMAT | --template FILE]")
+	var file, analysisID, exportFormat, templateFile string
+	fs.StringVar(&file, "file", "", "export the results saved in `file` with --output json (default standard input)")
+	fs.StringVar(&analysisID, "analysis", "", "export an analysis kept by the service, by `ID`")
+	fs.StringVar(&exportFormat, "format", exportMarkdown, "format to export to, one of "+strings.Join(exportFormats, ", "))
+	fs.StringVar(&templateFile, "template", "", "write the results with the Go text/template in `file` instead of a format")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if file != "" && analysisID != "" {
+		return usageError(fs, errors.New("--file and --analysis are mutually exclusive"))
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["format"] && templateFile != "" {
+		return usageError(fs, errors.New("--format and --template are mutually exclusive"))
+	}
+
+	tmpl := markdownTemplate
+	switch {
+	case templateFile != "":
+		text, err := os.ReadFile(templateFile)
+		if err != nil {
+			return err
+		}
+		if tmpl, err = template.New(templateFile).Funcs(exportFuncs).Parse(string(text)); err != nil {
+			return err
+		}
+	case exportFormat == exportBibTeX, exportFormat == exportCSV, exportFormat == exportMarkdown:
+	default:
+		return usageError(fs, fmt.Errorf("unknown --format %q", exportFormat))
+	}
+
+	r, err := a.loadResults(ctx, file, analysisID)
+	if err != nil {
+		return err
+	}
+	data := newExportData(r)
+	switch {
+	case templateFile != "", exportFormat == exportMarkdown:
+		return tmpl.Execute(a.stdout, data)
+	case exportFormat == exportBibTeX:
+		if len(data.Papers) == 0 {
+			return errors.New("the results name no papers to cite")
+		}
+		return writeBibTeX(a.stdout, data.Papers)
+	}
+	return writeGapsCSV(a.stdout, data)
+}
+
+// exportData is what the export formats, and templates, are given
+type exportData struct {
+	Title            string              // the topic, or the paper analyzed
+	Topic            bool                // whether the results are of a topic
+	Gaps             []types.ResearchGap // the gaps common to the papers of a topic, or those of the paper
+	IntersectionGaps []types.ResearchGap // of a topic analyzed across fields
+	Directions       []string            // research directions suggested for a topic
+	Papers           []exportPaper
+}
+
+// exportPaper is a paper of exported results
+type exportPaper struct {
+	Key      string // BibTeX citation key, unique among the papers
+	Title    string
+	Authors  []string
+	Abstract string
+	URL      string
+	Year     int // zero if unknown
+	Gaps     []types.ResearchGap
+}
+
+func newExportData(r *results) *exportData {
+	d := &exportData{Title: r.title}
+	if t := r.topic; t != nil {
+		d.Topic = true
+		d.Gaps, d.IntersectionGaps, d.Directions = t.CommonGaps, t.IntersectionGaps, t.SuggestedResearchDirections
+		for _, p := range t.IndividualResults {
+			d.Papers = append(d.Papers, exportPaper{Title: p.PaperTitle, Authors: p.Authors, Abstract: p.Abstract, URL: p.URL, Year: p.Year, Gaps: p.Gaps})
+		}
+	} else {
+		d.Gaps = r.analysis.Gaps
+		if p := r.analysis.Paper; p != nil {
+			d.Papers = append(d.Papers, exportPaper{Title: p.Title, Authors: p.Authors, Abstract: p.Abstract, URL: p.URL, Year: p.Year, Gaps: r.analysis.Gaps})
+		} else if r.title != "" {
+			d.Papers = append(d.Papers, exportPaper{Title: r.title, Gaps: r.analysis.Gaps})
+		}
+	}
+
+	used := map[string]int{}
+	for i := range d.Papers {
+		key := citationKey(d.Papers[i])
+		if used[key]++; used[key] > 1 {
+			key += strconv.Itoa(used[key])
+		}
+		d.Papers[i].Key = key
+	}
+	return d
+}
+
+// citationKey returns the surname of a paper's first author followed by the
+// first word of its title, such as "curiedosimetry"
+func citationKey(p exportPaper) string {
+	var name, word string
+	if len(p.Authors) > 0 {
+		fields := strings.Fields(p.Authors[0])
+		if len(fields) > 0 {
+			name = fields[len(fields)-1]
+		}
+	}
+	for _, w := range strings.Fields(p.Title) {
+		if len(w) > 3 {
+			word = w
+			break
+		}
+	}
+	key := strings.Map(func(r rune) rune {
+		if r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name+word)
+	if key == "" {
+		return "paper"
+	}
+	return key
+}
+
+// writeBibTeX writes a @misc entry for each paper, with its gaps as the
+// annotation reference managers show as a note
+func writeBibTeX(w io.Writer, papers []exportPaper) error {
+	for i, p := range papers {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "@misc{%s,\n", p.Key)
+		fmt.Fprintf(w, "  title = {{%s}},\n", bibtexEscape(p.Title))
+		if len(p.Authors) > 0 {
+			fmt.Fprintf(w, "  author = {%s},\n", bibtexEscape(strings.Join(p.Authors, " and ")))
+		}
+		if p.Year != 0 {
+			fmt.Fprintf(w, "  year = {%d},\n", p.Year)
+		}
+		if p.URL != "" {
+			fmt.Fprintf(w, "  url = {%s},\n", p.URL)
+		}
+		if p.Abstract != "" {
+			fmt.Fprintf(w, "  abstract = {%s},\n", bibtexEscape(p.Abstract))
+		}
+		if len(p.Gaps) > 0 {
+			notes := make([]string, len(p.Gaps))
+			for j, g := range p.Gaps {
+				notes[j] = fmt.Sprintf("%d. %s (%s, confidence %.2f)", j+1, g.GapDescription, g.GapType, g.ConfidenceScore)
+			}
+			fmt.Fprintf(w, "  annote = {Research gaps: %s},\n", bibtexEscape(strings.Join(notes, " ")))
+		}
+		if _, err := fmt.Fprintln(w, "}"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bibtexEscape keeps text from unbalancing the braces of a field or being
+// taken for LaTeX
+var bibtexEscape = strings.NewReplacer(
+	"\\", "\\textbackslash{}", "{", "\\{", "}", "\\}", "&", "\\&", "%", "\\%", "$", "\\$", "#", "\\#", "_", "\\_",
+	"~", "\\textasciitilde{}", "^", "\\textasciicircum{}", "\n", " ",
+).Replace
+
+// writeGapsCSV writes a row for each gap. The scope of a gap is "common" or
+// "intersection" for those of a topic, and "paper" for those of a paper.
+func writeGapsCSV(w io.Writer, d *exportData) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"scope", "paper", "url", "gap", "type", "confidence", "impact"})
+	row := func(scope string, p exportPaper, g types.ResearchGap) {
+		cw.Write([]string{scope, p.Title, p.URL, g.GapDescription, string(g.GapType), strconv.FormatFloat(g.ConfidenceScore, 'f', 2, 64), g.PotentialImpact})
+	}
+	if d.Topic {
+		for _, g := range d.Gaps {
+			row("common", exportPaper{}, g)
+		}
+		for _, g := range d.IntersectionGaps {
+			row("intersection", exportPaper{}, g)
+		}
+	}
+	for _, p := range d.Papers {
+		for _, g := range p.Gaps {
+			row("paper", p, g)
+		}
+	}
+	if !d.Topic && len(d.Papers) == 0 {
+		for _, g := range d.Gaps {
+			row("paper", exportPaper{}, g)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportFuncs are the functions templates may call besides the built-in ones
+var exportFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"markdown": markdownEscape,
+	"link":     markdownLink,
+	"bibtex":   bibtexEscape,
+	"confidence": func(g types.ResearchGap) string {
+		return strconv.FormatFloat(g.ConfidenceScore, 'f', 2, 64)
+	},
+}
+
+// markdownLink returns the destination of a markdown link to u. It is
+// written between angle brackets, so that spaces and parentheses in u don't
+// end it early.
+func markdownLink(u string) string {
+	return "<" + strings.NewReplacer("<", "%3C", ">", "%3E", "\n", "").Replace(u) + ">"
+}
+
+// markdownTemplate is the markdown format, a page for lab wikis. It is also
+// where to start writing a template from.
+var markdownTemplate = template.Must(template.New(exportMarkdown).Funcs(exportFuncs).Parse(
+	`{{with .Title}}# {{markdown .}}
+
+{{end}}{{if .Gaps}}## Research gaps
+
+| Gap | Type | Confidence | Impact |
+| --- | --- | ---: | --- |
+{{range .Gaps}}| {{markdown .GapDescription}} | {{.GapType}} | {{confidence .}} | {{markdown .PotentialImpact}} |
+{{end}}
+{{end}}{{if .IntersectionGaps}}## Gaps between fields
+
+{{range .IntersectionGaps}}- {{markdown .GapDescription}} ({{.GapType}}, confidence {{confidence .}})
+{{end}}
+{{end}}{{if .Directions}}## Research directions
+
+{{range .Directions}}- {{markdown .}}
+{{end}}
+{{end}}{{if .Topic}}{{if .Papers}}## Papers
+
+{{range .Papers}}### {{if .URL}}[{{markdown .Title}}]({{link .URL}}){{else}}{{markdown .Title}}{{end}}
+
+{{with .Authors}}{{markdown (join . ", ")}}
+
+{{end}}{{range .Gaps}}- {{markdown .GapDescription}} ({{.GapType}}, confidence {{confidence .}})
+{{end}}{{if .Gaps}}
+{{end}}{{end}}{{end}}{{end}}`))
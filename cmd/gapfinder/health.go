@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func runHealth(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("health", "")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	health, err := c.HealthCheck(ctx)
+	if err != nil {
+		return err
+	}
+	if err := a.print(health); err != nil {
+		return err
+	}
+	if health.Status != "healthy" {
+		return fmt.Errorf("service is %s", health.Status)
+	}
+	return nil
+}
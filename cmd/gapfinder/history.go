@@ -0,0 +1,245 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// historyOff is the history setting that keeps no history
+const historyOff = "off"
+
+// historySchema creates the history database. Results are kept as the JSON
+// the service returned them as.
+const historySchema = `
+CREATE TABLE IF NOT EXISTS analyses (
+	id         INTEGER PRIMARY KEY,
+	kind       TEXT NOT NULL,
+	title      TEXT NOT NULL,
+	field      TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	gaps       INTEGER NOT NULL,
+	result     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS analyses_created_at ON analyses (created_at);
+`
+
+// history is the local database every analysis of the CLI is kept in, so
+// that results are not lost when the terminal scrolls away
+type history struct {
+	db *sql.DB
+}
+
+// historyEntry is an analysis kept in the history
+type historyEntry struct {
+	ID        int64       `json:"id"`
+	Kind      string      `json:"kind"` // types.AnalysisAbstract or types.AnalysisTopic
+	Title     string      `json:"title"`
+	Field     types.Field `json:"field"`
+	CreatedAt time.Time   `json:"created_at"`
+	Gaps      int         `json:"gaps"`
+}
+
+// historyList is what "history list" and "history search" print
+type historyList []historyEntry
+
+// historyPath returns where the history is kept by default: history.db next
+// to the config file
+func historyPath() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "history.db"), nil
+}
+
+func openHistory(path string) (*history, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// Concurrent batch analyses would otherwise find the database locked
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &history{db: db}, nil
+}
+
+func (h *history) close() error {
+	return h.db.Close()
+}
+
+// add keeps the result of an analysis, an AnalyzeResponse or TopicResponse
+func (h *history) add(kind, title string, field types.Field, gaps int, result any) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = h.db.Exec(`INSERT INTO analyses (kind, title, field, created_at, gaps, result) VALUES (?, ?, ?, ?, ?, ?)`,
+		kind, title, cmp.Or(field, types.FieldGeneral), time.Now().UTC().Format(time.RFC3339Nano), gaps, string(data))
+	return err
+}
+
+// list returns the most recent analyses, newest first. A non-empty query
+// keeps those whose title or results contain it, ignoring case.
+func (h *history) list(query string, kind string, limit int) (historyList, error) {
+	q := `SELECT id, kind, title, field, created_at, gaps FROM analyses WHERE 1=1`
+	var args []any
+	if query != "" {
+		// The results are searched as JSON, so gaps, findings and paper
+		// titles all match
+		like := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(query)) + "%"
+		q += ` AND (lower(title) LIKE ? ESCAPE '\' OR lower(result) LIKE ? ESCAPE '\')`
+		args = append(args, like, like)
+	}
+	if kind != "" {
+		q += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	q += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := h.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := historyList{}
+	for rows.Next() {
+		var e historyEntry
+		var created string
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Title, &e.Field, &created, &e.Gaps); err != nil {
+			return nil, err
+		}
+		if e.CreatedAt, err = time.Parse(time.RFC3339Nano, created); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// get returns the results of an analysis, or nil if there is none with the
+// id
+func (h *history) get(id int64) (any, error) {
+	var kind, data string
+	err := h.db.QueryRow(`SELECT kind, result FROM analyses WHERE id = ?`, id).Scan(&kind, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result any = new(types.AnalyzeResponse)
+	if kind == types.AnalysisTopic {
+		result = new(types.TopicResponse)
+	}
+	return result, json.Unmarshal([]byte(data), result)
+}
+
+// keep adds the result of an analysis to the history, unless it is off.
+// Failing to is reported but doesn't fail the analysis.
+func (a *app) keep(kind, title string, field types.Field, result any) {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+	if a.historyPath == historyOff || a.historyErr != nil {
+		return
+	}
+	if a.history == nil {
+		if a.history, a.historyErr = openHistory(a.historyPath); a.historyErr != nil {
+			fmt.Fprintf(a.stderr, "gapfinder: not keeping history: %v\n", a.historyErr)
+			return
+		}
+	}
+	var gaps int
+	switch r := result.(type) {
+	case *types.AnalyzeResponse:
+		gaps = len(r.Gaps)
+	case *types.TopicResponse:
+		gaps = len(r.CommonGaps) + len(r.IntersectionGaps)
+	}
+	if err := a.history.add(kind, title, field, gaps, result); err != nil {
+		fmt.Fprintf(a.stderr, "gapfinder: error keeping %q in history: %v\n", title, err)
+	}
+}
+
+func runHistory(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("history", "list | show ID | search QUERY [flags]")
+	var kind string
+	var limit int
+	fs.StringVar(&kind, "kind", "", "list only analyses of this kind, "+types.AnalysisAbstract+" or "+types.AnalysisTopic)
+	fs.IntVar(&limit, "limit", 20, "most analyses listed")
+	// Flags may come before, after or between the arguments
+	var rest []string
+	for {
+		if err := a.parseFlags(fs, args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(rest) == 0 {
+		return usageError(fs, errors.New("list, show or search is required"))
+	}
+	if kind != "" && kind != types.AnalysisAbstract && kind != types.AnalysisTopic {
+		return usageError(fs, fmt.Errorf("unknown --kind %q", kind))
+	}
+	if a.historyPath == historyOff {
+		return errors.New("the history is off")
+	}
+
+	var query string
+	var id int64
+	switch sub := rest[0]; {
+	case sub == "list" && len(rest) == 1:
+	case sub == "search" && len(rest) > 1:
+		query = strings.Join(rest[1:], " ")
+	case sub == "show" && len(rest) == 2:
+		var err error
+		if id, err = strconv.ParseInt(rest[1], 10, 64); err != nil {
+			return usageError(fs, fmt.Errorf("invalid analysis ID %q", rest[1]))
+		}
+	default:
+		return usageError(fs, fmt.Errorf("unexpected arguments: %q", rest))
+	}
+
+	h, err := openHistory(a.historyPath)
+	if err != nil {
+		return err
+	}
+	defer h.close()
+	if rest[0] == "show" {
+		result, err := h.get(id)
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			return fmt.Errorf("no analysis %d in the history", id)
+		}
+		return a.print(result)
+	}
+	entries, err := h.list(query, kind, limit)
+	if err != nil {
+		return err
+	}
+	return a.print(entries)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+)
+
+// logOff is the log level that logs nothing, the default as the CLI reports
+// failures itself
+const logOff = "off"
+
+// logLevels are the values of the global --log-level flag
+var logLevels = []string{logOff, "debug", "info", "warn", "error"}
+
+// Formats of the global --log-format flag, those of the server's logs
+const (
+	logText = "text"
+	logJSON = "json"
+)
+
+var logFormats = []string{logText, logJSON}
+
+// newLogger returns the logger the client logs its requests, retries and
+// failures to, or nil if logging is off
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	if !slices.Contains(logFormats, format) {
+		return nil, fmt.Errorf("unknown log format %q, want %s or %s", format, logText, logJSON)
+	}
+	if level == logOff {
+		return nil, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: l}
+	if format == logJSON {
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return slog.New(slog.NewTextHandler(w, opts)), nil
+}
@@ -0,0 +1,355 @@
+// Command gapfinder runs gap analyses against the AI Gap Finder service from
+// the terminal.
+//
+// Usage:
+//
+//	gapfinder [global flags] <command> [flags]
+//
+// The commands are:
+//
+//	analyze     analyze a single research abstract
+//	topic       analyze the papers found for a research topic
+//	batch       analyze the abstracts of a JSONL or CSV file
+//	watch       analyze new arXiv papers as they appear
+//	tui         browse the results of a topic analysis
+//	models      list the models analyses may choose
+//	export      export results to BibTeX, CSV or Markdown
+//	diff        compare the gaps of two results
+//	report      write a Markdown or HTML report of results
+//	history     list, show and search the analyses made
+//	serve-mock  serve canned responses for developing against the API
+//	health      check that the service is up
+//	doctor      diagnose problems reaching or using the service
+//	completion  write a shell completion script
+//
+// Run "gapfinder <command> -h" for the flags of a command.
+//
+// Flags default to the settings of the config file, gapfinder/config.yaml in
+// the user's config directory (~/.config on Linux) or $GAPFINDER_CONFIG.
+// Profiles hold the settings of each deployment:
+//
+//	field: neuroscience
+//	timeout: 10m
+//	default_profile: local
+//	profiles:
+//	  local:
+//	    url: http://localhost:8001
+//	  prod:
+//	    url: https://gapfinder.example.com
+//	    api_key: ...
+//
+// The settings of the profile chosen with --profile, $GAPFINDER_PROFILE or
+// default_profile override those outside profiles. The environment variables
+// GAPFINDER_URL, GAPFINDER_API_KEY, GAPFINDER_FIELD, GAPFINDER_TIMEOUT,
+// GAPFINDER_LOG_LEVEL and GAPFINDER_LOG_FORMAT override the config file, and
+// flags override everything.
+//
+// --log-level logs the client's requests, retries and failures on standard
+// error, as text or, with --log-format json, as JSON lines like those of the
+// server.
+//
+// Every analysis is kept in a SQLite database, history.db next to the config
+// file, for "gapfinder history". The history setting, or GAPFINDER_HISTORY,
+// names another file or is "off" to keep none.
+//
+// gapfinder exits with status 1 when a call fails, 2 when it is used wrongly
+// and 3 when "analyze --require-gap" found no gap confident enough.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// version is the CLI's version, whose major version is that of the API it
+// was built for
+const version = "1.0.0"
+
+// Exit codes
+const (
+	exitOK     = 0
+	exitError  = 1
+	exitUsage  = 2
+	exitNoGaps = 3
+)
+
+// errUsage is returned by commands whose arguments are invalid. The flag
+// package or usageError has already described the problem by then.
+var errUsage = errors.New("usage error")
+
+// errFlagsDefined is returned by commands run only to define their flags,
+// for completion, once they have
+var errFlagsDefined = errors.New("flags defined")
+
+// errNoGaps is returned by commands asked to fail when the analysis found no
+// gaps, so that pipelines can tell it from the analysis itself failing
+var errNoGaps = errors.New("no research gap found")
+
+// app holds the global flags and I/O streams shared by all commands
+type app struct {
+	baseURL string
+	apiKey  string
+	timeout time.Duration
+	output  string       // one of outputFormats
+	field   types.Field  // what --field defaults to
+	logger  *slog.Logger // nil unless --log-level is set
+
+	historyPath string // where analyses are kept, or historyOff
+	historyMu   sync.Mutex
+	history     *history // opened by the first analysis kept
+	historyErr  error    // why the history couldn't be opened
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	// defineOnly makes commands return errFlagsDefined instead of parsing
+	// their flags, so completion can list them without running them
+	defineOnly bool
+	flags      *flag.FlagSet // the last flag set newFlagSet returned
+}
+
+// command is a gapfinder subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, a *app, args []string) error
+}
+
+func commands() []command {
+	return []command{
+		{"analyze", "analyze a single research abstract", runAnalyze},
+		{"topic", "analyze the papers found for a research topic", runTopic},
+		{"batch", "analyze the abstracts of a JSONL or CSV file", runBatch},
+		{"watch", "analyze new arXiv papers as they appear", runWatch},
+		{"tui", "browse the results of a topic analysis", runTUI},
+		{"models", "list the models analyses may choose", runModels},
+		{"export", "export results to BibTeX, CSV or Markdown", runExport},
+		{"diff", "compare the gaps of two results", runDiff},
+		{"report", "write a Markdown or HTML report of results", runReport},
+		{"history", "list, show and search the analyses made", runHistory},
+		{"serve-mock", "serve canned responses for developing against the API", runServeMock},
+		{"health", "check that the service is up", runHealth},
+		{"doctor", "diagnose problems reaching or using the service", runDoctor},
+		{"completion", "write a shell completion script", runCompletion},
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the process exit code
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	a := &app{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("gapfinder", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var profile string
+	fs.StringVar(&profile, "profile", "", "profile of the config file to use, such as staging (default $GAPFINDER_PROFILE, else the file's default_profile)")
+	fs.StringVar(&a.baseURL, "url", defaultConfig.URL, "address of the AI Gap Finder service")
+	fs.StringVar(&a.apiKey, "api-key", "", "API key sent in the X-API-Key header")
+	fs.DurationVar(&a.timeout, "timeout", defaultConfig.Timeout, "time limit for each call")
+	var logLevel, logFormat string
+	fs.StringVar(&logLevel, "log-level", defaultConfig.LogLevel, "log the client's requests, retries and failures on standard error at this level, one of "+strings.Join(logLevels, ", "))
+	fs.StringVar(&logFormat, "log-format", defaultConfig.LogFormat, "format of the logs, "+logText+" or "+logJSON+" like the server's")
+	fs.StringVar(&a.output, "output", outputText, "format of the results, one of "+strings.Join(outputFormats, ", ")+"; batch and watch always write JSONL, and export its --format")
+	fs.Usage = func() { usage(fs) }
+	if len(args) > 0 && args[0] == "__complete" {
+		// The completion scripts call this hidden command on each <Tab>, and
+		// completing nothing is better than printing errors into the prompt.
+		// The global flags before the word completed, such as --url and
+		// --profile, choose the service as they will for the command.
+		words := args[1:]
+		fs.SetOutput(io.Discard)
+		if len(words) > 0 {
+			fs.Parse(words[:len(words)-1])
+		}
+		cfg, _ := loadConfig(profile)
+		a.useConfig(fs, cfg)
+		a.complete(ctx, fs, words)
+		return exitOK
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if !slices.Contains(outputFormats, a.output) {
+		fmt.Fprintf(stderr, "gapfinder: unknown --output %q\n", a.output)
+		usage(fs)
+		return exitUsage
+	}
+
+	// Settings not given as flags come from the config
+	cfg, err := loadConfig(profile)
+	if err != nil {
+		fmt.Fprintf(stderr, "gapfinder: error loading config: %v\n", err)
+		return exitError
+	}
+	set := a.useConfig(fs, cfg)
+	if !set["log-level"] {
+		logLevel = cfg.LogLevel
+	}
+	if !set["log-format"] {
+		logFormat = cfg.LogFormat
+	}
+	if a.logger, err = newLogger(stderr, logLevel, logFormat); err != nil {
+		fmt.Fprintf(stderr, "gapfinder: %v\n", err)
+		usage(fs)
+		return exitUsage
+	}
+	a.field = cfg.Field
+	a.historyPath = cfg.History
+	if a.historyPath == "" {
+		if a.historyPath, err = historyPath(); err != nil {
+			a.historyPath = historyOff
+		}
+	}
+	defer func() {
+		if a.history != nil {
+			a.history.close()
+		}
+	}()
+	if fs.NArg() == 0 {
+		usage(fs)
+		return exitUsage
+	}
+
+	name := fs.Arg(0)
+	for _, cmd := range commands() {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(ctx, a, fs.Args()[1:])
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.Is(err, errUsage):
+			return exitUsage
+		case errors.Is(err, errNoGaps):
+			fmt.Fprintf(stderr, "gapfinder %s: %v\n", name, err)
+			return exitNoGaps
+		}
+		fmt.Fprintf(stderr, "gapfinder %s: %v\n", name, err)
+		return exitError
+	}
+	fmt.Fprintf(stderr, "gapfinder: unknown command %q\n", name)
+	usage(fs)
+	return exitUsage
+}
+
+// useConfig sets the service's address, API key and timeout from cfg unless
+// they were given as flags of fs, and returns the names of the flags given
+func (a *app) useConfig(fs *flag.FlagSet, cfg config) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["url"] {
+		a.baseURL = cfg.URL
+	}
+	if !set["api-key"] {
+		a.apiKey = cfg.APIKey
+	}
+	if !set["timeout"] {
+		a.timeout = cfg.Timeout
+	}
+	return set
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprint(w, "Usage: gapfinder [global flags] <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprint(w, "\nGlobal flags:\n")
+	fs.PrintDefaults()
+}
+
+// newFlagSet returns the flag set for a subcommand, printing its usage line
+// and flags on -h
+func (a *app) newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: gapfinder %s %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	a.flags = fs
+	return fs
+}
+
+// parse parses the flags of a subcommand that takes no arguments, mapping
+// failures to errUsage
+func (a *app) parse(fs *flag.FlagSet, args []string) error {
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, fmt.Errorf("unexpected arguments: %q", fs.Args()))
+	}
+	return nil
+}
+
+// parseFlags parses a subcommand's flags, leaving its arguments to it, and
+// maps failures to errUsage
+func (a *app) parseFlags(fs *flag.FlagSet, args []string) error {
+	if a.defineOnly {
+		return errFlagsDefined
+	}
+	if err := fs.Parse(args); err != nil {
+		return flagError(err)
+	}
+	return nil
+}
+
+// flagError maps a failure to parse flags to errUsage, leaving -h to print
+// the usage and succeed
+func flagError(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return errUsage
+}
+
+// usageError reports a problem with a subcommand's arguments that the flag
+// package doesn't detect, such as conflicting flags, and returns errUsage
+func usageError(fs *flag.FlagSet, err error) error {
+	fmt.Fprintln(fs.Output(), err)
+	fs.Usage()
+	return errUsage
+}
+
+// client creates a service client from the global flags. The extra options
+// of a command override them.
+func (a *app) client(extra ...client.Option) (*client.Client, error) {
+	opts := []client.Option{
+		client.WithBaseURL(a.baseURL),
+		client.WithTimeout(a.timeout),
+		client.WithRetryPolicy(client.DefaultRetryPolicy),
+		client.WithUserAgent("gapfinder-cli/" + version),
+	}
+	if a.apiKey != "" {
+		opts = append(opts, client.WithAPIKey(a.apiKey))
+	}
+	if a.logger != nil {
+		opts = append(opts, client.WithLogger(a.logger))
+	}
+	return client.New(append(opts, extra...)...)
+}
@@ -0,0 +1,1136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/net/websocket"
+)
+
+// runCLI runs the CLI against a server running handler
+func runCLI(t *testing.T, handler http.HandlerFunc, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	return runCLIInput(t, handler, "", args...)
+}
+
+// runCLIInput is runCLI with stdin reading input
+func runCLIInput(t *testing.T, handler http.HandlerFunc, input string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	// Keep the developer's config from affecting the tests
+	t.Setenv("GAPFINDER_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), append([]string{"-url", srv.URL}, args...), strings.NewReader(input), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestAnalyze(t *testing.T) {
+	code, stdout, stderr := runCLI(t, func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/analyze" || req["field"] != "biology" || len(req["authors"].([]any)) != 2 || req["model"] != "gpt-4o-mini" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, req)
+		}
+		w.Write([]byte(`{"key_findings":["finding"],"gaps":[{"gap_description":"small cohort","confidence_score":0.8,"gap_type":"empirical","potential_impact":"high"}],
+			"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1.5}`))
+	}, "analyze", "--title", "T", "--abstract", "A", "--field", "biology", "--authors", "Ada, Grace", "--model", "gpt-4o-mini")
+
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	for _, want := range []string{"Key findings:", "1. small cohort", "Type: empirical, confidence 0.80"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"key_findings":["true"],"gaps":[{"gap_description":"small | cohort","confidence_score":0.8,"gap_type":"empirical","potential_impact":"high"}],
+			"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1.5}`))
+	}
+	tests := map[string][]string{
+		"table":    {"#  GAP             TYPE       CONFIDENCE  IMPACT\n1  small | cohort  empirical  0.80        high\n"},
+		"markdown": {"## Key findings\n\n- true\n", "| 1 | small \\| cohort | empirical | 0.80 | high |\n"},
+		"yaml":     {"key_findings:\n  - \"true\"\ngaps:\n  - gap_description: small | cohort\n    confidence_score: 0.8\n"},
+		"json":     {"{\n  \"key_findings\": [\n    \"true\"\n  ],"},
+	}
+	for format, wants := range tests {
+		code, stdout, stderr := runCLI(t, handler, "-output", format, "analyze", "--title", "T", "--abstract", "A")
+		if code != exitOK {
+			t.Fatalf("%s: exit code = %d, stderr = %s", format, code, stderr)
+		}
+		for _, want := range wants {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("%s output missing %q:\n%s", format, want, stdout)
+			}
+		}
+	}
+}
+
+func TestAnalyzeRequireGap(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["abstract"] != "We studied sleep." {
+			t.Errorf("abstract = %v, want the one read from stdin", req["abstract"])
+		}
+		w.Write([]byte(`{"key_findings":[],"gaps":[{"gap_description":"small cohort","confidence_score":0.6,"gap_type":"empirical","potential_impact":"high"}],
+			"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1}`))
+	}
+	tests := []struct {
+		require  string
+		wantCode int
+	}{
+		{"0.5", exitOK},
+		{"0.6", exitOK},
+		{"0.7", exitNoGaps},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := runCLIInput(t, handler, "We studied sleep.", "analyze", "--title", "T", "--abstract-file", "-", "--require-gap", tt.require)
+		if code != tt.wantCode {
+			t.Errorf("--require-gap %s: exit code = %d, want %d; stderr = %s", tt.require, code, tt.wantCode, stderr)
+		}
+		if !strings.Contains(stdout, "small cohort") {
+			t.Errorf("--require-gap %s: output missing the gaps:\n%s", tt.require, stdout)
+		}
+	}
+}
+
+func TestAnalyzeStdin(t *testing.T) {
+	var got types.AnalyzeRequest
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1}`))
+	}
+	tests := []struct {
+		args                 []string
+		wantTitle, wantAbstr string
+	}{
+		{[]string{"--field", "biology", "-"}, "Sleep and memory", "We studied sleep.\nIt helps."},
+		{[]string{"--title", "T", "-"}, "T", "Sleep and memory\n\nWe studied sleep.\nIt helps.\n"},
+		{[]string{"--abstract-file", "-"}, "Sleep and memory", "We studied sleep.\nIt helps."},
+	}
+	for _, tt := range tests {
+		got = types.AnalyzeRequest{}
+		code, _, stderr := runCLIInput(t, handler, "Sleep and memory\n\nWe studied sleep.\nIt helps.\n", append([]string{"analyze"}, tt.args...)...)
+		if code != exitOK {
+			t.Fatalf("%q: exit code = %d, stderr = %s", tt.args, code, stderr)
+		}
+		if got.Title != tt.wantTitle || got.Abstract != tt.wantAbstr {
+			t.Errorf("%q: title = %q, abstract = %q, want %q, %q", tt.args, got.Title, got.Abstract, tt.wantTitle, tt.wantAbstr)
+		}
+	}
+
+	for _, input := range []string{"We studied sleep.\n", "We studied sleep.\nIt helps.\n"} {
+		if code, _, stderr := runCLIInput(t, handler, input, "analyze", "-"); code != exitUsage || !strings.Contains(stderr, "--title") {
+			t.Errorf("%q without a title line: exit code = %d, stderr = %s", input, code, stderr)
+		}
+	}
+}
+
+func TestTopicValidationError(t *testing.T) {
+	code, _, stderr := runCLI(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid request reached the server")
+	}, "topic", "--topic", "CRISPR", "--max-papers", "100")
+
+	if code != exitError || !strings.Contains(stderr, "max_papers") {
+		t.Errorf("exit code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestTopicCrossFields(t *testing.T) {
+	code, stdout, stderr := runCLI(t, func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		if fields, _ := req["cross_fields"].([]any); len(fields) != 2 || fields[1] != "physics" {
+			t.Errorf("cross_fields = %v, want [biology physics]", req["cross_fields"])
+		}
+		if req["surveys"] != "exclude" {
+			t.Errorf("surveys = %v, want exclude", req["surveys"])
+		}
+		w.Write([]byte(`{"topic":"CRISPR","papers_analyzed":2,"common_gaps":[],"individual_results":[],"suggested_research_directions":[],
+			"intersection_gaps":[{"gap_description":"physical models of Cas9 binding","confidence_score":0.7,"gap_type":"theoretical","potential_impact":"high"}],"processing_time":1}`))
+	}, "topic", "--topic", "CRISPR", "--cross-fields", "biology, physics", "--surveys", "exclude")
+
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "Intersection gaps:\n  1. physical models of Cas9 binding") {
+		t.Errorf("output missing the intersection gaps:\n%s", stdout)
+	}
+}
+
+func TestTopicEstimate(t *testing.T) {
+	code, stdout, stderr := runCLI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topic/estimate" {
+			t.Errorf("path = %s, want /topic/estimate", r.URL.Path)
+		}
+		w.Write([]byte(`{"model":"gpt-4","papers":50,"llm_calls":1,"input_tokens":20000,"output_tokens":7900,
+			"cost_usd":1.074,"duration_seconds":198.5,"processing_time":1.2}`))
+	}, "topic", "--topic", "CRISPR", "--max-papers", "50", "--estimate")
+
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	want := "Papers: 50\nLLM calls: 1\nTokens: about 20000 in, 7900 out\nCost: about $1.07 (gpt-4)\nDuration: about 3m19s\n"
+	if stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+}
+
+func TestTopicProgress(t *testing.T) {
+	code, stdout, stderr := runCLI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topic/ws" {
+			t.Errorf("path = %s, want /topic/ws", r.URL.Path)
+		}
+		websocket.Server{Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			var req types.TopicRequest
+			websocket.JSON.Receive(ws, &req)
+			for _, stage := range []string{types.ProgressFetched, types.ProgressAnalyzing, types.ProgressDone} {
+				websocket.JSON.Send(ws, types.ProgressMessage{Type: types.MessageProgress, Progress: &types.TopicProgress{PaperTitle: "P1", Total: 1, Stage: stage}})
+			}
+			websocket.JSON.Send(ws, types.ProgressMessage{Type: types.MessageResult, Result: &types.TopicResponse{Topic: req.Topic, PapersAnalyzed: 1}})
+		}}.ServeHTTP(w, r)
+	}, "topic", "--topic", "CRISPR", "--progress")
+
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	// stderr isn't a terminal, so each change is logged on its own line
+	if want := "[1/1] P1: fetched\n[1/1] P1: analyzing\n[1/1] P1: done\n"; stderr != want {
+		t.Errorf("stderr = %q, want %q", stderr, want)
+	}
+	if !strings.HasPrefix(stdout, "Topic: CRISPR (1 papers analyzed)") {
+		t.Errorf("stdout = %q, want the topic response", stdout)
+	}
+}
+
+func TestProgressTerminal(t *testing.T) {
+	var out bytes.Buffer
+	p := &progress{w: &out, tty: true}
+	p.update(types.TopicProgress{PaperTitle: "P1", Index: 0, Total: 2, Stage: types.ProgressAnalyzing})
+	p.update(types.TopicProgress{PaperTitle: strings.Repeat("x", 100), Index: 1, Total: 2, Stage: types.ProgressDone})
+
+	want := "\x1b[2KAnalyzed 0 of 2 papers\n\x1b[2K    1. analyzing  P1\n\x1b[2K    2. waiting    \n" +
+		"\x1b[3F\x1b[2KAnalyzed 1 of 2 papers\n\x1b[2K    1. analyzing  P1\n\x1b[2K    2. done       " + strings.Repeat("x", maxTitleWidth-1) + "…\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+// batchHandler analyzes abstracts, refusing those titled "bad"
+func batchHandler(t *testing.T, titles *[]string) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.AnalyzeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		*titles = append(*titles, req.Title)
+		mu.Unlock()
+		if req.Title == "bad" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"detail":"abstract too short"}`))
+			return
+		}
+		if req.Field != "biology" {
+			t.Errorf("%s: field = %q, want the --field default", req.Title, req.Field)
+		}
+		w.Write([]byte(`{"key_findings":["` + req.Title + `"],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1}`))
+	}
+}
+
+func TestBatch(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "papers.jsonl")
+	output := filepath.Join(dir, "results.jsonl")
+	checkpoint := filepath.Join(dir, "checkpoint")
+	os.WriteFile(input, []byte(`{"title":"a","abstract":"A"}
+
+{"title":"bad","abstract":"B"}
+not json
+{"title":"c","abstract":"C"}
+`), 0o644)
+
+	var titles []string
+	args := []string{"batch", "--input", input, "--results", output, "--checkpoint", checkpoint, "--concurrency", "2", "--field", "biology"}
+	code, _, stderr := runCLI(t, batchHandler(t, &titles), args...)
+	if code != exitError || !strings.Contains(stderr, "2 records analyzed, 2 failed, 0 skipped") {
+		t.Errorf("exit code = %d, stderr = %s", code, stderr)
+	}
+	data, _ := os.ReadFile(output)
+	results := map[int]batchResult{}
+	for line := range strings.Lines(string(data)) {
+		var res batchResult
+		if err := json.Unmarshal([]byte(line), &res); err != nil {
+			t.Fatalf("invalid output line %q: %v", line, err)
+		}
+		results[res.Index] = res
+	}
+	if len(results) != 4 || results[0].Result == nil || results[1].Error == "" || !strings.Contains(results[2].Error, "invalid record") ||
+		results[3].Result == nil || results[3].Result.KeyFindings[0] != "c" {
+		t.Errorf("results = %+v", results)
+	}
+
+	// Resuming retries only the failed records
+	titles = nil
+	code, _, stderr = runCLI(t, batchHandler(t, &titles), args...)
+	if code != exitError || !strings.Contains(stderr, "0 records analyzed, 2 failed, 2 skipped") {
+		t.Errorf("resumed: exit code = %d, stderr = %s", code, stderr)
+	}
+	if len(titles) != 1 || titles[0] != "bad" {
+		t.Errorf("resumed: analyzed %q, want only the failed record", titles)
+	}
+}
+
+func TestBatchCSV(t *testing.T) {
+	input := "Title,Abstract,Authors\n\"Sleep, memory\",We studied sleep.,\"Doe, J.; Roe, R.\"\n"
+	code, stdout, stderr := runCLIInput(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.AnalyzeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Authors) != 2 || req.Authors[1] != "Roe, R." {
+			t.Errorf("authors = %q, want [Doe, J. Roe, R.]", req.Authors)
+		}
+		w.Write([]byte(`{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1}`))
+	}, input, "batch", "--input", "-", "--format", "csv", "--field", "biology")
+
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	var res batchResult
+	if err := json.Unmarshal([]byte(stdout), &res); err != nil || res.Title != "Sleep, memory" || res.Result == nil {
+		t.Errorf("stdout = %q (%v)", stdout, err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/topic/estimate":
+			w.Write([]byte(`{"model":"gpt-4o-mini","papers":5,"llm_calls":1,"input_tokens":4000,"output_tokens":1150,"cost_usd":0.0013,"duration_seconds":30,"processing_time":1}`))
+		case "/analyze/estimate":
+			w.Write([]byte(`{"model":"gpt-4o","papers":1,"llm_calls":1,"input_tokens":800,"output_tokens":550,"cost_usd":0.0075,"duration_seconds":13.75,"processing_time":0}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}
+	dryRun := func(t *testing.T, input string, args ...string) (int, *dryRunReport) {
+		t.Helper()
+		code, stdout, stderr := runCLIInput(t, handler, input, append([]string{"--output", "json"}, args...)...)
+		var d dryRunReport
+		if err := json.Unmarshal([]byte(stdout), &d); err != nil {
+			t.Fatalf("%v, stderr = %s", err, stderr)
+		}
+		return code, &d
+	}
+
+	t.Run("analyze", func(t *testing.T) {
+		code, d := dryRun(t, "", "analyze", "--dry-run", "--title", "T", "--abstract", "A", "--field", "biology")
+		if code != exitOK || len(d.Calls) != 1 || !strings.HasSuffix(d.Calls[0].URL, "/analyze") || d.Estimate == nil || d.Estimate.Papers != 1 {
+			t.Fatalf("exit code = %d, report = %+v", code, d)
+		}
+		if body := d.Calls[0].Body.(map[string]any); body["title"] != "T" || body["field"] != "biology" {
+			t.Errorf("body = %v", body)
+		}
+	})
+	t.Run("topic", func(t *testing.T) {
+		code, d := dryRun(t, "", "topic", "--dry-run", "--topic", "CRISPR")
+		if code != exitOK || len(d.Calls) != 1 || !strings.HasSuffix(d.Calls[0].URL, "/topic") {
+			t.Fatalf("exit code = %d, report = %+v", code, d)
+		}
+		if d.Estimate == nil || d.Estimate.Papers != 5 {
+			t.Errorf("estimate = %+v", d.Estimate)
+		}
+	})
+	t.Run("batch", func(t *testing.T) {
+		input := `{"title":"a","abstract":"A"}` + "\n" + `{"title":"","abstract":"B"}` + "\n" + `{"title":"c","abstract":"C"}` + "\n"
+		code, d := dryRun(t, input, "batch", "--input", "-", "--dry-run", "--model", "gpt-4o")
+		if code != exitError {
+			t.Errorf("exit code = %d, want %d for the invalid record", code, exitError)
+		}
+		if len(d.Calls) != 2 || d.Calls[1].Body.(map[string]any)["model"] != "gpt-4o" {
+			t.Errorf("calls = %+v", d.Calls)
+		}
+		if len(d.Invalid) != 1 || d.Invalid[0].Index != 1 || !strings.Contains(d.Invalid[0].Error, "title") {
+			t.Errorf("invalid = %+v", d.Invalid)
+		}
+		if e := d.Estimate; e == nil || e.Papers != 2 || e.InputTokens != 1600 || e.CostUSD == nil || *e.CostUSD != 0.015 || e.DurationSeconds != 13.75 {
+			t.Errorf("estimate = %+v", e)
+		}
+	})
+}
+
+func TestBatchRetryBudget(t *testing.T) {
+	var calls atomic.Int32
+	input := `{"title":"a","abstract":"A"}` + "\n" + `{"title":"b","abstract":"B"}` + "\n"
+	code, _, stderr := runCLIInput(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, input, "batch", "--input", "-", "--concurrency", "1", "--qps", "1000", "--retry-budget", "0")
+
+	if code != exitError || !strings.Contains(stderr, "0 records analyzed, 2 failed") {
+		t.Errorf("exit code = %d, stderr = %s", code, stderr)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("service saw %d requests, want 2 as the retry budget is 0", got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	arxiv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("search_query"); q != "cat:q-bio.NC" {
+			t.Errorf("search_query = %q, want cat:q-bio.NC", q)
+		}
+		w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+			<entry><id>http://arxiv.org/abs/2401.00001v1</id><title>Sleep spindles</title><summary>S</summary></entry>
+			<entry><id>http://arxiv.org/abs/2401.00002v1</id><title>Grid cells</title><summary>G</summary><category term="q-bio.NC"/></entry>
+			<entry><id>http://arxiv.org/abs/2401.00003v1</id><title>Withdrawn</title><summary> </summary></entry>
+		</feed>`))
+	}))
+	defer arxiv.Close()
+	var notified []watchFinding
+	notify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var f watchFinding
+		json.NewDecoder(r.Body).Decode(&f)
+		notified = append(notified, f)
+	}))
+	defer notify.Close()
+
+	var analyzed []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req types.AnalyzeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		analyzed = append(analyzed, req.Title)
+		if len(req.Keywords) > 0 {
+			t.Errorf("%s: keywords = %q, want none", req.Title, req.Keywords)
+		}
+		gaps := `[]`
+		if req.Title == "Sleep spindles" {
+			gaps = `[{"gap_description":"no human data","confidence_score":0.9,"gap_type":"empirical","potential_impact":"high"}]`
+		}
+		w.Write([]byte(`{"key_findings":[],"gaps":` + gaps + `,"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1}`))
+	}
+	state := filepath.Join(t.TempDir(), "seen")
+	args := []string{"watch", "--category", "q-bio.NC", "--once", "--state", state, "--arxiv-url", arxiv.URL, "--notify", notify.URL}
+
+	code, stdout, stderr := runCLI(t, handler, args...)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	var f watchFinding
+	if err := json.Unmarshal([]byte(stdout), &f); err != nil || f.Title != "Sleep spindles" || len(f.Gaps) != 1 {
+		t.Errorf("stdout = %q, want the one paper with gaps", stdout)
+	}
+	if len(notified) != 1 || notified[0].URL != "http://arxiv.org/abs/2401.00001v1" {
+		t.Errorf("notified %+v", notified)
+	}
+	if slices.Contains(analyzed, "Withdrawn") || !strings.Contains(stderr, "skipped Withdrawn: no abstract") {
+		t.Errorf("analyzed %q, stderr = %s; want the paper without an abstract skipped", analyzed, stderr)
+	}
+
+	// The state file keeps a restarted watch from analyzing the papers again,
+	// or skipping them again
+	analyzed = nil
+	if code, stdout, stderr = runCLI(t, handler, args...); code != exitOK || stdout != "" || len(analyzed) != 0 || strings.Contains(stderr, "skipped") {
+		t.Errorf("second poll: exit code = %d, stdout = %q, analyzed %q; stderr = %s", code, stdout, analyzed, stderr)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	var got types.AnalyzeRequest
+	var apiKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		apiKey = r.Header.Get("X-API-Key")
+		w.Write([]byte(`{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1}`))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("url: "+srv.URL+"\napi_key: from-file\nfield: physics\ntimeout: 30s\n"), 0o644)
+	t.Setenv("GAPFINDER_CONFIG", path)
+	analyze := func(args ...string) {
+		t.Helper()
+		var stderr bytes.Buffer
+		args = append(args, "analyze", "--title", "T", "--abstract", "A")
+		if code := run(context.Background(), args, nil, &bytes.Buffer{}, &stderr); code != exitOK {
+			t.Fatalf("exit code = %d, stderr = %s", code, stderr.String())
+		}
+	}
+
+	analyze()
+	if got.Field != types.FieldPhysics || apiKey != "from-file" {
+		t.Errorf("config file: field = %q, API key = %q", got.Field, apiKey)
+	}
+	// The environment overrides the config file, and flags override both
+	t.Setenv("GAPFINDER_FIELD", "biology")
+	t.Setenv("GAPFINDER_API_KEY", "from-env")
+	analyze()
+	if got.Field != types.FieldBiology || apiKey != "from-env" {
+		t.Errorf("environment: field = %q, API key = %q", got.Field, apiKey)
+	}
+	analyze("--api-key", "from-flag")
+	if apiKey != "from-flag" {
+		t.Errorf("flag: API key = %q", apiKey)
+	}
+
+	os.WriteFile(path, []byte("uri: http://typo\n"), 0o644)
+	var stderr bytes.Buffer
+	if code := run(context.Background(), []string{"health"}, nil, &bytes.Buffer{}, &stderr); code != exitError || !strings.Contains(stderr.String(), "uri") {
+		t.Errorf("misspelled setting: exit code = %d, stderr = %s", code, stderr.String())
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	servers := map[string]string{}
+	var hits []string
+	for _, name := range []string{"local", "staging"} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits = append(hits, name+" "+r.Header.Get("X-API-Key"))
+			w.Write([]byte(`{"status":"healthy","version":"1.0.0","timestamp":"now"}`))
+		}))
+		defer srv.Close()
+		servers[name] = srv.URL
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`api_key: shared
+default_profile: local
+profiles:
+  local:
+    url: `+servers["local"]+`
+  staging:
+    url: `+servers["staging"]+`
+    api_key: staging-key
+`), 0o644)
+	t.Setenv("GAPFINDER_CONFIG", path)
+	health := func(args ...string) int {
+		return run(context.Background(), append(args, "health"), nil, &bytes.Buffer{}, &bytes.Buffer{})
+	}
+
+	health()
+	health("--profile", "staging")
+	t.Setenv("GAPFINDER_PROFILE", "staging")
+	health()
+	want := []string{"local shared", "staging staging-key", "staging staging-key"}
+	if !slices.Equal(hits, want) {
+		t.Errorf("requests = %q, want %q", hits, want)
+	}
+	if code := health("--profile", "prod"); code != exitError {
+		t.Errorf("unknown profile: exit code = %d, want %d", code, exitError)
+	}
+}
+
+func TestTUI(t *testing.T) {
+	topic := &types.TopicResponse{
+		Topic: "CRISPR", PapersAnalyzed: 2,
+		CommonGaps: []types.ResearchGap{{GapDescription: "off-target effects", ConfidenceScore: 0.9}},
+		IndividualResults: []types.TopicAnalysisResult{
+			{PaperTitle: "Base editing", Gaps: []types.ResearchGap{{GapDescription: "delivery", ConfidenceScore: 0.7}, {GapDescription: "cost", ConfidenceScore: 0.4}}},
+			{PaperTitle: "Prime editing"},
+		},
+	}
+	analyzer := &client.AnalyzerMock{
+		GenerateHypothesesFunc: func(ctx context.Context, gaps []types.ResearchGap, opts ...client.RequestOption) (*types.HypothesesResponse, error) {
+			return &types.HypothesesResponse{Hypotheses: []types.Hypothesis{{Hypothesis: "LNPs improve " + gaps[0].GapDescription}}}, nil
+		},
+	}
+	export := filepath.Join(t.TempDir(), "gaps.json")
+	m := newTUIModel(context.Background(), analyzer, topic, export)
+	press := func(keys ...string) {
+		for _, k := range keys {
+			var cmd tea.Cmd
+			if k == "tab" {
+				_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+			} else {
+				_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+			}
+			if cmd != nil {
+				m.Update(cmd())
+			}
+		}
+	}
+
+	// Into the first paper's gaps: tag delivery with its hypotheses, dismiss cost
+	press("j", "tab", "t", "h", "j", "d")
+	view := m.View()
+	for _, want := range []string{"Base editing (2)", "★ 0.70 delivery", "✗ 0.40 cost", "Prime editing (0)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	press("e")
+	var exported []exportedGap
+	data, _ := os.ReadFile(export)
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("export %q: %v", data, err)
+	}
+	if len(exported) != 1 || exported[0].Paper != "Base editing" || len(exported[0].Hypotheses) != 1 {
+		t.Errorf("exported %+v, want the tagged gap with its hypothesis", exported)
+	}
+
+	// Without tags, every gap not dismissed is exported
+	press("k", "t", "e")
+	data, _ = os.ReadFile(export)
+	json.Unmarshal(data, &exported)
+	if len(exported) != 2 {
+		t.Errorf("exported %d gaps, want 2", len(exported))
+	}
+}
+
+func TestHealth(t *testing.T) {
+	code, stdout, _ := runCLI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"healthy","version":"1.0.0","timestamp":"now"}`))
+	}, "health")
+
+	if code != exitOK || stdout != "healthy (version 1.0.0)\n" {
+		t.Errorf("exit code = %d, stdout = %q", code, stdout)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"healthy","version":"1.0.0","timestamp":"now"}`))
+	}
+	code, _, stderr := runCLI(t, handler, "--log-level", "debug", "--log-format", "json", "health")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	var msgs []string
+	for line := range strings.Lines(stderr) {
+		var entry struct {
+			Level, Msg, Path string
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		if entry.Level != "DEBUG" || entry.Path != "/health" {
+			t.Errorf("log entry = %+v", entry)
+		}
+		msgs = append(msgs, entry.Msg)
+	}
+	if len(msgs) != 2 {
+		t.Errorf("logged %q, want the request and response", msgs)
+	}
+
+	// Nothing is logged by default, and GAPFINDER_LOG_LEVEL sets the level
+	if _, _, stderr := runCLI(t, handler, "health"); stderr != "" {
+		t.Errorf("stderr = %q, want nothing", stderr)
+	}
+	t.Setenv("GAPFINDER_LOG_LEVEL", "info")
+	if _, _, stderr := runCLI(t, handler, "--log-level", "debug", "health"); !strings.Contains(stderr, "level=DEBUG") {
+		t.Errorf("stderr = %q, want text logs at debug level", stderr)
+	}
+}
+
+func TestModels(t *testing.T) {
+	code, stdout, _ := runCLI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"models":["gpt-4","gpt-4o-mini"],"default_model":"gpt-4"}`))
+	}, "models")
+
+	if code != exitOK || stdout != "gpt-4 (default)\ngpt-4o-mini\n" {
+		t.Errorf("exit code = %d, stdout = %q", code, stdout)
+	}
+}
+
+func TestExport(t *testing.T) {
+	topic := `{"topic":"CRISPR","papers_analyzed":2,"common_gaps":[{"gap_description":"few in vivo studies","confidence_score":0.8,"gap_type":"empirical","potential_impact":"high"}],
+		"individual_results":[{"paper_title":"Off-target effects of Cas9","authors":["Marie Curie","Ada Lovelace"],"abstract":"50% of {targets}","url":"https://doi.org/10.1002/(SICI)1","year":2019,
+			"gaps":[{"gap_description":"small cohort","confidence_score":0.7,"gap_type":"methodological","potential_impact":"medium"}]},
+		{"paper_title":"Off-target effects of base editors","authors":["Pierre Curie"],"abstract":"","url":"","gaps":[]}],
+		"suggested_research_directions":["long-term follow-up"],"processing_time":1}`
+	tmpl := filepath.Join(t.TempDir(), "gaps.tmpl")
+	os.WriteFile(tmpl, []byte(`{{range .Gaps}}{{.GapType}}: {{.GapDescription}} ({{confidence .}}){{"\n"}}{{end}}`), 0o644)
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--format", "markdown"}, []string{"# CRISPR\n", "| few in vivo studies | empirical | 0.80 | high |", "### [Off-target effects of Cas9](<https://doi.org/10.1002/(SICI)1>)", "- small cohort (methodological, confidence 0.70)"}},
+		{[]string{"--format", "bibtex"}, []string{"@misc{curieofftarget,\n  title = {{Off-target effects of Cas9}},\n  author = {Marie Curie and Ada Lovelace},",
+			"year = {2019},\n  url = {https://doi.org/10.1002/(SICI)1},", "abstract = {50\\% of \\{targets\\}}", "annote = {Research gaps: 1. small cohort (methodological, confidence 0.70)}", "@misc{curieofftarget2,"}},
+		{[]string{"--format", "csv"}, []string{"scope,paper,url,gap,type,confidence,impact\ncommon,,,few in vivo studies,empirical,0.80,high\npaper,Off-target effects of Cas9,https://doi.org/10.1002/(SICI)1,small cohort,methodological,0.70,medium\n"}},
+		{[]string{"--template", tmpl}, []string{"empirical: few in vivo studies (0.80)\n"}},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := runCLIInput(t, nil, topic, append([]string{"export"}, tt.args...)...)
+		if code != exitOK {
+			t.Fatalf("%q: exit code = %d, stderr = %s", tt.args, code, stderr)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("%q: output missing %q:\n%s", tt.args, want, stdout)
+			}
+		}
+	}
+
+	// Analyses kept by the service name their paper
+	code, stdout, stderr := runCLI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/analyses/a1" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"analysis_id":"a1","kind":"abstract","title":"CNNs in radiology","field":"medicine","created_at":"2025-01-01T00:00:00Z",
+			"result":{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1}}`))
+	}, "export", "--analysis", "a1", "--format", "bibtex")
+	if code != exitOK || !strings.Contains(stdout, "@misc{cnns,\n  title = {{CNNs in radiology}},\n}") {
+		t.Errorf("exit code = %d, stderr = %s\n%s", code, stderr, stdout)
+	}
+
+	if code, _, stderr := runCLIInput(t, nil, `{"topic":"CRISPR","common_gaps":[]}`, "export", "--format", "bibtex"); code != exitError || !strings.Contains(stderr, "no papers") {
+		t.Errorf("no papers: exit code = %d, stderr = %s", code, stderr)
+	}
+	if code, _, stderr := runCLIInput(t, nil, `{"status":"healthy"}`, "export"); code != exitError || !strings.Contains(stderr, "not the results") {
+		t.Errorf("not results: exit code = %d, stderr = %s", code, stderr)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0o644)
+		return path
+	}
+	before := write("before.json", `{"topic":"CRISPR","papers_analyzed":2,"common_gaps":[
+		{"gap_description":"Few in vivo studies","confidence_score":0.8,"gap_type":"empirical","potential_impact":"high"},
+		{"gap_description":"no long-term data","confidence_score":0.6,"gap_type":"empirical","potential_impact":"high"},
+		{"gap_description":"unclear delivery","confidence_score":0.5,"gap_type":"methodological","potential_impact":"medium"}],
+		"individual_results":[{"paper_title":"Cas9","url":"https://arxiv.org/abs/1","gaps":[]},{"paper_title":"Base editing","url":"","gaps":[]}]}`)
+	after := write("after.json", `{"topic":"CRISPR","papers_analyzed":2,"common_gaps":[
+		{"gap_description":"few in  vivo studies","confidence_score":0.6,"gap_type":"empirical","potential_impact":"high"},
+		{"gap_description":"no long-term data","confidence_score":0.605,"gap_type":"empirical","potential_impact":"high"},
+		{"gap_description":"off-target effects","confidence_score":0.7,"gap_type":"empirical","potential_impact":"high"}],
+		"individual_results":[{"paper_title":"Cas9 revisited","url":"https://arxiv.org/abs/1","gaps":[]},{"paper_title":"Prime editing","url":"","gaps":[]}]}`)
+	analysis := write("analysis.json", `{"gaps":[]}`)
+
+	code, stdout, stderr := runCLI(t, nil, "diff", before, after)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	want := "--- " + before + "\n+++ " + after + `
++ off-target effects (empirical, confidence 0.70)
+- unclear delivery (methodological, confidence 0.50)
+~ few in  vivo studies (empirical, confidence 0.80 -> 0.60)
++ paper: Prime editing
+- paper: Base editing
+`
+	if stdout != want {
+		t.Errorf("output = %q, want %q", stdout, want)
+	}
+
+	code, stdout, _ = runCLI(t, nil, "--output", "json", "diff", "--min-change", "0.3", before, after)
+	var d resultsDiff
+	if err := json.Unmarshal([]byte(stdout), &d); code != exitOK || err != nil || len(d.Added) != 1 || len(d.Removed) != 1 || d.Changed == nil || len(d.Changed) != 0 {
+		t.Errorf("exit code = %d, err = %v, diff = %+v", code, err, d)
+	}
+
+	if code, stdout, _ := runCLI(t, nil, "diff", analysis, analysis); code != exitOK || stdout != "--- "+analysis+"\n+++ "+analysis+"\nNo differences.\n" {
+		t.Errorf("same results: exit code = %d, output = %q", code, stdout)
+	}
+	if code, _, stderr := runCLI(t, nil, "diff", before, analysis); code != exitError || !strings.Contains(stderr, "only be compared") {
+		t.Errorf("topic and abstract: exit code = %d, stderr = %s", code, stderr)
+	}
+}
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	topic := filepath.Join(dir, "topic.json")
+	os.WriteFile(topic, []byte(`{"topic":"CRISPR","papers_analyzed":2,"common_gaps":[
+		{"gap_description":"few in vivo studies","confidence_score":0.7,"gap_type":"empirical","potential_impact":"high"}],
+		"individual_results":[{"paper_title":"Off-target effects of Cas9","authors":["Marie Curie"],"url":"https://arxiv.org/abs/1",
+			"gaps":[{"gap_description":"small cohort","confidence_score":0.5,"gap_type":"methodological","potential_impact":"medium"}]}]}`), 0o644)
+	analysis := `{"gaps":[{"gap_description":"Few in vivo  studies","confidence_score":0.9,"gap_type":"empirical","potential_impact":"high"},
+		{"gap_description":"no <controls>","confidence_score":0.1,"gap_type":"replication","potential_impact":"low"}],
+		"paper":{"source":"crossref","id":"10.1/x","title":"Cas9 in mice","authors":["Ada Lovelace"],"abstract":"","url":"https://doi.org/10.1/x"}}`
+
+	code, stdout, stderr := runCLIInput(t, nil, analysis, "report", "--title", "CRISPR gaps", topic, "-")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr)
+	}
+	for _, want := range []string{
+		"# CRISPR gaps\n\n3 gaps from CRISPR, Cas9 in mice.",
+		"| Methodological gaps | 1 | 0.50 |\n| Empirical gaps | 1 | 0.90 |\n| Replication gaps | 1 | 0.10 |",
+		"## Empirical gaps\n\n```text\n0.0-0.2  " + strings.Repeat(" ", 30) + " 0\n",
+		"0.8-1.0  " + strings.Repeat("█", 30) + " 1\n```\n\n1. few in vivo studies (confidence 0.90, high impact) [2]\n",
+		"1. small cohort (confidence 0.50, medium impact) [1]\n",
+		"## Cited papers\n\n1. Marie Curie. *Off-target effects of Cas9*. <https://arxiv.org/abs/1>\n2. Ada Lovelace. *Cas9 in mice*. <https://doi.org/10.1/x>\n",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("markdown report missing %q:\n%s", want, stdout)
+		}
+	}
+
+	code, stdout, stderr = runCLIInput(t, nil, analysis, "report", "--format", "html", "-")
+	if code != exitOK {
+		t.Fatalf("html: exit code = %d, stderr = %s", code, stderr)
+	}
+	for _, want := range []string{"<h2>Replication gaps</h2>", "no &lt;controls&gt;", `<span class="bar" style="width: 100%"></span>1`, `<li id="paper-1">Ada Lovelace. <em>Cas9 in mice</em>.`} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("html report missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t.Setenv("GAPFINDER_HISTORY", filepath.Join(t.TempDir(), "history.db"))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/analyze":
+			w.Write([]byte(`{"key_findings":[],"gaps":[{"gap_description":"small cohort","confidence_score":0.8,"gap_type":"empirical","potential_impact":"high"}],
+				"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1}`))
+		case "/topic":
+			w.Write([]byte(`{"topic":"CRISPR","papers_analyzed":0,"common_gaps":[],"individual_results":[],"suggested_research_directions":[],"processing_time":1}`))
+		}
+	}
+	if code, _, stderr := runCLI(t, handler, "analyze", "--title", "Sleep and memory", "--abstract", "A", "--field", "neuroscience"); code != exitOK {
+		t.Fatalf("analyze: exit code = %d, stderr = %s", code, stderr)
+	}
+	if code, _, stderr := runCLI(t, handler, "topic", "--topic", "CRISPR"); code != exitOK {
+		t.Fatalf("topic: exit code = %d, stderr = %s", code, stderr)
+	}
+
+	list := func(args ...string) historyList {
+		t.Helper()
+		code, stdout, stderr := runCLI(t, nil, append([]string{"--output", "json", "history"}, args...)...)
+		if code != exitOK {
+			t.Fatalf("%q: exit code = %d, stderr = %s", args, code, stderr)
+		}
+		var entries historyList
+		if err := json.Unmarshal([]byte(stdout), &entries); err != nil {
+			t.Fatal(err)
+		}
+		return entries
+	}
+	titles := func(entries historyList) []string {
+		var titles []string
+		for _, e := range entries {
+			titles = append(titles, e.Title)
+		}
+		return titles
+	}
+	entries := list("list")
+	if got := titles(entries); !slices.Equal(got, []string{"CRISPR", "Sleep and memory"}) {
+		t.Errorf("list = %q, want the newest first", got)
+	}
+	if e := entries[1]; e.ID != 1 || e.Kind != types.AnalysisAbstract || e.Field != types.FieldNeuroscience || e.Gaps != 1 {
+		t.Errorf("entry = %+v", e)
+	}
+	if got := titles(list("list", "--kind", "topic")); !slices.Equal(got, []string{"CRISPR"}) {
+		t.Errorf("list --kind topic = %q", got)
+	}
+	if got := titles(list("search", "SMALL", "cohort", "--limit", "1")); !slices.Equal(got, []string{"Sleep and memory"}) {
+		t.Errorf("search = %q", got)
+	}
+	if got := titles(list("--limit", "1", "list")); !slices.Equal(got, []string{"CRISPR"}) {
+		t.Errorf("list --limit 1 = %q", got)
+	}
+
+	code, stdout, stderr := runCLI(t, nil, "history", "show", "1")
+	if code != exitOK || !strings.Contains(stdout, "1. small cohort") {
+		t.Errorf("show: exit code = %d, stderr = %s\n%s", code, stderr, stdout)
+	}
+	if code, _, stderr := runCLI(t, nil, "history", "show", "99"); code != exitError || !strings.Contains(stderr, "no analysis 99") {
+		t.Errorf("show 99: exit code = %d, stderr = %s", code, stderr)
+	}
+
+	t.Setenv("GAPFINDER_HISTORY", "off")
+	if code, _, stderr := runCLI(t, handler, "topic", "--topic", "CRISPR"); code != exitOK || stderr != "" {
+		t.Errorf("history off: exit code = %d, stderr = %s", code, stderr)
+	}
+	if code, _, stderr := runCLI(t, nil, "history", "list"); code != exitError || !strings.Contains(stderr, "history is off") {
+		t.Errorf("history off: exit code = %d, stderr = %s", code, stderr)
+	}
+}
+
+func TestServeMock(t *testing.T) {
+	// Find a free port to serve on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	t.Setenv("GAPFINDER_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"serve-mock", "--addr", addr, "--analyze-fixture", "empty_gaps"}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	}()
+
+	var stdout bytes.Buffer
+	code := exitError
+	for range 50 {
+		stdout.Reset()
+		if code = run(context.Background(), []string{"--url", "http://" + addr, "--output", "json", "analyze", "--title", "T", "--abstract", "A"}, nil, &stdout, &bytes.Buffer{}); code == exitOK {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	var resp types.AnalyzeResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); code != exitOK || err != nil || len(resp.Gaps) != 0 {
+		t.Errorf("analyze against the mock: exit code = %d, err = %v, gaps = %v", code, err, resp.Gaps)
+	}
+
+	cancel()
+	if code := <-done; code != exitOK {
+		t.Errorf("exit code = %d after stopping", code)
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	doctor := func(t *testing.T, handler http.HandlerFunc, args ...string) (int, map[string]doctorCheck) {
+		t.Helper()
+		code, stdout, stderr := runCLI(t, handler, append(args, "--output", "json", "doctor")...)
+		var rep doctorReport
+		if err := json.Unmarshal([]byte(stdout), &rep); err != nil {
+			t.Fatalf("%v, stderr = %s", err, stderr)
+		}
+		checks := map[string]doctorCheck{}
+		for _, c := range rep.Checks {
+			checks[c.Name] = c
+		}
+		return code, checks
+	}
+	service := func(version string, usage func(w http.ResponseWriter)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/health":
+				fmt.Fprintf(w, `{"status":"healthy","version":%q,"timestamp":"2024-01-01T00:00:00Z"}`, version)
+			case "/usage":
+				usage(w)
+			}
+		}
+	}
+	quota := func(remaining int) func(w http.ResponseWriter) {
+		return func(w http.ResponseWriter) {
+			fmt.Fprintf(w, `{"requests":3,"since":"2024-01-01T00:00:00Z","endpoints":[],"quota":{"limit":100,"remaining":%d,"reset_at":"2024-01-02T00:00:00Z"}}`, remaining)
+		}
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		code, checks := doctor(t, service("1.2.0", quota(97)))
+		if code != exitOK {
+			t.Errorf("exit code = %d", code)
+		}
+		for _, name := range []string{"config", "connectivity", "access", "version", "latency"} {
+			if c := checks[name]; c.Status != checkOK || c.Fix != "" {
+				t.Errorf("%s = %+v, want ok", name, c)
+			}
+		}
+		if !strings.Contains(checks["access"].Detail, "97 of 100") {
+			t.Errorf("access detail = %q", checks["access"].Detail)
+		}
+	})
+	t.Run("refused key", func(t *testing.T) {
+		code, checks := doctor(t, service("1.0.0", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"detail":"invalid API key"}`))
+		}), "--api-key", "wrong")
+		if code != exitError {
+			t.Errorf("exit code = %d, want %d", code, exitError)
+		}
+		if c := checks["access"]; c.Status != checkFail || !strings.Contains(c.Fix, "GAPFINDER_API_KEY") {
+			t.Errorf("access = %+v", c)
+		}
+	})
+	t.Run("low quota and new service", func(t *testing.T) {
+		code, checks := doctor(t, service("2.0.0", quota(5)))
+		if code != exitError {
+			t.Errorf("exit code = %d, want %d", code, exitError)
+		}
+		if c := checks["access"]; c.Status != checkWarn {
+			t.Errorf("access = %+v, want warn", c)
+		}
+		if c := checks["version"]; c.Status != checkFail || !strings.Contains(c.Fix, "go install") {
+			t.Errorf("version = %+v", c)
+		}
+	})
+	t.Run("unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+		code, checks := doctor(t, nil, "--url", srv.URL)
+		if code != exitError {
+			t.Errorf("exit code = %d, want %d", code, exitError)
+		}
+		if c := checks["connectivity"]; c.Status != checkFail || !strings.Contains(c.Fix, "Nothing is listening") {
+			t.Errorf("connectivity = %+v", c)
+		}
+		for _, name := range []string{"access", "version", "latency"} {
+			if c := checks[name]; c.Status != checkSkip {
+				t.Errorf("%s = %+v, want skip", name, c)
+			}
+		}
+	})
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		service, status, fix string
+	}{
+		{"1.4.2", checkOK, ""},
+		{"v1.0.0", checkOK, ""},
+		{"0.9.0", checkFail, "version 0.x"},
+		{"10.0.0", checkFail, "go install"},
+		{"nightly", checkWarn, ""},
+		{"", checkWarn, ""},
+	}
+	for _, tt := range tests {
+		_, status, _, fix := checkVersion(tt.service)
+		if status != tt.status || !strings.Contains(fix, tt.fix) {
+			t.Errorf("checkVersion(%q) = %s, %q; want %s, %q", tt.service, status, fix, tt.status, tt.fix)
+		}
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fields" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"fields":["biology","bioinformatics","physics"]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("GAPFINDER_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	t.Setenv("GAPFINDER_URL", srv.URL)
+	complete := func(words ...string) string {
+		var stdout bytes.Buffer
+		if code := run(context.Background(), append([]string{"__complete"}, words...), nil, &stdout, &bytes.Buffer{}); code != exitOK {
+			t.Errorf("%q: exit code = %d", words, code)
+		}
+		return stdout.String()
+	}
+
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{""}, "analyze\ntopic\nbatch\nwatch\ntui\nmodels\nexport\ndiff\nreport\nhistory\nserve-mock\nhealth\ndoctor\ncompletion\n"},
+		{[]string{"--output", "json", "t"}, "topic\ntui\n"},
+		{[]string{"--o"}, "--output\n"},
+		{[]string{"--output", "y"}, "yaml\n"},
+		{[]string{"--log-format", ""}, "text\njson\n"},
+		{[]string{"analyze", "--abs"}, "--abstract\n--abstract-file\n"},
+		{[]string{"analyze", "--field", "bio"}, "biology\nbioinformatics\n"},
+		{[]string{"topic", "--topic", "CRISPR", "--surveys", ""}, "include\nexclude\ndownweight\n"},
+		{[]string{"batch", "--input", "pap"}, completeFiles + "\n"},
+		{[]string{"analyze", "--title", ""}, ""},
+		{[]string{"completion", "z"}, "zsh\n"},
+		{[]string{"frobnicate", ""}, ""},
+	}
+	for _, tt := range tests {
+		if got := complete(tt.words...); got != tt.want {
+			t.Errorf("completions of %q = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+
+	// The service and profile on the line are asked for the fields
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"fields":["biochemistry"]}`))
+	}))
+	defer other.Close()
+	if got := complete("--url", other.URL, "analyze", "--field", "bio"); got != "biochemistry\n" {
+		t.Errorf("field completions with --url = %q", got)
+	}
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, []byte("profiles:\n  other:\n    url: "+other.URL+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GAPFINDER_CONFIG", config)
+	t.Setenv("GAPFINDER_URL", "")
+	if got := complete("--profile", "other", "analyze", "--field", "bio"); got != "biochemistry\n" {
+		t.Errorf("field completions with --profile = %q", got)
+	}
+
+	// Without the service, the fields this build knows of are offered
+	t.Setenv("GAPFINDER_URL", srv.URL)
+	srv.Close()
+	if got := complete("topic", "--field", "neuro"); got != "neuroscience\n" {
+		t.Errorf("offline field completions = %q", got)
+	}
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		code, stdout, stderr := runCLI(t, nil, "completion", shell)
+		if code != exitOK || !strings.Contains(stdout, "gapfinder __complete") {
+			t.Errorf("%s script: exit code = %d, stderr = %s\n%s", shell, code, stderr, stdout)
+		}
+	}
+}
+
+func TestCommandsDefineFlagsOnly(t *testing.T) {
+	for _, cmd := range commands() {
+		a := &app{stdout: io.Discard, stderr: io.Discard, defineOnly: true}
+		if err := cmd.run(context.Background(), a, nil); !errors.Is(err, errFlagsDefined) || a.flags == nil || a.flags.Name() != cmd.name {
+			t.Errorf("%s: error = %v, flags = %v; want its flags defined and errFlagsDefined", cmd.name, err, a.flags)
+		}
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	tests := map[string][]string{
+		"no command":      {},
+		"unknown command": {"frobnicate"},
+		"unknown flag":    {"health", "--verbose"},
+		"unknown output":  {"--output", "xml", "health"},
+		"log level":       {"--log-level", "loud", "health"},
+		"log format":      {"--log-format", "logfmt", "health"},
+		"extra argument":  {"health", "now"},
+		"two abstracts":   {"analyze", "--title", "T", "--abstract", "A", "--abstract-file", "a.txt"},
+		"abstract files":  {"analyze", "--abstract-file", "a.txt", "-"},
+		"two files":       {"analyze", "a.txt", "b.txt"},
+		"estimate":        {"topic", "--topic", "T", "--estimate", "--progress"},
+		"dry run":         {"topic", "--topic", "T", "--dry-run", "--progress"},
+		"batch input":     {"batch"},
+		"batch format":    {"batch", "--input", "papers.xml", "--format", "xml"},
+		"batch qps":       {"batch", "--input", "papers.jsonl", "--qps", "-1"},
+		"retry budget":    {"batch", "--input", "papers.jsonl", "--retry-budget", "-2"},
+		"watch search":    {"watch"},
+		"watch interval":  {"watch", "--category", "cs.LG", "--interval", "1s"},
+		"watch workers":   {"watch", "--category", "cs.LG", "--concurrency", "0"},
+		"tui source":      {"tui", "--topic", "CRISPR", "--file", "topic.json"},
+		"gap confidence":  {"analyze", "--title", "T", "--abstract", "A", "--require-gap", "2"},
+		"shell":           {"completion", "powershell"},
+		"export format":   {"export", "--format", "ris"},
+		"export template": {"export", "--format", "csv", "--template", "gaps.tmpl"},
+		"diff files":      {"diff", "before.json"},
+		"mock fixture":    {"serve-mock", "--topic-fixture", "huge"},
+		"report files":    {"report"},
+		"report format":   {"report", "--format", "pdf", "topic.json"},
+		"history command": {"history"},
+		"history ID":      {"history", "show", "first"},
+		"history kind":    {"history", "list", "--kind", "paper"},
+		"doctor argument": {"doctor", "now"},
+	}
+	t.Setenv("GAPFINDER_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			if code := run(context.Background(), args, nil, &bytes.Buffer{}, &stderr); code != exitUsage {
+				t.Errorf("exit code = %d, want %d", code, exitUsage)
+			}
+			if !strings.Contains(stderr.String(), "Usage:") {
+				t.Errorf("stderr does not show usage:\n%s", stderr.String())
+			}
+		})
+	}
+}
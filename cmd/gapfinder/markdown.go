@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// The markdown output is meant for pasting into lab notebooks, wikis and
+// issues. Gaps are tables, the rest headed lists.
+
+func markdownAnalysis(w io.Writer, r *types.AnalyzeResponse) {
+	markdownList(w, "Key findings", r.KeyFindings)
+	markdownGaps(w, "Research gaps", r.Gaps)
+	if len(r.SuggestedHypotheses) > 0 {
+		fmt.Fprint(w, "## Suggested hypotheses\n\n")
+		for i, h := range r.SuggestedHypotheses {
+			fmt.Fprintf(w, "%d. **%s** (feasibility %.2f)", i+1, markdownEscape(h.Hypothesis), h.FeasibilityScore)
+			if h.Rationale != "" {
+				fmt.Fprintf(w, "  \n   %s", markdownEscape(h.Rationale))
+			}
+			if len(h.RequiredMethods) > 0 {
+				fmt.Fprintf(w, "  \n   Methods: %s", markdownEscape(strings.Join(h.RequiredMethods, ", ")))
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w)
+	}
+	markdownList(w, "Limitations", r.Limitations)
+	markdownList(w, "Methodology gaps", r.MethodologyGaps)
+	markdownList(w, "Future directions", r.FutureDirections)
+}
+
+func markdownTopic(w io.Writer, r *types.TopicResponse) {
+	fmt.Fprintf(w, "# %s\n\n%d papers analyzed.\n\n", markdownEscape(r.Topic), r.PapersAnalyzed)
+	markdownGaps(w, "Common gaps", r.CommonGaps)
+	markdownGaps(w, "Intersection gaps", r.IntersectionGaps)
+	markdownList(w, "Suggested research directions", r.SuggestedResearchDirections)
+	if len(r.IndividualResults) > 0 {
+		fmt.Fprint(w, "## Papers\n\n")
+		for i, p := range r.IndividualResults {
+			title := markdownEscape(p.PaperTitle)
+			if p.URL != "" {
+				title = fmt.Sprintf("[%s](%s)", title, p.URL)
+			}
+			fmt.Fprintf(w, "%d. %s\n", i+1, title)
+			for _, g := range p.Gaps {
+				fmt.Fprintf(w, "   - %s (%s, confidence %.2f)\n", markdownEscape(g.GapDescription), g.GapType, g.ConfidenceScore)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func markdownEstimate(w io.Writer, r *types.CostEstimate) {
+	fmt.Fprint(w, "| Papers | LLM calls | Input tokens | Output tokens | Cost | Duration |\n")
+	fmt.Fprint(w, "| ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	cost := "unknown"
+	if r.CostUSD != nil {
+		cost = fmt.Sprintf("$%.2f", *r.CostUSD)
+	}
+	fmt.Fprintf(w, "| %d | %d | %d | %d | %s | %s |\n", r.Papers, r.LLMCalls, r.InputTokens, r.OutputTokens, cost,
+		time.Duration(r.DurationSeconds*float64(time.Second)).Round(time.Second))
+}
+
+func markdownModels(w io.Writer, r *types.ModelsResponse) {
+	for _, m := range r.Models {
+		if m == r.DefaultModel {
+			fmt.Fprintf(w, "- `%s` (default)\n", m)
+		} else {
+			fmt.Fprintf(w, "- `%s`\n", m)
+		}
+	}
+}
+
+func markdownHealth(w io.Writer, r *types.HealthResponse) {
+	fmt.Fprintf(w, "**%s** (version %s)\n", r.Status, r.Version)
+}
+
+func markdownDiff(w io.Writer, d *resultsDiff) {
+	fmt.Fprintf(w, "# Changes from %s to %s\n\n", markdownEscape(d.Old), markdownEscape(d.New))
+	markdownGaps(w, "Gaps added", d.Added)
+	markdownGaps(w, "Gaps removed", d.Removed)
+	if len(d.Changed) > 0 {
+		fmt.Fprint(w, "## Confidence changed\n\n")
+		fmt.Fprint(w, "| Gap | Type | Old | New |\n")
+		fmt.Fprint(w, "| --- | --- | ---: | ---: |\n")
+		for _, c := range d.Changed {
+			fmt.Fprintf(w, "| %s | %s | %.2f | %.2f |\n", markdownEscape(c.Gap.GapDescription), c.Gap.GapType, c.OldConfidence, c.Gap.ConfidenceScore)
+		}
+		fmt.Fprintln(w)
+	}
+	markdownList(w, "Papers added", d.PapersAdded)
+	markdownList(w, "Papers removed", d.PapersRemoved)
+}
+
+func markdownHistory(w io.Writer, entries historyList) {
+	fmt.Fprint(w, "| ID | Created | Kind | Title | Field | Gaps |\n")
+	fmt.Fprint(w, "| ---: | --- | --- | --- | --- | ---: |\n")
+	for _, e := range entries {
+		fmt.Fprintf(w, "| %d | %s | %s | %s | %s | %d |\n", e.ID, e.CreatedAt.Local().Format("2006-01-02 15:04"), e.Kind, markdownEscape(e.Title), e.Field, e.Gaps)
+	}
+}
+
+func markdownDoctor(w io.Writer, rep *doctorReport) {
+	fmt.Fprintf(w, "# Diagnosis of %s\n\n", markdownEscape(rep.URL))
+	fmt.Fprint(w, "| Check | Status | Detail | Fix |\n")
+	fmt.Fprint(w, "| --- | --- | --- | --- |\n")
+	for _, c := range rep.Checks {
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", c.Name, c.Status, markdownEscape(c.Detail), markdownEscape(c.Fix))
+	}
+}
+
+func markdownDryRun(w io.Writer, d *dryRunReport) {
+	fmt.Fprint(w, "# Dry run\n\n")
+	for _, c := range d.Calls {
+		body, _ := json.MarshalIndent(c.Body, "", "  ")
+		fmt.Fprintf(w, "`%s %s`\n\n```json\n%s\n```\n\n", c.Method, c.URL, body)
+	}
+	if len(d.Invalid) > 0 {
+		fmt.Fprint(w, "## Invalid records\n\n| # | Title | Error |\n| ---: | --- | --- |\n")
+		for _, in := range d.Invalid {
+			fmt.Fprintf(w, "| %d | %s | %s |\n", in.Index, markdownEscape(in.Title), markdownEscape(in.Error))
+		}
+		fmt.Fprintln(w)
+	}
+	if d.Estimate != nil {
+		fmt.Fprint(w, "## Estimate\n\n")
+		markdownEstimate(w, d.Estimate)
+	}
+}
+
+func markdownGaps(w io.Writer, title string, gaps []types.ResearchGap) {
+	if len(gaps) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "## %s\n\n", title)
+	fmt.Fprint(w, "| # | Gap | Type | Confidence | Impact |\n")
+	fmt.Fprint(w, "| ---: | --- | --- | ---: | --- |\n")
+	for i, g := range gaps {
+		fmt.Fprintf(w, "| %d | %s | %s | %.2f | %s |\n", i+1, markdownEscape(g.GapDescription), g.GapType, g.ConfidenceScore, markdownEscape(g.PotentialImpact))
+	}
+	fmt.Fprintln(w)
+}
+
+func markdownList(w io.Writer, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "## %s\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(w, "- %s\n", markdownEscape(item))
+	}
+	fmt.Fprintln(w)
+}
+
+// markdownEscape keeps text written by the model from breaking tables or
+// being taken for markup
+var markdownEscape = strings.NewReplacer(
+	"\\", "\\\\", "|", "\\|", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]", "<", "&lt;", "\n", " ",
+).Replace
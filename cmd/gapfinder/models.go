@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+)
+
+func runModels(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("models", "")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(models)
+}
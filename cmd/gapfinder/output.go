@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the global --output flag
+const (
+	outputText     = "text"
+	outputTable    = "table"
+	outputMarkdown = "markdown"
+	outputJSON     = "json"
+	outputYAML     = "yaml"
+)
+
+var outputFormats = []string{outputText, outputTable, outputMarkdown, outputJSON, outputYAML}
+
+// format writes each kind of result for people to read
+type format struct {
+	analysis func(io.Writer, *types.AnalyzeResponse)
+	topic    func(io.Writer, *types.TopicResponse)
+	estimate func(io.Writer, *types.CostEstimate)
+	models   func(io.Writer, *types.ModelsResponse)
+	health   func(io.Writer, *types.HealthResponse)
+	diff     func(io.Writer, *resultsDiff)
+	history  func(io.Writer, historyList)
+	doctor   func(io.Writer, *doctorReport)
+	dryRun   func(io.Writer, *dryRunReport)
+}
+
+var formats = map[string]format{
+	outputText:     {printAnalysis, printTopic, printEstimate, printModels, printHealth, printDiff, printHistory, printDoctor, printDryRun},
+	outputTable:    {tableAnalysis, tableTopic, tableEstimate, tableModels, tableHealth, tableDiff, tableHistory, tableDoctor, tableDryRun},
+	outputMarkdown: {markdownAnalysis, markdownTopic, markdownEstimate, markdownModels, markdownHealth, markdownDiff, markdownHistory, markdownDoctor, markdownDryRun},
+}
+
+// print writes a command's result in the format chosen with --output
+func (a *app) print(v any) error {
+	switch a.output {
+	case outputJSON:
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		return writeYAML(a.stdout, v)
+	}
+	f := formats[a.output]
+	switch v := v.(type) {
+	case *types.AnalyzeResponse:
+		f.analysis(a.stdout, v)
+	case *types.TopicResponse:
+		f.topic(a.stdout, v)
+	case *types.CostEstimate:
+		f.estimate(a.stdout, v)
+	case *types.ModelsResponse:
+		f.models(a.stdout, v)
+	case *types.HealthResponse:
+		f.health(a.stdout, v)
+	case *resultsDiff:
+		f.diff(a.stdout, v)
+	case historyList:
+		f.history(a.stdout, v)
+	case *doctorReport:
+		f.doctor(a.stdout, v)
+	case *dryRunReport:
+		f.dryRun(a.stdout, v)
+	default:
+		panic(fmt.Sprintf("no %s output for %T", a.output, v))
+	}
+	return nil
+}
+
+// writeYAML writes v as YAML with the keys and key order of its JSON
+// encoding, which the types are tagged for
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// JSON is YAML, so it decodes into a node that keeps the key order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle drops the flow style and quoting n was decoded from JSON with
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+func printAnalysis(w io.Writer, r *types.AnalyzeResponse) {
+	printList(w, "Key findings", r.KeyFindings)
+	printGaps(w, "Research gaps", r.Gaps)
+
+	if len(r.SuggestedHypotheses) > 0 {
+		fmt.Fprintln(w, "Suggested hypotheses:")
+		for i, h := range r.SuggestedHypotheses {
+			fmt.Fprintf(w, "  %d. %s (feasibility %.2f)\n", i+1, h.Hypothesis, h.FeasibilityScore)
+			if h.Rationale != "" {
+				fmt.Fprintf(w, "     Rationale: %s\n", h.Rationale)
+			}
+			if len(h.RequiredMethods) > 0 {
+				fmt.Fprintf(w, "     Methods: %s\n", strings.Join(h.RequiredMethods, ", "))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	printList(w, "Limitations", r.Limitations)
+	printList(w, "Methodology gaps", r.MethodologyGaps)
+	printList(w, "Future directions", r.FutureDirections)
+	fmt.Fprintf(w, "Completed in %.2fs\n", r.ProcessingTime)
+}
+
+func printTopic(w io.Writer, r *types.TopicResponse) {
+	fmt.Fprintf(w, "Topic: %s (%d papers analyzed)\n\n", r.Topic, r.PapersAnalyzed)
+	printGaps(w, "Common gaps", r.CommonGaps)
+	printGaps(w, "Intersection gaps", r.IntersectionGaps)
+	printList(w, "Suggested research directions", r.SuggestedResearchDirections)
+
+	if len(r.IndividualResults) > 0 {
+		fmt.Fprintln(w, "Papers:")
+		for i, p := range r.IndividualResults {
+			fmt.Fprintf(w, "  %d. %s\n", i+1, p.PaperTitle)
+			if p.URL != "" {
+				fmt.Fprintf(w, "     %s\n", p.URL)
+			}
+			for _, g := range p.Gaps {
+				fmt.Fprintf(w, "     - %s (%s, confidence %.2f)\n", g.GapDescription, g.GapType, g.ConfidenceScore)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Completed in %.2fs\n", r.ProcessingTime)
+}
+
+func printEstimate(w io.Writer, r *types.CostEstimate) {
+	fmt.Fprintf(w, "Papers: %d\n", r.Papers)
+	fmt.Fprintf(w, "LLM calls: %d\n", r.LLMCalls)
+	fmt.Fprintf(w, "Tokens: about %d in, %d out\n", r.InputTokens, r.OutputTokens)
+	if r.CostUSD != nil {
+		fmt.Fprintf(w, "Cost: about $%.2f", *r.CostUSD)
+		if r.Model != "" {
+			fmt.Fprintf(w, " (%s)", r.Model)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Duration: about %s\n", time.Duration(r.DurationSeconds*float64(time.Second)).Round(time.Second))
+}
+
+func printModels(w io.Writer, r *types.ModelsResponse) {
+	for _, m := range r.Models {
+		if m == r.DefaultModel {
+			fmt.Fprintf(w, "%s (default)\n", m)
+		} else {
+			fmt.Fprintln(w, m)
+		}
+	}
+}
+
+func printHealth(w io.Writer, r *types.HealthResponse) {
+	fmt.Fprintf(w, "%s (version %s)\n", r.Status, r.Version)
+}
+
+func printHistory(w io.Writer, entries historyList) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No analyses kept.")
+	}
+	for _, e := range entries {
+		fmt.Fprintf(w, "%4d  %s  %-8s  %s (%s, %d gaps)\n", e.ID, e.CreatedAt.Local().Format("2006-01-02 15:04"), e.Kind, e.Title, e.Field, e.Gaps)
+	}
+}
+
+func printGaps(w io.Writer, title string, gaps []types.ResearchGap) {
+	if len(gaps) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for i, g := range gaps {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, g.GapDescription)
+		fmt.Fprintf(w, "     Type: %s, confidence %.2f\n", g.GapType, g.ConfidenceScore)
+		if g.PotentialImpact != "" {
+			fmt.Fprintf(w, "     Impact: %s\n", g.PotentialImpact)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func printList(w io.Writer, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, item := range items {
+		fmt.Fprintf(w, "  - %s\n", item)
+	}
+	fmt.Fprintln(w)
+}
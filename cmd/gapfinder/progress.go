@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// maxTitleWidth is the width titles are cut to on a terminal, so that no
+// line of the display wraps and throws off redrawing it
+const maxTitleWidth = 60
+
+// progress shows the stage of each paper of a topic analysis. On a terminal
+// it keeps a line per paper up to date; elsewhere, such as in CI logs, it
+// writes a line per change.
+type progress struct {
+	w      io.Writer
+	tty    bool
+	papers []types.TopicProgress // by index; a zero Stage has not been heard of
+	drawn  int                   // lines drawn by the last update
+}
+
+func newProgress(w io.Writer) *progress {
+	return &progress{w: w, tty: isTerminal(w)}
+}
+
+// update records a change of stage and shows it
+func (p *progress) update(u types.TopicProgress) {
+	if !p.tty {
+		fmt.Fprintf(p.w, "[%d/%d] %s: %s\n", u.Index+1, u.Total, u.PaperTitle, u.Stage)
+		return
+	}
+	if n := max(u.Total, u.Index+1); len(p.papers) < n {
+		p.papers = append(p.papers, make([]types.TopicProgress, n-len(p.papers))...)
+	}
+	p.papers[u.Index] = u
+	p.draw()
+}
+
+// draw replaces the lines drawn last with the current stages
+func (p *progress) draw() {
+	if p.drawn > 0 {
+		// Move to the start of the first line drawn
+		fmt.Fprintf(p.w, "\x1b[%dF", p.drawn)
+	}
+	done := 0
+	for _, paper := range p.papers {
+		if paper.Stage == types.ProgressDone || paper.Stage == types.ProgressFailed {
+			done++
+		}
+	}
+	fmt.Fprintf(p.w, "\x1b[2KAnalyzed %d of %d papers\n", done, len(p.papers))
+	for i, paper := range p.papers {
+		stage := paper.Stage
+		if stage == "" {
+			stage = "waiting"
+		}
+		fmt.Fprintf(p.w, "\x1b[2K  %3d. %-9s  %s\n", i+1, stage, truncate(paper.PaperTitle, maxTitleWidth))
+	}
+	p.drawn = len(p.papers) + 1
+}
+
+// truncate cuts s to n runes, marking the cut with an ellipsis
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// isTerminal reports whether a reader or writer is a terminal
+func isTerminal(rw any) bool {
+	f, ok := rw.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// Formats of report
+const (
+	reportMarkdown = "markdown"
+	reportHTML     = "html"
+)
+
+// confidenceBuckets are the ranges of confidence scores the charts of a
+// report count gaps in, the last one including 1
+var confidenceBuckets = []string{"0.0-0.2", "0.2-0.4", "0.4-0.6", "0.6-0.8", "0.8-1.0"}
+
+func runReport(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("report", "[flags] FILE...")
+	var title, reportFormat string
+	fs.StringVar(&title, "title", "Research gap report", "title of the report")
+	fs.StringVar(&reportFormat, "format", reportMarkdown, "format of the report, "+reportMarkdown+" or "+reportHTML)
+	if err := a.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError(fs, errors.New("at least one result file is required, saved with --output json or - for standard input"))
+	}
+	if reportFormat != reportMarkdown && reportFormat != reportHTML {
+		return usageError(fs, fmt.Errorf("unknown --format %q", reportFormat))
+	}
+
+	var all []*results
+	for _, file := range fs.Args() {
+		r, err := a.loadResults(ctx, file, "")
+		if err != nil {
+			return err
+		}
+		if r.title == "" {
+			r.title = file
+		}
+		all = append(all, r)
+	}
+	rep := newReport(title, all)
+	if reportFormat == reportHTML {
+		return reportTemplate.Execute(a.stdout, rep)
+	}
+	writeReportMarkdown(a.stdout, rep)
+	return nil
+}
+
+// report gathers the gaps of several results by type. Gaps found in more
+// than one result are merged, keeping the highest confidence.
+type report struct {
+	Title    string
+	Sources  []string // titles of the results, such as their topics
+	Gaps     int
+	Sections []reportSection
+	Papers   []reportPaper // cited by position, starting at 1
+}
+
+// reportSection holds the gaps of a type, the most confident first
+type reportSection struct {
+	Type  types.GapType
+	Gaps  []*reportGap
+	Chart []reportBar // gaps by confidenceBuckets
+}
+
+// Heading returns the heading of the section, such as "Data gaps"
+func (s reportSection) Heading() string {
+	if s.Type == "" {
+		return "Other gaps"
+	}
+	t := string(s.Type)
+	return strings.ToUpper(t[:1]) + t[1:] + " gaps"
+}
+
+// MeanConfidence returns the mean confidence score of the section's gaps
+func (s reportSection) MeanConfidence() float64 {
+	var sum float64
+	for _, g := range s.Gaps {
+		sum += g.ConfidenceScore
+	}
+	return sum / float64(len(s.Gaps))
+}
+
+// reportBar is a bar of a confidence chart
+type reportBar struct {
+	Label   string
+	Count   int
+	Percent int // of the longest bar of the chart
+}
+
+// reportGap is a gap of a report, with the papers it was found in. Gaps
+// common to the papers of a topic cite none.
+type reportGap struct {
+	types.ResearchGap
+	Papers []int
+}
+
+type reportPaper struct {
+	Title   string
+	Authors []string
+	URL     string
+}
+
+func newReport(title string, all []*results) *report {
+	rep := &report{Title: title}
+	gaps := map[string]*reportGap{}
+	var order []*reportGap
+	add := func(g types.ResearchGap, paper int) {
+		key := gapKeyText(g)
+		rg, ok := gaps[key]
+		if !ok {
+			rg = &reportGap{ResearchGap: g}
+			gaps[key] = rg
+			order = append(order, rg)
+		} else if g.ConfidenceScore > rg.ConfidenceScore {
+			rg.ConfidenceScore = g.ConfidenceScore
+		}
+		if paper > 0 && !slices.Contains(rg.Papers, paper) {
+			rg.Papers = append(rg.Papers, paper)
+		}
+	}
+	// Papers are the same if their URLs are, or their titles if they have none
+	cited := map[string]int{}
+	cite := func(p reportPaper) int {
+		key := cmp.Or(p.URL, strings.ToLower(p.Title))
+		if n, ok := cited[key]; ok {
+			return n
+		}
+		rep.Papers = append(rep.Papers, p)
+		cited[key] = len(rep.Papers)
+		return len(rep.Papers)
+	}
+
+	for _, r := range all {
+		rep.Sources = append(rep.Sources, r.title)
+		if t := r.topic; t != nil {
+			for _, g := range slices.Concat(t.CommonGaps, t.IntersectionGaps) {
+				add(g, 0)
+			}
+			for _, p := range t.IndividualResults {
+				n := cite(reportPaper{Title: p.PaperTitle, Authors: p.Authors, URL: p.URL})
+				for _, g := range p.Gaps {
+					add(g, n)
+				}
+			}
+			continue
+		}
+		paper := reportPaper{Title: r.title}
+		if p := r.analysis.Paper; p != nil {
+			paper = reportPaper{Title: p.Title, Authors: p.Authors, URL: p.URL}
+		}
+		n := cite(paper)
+		for _, g := range r.analysis.Gaps {
+			add(g, n)
+		}
+	}
+
+	byType := map[types.GapType][]*reportGap{}
+	for _, g := range order {
+		byType[g.GapType] = append(byType[g.GapType], g)
+	}
+	// Known types come first, in their usual order, then others, then gaps
+	// without a type
+	kinds := slices.Clone(types.GapTypes)
+	var others []types.GapType
+	for t := range byType {
+		if t != "" && !slices.Contains(types.GapTypes, t) {
+			others = append(others, t)
+		}
+	}
+	slices.Sort(others)
+	kinds = append(append(kinds, others...), "")
+	for _, t := range kinds {
+		section := reportSection{Type: t, Gaps: byType[t]}
+		if len(section.Gaps) == 0 {
+			continue
+		}
+		slices.SortStableFunc(section.Gaps, func(a, b *reportGap) int { return cmp.Compare(b.ConfidenceScore, a.ConfidenceScore) })
+		counts := make([]int, len(confidenceBuckets))
+		for _, g := range section.Gaps {
+			counts[min(max(int(g.ConfidenceScore*float64(len(counts))), 0), len(counts)-1)]++
+		}
+		longest := slices.Max(counts)
+		for i, n := range counts {
+			section.Chart = append(section.Chart, reportBar{Label: confidenceBuckets[i], Count: n, Percent: n * 100 / longest})
+		}
+		rep.Sections = append(rep.Sections, section)
+		rep.Gaps += len(section.Gaps)
+	}
+	return rep
+}
+
+// chartWidth is the length of the longest bar of a Markdown chart
+const chartWidth = 30
+
+func writeReportMarkdown(w io.Writer, rep *report) {
+	fmt.Fprintf(w, "# %s\n\n", markdownEscape(rep.Title))
+	sources := make([]string, len(rep.Sources))
+	for i, s := range rep.Sources {
+		sources[i] = markdownEscape(s)
+	}
+	fmt.Fprintf(w, "%d gaps from %s.\n\n", rep.Gaps, strings.Join(sources, ", "))
+	if len(rep.Sections) > 0 {
+		fmt.Fprint(w, "| Type | Gaps | Mean confidence |\n| --- | ---: | ---: |\n")
+		for _, s := range rep.Sections {
+			fmt.Fprintf(w, "| %s | %d | %.2f |\n", s.Heading(), len(s.Gaps), s.MeanConfidence())
+		}
+		fmt.Fprintln(w)
+	}
+
+	for _, s := range rep.Sections {
+		fmt.Fprintf(w, "## %s\n\n```text\n", s.Heading())
+		for _, bar := range s.Chart {
+			n := bar.Percent * chartWidth / 100
+			fmt.Fprintf(w, "%s  %s%s %d\n", bar.Label, strings.Repeat("█", n), strings.Repeat(" ", chartWidth-n), bar.Count)
+		}
+		fmt.Fprint(w, "```\n\n")
+		for i, g := range s.Gaps {
+			fmt.Fprintf(w, "%d. %s (confidence %.2f", i+1, markdownEscape(g.GapDescription), g.ConfidenceScore)
+			if g.PotentialImpact != "" {
+				fmt.Fprintf(w, ", %s impact", markdownEscape(g.PotentialImpact))
+			}
+			fmt.Fprint(w, ")")
+			for _, n := range g.Papers {
+				fmt.Fprintf(w, " [%d]", n)
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(rep.Papers) > 0 {
+		fmt.Fprint(w, "## Cited papers\n\n")
+		for i, p := range rep.Papers {
+			fmt.Fprintf(w, "%d. ", i+1)
+			if len(p.Authors) > 0 {
+				fmt.Fprintf(w, "%s. ", markdownEscape(strings.Join(p.Authors, ", ")))
+			}
+			fmt.Fprintf(w, "*%s*.", markdownEscape(p.Title))
+			if p.URL != "" {
+				fmt.Fprintf(w, " <%s>", p.URL)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// reportTemplate is the HTML report, a single page with no external assets
+// so it can be mailed or attached
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"add":  func(a, b int) int { return a + b },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+table { border-collapse: collapse; }
+th, td { padding: 0.25rem 0.75rem; border-bottom: 1px solid #ddd; text-align: left; }
+.chart { margin: 1rem 0; }
+.chart div { display: flex; align-items: center; gap: 0.5rem; font-size: 0.85rem; }
+.chart span.label { width: 4rem; }
+.chart span.bar { display: inline-block; height: 0.9rem; background: #4a7bd0; }
+.cite { color: #666; font-size: 0.85rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Gaps}} gaps from {{join .Sources ", "}}.</p>
+{{- if .Sections}}
+<table>
+<tr><th>Type</th><th>Gaps</th><th>Mean confidence</th></tr>
+{{- range .Sections}}
+<tr><td>{{.Heading}}</td><td>{{len .Gaps}}</td><td>{{printf "%.2f" .MeanConfidence}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Sections}}
+<h2>{{.Heading}}</h2>
+<div class="chart">
+{{- range .Chart}}
+<div><span class="label">{{.Label}}</span><span class="bar" style="width: {{.Percent}}%"></span>{{.Count}}</div>
+{{- end}}
+</div>
+<ol>
+{{- range .Gaps}}
+<li>{{.GapDescription}} (confidence {{printf "%.2f" .ConfidenceScore}}{{with .PotentialImpact}}, {{.}} impact{{end}}){{range .Papers}} <a class="cite" href="#paper-{{.}}">[{{.}}]</a>{{end}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- if .Papers}}
+<h2>Cited papers</h2>
+<ol>
+{{- range $i, $p := .Papers}}
+<li id="paper-{{add $i 1}}">{{with $p.Authors}}{{join . ", "}}. {{end}}<em>{{$p.Title}}</em>.{{with $p.URL}} <a href="{{.}}">{{.}}</a>{{end}}</li>
+{{- end}}
+</ol>
+{{- end}}
+</body>
+</html>
+`))
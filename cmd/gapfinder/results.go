@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// results are the results of an analysis made earlier: those of an abstract
+// or of a topic. Exactly one of analysis and topic is set.
+type results struct {
+	title    string // of the paper or the topic, if known
+	analysis *types.AnalyzeResponse
+	topic    *types.TopicResponse
+}
+
+// loadResults reads results kept by the service, if analysisID is set, or
+// else saved with --output json in file, standard input if it is "" or -.
+// Files may also hold an analysis saved from the service's /analyses.
+func (a *app) loadResults(ctx context.Context, file, analysisID string) (*results, error) {
+	if analysisID != "" {
+		c, err := a.client()
+		if err != nil {
+			return nil, err
+		}
+		analysis, err := c.GetAnalysis(ctx, analysisID)
+		if err != nil {
+			return nil, err
+		}
+		return analysisResults(analysis), nil
+	}
+
+	var data []byte
+	var err error
+	if file == "" || file == "-" {
+		file = "standard input"
+		data, err = io.ReadAll(a.stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r, err := decodeResults(data)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", file, err)
+	}
+	return r, nil
+}
+
+// decodeResults decodes an AnalyzeResponse, TopicResponse or Analysis,
+// telling them apart by the fields only each has
+func decodeResults(data []byte) (*results, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	has := func(name string) bool { _, ok := fields[name]; return ok }
+	switch {
+	case has("analysis_id"):
+		var analysis types.Analysis
+		if err := json.Unmarshal(data, &analysis); err != nil {
+			return nil, err
+		}
+		if analysis.Result == nil && analysis.TopicResult == nil {
+			return nil, errors.New("the analysis has no results")
+		}
+		return analysisResults(&analysis), nil
+	case has("individual_results"), has("common_gaps"):
+		r := &results{topic: new(types.TopicResponse)}
+		if err := json.Unmarshal(data, r.topic); err != nil {
+			return nil, err
+		}
+		r.title = r.topic.Topic
+		return r, nil
+	case has("gaps"):
+		r := &results{analysis: new(types.AnalyzeResponse)}
+		if err := json.Unmarshal(data, r.analysis); err != nil {
+			return nil, err
+		}
+		if r.analysis.Paper != nil {
+			r.title = r.analysis.Paper.Title
+		}
+		return r, nil
+	}
+	return nil, errors.New("not the results of an analysis or a topic")
+}
+
+func analysisResults(analysis *types.Analysis) *results {
+	return &results{title: analysis.Title, analysis: analysis.Result, topic: analysis.TopicResult}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/fixtures"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/gapfindertest"
+)
+
+func runServeMock(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("serve-mock", "[flags]")
+	var addr, analyzeFixture, topicFixture string
+	var latency time.Duration
+	fs.StringVar(&addr, "addr", "localhost:8001", "address to listen on; the default is where the CLI calls the service")
+	fs.StringVar(&analyzeFixture, "analyze-fixture", "default", "analysis served to /analyze, one of "+strings.Join(fixtures.AnalyzeNames(), ", "))
+	fs.StringVar(&topicFixture, "topic-fixture", "default", "results served to /topic, one of "+strings.Join(fixtures.TopicNames(), ", "))
+	fs.DurationVar(&latency, "latency", 0, "delay every response by this long, to see how a frontend handles slow analyses")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if !slices.Contains(fixtures.AnalyzeNames(), analyzeFixture) {
+		return usageError(fs, fmt.Errorf("unknown --analyze-fixture %q", analyzeFixture))
+	}
+	if !slices.Contains(fixtures.TopicNames(), topicFixture) {
+		return usageError(fs, fmt.Errorf("unknown --topic-fixture %q", topicFixture))
+	}
+
+	srv, err := gapfindertest.NewServerAt(addr)
+	if err != nil {
+		return err
+	}
+	defer srv.Close()
+	srv.SetAnalyzeResponse(fixtures.MustAnalyze(analyzeFixture))
+	srv.SetTopicResponse(fixtures.MustTopic(topicFixture))
+	srv.SetLatency("", latency)
+	fmt.Fprintf(a.stderr, "Serving the mock service at %s; press Ctrl+C to stop\n", srv.URL)
+	<-ctx.Done()
+	// Stopping the mock is how it is meant to end
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// The table output aligns results in columns for reading in a terminal.
+// Lists of text, such as key findings, are left to the other formats.
+
+func tableAnalysis(w io.Writer, r *types.AnalyzeResponse) {
+	tableGaps(w, r.Gaps)
+	if len(r.SuggestedHypotheses) > 0 {
+		fmt.Fprintln(w)
+		tw := newTable(w, "#", "HYPOTHESIS", "FEASIBILITY", "METHODS")
+		for i, h := range r.SuggestedHypotheses {
+			fmt.Fprintf(tw, "%d\t%s\t%.2f\t%s\n", i+1, h.Hypothesis, h.FeasibilityScore, strings.Join(h.RequiredMethods, ", "))
+		}
+		tw.Flush()
+	}
+}
+
+func tableTopic(w io.Writer, r *types.TopicResponse) {
+	tableGaps(w, slices.Concat(r.CommonGaps, r.IntersectionGaps))
+	if len(r.IndividualResults) > 0 {
+		fmt.Fprintln(w)
+		tw := newTable(w, "#", "PAPER", "GAPS", "URL")
+		for i, p := range r.IndividualResults {
+			fmt.Fprintf(tw, "%d\t%s\t%d\t%s\n", i+1, p.PaperTitle, len(p.Gaps), p.URL)
+		}
+		tw.Flush()
+	}
+}
+
+func tableEstimate(w io.Writer, r *types.CostEstimate) {
+	tw := newTable(w, "PAPERS", "LLM CALLS", "INPUT TOKENS", "OUTPUT TOKENS", "COST", "DURATION")
+	cost := "unknown"
+	if r.CostUSD != nil {
+		cost = fmt.Sprintf("$%.2f", *r.CostUSD)
+	}
+	fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%s\t%s\n", r.Papers, r.LLMCalls, r.InputTokens, r.OutputTokens, cost,
+		time.Duration(r.DurationSeconds*float64(time.Second)).Round(time.Second))
+	tw.Flush()
+}
+
+func tableModels(w io.Writer, r *types.ModelsResponse) {
+	tw := newTable(w, "MODEL", "DEFAULT")
+	for _, m := range r.Models {
+		def := ""
+		if m == r.DefaultModel {
+			def = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", m, def)
+	}
+	tw.Flush()
+}
+
+func tableHealth(w io.Writer, r *types.HealthResponse) {
+	tw := newTable(w, "STATUS", "VERSION")
+	fmt.Fprintf(tw, "%s\t%s\n", r.Status, r.Version)
+	tw.Flush()
+}
+
+func tableDiff(w io.Writer, d *resultsDiff) {
+	tw := newTable(w, "CHANGE", "GAP", "TYPE", "CONFIDENCE")
+	for _, g := range d.Added {
+		fmt.Fprintf(tw, "added\t%s\t%s\t%.2f\n", g.GapDescription, g.GapType, g.ConfidenceScore)
+	}
+	for _, g := range d.Removed {
+		fmt.Fprintf(tw, "removed\t%s\t%s\t%.2f\n", g.GapDescription, g.GapType, g.ConfidenceScore)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(tw, "changed\t%s\t%s\t%.2f -> %.2f\n", c.Gap.GapDescription, c.Gap.GapType, c.OldConfidence, c.Gap.ConfidenceScore)
+	}
+	tw.Flush()
+	if len(d.PapersAdded)+len(d.PapersRemoved) > 0 {
+		fmt.Fprintln(w)
+		tw := newTable(w, "CHANGE", "PAPER")
+		for _, title := range d.PapersAdded {
+			fmt.Fprintf(tw, "added\t%s\n", title)
+		}
+		for _, title := range d.PapersRemoved {
+			fmt.Fprintf(tw, "removed\t%s\n", title)
+		}
+		tw.Flush()
+	}
+}
+
+func tableHistory(w io.Writer, entries historyList) {
+	tw := newTable(w, "ID", "CREATED", "KIND", "TITLE", "FIELD", "GAPS")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%d\n", e.ID, e.CreatedAt.Local().Format("2006-01-02 15:04"), e.Kind, e.Title, e.Field, e.Gaps)
+	}
+	tw.Flush()
+}
+
+func tableDoctor(w io.Writer, rep *doctorReport) {
+	tw := newTable(w, "CHECK", "STATUS", "DETAIL", "FIX")
+	for _, c := range rep.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Name, c.Status, c.Detail, c.Fix)
+	}
+	tw.Flush()
+}
+
+func tableDryRun(w io.Writer, d *dryRunReport) {
+	tw := newTable(w, "METHOD", "URL", "BODY")
+	for _, c := range d.Calls {
+		body, _ := json.Marshal(c.Body)
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Method, c.URL, body)
+	}
+	tw.Flush()
+	if len(d.Invalid) > 0 {
+		fmt.Fprintln(w)
+		tw = newTable(w, "INVALID", "TITLE", "ERROR")
+		for _, in := range d.Invalid {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", in.Index, in.Title, in.Error)
+		}
+		tw.Flush()
+	}
+	if d.Estimate != nil {
+		fmt.Fprintln(w)
+		tableEstimate(w, d.Estimate)
+	}
+}
+
+// tableGaps writes a table of gaps; common gaps of a topic come before its
+// intersection gaps
+func tableGaps(w io.Writer, gaps []types.ResearchGap) {
+	tw := newTable(w, "#", "GAP", "TYPE", "CONFIDENCE", "IMPACT")
+	for i, g := range gaps {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%.2f\t%s\n", i+1, g.GapDescription, g.GapType, g.ConfidenceScore, g.PotentialImpact)
+	}
+	tw.Flush()
+}
+
+// newTable returns a writer aligning tab-separated cells, with a header row
+// already written
+func newTable(w io.Writer, header ...string) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	return tw
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func runTopic(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("topic", "--topic TOPIC [flags]")
+	var req types.TopicRequest
+	var crossFields string
+	var estimate, showProgress, dryRun bool
+	fs.StringVar(&req.Topic, "topic", "", "research topic or keywords (required)")
+	a.fieldFlag(fs, &req.Field)
+	fs.IntVar(&req.MaxPapers, "max-papers", 10, fmt.Sprintf("number of papers to analyze, at most %d", types.MaxPapersLimit))
+	modelFlags(fs, &req.Model, &req.Temperature)
+	languageFlags(fs, &req.Language, &req.TranslateOutput)
+	fs.Float64Var(&req.MinConfidence, "min-confidence", 0, "leave out gaps with a lower confidence score, from 0 to 1")
+	fs.IntVar(&req.MaxGaps, "max-gaps", 0, "return at most this many common gaps, and gaps per paper (default no limit)")
+	fs.StringVar(&crossFields, "cross-fields", "", fmt.Sprintf("comma-separated list of 2 to %d fields to analyze the topic across, reporting the gaps at their intersection", types.MaxCrossFields))
+	fs.StringVar(&req.Surveys, "surveys", types.SurveysInclude, fmt.Sprintf("how to treat survey and review papers: %s, %s or %s", types.SurveysInclude, types.SurveysExclude, types.SurveysDownweight))
+	fs.BoolVar(&estimate, "estimate", false, "print the expected cost and duration of the analysis instead of running it")
+	dryRunFlag(fs, &dryRun)
+	fs.BoolVar(&showProgress, "progress", false, "show each paper's progress on standard error; this makes an LLM call per paper")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if (estimate || dryRun) && showProgress {
+		return usageError(fs, errors.New("--estimate and --dry-run are mutually exclusive with --progress"))
+	}
+	for _, f := range splitList(crossFields) {
+		req.CrossFields = append(req.CrossFields, types.Field(f))
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	if estimate || dryRun {
+		// The estimate checks the request, and the service its model
+		est, err := c.EstimateCost(ctx, req)
+		if err != nil {
+			return err
+		}
+		if estimate {
+			return a.print(est)
+		}
+		return a.print(&dryRunReport{Calls: []plannedCall{a.plan(http.MethodPost, "/topic", req)}, Estimate: est})
+	}
+	var result *types.TopicResponse
+	if showProgress {
+		result, err = c.WatchTopic(ctx, req, newProgress(a.stderr).update)
+	} else {
+		result, err = c.AnalyzeTopic(ctx, req)
+	}
+	if err != nil {
+		return err
+	}
+	a.keep(types.AnalysisTopic, req.Topic, req.Field, result)
+	return a.print(result)
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func runTUI(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("tui", "(--topic TOPIC | --file FILE | --analysis ID) [flags]")
+	var req types.TopicRequest
+	var file, analysisID, export string
+	fs.StringVar(&req.Topic, "topic", "", "analyze this research topic and browse the results")
+	a.fieldFlag(fs, &req.Field)
+	fs.IntVar(&req.MaxPapers, "max-papers", 10, fmt.Sprintf("number of papers to analyze, at most %d", types.MaxPapersLimit))
+	fs.StringVar(&file, "file", "", "browse the topic results saved in `file` with --output json")
+	fs.StringVar(&analysisID, "analysis", "", "browse a topic analysis kept by the service, by `ID`")
+	fs.StringVar(&export, "export", "gaps.json", "`file` the e key writes the tagged gaps to")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	given := 0
+	for _, s := range []string{req.Topic, file, analysisID} {
+		if s != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		return usageError(fs, errors.New("exactly one of --topic, --file and --analysis is required"))
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	var topic *types.TopicResponse
+	if req.Topic != "" {
+		fmt.Fprintf(a.stderr, "Analyzing %q...\n", req.Topic)
+		if topic, err = c.AnalyzeTopic(ctx, req); err != nil {
+			return err
+		}
+		a.keep(types.AnalysisTopic, req.Topic, req.Field, topic)
+	} else {
+		r, err := a.loadResults(ctx, file, analysisID)
+		if err != nil {
+			return err
+		}
+		if r.topic == nil {
+			return errors.New("the results are not of a topic")
+		}
+		topic = r.topic
+	}
+
+	m := newTUIModel(ctx, c, topic, export)
+	_, err = tea.NewProgram(m, tea.WithContext(ctx), tea.WithInput(a.stdin), tea.WithOutput(a.stdout), tea.WithAltScreen()).Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// tuiItem is an entry of the list of papers: a paper, or the topic itself
+// with the gaps common to its papers
+type tuiItem struct {
+	title string
+	url   string
+	gaps  []types.ResearchGap
+	notes []string // research directions of the topic
+}
+
+// gapKey identifies a gap of an item
+type gapKey struct{ item, gap int }
+
+// Panes of the TUI that can have the focus
+const (
+	focusPapers = iota
+	focusGaps
+)
+
+// tuiModel browses topic results: papers on the left, the gaps of the
+// selected one on the right
+type tuiModel struct {
+	ctx    context.Context
+	client client.Analyzer
+	export string // file tagged gaps are exported to
+
+	items  []tuiItem
+	item   int // selected item
+	gap    int // selected gap of the item
+	focus  int
+	width  int
+	height int
+
+	tagged     map[gapKey]bool
+	dismissed  map[gapKey]bool
+	hypotheses map[gapKey][]types.Hypothesis
+	loading    map[gapKey]bool
+	status     string // result of the last action
+}
+
+func newTUIModel(ctx context.Context, c client.Analyzer, topic *types.TopicResponse, export string) *tuiModel {
+	m := &tuiModel{
+		ctx:        ctx,
+		client:     c,
+		export:     export,
+		width:      100,
+		height:     30,
+		tagged:     map[gapKey]bool{},
+		dismissed:  map[gapKey]bool{},
+		hypotheses: map[gapKey][]types.Hypothesis{},
+		loading:    map[gapKey]bool{},
+	}
+	m.items = append(m.items, tuiItem{
+		title: fmt.Sprintf("%s (%d papers)", topic.Topic, topic.PapersAnalyzed),
+		gaps:  slices.Concat(topic.CommonGaps, topic.IntersectionGaps),
+		notes: topic.SuggestedResearchDirections,
+	})
+	for _, p := range topic.IndividualResults {
+		m.items = append(m.items, tuiItem{title: p.PaperTitle, url: p.URL, gaps: p.Gaps})
+	}
+	return m
+}
+
+// hypothesesMsg carries the hypotheses generated for a gap
+type hypothesesMsg struct {
+	key        gapKey
+	hypotheses []types.Hypothesis
+	err        error
+}
+
+func (m *tuiModel) Init() tea.Cmd { return nil }
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case hypothesesMsg:
+		delete(m.loading, msg.key)
+		if msg.err != nil {
+			m.status = "Error generating hypotheses: " + msg.err.Error()
+		} else {
+			m.hypotheses[msg.key] = msg.hypotheses
+		}
+	case tea.KeyMsg:
+		return m, m.key(msg.String())
+	}
+	return m, nil
+}
+
+// key handles a key press
+func (m *tuiModel) key(k string) tea.Cmd {
+	gaps := m.items[m.item].gaps
+	key := gapKey{m.item, m.gap}
+	switch k {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "tab", "left", "right":
+		m.focus = 1 - m.focus
+	case "up", "k":
+		if m.focus == focusPapers {
+			m.item, m.gap = max(m.item-1, 0), 0
+		} else {
+			m.gap = max(m.gap-1, 0)
+		}
+	case "down", "j":
+		if m.focus == focusPapers {
+			m.item, m.gap = min(m.item+1, len(m.items)-1), 0
+		} else {
+			m.gap = max(min(m.gap+1, len(gaps)-1), 0)
+		}
+	case "t":
+		if m.gap < len(gaps) {
+			m.tagged[key] = !m.tagged[key]
+			delete(m.dismissed, key)
+		}
+	case "d":
+		if m.gap < len(gaps) {
+			m.dismissed[key] = !m.dismissed[key]
+			delete(m.tagged, key)
+		}
+	case "h":
+		if m.gap < len(gaps) && !m.loading[key] {
+			m.loading[key] = true
+			gap := gaps[m.gap]
+			return func() tea.Msg {
+				resp, err := m.client.GenerateHypotheses(m.ctx, []types.ResearchGap{gap})
+				if err != nil {
+					return hypothesesMsg{key: key, err: err}
+				}
+				return hypothesesMsg{key: key, hypotheses: resp.Hypotheses}
+			}
+		}
+	case "e":
+		n, err := m.exportTagged()
+		if err != nil {
+			m.status = "Error exporting: " + err.Error()
+		} else {
+			m.status = fmt.Sprintf("Exported %d gaps to %s", n, m.export)
+		}
+	}
+	return nil
+}
+
+// exportedGap is a gap written by the e key
+type exportedGap struct {
+	Paper      string             `json:"paper"`
+	URL        string             `json:"url,omitempty"`
+	Gap        types.ResearchGap  `json:"gap"`
+	Hypotheses []types.Hypothesis `json:"hypotheses,omitempty"`
+}
+
+// exportTagged writes the tagged gaps, or if none are tagged all those not
+// dismissed, to the export file as JSON
+func (m *tuiModel) exportTagged() (int, error) {
+	var all, tagged []exportedGap
+	for i, item := range m.items {
+		for j, g := range item.gaps {
+			key := gapKey{i, j}
+			if m.dismissed[key] {
+				continue
+			}
+			e := exportedGap{Paper: item.title, URL: item.url, Gap: g, Hypotheses: m.hypotheses[key]}
+			all = append(all, e)
+			if m.tagged[key] {
+				tagged = append(tagged, e)
+			}
+		}
+	}
+	if len(tagged) == 0 {
+		tagged = all
+	}
+	data, err := json.MarshalIndent(tagged, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	return len(tagged), os.WriteFile(m.export, append(data, '\n'), 0o644)
+}
+
+var (
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	focusedStyle  = paneStyle.BorderForeground(lipgloss.Color("12"))
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	taggedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	faintStyle    = lipgloss.NewStyle().Faint(true)
+	helpText      = "↑/↓ move  tab switch pane  t tag  d dismiss  h hypotheses  e export  q quit"
+)
+
+func (m *tuiModel) View() string {
+	// Panes lose 4 columns and 2 rows to their border and padding; the
+	// status and help lines take 2 more rows
+	leftWidth := max(m.width/3, 20)
+	rightWidth := max(m.width-leftWidth-8, 20)
+	height := max(m.height-4, 5)
+
+	var papers []string
+	for i, item := range m.items {
+		line := ansi.Truncate(fmt.Sprintf("%s (%d)", item.title, len(item.gaps)), leftWidth, "…")
+		if i == m.item {
+			line = selectedStyle.Render(line)
+		}
+		papers = append(papers, line)
+	}
+
+	right := m.details(rightWidth)
+	left, rightPane := paneStyle, paneStyle
+	if m.focus == focusPapers {
+		left = focusedStyle
+	} else {
+		rightPane = focusedStyle
+	}
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		left.Width(leftWidth+2).Render(strings.Join(window(papers, m.item, height), "\n")),
+		rightPane.Width(rightWidth+2).Render(strings.Join(window(right, m.gap+2, height), "\n")),
+	)
+	return body + "\n" + m.status + "\n" + faintStyle.Render(helpText)
+}
+
+// details returns the lines of the right pane: the selected item's gaps, then
+// more about the selected gap
+func (m *tuiModel) details(width int) []string {
+	item := m.items[m.item]
+	lines := []string{lipgloss.NewStyle().Bold(true).Render(ansi.Truncate(item.title, width, "…")), ""}
+	if len(item.gaps) == 0 {
+		lines = append(lines, faintStyle.Render("No gaps"))
+	}
+	for j, g := range item.gaps {
+		key := gapKey{m.item, j}
+		mark := "  "
+		switch {
+		case m.tagged[key]:
+			mark = "★ "
+		case m.dismissed[key]:
+			mark = "✗ "
+		}
+		line := ansi.Truncate(fmt.Sprintf("%s%.2f %s", mark, g.ConfidenceScore, g.GapDescription), width, "…")
+		switch {
+		case j == m.gap && m.focus == focusGaps:
+			line = selectedStyle.Render(line)
+		case m.tagged[key]:
+			line = taggedStyle.Render(line)
+		case m.dismissed[key]:
+			line = faintStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	if m.gap < len(item.gaps) {
+		g := item.gaps[m.gap]
+		wrap := lipgloss.NewStyle().Width(width)
+		lines = append(lines, "", wrap.Render(g.GapDescription), "")
+		lines = append(lines, wrap.Render(fmt.Sprintf("Type: %s, confidence %.2f", g.GapType, g.ConfidenceScore)))
+		if g.PotentialImpact != "" {
+			lines = append(lines, wrap.Render("Impact: "+g.PotentialImpact))
+		}
+		key := gapKey{m.item, m.gap}
+		switch {
+		case m.loading[key]:
+			lines = append(lines, "", faintStyle.Render("Generating hypotheses..."))
+		case len(m.hypotheses[key]) > 0:
+			lines = append(lines, "", "Hypotheses:")
+			for i, h := range m.hypotheses[key] {
+				lines = append(lines, wrap.Render(fmt.Sprintf("%d. %s (feasibility %.2f)", i+1, h.Hypothesis, h.FeasibilityScore)))
+			}
+		}
+	}
+	if len(item.notes) > 0 {
+		lines = append(lines, "", "Suggested research directions:")
+		for _, n := range item.notes {
+			lines = append(lines, lipgloss.NewStyle().Width(width).Render("- "+n))
+		}
+	}
+	// Wrapped entries hold several lines
+	return strings.Split(strings.Join(lines, "\n"), "\n")
+}
+
+// window returns at most height of lines, scrolled so that line cursor shows
+func window(lines []string, cursor, height int) []string {
+	if len(lines) <= height {
+		return lines
+	}
+	start := min(max(cursor-height/2, 0), len(lines)-height)
+	return lines[start : start+height]
+}
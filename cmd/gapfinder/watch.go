@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/sources"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/sources/arxiv"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// watchFinding is what watch reports of a new paper with research gaps
+type watchFinding struct {
+	URL       string              `json:"url"`
+	Title     string              `json:"title"`
+	Authors   []string            `json:"authors"`
+	Published time.Time           `json:"published"`
+	Gaps      []types.ResearchGap `json:"gaps"`
+}
+
+func runWatch(ctx context.Context, a *app, args []string) error {
+	fs := a.newFlagSet("watch", "(--category CATEGORY | --query QUERY) [flags]")
+	var category, query, store, notify, state, arxivURL string
+	var req types.AnalyzeRequest
+	var maxPapers int
+	var interval time.Duration
+	var once bool
+	var t throttle
+	fs.StringVar(&category, "category", "", "arXiv category to watch, such as cs.LG or q-bio.NC")
+	fs.StringVar(&query, "query", "", "arXiv search to watch, such as \"graph neural networks\"")
+	a.fieldFlag(fs, &req.Field)
+	fs.Float64Var(&req.MinConfidence, "min-confidence", 0, "leave out gaps with a lower confidence score, from 0 to 1")
+	fs.IntVar(&maxPapers, "max-papers", 20, "number of the latest papers checked for new ones at each poll")
+	fs.DurationVar(&interval, "interval", time.Hour, "time between polls of arXiv")
+	fs.BoolVar(&once, "once", false, "poll once and exit, e.g. when run by cron")
+	fs.StringVar(&store, "store", "", "append the gaps found to `file` as JSONL (default standard output)")
+	fs.StringVar(&notify, "notify", "", "also POST each paper's gaps as JSON to `url`")
+	fs.StringVar(&state, "state", "", "remember the papers analyzed in `file`, so they aren't analyzed again after a restart")
+	fs.StringVar(&arxivURL, "arxiv-url", arxiv.DefaultURL, "address of the arXiv API")
+	t.flags(fs, 1)
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if err := t.check(); err != nil {
+		return usageError(fs, err)
+	}
+	var search string
+	switch {
+	case category != "" && query != "":
+		return usageError(fs, errors.New("--category and --query are mutually exclusive"))
+	case category != "":
+		search = "cat:" + category
+	case query != "":
+		search = "all:" + query
+	default:
+		return usageError(fs, errors.New("--category or --query is required"))
+	}
+	if interval < time.Minute {
+		// arXiv asks API users not to poll more often than this
+		return usageError(fs, errors.New("--interval must be at least 1m"))
+	}
+
+	c, err := a.client(t.options()...)
+	if err != nil {
+		return err
+	}
+	w := &watcher{
+		client:      c,
+		arxiv:       arxiv.New(arxiv.WithBaseURL(arxivURL), arxiv.WithInterval(0)),
+		search:      search,
+		max:         maxPapers,
+		concurrency: t.concurrency,
+		req:         req,
+		out:         a.stdout,
+		log:         a.stderr,
+		notify:      notify,
+		notifier:    &http.Client{Timeout: 30 * time.Second},
+		keep:        a.keep,
+		seen:        map[string]bool{},
+	}
+	if store != "" {
+		f, err := os.OpenFile(store, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w.out = f
+	}
+	if state != "" {
+		if err := w.loadState(state); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(state, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w.state = f
+	}
+
+	if once {
+		return w.poll(ctx)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// A failed poll is reported and tried again at the next one
+		if err := w.poll(ctx); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(a.stderr, "gapfinder watch: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			// Interrupting a watch is how it is meant to end
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watcher analyzes the papers that appear on arXiv for a search
+type watcher struct {
+	client      *client.Client
+	arxiv       *arxiv.Client
+	search      string
+	max         int
+	concurrency int                  // number of new papers analyzed at once
+	req         types.AnalyzeRequest // the settings of each paper's analysis
+
+	mu       sync.Mutex // guards out, log, state and seen
+	out      io.Writer  // where findings are appended as JSONL
+	log      io.Writer
+	notify   string // URL findings are POSTed to, if any
+	notifier *http.Client
+	state    io.Writer // where analyzed papers are recorded, if anywhere
+	keep     func(kind, title string, field types.Field, result any)
+
+	seen map[string]bool // URLs of the papers analyzed
+}
+
+// poll analyzes the papers that appeared since the last poll. Papers whose
+// analysis or report fails are left for the next poll; those without an
+// abstract, which can't be analyzed, are skipped for good.
+func (w *watcher) poll(ctx context.Context) error {
+	papers, err := w.arxiv.Search(ctx, arxiv.Query{Search: w.search, SortBy: arxiv.SortSubmitted}, w.max)
+	if err != nil {
+		return err
+	}
+	var fresh []sources.Paper
+	for _, p := range papers {
+		if !w.seen[p.URL] {
+			fresh = append(fresh, p)
+		}
+	}
+
+	work := make(chan sources.Paper)
+	var wg sync.WaitGroup
+	failed := 0
+	var stateErr error
+	// done records a paper, so that it isn't analyzed again. The caller must
+	// hold w.mu.
+	done := func(p sources.Paper) {
+		w.seen[p.URL] = true
+		if w.state != nil && stateErr == nil {
+			if _, err := fmt.Fprintln(w.state, p.URL); err != nil {
+				stateErr = fmt.Errorf("error writing state: %w", err)
+			}
+		}
+	}
+	for range w.concurrency {
+		wg.Go(func() {
+			for p := range work {
+				err := w.analyze(ctx, p)
+				w.mu.Lock()
+				switch {
+				case ctx.Err() != nil:
+				case err != nil:
+					fmt.Fprintf(w.log, "gapfinder watch: %s: %v\n", p.URL, err)
+					failed++
+				default:
+					done(p)
+				}
+				w.mu.Unlock()
+			}
+		})
+	}
+	for _, p := range fresh {
+		if strings.TrimSpace(p.Abstract) == "" {
+			w.mu.Lock()
+			fmt.Fprintf(w.log, "skipped %s: no abstract\n", p.Title)
+			done(p)
+			w.mu.Unlock()
+			continue
+		}
+		work <- p
+	}
+	close(work)
+	wg.Wait()
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case stateErr != nil:
+		return stateErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of the new papers failed and will be retried", failed)
+	}
+	return nil
+}
+
+// analyze analyzes a paper and reports its gaps, if it has any
+func (w *watcher) analyze(ctx context.Context, p sources.Paper) error {
+	req := w.req
+	req.Title, req.Abstract, req.Authors = p.Title, p.Abstract, p.Authors
+	result, err := w.client.AnalyzeAbstract(ctx, req)
+	if err != nil {
+		return err
+	}
+	w.keep(types.AnalysisAbstract, req.Title, req.Field, result)
+	w.mu.Lock()
+	fmt.Fprintf(w.log, "analyzed %s: %d gaps\n", p.Title, len(result.Gaps))
+	w.mu.Unlock()
+	if len(result.Gaps) == 0 {
+		return nil
+	}
+	finding := watchFinding{URL: p.URL, Title: p.Title, Authors: p.Authors, Published: p.Published, Gaps: result.Gaps}
+	body, err := json.Marshal(finding)
+	if err != nil {
+		return err
+	}
+	if w.notify != "" {
+		if err := w.post(ctx, body); err != nil {
+			return err
+		}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.out.Write(append(body, '\n')); err != nil {
+		return fmt.Errorf("error storing gaps: %w", err)
+	}
+	return nil
+}
+
+// post sends a finding to the notification URL
+func (w *watcher) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.notify, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.notifier.Do(req)
+	if err != nil {
+		return fmt.Errorf("error notifying: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// loadState marks the papers recorded in a state file as seen. A missing
+// file records none.
+func (w *watcher) loadState(name string) error {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for line := range strings.Lines(string(data)) {
+		if line = strings.TrimSpace(line); line != "" {
+			w.seen[line] = true
+		}
+	}
+	return nil
+}
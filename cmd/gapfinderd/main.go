@@ -0,0 +1,171 @@
+// Command gapfinderd serves the AI Gap Finder API as a single binary, as an
+// alternative to deploying the Python microservice.
+//
+// Usage:
+//
+//	gapfinderd [flags]
+//
+// The OpenAI backend reads its API key from the OPENAI_API_KEY environment
+// variable. Jobs are called back, signed with the secret in the
+// WEBHOOK_SECRET environment variable, only if it is set. Callers are told
+// apart for -quota by the comma-separated API keys in the QUOTA_KEYS
+// environment variable, or else by address. With -grpc-addr the API is also
+// served over gRPC. Run "gapfinderd -h" for the flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/llm"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/rpc"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/server"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "gapfinderd: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		addr        = flag.String("addr", ":8001", "address to listen on")
+		grpcAddr    = flag.String("grpc-addr", "", "address to serve the gRPC API on (disabled if empty)")
+		backendName = flag.String("backend", "openai", "LLM backend: openai or ollama")
+		model       = flag.String("model", "gpt-4", "model name passed to the backend")
+		models      = flag.String("models", "", "comma-separated models requests may choose besides -model")
+		llmURL      = flag.String("llm-url", "", "base URL of the backend API (default depends on -backend)")
+		temperature = flag.Float64("temperature", 0.7, "sampling temperature")
+		maxTokens   = flag.Int("max-tokens", 2000, "maximum tokens per reply (openai only)")
+		llmTimeout  = flag.Duration("llm-timeout", 2*time.Minute, "time limit for each LLM call")
+		funding     = flag.String("funding-feeds", "", "comma-separated AGENCY=URL feeds of funding calls, RSS or the EU portal's search API (default NSF, NIH and EU)")
+		quota       = flag.Int("quota", 0, "requests each caller may make per -quota-window (unlimited if 0)")
+		quotaWindow = flag.Duration("quota-window", time.Hour, "window of -quota")
+		privateHook = flag.Bool("private-callbacks", false, "allow job callbacks to localhost and private addresses")
+		debug       = flag.Bool("debug", false, "log at debug level")
+	)
+	flag.Parse()
+
+	level := slog.LevelInfo
+	if *debug {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	hc := &http.Client{Timeout: *llmTimeout}
+	var backend llm.Backend
+	switch *backendName {
+	case "openai":
+		key := os.Getenv("OPENAI_API_KEY")
+		if key == "" {
+			return errors.New("OpenAI API key not found; set the OPENAI_API_KEY environment variable")
+		}
+		backend = &llm.OpenAI{
+			APIKey:      key,
+			Model:       *model,
+			BaseURL:     *llmURL,
+			Temperature: *temperature,
+			MaxTokens:   *maxTokens,
+			HTTPClient:  hc,
+		}
+	case "ollama":
+		backend = &llm.Ollama{
+			Model:       *model,
+			BaseURL:     *llmURL,
+			Temperature: *temperature,
+			HTTPClient:  hc,
+		}
+	default:
+		return fmt.Errorf("unknown backend %q", *backendName)
+	}
+
+	// Like the Python service, identify to CrossRef and NCBI if configured
+	crossRef := server.NewCrossRefSource(nil)
+	crossRef.Mailto = os.Getenv("CROSSREF_MAILTO")
+	pubMed := server.NewPubMedSource(nil)
+	pubMed.APIKey = os.Getenv("NCBI_API_KEY")
+	var feeds []server.FundingFeed
+	for f := range strings.SplitSeq(*funding, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		agency, u, ok := strings.Cut(f, "=")
+		if !ok {
+			return fmt.Errorf("invalid funding feed %q; want AGENCY=URL", f)
+		}
+		feeds = append(feeds, server.FundingFeed{Agency: agency, URL: u})
+	}
+	offered := []string{*model}
+	for m := range strings.SplitSeq(*models, ",") {
+		if m = strings.TrimSpace(m); m != "" && m != *model {
+			offered = append(offered, m)
+		}
+	}
+	var quotaKeys []string
+	for k := range strings.SplitSeq(os.Getenv("QUOTA_KEYS"), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			quotaKeys = append(quotaKeys, k)
+		}
+	}
+	opts := []server.Option{server.WithLogger(logger), server.WithModels(offered...),
+		server.WithDOIResolver(crossRef), server.WithPMIDResolver(pubMed),
+		server.WithFundingSource(server.NewFeedFundingSource(nil, feeds...)),
+		server.WithQuota(*quota, *quotaWindow), server.WithQuotaKeys(quotaKeys...)}
+	if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+		opts = append(opts, server.WithWebhooks([]byte(secret), nil))
+		if *privateHook {
+			opts = append(opts, server.WithPrivateCallbacks())
+		}
+	}
+	gapfinder := server.New(backend, opts...)
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           gapfinder,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 2)
+	go func() {
+		logger.Info("listening", "addr", *addr, "backend", *backendName, "model", *model)
+		errc <- srv.ListenAndServe()
+	}()
+
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return err
+		}
+		gs := grpc.NewServer()
+		rpc.NewServer(gapfinder, rpc.WithLogger(logger)).Register(gs)
+		defer gs.GracefulStop()
+		go func() {
+			logger.Info("serving gRPC", "addr", *grpcAddr)
+			errc <- gs.Serve(lis)
+		}()
+	}
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	logger.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
@@ -1,188 +1,47 @@
+// Command go_client demonstrates the gapfinder/client package against a
+// locally running AI Gap Finder microservice.
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
-)
-
-// Request structures matching the Python microservice
-type AnalyzeRequest struct {
-	Title    string   `json:"title"`
-	Abstract string   `json:"abstract"`
-	Field    string   `json:"field"`
-	Authors  []string `json:"authors,omitempty"`
-	Keywords []string `json:"keywords,omitempty"`
-}
-
-type TopicRequest struct {
-	Topic     string `json:"topic"`
-	Field     string `json:"field"`
-	MaxPapers int    `json:"max_papers,omitempty"`
-}
-
-// Response structures
-type ResearchGap struct {
-	GapDescription   string  `json:"gap_description"`
-	ConfidenceScore  float64 `json:"confidence_score"`
-	GapType         string  `json:"gap_type"`
-	PotentialImpact string  `json:"potential_impact"`
-}
-
-type Hypothesis struct {
-	Hypothesis       string   `json:"hypothesis"`
-	Rationale       string   `json:"rationale"`
-	FeasibilityScore float64  `json:"feasibility_score"`
-	RequiredMethods []string `json:"required_methods"`
-}
-
-type AnalyzeResponse struct {
-	KeyFindings        []string      `json:"key_findings"`
-	Gaps              []ResearchGap `json:"gaps"`
-	SuggestedHypotheses []Hypothesis  `json:"suggested_hypotheses"`
-	Limitations       []string      `json:"limitations"`
-	MethodologyGaps   []string      `json:"methodology_gaps"`
-	FutureDirections  []string      `json:"future_directions"`
-	ProcessingTime    float64       `json:"processing_time"`
-}
-
-type TopicAnalysisResult struct {
-	PaperTitle string        `json:"paper_title"`
-	Authors    []string      `json:"authors"`
-	Abstract   string        `json:"abstract"`
-	Gaps       []ResearchGap `json:"gaps"`
-	URL        string        `json:"url"`
-}
-
-type TopicResponse struct {
-	Topic                    string                 `json:"topic"`
-	PapersAnalyzed          int                    `json:"papers_analyzed"`
-	CommonGaps              []ResearchGap          `json:"common_gaps"`
-	IndividualResults       []TopicAnalysisResult  `json:"individual_results"`
-	SuggestedResearchDirections []string            `json:"suggested_research_directions"`
-	ProcessingTime          float64                `json:"processing_time"`
-}
-
-type HealthResponse struct {
-	Status    string `json:"status"`
-	Version   string `json:"version"`
-	Timestamp string `json:"timestamp"`
-}
-
-// AIGapFinderClient is a client for the AI Gap Finder microservice
-type AIGapFinderClient struct {
-	baseURL    string
-	httpClient *http.Client
-}
-
-// NewAIGapFinderClient creates a new client instance
-func NewAIGapFinderClient(baseURL string) *AIGapFinderClient {
-	return &AIGapFinderClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
-	}
-}
-
-// AnalyzeAbstract analyzes a single research abstract
-func (c *AIGapFinderClient) AnalyzeAbstract(req AnalyzeRequest) (*AnalyzeResponse, error) {
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %w", err)
-	}
-
-	url := c.baseURL + "/analyze"
-	resp, err := c.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result AnalyzeResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("error unmarshaling response: %w", err)
-	}
 
-	return &result, nil
-}
-
-// AnalyzeTopic analyzes multiple papers on a topic
-func (c *AIGapFinderClient) AnalyzeTopic(req TopicRequest) (*TopicResponse, error) {
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %w", err)
-	}
-
-	url := c.baseURL + "/topic"
-	resp, err := c.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result TopicResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("error unmarshaling response: %w", err)
-	}
-
-	return &result, nil
-}
-
-// HealthCheck checks if the microservice is healthy
-func (c *AIGapFinderClient) HealthCheck() (*HealthResponse, error) {
-	url := c.baseURL + "/health"
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result HealthResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("error unmarshaling response: %w", err)
-	}
-
-	return &result, nil
-}
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
 
 // Example usage
 func main() {
 	// Create client
-	client := NewAIGapFinderClient("http://localhost:8001")
+	c, err := client.New(
+		client.WithBaseURL("http://localhost:8001"),
+		client.WithTimeout(time.Minute),
+		client.WithRetryPolicy(client.DefaultRetryPolicy),
+		client.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, nil))),
+		client.WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+			return client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				start := time.Now()
+				resp, err := next.RoundTrip(req)
+				fmt.Printf("%s %s took %s\n", req.Method, req.URL.Path, time.Since(start).Round(time.Millisecond))
+				return resp, err
+			})
+		}),
+	)
+	if err != nil {
+		fmt.Printf("Invalid client configuration: %v\n", err)
+		return
+	}
+	ctx := context.Background()
 
-	// Check health
-	health, err := client.HealthCheck()
+	// Check health, waiting in case the service is still starting up
+	waitCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	health, err := c.WaitForHealthy(waitCtx, client.WaitOptions{})
 	if err != nil {
 		fmt.Printf("Health check failed: %v\n", err)
 		return
@@ -190,16 +49,21 @@ func main() {
 	fmt.Printf("Service status: %s\n", health.Status)
 
 	// Analyze an abstract
-	analyzeReq := AnalyzeRequest{
+	analyzeReq := types.AnalyzeRequest{
 		Title:    "Deep Learning Applications in Medical Imaging",
 		Abstract: "This study explores the use of convolutional neural networks for medical image analysis, focusing on diagnostic accuracy improvements. We trained models on radiological datasets and evaluated performance across multiple metrics.",
-		Field:    "medicine",
+		Field:    types.FieldMedicine,
 		Authors:  []string{"Dr. Jane Smith", "Dr. John Doe"},
 	}
 
-	result, err := client.AnalyzeAbstract(analyzeReq)
+	result, err := c.AnalyzeAbstract(ctx, analyzeReq)
 	if err != nil {
-		fmt.Printf("Analysis failed: %v\n", err)
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) {
+			fmt.Printf("Analysis failed with status %d: %s (request ID %s)\n", apiErr.StatusCode, apiErr.Message, apiErr.RequestID)
+		} else {
+			fmt.Printf("Analysis failed: %v\n", err)
+		}
 		return
 	}
 
@@ -210,13 +74,14 @@ func main() {
 	}
 
 	// Analyze a topic
-	topicReq := TopicRequest{
+	topicReq := types.TopicRequest{
 		Topic:     "quantum computing in cryptography",
-		Field:     "computer_science",
+		Field:     types.FieldComputerScience,
 		MaxPapers: 5,
 	}
 
-	topicResult, err := client.AnalyzeTopic(topicReq)
+	// Topic analyses fetch and analyze several papers, so allow more time
+	topicResult, err := c.AnalyzeTopic(ctx, topicReq, client.WithRequestTimeout(5*time.Minute))
 	if err != nil {
 		fmt.Printf("Topic analysis failed: %v\n", err)
 		return
@@ -228,4 +93,10 @@ func main() {
 	for i, gap := range topicResult.CommonGaps {
 		fmt.Printf("  %d. %s (Type: %s)\n", i+1, gap.GapDescription, gap.GapType)
 	}
+
+	fmt.Println("\nClient statistics:")
+	for endpoint, stats := range c.Stats() {
+		fmt.Printf("  %s: %d calls, %d errors, %d retries, avg %s\n",
+			endpoint, stats.Requests, stats.Errors, stats.Retries, stats.AverageLatency().Round(time.Millisecond))
+	}
 }
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// bearerToken holds the current bearer token, which a refresher may replace
+// while requests are in flight. A nil *bearerToken sends no token.
+type bearerToken struct {
+	refresher TokenRefresher
+
+	mu    sync.Mutex
+	value string
+}
+
+// current returns the token without refreshing it
+func (b *bearerToken) current() string {
+	if b == nil {
+		return ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.value
+}
+
+// get returns the token, fetching the first one from the refresher if needed
+func (b *bearerToken) get(ctx context.Context) (string, error) {
+	if b == nil {
+		return "", nil
+	}
+	if token := b.current(); token != "" || b.refresher == nil {
+		return token, nil
+	}
+	if err := b.refresh(ctx, ""); err != nil {
+		return "", err
+	}
+	return b.current(), nil
+}
+
+func (b *bearerToken) canRefresh() bool {
+	return b != nil && b.refresher != nil
+}
+
+// refresh replaces the token that was rejected. If another request already
+// replaced it in the meantime, the newer token is kept.
+func (b *bearerToken) refresh(ctx context.Context, rejected string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.value != rejected {
+		return nil
+	}
+	token, err := b.refresher(ctx)
+	if err != nil {
+		return err
+	}
+	b.value = token
+	return nil
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the service while the
+// circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open: service unavailable")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker tracks consecutive failures against the service. A nil
+// *circuitBreaker allows every request.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// allow reports whether a request may be sent. In the half-open state only
+// the first caller gets through as the probe.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = circuitHalfOpen
+		return nil
+	case circuitHalfOpen:
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// observe records the outcome of an allowed request. Failures caused by the
+// caller cancelling ctx say nothing about the service and aren't counted.
+func (b *circuitBreaker) observe(ctx context.Context, failed bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case failed && ctx.Err() != nil:
+		if b.state == circuitHalfOpen {
+			// Let the next caller probe instead
+			b.state = circuitOpen
+		}
+	case failed:
+		b.failures++
+		if b.state == circuitHalfOpen || b.failures >= b.threshold {
+			b.state = circuitOpen
+			b.openedAt = time.Now()
+		}
+	default:
+		b.state = circuitClosed
+		b.failures = 0
+	}
+}
@@ -0,0 +1,622 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/internal/uuid"
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultBaseURL   = "http://localhost:8001"
+	defaultTimeout   = 60 * time.Second
+	defaultUserAgent = "ai-gap-finder-go/1.0.0"
+
+	// maxErrorBodyBytes bounds how much of an error response is kept
+	maxErrorBodyBytes = 64 << 10
+)
+
+// Analyzer is the set of operations offered by the AI Gap Finder service.
+// *Client implements it; code that depends on Analyzer can be unit
+// tested with AnalyzerMock instead of a live microservice. Mocks of
+// AnalyzeTopicStream can return streams made with NewTopicStream.
+//
+//go:generate go run github.com/matryer/moq@v0.5.3 -out analyzer_mock.go . Analyzer
+type Analyzer interface {
+	AnalyzeAbstract(ctx context.Context, req types.AnalyzeRequest, opts ...RequestOption) (*types.AnalyzeResponse, error)
+	AnalyzeArxiv(ctx context.Context, arxivID string, opts ...RequestOption) (*types.AnalyzeResponse, error)
+	AnalyzeBatch(ctx context.Context, reqs []types.AnalyzeRequest, opts ...RequestOption) (*types.BatchAnalyzeResponse, error)
+	AnalyzeDOI(ctx context.Context, doi string, opts ...RequestOption) (*types.AnalyzeResponse, error)
+	AnalyzePDF(ctx context.Context, pdf io.Reader, meta types.PDFMetadata, opts ...RequestOption) (*types.AnalyzeResponse, error)
+	AnalyzeProposal(ctx context.Context, proposal string, field types.Field, opts ...RequestOption) (*types.ProposalResponse, error)
+	AnalyzePMID(ctx context.Context, pmid string, opts ...RequestOption) (*types.AnalyzeResponse, error)
+	AnalyzeTopic(ctx context.Context, req types.TopicRequest, opts ...RequestOption) (*types.TopicResponse, error)
+	AnalyzeTopicAsync(ctx context.Context, req types.TopicRequest, opts ...RequestOption) (*types.Job, error)
+	AnalyzeTopicStream(ctx context.Context, req types.TopicRequest, opts ...RequestOption) (*TopicStream, error)
+	AnalyzeTrends(ctx context.Context, topic string, yearsBack int, opts ...RequestOption) (*types.TrendsResponse, error)
+	CancelJob(ctx context.Context, jobID string, opts ...RequestOption) (*types.Job, error)
+	CheckNovelty(ctx context.Context, idea string, field types.Field, opts ...RequestOption) (*types.NoveltyResponse, error)
+	ComparePapers(ctx context.Context, req types.CompareRequest, opts ...RequestOption) (*types.ComparisonResponse, error)
+	DeduplicateGaps(ctx context.Context, req types.DeduplicateRequest, opts ...RequestOption) (*types.DeduplicateResponse, error)
+	DeleteAnalysis(ctx context.Context, analysisID string, opts ...RequestOption) error
+	EstimateAnalysis(ctx context.Context, req types.AnalyzeRequest, opts ...RequestOption) (*types.CostEstimate, error)
+	EstimateCost(ctx context.Context, req types.TopicRequest, opts ...RequestOption) (*types.CostEstimate, error)
+	FindResearchGroups(ctx context.Context, topic *types.TopicResponse, opts ...RequestOption) (*types.ResearchGroupsResponse, error)
+	GenerateHypotheses(ctx context.Context, gaps []types.ResearchGap, opts ...RequestOption) (*types.HypothesesResponse, error)
+	GenerateReview(ctx context.Context, topic *types.TopicResponse, opts ...RequestOption) (*types.ReviewResponse, error)
+	GetAnalysis(ctx context.Context, analysisID string, opts ...RequestOption) (*types.Analysis, error)
+	GetJob(ctx context.Context, jobID string, opts ...RequestOption) (*types.Job, error)
+	GetRelatedWork(ctx context.Context, gap types.ResearchGap, opts ...RequestOption) (*types.RelatedWorkResponse, error)
+	GetTopicResults(ctx context.Context, cursor string, opts ...RequestOption) (*types.TopicResultsPage, error)
+	GetUsage(ctx context.Context, opts ...RequestOption) (*types.UsageResponse, error)
+	ListAnalyses(ctx context.Context, q types.AnalysesQuery, opts ...RequestOption) (*types.AnalysesResponse, error)
+	ListFields(ctx context.Context, opts ...RequestOption) (*types.FieldsResponse, error)
+	ListModels(ctx context.Context, opts ...RequestOption) (*types.ModelsResponse, error)
+	MatchFunding(ctx context.Context, gaps []types.ResearchGap, opts ...RequestOption) (*types.FundingResponse, error)
+	RecommendMethods(ctx context.Context, hypothesis types.Hypothesis, opts ...RequestOption) (*types.MethodsResponse, error)
+	Refine(ctx context.Context, previous *types.AnalyzeResponse, feedback string, opts ...RequestOption) (*types.AnalyzeResponse, error)
+	SimulateReview(ctx context.Context, req types.AnalyzeRequest, opts ...RequestOption) (*types.PeerReviewResponse, error)
+	SuggestDatasets(ctx context.Context, req types.DatasetsRequest, opts ...RequestOption) (*types.DatasetsResponse, error)
+	SuggestCitations(ctx context.Context, gap types.ResearchGap, opts ...RequestOption) (*types.CitationsResponse, error)
+	TopicResults(ctx context.Context, resp *types.TopicResponse, opts ...RequestOption) iter.Seq2[types.TopicAnalysisResult, error]
+	WaitForJob(ctx context.Context, jobID string, opts WaitOptions) (*types.Job, error)
+	WatchTopic(ctx context.Context, req types.TopicRequest, fn func(types.TopicProgress), opts ...RequestOption) (*types.TopicResponse, error)
+	HealthCheck(ctx context.Context, opts ...RequestOption) (*types.HealthResponse, error)
+	WaitForHealthy(ctx context.Context, opts WaitOptions) (*types.HealthResponse, error)
+	Do(ctx context.Context, method, path string, reqBody, respOut any, opts ...RequestOption) error
+}
+
+var _ Analyzer = (*Client)(nil)
+
+// Client is a client for the AI Gap Finder microservice
+type Client struct {
+	baseURL          string
+	timeout          time.Duration
+	headers          http.Header
+	baseClient       *http.Client            // as passed to WithHTTPClient
+	transport        http.RoundTripper       // base transport, without middleware
+	transportOptions []func(*http.Transport) // not yet applied to transport
+	middleware       []Middleware
+	httpClient       *http.Client // assembled from the above; nil until built
+	compress         bool
+	maxResponseBytes int64
+	strict           bool
+	apiKey           string
+	bearer           *bearerToken
+	tokens           oauth2.TokenSource
+	retry            RetryPolicy
+	limiter          *rate.Limiter
+	budget           *retryBudget
+	breaker          *circuitBreaker
+	stats            *clientStats
+	logger           *slog.Logger
+}
+
+// Option configures a Client
+type Option func(*Client) error
+
+// New creates a new client. Without options the client talks to
+// http://localhost:8001 and gives each call 60 seconds.
+func New(opts ...Option) (*Client, error) {
+	c := &Client{
+		baseURL:  defaultBaseURL,
+		timeout:  defaultTimeout,
+		headers:  make(http.Header),
+		compress: true,
+		stats:    newClientStats(),
+		logger:   slog.New(slog.DiscardHandler),
+	}
+	if err := c.apply(opts); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Clone returns a copy of the client with opts applied on top of its
+// configuration, e.g. a different API key or timeout per tenant. The copy
+// shares the underlying transport and connection pool, rate limiter, retry
+// budget and circuit breaker with c unless opts replace them; transport-level
+// options (TLS, proxy, connection pool) give the copy a transport of its own.
+// Stats are tracked separately for the copy.
+func (c *Client) Clone(opts ...Option) (*Client, error) {
+	clone := *c
+	clone.headers = c.headers.Clone()
+	clone.stats = newClientStats()
+	// Middleware added to the copy must not end up in c's chain
+	clone.middleware = slices.Clip(c.middleware)
+	if err := clone.apply(opts); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// apply applies opts and assembles the HTTP client
+func (c *Client) apply(opts []Option) error {
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return err
+		}
+	}
+	if c.tokens != nil && c.bearer != nil {
+		return errors.New("WithTokenSource cannot be combined with WithBearerToken or WithTokenRefresher")
+	}
+	return c.buildHTTPClient()
+}
+
+// buildHTTPClient assembles the HTTP client that sends requests from the
+// configured client, base transport, transport settings and middleware,
+// unless the one already assembled is up to date. Transport settings are
+// applied to a copy of the base transport, which then replaces it, so a
+// clone can add settings and middleware on top of its parent's. A client
+// passed in with WithHTTPClient is copied rather than modified.
+func (c *Client) buildHTTPClient() error {
+	if c.httpClient != nil && len(c.transportOptions) == 0 {
+		return nil
+	}
+	base := c.baseClient
+	if base == nil {
+		base = &http.Client{}
+	}
+
+	transport := c.transport
+	if transport == nil {
+		transport = base.Transport
+	}
+	if len(c.transportOptions) > 0 {
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		t, ok := transport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("TLS, proxy and connection options require an *http.Transport, got %T", transport)
+		}
+		t = t.Clone()
+		for _, apply := range c.transportOptions {
+			apply(t)
+		}
+		c.transport, c.transportOptions = t, nil
+		transport = t
+	}
+	if c.transport == nil && len(c.middleware) == 0 {
+		c.httpClient = base
+		return nil
+	}
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		transport = c.middleware[i](transport)
+	}
+	hc := *base
+	hc.Transport = transport
+	c.httpClient = &hc
+	return nil
+}
+
+// newRequest builds a request against the microservice with the client's
+// default headers applied
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	for k, v := range c.headers {
+		req.Header[k] = slices.Clone(v)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", defaultUserAgent)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.bearer != nil {
+		token, err := c.bearer.get(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error obtaining bearer token: %w", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	if c.tokens != nil {
+		token, err := c.tokens.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error obtaining OAuth2 token: %w", err)
+		}
+		token.SetAuthHeader(req)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// Setting Accept-Encoding explicitly also stops net/http from negotiating
+	// compression on its own, so decoding stays under the client's control
+	// regardless of the transport in use.
+	if c.compress {
+		req.Header.Set("Accept-Encoding", "gzip")
+	} else {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+	return req, nil
+}
+
+// Do calls an arbitrary endpoint of the service, for endpoints this client
+// doesn't have a method for yet. reqBody, if non-nil, is sent as JSON and a
+// JSON response is decoded into respOut, if non-nil. The call gets the same
+// authentication, retries, limits and error handling as the typed methods.
+//
+//	var out struct{ Fields []string `json:"fields"` }
+//	err := client.Do(ctx, http.MethodGet, "/fields", nil, &out)
+func (c *Client) Do(ctx context.Context, method, path string, reqBody, respOut any, opts ...RequestOption) error {
+	_, err := c.do(ctx, method, path, reqBody, respOut, opts)
+	return err
+}
+
+// do implements Do and returns the X-Request-ID the call was sent with
+func (c *Client) do(ctx context.Context, method, path string, reqBody, respOut any, opts []RequestOption) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("path %q must start with /", path)
+	}
+	rc := requestConfig{timeout: c.timeout}
+	for _, opt := range opts {
+		opt(&rc)
+	}
+	if rc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rc.timeout)
+		defer cancel()
+	}
+
+	// Every attempt of a POST carries the same key, so the service can tell a
+	// retry from a new request and avoid analyzing the same input twice
+	if method == http.MethodPost && rc.idempotencyKey == "" {
+		rc.idempotencyKey = uuid.New()
+	}
+	rc.requestID = RequestIDFromContext(ctx)
+	if rc.requestID == "" {
+		rc.requestID = uuid.New()
+	}
+
+	var payload []byte
+	switch body := reqBody.(type) {
+	case nil:
+	case rawBody:
+		payload, rc.contentType = body.data, body.contentType
+	default:
+		var err error
+		payload, err = json.Marshal(reqBody)
+		if err != nil {
+			return "", fmt.Errorf("error marshaling request: %w", err)
+		}
+	}
+
+	start := time.Now()
+	sent, err := c.send(ctx, method, path, &rc, payload, respOut)
+	elapsed := time.Since(start)
+	endpoint, _, _ := strings.Cut(path, "?")
+	c.stats.record(method+" "+endpoint, sent, err, elapsed)
+	if err != nil {
+		c.logger.WarnContext(ctx, "gap finder request failed",
+			"method", method, "path", endpoint, "request_id", rc.requestID,
+			"attempts", sent, "duration", elapsed, "error", err)
+		return "", err
+	}
+	return rc.requestID, nil
+}
+
+// send performs the attempts of a call, retrying according to the client's
+// retry policy, and reports how many requests it sent
+func (c *Client) send(ctx context.Context, method, path string, rc *requestConfig, payload []byte, respOut any) (int, error) {
+	attempts := max(c.retry.MaxAttempts, 1)
+	refreshed := false
+	sent := 0
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		sentToken := c.bearer.current()
+		retryable, err := c.attempt(ctx, method, path, rc, payload, respOut)
+		sent++
+		if err == nil {
+			return sent, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+
+		if !refreshed && c.bearer.canRefresh() && errors.Is(err, ErrUnauthorized) {
+			// Re-send once with fresh credentials; this doesn't use up a retry
+			refreshed = true
+			c.logger.InfoContext(ctx, "refreshing bearer token after 401",
+				"method", method, "path", path, "request_id", rc.requestID)
+			if err := c.bearer.refresh(ctx, sentToken); err != nil {
+				return sent, fmt.Errorf("error refreshing bearer token: %w", err)
+			}
+			attempt--
+			continue
+		}
+
+		delay := c.retry.backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			if apiErr.StatusCode == http.StatusTooManyRequests {
+				retryable = c.retry.RetryOnRateLimit
+			}
+			if apiErr.RetryAfter > 0 {
+				if c.retry.MaxRetryAfter > 0 && apiErr.RetryAfter > c.retry.MaxRetryAfter {
+					break
+				}
+				delay = apiErr.RetryAfter
+			}
+		}
+		if !retryable || attempt >= attempts {
+			break
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// Waiting would outlive the call anyway
+			break
+		}
+		if !c.budget.take() {
+			c.logger.WarnContext(ctx, "retry budget spent, not retrying gap finder request",
+				"method", method, "path", path, "request_id", rc.requestID, "error", err)
+			break
+		}
+		c.logger.InfoContext(ctx, "retrying gap finder request",
+			"method", method, "path", path, "request_id", rc.requestID,
+			"attempt", attempt+1, "max_attempts", attempts, "delay", delay, "error", err)
+		if err := sleepContext(ctx, delay); err != nil {
+			return sent, fmt.Errorf("%w; last error: %v", err, lastErr)
+		}
+	}
+	return sent, lastErr
+}
+
+// attempt performs a single round trip. The returned bool reports whether
+// the failure is transient and the request may be retried.
+func (c *Client) attempt(ctx context.Context, method, path string, rc *requestConfig, payload []byte, out any) (bool, error) {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return false, fmt.Errorf("rate limiter: %w", err)
+		}
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	httpReq, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return false, err
+	}
+	if rc.idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", rc.idempotencyKey)
+	}
+	httpReq.Header.Set("X-Request-ID", rc.requestID)
+	if rc.callbackURL != "" {
+		httpReq.Header.Set("Callback-URL", rc.callbackURL)
+	}
+	if rc.contentType != "" {
+		httpReq.Header.Set("Content-Type", rc.contentType)
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		return false, err
+	}
+	c.logger.DebugContext(ctx, "sending gap finder request",
+		"method", method, "path", path, "request_id", rc.requestID)
+	start := time.Now()
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		c.breaker.observe(ctx, true)
+		return isTransientError(err), fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+	c.breaker.observe(ctx, resp.StatusCode >= http.StatusInternalServerError)
+	c.logger.DebugContext(ctx, "received gap finder response",
+		"method", method, "path", path, "request_id", rc.requestID,
+		"status", resp.StatusCode, "duration", time.Since(start))
+
+	// Not every RoundTripper aborts body reads when ctx is cancelled. Closing
+	// the body does, and releases the connection right away instead of after
+	// the rest of a large response has arrived.
+	stop := context.AfterFunc(ctx, func() { resp.Body.Close() })
+	defer stop()
+
+	var reader io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			err = contextError(ctx, err)
+			return isTransientError(err), fmt.Errorf("error decompressing response: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	if c.maxResponseBytes > 0 {
+		reader = &maxBytesReader{r: reader, remaining: c.maxResponseBytes}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, err := io.ReadAll(io.LimitReader(reader, maxErrorBodyBytes))
+		if err != nil {
+			err = contextError(ctx, err)
+			return isTransientError(err), fmt.Errorf("error reading response: %w", err)
+		}
+		apiErr := newAPIError(resp, respBody)
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = rc.requestID
+		}
+		return isRetryableStatus(resp.StatusCode), apiErr
+	}
+
+	if sd, ok := out.(streamDecoder); ok {
+		if err := sd.decodeStream(reader); err != nil {
+			err = contextError(ctx, err)
+			return isTransientError(err) && !sd.started(), fmt.Errorf("error reading stream: %w", err)
+		}
+		return false, nil
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := c.decode(reader, out); err != nil {
+			err = contextError(ctx, err)
+			return isTransientError(err), fmt.Errorf("error unmarshaling response: %w", err)
+		}
+	}
+	// Drain what's left so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodyBytes))
+	return false, nil
+}
+
+// contextError returns ctx's error in place of err if ctx is done, since
+// errors from a body closed on cancellation don't say why it was closed
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// AnalyzeAbstract analyzes a single research abstract. Requests that fail
+// validation are rejected with a *types.ValidationError without being sent.
+func (c *Client) AnalyzeAbstract(ctx context.Context, req types.AnalyzeRequest, opts ...RequestOption) (*types.AnalyzeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.AnalyzeResponse
+	id, err := c.do(ctx, http.MethodPost, "/analyze", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// AnalyzeBatch analyzes up to types.MaxBatchSize abstracts in one round
+// trip. The call fails only if the batch as a whole does; abstracts whose
+// analysis failed are reported in the Error of their result. Batches that
+// fail validation are rejected with a *types.ValidationError without being
+// sent.
+func (c *Client) AnalyzeBatch(ctx context.Context, reqs []types.AnalyzeRequest, opts ...RequestOption) (*types.BatchAnalyzeResponse, error) {
+	req := types.BatchAnalyzeRequest{Requests: reqs}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.BatchAnalyzeResponse
+	id, err := c.do(ctx, http.MethodPost, "/analyze/batch", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// AnalyzeTopic analyzes multiple papers on a topic. Requests that fail
+// validation are rejected with a *types.ValidationError without being sent.
+func (c *Client) AnalyzeTopic(ctx context.Context, req types.TopicRequest, opts ...RequestOption) (*types.TopicResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.TopicResponse
+	id, err := c.do(ctx, http.MethodPost, "/topic", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// EstimateCost returns the expected tokens, price and duration of
+// AnalyzeTopic for req without running the analysis, so a large one can be
+// weighed before committing to it. The service searches for the papers to
+// measure them, but makes no LLM call.
+func (c *Client) EstimateCost(ctx context.Context, req types.TopicRequest, opts ...RequestOption) (*types.CostEstimate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.CostEstimate
+	id, err := c.do(ctx, http.MethodPost, "/topic/estimate", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// EstimateAnalysis returns the expected tokens, price and duration of
+// AnalyzeAbstract for req without running the analysis, so that batches can
+// be priced before they are run. The service makes no LLM call.
+func (c *Client) EstimateAnalysis(ctx context.Context, req types.AnalyzeRequest, opts ...RequestOption) (*types.CostEstimate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.CostEstimate
+	id, err := c.do(ctx, http.MethodPost, "/analyze/estimate", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// ListFields returns the research fields the service accepts. They match
+// types.Fields unless the service is of a different version than the client.
+func (c *Client) ListFields(ctx context.Context, opts ...RequestOption) (*types.FieldsResponse, error) {
+	var result types.FieldsResponse
+	id, err := c.do(ctx, http.MethodGet, "/fields", nil, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// ListModels returns the models AnalyzeRequest.Model and TopicRequest.Model
+// may select, such as a fast model for triage and a strong one for final
+// analysis
+func (c *Client) ListModels(ctx context.Context, opts ...RequestOption) (*types.ModelsResponse, error) {
+	var result types.ModelsResponse
+	id, err := c.do(ctx, http.MethodGet, "/models", nil, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// GetUsage returns the caller's requests in the current quota window and,
+// if the service has a quota, how many remain and when they reset, so batch
+// work can be paced to fit instead of being refused with ErrRateLimited
+func (c *Client) GetUsage(ctx context.Context, opts ...RequestOption) (*types.UsageResponse, error) {
+	var result types.UsageResponse
+	id, err := c.do(ctx, http.MethodGet, "/usage", nil, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// HealthCheck checks if the microservice is healthy
+func (c *Client) HealthCheck(ctx context.Context, opts ...RequestOption) (*types.HealthResponse, error) {
+	var result types.HealthResponse
+	id, err := c.do(ctx, http.MethodGet, "/health", nil, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
@@ -0,0 +1,757 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/fixtures"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
+)
+
+const healthyJSON = `{"status":"healthy","version":"1.0.0","timestamp":"2024-01-01T00:00:00"}`
+
+// newTestClient starts a server running handler and returns a client for it
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := New(append([]Option{WithBaseURL(srv.URL)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	tests := map[string]Option{
+		"relative base URL":  WithBaseURL("localhost:8001"),
+		"ftp base URL":       WithBaseURL("ftp://example.com"),
+		"negative timeout":   WithTimeout(-time.Second),
+		"empty API key":      WithAPIKey(""),
+		"empty header name":  WithHeader("", "x"),
+		"bad proxy scheme":   WithProxy("gopher://proxy:70"),
+		"zero max bytes":     WithMaxResponseBytes(0),
+		"jitter above one":   WithRetryPolicy(RetryPolicy{Jitter: 2}),
+		"zero breaker limit": WithCircuitBreaker(0, time.Second),
+		"zero rate limit":    WithRateLimit(0, 1),
+		"zero rate burst":    WithRateLimit(1, 0),
+		"negative budget":    WithRetryBudget(-1),
+		"nil transport":      WithTransport(nil),
+		"nil middleware":     WithMiddleware(nil),
+		"nil logger":         WithLogger(nil),
+		"zero idle conns":    WithMaxIdleConnsPerHost(0),
+		"negative idle time": WithIdleConnTimeout(-time.Second),
+	}
+	for name, opt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(opt); err == nil {
+				t.Error("New() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestNewRejectsTokenSourceWithBearerToken(t *testing.T) {
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "t"})
+	_, err := New(WithBearerToken("t"), WithTokenSource(ts))
+	if err == nil {
+		t.Fatal("New() error = nil, want error")
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		checks := map[string]string{
+			"User-Agent":      "ai-gap-finder-go/1.0.0",
+			"X-API-Key":       "secret",
+			"X-Team":          "search",
+			"X-Request-ID":    "req-123",
+			"Accept-Encoding": "gzip",
+		}
+		for k, want := range checks {
+			if got := r.Header.Get(k); got != want {
+				t.Errorf("header %s = %q, want %q", k, got, want)
+			}
+		}
+		w.Write([]byte(healthyJSON))
+	}, WithAPIKey("secret"), WithHeader("X-Team", "search"))
+
+	ctx := ContextWithRequestID(context.Background(), "req-123")
+	health, err := c.HealthCheck(ctx)
+	if err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	if health.RequestID != "req-123" {
+		t.Errorf("RequestID = %q, want %q", health.RequestID, "req-123")
+	}
+}
+
+func TestListFields(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/fields" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"fields":["general","neuroscience"]}`))
+	})
+
+	result, err := c.ListFields(context.Background())
+	if err != nil {
+		t.Fatalf("ListFields() error = %v", err)
+	}
+	if len(result.Fields) != 2 || result.Fields[1] != types.FieldNeuroscience || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/models" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"models":["gpt-4","gpt-4o-mini"],"default_model":"gpt-4"}`))
+	})
+
+	result, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if len(result.Models) != 2 || result.DefaultModel != "gpt-4" || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/topic/estimate" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		var req types.TopicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Topic != "sleep" || req.MaxPapers != 50 {
+			t.Errorf("request body = %+v, %v", req, err)
+		}
+		w.Write([]byte(`{"model":"gpt-4","papers":50,"llm_calls":1,"input_tokens":20000,"output_tokens":7900,
+			"cost_usd":1.074,"duration_seconds":198.5,"processing_time":1.2}`))
+	})
+
+	result, err := c.EstimateCost(context.Background(), types.TopicRequest{Topic: "sleep", MaxPapers: 50})
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	if result.Papers != 50 || result.CostUSD == nil || *result.CostUSD != 1.074 || result.DurationSeconds != 198.5 || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+
+	if _, err := c.EstimateCost(context.Background(), types.TopicRequest{}); err == nil {
+		t.Error("EstimateCost() accepted a request without a topic")
+	}
+}
+
+func TestEstimateAnalysis(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/analyze/estimate" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"model":"gpt-4","papers":1,"llm_calls":1,"input_tokens":900,"output_tokens":550,
+			"cost_usd":0.06,"duration_seconds":13.75,"processing_time":0}`))
+	})
+
+	result, err := c.EstimateAnalysis(context.Background(), types.AnalyzeRequest{Title: "Sleep", Abstract: "Sleep helps memory."})
+	if err != nil {
+		t.Fatalf("EstimateAnalysis() error = %v", err)
+	}
+	if result.Papers != 1 || result.CostUSD == nil || *result.CostUSD != 0.06 || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+
+	if _, err := c.EstimateAnalysis(context.Background(), types.AnalyzeRequest{Title: "Sleep"}); err == nil {
+		t.Error("EstimateAnalysis() accepted a request without an abstract")
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/usage" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"requests":3,"since":"2025-01-01T10:00:00Z","endpoints":[{"endpoint":"POST /analyze","requests":3}],
+			"quota":{"limit":100,"remaining":97,"reset_at":"2025-01-01T11:00:00Z"}}`))
+	})
+
+	result, err := c.GetUsage(context.Background())
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	want := time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)
+	if result.Requests != 3 || len(result.Endpoints) != 1 || result.Quota == nil || result.Quota.Remaining != 97 ||
+		!result.Quota.ResetAt.Equal(want) || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestIdempotencyKeyStableAcrossRetries(t *testing.T) {
+	var keys []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1}`))
+	}, WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+
+	if _, err := c.AnalyzeAbstract(context.Background(), types.AnalyzeRequest{Title: "t", Abstract: "a", Field: "general"}); err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("Idempotency-Key headers = %q, want the same non-empty key twice", keys)
+	}
+}
+
+func TestTokenRefreshOn401(t *testing.T) {
+	refreshes := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(healthyJSON))
+	}, WithBearerToken("stale"), WithTokenRefresher(func(context.Context) (string, error) {
+		refreshes++
+		return "fresh", nil
+	}))
+
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	if refreshes != 1 {
+		t.Errorf("refresher called %d times, want 1", refreshes)
+	}
+}
+
+func TestGzipResponse(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(healthyJSON))
+		gz.Close()
+	})
+
+	health, err := c.HealthCheck(context.Background())
+	if err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	if health.Status != "healthy" {
+		t.Errorf("Status = %q, want %q", health.Status, "healthy")
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode string
+		wantMsg  string
+		wantIs   error
+	}{
+		{"fastapi detail", http.StatusNotFound, `{"detail":"Not Found"}`, "", "Not Found", ErrNotFound},
+		{"validation list", http.StatusUnprocessableEntity, `{"detail":[{"msg":"field required","type":"missing"}]}`, "validation_error", "field required", nil},
+		{"service error", http.StatusInternalServerError, `{"error":"LLM unavailable","error_type":"llm_error"}`, "llm_error", "LLM unavailable", nil},
+		{"plain text", http.StatusUnauthorized, "denied\n", "", "denied", ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := c.HealthCheck(context.Background())
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("HealthCheck() error = %v, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.ErrorCode != tt.wantCode || apiErr.Message != tt.wantMsg {
+				t.Errorf("APIError = {%d %q %q}, want {%d %q %q}",
+					apiErr.StatusCode, apiErr.ErrorCode, apiErr.Message, tt.status, tt.wantCode, tt.wantMsg)
+			}
+			if apiErr.RequestID == "" {
+				t.Error("RequestID is empty")
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(err, %v) = false", tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(healthyJSON))
+	}
+
+	c := newTestClient(t, handler, WithMaxResponseBytes(20))
+	if _, err := c.HealthCheck(context.Background()); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("HealthCheck() error = %v, want ErrResponseTooLarge", err)
+	}
+
+	c = newTestClient(t, handler, WithMaxResponseBytes(int64(len(healthyJSON))))
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Errorf("HealthCheck() error = %v, want nil at exactly the limit", err)
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	c, err := New(WithStrictDecoding())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		body    string
+		wantErr bool
+	}{
+		"exact":         {healthyJSON, false},
+		"unknown field": {`{"status":"ok","version":"1","timestamp":"t","uptime":3}`, true},
+		"missing field": {`{"status":"ok","version":"1"}`, true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := c.decode(strings.NewReader(tt.body), &types.HealthResponse{})
+			if tt.wantErr != errors.Is(err, ErrSchemaMismatch) {
+				t.Errorf("decode() error = %v, want schema mismatch: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCancelAbortsBodyRead(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	transport := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		go pw.Write([]byte(`{"status":`))
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: pr}, nil
+	})
+	c, err := New(WithTransport(transport))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.HealthCheck(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("HealthCheck() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	var seen []string
+	parent := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("X-API-Key"))
+		w.Write([]byte(healthyJSON))
+	}, WithAPIKey("parent"))
+
+	child, err := parent.Clone(WithAPIKey("child"))
+	if err != nil {
+		t.Fatalf("Clone() error = %v", err)
+	}
+	parent.HealthCheck(context.Background())
+	child.HealthCheck(context.Background())
+
+	if len(seen) != 2 || seen[0] != "parent" || seen[1] != "child" {
+		t.Errorf("X-API-Key headers = %q, want [parent child]", seen)
+	}
+	if parent.httpClient != child.httpClient {
+		t.Error("clone does not share the parent's HTTP client")
+	}
+	if got := parent.Stats()["GET /health"].Requests; got != 1 {
+		t.Errorf("parent recorded %d requests, want 1", got)
+	}
+}
+
+func TestCloneWithMiddleware(t *testing.T) {
+	tagged := func(tag string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				r.Header.Add("X-Tag", tag)
+				return next.RoundTrip(r)
+			})
+		}
+	}
+	var direct, proxied [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		direct = append(direct, r.Header.Values("X-Tag"))
+		w.Write([]byte(healthyJSON))
+	}))
+	t.Cleanup(srv.Close)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.Header.Values("X-Tag"))
+		w.Write([]byte(healthyJSON))
+	}))
+	t.Cleanup(proxy.Close)
+
+	parent, err := New(WithBaseURL(srv.URL), WithMiddleware(tagged("parent")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	viaProxy, err := parent.Clone(WithProxy(proxy.URL), WithMiddleware(tagged("child")))
+	if err != nil {
+		t.Fatalf("Clone(WithProxy) of a client with middleware error = %v", err)
+	}
+	parent.HealthCheck(context.Background())
+	viaProxy.HealthCheck(context.Background())
+
+	if len(direct) != 1 || !slices.Equal(direct[0], []string{"parent"}) {
+		t.Errorf("parent sent X-Tag %q, want [[parent]]", direct)
+	}
+	if len(proxied) != 1 || !slices.Equal(proxied[0], []string{"parent", "child"}) {
+		t.Errorf("clone sent X-Tag %q through the proxy, want [[parent child]]", proxied)
+	}
+}
+
+func TestInvalidRequestNotSent(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid request reached the server")
+	})
+
+	_, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "CRISPR", MaxPapers: 500})
+	var verr *types.ValidationError
+	if !errors.As(err, &verr) || verr.Field != "max_papers" {
+		t.Errorf("AnalyzeTopic() error = %v, want max_papers validation error", err)
+	}
+}
+
+func TestStrictDecodingAcceptsFixtures(t *testing.T) {
+	c, err := New(WithStrictDecoding())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range fixtures.AnalyzeNames() {
+		raw, _ := fixtures.AnalyzeJSON(name)
+		if err := c.decode(bytes.NewReader(raw), &types.AnalyzeResponse{}); err != nil {
+			t.Errorf("analyze fixture %q: %v", name, err)
+		}
+	}
+	for _, name := range fixtures.TopicNames() {
+		raw, _ := fixtures.TopicJSON(name)
+		if err := c.decode(bytes.NewReader(raw), &types.TopicResponse{}); err != nil {
+			t.Errorf("topic fixture %q: %v", name, err)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(healthyJSON))
+	}, WithRateLimit(rate.Every(50*time.Millisecond), 1))
+
+	start := time.Now()
+	for range 3 {
+		if _, err := c.HealthCheck(context.Background()); err != nil {
+			t.Fatalf("HealthCheck() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 calls at 20/s with burst 1 took %s, want at least 100ms", elapsed)
+	}
+}
+
+func TestRateLimitCancelled(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(healthyJSON))
+	}, WithRateLimit(rate.Every(time.Hour), 1))
+
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.HealthCheck(ctx); err == nil {
+		t.Error("HealthCheck() error = nil, want the rate limiter to give up")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var calls int
+	transport := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if r.URL.String() != "http://gap-finder:8001/health" {
+			t.Errorf("URL = %s", r.URL)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(healthyJSON))}, nil
+	})
+	hc := &http.Client{}
+	c, err := New(WithBaseURL("http://gap-finder:8001"), WithHTTPClient(hc), WithTransport(transport))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("transport called %d times, want 1", calls)
+	}
+	if hc.Transport != nil {
+		t.Error("WithTransport modified the client passed to WithHTTPClient")
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		w.Write([]byte(healthyJSON))
+	}, WithTimeout(20*time.Millisecond))
+
+	if _, err := c.HealthCheck(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("HealthCheck() error = %v, want context.DeadlineExceeded", err)
+	}
+	if _, err := c.HealthCheck(context.Background(), WithRequestTimeout(time.Second)); err != nil {
+		t.Errorf("HealthCheck() with a longer request timeout error = %v", err)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				order = append(order, name+" request")
+				r.Header.Add("X-Middleware", name)
+				resp, err := next.RoundTrip(r)
+				order = append(order, name+" response")
+				return resp, err
+			})
+		}
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Values("X-Middleware"); len(got) != 2 || got[0] != "outer" || got[1] != "inner" {
+			t.Errorf("X-Middleware = %q, want [outer inner]", got)
+		}
+		w.Write([]byte(healthyJSON))
+	}, WithMiddleware(mw("outer")), WithMiddleware(mw("inner")))
+
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	want := []string{"outer request", "inner request", "inner response", "outer response"}
+	if !slices.Equal(order, want) {
+		t.Errorf("order = %q, want %q", order, want)
+	}
+}
+
+func TestCompressionDisabled(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept-Encoding"); got != "identity" {
+			t.Errorf("Accept-Encoding = %q, want identity", got)
+		}
+		w.Write([]byte(healthyJSON))
+	}, WithCompression(false))
+
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+}
+
+func TestTokenSource(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer from-idp" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer from-idp")
+		}
+		w.Write([]byte(healthyJSON))
+	}, WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "from-idp"})))
+
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(healthyJSON))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.HealthCheck(context.Background()); err == nil {
+		t.Error("HealthCheck() without the server's CA error = nil, want certificate error")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	c, err = New(WithBaseURL(srv.URL), WithTLSConfig(&tls.Config{RootCAs: roots}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Errorf("HealthCheck() with the server's CA error = %v", err)
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) != 1 {
+			t.Errorf("client presented %d certificates, want 1", len(r.TLS.PeerCertificates))
+		}
+		w.Write([]byte(healthyJSON))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	// The server's own certificate serves as the client's
+	cert := srv.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0o600)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	c, err := New(WithBaseURL(srv.URL), WithTLSConfig(&tls.Config{RootCAs: roots}), WithClientCertificate(certFile, keyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+
+	if _, err := New(WithClientCertificate(filepath.Join(dir, "missing.crt"), keyFile)); err == nil {
+		t.Error("New() with a missing certificate error = nil, want error")
+	}
+}
+
+func TestProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Plain HTTP requests reach a proxy with their absolute URL
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte(healthyJSON))
+	}))
+	t.Cleanup(proxy.Close)
+
+	c, err := New(WithBaseURL("http://gap-finder.internal:8001"), WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://gap-finder.internal:8001/health" {
+		t.Errorf("proxied = %q, want the health check", proxied)
+	}
+}
+
+func TestConnectionPoolOptions(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(healthyJSON))
+	}, WithMaxIdleConnsPerHost(32), WithIdleConnTimeout(time.Minute), WithForceHTTP2(false))
+
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport is %T, want *http.Transport", c.httpClient.Transport)
+	}
+	if transport == http.DefaultTransport {
+		t.Fatal("options modified http.DefaultTransport")
+	}
+	if transport.MaxIdleConnsPerHost != 32 || transport.MaxIdleConns < 32 {
+		t.Errorf("MaxIdleConnsPerHost, MaxIdleConns = %d, %d, want 32 and at least 32",
+			transport.MaxIdleConnsPerHost, transport.MaxIdleConns)
+	}
+	if transport.IdleConnTimeout != time.Minute || transport.ForceAttemptHTTP2 {
+		t.Errorf("IdleConnTimeout, ForceAttemptHTTP2 = %s, %v, want 1m0s and false",
+			transport.IdleConnTimeout, transport.ForceAttemptHTTP2)
+	}
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+}
+
+func TestDo(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/experimental" {
+			t.Errorf("request = %s %s, want POST /experimental", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("X-API-Key") != "secret" || r.Header.Get("Idempotency-Key") == "" {
+			t.Errorf("headers = %v, want the client's API key and an idempotency key", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"query":"sleep"}` {
+			t.Errorf("body = %s", body)
+		}
+		w.Write([]byte(`{"answer":42}`))
+	}, WithAPIKey("secret"))
+
+	var out struct {
+		Answer int `json:"answer"`
+	}
+	if err := c.Do(context.Background(), http.MethodPost, "/experimental", map[string]string{"query": "sleep"}, &out); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if out.Answer != 42 {
+		t.Errorf("Answer = %d, want 42", out.Answer)
+	}
+	if err := c.Do(context.Background(), http.MethodGet, "health", nil, nil); err == nil {
+		t.Error("Do() with a relative path error = nil, want error")
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	},
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+	)
+
+	ctx := ContextWithRequestID(context.Background(), "req-log")
+	if _, err := c.HealthCheck(ctx); err == nil {
+		t.Fatal("HealthCheck() error = nil, want error")
+	}
+
+	logs := buf.String()
+	for _, want := range []string{
+		`level=DEBUG msg="sending gap finder request"`,
+		`level=INFO msg="retrying gap finder request"`,
+		`level=WARN msg="gap finder request failed"`,
+		"request_id=req-log",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs lack %q:\n%s", want, logs)
+		}
+	}
+}
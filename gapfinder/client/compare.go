@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// ComparePapers compares two papers, reporting the findings both support,
+// the conclusions they disagree on and the gaps unique to each. Requests
+// that fail validation are rejected with a *types.ValidationError without
+// being sent.
+func (c *Client) ComparePapers(ctx context.Context, req types.CompareRequest, opts ...RequestOption) (*types.ComparisonResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.ComparisonResponse
+	id, err := c.do(ctx, http.MethodPost, "/compare", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
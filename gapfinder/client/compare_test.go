@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestComparePapers(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.CompareRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/compare" || req.PaperB.Title != "B" {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"overlapping_findings":["f"],"conflicting_conclusions":[{"topic":"t","paper_a_position":"yes","paper_b_position":"no"}],
+			"gaps_unique_to_a":[],"gaps_unique_to_b":[],"processing_time":1.5}`))
+	})
+
+	result, err := c.ComparePapers(context.Background(), types.CompareRequest{
+		PaperA: types.AnalyzeRequest{Title: "A", Abstract: "Abstract A"},
+		PaperB: types.AnalyzeRequest{Title: "B", Abstract: "Abstract B"},
+	})
+	if err != nil {
+		t.Fatalf("ComparePapers() error = %v", err)
+	}
+	if len(result.ConflictingConclusions) != 1 || result.ConflictingConclusions[0].PaperBPosition != "no" || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds the limit set
+// with WithMaxResponseBytes
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// maxBytesReader reads from r until more than remaining bytes have been
+// read, then fails with ErrResponseTooLarge. Unlike io.LimitReader it doesn't
+// silently truncate, which would surface as a confusing JSON syntax error.
+type maxBytesReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > m.remaining+1 {
+		p = p[:m.remaining+1]
+	}
+	n, err := m.r.Read(p)
+	m.remaining -= int64(n)
+	if m.remaining < 0 {
+		return n, ErrResponseTooLarge
+	}
+	return n, err
+}
+
+// ErrSchemaMismatch is wrapped by decoding errors in strict mode when the
+// response doesn't match the Go types
+var ErrSchemaMismatch = errors.New("response does not match client schema")
+
+// decode streams a response body into out. Strict mode has to look at the
+// raw JSON to find missing fields, so it buffers the body first.
+func (c *Client) decode(r io.Reader, out any) error {
+	if !c.strict {
+		return json.NewDecoder(r).Decode(out)
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(r).Decode(&body); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		if strings.HasPrefix(err.Error(), "json: unknown field") {
+			return fmt.Errorf("%w: %v", ErrSchemaMismatch, err)
+		}
+		return err
+	}
+	if err := checkRequired(body, reflect.TypeOf(out), ""); err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaMismatch, err)
+	}
+	return nil
+}
+
+// checkRequired verifies that raw contains every field of t that is tagged
+// without omitempty, recursing into nested structs and slices
+func checkRequired(raw json.RawMessage, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := checkRequired(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" || name == "" {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			value, ok := fields[name]
+			if !ok {
+				if !strings.Contains(opts, "omitempty") {
+					return fmt.Errorf("missing required field %q", fieldPath)
+				}
+				continue
+			}
+			if err := checkRequired(value, f.Type, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
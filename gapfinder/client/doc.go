@@ -0,0 +1,81 @@
+// Package client is a Go client for the AI Gap Finder microservice.
+//
+// Create a client with New and call the method of the analysis at hand, such
+// as AnalyzeAbstract, AnalyzePDF, AnalyzeBatch, AnalyzeTopic or ComparePapers:
+//
+//	c, err := client.New(
+//		client.WithBaseURL("http://gap-finder:8001"),
+//		client.WithRetryPolicy(client.DefaultRetryPolicy),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	result, err := c.AnalyzeAbstract(ctx, types.AnalyzeRequest{
+//		Title:    "Deep Learning Applications in Medical Imaging",
+//		Abstract: "...",
+//		Field:    types.FieldMedicine,
+//	})
+//
+// Request and response structures live in package types; requests are
+// validated before they are sent. Failed calls return an *APIError for
+// non-2xx responses, which can be matched against ErrUnauthorized,
+// ErrNotFound and ErrRateLimited with errors.Is. Code that depends on the
+// Analyzer interface can be tested with AnalyzerMock instead of a live
+// service; NewTopicStream builds the streams a mocked AnalyzeTopicStream
+// returns.
+//
+// Research fields are typed as types.Field; ListFields reports the fields the
+// service accepts. AnalyzeRequest and TopicRequest can choose a model listed
+// by ListModels and a temperature, trading cost for quality. Abstracts in
+// other languages are analyzed by setting their Language, and TranslateOutput
+// returns the analysis in that language instead of English. MinConfidence
+// leaves weak gaps out of a response, and MaxGaps, MaxHypotheses and
+// MaxFindings bound its size; types.FilterGaps and the Truncate methods do
+// the same for responses already received. Instructions steer an
+// analysis, such as "focus on reproducibility gaps", without an endpoint of
+// its own. A TopicRequest with CrossFields analyzes its topic across several
+// fields and reports the gaps at their intersection in IntersectionGaps.
+// Surveys leaves survey papers out of a topic analysis, or keeps them as
+// background, so their meta-level observations don't crowd out its gaps.
+// EstimateCost tells what a topic analysis would cost in tokens, money and
+// time before it is run, and EstimateAnalysis what an abstract's would.
+//
+// AnalyzeDOI, AnalyzeArxiv and AnalyzePMID analyze a paper known only by its
+// identifier; the service looks up its metadata. SimulateReview critiques a
+// manuscript the way a peer reviewer would, for checks before submission.
+// Refine updates an analysis following feedback, such as rejecting a gap or
+// asking for a deeper look at one, for a conversational workflow.
+//
+// DeduplicateGaps merges the gaps of several analyses that describe the same
+// problem, such as the near-duplicates among a topic's papers, and
+// GenerateHypotheses proposes hypotheses for a curated list of gaps, which
+// RecommendMethods turns into experimental designs, and
+// SuggestCitations finds papers to cite when writing about a gap, and
+// GetRelatedWork the closest existing papers, to verify a gap is real.
+// SuggestDatasets names public datasets to start work on a gap or hypothesis
+// from.
+// FindResearchGroups tells which groups of co-authors work near each of a
+// topic's common gaps. MatchFunding ranks the open funding calls that would
+// fund work on a list of gaps.
+// CheckNovelty tells whether the literature already addresses a proposed
+// research idea, and AnalyzeProposal how well a draft grant proposal
+// addresses its field's gaps.
+// GenerateReview turns a topic analysis into a literature review draft.
+// AnalyzeTrends follows a topic's gaps over the publication years, telling
+// which are closing and which persist.
+//
+// AnalyzeTopicStream delivers a topic's per-paper results on a channel as the
+// service finishes them, and WatchTopic reports each paper's progress over a
+// WebSocket, for callers that want to show progress. AnalyzeTopicAsync starts
+// one in the background instead; WithCallbackURL has the service POST the
+// finished job to a handler built with package webhook, rather than polling
+// it with WaitForJob. CancelJob stops a job launched by mistake.
+//
+// ListAnalyses finds the abstract and topic analyses the service has kept,
+// by topic, field and date, and GetAnalysis fetches one's result again
+// without re-running it. DeleteAnalysis removes one from the service, e.g.
+// when it is of unpublished work.
+//
+// GetUsage reports the caller's requests and remaining quota, so batch
+// schedulers can pace themselves instead of running into ErrRateLimited.
+package client
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched by *APIError through errors.Is
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// APIError is returned when the service responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	// ErrorCode is the machine-readable error type reported by the service,
+	// if any (e.g. "validation_error")
+	ErrorCode string
+	Message   string
+	// RetryAfter is how long the service asked the client to wait before
+	// retrying, taken from the Retry-After header. Zero when absent.
+	RetryAfter time.Duration
+	// RequestID is the X-Request-ID of the failed call, for correlating it
+	// with the service's logs
+	RequestID string
+	// Body is the raw response body
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API returned status %d", e.StatusCode)
+	if e.ErrorCode != "" {
+		msg += " (" + e.ErrorCode + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += " [request " + e.RequestID + "]"
+	}
+	return msg
+}
+
+// Is lets errors.Is match an *APIError against the sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// newAPIError builds an APIError from a failed response. The service reports
+// errors either as FastAPI's {"detail": ...} or as {"error": ..., "error_type": ...}.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		Body:       body,
+	}
+
+	var payload struct {
+		Detail    json.RawMessage `json:"detail"`
+		Error     string          `json:"error"`
+		ErrorType string          `json:"error_type"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		apiErr.Message = strings.TrimSpace(string(body))
+		return apiErr
+	}
+
+	apiErr.ErrorCode = payload.ErrorType
+	apiErr.Message = payload.Error
+	if len(payload.Detail) > 0 {
+		var detail string
+		var validation []struct {
+			Msg  string `json:"msg"`
+			Type string `json:"type"`
+		}
+		switch {
+		case json.Unmarshal(payload.Detail, &detail) == nil:
+			apiErr.Message = detail
+		case json.Unmarshal(payload.Detail, &validation) == nil && len(validation) > 0:
+			msgs := make([]string, len(validation))
+			for i, v := range validation {
+				msgs[i] = v.Msg
+			}
+			apiErr.Message = strings.Join(msgs, "; ")
+			if apiErr.ErrorCode == "" {
+				apiErr.ErrorCode = "validation_error"
+			}
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// DeduplicateGaps clusters the gaps of several analyses that describe the
+// same problem, returning a canonical gap for each cluster. Use it to merge
+// the near-duplicate gaps of a topic's papers. Requests that fail validation
+// are rejected with a *types.ValidationError without being sent.
+func (c *Client) DeduplicateGaps(ctx context.Context, req types.DeduplicateRequest, opts ...RequestOption) (*types.DeduplicateResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.DeduplicateResponse
+	id, err := c.do(ctx, http.MethodPost, "/gaps/deduplicate", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// GenerateHypotheses proposes research hypotheses for a list of gaps, such as
+// gaps curated from earlier analyses, without analyzing their papers again.
+// At most types.MaxHypothesisGaps gaps are accepted per call, and each needs
+// a description.
+func (c *Client) GenerateHypotheses(ctx context.Context, gaps []types.ResearchGap, opts ...RequestOption) (*types.HypothesesResponse, error) {
+	req := types.HypothesesRequest{Gaps: gaps}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.HypothesesResponse
+	id, err := c.do(ctx, http.MethodPost, "/hypotheses", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// RecommendMethods expands a hypothesis' RequiredMethods into concrete
+// experimental designs, each with sample-size guidance, and names candidate
+// datasets to start from.
+func (c *Client) RecommendMethods(ctx context.Context, hypothesis types.Hypothesis, opts ...RequestOption) (*types.MethodsResponse, error) {
+	req := types.MethodsRequest{Hypothesis: hypothesis}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.MethodsResponse
+	id, err := c.do(ctx, http.MethodPost, "/hypotheses/methods", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// SuggestDatasets returns public datasets suitable for investigating
+// req.Gap or testing req.Hypothesis, exactly one of which is set, each with
+// a URL to get it from and its license where known.
+func (c *Client) SuggestDatasets(ctx context.Context, req types.DatasetsRequest, opts ...RequestOption) (*types.DatasetsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.DatasetsResponse
+	id, err := c.do(ctx, http.MethodPost, "/gaps/datasets", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// SuggestCitations returns papers a researcher should cite when writing
+// about a gap, with how each relates to it. The service searches for papers
+// with the gap's description and only suggests papers it found, so the
+// suggestions can be stored in the gap's SupportingCitations.
+func (c *Client) SuggestCitations(ctx context.Context, gap types.ResearchGap, opts ...RequestOption) (*types.CitationsResponse, error) {
+	req := types.CitationsRequest{Gap: gap}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.CitationsResponse
+	id, err := c.do(ctx, http.MethodPost, "/gaps/citations", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// GetRelatedWork returns the existing papers closest to a gap, most similar
+// first, each telling whether it already addresses the gap, so a researcher
+// can verify the gap is real before committing to it. The service searches
+// for papers with the gap's description and only returns papers it found.
+func (c *Client) GetRelatedWork(ctx context.Context, gap types.ResearchGap, opts ...RequestOption) (*types.RelatedWorkResponse, error) {
+	req := types.RelatedWorkRequest{Gap: gap}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.RelatedWorkResponse
+	id, err := c.do(ctx, http.MethodPost, "/gaps/related", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// MatchFunding cross-references gaps against the open funding calls the
+// service reads (NSF and NIH by default) and returns the calls that fit,
+// most relevant first, each with the indexes of the gaps it would fund. At
+// most types.MaxFundingGaps gaps are accepted per call, and each needs a
+// description.
+func (c *Client) MatchFunding(ctx context.Context, gaps []types.ResearchGap, opts ...RequestOption) (*types.FundingResponse, error) {
+	req := types.FundingRequest{Gaps: gaps}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.FundingResponse
+	id, err := c.do(ctx, http.MethodPost, "/gaps/funding", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// FindResearchGroups finds the research groups working near the common gaps
+// of a topic analysis: the service picks the papers near each gap and groups
+// their authors by co-authorship, to find collaborators or competitors. The
+// returned CommonGaps are those of topic with their ResearchGroups set. Only
+// the IndividualResults held by topic are searched, so fetch the remaining
+// pages of a paged TopicResponse with GetTopicResults first.
+func (c *Client) FindResearchGroups(ctx context.Context, topic *types.TopicResponse, opts ...RequestOption) (*types.ResearchGroupsResponse, error) {
+	if topic == nil {
+		return nil, errors.New("topic response must not be nil")
+	}
+	req := types.ResearchGroupsRequest{
+		Topic:      topic.Topic,
+		Papers:     topic.IndividualResults,
+		CommonGaps: topic.CommonGaps,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.ResearchGroupsResponse
+	id, err := c.do(ctx, http.MethodPost, "/gaps/groups", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestDeduplicateGaps(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.DeduplicateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/gaps/deduplicate" || len(req.Analyses) != 2 {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"clusters":[{"gap":{"gap_description":"Small samples","confidence_score":0.9,"gap_type":"empirical","potential_impact":"High"},
+			"members":[{"analysis":0,"gap":0},{"analysis":1,"gap":0}]}],"processing_time":1.5}`))
+	})
+
+	gap := types.ResearchGap{GapDescription: "Small sample", ConfidenceScore: 0.9}
+	result, err := c.DeduplicateGaps(context.Background(), types.DeduplicateRequest{
+		Analyses: [][]types.ResearchGap{{gap}, {gap}},
+	})
+	if err != nil {
+		t.Fatalf("DeduplicateGaps() error = %v", err)
+	}
+	if len(result.Clusters) != 1 || result.Clusters[0].Members[1] != (types.GapRef{Analysis: 1}) || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestDeduplicateGapsValidates(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid request was sent")
+	})
+	_, err := c.DeduplicateGaps(context.Background(), types.DeduplicateRequest{Analyses: [][]types.ResearchGap{{}}})
+	var verr *types.ValidationError
+	if !errors.As(err, &verr) || verr.Field != "analyses" {
+		t.Errorf("DeduplicateGaps() error = %v, want *types.ValidationError for analyses", err)
+	}
+}
+
+func TestGenerateHypotheses(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.HypothesesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/hypotheses" || len(req.Gaps) != 1 {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"hypotheses":[{"hypothesis":"Larger samples","rationale":"r","feasibility_score":0.7,"required_methods":["RCT"]}],"processing_time":1.5}`))
+	})
+
+	result, err := c.GenerateHypotheses(context.Background(), []types.ResearchGap{{GapDescription: "Small sample", ConfidenceScore: 0.8}})
+	if err != nil {
+		t.Fatalf("GenerateHypotheses() error = %v", err)
+	}
+	if len(result.Hypotheses) != 1 || result.Hypotheses[0].Hypothesis != "Larger samples" || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestRecommendMethods(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.MethodsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/hypotheses/methods" || req.Hypothesis.Hypothesis != "Larger samples" {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"designs":[{"name":"RCT","description":"d","methods":["RCT"],"sample_size":"200 per arm","limitations":[]}],
+			"datasets":[{"name":"UK Biobank","description":"d","url":"https://www.ukbiobank.ac.uk"}],"processing_time":1.5}`))
+	})
+
+	result, err := c.RecommendMethods(context.Background(), types.Hypothesis{Hypothesis: "Larger samples", FeasibilityScore: 0.7})
+	if err != nil {
+		t.Fatalf("RecommendMethods() error = %v", err)
+	}
+	if len(result.Designs) != 1 || result.Designs[0].SampleSize != "200 per arm" || len(result.Datasets) != 1 || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestSuggestDatasets(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.DatasetsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/gaps/datasets" || req.Gap == nil || req.Hypothesis != nil {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"datasets":[{"name":"UK Biobank","description":"d","url":"https://www.ukbiobank.ac.uk","license":"Access on application"}],"processing_time":1.5}`))
+	})
+
+	result, err := c.SuggestDatasets(context.Background(), types.DatasetsRequest{Gap: &types.ResearchGap{GapDescription: "Small samples", ConfidenceScore: 0.8}})
+	if err != nil {
+		t.Fatalf("SuggestDatasets() error = %v", err)
+	}
+	if len(result.Datasets) != 1 || result.Datasets[0].License != "Access on application" || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestSuggestCitations(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.CitationsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/gaps/citations" || req.Gap.GapDescription != "Small sample" {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"citations":[{"title":"Sleep and recall","authors":["A. Author"],"url":"http://arxiv.org/abs/2","reason":"r"}],"processing_time":1.5}`))
+	})
+
+	result, err := c.SuggestCitations(context.Background(), types.ResearchGap{GapDescription: "Small sample", ConfidenceScore: 0.8})
+	if err != nil {
+		t.Fatalf("SuggestCitations() error = %v", err)
+	}
+	if len(result.Citations) != 1 || result.Citations[0].URL != "http://arxiv.org/abs/2" || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestGetRelatedWork(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.RelatedWorkRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/gaps/related" || req.Gap.GapDescription != "Small sample" {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"papers":[{"title":"Sleep and recall","authors":["A. Author"],"year":2024,"similarity":0.9,"addresses_gap":true,"reason":"r"}],"processing_time":1.5}`))
+	})
+
+	result, err := c.GetRelatedWork(context.Background(), types.ResearchGap{GapDescription: "Small sample", ConfidenceScore: 0.8})
+	if err != nil {
+		t.Fatalf("GetRelatedWork() error = %v", err)
+	}
+	if len(result.Papers) != 1 || !result.Papers[0].AddressesGap || result.Papers[0].Year != 2024 || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+	if _, err := c.GetRelatedWork(context.Background(), types.ResearchGap{}); err == nil {
+		t.Error("GetRelatedWork() without a description succeeded")
+	}
+}
+
+func TestMatchFunding(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.FundingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/gaps/funding" || len(req.Gaps) != 1 {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"matches":[{"call":{"agency":"NIH","title":"Sleep research","url":"https://grants.nih.gov/1"},
+			"gaps":[0],"relevance_score":0.9,"reason":"r"}],"calls_searched":120,"processing_time":1.5}`))
+	})
+
+	result, err := c.MatchFunding(context.Background(), []types.ResearchGap{{GapDescription: "Small sample", ConfidenceScore: 0.8}})
+	if err != nil {
+		t.Fatalf("MatchFunding() error = %v", err)
+	}
+	if len(result.Matches) != 1 || result.Matches[0].Call.Agency != "NIH" || result.CallsSearched != 120 || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+	if _, err := c.MatchFunding(context.Background(), nil); err == nil {
+		t.Error("MatchFunding() without gaps succeeded")
+	}
+}
+
+func TestFindResearchGroups(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.ResearchGroupsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/gaps/groups" || req.Topic != "sleep" || len(req.CommonGaps) != 1 {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"common_gaps":[{"gap_description":"Small sample","confidence_score":0.8,"gap_type":"empirical","potential_impact":"High",
+			"research_groups":[{"authors":["A. Author","B. Author"],"papers":["Sleep helps memory"]}]}],"processing_time":1.5}`))
+	})
+
+	topic := &types.TopicResponse{
+		Topic:             "sleep",
+		CommonGaps:        []types.ResearchGap{{GapDescription: "Small sample", ConfidenceScore: 0.8}},
+		IndividualResults: []types.TopicAnalysisResult{{PaperTitle: "Sleep helps memory", Authors: []string{"A. Author", "B. Author"}}},
+	}
+	result, err := c.FindResearchGroups(context.Background(), topic)
+	if err != nil {
+		t.Fatalf("FindResearchGroups() error = %v", err)
+	}
+	if len(result.CommonGaps) != 1 || len(result.CommonGaps[0].ResearchGroups[0].Authors) != 2 || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+	if _, err := c.FindResearchGroups(context.Background(), &types.TopicResponse{Topic: "sleep"}); err == nil {
+		t.Error("FindResearchGroups() without papers succeeded")
+	}
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// WaitOptions controls how WaitForHealthy and WaitForJob poll the service.
+// Zero values select the defaults.
+type WaitOptions struct {
+	// Interval is the delay before the second poll (default 500ms). It grows
+	// by Multiplier (default 2) after every failed poll, up to MaxInterval
+	// (default 5s).
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Multiplier  float64
+	// PollTimeout bounds each poll (default 2s)
+	PollTimeout time.Duration
+}
+
+// poll calls check until it reports done or fails, backing off between calls
+// as described by opts. check is passed a request option bounding the poll.
+// poll returns the error of check, or ctx's once ctx is done.
+func poll(ctx context.Context, opts WaitOptions, check func(timeout RequestOption) (done bool, err error)) error {
+	policy := RetryPolicy{
+		InitialBackoff: cmp.Or(opts.Interval, 500*time.Millisecond),
+		MaxBackoff:     cmp.Or(opts.MaxInterval, 5*time.Second),
+		Multiplier:     cmp.Or(opts.Multiplier, 2),
+		Jitter:         0.1,
+	}
+	timeout := WithRequestTimeout(cmp.Or(opts.PollTimeout, 2*time.Second))
+	for attempt := 1; ; attempt++ {
+		if done, err := check(timeout); done || err != nil {
+			return err
+		}
+		if err := sleepContext(ctx, policy.backoff(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// WaitForHealthy polls /health until the service reports itself healthy or
+// ctx is done, e.g. to order service startup or in integration tests
+func (c *Client) WaitForHealthy(ctx context.Context, opts WaitOptions) (*types.HealthResponse, error) {
+	var health *types.HealthResponse
+	var lastErr error
+	err := poll(ctx, opts, func(timeout RequestOption) (bool, error) {
+		var err error
+		health, err = c.HealthCheck(ctx, timeout)
+		if err == nil && health.Status == "healthy" {
+			return true, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("service status is %q", health.Status)
+		}
+		lastErr = err
+		return false, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service did not become healthy: %w; last error: %v", err, lastErr)
+	}
+	return health, nil
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForHealthy(t *testing.T) {
+	var polls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch polls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Write([]byte(strings.Replace(healthyJSON, "healthy", "starting", 1)))
+		default:
+			w.Write([]byte(healthyJSON))
+		}
+	})
+
+	health, err := c.WaitForHealthy(context.Background(), WaitOptions{Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("WaitForHealthy() error = %v", err)
+	}
+	if health.Status != "healthy" {
+		t.Errorf("Status = %q, want healthy", health.Status)
+	}
+	if got := polls.Load(); got != 3 {
+		t.Errorf("polled %d times, want 3", got)
+	}
+}
+
+func TestWaitForHealthyContextDone(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForHealthy(ctx, WaitOptions{Interval: time.Millisecond}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForHealthy() error = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// ListAnalyses lists the abstract and topic analyses the service has kept
+// that match q, newest first, so earlier results can be fetched with
+// GetAnalysis instead of being run again. Queries that fail validation are
+// rejected with a *types.ValidationError without being sent.
+func (c *Client) ListAnalyses(ctx context.Context, q types.AnalysesQuery, opts ...RequestOption) (*types.AnalysesResponse, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	v := url.Values{}
+	if q.Topic != "" {
+		v.Set("topic", q.Topic)
+	}
+	if q.Field != "" {
+		v.Set("field", string(q.Field))
+	}
+	if !q.Since.IsZero() {
+		v.Set("since", q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		v.Set("until", q.Until.Format(time.RFC3339))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	path := "/analyses"
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+	var result types.AnalysesResponse
+	id, err := c.do(ctx, http.MethodGet, path, nil, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// GetAnalysis returns a kept analysis with its result: Result for an
+// abstract, TopicResult with every individual result for a topic. Unknown or
+// forgotten analyses fail with an error matching ErrNotFound.
+func (c *Client) GetAnalysis(ctx context.Context, analysisID string, opts ...RequestOption) (*types.Analysis, error) {
+	var result types.Analysis
+	id, err := c.do(ctx, http.MethodGet, "/analyses/"+url.PathEscape(analysisID), nil, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// DeleteAnalysis removes a kept analysis and its result from the service, for
+// abstracts of unpublished work that mustn't stay on it, along with the job
+// it ran in and the result stored for its idempotency key. Unknown or
+// already deleted analyses fail with an error matching ErrNotFound.
+func (c *Client) DeleteAnalysis(ctx context.Context, analysisID string, opts ...RequestOption) error {
+	_, err := c.do(ctx, http.MethodDelete, "/analyses/"+url.PathEscape(analysisID), nil, nil, opts)
+	return err
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestListAnalyses(t *testing.T) {
+	var query string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/analyses" {
+			t.Errorf("path = %q, want /analyses", r.URL.Path)
+		}
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"analyses":[{"analysis_id":"a1","kind":"topic","title":"CRISPR","field":"biology","created_at":"2025-03-01T10:00:00Z"}]}`))
+	})
+	ctx := context.Background()
+
+	resp, err := c.ListAnalyses(ctx, types.AnalysesQuery{
+		Topic: "crispr", Field: types.FieldBiology, Since: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Limit: 10,
+	})
+	if err != nil {
+		t.Fatalf("ListAnalyses() error = %v", err)
+	}
+	if want := "field=biology&limit=10&since=2025-01-01T00%3A00%3A00Z&topic=crispr"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if len(resp.Analyses) != 1 || resp.Analyses[0].Kind != types.AnalysisTopic || resp.Analyses[0].CreatedAt.Month() != time.March {
+		t.Errorf("Analyses = %+v", resp.Analyses)
+	}
+
+	if _, err := c.ListAnalyses(ctx, types.AnalysesQuery{}); err != nil || query != "" {
+		t.Errorf("ListAnalyses() sent query %q, error = %v; want no query", query, err)
+	}
+	var verr *types.ValidationError
+	if _, err := c.ListAnalyses(ctx, types.AnalysesQuery{Limit: types.MaxAnalysesLimit + 1}); !errors.As(err, &verr) || verr.Field != "limit" {
+		t.Errorf("ListAnalyses() error = %v, want a limit ValidationError", err)
+	}
+}
+
+func TestGetAnalysis(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/analyses/a1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"Analysis not found"}`))
+			return
+		}
+		w.Write([]byte(`{"analysis_id":"a1","kind":"abstract","title":"Sleep","field":"general","created_at":"2025-03-01T10:00:00Z",
+			"result":{"key_findings":["f"],"gaps":[],"limitations":[],"methodology_gaps":[],"suggested_hypotheses":[],"future_directions":[],"processing_time":1}}`))
+	})
+	ctx := context.Background()
+
+	a, err := c.GetAnalysis(ctx, "a1")
+	if err != nil {
+		t.Fatalf("GetAnalysis() error = %v", err)
+	}
+	if a.Kind != types.AnalysisAbstract || a.Result == nil || len(a.Result.KeyFindings) != 1 || a.TopicResult != nil {
+		t.Errorf("analysis = %+v", a)
+	}
+	if _, err := c.GetAnalysis(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetAnalysis() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteAnalysis(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("method = %s, want DELETE", r.Method)
+		}
+		if r.URL.Path != "/analyses/a1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"Analysis not found"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ctx := context.Background()
+
+	if err := c.DeleteAnalysis(ctx, "a1"); err != nil {
+		t.Fatalf("DeleteAnalysis() error = %v", err)
+	}
+	if err := c.DeleteAnalysis(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteAnalysis() error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// CheckNovelty searches the literature for papers related to a proposed
+// research idea and reports whether the idea appears already addressed,
+// partially addressed or open, with a novelty score and the papers
+// supporting the verdict. An empty field means types.FieldGeneral.
+func (c *Client) CheckNovelty(ctx context.Context, idea string, field types.Field, opts ...RequestOption) (*types.NoveltyResponse, error) {
+	req := types.NoveltyRequest{Idea: idea, Field: field}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.NoveltyResponse
+	id, err := c.do(ctx, http.MethodPost, "/ideas/novelty", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// AnalyzeProposal scores how well a draft grant proposal addresses the known
+// gaps of its field and flags its claims of novelty that conflict with
+// existing work. The service searches for related papers with the
+// proposal's first line, so start the text with its title, and scores the
+// proposal against the gaps those papers raise. An empty field means
+// types.FieldGeneral; use Do with a types.ProposalRequest to set the search
+// topic or the known gaps.
+func (c *Client) AnalyzeProposal(ctx context.Context, proposal string, field types.Field, opts ...RequestOption) (*types.ProposalResponse, error) {
+	req := types.ProposalRequest{Proposal: proposal, Field: field}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.ProposalResponse
+	id, err := c.do(ctx, http.MethodPost, "/ideas/proposal", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestCheckNovelty(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.NoveltyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/ideas/novelty" || req.Idea != "Spindles predict recall" || req.Field != types.FieldNeuroscience {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"status":"partially_addressed","novelty_score":0.5,"summary":"s",
+			"supporting_papers":[{"title":"Spindles and memory","authors":[],"reason":"r"}],"papers_searched":10,"processing_time":1.5}`))
+	})
+
+	result, err := c.CheckNovelty(context.Background(), "Spindles predict recall", types.FieldNeuroscience)
+	if err != nil {
+		t.Fatalf("CheckNovelty() error = %v", err)
+	}
+	if result.Status != types.NoveltyPartiallyAddressed || len(result.SupportingPapers) != 1 || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+	if _, err := c.CheckNovelty(context.Background(), " ", ""); err == nil {
+		t.Error("CheckNovelty() without an idea succeeded")
+	}
+}
+
+func TestAnalyzeProposal(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.ProposalRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/ideas/proposal" || req.Proposal != "Spindles in children" {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"alignment_score":0.7,"gaps":[{"gap_description":"Small samples","coverage":0.8,"comment":"c"}],
+			"novelty_conflicts":[{"claim":"First study of spindles","papers":[{"title":"Spindles and memory","authors":[],"reason":"r"}]}],
+			"summary":"s","papers_searched":10,"processing_time":1.5}`))
+	})
+
+	result, err := c.AnalyzeProposal(context.Background(), "Spindles in children", "")
+	if err != nil {
+		t.Fatalf("AnalyzeProposal() error = %v", err)
+	}
+	if result.AlignmentScore != 0.7 || len(result.Gaps) != 1 || len(result.NoveltyConflicts[0].Papers) != 1 || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+	if _, err := c.AnalyzeProposal(context.Background(), "", ""); err == nil {
+		t.Error("AnalyzeProposal() without a proposal succeeded")
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+var (
+	// ErrJobFailed is returned by WaitForJob when the job finished
+	// unsuccessfully
+	ErrJobFailed = errors.New("job failed")
+	// ErrJobCancelled is returned by WaitForJob when the job was cancelled
+	ErrJobCancelled = errors.New("job cancelled")
+)
+
+// AnalyzeTopicAsync starts a topic analysis in the background and returns
+// the job without waiting for it. Poll it with GetJob or WaitForJob, or have
+// the service call back with WithCallbackURL. Requests
+// that fail validation are rejected with a *types.ValidationError without
+// being sent.
+func (c *Client) AnalyzeTopicAsync(ctx context.Context, req types.TopicRequest, opts ...RequestOption) (*types.Job, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var job types.Job
+	id, err := c.do(ctx, http.MethodPost, "/topic/jobs", req, &job, opts)
+	if err != nil {
+		return nil, err
+	}
+	job.RequestID = id
+	return &job, nil
+}
+
+// GetJob returns the current state of a job. Unknown or expired jobs fail
+// with an error matching ErrNotFound.
+func (c *Client) GetJob(ctx context.Context, jobID string, opts ...RequestOption) (*types.Job, error) {
+	var job types.Job
+	id, err := c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(jobID), nil, &job, opts)
+	if err != nil {
+		return nil, err
+	}
+	job.RequestID = id
+	return &job, nil
+}
+
+// CancelJob stops a job that is still pending or running, so that the
+// service makes no further LLM calls for it, and returns it with status
+// types.JobCancelled. A job that already finished is returned as it is.
+// Unknown or expired jobs fail with an error matching ErrNotFound.
+func (c *Client) CancelJob(ctx context.Context, jobID string, opts ...RequestOption) (*types.Job, error) {
+	var job types.Job
+	id, err := c.do(ctx, http.MethodPost, "/jobs/"+url.PathEscape(jobID)+"/cancel", nil, &job, opts)
+	if err != nil {
+		return nil, err
+	}
+	job.RequestID = id
+	return &job, nil
+}
+
+// WaitForJob polls a job until it finishes or ctx is done, backing off
+// between polls as described by opts. A failed job is returned along with an
+// error matching ErrJobFailed, and a cancelled one with ErrJobCancelled.
+func (c *Client) WaitForJob(ctx context.Context, jobID string, opts WaitOptions) (*types.Job, error) {
+	var job *types.Job
+	var getErr error
+	err := poll(ctx, opts, func(timeout RequestOption) (bool, error) {
+		job, getErr = c.GetJob(ctx, jobID, timeout)
+		if getErr != nil {
+			return false, getErr
+		}
+		return job.Done(), nil
+	})
+	switch {
+	case getErr != nil:
+		return nil, getErr
+	case err != nil:
+		return nil, fmt.Errorf("job %s did not finish: %w", jobID, err)
+	case job.Status == types.JobFailed:
+		return job, fmt.Errorf("%w: %s", ErrJobFailed, job.Error)
+	case job.Status == types.JobCancelled:
+		return job, fmt.Errorf("job %s: %w", jobID, ErrJobCancelled)
+	}
+	return job, nil
+}
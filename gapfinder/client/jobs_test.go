@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func jobJSON(status, extra string) string {
+	return fmt.Sprintf(`{"job_id":"j1","status":%q,"created_at":"2024-01-01T00:00:00+00:00","updated_at":"2024-01-01T00:00:01.5+00:00"%s}`, status, extra)
+}
+
+func TestAnalyzeTopicAsync(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/topic/jobs" {
+			t.Errorf("request = %s %s, want POST /topic/jobs", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(jobJSON("pending", `,"result":null,"error":null`)))
+	})
+
+	job, err := c.AnalyzeTopicAsync(context.Background(), types.TopicRequest{Topic: "CRISPR"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicAsync() error = %v", err)
+	}
+	if job.JobID != "j1" || job.Status != types.JobPending || job.Done() || job.CreatedAt.Year() != 2024 {
+		t.Errorf("job = %+v", job)
+	}
+}
+
+func TestAnalyzeTopicAsyncCallback(t *testing.T) {
+	var callbackURL string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		callbackURL = r.Header.Get("Callback-URL")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(jobJSON("pending", `,"result":null,"error":null`)))
+	})
+
+	ctx := context.Background()
+	if _, err := c.AnalyzeTopicAsync(ctx, types.TopicRequest{Topic: "CRISPR"}, WithCallbackURL("https://example.com/hook")); err != nil {
+		t.Fatalf("AnalyzeTopicAsync() error = %v", err)
+	}
+	if callbackURL != "https://example.com/hook" {
+		t.Errorf("Callback-URL = %q", callbackURL)
+	}
+	if _, err := c.AnalyzeTopicAsync(ctx, types.TopicRequest{Topic: "CRISPR"}); err != nil {
+		t.Fatalf("AnalyzeTopicAsync() error = %v", err)
+	}
+	if callbackURL != "" {
+		t.Errorf("Callback-URL = %q without WithCallbackURL", callbackURL)
+	}
+}
+
+func TestWaitForJob(t *testing.T) {
+	var polls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs/j1" {
+			t.Errorf("path = %q, want /jobs/j1", r.URL.Path)
+		}
+		if polls.Add(1) < 3 {
+			w.Write([]byte(jobJSON("running", "")))
+			return
+		}
+		w.Write([]byte(jobJSON("succeeded", `,"result":{"topic":"CRISPR","papers_analyzed":0,"common_gaps":[],"individual_results":[],"suggested_research_directions":[],"processing_time":1}`)))
+	})
+
+	job, err := c.WaitForJob(context.Background(), "j1", WaitOptions{Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("WaitForJob() error = %v", err)
+	}
+	if job.Result == nil || job.Result.Topic != "CRISPR" {
+		t.Errorf("Result = %+v", job.Result)
+	}
+	if got := polls.Load(); got != 3 {
+		t.Errorf("polled %d times, want 3", got)
+	}
+}
+
+func TestWaitForJobFailed(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(jobJSON("failed", `,"error":"An error occurred during topic analysis."`)))
+	})
+
+	job, err := c.WaitForJob(context.Background(), "j1", WaitOptions{})
+	if !errors.Is(err, ErrJobFailed) {
+		t.Errorf("WaitForJob() error = %v, want ErrJobFailed", err)
+	}
+	if job == nil || job.Status != types.JobFailed {
+		t.Errorf("job = %+v, want the failed job", job)
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path != "/jobs/j1/cancel" {
+			t.Errorf("request = POST %s, want POST /jobs/j1/cancel", r.URL.Path)
+		}
+		w.Write([]byte(jobJSON("cancelled", `,"result":null,"error":null`)))
+	})
+
+	job, err := c.CancelJob(context.Background(), "j1")
+	if err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+	if job.Status != types.JobCancelled || !job.Done() {
+		t.Errorf("job = %+v, want it cancelled", job)
+	}
+	if _, err := c.WaitForJob(context.Background(), "j1", WaitOptions{}); !errors.Is(err, ErrJobCancelled) {
+		t.Errorf("WaitForJob() error = %v, want ErrJobCancelled", err)
+	}
+}
+
+func TestWaitForJobContextDone(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(jobJSON("running", "")))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForJob(ctx, "j1", WaitOptions{Interval: time.Millisecond}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForJob() error = %v, want context.DeadlineExceeded", err)
+	}
+}
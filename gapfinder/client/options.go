@@ -0,0 +1,424 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
+)
+
+// WithBaseURL sets the microservice address, e.g. "http://gap-finder:8001".
+// The URL must be absolute and use the http or https scheme.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid base URL %q: missing host", baseURL)
+		}
+		c.baseURL = strings.TrimRight(u.String(), "/")
+		return nil
+	}
+}
+
+// WithTimeout sets the default time limit for each call, retries included.
+// Zero means no limit. Individual calls can override it with
+// WithRequestTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("timeout must not be negative, got %s", timeout)
+		}
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to reach the microservice, e.g.
+// one already configured for a corporate proxy or custom TLS settings
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) error {
+		if httpClient == nil {
+			return errors.New("http client must not be nil")
+		}
+		c.baseClient, c.httpClient = httpClient, nil
+		return nil
+	}
+}
+
+// WithTransport sets the RoundTripper used to send requests, e.g. an
+// instrumented transport. When combined with WithHTTPClient the given client
+// is copied rather than modified.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) error {
+		if transport == nil {
+			return errors.New("transport must not be nil")
+		}
+		c.transport, c.httpClient = transport, nil
+		return nil
+	}
+}
+
+// WithCompression controls whether responses are requested gzip-compressed
+// and transparently decompressed. It is enabled by default; topic responses
+// with many individual results compress well.
+func WithCompression(enabled bool) Option {
+	return func(c *Client) error {
+		c.compress = enabled
+		return nil
+	}
+}
+
+// WithAPIKey sends key in the X-API-Key header of every request
+func WithAPIKey(key string) Option {
+	return func(c *Client) error {
+		if key == "" {
+			return errors.New("API key must not be empty")
+		}
+		c.apiKey = key
+		return nil
+	}
+}
+
+// WithBearerToken sends token in the Authorization header of every request
+func WithBearerToken(token string) Option {
+	return func(c *Client) error {
+		if token == "" {
+			return errors.New("bearer token must not be empty")
+		}
+		// Replace rather than modify the token holder, which a client
+		// created with Clone may share with its parent
+		b := &bearerToken{value: token}
+		if c.bearer != nil {
+			b.refresher = c.bearer.refresher
+		}
+		c.bearer = b
+		return nil
+	}
+}
+
+// TokenRefresher returns a new bearer token
+type TokenRefresher func(ctx context.Context) (string, error)
+
+// WithTokenRefresher makes the client obtain a fresh bearer token and re-send
+// the request once when the service responds with 401. If no initial token
+// is set with WithBearerToken, the refresher is also used to get the first one.
+func WithTokenRefresher(refresh TokenRefresher) Option {
+	return func(c *Client) error {
+		if refresh == nil {
+			return errors.New("token refresher must not be nil")
+		}
+		b := &bearerToken{refresher: refresh}
+		if c.bearer != nil {
+			b.value = c.bearer.current()
+		}
+		c.bearer = b
+		return nil
+	}
+}
+
+// WithTokenSource authenticates every request with a token from ts, e.g. a
+// client-credentials source for deployments behind an identity provider.
+// Tokens are cached and refreshed by ts when they expire. It cannot be
+// combined with WithBearerToken or WithTokenRefresher.
+func WithTokenSource(ts oauth2.TokenSource) Option {
+	return func(c *Client) error {
+		if ts == nil {
+			return errors.New("token source must not be nil")
+		}
+		c.tokens = oauth2.ReuseTokenSource(nil, ts)
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used to connect to the service,
+// e.g. custom root CAs. It replaces any TLS settings of the underlying
+// transport, so combine it with WithClientCertificate by passing that option
+// afterwards.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) error {
+		if cfg == nil {
+			return errors.New("TLS config must not be nil")
+		}
+		cfg = cfg.Clone()
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			t.TLSClientConfig = cfg.Clone()
+		})
+		return nil
+	}
+}
+
+// WithClientCertificate presents the PEM-encoded certificate and key in
+// certFile and keyFile to the service, for deployments that require mutual TLS
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(c *Client) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("error loading client certificate: %w", err)
+		}
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			if t.TLSClientConfig == nil {
+				t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			}
+			t.TLSClientConfig.Certificates = append(t.TLSClientConfig.Certificates, cert)
+		})
+		return nil
+	}
+}
+
+// WithProxy routes all requests through the proxy at proxyURL, e.g.
+// "http://proxy.internal:3128" or "socks5://127.0.0.1:1080", regardless of
+// the HTTP_PROXY and HTTPS_PROXY environment variables
+func WithProxy(proxyURL string) Option {
+	return func(c *Client) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("invalid proxy URL %q: unsupported scheme %q", proxyURL, u.Scheme)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q: missing host", proxyURL)
+		}
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			t.Proxy = http.ProxyURL(u)
+		})
+		return nil
+	}
+}
+
+// WithProxyFunc selects the proxy for each request with fn, which follows the
+// semantics of http.Transport.Proxy: a nil URL means no proxy
+func WithProxyFunc(fn func(*http.Request) (*url.URL, error)) Option {
+	return func(c *Client) error {
+		if fn == nil {
+			return errors.New("proxy func must not be nil")
+		}
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			t.Proxy = fn
+		})
+		return nil
+	}
+}
+
+// WithMaxResponseBytes fails calls with ErrResponseTooLarge once a response
+// body exceeds n bytes after decompression, so a misbehaving endpoint can't
+// make the client consume unbounded memory. By default there is no limit.
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("max response bytes must be positive, got %d", n)
+		}
+		c.maxResponseBytes = n
+		return nil
+	}
+}
+
+// WithStrictDecoding makes the client reject responses that contain fields
+// the Go structs don't know about or that lack fields the structs require
+// (those without omitempty). Meant for CI, where schema drift between this
+// client and the service should fail loudly instead of leaving fields zero.
+func WithStrictDecoding() Option {
+	return func(c *Client) error {
+		c.strict = true
+		return nil
+	}
+}
+
+// WithMaxIdleConnsPerHost sets how many idle keep-alive connections to the
+// service are kept for reuse. The net/http default of 2 forces high-throughput
+// batch consumers to constantly open new connections.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(c *Client) error {
+		if n < 1 {
+			return fmt.Errorf("max idle connections per host must be at least 1, got %d", n)
+		}
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			t.MaxIdleConnsPerHost = n
+			if t.MaxIdleConns != 0 && t.MaxIdleConns < n {
+				t.MaxIdleConns = n
+			}
+		})
+		return nil
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection is kept in the pool
+// before it is closed. Zero means no limit.
+func WithIdleConnTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("idle connection timeout must not be negative, got %s", timeout)
+		}
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			t.IdleConnTimeout = timeout
+		})
+		return nil
+	}
+}
+
+// WithForceHTTP2 controls whether HTTP/2 is attempted even when the transport
+// has a custom TLS configuration or dialer, which otherwise disables it
+func WithForceHTTP2(enabled bool) Option {
+	return func(c *Client) error {
+		c.transportOptions = append(c.transportOptions, func(t *http.Transport) {
+			t.ForceAttemptHTTP2 = enabled
+		})
+		return nil
+	}
+}
+
+// WithLogger makes the client log requests at debug level and retries and
+// failures at info and warn level to logger. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) error {
+		if logger == nil {
+			return errors.New("logger must not be nil")
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+// Middleware wraps the RoundTripper that sends requests to the service.
+// It can inspect or mutate requests and responses, e.g. for logging,
+// metrics or auth injection.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper, which is handy
+// when writing a Middleware
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware adds middleware around the client's transport. Middleware
+// runs in the order given, so the first one sees each request first and
+// each response last. It applies to every attempt, retries included.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Client) error {
+		for _, mw := range middleware {
+			if mw == nil {
+				return errors.New("middleware must not be nil")
+			}
+		}
+		c.middleware, c.httpClient = append(c.middleware, middleware...), nil
+		return nil
+	}
+}
+
+// WithHeaders adds headers that are sent with every request, e.g. a team
+// name, trace headers or API version hints
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) error {
+		for k, v := range headers {
+			c.headers.Set(k, v)
+		}
+		return nil
+	}
+}
+
+// WithHeader adds a single header that is sent with every request. Calling it
+// again for the same key adds another value rather than replacing it.
+func WithHeader(key, value string) Option {
+	return func(c *Client) error {
+		if key == "" {
+			return errors.New("header name must not be empty")
+		}
+		c.headers.Add(key, value)
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent sent with every request, replacing the
+// default "ai-gap-finder-go/<version>"
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) error {
+		if userAgent == "" {
+			return errors.New("user agent must not be empty")
+		}
+		c.headers.Set("User-Agent", userAgent)
+		return nil
+	}
+}
+
+// WithRetryPolicy enables retries of transient failures
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) error {
+		if policy.InitialBackoff < 0 || policy.MaxBackoff < 0 || policy.MaxRetryAfter < 0 {
+			return errors.New("retry backoff must not be negative")
+		}
+		if policy.Jitter < 0 || policy.Jitter > 1 {
+			return fmt.Errorf("retry jitter must be between 0 and 1, got %v", policy.Jitter)
+		}
+		c.retry = policy
+		return nil
+	}
+}
+
+// WithRateLimit caps the client at r requests per second with bursts of up to
+// burst requests. The limit applies to every attempt, retries included, and
+// is shared by all goroutines using the client.
+func WithRateLimit(r rate.Limit, burst int) Option {
+	return func(c *Client) error {
+		if r <= 0 {
+			return fmt.Errorf("rate limit must be positive, got %v", r)
+		}
+		if burst < 1 {
+			return fmt.Errorf("rate limit burst must be at least 1, got %d", burst)
+		}
+		c.limiter = rate.NewLimiter(r, burst)
+		return nil
+	}
+}
+
+// WithRetryBudget caps the retries of all calls of the client at n, on top of
+// the retry policy's attempts per call. Once they are spent failures are no
+// longer retried, so that a long batch run meeting an outage fails fast
+// rather than multiplying the load on the service. The budget is shared by
+// all goroutines using the client.
+func WithRetryBudget(n int) Option {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("retry budget must not be negative, got %d", n)
+		}
+		c.budget = &retryBudget{}
+		c.budget.left.Store(int64(n))
+		return nil
+	}
+}
+
+// WithCircuitBreaker makes the client fail fast with ErrCircuitOpen after
+// threshold consecutive failed attempts (transport errors or 5xx responses).
+// Once cooldown has elapsed a single probe request is let through; if it
+// succeeds the circuit closes again, otherwise it stays open for another
+// cooldown period.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) error {
+		if threshold < 1 {
+			return fmt.Errorf("circuit breaker threshold must be at least 1, got %d", threshold)
+		}
+		if cooldown <= 0 {
+			return fmt.Errorf("circuit breaker cooldown must be positive, got %s", cooldown)
+		}
+		c.breaker = &circuitBreaker{threshold: threshold, cooldown: cooldown}
+		return nil
+	}
+}
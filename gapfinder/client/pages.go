@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// GetTopicResults fetches the page of a topic's individual results that
+// cursor, the NextCursor of a TopicResponse or of a previous page, points
+// to. Unknown or expired cursors fail with an error matching ErrNotFound.
+func (c *Client) GetTopicResults(ctx context.Context, cursor string, opts ...RequestOption) (*types.TopicResultsPage, error) {
+	var page types.TopicResultsPage
+	id, err := c.do(ctx, http.MethodGet, "/topic/results?cursor="+url.QueryEscape(cursor), nil, &page, opts)
+	if err != nil {
+		return nil, err
+	}
+	page.RequestID = id
+	return &page, nil
+}
+
+// TopicResults iterates over all individual results of a topic response,
+// fetching further pages as needed when the analysis was requested with a
+// PageSize. Iteration stops after the first error.
+//
+//	for result, err := range c.TopicResults(ctx, resp) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(result.PaperTitle)
+//	}
+func (c *Client) TopicResults(ctx context.Context, resp *types.TopicResponse, opts ...RequestOption) iter.Seq2[types.TopicAnalysisResult, error] {
+	return func(yield func(types.TopicAnalysisResult, error) bool) {
+		results, cursor := resp.IndividualResults, resp.NextCursor
+		for {
+			for _, r := range results {
+				if !yield(r, nil) {
+					return
+				}
+			}
+			if cursor == "" {
+				return
+			}
+			page, err := c.GetTopicResults(ctx, cursor, opts...)
+			if err != nil {
+				yield(types.TopicAnalysisResult{}, err)
+				return
+			}
+			results, cursor = page.IndividualResults, page.NextCursor
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestTopicResults(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "c/1":
+			w.Write([]byte(`{"individual_results":[{"paper_title":"B","gaps":[]}],"next_cursor":"c/2"}`))
+		case "c/2":
+			w.Write([]byte(`{"individual_results":[{"paper_title":"C","gaps":[]}],"next_cursor":null}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	})
+
+	resp := &types.TopicResponse{
+		IndividualResults: []types.TopicAnalysisResult{{PaperTitle: "A"}},
+		NextCursor:        "c/1",
+	}
+	var titles []string
+	for result, err := range c.TopicResults(context.Background(), resp) {
+		if err != nil {
+			t.Fatalf("TopicResults() error = %v", err)
+		}
+		titles = append(titles, result.PaperTitle)
+	}
+	if len(titles) != 3 || titles[0] != "A" || titles[2] != "C" {
+		t.Errorf("titles = %q, want [A B C]", titles)
+	}
+}
+
+func TestTopicResultsExpiredCursor(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"detail":"Cursor not found or expired"}`))
+	})
+
+	resp := &types.TopicResponse{IndividualResults: []types.TopicAnalysisResult{{PaperTitle: "A"}}, NextCursor: "gone"}
+	var results, failures int
+	for _, err := range c.TopicResults(context.Background(), resp) {
+		if err != nil {
+			failures++
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("error = %v, want ErrNotFound", err)
+			}
+			continue
+		}
+		results++
+	}
+	if results != 1 || failures != 1 {
+		t.Errorf("got %d results and %d errors, want 1 and 1", results, failures)
+	}
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// AnalyzeDOI analyzes the paper with a DOI, such as "10.1038/nature12373" or
+// its doi.org URL. The service looks up the title, abstract and authors on
+// CrossRef and returns them in the response's Paper. Unknown DOIs fail with
+// a 404 *APIError, and papers CrossRef has no abstract for with a 422 one.
+func (c *Client) AnalyzeDOI(ctx context.Context, doi string, opts ...RequestOption) (*types.AnalyzeResponse, error) {
+	req := types.DOIRequest{DOI: types.NormalizeDOI(doi)}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.AnalyzeResponse
+	id, err := c.do(ctx, http.MethodPost, "/analyze/doi", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// AnalyzePMID analyzes the paper with a PubMed ID, such as "31452104". The
+// service looks up the title, abstract and authors with NCBI E-utilities and
+// returns them in the response's Paper. Unknown IDs fail with a 404
+// *APIError, and articles without an abstract with a 422 one.
+func (c *Client) AnalyzePMID(ctx context.Context, pmid string, opts ...RequestOption) (*types.AnalyzeResponse, error) {
+	req := types.PMIDRequest{PMID: types.NormalizePMID(pmid)}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.AnalyzeResponse
+	id, err := c.do(ctx, http.MethodPost, "/analyze/pmid", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
+
+// AnalyzeArxiv analyzes the paper with an arXiv ID, such as "2101.00001" or
+// its arxiv.org URL. The service looks up the title, abstract and authors on
+// arXiv and returns them in the response's Paper. With WithFullText the
+// service also downloads and analyzes the paper's PDF. Unknown IDs fail with
+// a 404 *APIError.
+func (c *Client) AnalyzeArxiv(ctx context.Context, arxivID string, opts ...RequestOption) (*types.AnalyzeResponse, error) {
+	var rc requestConfig
+	for _, opt := range opts {
+		opt(&rc)
+	}
+	req := types.ArxivRequest{ID: types.NormalizeArxivID(arxivID), FullText: rc.fullText}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.AnalyzeResponse
+	id, err := c.do(ctx, http.MethodPost, "/analyze/arxiv", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
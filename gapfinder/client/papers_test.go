@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestAnalyzeDOI(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/analyze/doi" {
+			t.Errorf("path = %s, want /analyze/doi", r.URL.Path)
+		}
+		var req types.DOIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DOI != "10.1038/nature12373" {
+			t.Errorf("request = %+v, %v", req, err)
+		}
+		w.Write([]byte(`{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1,
+			"paper":{"source":"crossref","id":"10.1038/nature12373","title":"Sleep","authors":["Ada"],"abstract":"We study sleep."}}`))
+	})
+
+	result, err := c.AnalyzeDOI(context.Background(), "https://doi.org/10.1038/nature12373")
+	if err != nil {
+		t.Fatalf("AnalyzeDOI() error = %v", err)
+	}
+	if result.Paper == nil || result.Paper.Title != "Sleep" || result.Paper.Source != "crossref" {
+		t.Errorf("Paper = %+v", result.Paper)
+	}
+}
+
+func TestAnalyzeDOIRejectsMalformedDOI(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("malformed DOI reached the server")
+	})
+	_, err := c.AnalyzeDOI(context.Background(), "nature12373")
+	var verr *types.ValidationError
+	if !errors.As(err, &verr) || verr.Field != "doi" {
+		t.Errorf("AnalyzeDOI() error = %v, want a ValidationError for doi", err)
+	}
+}
+
+func TestAnalyzeArxiv(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/analyze/arxiv" {
+			t.Errorf("path = %s, want /analyze/arxiv", r.URL.Path)
+		}
+		var req types.ArxivRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID != "2101.00001" || !req.FullText {
+			t.Errorf("request = %+v, %v", req, err)
+		}
+		w.Write([]byte(analyzeJSON))
+	})
+
+	if _, err := c.AnalyzeArxiv(context.Background(), "arXiv:2101.00001", WithFullText()); err != nil {
+		t.Fatalf("AnalyzeArxiv() error = %v", err)
+	}
+
+	_, err := c.AnalyzeArxiv(context.Background(), "https://example.com/2101.00001")
+	var verr *types.ValidationError
+	if !errors.As(err, &verr) || verr.Field != "arxiv_id" {
+		t.Errorf("AnalyzeArxiv() error = %v, want a ValidationError for arxiv_id", err)
+	}
+}
+
+func TestAnalyzePMID(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.PMIDRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/analyze/pmid" || req.PMID != "31452104" {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(analyzeJSON))
+	})
+
+	if _, err := c.AnalyzePMID(context.Background(), "PMID: 31452104"); err != nil {
+		t.Fatalf("AnalyzePMID() error = %v", err)
+	}
+	var verr *types.ValidationError
+	if _, err := c.AnalyzePMID(context.Background(), "PMC6710543"); !errors.As(err, &verr) {
+		t.Errorf("AnalyzePMID() error = %v, want a ValidationError", err)
+	}
+}
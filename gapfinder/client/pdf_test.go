@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+const analyzeJSON = `{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1}`
+
+func TestAnalyzePDF(t *testing.T) {
+	attempts := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.URL.Path != "/analyze/pdf" {
+			t.Errorf("path = %s, want /analyze/pdf", r.URL.Path)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("FormFile() error = %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		if string(data) != "%PDF-1.4" || header.Filename != "sleep.pdf" || header.Header.Get("Content-Type") != "application/pdf" {
+			t.Errorf("file = %q named %q", data, header.Filename)
+		}
+		if r.FormValue("title") != "Sleep" || r.FormValue("field") != string(types.FieldNeuroscience) || len(r.Form["authors"]) != 2 {
+			t.Errorf("form = %v", r.Form)
+		}
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(analyzeJSON))
+	}, WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+
+	_, err := c.AnalyzePDF(context.Background(), strings.NewReader("%PDF-1.4"), types.PDFMetadata{
+		Filename: "sleep.pdf",
+		Title:    "Sleep",
+		Field:    types.FieldNeuroscience,
+		Authors:  []string{"A", "B"},
+	})
+	if err != nil {
+		t.Fatalf("AnalyzePDF() error = %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("server saw %d attempts, want the upload retried once", attempts)
+	}
+}
+
+func TestAnalyzePDFRejectsEmptyFile(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("empty file reached the server")
+	})
+
+	_, err := c.AnalyzePDF(context.Background(), strings.NewReader(""), types.PDFMetadata{})
+	var verr *types.ValidationError
+	if !errors.As(err, &verr) || verr.Field != "file" {
+		t.Errorf("AnalyzePDF() error = %v, want file validation error", err)
+	}
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// SimulateReview analyzes a paper as AnalyzeAbstract does and critiques it
+// the way a peer reviewer would, on its soundness, novelty, missing
+// baselines, clarity and significance, for checking a manuscript before
+// submission. Requests that fail validation are rejected with a
+// *types.ValidationError without being sent.
+func (c *Client) SimulateReview(ctx context.Context, req types.AnalyzeRequest, opts ...RequestOption) (*types.PeerReviewResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.PeerReviewResponse
+	id, err := c.do(ctx, http.MethodPost, "/analyze/peer-review", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestSimulateReview(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.AnalyzeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/analyze/peer-review" || req.Title != "Sleep and memory" {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"recommendation":"major_revision","summary":"s",
+			"critiques":[{"aspect":"baselines","severity":"major","comment":"No comparison with prior work"}],
+			"missing_baselines":["Walker et al. 2002"],"gaps":[],"processing_time":2.5}`))
+	})
+
+	result, err := c.SimulateReview(context.Background(), types.AnalyzeRequest{Title: "Sleep and memory", Abstract: "We studied sleep."})
+	if err != nil {
+		t.Fatalf("SimulateReview() error = %v", err)
+	}
+	if result.Recommendation != types.RecommendMajorRevision || len(result.Critiques) != 1 || result.MissingBaselines[0] != "Walker et al. 2002" || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestSimulateReviewValidates(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid request was sent")
+	})
+	_, err := c.SimulateReview(context.Background(), types.AnalyzeRequest{Title: "Sleep and memory"})
+	if err == nil {
+		t.Fatal("SimulateReview() with no abstract succeeded")
+	}
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// Refine updates a previous analysis following feedback such as "gap 2 is
+// out of scope" or "expand on the sampling gap", keeping what the feedback
+// doesn't concern, so an analysis can be worked on conversationally. The
+// result can be refined again in turn.
+func (c *Client) Refine(ctx context.Context, previous *types.AnalyzeResponse, feedback string, opts ...RequestOption) (*types.AnalyzeResponse, error) {
+	if previous == nil {
+		return nil, errors.New("previous response must not be nil")
+	}
+	req := types.RefineRequest{Previous: *previous, Feedback: feedback}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.AnalyzeResponse
+	id, err := c.do(ctx, http.MethodPost, "/analyze/refine", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestRefine(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.RefineRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/analyze/refine" || req.Feedback != "Drop gap 2" || len(req.Previous.Gaps) != 2 {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"key_findings":[],"gaps":[{"gap_description":"Small samples","confidence_score":0.8,"gap_type":"empirical","potential_impact":"i"}],
+			"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[],"processing_time":1.5}`))
+	})
+
+	previous := &types.AnalyzeResponse{Gaps: []types.ResearchGap{{GapDescription: "Small samples"}, {GapDescription: "Funding"}}}
+	result, err := c.Refine(context.Background(), previous, "Drop gap 2")
+	if err != nil {
+		t.Fatalf("Refine() error = %v", err)
+	}
+	if len(result.Gaps) != 1 || result.Gaps[0].GapDescription != "Small samples" || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestRefineValidates(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid request was sent")
+	})
+	previous := &types.AnalyzeResponse{Gaps: []types.ResearchGap{{GapDescription: "Small samples"}}}
+	if _, err := c.Refine(context.Background(), previous, " "); err == nil {
+		t.Fatal("Refine() with no feedback succeeded")
+	}
+}
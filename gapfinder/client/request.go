@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// RequestOption customizes a single call
+type RequestOption func(*requestConfig)
+
+type requestConfig struct {
+	timeout        time.Duration
+	idempotencyKey string
+	requestID      string
+	contentType    string // of a raw request body; JSON bodies set their own
+	fullText       bool
+	callbackURL    string
+}
+
+// WithRequestTimeout overrides the client's default timeout for one call,
+// e.g. a few seconds for HealthCheck or several minutes for a large
+// AnalyzeTopic. Zero means no limit.
+func WithRequestTimeout(timeout time.Duration) RequestOption {
+	return func(rc *requestConfig) {
+		rc.timeout = timeout
+	}
+}
+
+// WithIdempotencyKey sends key as the Idempotency-Key of a POST instead of a
+// generated one, e.g. to deduplicate a request across process restarts
+func WithIdempotencyKey(key string) RequestOption {
+	return func(rc *requestConfig) {
+		rc.idempotencyKey = key
+	}
+}
+
+// WithFullText makes AnalyzeArxiv have the service download the paper's PDF
+// and analyze its methods, results and discussion too, which takes longer.
+// Other calls ignore it.
+func WithFullText() RequestOption {
+	return func(rc *requestConfig) {
+		rc.fullText = true
+	}
+}
+
+// WithCallbackURL makes AnalyzeTopicAsync have the service POST the finished
+// job to u, so it needn't be polled. The callbacks are signed with a secret
+// set on the service; verify them with package webhook. Other calls ignore
+// it.
+func WithCallbackURL(u string) RequestOption {
+	return func(rc *requestConfig) {
+		rc.callbackURL = u
+	}
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context whose calls send id as their
+// X-Request-ID, e.g. to propagate the ID of an incoming request. Without it
+// each call gets a freshly generated ID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID set with ContextWithRequestID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how transient failures (connection errors and 502,
+// 503 and 504 responses) are retried. Backoff between attempts grows
+// exponentially from InitialBackoff by Multiplier up to MaxBackoff, and
+// Jitter randomly shortens each delay by up to that fraction so that many
+// clients failing together don't retry in lockstep.
+//
+// When the service sends a Retry-After header, the client waits exactly that
+// long instead. RetryOnRateLimit additionally retries 429 responses, as long
+// as the requested wait is no longer than MaxRetryAfter (if set) and fits
+// within the call's deadline.
+type RetryPolicy struct {
+	MaxAttempts      int // total attempts including the first; 1 or less disables retries
+	InitialBackoff   time.Duration
+	MaxBackoff       time.Duration
+	Multiplier       float64
+	Jitter           float64 // between 0 and 1
+	RetryOnRateLimit bool
+	MaxRetryAfter    time.Duration
+}
+
+// DefaultRetryPolicy is a reasonable policy for WithRetryPolicy. Clients
+// do not retry unless a policy is configured.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:      4,
+	InitialBackoff:   500 * time.Millisecond,
+	MaxBackoff:       10 * time.Second,
+	Multiplier:       2,
+	Jitter:           0.2,
+	RetryOnRateLimit: true,
+	MaxRetryAfter:    30 * time.Second,
+}
+
+// backoff returns the delay before retry number n (starting at 1)
+func (p RetryPolicy) backoff(n int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(n-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d -= d * min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// retryBudget is the number of retries left to the calls of a client. A nil
+// *retryBudget allows every retry.
+type retryBudget struct {
+	left atomic.Int64
+}
+
+// take spends a retry, reporting false if there is none left
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	return b.left.Add(-1) >= 0
+}
+
+// isRetryableStatus reports whether a response status indicates a transient
+// failure of the service or a proxy in front of it
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isTransientError reports whether a transport error is worth retrying
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
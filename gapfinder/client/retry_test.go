@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var fastRetries = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2}
+
+func TestRetryTransientStatus(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(healthyJSON))
+	}, WithRetryPolicy(fastRetries))
+
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server saw %d requests, want 3", got)
+	}
+	if got := c.Stats()["GET /health"].Retries; got != 2 {
+		t.Errorf("Retries = %d, want 2", got)
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}, WithRetryPolicy(fastRetries))
+
+	if _, err := c.HealthCheck(context.Background()); err == nil {
+		t.Fatal("HealthCheck() error = nil, want error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server saw %d requests, want 1", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"detail":"slow down"}`))
+			return
+		}
+		w.Write([]byte(healthyJSON))
+	}
+
+	t.Run("without retries", func(t *testing.T) {
+		calls.Store(0)
+		c := newTestClient(t, handler)
+		_, err := c.HealthCheck(context.Background())
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("HealthCheck() error = %v, want rate limited *APIError", err)
+		}
+		if apiErr.RetryAfter != time.Second {
+			t.Errorf("RetryAfter = %s, want 1s", apiErr.RetryAfter)
+		}
+	})
+
+	t.Run("honored", func(t *testing.T) {
+		calls.Store(0)
+		policy := fastRetries
+		policy.RetryOnRateLimit = true
+		c := newTestClient(t, handler, WithRetryPolicy(policy))
+		start := time.Now()
+		if _, err := c.HealthCheck(context.Background()); err != nil {
+			t.Fatalf("HealthCheck() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("retried after %s, want at least 1s", elapsed)
+		}
+	})
+
+	t.Run("exceeds MaxRetryAfter", func(t *testing.T) {
+		calls.Store(0)
+		policy := fastRetries
+		policy.RetryOnRateLimit = true
+		policy.MaxRetryAfter = 500 * time.Millisecond
+		c := newTestClient(t, handler, WithRetryPolicy(policy))
+		if _, err := c.HealthCheck(context.Background()); !errors.Is(err, ErrRateLimited) {
+			t.Errorf("HealthCheck() error = %v, want ErrRateLimited", err)
+		}
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+
+	p.Jitter = 0.5
+	for range 100 {
+		if got := p.backoff(1); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("backoff(1) with jitter = %s, want within [50ms, 100ms]", got)
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithRetryPolicy(fastRetries), WithRetryBudget(3))
+	ctx := context.Background()
+
+	// The first call retries twice, the second once before the budget is
+	// spent, and the third not at all
+	for range 3 {
+		if _, err := c.HealthCheck(ctx); err == nil {
+			t.Fatal("HealthCheck() error = nil, want error")
+		}
+	}
+	if got := calls.Load(); got != 3+2+1 {
+		t.Errorf("server saw %d requests, want 6", got)
+	}
+
+	clone, err := c.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls.Store(0)
+	clone.HealthCheck(ctx)
+	if got := calls.Load(); got != 1 {
+		t.Errorf("clone sent %d requests, want 1 as it shares the spent budget", got)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(healthyJSON))
+	}, WithCircuitBreaker(2, 50*time.Millisecond))
+	ctx := context.Background()
+
+	c.HealthCheck(ctx)
+	c.HealthCheck(ctx)
+	if _, err := c.HealthCheck(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("HealthCheck() error = %v, want ErrCircuitOpen", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server saw %d requests while open, want 2", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	failing.Store(false)
+	for range 2 {
+		if _, err := c.HealthCheck(ctx); err != nil {
+			t.Fatalf("HealthCheck() after cooldown error = %v", err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// GenerateReview drafts a literature review of a topic analysis: its
+// themes, the papers it cites and the gaps the papers leave open, as
+// sections to render in order. Only the IndividualResults held by topic are
+// reviewed, so fetch the remaining pages of a paged TopicResponse with
+// GetTopicResults first.
+func (c *Client) GenerateReview(ctx context.Context, topic *types.TopicResponse, opts ...RequestOption) (*types.ReviewResponse, error) {
+	if topic == nil {
+		return nil, errors.New("topic response must not be nil")
+	}
+	req := types.ReviewRequest{
+		Topic:      topic.Topic,
+		Papers:     topic.IndividualResults,
+		CommonGaps: topic.CommonGaps,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.ReviewResponse
+	id, err := c.do(ctx, http.MethodPost, "/review", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestGenerateReview(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.ReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/review" || req.Topic != "sleep" || len(req.Papers) != 2 {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"title":"Sleep: a review","sections":[{"kind":"theme","heading":"Recall","paragraphs":["Sleep helps [1]."],"citations":[1]}],
+			"references":[{"number":1,"title":"Sleep helps memory","authors":[]}],"processing_time":1.5}`))
+	})
+
+	result, err := c.GenerateReview(context.Background(), &types.TopicResponse{
+		Topic:             "sleep",
+		IndividualResults: []types.TopicAnalysisResult{{PaperTitle: "Sleep helps memory"}, {PaperTitle: "Sleep and recall"}},
+	})
+	if err != nil {
+		t.Fatalf("GenerateReview() error = %v", err)
+	}
+	if len(result.Sections) != 1 || result.Sections[0].Kind != types.SectionTheme || result.References[0].Number != 1 || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// EndpointStats holds counters for calls to one endpoint
+type EndpointStats struct {
+	Requests     int64 // calls made, each counted once regardless of retries
+	Errors       int64 // calls that ultimately failed
+	Retries      int64 // extra attempts sent after the first
+	TotalLatency time.Duration
+}
+
+// AverageLatency returns the mean duration of a call, retries included
+func (s EndpointStats) AverageLatency() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Requests)
+}
+
+type clientStats struct {
+	mu        sync.Mutex
+	endpoints map[string]*EndpointStats
+}
+
+func newClientStats() *clientStats {
+	return &clientStats{endpoints: make(map[string]*EndpointStats)}
+}
+
+func (s *clientStats) record(endpoint string, sent int, err error, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	es, ok := s.endpoints[endpoint]
+	if !ok {
+		es = &EndpointStats{}
+		s.endpoints[endpoint] = es
+	}
+	es.Requests++
+	if err != nil {
+		es.Errors++
+	}
+	if sent > 1 {
+		es.Retries += int64(sent - 1)
+	}
+	es.TotalLatency += latency
+}
+
+// Stats returns a snapshot of per-endpoint counters keyed by method and
+// path, e.g. "POST /analyze". It is safe to call while requests are in flight.
+func (c *Client) Stats() map[string]EndpointStats {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	snapshot := make(map[string]EndpointStats, len(c.stats.endpoints))
+	for endpoint, es := range c.stats.endpoints {
+		snapshot[endpoint] = *es
+	}
+	return snapshot
+}
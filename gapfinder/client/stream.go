@@ -0,0 +1,175 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// ErrStreamFailed is returned by TopicStream.Wait and WatchTopic when the
+// service reports a failure after the analysis started
+var ErrStreamFailed = errors.New("stream failed")
+
+// TopicStream delivers the results of AnalyzeTopicStream. Read Results until
+// it is closed, then call Wait for the outcome.
+type TopicStream struct {
+	// Results receives each paper's analysis as soon as the service has
+	// finished it. It is closed when the stream ends.
+	Results <-chan types.TopicAnalysisResult
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	resp   *types.TopicResponse
+	err    error
+}
+
+// Wait blocks until the stream has ended and returns the topic-wide
+// response, whose IndividualResults hold every result delivered on Results
+func (s *TopicStream) Wait() (*types.TopicResponse, error) {
+	<-s.done
+	return s.resp, s.err
+}
+
+// Close abandons the stream. It is safe to call after the stream has ended.
+func (s *TopicStream) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// NewTopicStream returns a stream that delivers results and then ends with
+// resp and err, e.g. for AnalyzerMock.AnalyzeTopicStreamFunc to return
+func NewTopicStream(results []types.TopicAnalysisResult, resp *types.TopicResponse, err error) *TopicStream {
+	ch := make(chan types.TopicAnalysisResult, len(results))
+	for _, r := range results {
+		ch <- r
+	}
+	close(ch)
+	done := make(chan struct{})
+	close(done)
+	return &TopicStream{Results: ch, cancel: func() {}, done: done, resp: resp, err: err}
+}
+
+// AnalyzeTopicStream analyzes a topic like AnalyzeTopic but delivers each
+// paper's result on the returned stream as soon as it completes, so callers
+// can show progress. The service analyzes every paper on its own, which
+// costs more LLM calls than AnalyzeTopic. The client's timeout applies to
+// the whole stream; pass WithRequestTimeout to allow longer. Requests that
+// fail validation are rejected with a *types.ValidationError without being
+// sent.
+func (c *Client) AnalyzeTopicStream(ctx context.Context, req types.TopicRequest, opts ...RequestOption) (*TopicStream, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	results := make(chan types.TopicAnalysisResult)
+	s := &TopicStream{Results: results, cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(s.done)
+		defer cancel()
+		dec := &topicStreamDecoder{ctx: ctx, results: results}
+		id, err := c.do(ctx, http.MethodPost, "/topic/stream", req, dec, opts)
+		close(results)
+		switch {
+		case err != nil:
+			s.err = err
+		case dec.summary == nil:
+			s.err = fmt.Errorf("%w: stream ended without a summary", ErrStreamFailed)
+		default:
+			dec.summary.IndividualResults = dec.delivered
+			dec.summary.RequestID = id
+			s.resp = dec.summary
+		}
+	}()
+	return s, nil
+}
+
+// streamDecoder is implemented by response targets that consume a streamed
+// body themselves instead of having it decoded as JSON
+type streamDecoder interface {
+	decodeStream(r io.Reader) error
+	// started reports whether anything was delivered, after which the call
+	// must not be retried
+	started() bool
+}
+
+// topicStreamDecoder reads the server-sent events of /topic/stream
+type topicStreamDecoder struct {
+	ctx       context.Context
+	results   chan<- types.TopicAnalysisResult
+	delivered []types.TopicAnalysisResult
+	summary   *types.TopicResponse
+}
+
+func (d *topicStreamDecoder) started() bool {
+	return len(d.delivered) > 0
+}
+
+func (d *topicStreamDecoder) decodeStream(r io.Reader) error {
+	return readEvents(r, func(event string, data []byte) error {
+		switch event {
+		case "result":
+			var result types.TopicAnalysisResult
+			if err := json.Unmarshal(data, &result); err != nil {
+				return fmt.Errorf("error decoding result event: %w", err)
+			}
+			select {
+			case d.results <- result:
+			case <-d.ctx.Done():
+				return d.ctx.Err()
+			}
+			d.delivered = append(d.delivered, result)
+		case "summary":
+			var summary types.TopicResponse
+			if err := json.Unmarshal(data, &summary); err != nil {
+				return fmt.Errorf("error decoding summary event: %w", err)
+			}
+			d.summary = &summary
+		case "error":
+			var detail struct {
+				Detail string `json:"detail"`
+			}
+			_ = json.Unmarshal(data, &detail)
+			return fmt.Errorf("%w: %s", ErrStreamFailed, detail.Detail)
+		}
+		// Other events are for newer clients
+		return nil
+	})
+}
+
+// readEvents parses a text/event-stream body, calling fn with the type and
+// data of each event
+func readEvents(r io.Reader, fn func(event string, data []byte) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 16<<20)
+	var event string
+	var data [][]byte
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			if len(data) > 0 {
+				if err := fn(event, bytes.Join(data, []byte("\n"))); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+			continue
+		}
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			event = string(value)
+		case "data":
+			data = append(data, bytes.Clone(value))
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestAnalyzeTopicStream(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/topic/stream" {
+			t.Errorf("request = %s %s, want POST /topic/stream", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(": comment\n\n" +
+			"event: result\ndata: {\"paper_title\":\"A\",\"gaps\":[],\"confidence\":0.8}\n\n" +
+			"event: progress\ndata: {}\n\n" +
+			"event: result\ndata: {\"paper_title\":\"B\",\"gaps\":[],\"confidence\":0.6}\n\n" +
+			"event: summary\ndata: {\"topic\":\"CRISPR\",\"papers_analyzed\":2,\"common_gaps\":[],\"individual_results\":[],\"processing_time\":1}\n\n"))
+	})
+
+	stream, err := c.AnalyzeTopicStream(context.Background(), types.TopicRequest{Topic: "CRISPR"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicStream() error = %v", err)
+	}
+	var titles []string
+	for result := range stream.Results {
+		titles = append(titles, result.PaperTitle)
+	}
+	resp, err := stream.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if len(titles) != 2 || titles[0] != "A" || titles[1] != "B" {
+		t.Errorf("streamed titles = %q, want [A B]", titles)
+	}
+	if resp.PapersAnalyzed != 2 || len(resp.IndividualResults) != 2 || resp.RequestID == "" {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestAnalyzeTopicStreamError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("event: result\ndata: {\"paper_title\":\"A\",\"gaps\":[],\"confidence\":0.8}\n\n" +
+			"event: error\ndata: {\"detail\":\"LLM unavailable\"}\n\n"))
+	})
+
+	stream, err := c.AnalyzeTopicStream(context.Background(), types.TopicRequest{Topic: "CRISPR"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicStream() error = %v", err)
+	}
+	for range stream.Results {
+	}
+	if _, err := stream.Wait(); !errors.Is(err, ErrStreamFailed) {
+		t.Errorf("Wait() error = %v, want ErrStreamFailed", err)
+	}
+}
+
+func TestTopicStreamClose(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("event: result\ndata: {\"paper_title\":\"A\",\"gaps\":[],\"confidence\":0.8}\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	stream, err := c.AnalyzeTopicStream(context.Background(), types.TopicRequest{Topic: "CRISPR"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicStream() error = %v", err)
+	}
+	<-stream.Results
+	stream.Close()
+	if _, err := stream.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestNewTopicStream(t *testing.T) {
+	var a Analyzer = &AnalyzerMock{
+		AnalyzeTopicStreamFunc: func(ctx context.Context, req types.TopicRequest, opts ...RequestOption) (*TopicStream, error) {
+			results := []types.TopicAnalysisResult{{PaperTitle: "A"}, {PaperTitle: "B"}}
+			return NewTopicStream(results, &types.TopicResponse{Topic: req.Topic, PapersAnalyzed: 2}, nil), nil
+		},
+	}
+
+	stream, err := a.AnalyzeTopicStream(context.Background(), types.TopicRequest{Topic: "CRISPR"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicStream() error = %v", err)
+	}
+	var titles []string
+	for result := range stream.Results {
+		titles = append(titles, result.PaperTitle)
+	}
+	resp, err := stream.Wait()
+	if err != nil || resp.Topic != "CRISPR" {
+		t.Errorf("Wait() = %+v, %v", resp, err)
+	}
+	if len(titles) != 2 || titles[0] != "A" || titles[1] != "B" {
+		t.Errorf("streamed titles = %q, want [A B]", titles)
+	}
+	stream.Close()
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// AnalyzeTrends shows how the gaps raised by papers on a topic have evolved
+// over the last yearsBack publication years, counting the current one: the
+// gap types raised each year, and which gaps are closing, persistent or
+// emerging. Zero yearsBack means the service's default of 5.
+func (c *Client) AnalyzeTrends(ctx context.Context, topic string, yearsBack int, opts ...RequestOption) (*types.TrendsResponse, error) {
+	req := types.TrendsRequest{Topic: topic, YearsBack: yearsBack}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var result types.TrendsResponse
+	id, err := c.do(ctx, http.MethodPost, "/topic/trends", req, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = id
+	return &result, nil
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestAnalyzeTrends(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req types.TrendsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/topic/trends" || req.Topic != "sleep" || req.YearsBack != 3 {
+			t.Errorf("%s request = %+v, %v", r.URL.Path, req, err)
+		}
+		w.Write([]byte(`{"topic":"sleep","from_year":2024,"to_year":2026,"papers_analyzed":2,
+			"years":[{"year":2024,"paper_count":1,"gap_types":[{"gap_type":"empirical","count":1}]},
+				{"year":2025,"paper_count":0,"gap_types":[]},{"year":2026,"paper_count":1,"gap_types":[]}],
+			"gaps":[{"gap_description":"Small samples","gap_type":"empirical","years":[2024],"status":"closing"}],"processing_time":1.5}`))
+	})
+
+	result, err := c.AnalyzeTrends(context.Background(), "sleep", 3)
+	if err != nil {
+		t.Fatalf("AnalyzeTrends() error = %v", err)
+	}
+	if len(result.Years) != 3 || result.Gaps[0].Status != types.TrendClosing || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+	if _, err := c.AnalyzeTrends(context.Background(), "sleep", types.MaxYearsBack+1); err == nil {
+		t.Error("AnalyzeTrends() with too many years succeeded")
+	}
+}
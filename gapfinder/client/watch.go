@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/internal/uuid"
+	"golang.org/x/net/websocket"
+)
+
+// WatchTopic analyzes a topic over the service's /topic/ws WebSocket and
+// calls fn as each paper is fetched, starts being analyzed, and is done or
+// has failed, e.g. to drive a progress bar. It returns the topic response
+// once all papers are done. Like AnalyzeTopicStream, this makes an LLM call
+// per paper.
+//
+// The WebSocket is dialed directly: the client's authentication headers and
+// timeout apply, but not its transport, middleware, retries or rate limit. A
+// failure reported by the service wraps ErrStreamFailed.
+func (c *Client) WatchTopic(ctx context.Context, req types.TopicRequest, fn func(types.TopicProgress), opts ...RequestOption) (*types.TopicResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	rc := requestConfig{timeout: c.timeout}
+	for _, opt := range opts {
+		opt(&rc)
+	}
+	if rc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rc.timeout)
+		defer cancel()
+	}
+	requestID := RequestIDFromContext(ctx)
+	if requestID == "" {
+		requestID = uuid.New()
+	}
+
+	httpReq, err := c.newRequest(ctx, http.MethodGet, "/topic/ws", nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("X-Request-ID", requestID)
+	httpReq.Header.Del("Accept-Encoding")
+	wsURL := "ws" + strings.TrimPrefix(c.baseURL, "http") + "/topic/ws"
+	config, err := websocket.NewConfig(wsURL, c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	config.Header = httpReq.Header
+
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, contextError(ctx, fmt.Errorf("error connecting to %s: %w", wsURL, err))
+	}
+	defer ws.Close()
+	// Unblock reads when the context ends
+	stop := context.AfterFunc(ctx, func() { ws.Close() })
+	defer stop()
+
+	if err := websocket.JSON.Send(ws, req); err != nil {
+		return nil, contextError(ctx, fmt.Errorf("error sending request: %w", err))
+	}
+	for {
+		var msg types.ProgressMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			return nil, contextError(ctx, fmt.Errorf("error reading progress: %w", err))
+		}
+		switch msg.Type {
+		case types.MessageProgress:
+			if msg.Progress != nil {
+				fn(*msg.Progress)
+			}
+		case types.MessageResult:
+			if msg.Result == nil {
+				return nil, errors.New("result message without a result")
+			}
+			msg.Result.RequestID = requestID
+			return msg.Result, nil
+		case types.MessageError:
+			return nil, fmt.Errorf("%w: %s", ErrStreamFailed, msg.Detail)
+		}
+		// Other messages are for newer clients
+	}
+}
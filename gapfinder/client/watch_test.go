@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"golang.org/x/net/websocket"
+)
+
+// progressServer returns a handler that answers a topic request on
+// /topic/ws with msgs
+func progressServer(t *testing.T, msgs ...types.ProgressMessage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topic/ws" || r.Header.Get("X-API-Key") != "secret" || r.Header.Get("X-Request-ID") == "" {
+			t.Errorf("request = %s %s with headers %v", r.Method, r.URL.Path, r.Header)
+		}
+		websocket.Server{Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			var req types.TopicRequest
+			if err := websocket.JSON.Receive(ws, &req); err != nil || req.Topic != "CRISPR" {
+				t.Errorf("received %+v, %v", req, err)
+			}
+			for _, msg := range msgs {
+				websocket.JSON.Send(ws, msg)
+			}
+		}}.ServeHTTP(w, r)
+	}
+}
+
+func TestWatchTopic(t *testing.T) {
+	c := newTestClient(t, progressServer(t,
+		types.ProgressMessage{Type: types.MessageProgress, Progress: &types.TopicProgress{PaperTitle: "A", Total: 1, Stage: types.ProgressFetched}},
+		types.ProgressMessage{Type: "heartbeat"},
+		types.ProgressMessage{Type: types.MessageProgress, Progress: &types.TopicProgress{PaperTitle: "A", Total: 1, Stage: types.ProgressDone}},
+		types.ProgressMessage{Type: types.MessageResult, Result: &types.TopicResponse{Topic: "CRISPR", PapersAnalyzed: 1}},
+	), WithAPIKey("secret"))
+
+	var stages []string
+	result, err := c.WatchTopic(context.Background(), types.TopicRequest{Topic: "CRISPR"}, func(p types.TopicProgress) {
+		stages = append(stages, p.Stage)
+	})
+	if err != nil {
+		t.Fatalf("WatchTopic() error = %v", err)
+	}
+	if len(stages) != 2 || stages[0] != types.ProgressFetched || stages[1] != types.ProgressDone {
+		t.Errorf("stages = %q, want [fetched done]", stages)
+	}
+	if result.PapersAnalyzed != 1 || result.RequestID == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestWatchTopicError(t *testing.T) {
+	c := newTestClient(t, progressServer(t,
+		types.ProgressMessage{Type: types.MessageError, Detail: "An error occurred during topic analysis."},
+	), WithAPIKey("secret"))
+
+	_, err := c.WatchTopic(context.Background(), types.TopicRequest{Topic: "CRISPR"}, func(types.TopicProgress) {})
+	if !errors.Is(err, ErrStreamFailed) {
+		t.Errorf("WatchTopic() error = %v, want ErrStreamFailed", err)
+	}
+}
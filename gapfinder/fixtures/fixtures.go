@@ -0,0 +1,142 @@
+// Package fixtures ships representative responses of the AI Gap Finder
+// service, including edge cases, for use in tests of the client, the fake
+// server in gapfindertest and downstream code.
+//
+// Analyze fixtures:
+//
+//	default       typical analysis with gaps and a hypothesis
+//	empty_gaps    no gaps, hypotheses or directions at all
+//	unicode       German, Chinese, French and emoji text
+//	null_methods  a hypothesis whose required_methods is null
+//	fallback      what the Python service returns when the LLM fails
+//
+// Topic fixtures:
+//
+//	default       two papers, one without gaps
+//	no_papers     nothing found for the topic
+//	large         250 individual results (about 300 KB)
+//
+// Deduplicate fixtures, shared with the Python service's tests:
+//
+//	default       clusters with invented and repeated members and a gap left out
+//	blank_fields  a cluster gap whose fields the model left empty or null
+package fixtures
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+//go:embed data
+var data embed.FS
+
+// AnalyzeJSON returns the raw JSON of the named AnalyzeResponse fixture
+func AnalyzeJSON(name string) ([]byte, error) {
+	return data.ReadFile(path.Join("data/analyze", name+".json"))
+}
+
+// TopicJSON returns the raw JSON of the named TopicResponse fixture
+func TopicJSON(name string) ([]byte, error) {
+	return data.ReadFile(path.Join("data/topic", name+".json"))
+}
+
+// DeduplicateCase is a gap deduplication both services must handle alike:
+// given Request and the model's Reply, they respond with Clusters
+type DeduplicateCase struct {
+	Request  types.DeduplicateRequest `json:"request"`
+	Reply    json.RawMessage          `json:"reply"`
+	Clusters []types.GapCluster       `json:"clusters"`
+}
+
+// DeduplicateJSON returns the raw JSON of the named DeduplicateCase fixture
+func DeduplicateJSON(name string) ([]byte, error) {
+	return data.ReadFile(path.Join("data/deduplicate", name+".json"))
+}
+
+// Analyze returns the named AnalyzeResponse fixture
+func Analyze(name string) (types.AnalyzeResponse, error) {
+	var resp types.AnalyzeResponse
+	err := load(AnalyzeJSON, name, &resp)
+	return resp, err
+}
+
+// Topic returns the named TopicResponse fixture
+func Topic(name string) (types.TopicResponse, error) {
+	var resp types.TopicResponse
+	err := load(TopicJSON, name, &resp)
+	return resp, err
+}
+
+// Deduplicate returns the named DeduplicateCase fixture
+func Deduplicate(name string) (DeduplicateCase, error) {
+	var c DeduplicateCase
+	err := load(DeduplicateJSON, name, &c)
+	return c, err
+}
+
+// MustAnalyze is like Analyze but panics if the fixture doesn't exist
+func MustAnalyze(name string) types.AnalyzeResponse {
+	resp, err := Analyze(name)
+	if err != nil {
+		panic(err)
+	}
+	return resp
+}
+
+// MustTopic is like Topic but panics if the fixture doesn't exist
+func MustTopic(name string) types.TopicResponse {
+	resp, err := Topic(name)
+	if err != nil {
+		panic(err)
+	}
+	return resp
+}
+
+// AnalyzeNames lists the AnalyzeResponse fixtures
+func AnalyzeNames() []string {
+	return names("data/analyze")
+}
+
+// TopicNames lists the TopicResponse fixtures
+func TopicNames() []string {
+	return names("data/topic")
+}
+
+// DeduplicateNames lists the DeduplicateCase fixtures
+func DeduplicateNames() []string {
+	return names("data/deduplicate")
+}
+
+// load decodes a fixture. Each call returns a fresh copy, so callers may
+// modify the result.
+func load(read func(string) ([]byte, error), name string, out any) error {
+	raw, err := read(name)
+	if err != nil {
+		return fmt.Errorf("fixture %q: %w", name, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("fixture %q: %w", name, err)
+	}
+	return nil
+}
+
+func names(dir string) []string {
+	entries, err := fs.ReadDir(data, dir)
+	if err != nil {
+		panic(err)
+	}
+	var list []string
+	for _, e := range entries {
+		list = append(list, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	return list
+}
@@ -0,0 +1,54 @@
+package fixtures
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestAllFixturesLoad(t *testing.T) {
+	for _, name := range AnalyzeNames() {
+		if _, err := Analyze(name); err != nil {
+			t.Errorf("Analyze(%q) error = %v", name, err)
+		}
+	}
+	for _, name := range TopicNames() {
+		if _, err := Topic(name); err != nil {
+			t.Errorf("Topic(%q) error = %v", name, err)
+		}
+	}
+	for _, name := range DeduplicateNames() {
+		if _, err := Deduplicate(name); err != nil {
+			t.Errorf("Deduplicate(%q) error = %v", name, err)
+		}
+	}
+}
+
+func TestEdgeCases(t *testing.T) {
+	if r := MustAnalyze("empty_gaps"); r.Gaps == nil || len(r.Gaps) != 0 {
+		t.Errorf("empty_gaps Gaps = %#v, want empty non-nil slice", r.Gaps)
+	}
+	if r := MustAnalyze("null_methods"); r.SuggestedHypotheses[0].RequiredMethods != nil {
+		t.Errorf("null_methods RequiredMethods = %#v, want nil", r.SuggestedHypotheses[0].RequiredMethods)
+	}
+	r := MustAnalyze("unicode")
+	if desc := r.Gaps[1].GapDescription; !utf8.ValidString(desc) || utf8.RuneCountInString(desc) == len(desc) {
+		t.Errorf("unicode gap %q has no multi-byte characters", desc)
+	}
+	if large := MustTopic("large"); len(large.IndividualResults) != large.PapersAnalyzed || large.PapersAnalyzed < 200 {
+		t.Errorf("large has %d results for %d papers", len(large.IndividualResults), large.PapersAnalyzed)
+	}
+}
+
+func TestFixturesAreCopies(t *testing.T) {
+	a := MustAnalyze("default")
+	a.Gaps[0].GapDescription = "changed"
+	if MustAnalyze("default").Gaps[0].GapDescription == "changed" {
+		t.Error("modifying a fixture changed later loads")
+	}
+}
+
+func TestUnknownFixture(t *testing.T) {
+	if _, err := Topic("nope"); err == nil {
+		t.Error("Topic(\"nope\") error = nil")
+	}
+}
@@ -0,0 +1,19 @@
+package gapfindertest
+
+import (
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/fixtures"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// DefaultAnalyzeResponse returns the response a new Server sends to
+// /analyze, the "default" fixture of package fixtures. Other fixtures can be
+// served with SetAnalyzeResponse(fixtures.MustAnalyze(name)).
+func DefaultAnalyzeResponse() types.AnalyzeResponse {
+	return fixtures.MustAnalyze("default")
+}
+
+// DefaultTopicResponse returns the response a new Server sends to /topic,
+// the "default" fixture of package fixtures
+func DefaultTopicResponse() types.TopicResponse {
+	return fixtures.MustTopic("default")
+}
@@ -0,0 +1,1006 @@
+// Package gapfindertest provides a fake AI Gap Finder service for tests of
+// code that calls the service, in the spirit of net/http/httptest.
+//
+//	srv := gapfindertest.NewServer()
+//	defer srv.Close()
+//	srv.InjectFault("/analyze", gapfindertest.Fault{Status: 503, Times: 1})
+//	c, _ := client.New(client.WithBaseURL(srv.URL))
+package gapfindertest
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/webhook"
+	"golang.org/x/net/websocket"
+)
+
+// Server is a fake AI Gap Finder service. It validates requests like the real
+// service, answers them with canned responses and records them for
+// inspection. Like the service it keeps the analyses /analyze and /topic
+// served for /analyses. Its behavior can be changed while it is running.
+type Server struct {
+	// URL is the base URL of the server, for client.WithBaseURL
+	URL string
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	analyze  types.AnalyzeResponse
+	topic    types.TopicResponse
+	latency  map[string]time.Duration
+	faults   map[string][]*Fault
+	jobs     map[string]types.Job
+	analyses []types.Analysis // oldest first
+	requests []Request
+	usage    map[string]int // requests by endpoint in the current window
+	since    time.Time      // start of the current window
+	quota    int
+	window   time.Duration
+}
+
+// Request is a request received by the server
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Fault is an error response the server sends instead of the canned one
+type Fault struct {
+	Status     int
+	Detail     string        // sent as {"detail": ...}; defaults to the status text
+	RetryAfter time.Duration // sent as the Retry-After header if set
+	Times      int           // number of requests to fail; zero means all of them
+}
+
+// NewServer starts a server that answers with DefaultAnalyzeResponse and
+// DefaultTopicResponse. The caller must call Close when finished.
+func NewServer() *Server {
+	s := newServer()
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// NewServerAt is like NewServer but listens on addr, such as
+// "localhost:8001", for developing against the fake service outside tests
+func NewServerAt(addr string) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := newServer()
+	s.srv = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	s.srv.Listener.Close()
+	s.srv.Listener = l
+	s.srv.Start()
+	s.URL = s.srv.URL
+	return s, nil
+}
+
+func newServer() *Server {
+	return &Server{
+		analyze: DefaultAnalyzeResponse(),
+		topic:   DefaultTopicResponse(),
+		latency: make(map[string]time.Duration),
+		faults:  make(map[string][]*Fault),
+		jobs:    make(map[string]types.Job),
+		usage:   make(map[string]int),
+		since:   time.Now().UTC(),
+	}
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// SetAnalyzeResponse sets the response to /analyze and to each abstract of
+// /analyze/batch
+func (s *Server) SetAnalyzeResponse(resp types.AnalyzeResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.analyze = resp
+}
+
+// SetTopicResponse sets the response to /topic and the result of jobs
+// submitted to /topic/jobs, which succeed at once and are POSTed to their
+// Callback-URL, if any, signed with WebhookSecret. Its Topic is replaced by
+// the topic of each request, and requests with CrossFields get its common
+// gaps as intersection gaps unless it has some of its own.
+func (s *Server) SetTopicResponse(resp types.TopicResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.topic = resp
+}
+
+// SetLatency delays every response to path by d, e.g. to exercise client
+// timeouts. An empty path applies to all endpoints that have no latency of
+// their own.
+func (s *Server) SetLatency(path string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency[path] = d
+}
+
+// InjectFault makes requests to path fail with f. Faults queue up: once one
+// has failed its number of requests the next one applies.
+func (s *Server) InjectFault(path string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[path] = append(s.faults[path], &f)
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.faults)
+}
+
+// SetQuota allows limit requests per window, starting a window now, and
+// refuses the rest with 429 and a Retry-After header until the next one.
+// /usage reports the quota, and requests to it and to /health are never
+// counted. Requests are counted for /usage even without a quota.
+func (s *Server) SetQuota(limit int, window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quota, s.window = limit, window
+	s.since = time.Now().UTC()
+	clear(s.usage)
+}
+
+// Requests returns the requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if id := r.Header.Get("X-Request-ID"); id != "" {
+		w.Header().Set("X-Request-ID", id)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body})
+	delay, ok := s.latency[r.URL.Path]
+	if !ok {
+		delay = s.latency[""]
+	}
+	fault := s.nextFault(r.URL.Path)
+	retryAfter, overQuota := s.count(r)
+	analyze, topic := s.analyze, s.topic
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if fault != nil {
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Round(time.Second).Seconds())))
+		}
+		detail := fault.Detail
+		if detail == "" {
+			detail = http.StatusText(fault.Status)
+		}
+		writeJSON(w, fault.Status, map[string]string{"detail": detail})
+		return
+	}
+	if overQuota {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"detail": "Quota exceeded, try again later"})
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/analyze":
+		var req types.AnalyzeRequest
+		if decode(w, body, &req) {
+			if req.MinConfidence > 0 {
+				analyze.FilterGaps(req.MinConfidence)
+			}
+			analyze.Truncate(req.MaxGaps, req.MaxHypotheses, req.MaxFindings)
+			s.keep(types.Analysis{Kind: types.AnalysisAbstract, Title: req.Title, Field: req.Field, Result: &analyze})
+			writeJSON(w, http.StatusOK, analyze)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/analyze/estimate":
+		var req types.AnalyzeRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, estimateAbstract(req))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/analyze/batch":
+		var req types.BatchAnalyzeRequest
+		if decode(w, body, &req) {
+			resp := types.BatchAnalyzeResponse{Results: make([]types.BatchItemResult, len(req.Requests))}
+			for i := range req.Requests {
+				resp.Results[i] = types.BatchItemResult{Index: i, Result: &analyze}
+			}
+			writeJSON(w, http.StatusOK, resp)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/analyze/doi":
+		var req types.DOIRequest
+		if decode(w, body, &req) {
+			// Unless the canned response names a paper, echo the DOI
+			if analyze.Paper == nil {
+				doi := types.NormalizeDOI(req.DOI)
+				analyze.Paper = &types.PaperInfo{Source: "crossref", ID: doi, Title: "Paper " + doi, Authors: []string{}, Abstract: "Abstract of " + doi}
+			}
+			writeJSON(w, http.StatusOK, analyze)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/analyze/pmid":
+		var req types.PMIDRequest
+		if decode(w, body, &req) {
+			if analyze.Paper == nil {
+				pmid := types.NormalizePMID(req.PMID)
+				analyze.Paper = &types.PaperInfo{Source: "pubmed", ID: pmid, Title: "Paper " + pmid, Authors: []string{}, Abstract: "Abstract of " + pmid}
+			}
+			writeJSON(w, http.StatusOK, analyze)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/analyze/arxiv":
+		var req types.ArxivRequest
+		if decode(w, body, &req) {
+			if analyze.Paper == nil {
+				id := types.NormalizeArxivID(req.ID)
+				analyze.Paper = &types.PaperInfo{Source: "arxiv", ID: id, Title: "Paper " + id, Authors: []string{}, Abstract: "Abstract of " + id}
+			}
+			writeJSON(w, http.StatusOK, analyze)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/analyze/pdf":
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if _, _, err := r.FormFile("file"); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+				"detail": []map[string]any{{"loc": []string{"body", "file"}, "msg": "Field required", "type": "missing"}},
+			})
+			return
+		}
+		if err := (types.PDFMetadata{Field: types.Field(r.FormValue("field")), Mode: r.FormValue("mode")}).Validate(); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+				"detail": []map[string]any{{"loc": []string{"body", err.(*types.ValidationError).Field}, "msg": err.Error(), "type": "value_error"}},
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, analyze)
+	case r.Method == http.MethodPost && r.URL.Path == "/analyze/peer-review":
+		var req types.AnalyzeRequest
+		if decode(w, body, &req) {
+			if req.MinConfidence > 0 {
+				analyze.FilterGaps(req.MinConfidence)
+			}
+			analyze.Truncate(req.MaxGaps, req.MaxHypotheses, req.MaxFindings)
+			writeJSON(w, http.StatusOK, peerReview(analyze))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/analyze/refine":
+		var req types.RefineRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, refine(req))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/compare":
+		var req types.CompareRequest
+		if decode(w, body, &req) {
+			// Built from the canned analysis: its findings are shared and its
+			// gaps are the first paper's
+			writeJSON(w, http.StatusOK, types.ComparisonResponse{
+				OverlappingFindings:    analyze.KeyFindings,
+				ConflictingConclusions: []types.ConclusionConflict{},
+				GapsUniqueToA:          analyze.Gaps,
+				GapsUniqueToB:          []types.ResearchGap{},
+				ProcessingTime:         analyze.ProcessingTime,
+			})
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/gaps/deduplicate":
+		var req types.DeduplicateRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, types.DeduplicateResponse{Clusters: clusterGaps(req.Analyses)})
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/gaps/citations":
+		var req types.CitationsRequest
+		if decode(w, body, &req) {
+			// Cites the papers of the canned topic analysis
+			resp := types.CitationsResponse{Citations: []types.Citation{}}
+			for _, paper := range topic.IndividualResults[:min(len(topic.IndividualResults), cmp.Or(req.MaxCitations, 5))] {
+				resp.Citations = append(resp.Citations, types.Citation{
+					Title:   paper.PaperTitle,
+					Authors: paper.Authors,
+					URL:     paper.URL,
+					Reason:  "Related to " + req.Gap.GapDescription,
+				})
+			}
+			writeJSON(w, http.StatusOK, resp)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/gaps/related":
+		var req types.RelatedWorkRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, relatedWork(req, topic))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/gaps/datasets":
+		var req types.DatasetsRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, datasets(req))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/gaps/groups":
+		var req types.ResearchGroupsRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, researchGroups(req))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/gaps/funding":
+		var req types.FundingRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, fundingMatches(req))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/hypotheses":
+		var req types.HypothesesRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, types.HypothesesResponse{
+				Hypotheses:     analyze.SuggestedHypotheses,
+				ProcessingTime: analyze.ProcessingTime,
+			})
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/hypotheses/methods":
+		var req types.MethodsRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, methods(req.Hypothesis))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/ideas/novelty":
+		var req types.NoveltyRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, novelty(topic))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/ideas/proposal":
+		var req types.ProposalRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, proposal(req, topic))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/review":
+		var req types.ReviewRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, review(req))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/topic":
+		var req types.TopicRequest
+		if decode(w, body, &req) {
+			topic.Topic = req.Topic
+			if len(req.CrossFields) > 0 && topic.IntersectionGaps == nil {
+				topic.IntersectionGaps = topic.CommonGaps
+			}
+			// Filter a copy, leaving the canned results intact
+			topic.IndividualResults = slices.Clone(topic.IndividualResults)
+			if req.MinConfidence > 0 {
+				topic.FilterGaps(req.MinConfidence)
+			}
+			topic.TruncateGaps(req.MaxGaps)
+			kept := topic
+			s.keep(types.Analysis{Kind: types.AnalysisTopic, Title: req.Topic, Field: req.Field, TopicResult: &kept})
+			if req.PageSize > 0 {
+				topic.IndividualResults, topic.NextCursor = page(topic.IndividualResults, 0, req.PageSize)
+			}
+			writeJSON(w, http.StatusOK, topic)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/topic/estimate":
+		var req types.TopicRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, estimate(req, topic))
+		}
+	case r.Method == http.MethodGet && r.URL.Path == "/topic/results":
+		// Cursors hold the offset and page size into the canned results
+		var offset, size int
+		if _, err := fmt.Sscanf(r.URL.Query().Get("cursor"), "%d.%d", &offset, &size); err != nil || size <= 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Cursor not found or expired"})
+			return
+		}
+		var resp types.TopicResultsPage
+		resp.IndividualResults, resp.NextCursor = page(topic.IndividualResults, offset, size)
+		writeJSON(w, http.StatusOK, resp)
+	case r.Method == http.MethodPost && r.URL.Path == "/topic/trends":
+		var req types.TrendsRequest
+		if decode(w, body, &req) {
+			writeJSON(w, http.StatusOK, trends(req, topic))
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/topic/stream":
+		var req types.TopicRequest
+		if decode(w, body, &req) {
+			topic.Topic = req.Topic
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, result := range topic.IndividualResults {
+				writeEvent(w, "result", result)
+			}
+			topic.IndividualResults = nil
+			writeEvent(w, "summary", topic)
+		}
+	case r.Method == http.MethodGet && r.URL.Path == "/topic/ws":
+		websocket.Server{Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			var req types.TopicRequest
+			if err := websocket.JSON.Receive(ws, &req); err != nil {
+				return
+			}
+			if err := req.Validate(); err != nil {
+				websocket.JSON.Send(ws, types.ProgressMessage{Type: types.MessageError, Detail: err.Error()})
+				return
+			}
+			topic.Topic = req.Topic
+			results := topic.IndividualResults
+			for _, stage := range []string{types.ProgressFetched, types.ProgressAnalyzing, types.ProgressDone} {
+				for i := range results {
+					p := types.TopicProgress{PaperTitle: results[i].PaperTitle, Index: i, Total: len(results), Stage: stage}
+					if stage == types.ProgressDone {
+						p.Result = &results[i]
+					}
+					websocket.JSON.Send(ws, types.ProgressMessage{Type: types.MessageProgress, Progress: &p})
+				}
+			}
+			websocket.JSON.Send(ws, types.ProgressMessage{Type: types.MessageResult, Result: &topic})
+		}}.ServeHTTP(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/topic/jobs":
+		var req types.TopicRequest
+		if decode(w, body, &req) {
+			topic.Topic = req.Topic
+			s.mu.Lock()
+			now := time.Now().UTC()
+			job := types.Job{
+				JobID:     fmt.Sprintf("job-%d", len(s.jobs)+1),
+				Status:    types.JobSucceeded,
+				CreatedAt: now,
+				UpdatedAt: now,
+				Result:    &topic,
+			}
+			s.jobs[job.JobID] = job
+			s.mu.Unlock()
+			writeJSON(w, http.StatusAccepted, job)
+			if u := r.Header.Get("Callback-URL"); u != "" {
+				go callBack(u, job)
+			}
+		}
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/jobs/") && strings.HasSuffix(r.URL.Path, "/cancel"):
+		// Jobs succeed at once, so there's never one left to cancel
+		s.mu.Lock()
+		job, ok := s.jobs[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/cancel")]
+		s.mu.Unlock()
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Job not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/jobs/"):
+		s.mu.Lock()
+		job, ok := s.jobs[strings.TrimPrefix(r.URL.Path, "/jobs/")]
+		s.mu.Unlock()
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Job not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	case r.Method == http.MethodGet && r.URL.Path == "/analyses":
+		q, err := analysesQuery(r)
+		if err == nil {
+			err = q.Validate()
+		}
+		if err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+				"detail": []map[string]any{{"loc": []string{"query", err.(*types.ValidationError).Field}, "msg": err.Error(), "type": "value_error"}},
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.listAnalyses(q))
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/analyses/"):
+		s.mu.Lock()
+		i := slices.IndexFunc(s.analyses, func(a types.Analysis) bool { return a.AnalysisID == strings.TrimPrefix(r.URL.Path, "/analyses/") })
+		var a types.Analysis
+		if i >= 0 {
+			a = s.analyses[i]
+		}
+		s.mu.Unlock()
+		if i < 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Analysis not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, a)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/analyses/"):
+		s.mu.Lock()
+		i := slices.IndexFunc(s.analyses, func(a types.Analysis) bool { return a.AnalysisID == strings.TrimPrefix(r.URL.Path, "/analyses/") })
+		if i >= 0 {
+			s.analyses = slices.Delete(s.analyses, i, i+1)
+		}
+		s.mu.Unlock()
+		if i < 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Analysis not found"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Path == "/fields":
+		writeJSON(w, http.StatusOK, types.FieldsResponse{Fields: types.Fields})
+	case r.Method == http.MethodGet && r.URL.Path == "/models":
+		// Requests may name any model; these are just listed
+		writeJSON(w, http.StatusOK, types.ModelsResponse{Models: []string{"gpt-4", "gpt-4o-mini"}, DefaultModel: "gpt-4"})
+	case r.Method == http.MethodGet && r.URL.Path == "/usage":
+		writeJSON(w, http.StatusOK, s.usageReport())
+	case r.Method == http.MethodGet && r.URL.Path == "/health":
+		writeJSON(w, http.StatusOK, types.HealthResponse{
+			Status:    "healthy",
+			Version:   "1.0.0",
+			Timestamp: strconv.FormatInt(time.Now().Unix(), 10),
+		})
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Not Found"})
+	}
+}
+
+// nextFault returns the fault to apply to a request for path, if any. The
+// caller must hold s.mu.
+func (s *Server) nextFault(path string) *Fault {
+	queue := s.faults[path]
+	if len(queue) == 0 {
+		return nil
+	}
+	f := queue[0]
+	if f.Times > 0 {
+		f.Times--
+		if f.Times == 0 {
+			s.faults[path] = queue[1:]
+		}
+	}
+	return f
+}
+
+// count counts r for /usage, returning true and how long until the quota
+// resets if the quota is used up, in which case r isn't counted. The caller
+// must hold s.mu.
+func (s *Server) count(r *http.Request) (time.Duration, bool) {
+	if r.Method == http.MethodGet && (r.URL.Path == "/usage" || r.URL.Path == "/health") {
+		return 0, false
+	}
+	s.rollWindow()
+	if s.quota > 0 && s.requestsInWindow() >= s.quota {
+		return time.Until(s.since.Add(s.window)), true
+	}
+	endpoint := r.Method + " " + r.URL.Path
+	if strings.HasPrefix(r.URL.Path, "/jobs/") {
+		endpoint = r.Method + " /jobs/{job_id}"
+	}
+	s.usage[endpoint]++
+	return 0, false
+}
+
+// rollWindow starts a new quota window, forgetting the counts, once the
+// current one is over. The caller must hold s.mu.
+func (s *Server) rollWindow() {
+	if s.quota <= 0 || s.window <= 0 {
+		return
+	}
+	if elapsed := time.Since(s.since); elapsed >= s.window {
+		s.since = s.since.Add(elapsed.Truncate(s.window))
+		clear(s.usage)
+	}
+}
+
+// requestsInWindow returns the number of requests counted in the current
+// window. The caller must hold s.mu.
+func (s *Server) requestsInWindow() int {
+	n := 0
+	for _, count := range s.usage {
+		n += count
+	}
+	return n
+}
+
+// usageReport answers /usage from the requests counted so far
+func (s *Server) usageReport() types.UsageResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollWindow()
+	resp := types.UsageResponse{Requests: s.requestsInWindow(), Since: s.since, Endpoints: []types.EndpointUsage{}}
+	for endpoint, n := range s.usage {
+		resp.Endpoints = append(resp.Endpoints, types.EndpointUsage{Endpoint: endpoint, Requests: n})
+	}
+	slices.SortFunc(resp.Endpoints, func(a, b types.EndpointUsage) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), strings.Compare(a.Endpoint, b.Endpoint))
+	})
+	if s.quota > 0 && s.window > 0 {
+		resp.Quota = &types.Quota{Limit: s.quota, Remaining: max(s.quota-resp.Requests, 0), ResetAt: s.since.Add(s.window)}
+	}
+	return resp
+}
+
+// review drafts a review of req with a theme section citing every paper and
+// a gaps section listing the common gaps
+func review(req types.ReviewRequest) types.ReviewResponse {
+	resp := types.ReviewResponse{Title: "Literature review: " + req.Topic}
+	theme := types.ReviewSection{Kind: types.SectionTheme, Heading: req.Topic, Paragraphs: []string{}, Citations: []int{}}
+	for i, paper := range req.Papers {
+		n := i + 1
+		theme.Paragraphs = append(theme.Paragraphs, fmt.Sprintf("%s [%d].", paper.PaperTitle, n))
+		theme.Citations = append(theme.Citations, n)
+		authors := paper.Authors
+		if authors == nil {
+			authors = []string{}
+		}
+		resp.References = append(resp.References, types.ReviewReference{Number: n, Title: paper.PaperTitle, Authors: authors, URL: paper.URL})
+	}
+	gaps := types.ReviewSection{Kind: types.SectionGaps, Heading: "Research gaps", Paragraphs: []string{}, Citations: []int{}}
+	for _, gap := range req.CommonGaps {
+		gaps.Paragraphs = append(gaps.Paragraphs, gap.GapDescription)
+	}
+	resp.Sections = []types.ReviewSection{theme, gaps}
+	return resp
+}
+
+// researchGroups gives every gap a group per paper with authors, without
+// merging co-authors
+func researchGroups(req types.ResearchGroupsRequest) types.ResearchGroupsResponse {
+	resp := types.ResearchGroupsResponse{CommonGaps: slices.Clone(req.CommonGaps)}
+	for i := range resp.CommonGaps {
+		groups := []types.AuthorGroup{}
+		for _, paper := range req.Papers {
+			if len(paper.Authors) > 0 {
+				groups = append(groups, types.AuthorGroup{Authors: paper.Authors, Papers: []string{paper.PaperTitle}})
+			}
+		}
+		resp.CommonGaps[i].ResearchGroups = groups
+	}
+	return resp
+}
+
+// estimate prices an analysis of as many of the canned topic's papers as
+// req asks for at GPT-4's list prices, assuming prompts of 300 tokens per
+// paper
+func estimate(req types.TopicRequest, topic types.TopicResponse) types.CostEstimate {
+	resp := types.CostEstimate{Model: cmp.Or(req.Model, "gpt-4"), Papers: min(cmp.Or(req.MaxPapers, 10), len(topic.IndividualResults))}
+	if resp.Papers > 0 {
+		resp.LLMCalls = 1
+		resp.InputTokens = 500 + 300*resp.Papers
+		resp.OutputTokens = 400 + 150*resp.Papers
+		cost := (float64(resp.InputTokens)*30 + float64(resp.OutputTokens)*60) / 1e6
+		resp.CostUSD = &cost
+		resp.DurationSeconds = float64(resp.OutputTokens) / 40
+	}
+	return resp
+}
+
+// estimateAbstract prices an analysis of req at GPT-4's list prices,
+// assuming a token per four characters of its text
+func estimateAbstract(req types.AnalyzeRequest) types.CostEstimate {
+	resp := types.CostEstimate{Model: cmp.Or(req.Model, "gpt-4"), Papers: 1, LLMCalls: 1}
+	resp.InputTokens = 500 + (len(req.Title)+len(req.Abstract)+len(req.FullText))/4
+	resp.OutputTokens = 550
+	cost := (float64(resp.InputTokens)*30 + float64(resp.OutputTokens)*60) / 1e6
+	resp.CostUSD = &cost
+	resp.DurationSeconds = float64(resp.OutputTokens) / 40
+	return resp
+}
+
+// refine returns the previous analysis with the feedback added to its future
+// directions, so callers can tell it was refined
+func refine(req types.RefineRequest) types.AnalyzeResponse {
+	resp := req.Previous
+	resp.FutureDirections = append(slices.Clone(resp.FutureDirections), req.Feedback)
+	return resp
+}
+
+// peerReview asks for a minor revision with a soundness critique per gap of
+// the canned analysis and a clarity critique per limitation
+func peerReview(analyze types.AnalyzeResponse) types.PeerReviewResponse {
+	resp := types.PeerReviewResponse{
+		Recommendation:   types.RecommendMinorRevision,
+		Summary:          "The paper makes a sound contribution with minor issues.",
+		Critiques:        []types.Critique{},
+		MissingBaselines: []string{},
+		Gaps:             analyze.Gaps,
+		ProcessingTime:   analyze.ProcessingTime,
+	}
+	for _, gap := range analyze.Gaps {
+		resp.Critiques = append(resp.Critiques, types.Critique{Aspect: types.AspectSoundness, Severity: types.SeverityMinor, Comment: gap.GapDescription})
+	}
+	for _, limitation := range analyze.Limitations {
+		resp.Critiques = append(resp.Critiques, types.Critique{Aspect: types.AspectClarity, Severity: types.SeverityMinor, Comment: limitation})
+	}
+	return resp
+}
+
+// methods recommends one design using all of the hypothesis' methods and no
+// datasets
+func methods(h types.Hypothesis) types.MethodsResponse {
+	return types.MethodsResponse{
+		Designs: []types.ExperimentalDesign{{
+			Name:        "Controlled study",
+			Description: "Tests " + h.Hypothesis,
+			Methods:     append([]string{}, h.RequiredMethods...),
+			SampleSize:  "100 participants per group",
+			Limitations: []string{},
+		}},
+		Datasets: []types.Dataset{},
+	}
+}
+
+// datasets suggests MaxDatasets numbered datasets under CC BY 4.0
+func datasets(req types.DatasetsRequest) types.DatasetsResponse {
+	resp := types.DatasetsResponse{Datasets: []types.Dataset{}}
+	for i := range cmp.Or(req.MaxDatasets, 5) {
+		n := strconv.Itoa(i + 1)
+		resp.Datasets = append(resp.Datasets, types.Dataset{
+			Name:        "Dataset " + n,
+			Description: "Public dataset " + n,
+			URL:         "https://data.example.org/" + n,
+			License:     "CC BY 4.0",
+		})
+	}
+	return resp
+}
+
+// proposal finds a proposal to address half of every known gap, the
+// request's or the canned topic's common gaps, without novelty conflicts
+func proposal(req types.ProposalRequest, topic types.TopicResponse) types.ProposalResponse {
+	resp := types.ProposalResponse{
+		AlignmentScore:   0.5,
+		Gaps:             []types.GapAlignment{},
+		NoveltyConflicts: []types.NoveltyConflict{},
+		Summary:          "The proposal addresses parts of the known gaps.",
+		PapersSearched:   len(topic.IndividualResults),
+	}
+	gaps := req.Gaps
+	if len(gaps) == 0 {
+		gaps = topic.CommonGaps
+	}
+	for _, gap := range gaps {
+		resp.Gaps = append(resp.Gaps, types.GapAlignment{
+			GapDescription: gap.GapDescription,
+			Coverage:       0.5,
+			Comment:        "Partly addressed",
+		})
+	}
+	return resp
+}
+
+// relatedWork returns the canned topic's papers, each less similar than the
+// one before and none addressing the gap
+func relatedWork(req types.RelatedWorkRequest, topic types.TopicResponse) types.RelatedWorkResponse {
+	resp := types.RelatedWorkResponse{Papers: []types.RelatedPaper{}}
+	for i, paper := range topic.IndividualResults[:min(len(topic.IndividualResults), cmp.Or(req.MaxPapers, 5))] {
+		resp.Papers = append(resp.Papers, types.RelatedPaper{
+			Title:      paper.PaperTitle,
+			Authors:    paper.Authors,
+			URL:        paper.URL,
+			Similarity: 0.9 / float64(i+1),
+			Reason:     "Related to " + req.Gap.GapDescription,
+		})
+	}
+	return resp
+}
+
+// novelty finds every idea partially addressed by the canned topic's papers
+func novelty(topic types.TopicResponse) types.NoveltyResponse {
+	resp := types.NoveltyResponse{
+		Status:           types.NoveltyPartiallyAddressed,
+		NoveltyScore:     0.5,
+		Summary:          "The papers on " + topic.Topic + " address parts of the idea.",
+		SupportingPapers: []types.Citation{},
+		PapersSearched:   len(topic.IndividualResults),
+	}
+	for _, paper := range topic.IndividualResults {
+		resp.SupportingPapers = append(resp.SupportingPapers, types.Citation{
+			Title:   paper.PaperTitle,
+			Authors: paper.Authors,
+			URL:     paper.URL,
+			Reason:  "Addresses part of the idea",
+		})
+	}
+	return resp
+}
+
+// fundingMatches matches every gap with a call of its own, up to MaxMatches
+func fundingMatches(req types.FundingRequest) types.FundingResponse {
+	resp := types.FundingResponse{Matches: []types.FundingMatch{}, CallsSearched: len(req.Gaps)}
+	for i, gap := range req.Gaps[:min(len(req.Gaps), cmp.Or(req.MaxMatches, 10))] {
+		resp.Matches = append(resp.Matches, types.FundingMatch{
+			Call: types.FundingCall{
+				Agency: "NSF",
+				Title:  "Research on " + gap.GapDescription,
+				URL:    fmt.Sprintf("https://funding.example.org/calls/%d", i+1),
+			},
+			Gaps:           []int{i},
+			RelevanceScore: gap.ConfidenceScore,
+			Reason:         "Funds work on " + gap.GapDescription,
+		})
+	}
+	return resp
+}
+
+// trends reports the canned topic's papers as published this year and its
+// common gaps as persistent over every covered year
+func trends(req types.TrendsRequest, topic types.TopicResponse) types.TrendsResponse {
+	to := time.Now().Year()
+	resp := types.TrendsResponse{
+		Topic:          req.Topic,
+		FromYear:       to - cmp.Or(req.YearsBack, 5) + 1,
+		ToYear:         to,
+		PapersAnalyzed: len(topic.IndividualResults),
+		Gaps:           []types.GapTrend{},
+	}
+	var years []int
+	for year := resp.FromYear; year <= to; year++ {
+		years = append(years, year)
+	}
+	counts := map[types.GapType]int{}
+	for _, gap := range topic.CommonGaps {
+		resp.Gaps = append(resp.Gaps, types.GapTrend{
+			GapDescription: gap.GapDescription,
+			GapType:        gap.GapType,
+			Years:          years,
+			Status:         types.TrendPersistent,
+		})
+		counts[gap.GapType]++
+	}
+	for _, year := range years {
+		ty := types.TrendYear{Year: year, GapTypes: []types.GapTypeCount{}}
+		if year == to {
+			ty.PaperCount = resp.PapersAnalyzed
+		}
+		for gapType, count := range counts {
+			ty.GapTypes = append(ty.GapTypes, types.GapTypeCount{GapType: gapType, Count: count})
+		}
+		slices.SortFunc(ty.GapTypes, func(a, b types.GapTypeCount) int {
+			return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.GapType, b.GapType))
+		})
+		resp.Years = append(resp.Years, ty)
+	}
+	return resp
+}
+
+// clusterGaps stands in for the service's semantic clustering by grouping
+// gaps with the same description, ignoring case
+func clusterGaps(analyses [][]types.ResearchGap) []types.GapCluster {
+	clusters := []types.GapCluster{}
+	index := map[string]int{}
+	for i, gaps := range analyses {
+		for j, gap := range gaps {
+			ref := types.GapRef{Analysis: i, Gap: j}
+			key := strings.ToLower(strings.TrimSpace(gap.GapDescription))
+			k, ok := index[key]
+			if !ok {
+				index[key] = len(clusters)
+				clusters = append(clusters, types.GapCluster{Gap: gap, Members: []types.GapRef{ref}})
+				continue
+			}
+			c := &clusters[k]
+			c.Members = append(c.Members, ref)
+			c.Gap.ConfidenceScore = max(c.Gap.ConfidenceScore, gap.ConfidenceScore)
+		}
+	}
+	return clusters
+}
+
+// decode decodes and validates a request body, answering with a 422 like
+// FastAPI when it is invalid
+// keep records an analysis served, like the service keeps them for
+// /analyses
+func (s *Server) keep(a types.Analysis) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.AnalysisID = fmt.Sprintf("analysis-%d", len(s.analyses)+1)
+	a.Field = cmp.Or(a.Field, types.FieldGeneral)
+	a.CreatedAt = time.Now().UTC()
+	s.analyses = append(s.analyses, a)
+}
+
+// listAnalyses returns the summaries of the analyses kept that match q,
+// newest first
+func (s *Server) listAnalyses(q types.AnalysesQuery) types.AnalysesResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := types.AnalysesResponse{Analyses: []types.AnalysisSummary{}}
+	for _, a := range slices.Backward(s.analyses) {
+		if len(resp.Analyses) == cmp.Or(q.Limit, 50) {
+			break
+		}
+		if strings.Contains(strings.ToLower(a.Title), strings.ToLower(q.Topic)) &&
+			(q.Field == "" || a.Field == q.Field) &&
+			(q.Since.IsZero() || !a.CreatedAt.Before(q.Since)) &&
+			(q.Until.IsZero() || a.CreatedAt.Before(q.Until)) {
+			resp.Analyses = append(resp.Analyses, a.Summary())
+		}
+	}
+	return resp
+}
+
+// analysesQuery parses the query parameters of /analyses
+func analysesQuery(r *http.Request) (types.AnalysesQuery, error) {
+	v := r.URL.Query()
+	q := types.AnalysesQuery{Topic: v.Get("topic"), Field: types.Field(v.Get("field"))}
+	for name, t := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if s := v.Get(name); s != "" {
+			parsed, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return q, &types.ValidationError{Field: name, Message: "must be an RFC 3339 date-time"}
+			}
+			*t = parsed
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit == 0 {
+			return q, &types.ValidationError{Field: "limit", Message: "must be a positive integer"}
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
+
+// WebhookSecret is the secret the server signs the callbacks of jobs with,
+// for webhook.Handler or webhook.Decode
+var WebhookSecret = []byte("gapfindertest")
+
+// callBack POSTs a finished job to u once, like the service would
+func callBack(u string, job types.Job) {
+	body, _ := json.Marshal(job)
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(WebhookSecret, time.Now(), body))
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+func decode(w http.ResponseWriter, body []byte, req interface{ Validate() error }) bool {
+	err := json.NewDecoder(bytes.NewReader(body)).Decode(req)
+	if err == nil {
+		err = req.Validate()
+	}
+	if err == nil {
+		return true
+	}
+
+	loc := []string{"body"}
+	var verr *types.ValidationError
+	if errors.As(err, &verr) {
+		loc = append(loc, strings.Split(verr.Field, ".")...)
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+		"detail": []map[string]any{{"loc": loc, "msg": fmt.Sprint(err), "type": "value_error"}},
+	})
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// page returns size results from offset and the cursor of the next page
+func page(results []types.TopicAnalysisResult, offset, size int) ([]types.TopicAnalysisResult, string) {
+	offset = min(offset, len(results))
+	end := min(offset+size, len(results))
+	if end == len(results) {
+		return results[offset:end], ""
+	}
+	return results[offset:end], fmt.Sprintf("%d.%d", end, size)
+}
+
+// writeEvent writes v as a server-sent event
+func writeEvent(w http.ResponseWriter, event string, v any) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
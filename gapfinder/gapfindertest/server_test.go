@@ -0,0 +1,712 @@
+package gapfindertest_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/fixtures"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/gapfindertest"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/webhook"
+)
+
+func newClient(t *testing.T, srv *gapfindertest.Server, opts ...client.Option) *client.Client {
+	t.Helper()
+	c, err := client.New(append([]client.Option{client.WithBaseURL(srv.URL), client.WithStrictDecoding()}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCannedResponses(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+	ctx := context.Background()
+
+	analysis, err := c.AnalyzeAbstract(ctx, types.AnalyzeRequest{Title: "T", Abstract: "A"})
+	if err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	if len(analysis.Gaps) != len(gapfindertest.DefaultAnalyzeResponse().Gaps) {
+		t.Errorf("got %d gaps, want the default response", len(analysis.Gaps))
+	}
+
+	topic, err := c.AnalyzeTopic(ctx, types.TopicRequest{Topic: "quantum"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if topic.Topic != "quantum" {
+		t.Errorf("Topic = %q, want the requested topic", topic.Topic)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 || reqs[0].Path != "/analyze" || reqs[1].Header.Get("Idempotency-Key") == "" {
+		t.Errorf("recorded requests = %+v", reqs)
+	}
+}
+
+func TestNewServerAt(t *testing.T) {
+	srv, err := gapfindertest.NewServerAt("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	if !strings.HasPrefix(srv.URL, "http://127.0.0.1:") {
+		t.Errorf("URL = %q", srv.URL)
+	}
+	if _, err := newClient(t, srv).HealthCheck(context.Background()); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+
+	// The address is taken
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	if _, err := gapfindertest.NewServerAt(addr); err == nil {
+		t.Errorf("NewServerAt(%q) succeeded on a taken address", addr)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	item := types.AnalyzeRequest{Title: "T", Abstract: "A"}
+	batch, err := c.AnalyzeBatch(context.Background(), []types.AnalyzeRequest{item, item, item})
+	if err != nil {
+		t.Fatalf("AnalyzeBatch() error = %v", err)
+	}
+	if len(batch.Results) != 3 || batch.Results[2].Index != 2 || batch.Results[2].Result == nil {
+		t.Errorf("Results = %+v, want three canned results", batch.Results)
+	}
+}
+
+func TestTopicJob(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+	ctx := context.Background()
+
+	job, err := c.AnalyzeTopicAsync(ctx, types.TopicRequest{Topic: "quantum"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicAsync() error = %v", err)
+	}
+	job, err = c.WaitForJob(ctx, job.JobID, client.WaitOptions{})
+	if err != nil {
+		t.Fatalf("WaitForJob() error = %v", err)
+	}
+	if job.Result == nil || job.Result.Topic != "quantum" {
+		t.Errorf("Result = %+v, want the canned topic response", job.Result)
+	}
+
+	// Jobs have already finished when they are cancelled
+	if cancelled, err := c.CancelJob(ctx, job.JobID); err != nil || cancelled.Status != types.JobSucceeded {
+		t.Errorf("CancelJob() = %+v, %v, want the finished job", cancelled, err)
+	}
+	if _, err := c.CancelJob(ctx, "unknown"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("CancelJob() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTopicJobCallback(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+	jobs := make(chan *types.Job, 1)
+	receiver := httptest.NewServer(webhook.Handler(gapfindertest.WebhookSecret, func(ctx context.Context, job *types.Job) error {
+		jobs <- job
+		return nil
+	}))
+	defer receiver.Close()
+
+	job, err := c.AnalyzeTopicAsync(context.Background(), types.TopicRequest{Topic: "quantum"}, client.WithCallbackURL(receiver.URL))
+	if err != nil {
+		t.Fatalf("AnalyzeTopicAsync() error = %v", err)
+	}
+	select {
+	case got := <-jobs:
+		if got.JobID != job.JobID || got.Result == nil || got.Result.Topic != "quantum" {
+			t.Errorf("callback job = %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no callback")
+	}
+}
+
+func TestAnalyses(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+	ctx := context.Background()
+
+	if _, err := c.AnalyzeAbstract(ctx, types.AnalyzeRequest{Title: "T", Abstract: "A"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AnalyzeTopic(ctx, types.TopicRequest{Topic: "quantum", PageSize: 1}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := c.ListAnalyses(ctx, types.AnalysesQuery{Topic: "QUANT"})
+	if err != nil {
+		t.Fatalf("ListAnalyses() error = %v", err)
+	}
+	if len(list.Analyses) != 1 || list.Analyses[0].Kind != types.AnalysisTopic {
+		t.Fatalf("Analyses = %+v, want the topic analysis", list.Analyses)
+	}
+	a, err := c.GetAnalysis(ctx, list.Analyses[0].AnalysisID)
+	if err != nil {
+		t.Fatalf("GetAnalysis() error = %v", err)
+	}
+	if a.TopicResult == nil || len(a.TopicResult.IndividualResults) != len(gapfindertest.DefaultTopicResponse().IndividualResults) {
+		t.Errorf("TopicResult = %+v, want every individual result", a.TopicResult)
+	}
+	if _, err := c.GetAnalysis(ctx, "unknown"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetAnalysis() error = %v, want ErrNotFound", err)
+	}
+
+	if err := c.DeleteAnalysis(ctx, a.AnalysisID); err != nil {
+		t.Fatalf("DeleteAnalysis() error = %v", err)
+	}
+	if _, err := c.GetAnalysis(ctx, a.AnalysisID); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetAnalysis() after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAnalyzePDF(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	if _, err := c.AnalyzePDF(context.Background(), strings.NewReader("%PDF-1.4"), types.PDFMetadata{Title: "T"}); err != nil {
+		t.Fatalf("AnalyzePDF() error = %v", err)
+	}
+	if reqs := srv.Requests(); len(reqs) != 1 || !strings.HasPrefix(reqs[0].Header.Get("Content-Type"), "multipart/form-data") {
+		t.Errorf("recorded requests = %+v", reqs)
+	}
+}
+
+func TestAnalyzeDOI(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.AnalyzeDOI(context.Background(), "doi:10.1038/nature12373")
+	if err != nil {
+		t.Fatalf("AnalyzeDOI() error = %v", err)
+	}
+	if result.Paper == nil || result.Paper.ID != "10.1038/nature12373" {
+		t.Errorf("Paper = %+v, want the DOI echoed", result.Paper)
+	}
+}
+
+func TestAnalyzePMID(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.AnalyzePMID(context.Background(), "31452104")
+	if err != nil {
+		t.Fatalf("AnalyzePMID() error = %v", err)
+	}
+	if result.Paper == nil || result.Paper.Source != "pubmed" || result.Paper.ID != "31452104" {
+		t.Errorf("Paper = %+v, want the PMID echoed", result.Paper)
+	}
+}
+
+func TestAnalyzeArxiv(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.AnalyzeArxiv(context.Background(), "https://arxiv.org/abs/2101.00001")
+	if err != nil {
+		t.Fatalf("AnalyzeArxiv() error = %v", err)
+	}
+	if result.Paper == nil || result.Paper.Source != "arxiv" || result.Paper.ID != "2101.00001" {
+		t.Errorf("Paper = %+v, want the ID echoed", result.Paper)
+	}
+}
+
+func TestMinConfidence(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.AnalyzeAbstract(context.Background(), types.AnalyzeRequest{Title: "T", Abstract: "A", MinConfidence: 0.8})
+	if err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	all := gapfindertest.DefaultAnalyzeResponse().Gaps
+	if want := types.FilterGaps(all, 0.8); len(want) == len(all) || len(result.Gaps) != len(want) {
+		t.Errorf("got %d gaps, want the %d of %d from 0.8", len(result.Gaps), len(want), len(all))
+	}
+}
+
+func TestCrossFields(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{
+		Topic:       "oscillations",
+		CrossFields: []types.Field{types.FieldNeuroscience, types.FieldPhysics},
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	want := gapfindertest.DefaultTopicResponse().CommonGaps
+	if len(result.IntersectionGaps) != len(want) || result.IntersectionGaps[0].GapDescription != want[0].GapDescription {
+		t.Errorf("IntersectionGaps = %+v, want the canned common gaps", result.IntersectionGaps)
+	}
+}
+
+func TestListFields(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.ListFields(context.Background())
+	if err != nil {
+		t.Fatalf("ListFields() error = %v", err)
+	}
+	if !slices.Equal(result.Fields, types.Fields) {
+		t.Errorf("Fields = %v, want %v", result.Fields, types.Fields)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if !slices.Contains(result.Models, result.DefaultModel) {
+		t.Errorf("result = %+v, want the default among the models", result)
+	}
+}
+
+func TestComparePapers(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	paper := types.AnalyzeRequest{Title: "T", Abstract: "A"}
+	result, err := c.ComparePapers(context.Background(), types.CompareRequest{PaperA: paper, PaperB: paper})
+	if err != nil {
+		t.Fatalf("ComparePapers() error = %v", err)
+	}
+	if want := gapfindertest.DefaultAnalyzeResponse().Gaps; len(result.GapsUniqueToA) != len(want) {
+		t.Errorf("GapsUniqueToA = %+v, want the canned gaps", result.GapsUniqueToA)
+	}
+}
+
+func TestSimulateReview(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.SimulateReview(context.Background(), types.AnalyzeRequest{Title: "T", Abstract: "A"})
+	if err != nil {
+		t.Fatalf("SimulateReview() error = %v", err)
+	}
+	if want := gapfindertest.DefaultAnalyzeResponse().Gaps; result.Recommendation != types.RecommendMinorRevision || len(result.Gaps) != len(want) || len(result.Critiques) < len(want) {
+		t.Errorf("result = %+v, want a critique per canned gap", result)
+	}
+}
+
+func TestRefine(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	previous := gapfindertest.DefaultAnalyzeResponse()
+	result, err := c.Refine(context.Background(), &previous, "Expand on gap 1")
+	if err != nil {
+		t.Fatalf("Refine() error = %v", err)
+	}
+	if len(result.Gaps) != len(previous.Gaps) || result.FutureDirections[len(result.FutureDirections)-1] != "Expand on gap 1" {
+		t.Errorf("result = %+v, want the previous analysis with the feedback", result)
+	}
+}
+
+func TestDeduplicateGaps(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.DeduplicateGaps(context.Background(), types.DeduplicateRequest{Analyses: [][]types.ResearchGap{
+		{{GapDescription: "Small sample", ConfidenceScore: 0.6}, {GapDescription: "No replication", ConfidenceScore: 0.5}},
+		{{GapDescription: "small sample", ConfidenceScore: 0.9}},
+	}})
+	if err != nil {
+		t.Fatalf("DeduplicateGaps() error = %v", err)
+	}
+	if len(result.Clusters) != 2 || len(result.Clusters[0].Members) != 2 || result.Clusters[0].Gap.ConfidenceScore != 0.9 {
+		t.Errorf("Clusters = %+v, want the two small sample gaps together", result.Clusters)
+	}
+}
+
+func TestSuggestCitations(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.SuggestCitations(context.Background(), gapfindertest.DefaultAnalyzeResponse().Gaps[0])
+	if err != nil {
+		t.Fatalf("SuggestCitations() error = %v", err)
+	}
+	if len(result.Citations) == 0 || result.Citations[0].Title != gapfindertest.DefaultTopicResponse().IndividualResults[0].PaperTitle {
+		t.Errorf("Citations = %+v, want the canned topic's papers", result.Citations)
+	}
+}
+
+func TestGenerateHypotheses(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.GenerateHypotheses(context.Background(), gapfindertest.DefaultAnalyzeResponse().Gaps)
+	if err != nil {
+		t.Fatalf("GenerateHypotheses() error = %v", err)
+	}
+	if want := gapfindertest.DefaultAnalyzeResponse().SuggestedHypotheses; len(result.Hypotheses) != len(want) {
+		t.Errorf("Hypotheses = %+v, want the canned hypotheses", result.Hypotheses)
+	}
+}
+
+func TestRecommendMethods(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	h := gapfindertest.DefaultAnalyzeResponse().SuggestedHypotheses[0]
+	result, err := c.RecommendMethods(context.Background(), h)
+	if err != nil {
+		t.Fatalf("RecommendMethods() error = %v", err)
+	}
+	if len(result.Designs) != 1 || len(result.Designs[0].Methods) != len(h.RequiredMethods) {
+		t.Errorf("result = %+v, want a design using the hypothesis' methods", result)
+	}
+}
+
+func TestSuggestDatasets(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	gap := gapfindertest.DefaultAnalyzeResponse().Gaps[0]
+	result, err := c.SuggestDatasets(context.Background(), types.DatasetsRequest{Gap: &gap, MaxDatasets: 2})
+	if err != nil {
+		t.Fatalf("SuggestDatasets() error = %v", err)
+	}
+	if len(result.Datasets) != 2 || result.Datasets[0].URL == "" {
+		t.Errorf("Datasets = %+v, want 2 with URLs", result.Datasets)
+	}
+}
+
+func TestGenerateReview(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	topic, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "quantum"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	result, err := c.GenerateReview(context.Background(), topic)
+	if err != nil {
+		t.Fatalf("GenerateReview() error = %v", err)
+	}
+	if len(result.References) != len(topic.IndividualResults) {
+		t.Errorf("References = %+v, want one per paper", result.References)
+	}
+}
+
+func TestFindResearchGroups(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	topic, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "quantum"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	result, err := c.FindResearchGroups(context.Background(), topic)
+	if err != nil {
+		t.Fatalf("FindResearchGroups() error = %v", err)
+	}
+	if len(result.CommonGaps) != len(topic.CommonGaps) || len(result.CommonGaps[0].ResearchGroups) == 0 {
+		t.Errorf("CommonGaps = %+v, want research groups for each gap", result.CommonGaps)
+	}
+}
+
+func TestGetRelatedWork(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.GetRelatedWork(context.Background(), types.ResearchGap{GapDescription: "Small samples", ConfidenceScore: 0.8})
+	if err != nil {
+		t.Fatalf("GetRelatedWork() error = %v", err)
+	}
+	if len(result.Papers) == 0 || result.Papers[0].Reason != "Related to Small samples" {
+		t.Errorf("Papers = %+v, want the canned topic's papers", result.Papers)
+	}
+}
+
+func TestCheckNovelty(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.CheckNovelty(context.Background(), "Quantum error correction on photonic chips", types.FieldPhysics)
+	if err != nil {
+		t.Fatalf("CheckNovelty() error = %v", err)
+	}
+	if result.Status != types.NoveltyPartiallyAddressed || len(result.SupportingPapers) == 0 {
+		t.Errorf("result = %+v, want a partially addressed idea with papers", result)
+	}
+}
+
+func TestAnalyzeProposal(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.AnalyzeProposal(context.Background(), "Photonic error correction\nWe propose...", types.FieldPhysics)
+	if err != nil {
+		t.Fatalf("AnalyzeProposal() error = %v", err)
+	}
+	if result.AlignmentScore != 0.5 || len(result.Gaps) == 0 {
+		t.Errorf("result = %+v, want the canned topic's gaps half addressed", result)
+	}
+}
+
+func TestMatchFunding(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	gaps := []types.ResearchGap{{GapDescription: "Small samples", ConfidenceScore: 0.8}, {GapDescription: "No replication", ConfidenceScore: 0.6}}
+	result, err := c.MatchFunding(context.Background(), gaps)
+	if err != nil {
+		t.Fatalf("MatchFunding() error = %v", err)
+	}
+	if len(result.Matches) != 2 || result.Matches[1].Gaps[0] != 1 {
+		t.Errorf("Matches = %+v, want a call for each gap", result.Matches)
+	}
+}
+
+func TestAnalyzeTrends(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	result, err := c.AnalyzeTrends(context.Background(), "quantum", 3)
+	if err != nil {
+		t.Fatalf("AnalyzeTrends() error = %v", err)
+	}
+	if len(result.Years) != 3 || result.ToYear-result.FromYear != 2 || len(result.Gaps) == 0 || result.Gaps[0].Status != types.TrendPersistent {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestTopicStream(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	stream, err := c.AnalyzeTopicStream(context.Background(), types.TopicRequest{Topic: "quantum"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicStream() error = %v", err)
+	}
+	streamed := 0
+	for range stream.Results {
+		streamed++
+	}
+	resp, err := stream.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	want := len(gapfindertest.DefaultTopicResponse().IndividualResults)
+	if streamed != want || len(resp.IndividualResults) != want || resp.Topic != "quantum" {
+		t.Errorf("streamed %d results, response %+v; want %d canned results", streamed, resp, want)
+	}
+}
+
+func TestWatchTopic(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	done := 0
+	topic, err := c.WatchTopic(context.Background(), types.TopicRequest{Topic: "quantum"}, func(p types.TopicProgress) {
+		if p.Stage == types.ProgressDone {
+			done++
+		}
+	})
+	if err != nil {
+		t.Fatalf("WatchTopic() error = %v", err)
+	}
+	if want := len(gapfindertest.DefaultTopicResponse().IndividualResults); done != want || topic.Topic != "quantum" {
+		t.Errorf("%d papers done, response %+v; want %d canned results", done, topic, want)
+	}
+}
+
+func TestPagedTopic(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	srv.SetTopicResponse(fixtures.MustTopic("large"))
+	c := newClient(t, srv)
+	ctx := context.Background()
+
+	topic, err := c.AnalyzeTopic(ctx, types.TopicRequest{Topic: "quantum", PageSize: types.MaxPageSize})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if len(topic.IndividualResults) != types.MaxPageSize || topic.NextCursor == "" {
+		t.Fatalf("first page has %d results, cursor %q", len(topic.IndividualResults), topic.NextCursor)
+	}
+	n := 0
+	for _, err := range c.TopicResults(ctx, topic) {
+		if err != nil {
+			t.Fatalf("TopicResults() error = %v", err)
+		}
+		n++
+	}
+	if want := len(fixtures.MustTopic("large").IndividualResults); n != want {
+		t.Errorf("iterated over %d results, want %d", n, want)
+	}
+}
+
+func TestInjectFault(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	srv.InjectFault("/health", gapfindertest.Fault{Status: 503, Times: 2})
+	c := newClient(t, srv, client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v, want success on the third attempt", err)
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("server saw %d requests, want 3", n)
+	}
+}
+
+func TestInjectRateLimit(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	srv.InjectFault("/analyze", gapfindertest.Fault{Status: 429, RetryAfter: 30 * time.Second})
+	c := newClient(t, srv)
+
+	_, err := c.AnalyzeAbstract(context.Background(), types.AnalyzeRequest{Title: "T", Abstract: "A"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, client.ErrRateLimited) || apiErr.RetryAfter != 30*time.Second {
+		t.Errorf("AnalyzeAbstract() error = %v, want rate limit with Retry-After", err)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	est, err := c.EstimateCost(context.Background(), types.TopicRequest{Topic: "sleep", MaxPapers: 50})
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	papers := len(gapfindertest.DefaultTopicResponse().IndividualResults)
+	if est.Papers != papers || est.LLMCalls != 1 || est.CostUSD == nil || *est.CostUSD <= 0 || est.DurationSeconds <= 0 {
+		t.Errorf("estimate = %+v", est)
+	}
+}
+
+func TestEstimateAnalysis(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	est, err := c.EstimateAnalysis(context.Background(), types.AnalyzeRequest{Title: "Sleep", Abstract: strings.Repeat("word ", 400)})
+	if err != nil {
+		t.Fatalf("EstimateAnalysis() error = %v", err)
+	}
+	if est.Papers != 1 || est.LLMCalls != 1 || est.InputTokens <= 500 || est.CostUSD == nil || *est.CostUSD <= 0 {
+		t.Errorf("estimate = %+v", est)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	srv.SetQuota(2, time.Hour)
+	c := newClient(t, srv)
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := c.AnalyzeAbstract(ctx, types.AnalyzeRequest{Title: "T", Abstract: "A"}); err != nil {
+			t.Fatalf("AnalyzeAbstract() error = %v", err)
+		}
+	}
+	_, err := c.ListFields(ctx)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, client.ErrRateLimited) || apiErr.RetryAfter <= 0 {
+		t.Errorf("ListFields() error = %v, want rate limit with Retry-After", err)
+	}
+
+	usage, err := c.GetUsage(ctx)
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if usage.Requests != 2 || len(usage.Endpoints) != 1 || usage.Endpoints[0].Endpoint != "POST /analyze" ||
+		usage.Quota == nil || usage.Quota.Remaining != 0 || !usage.Quota.ResetAt.Equal(usage.Since.Add(time.Hour)) {
+		t.Errorf("usage = %+v, quota = %+v", usage, usage.Quota)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	srv.SetLatency("/health", time.Second)
+	c := newClient(t, srv)
+
+	_, err := c.HealthCheck(context.Background(), client.WithRequestTimeout(50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("HealthCheck() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestValidatesRequests(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	c := newClient(t, srv)
+
+	err := c.Do(context.Background(), "POST", "/topic", map[string]any{"topic": "x", "max_papers": 99}, nil)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 422 {
+		t.Errorf("Do() error = %v, want 422", err)
+	}
+}
+
+func TestServeFixture(t *testing.T) {
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	srv.SetTopicResponse(fixtures.MustTopic("large"))
+	c := newClient(t, srv)
+
+	topic, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "scRNA-seq"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if len(topic.IndividualResults) != topic.PapersAnalyzed {
+		t.Errorf("got %d results for %d papers", len(topic.IndividualResults), topic.PapersAnalyzed)
+	}
+}
@@ -0,0 +1,75 @@
+// Package llm provides the language model backends used by the Go
+// implementation of the AI Gap Finder service.
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Backend sends a prompt to a language model and returns its reply
+type Backend interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// BackendFunc adapts a function to Backend, e.g. for tests
+type BackendFunc func(ctx context.Context, prompt string) (string, error)
+
+func (f BackendFunc) Complete(ctx context.Context, prompt string) (string, error) {
+	return f(ctx, prompt)
+}
+
+// Params overrides a backend's model settings for one call
+type Params struct {
+	Model       string   // empty means the backend's model
+	Temperature *float64 // nil means the backend's temperature
+}
+
+// temperature returns the temperature to send: p's if set, else fallback
+// unless it is zero, which leaves the model's default
+func (p Params) temperature(fallback float64) *float64 {
+	if p.Temperature == nil && fallback != 0 {
+		return &fallback
+	}
+	return p.Temperature
+}
+
+type paramsKey struct{}
+
+// ContextWithParams returns a context that makes the backends in this
+// package complete prompts with p instead of their own settings
+func ContextWithParams(ctx context.Context, p Params) context.Context {
+	return context.WithValue(ctx, paramsKey{}, p)
+}
+
+// ParamsFromContext returns the Params set with ContextWithParams, if any
+func ParamsFromContext(ctx context.Context) Params {
+	p, _ := ctx.Value(paramsKey{}).(Params)
+	return p
+}
+
+// ErrNoJSON is returned by DecodeJSON when a reply contains no JSON object
+var ErrNoJSON = errors.New("model reply contains no JSON object")
+
+// DecodeJSON decodes the JSON object in a model reply into out. Models often
+// wrap their JSON in a Markdown code block or surround it with prose, so
+// anything before the first '{' and after the last '}' is ignored if the
+// reply as a whole isn't valid JSON.
+func DecodeJSON(reply string, out any) error {
+	reply = strings.TrimSpace(reply)
+	reply = strings.TrimPrefix(reply, "```json")
+	reply = strings.TrimSuffix(reply, "```")
+	reply = strings.TrimSpace(reply)
+	if err := json.Unmarshal([]byte(reply), out); err == nil {
+		return nil
+	}
+
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start == -1 || end < start {
+		return ErrNoJSON
+	}
+	return json.Unmarshal([]byte(reply[start:end+1]), out)
+}
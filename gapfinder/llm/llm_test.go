@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := map[string]string{
+		"plain":      `{"gaps":["a"]}`,
+		"code block": "```json\n{\"gaps\":[\"a\"]}\n```",
+		"with prose": "Here is the analysis:\n{\"gaps\":[\"a\"]}\nLet me know if you need more.",
+		"whitespace": "\n\n  {\"gaps\": [\"a\"]}  \n",
+	}
+	for name, reply := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out struct{ Gaps []string }
+			if err := DecodeJSON(reply, &out); err != nil {
+				t.Fatalf("DecodeJSON() error = %v", err)
+			}
+			if len(out.Gaps) != 1 || out.Gaps[0] != "a" {
+				t.Errorf("Gaps = %q", out.Gaps)
+			}
+		})
+	}
+
+	var out map[string]any
+	if err := DecodeJSON("I cannot help with that.", &out); !errors.Is(err, ErrNoJSON) {
+		t.Errorf("DecodeJSON() error = %v, want ErrNoJSON", err)
+	}
+}
+
+func TestOpenAI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("unexpected request %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req openAIRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "gpt-4" || req.Messages[0].Content != "hello" {
+			t.Errorf("request = %+v", req)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`))
+	}))
+	defer srv.Close()
+
+	backend := &OpenAI{APIKey: "sk-test", Model: "gpt-4", BaseURL: srv.URL}
+	reply, err := backend.Complete(context.Background(), "hello")
+	if err != nil || reply != "hi" {
+		t.Errorf("Complete() = %q, %v", reply, err)
+	}
+}
+
+func TestOpenAIParams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "gpt-4o-mini" || req.Temperature == nil || *req.Temperature != 0.2 || req.MaxTokens != 500 {
+			t.Errorf("request = %+v, want the context's model and temperature", req)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`))
+	}))
+	defer srv.Close()
+
+	backend := &OpenAI{APIKey: "sk-test", Model: "gpt-4", Temperature: 0.7, MaxTokens: 500, BaseURL: srv.URL}
+	ctx := ContextWithParams(context.Background(), Params{Model: "gpt-4o-mini", Temperature: new(0.2)})
+	if _, err := backend.Complete(ctx, "hello"); err != nil {
+		t.Errorf("Complete() error = %v", err)
+	}
+}
+
+func TestOpenAIZeroTemperature(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		if temperature, ok := req["temperature"]; !ok || temperature != 0.0 {
+			t.Errorf("temperature = %v, want an explicit 0", temperature)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`))
+	}))
+	defer srv.Close()
+
+	backend := &OpenAI{APIKey: "sk-test", Model: "gpt-4", Temperature: 0.7, BaseURL: srv.URL}
+	ctx := ContextWithParams(context.Background(), Params{Temperature: new(0.0)})
+	if _, err := backend.Complete(ctx, "hello"); err != nil {
+		t.Errorf("Complete() error = %v", err)
+	}
+}
+
+func TestOpenAIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"Rate limit reached"}}`))
+	}))
+	defer srv.Close()
+
+	backend := &OpenAI{APIKey: "sk-test", BaseURL: srv.URL}
+	if _, err := backend.Complete(context.Background(), "hello"); err == nil || err.Error() != "model API returned status 429: Rate limit reached" {
+		t.Errorf("Complete() error = %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultOllamaURL is the address a local Ollama server listens on
+const DefaultOllamaURL = "http://localhost:11434"
+
+// Ollama is a Backend for a self-hosted Ollama server, for deployments that
+// can't send abstracts to a third-party API
+type Ollama struct {
+	Model       string  // e.g. "llama3"
+	BaseURL     string  // defaults to DefaultOllamaURL
+	Temperature float64 // zero means the model's default
+
+	// HTTPClient is used to send requests; nil means http.DefaultClient
+	HTTPClient *http.Client
+}
+
+type ollamaRequest struct {
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	Stream  bool           `json:"stream"`
+	Format  string         `json:"format,omitempty"`
+	Options map[string]any `json:"options,omitempty"`
+}
+
+type ollamaResponse struct {
+	Response string `json:"response"`
+	Error    string `json:"error"`
+}
+
+// Complete sends prompt to the generate endpoint, asking for a JSON reply,
+// with the model and temperature of the context's Params if set
+func (o *Ollama) Complete(ctx context.Context, prompt string) (string, error) {
+	params := ParamsFromContext(ctx)
+	payload := ollamaRequest{Model: cmp.Or(params.Model, o.Model), Prompt: prompt, Format: "json"}
+	if t := params.temperature(o.Temperature); t != nil {
+		payload.Options = map[string]any{"temperature": *t}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	url := strings.TrimRight(cmp.Or(o.BaseURL, DefaultOllamaURL), "/") + "/api/generate"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var result ollamaResponse
+	if err := doJSON(o.HTTPClient, req, &result); err != nil {
+		if result.Error != "" {
+			return "", fmt.Errorf("%w: %s", err, result.Error)
+		}
+		return "", err
+	}
+	return result.Response, nil
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultOpenAIURL is the base URL of the OpenAI API
+const DefaultOpenAIURL = "https://api.openai.com/v1"
+
+// OpenAI is a Backend for the OpenAI chat completions API and compatible
+// APIs (Azure OpenAI, vLLM, LiteLLM, ...)
+type OpenAI struct {
+	APIKey      string
+	Model       string  // e.g. "gpt-4"
+	BaseURL     string  // defaults to DefaultOpenAIURL
+	Temperature float64 // zero means the model's default
+	MaxTokens   int     // zero means the model's default
+
+	// HTTPClient is used to send requests; nil means http.DefaultClient
+	HTTPClient *http.Client
+}
+
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Complete sends prompt as a single user message, with the model and
+// temperature of the context's Params if set
+func (o *OpenAI) Complete(ctx context.Context, prompt string) (string, error) {
+	if o.APIKey == "" {
+		return "", errors.New("OpenAI API key not set")
+	}
+	params := ParamsFromContext(ctx)
+	body, err := json.Marshal(openAIRequest{
+		Model:       cmp.Or(params.Model, o.Model),
+		Messages:    []openAIMessage{{Role: "user", Content: prompt}},
+		Temperature: params.temperature(o.Temperature),
+		MaxTokens:   o.MaxTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	url := strings.TrimRight(cmp.Or(o.BaseURL, DefaultOpenAIURL), "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	var result openAIResponse
+	if err := doJSON(o.HTTPClient, req, &result); err != nil {
+		if result.Error != nil && result.Error.Message != "" {
+			return "", fmt.Errorf("%w: %s", err, result.Error.Message)
+		}
+		return "", err
+	}
+	if len(result.Choices) == 0 {
+		return "", errors.New("OpenAI returned no choices")
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+// doJSON sends req and decodes the JSON response into out. On a non-2xx
+// status out is still decoded where possible, so callers can report the
+// error message in the body.
+func doJSON(hc *http.Client, req *http.Request, out any) error {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+	decodeErr := json.Unmarshal(data, out)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("model API returned status %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("error unmarshaling response: %w", decodeErr)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Package recorder provides an http.RoundTripper that records requests to
+// the AI Gap Finder service and their responses in a cassette file and
+// replays them later, so tests don't depend on a live service or use up LLM
+// quota.
+//
+// Record once against a real service, commit the cassette, and replay it in
+// CI:
+//
+//	rec, err := recorder.New("testdata/analyze.json", recorder.ModeAuto, nil)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Save()
+//	c, _ := client.New(client.WithBaseURL(baseURL), client.WithTransport(rec))
+package recorder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode selects whether a Recorder talks to the service
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette and fails requests that
+	// weren't recorded. The service is never contacted.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the service and records them, replacing
+	// the cassette on Save
+	ModeRecord
+	// ModeAuto replays if the cassette exists and records otherwise
+	ModeAuto
+)
+
+// ErrNotRecorded is returned in replay mode for requests that have no
+// recorded interaction left
+var ErrNotRecorded = errors.New("request not recorded in cassette")
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest holds the parts of a request that identify it in a
+// cassette. Multipart bodies, such as PDF uploads, are recorded with a fixed
+// boundary so they match when replayed, and bodies that aren't valid UTF-8
+// by their SHA-256 hash.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"` // path and query; the host is ignored
+	Body   string `json:"body,omitempty"`
+}
+
+// recordedBoundary replaces the random boundary of multipart bodies
+const recordedBoundary = "recorded-boundary"
+
+// requestBody returns the Body of the RecordedRequest for a request body
+func requestBody(contentType string, body []byte) string {
+	if mediaType, params, err := mime.ParseMediaType(contentType); err == nil && strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		body = bytes.ReplaceAll(body, []byte(params["boundary"]), []byte(recordedBoundary))
+	}
+	if !utf8.Valid(body) {
+		return fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	}
+	return string(body)
+}
+
+// RecordedResponse is a recorded response. Bodies that aren't valid UTF-8,
+// such as gzip-compressed ones, are stored base64-encoded.
+type RecordedResponse struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+type cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// omittedHeaders are response headers not worth keeping in a cassette
+var omittedHeaders = []string{"Date", "Set-Cookie", "Content-Length"}
+
+// Recorder is an http.RoundTripper that records or replays interactions
+type Recorder struct {
+	path string
+	mode Mode
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// New returns a Recorder for the cassette at path. In recording mode
+// requests are sent with next, or http.DefaultTransport if nil.
+func New(path string, mode Mode, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, next: next}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && mode != ModeReplay:
+		r.mode = ModeRecord
+		return r, nil
+	case err != nil:
+		return nil, fmt.Errorf("error reading cassette: %w", err)
+	case mode == ModeRecord:
+		return r, nil
+	}
+
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("error parsing cassette %s: %w", path, err)
+	}
+	r.mode = ModeReplay
+	r.interactions = c.Interactions
+	r.used = make([]bool, len(c.Interactions))
+	return r, nil
+}
+
+// Recording reports whether the Recorder sends requests to the service
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	key := RecordedRequest{Method: req.Method, URL: req.URL.RequestURI(), Body: requestBody(req.Header.Get("Content-Type"), body)}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, key)
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := r.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := RecordedResponse{Status: resp.StatusCode, Header: resp.Header.Clone()}
+	for _, h := range omittedHeaders {
+		recorded.Header.Del(h)
+	}
+	if utf8.Valid(respBody) {
+		recorded.Body = string(respBody)
+	} else {
+		recorded.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{Request: key, Response: recorded})
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// replay answers req with the first unused interaction recorded for it, so
+// repeated identical requests (e.g. retries) get their responses in order
+func (r *Recorder) replay(req *http.Request, key RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.used[i] || in.Request != key {
+			continue
+		}
+		r.used[i] = true
+
+		body := []byte(in.Response.Body)
+		if in.Response.BodyBase64 != "" {
+			var err error
+			body, err = base64.StdEncoding.DecodeString(in.Response.BodyBase64)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding recorded body: %w", err)
+			}
+		}
+		header := in.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+			StatusCode:    in.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, key.Method, key.URL)
+}
+
+// Save writes the recorded interactions to the cassette. It does nothing
+// when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(cassette{Interactions: r.interactions}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// Unused returns the recorded requests that were never replayed, which
+// usually means the code under test no longer makes them and the cassette
+// should be re-recorded
+func (r *Recorder) Unused() []RecordedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []RecordedRequest
+	for i, in := range r.interactions {
+		if r.mode == ModeReplay && !r.used[i] {
+			unused = append(unused, in.Request)
+		}
+	}
+	return unused
+}
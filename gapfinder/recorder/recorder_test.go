@@ -0,0 +1,125 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/gapfindertest"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	req := types.AnalyzeRequest{Title: "T", Abstract: "A", Field: types.FieldBiology}
+	ctx := context.Background()
+
+	srv := gapfindertest.NewServer()
+	rec, err := New(path, ModeAuto, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Recording() {
+		t.Fatal("Recording() = false without a cassette")
+	}
+	c, _ := client.New(client.WithBaseURL(srv.URL), client.WithTransport(rec))
+	want, err := c.AnalyzeAbstract(ctx, req)
+	if err != nil {
+		t.Fatalf("AnalyzeAbstract() while recording error = %v", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	srv.Close()
+
+	// The service is gone; the cassette has to answer
+	rec, err = New(path, ModeAuto, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Recording() {
+		t.Fatal("Recording() = true with a cassette")
+	}
+	c, _ = client.New(client.WithBaseURL("http://recorded.invalid"), client.WithTransport(rec))
+	got, err := c.AnalyzeAbstract(ctx, req)
+	if err != nil {
+		t.Fatalf("AnalyzeAbstract() while replaying error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Gaps, want.Gaps) {
+		t.Errorf("replayed gaps = %+v, want %+v", got.Gaps, want.Gaps)
+	}
+	if len(rec.Unused()) != 0 {
+		t.Errorf("Unused() = %v, want none", rec.Unused())
+	}
+
+	// A different request was never recorded
+	req.Abstract = "B"
+	if _, err := c.AnalyzeAbstract(ctx, req); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("AnalyzeAbstract() of unrecorded request error = %v, want ErrNotRecorded", err)
+	}
+}
+
+func TestReplayPDFUpload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	pdf := []byte("%PDF-1.4\n\xff\xfe binary")
+	meta := types.PDFMetadata{Title: "T", Filename: "paper.pdf"}
+	ctx := context.Background()
+
+	srv := gapfindertest.NewServer()
+	rec, _ := New(path, ModeRecord, nil)
+	c, _ := client.New(client.WithBaseURL(srv.URL), client.WithTransport(rec))
+	if _, err := c.AnalyzePDF(ctx, bytes.NewReader(pdf), meta); err != nil {
+		t.Fatalf("AnalyzePDF() while recording error = %v", err)
+	}
+	rec.Save()
+	srv.Close()
+
+	// The new upload has a different multipart boundary
+	rec, err := New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ = client.New(client.WithBaseURL(srv.URL), client.WithTransport(rec))
+	if _, err := c.AnalyzePDF(ctx, bytes.NewReader(pdf), meta); err != nil {
+		t.Errorf("AnalyzePDF() while replaying error = %v", err)
+	}
+	meta.Title = "Other"
+	if _, err := c.AnalyzePDF(ctx, bytes.NewReader(pdf), meta); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("AnalyzePDF() with other metadata error = %v, want ErrNotRecorded", err)
+	}
+}
+
+func TestReplayInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	srv := gapfindertest.NewServer()
+	defer srv.Close()
+	srv.InjectFault("/health", gapfindertest.Fault{Status: 503, Times: 1})
+
+	rec, _ := New(path, ModeRecord, nil)
+	c, _ := client.New(client.WithBaseURL(srv.URL), client.WithTransport(rec))
+	c.HealthCheck(context.Background())
+	c.HealthCheck(context.Background())
+	rec.Save()
+
+	rec, err := New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ = client.New(client.WithBaseURL(srv.URL), client.WithTransport(rec))
+	if _, err := c.HealthCheck(context.Background()); err == nil {
+		t.Errorf("first replayed HealthCheck() error = nil, want the recorded 503")
+	}
+	if _, err := c.HealthCheck(context.Background()); err != nil {
+		t.Errorf("second replayed HealthCheck() error = %v", err)
+	}
+}
+
+func TestReplayWithoutCassette(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), ModeReplay, nil); err == nil {
+		t.Error("New() in replay mode without a cassette error = nil")
+	}
+}
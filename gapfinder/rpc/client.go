@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/rpc/gapfinderpb"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Client calls the GapFinder gRPC service
+type Client struct {
+	pb gapfinderpb.GapFinderClient
+}
+
+// NewClient creates a client using conn, usually a *grpc.ClientConn
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{pb: gapfinderpb.NewGapFinderClient(conn)}
+}
+
+// AnalyzeAbstract identifies research gaps in a single abstract. The
+// request is validated before it is sent.
+func (c *Client) AnalyzeAbstract(ctx context.Context, req types.AnalyzeRequest, opts ...grpc.CallOption) (*types.AnalyzeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var header metadata.MD
+	resp, err := c.pb.AnalyzeAbstract(ctx, analyzeRequestToPB(req), append(opts, grpc.Header(&header))...)
+	if err != nil {
+		return nil, err
+	}
+	result := analyzeResponseFromPB(resp)
+	result.RequestID = requestID(header)
+	return result, nil
+}
+
+// AnalyzeTopic analyzes the papers found for a research topic. The request
+// is validated before it is sent.
+func (c *Client) AnalyzeTopic(ctx context.Context, req types.TopicRequest, opts ...grpc.CallOption) (*types.TopicResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var header metadata.MD
+	resp, err := c.pb.AnalyzeTopic(ctx, topicRequestToPB(req), append(opts, grpc.Header(&header))...)
+	if err != nil {
+		return nil, err
+	}
+	result := topicResponseFromPB(resp)
+	result.RequestID = requestID(header)
+	return result, nil
+}
+
+// AnalyzeTopicStream analyzes a topic like AnalyzeTopic, calling fn with
+// each paper's result as it arrives. If fn returns an error the call is
+// abandoned and the error returned. The returned response includes all
+// results.
+func (c *Client) AnalyzeTopicStream(ctx context.Context, req types.TopicRequest, fn func(types.TopicAnalysisResult) error, opts ...grpc.CallOption) (*types.TopicResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.pb.AnalyzeTopicStream(ctx, topicRequestToPB(req), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []types.TopicAnalysisResult{}
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("stream ended without a summary")
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch e := event.GetEvent().(type) {
+		case *gapfinderpb.TopicEvent_Result:
+			r := topicResultFromPB(e.Result)
+			results = append(results, r)
+			if err := fn(r); err != nil {
+				return nil, err
+			}
+		case *gapfinderpb.TopicEvent_Summary:
+			result := topicResponseFromPB(e.Summary)
+			result.IndividualResults = results
+			header, _ := stream.Header()
+			result.RequestID = requestID(header)
+			return result, nil
+		}
+	}
+}
+
+// HealthCheck reports whether the service is up
+func (c *Client) HealthCheck(ctx context.Context, opts ...grpc.CallOption) (*types.HealthResponse, error) {
+	var header metadata.MD
+	resp, err := c.pb.HealthCheck(ctx, &gapfinderpb.HealthRequest{}, append(opts, grpc.Header(&header))...)
+	if err != nil {
+		return nil, err
+	}
+	result := healthResponseFromPB(resp)
+	result.RequestID = requestID(header)
+	return result, nil
+}
+
+func requestID(md metadata.MD) string {
+	if v := md.Get(requestIDHeader); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
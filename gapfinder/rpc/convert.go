@@ -0,0 +1,308 @@
+package rpc
+
+import (
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/rpc/gapfinderpb"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// Conversions between the protobuf messages and package types. Nil slices
+// in responses become empty slices, matching the JSON API.
+
+func analyzeRequestToPB(r types.AnalyzeRequest) *gapfinderpb.AnalyzeRequest {
+	return &gapfinderpb.AnalyzeRequest{
+		Title:           r.Title,
+		Abstract:        r.Abstract,
+		Field:           string(r.Field),
+		Authors:         r.Authors,
+		Keywords:        r.Keywords,
+		FullText:        r.FullText,
+		Mode:            r.Mode,
+		Model:           r.Model,
+		Temperature:     r.Temperature,
+		Language:        r.Language,
+		TranslateOutput: r.TranslateOutput,
+		MinConfidence:   r.MinConfidence,
+		MaxGaps:         int32(r.MaxGaps),
+		MaxHypotheses:   int32(r.MaxHypotheses),
+		MaxFindings:     int32(r.MaxFindings),
+		Instructions:    r.Instructions,
+	}
+}
+
+func analyzeRequestFromPB(r *gapfinderpb.AnalyzeRequest) types.AnalyzeRequest {
+	return types.AnalyzeRequest{
+		Title:           r.GetTitle(),
+		Abstract:        r.GetAbstract(),
+		Field:           types.Field(r.GetField()),
+		Authors:         r.GetAuthors(),
+		Keywords:        r.GetKeywords(),
+		FullText:        r.GetFullText(),
+		Mode:            r.GetMode(),
+		Model:           r.GetModel(),
+		Temperature:     r.Temperature,
+		Language:        r.GetLanguage(),
+		TranslateOutput: r.GetTranslateOutput(),
+		MinConfidence:   r.GetMinConfidence(),
+		MaxGaps:         int(r.GetMaxGaps()),
+		MaxHypotheses:   int(r.GetMaxHypotheses()),
+		MaxFindings:     int(r.GetMaxFindings()),
+		Instructions:    r.GetInstructions(),
+	}
+}
+
+func topicRequestToPB(r types.TopicRequest) *gapfinderpb.TopicRequest {
+	return &gapfinderpb.TopicRequest{
+		Topic:           r.Topic,
+		Field:           string(r.Field),
+		MaxPapers:       int32(r.MaxPapers),
+		PageSize:        int32(r.PageSize),
+		Model:           r.Model,
+		Temperature:     r.Temperature,
+		Language:        r.Language,
+		TranslateOutput: r.TranslateOutput,
+		MinConfidence:   r.MinConfidence,
+		MaxGaps:         int32(r.MaxGaps),
+		CrossFields:     fieldsToPB(r.CrossFields),
+		Surveys:         r.Surveys,
+	}
+}
+
+func topicRequestFromPB(r *gapfinderpb.TopicRequest) types.TopicRequest {
+	return types.TopicRequest{
+		Topic:           r.GetTopic(),
+		Field:           types.Field(r.GetField()),
+		MaxPapers:       int(r.GetMaxPapers()),
+		PageSize:        int(r.GetPageSize()),
+		Model:           r.GetModel(),
+		Temperature:     r.Temperature,
+		Language:        r.GetLanguage(),
+		TranslateOutput: r.GetTranslateOutput(),
+		MinConfidence:   r.GetMinConfidence(),
+		MaxGaps:         int(r.GetMaxGaps()),
+		CrossFields:     fieldsFromPB(r.GetCrossFields()),
+		Surveys:         r.GetSurveys(),
+	}
+}
+
+func fieldsToPB(fields []types.Field) []string {
+	var out []string
+	for _, f := range fields {
+		out = append(out, string(f))
+	}
+	return out
+}
+
+func fieldsFromPB(fields []string) []types.Field {
+	var out []types.Field
+	for _, f := range fields {
+		out = append(out, types.Field(f))
+	}
+	return out
+}
+
+func gapsToPB(gaps []types.ResearchGap) []*gapfinderpb.ResearchGap {
+	out := make([]*gapfinderpb.ResearchGap, len(gaps))
+	for i, g := range gaps {
+		out[i] = &gapfinderpb.ResearchGap{
+			GapDescription:  g.GapDescription,
+			ConfidenceScore: g.ConfidenceScore,
+			GapType:         string(g.GapType),
+			PotentialImpact: g.PotentialImpact,
+		}
+		for _, c := range g.SupportingCitations {
+			out[i].SupportingCitations = append(out[i].SupportingCitations, &gapfinderpb.Citation{
+				Title:   c.Title,
+				Authors: c.Authors,
+				Url:     c.URL,
+				Reason:  c.Reason,
+			})
+		}
+		for _, group := range g.ResearchGroups {
+			out[i].ResearchGroups = append(out[i].ResearchGroups, &gapfinderpb.AuthorGroup{Authors: group.Authors, Papers: group.Papers})
+		}
+	}
+	return out
+}
+
+func gapsFromPB(gaps []*gapfinderpb.ResearchGap) []types.ResearchGap {
+	out := make([]types.ResearchGap, len(gaps))
+	for i, g := range gaps {
+		out[i] = types.ResearchGap{
+			GapDescription:  g.GetGapDescription(),
+			ConfidenceScore: g.GetConfidenceScore(),
+			GapType:         types.GapType(g.GetGapType()),
+			PotentialImpact: g.GetPotentialImpact(),
+		}
+		for _, c := range g.GetSupportingCitations() {
+			out[i].SupportingCitations = append(out[i].SupportingCitations, types.Citation{
+				Title:   c.GetTitle(),
+				Authors: orEmpty(c.GetAuthors()),
+				URL:     c.GetUrl(),
+				Reason:  c.GetReason(),
+			})
+		}
+		for _, group := range g.GetResearchGroups() {
+			out[i].ResearchGroups = append(out[i].ResearchGroups, types.AuthorGroup{
+				Authors: orEmpty(group.GetAuthors()),
+				Papers:  orEmpty(group.GetPapers()),
+			})
+		}
+	}
+	return out
+}
+
+func analyzeResponseToPB(r *types.AnalyzeResponse) *gapfinderpb.AnalyzeResponse {
+	hypotheses := make([]*gapfinderpb.Hypothesis, len(r.SuggestedHypotheses))
+	for i, h := range r.SuggestedHypotheses {
+		hypotheses[i] = &gapfinderpb.Hypothesis{
+			Hypothesis:       h.Hypothesis,
+			Rationale:        h.Rationale,
+			FeasibilityScore: h.FeasibilityScore,
+			RequiredMethods:  h.RequiredMethods,
+		}
+	}
+	return &gapfinderpb.AnalyzeResponse{
+		KeyFindings:         r.KeyFindings,
+		Gaps:                gapsToPB(r.Gaps),
+		SuggestedHypotheses: hypotheses,
+		Limitations:         r.Limitations,
+		MethodologyGaps:     r.MethodologyGaps,
+		FutureDirections:    r.FutureDirections,
+		ProcessingTime:      r.ProcessingTime,
+		Paper:               paperInfoToPB(r.Paper),
+	}
+}
+
+func analyzeResponseFromPB(r *gapfinderpb.AnalyzeResponse) *types.AnalyzeResponse {
+	hypotheses := make([]types.Hypothesis, len(r.GetSuggestedHypotheses()))
+	for i, h := range r.GetSuggestedHypotheses() {
+		hypotheses[i] = types.Hypothesis{
+			Hypothesis:       h.GetHypothesis(),
+			Rationale:        h.GetRationale(),
+			FeasibilityScore: h.GetFeasibilityScore(),
+			RequiredMethods:  orEmpty(h.GetRequiredMethods()),
+		}
+	}
+	return &types.AnalyzeResponse{
+		KeyFindings:         orEmpty(r.GetKeyFindings()),
+		Gaps:                gapsFromPB(r.GetGaps()),
+		SuggestedHypotheses: hypotheses,
+		Limitations:         orEmpty(r.GetLimitations()),
+		MethodologyGaps:     orEmpty(r.GetMethodologyGaps()),
+		FutureDirections:    orEmpty(r.GetFutureDirections()),
+		ProcessingTime:      r.GetProcessingTime(),
+		Paper:               paperInfoFromPB(r.GetPaper()),
+	}
+}
+
+func paperInfoToPB(p *types.PaperInfo) *gapfinderpb.PaperInfo {
+	if p == nil {
+		return nil
+	}
+	return &gapfinderpb.PaperInfo{
+		Source:   p.Source,
+		Id:       p.ID,
+		Title:    p.Title,
+		Authors:  p.Authors,
+		Abstract: p.Abstract,
+		Url:      p.URL,
+		Year:     int32(p.Year),
+	}
+}
+
+func paperInfoFromPB(p *gapfinderpb.PaperInfo) *types.PaperInfo {
+	if p == nil {
+		return nil
+	}
+	return &types.PaperInfo{
+		Source:   p.GetSource(),
+		ID:       p.GetId(),
+		Title:    p.GetTitle(),
+		Authors:  orEmpty(p.GetAuthors()),
+		Abstract: p.GetAbstract(),
+		URL:      p.GetUrl(),
+		Year:     int(p.GetYear()),
+	}
+}
+
+func topicResultToPB(r types.TopicAnalysisResult) *gapfinderpb.TopicAnalysisResult {
+	return &gapfinderpb.TopicAnalysisResult{
+		PaperTitle: r.PaperTitle,
+		Authors:    r.Authors,
+		Abstract:   r.Abstract,
+		Gaps:       gapsToPB(r.Gaps),
+		Url:        r.URL,
+		Year:       int32(r.Year),
+	}
+}
+
+func topicResultFromPB(r *gapfinderpb.TopicAnalysisResult) types.TopicAnalysisResult {
+	return types.TopicAnalysisResult{
+		PaperTitle: r.GetPaperTitle(),
+		Authors:    orEmpty(r.GetAuthors()),
+		Abstract:   r.GetAbstract(),
+		Gaps:       gapsFromPB(r.GetGaps()),
+		URL:        r.GetUrl(),
+		Year:       int(r.GetYear()),
+	}
+}
+
+func topicResponseToPB(r *types.TopicResponse) *gapfinderpb.TopicResponse {
+	results := make([]*gapfinderpb.TopicAnalysisResult, len(r.IndividualResults))
+	for i, res := range r.IndividualResults {
+		results[i] = topicResultToPB(res)
+	}
+	var intersection []*gapfinderpb.ResearchGap
+	if r.IntersectionGaps != nil {
+		intersection = gapsToPB(r.IntersectionGaps)
+	}
+	return &gapfinderpb.TopicResponse{
+		Topic:                       r.Topic,
+		PapersAnalyzed:              int32(r.PapersAnalyzed),
+		CommonGaps:                  gapsToPB(r.CommonGaps),
+		IndividualResults:           results,
+		SuggestedResearchDirections: r.SuggestedResearchDirections,
+		ProcessingTime:              r.ProcessingTime,
+		NextCursor:                  r.NextCursor,
+		IntersectionGaps:            intersection,
+	}
+}
+
+func topicResponseFromPB(r *gapfinderpb.TopicResponse) *types.TopicResponse {
+	results := make([]types.TopicAnalysisResult, len(r.GetIndividualResults()))
+	for i, res := range r.GetIndividualResults() {
+		results[i] = topicResultFromPB(res)
+	}
+	// Protobuf doesn't tell an empty list from an unset one, so a
+	// cross-field analysis without intersection gaps comes back without any
+	var intersection []types.ResearchGap
+	if len(r.GetIntersectionGaps()) > 0 {
+		intersection = gapsFromPB(r.GetIntersectionGaps())
+	}
+	return &types.TopicResponse{
+		Topic:                       r.GetTopic(),
+		PapersAnalyzed:              int(r.GetPapersAnalyzed()),
+		CommonGaps:                  gapsFromPB(r.GetCommonGaps()),
+		IndividualResults:           results,
+		SuggestedResearchDirections: orEmpty(r.GetSuggestedResearchDirections()),
+		ProcessingTime:              r.GetProcessingTime(),
+		NextCursor:                  r.GetNextCursor(),
+		IntersectionGaps:            intersection,
+	}
+}
+
+func healthResponseToPB(r *types.HealthResponse) *gapfinderpb.HealthResponse {
+	return &gapfinderpb.HealthResponse{Status: r.Status, Version: r.Version, Timestamp: r.Timestamp}
+}
+
+func healthResponseFromPB(r *gapfinderpb.HealthResponse) *types.HealthResponse {
+	return &types.HealthResponse{Status: r.GetStatus(), Version: r.GetVersion(), Timestamp: r.GetTimestamp()}
+}
+
+// orEmpty returns s, or an empty slice if s is nil
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,25 @@
+// Package rpc serves and calls the AI Gap Finder API over gRPC. The service
+// is defined in api/gapfinder/v1/gapfinder.proto and its generated code lives
+// in package gapfinderpb; this package converts between those messages and
+// the structures in package types.
+//
+// Serve the API with a server.Server:
+//
+//	gs := grpc.NewServer()
+//	rpc.NewServer(server.New(backend)).Register(gs)
+//	gs.Serve(lis)
+//
+// and call it with a Client:
+//
+//	conn, err := grpc.NewClient("gap-finder:9001", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	if err != nil {
+//		return err
+//	}
+//	result, err := rpc.NewClient(conn).AnalyzeAbstract(ctx, req)
+//
+// Deadlines and cancellation of the caller's context reach the server, and
+// invalid requests fail with codes.InvalidArgument. The request ID is sent in
+// the x-request-id metadata key.
+package rpc
+
+//go:generate go run ../../internal/cmd/protogen -root ../.. gapfinder/v1/gapfinder.proto
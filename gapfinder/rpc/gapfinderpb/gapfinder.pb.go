@@ -0,0 +1,1382 @@
+// gRPC interface of the AI Gap Finder service. The messages mirror the JSON
+// API described in api/openapi.yaml field for field.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: gapfinder/v1/gapfinder.proto
+
+package gapfinderpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AnalyzeRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Title    string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Abstract string                 `protobuf:"bytes,2,opt,name=abstract,proto3" json:"abstract,omitempty"`
+	// Research field; defaults to "general"
+	Field    string   `protobuf:"bytes,3,opt,name=field,proto3" json:"field,omitempty"`
+	Authors  []string `protobuf:"bytes,4,rep,name=authors,proto3" json:"authors,omitempty"`
+	Keywords []string `protobuf:"bytes,5,rep,name=keywords,proto3" json:"keywords,omitempty"`
+	// Full text of the paper, required if mode is "full_text"
+	FullText string `protobuf:"bytes,6,opt,name=full_text,json=fullText,proto3" json:"full_text,omitempty"`
+	// "abstract" or "full_text"; defaults to "abstract"
+	Mode string `protobuf:"bytes,7,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Model to analyze with, one of those the service offers; defaults to the
+	// service's default model
+	Model string `protobuf:"bytes,8,opt,name=model,proto3" json:"model,omitempty"`
+	// Sampling temperature, 0 to 2; defaults to the service's
+	Temperature *float64 `protobuf:"fixed64,9,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	// ISO 639-1 code of the language the abstract is written in, such as
+	// "de"; defaults to English
+	Language string `protobuf:"bytes,10,opt,name=language,proto3" json:"language,omitempty"`
+	// Write the analysis in language instead of English; requires language
+	TranslateOutput bool `protobuf:"varint,11,opt,name=translate_output,json=translateOutput,proto3" json:"translate_output,omitempty"`
+	// Leave out gaps with a lower confidence_score, 0 to 1
+	MinConfidence float64 `protobuf:"fixed64,12,opt,name=min_confidence,json=minConfidence,proto3" json:"min_confidence,omitempty"`
+	// Bounds on the number of gaps, suggested_hypotheses and key_findings in
+	// the response, 1 to 50; unset means no limit
+	MaxGaps       int32 `protobuf:"varint,13,opt,name=max_gaps,json=maxGaps,proto3" json:"max_gaps,omitempty"`
+	MaxHypotheses int32 `protobuf:"varint,14,opt,name=max_hypotheses,json=maxHypotheses,proto3" json:"max_hypotheses,omitempty"`
+	MaxFindings   int32 `protobuf:"varint,15,opt,name=max_findings,json=maxFindings,proto3" json:"max_findings,omitempty"`
+	// Steer the analysis, such as "focus on reproducibility gaps"; at most
+	// 1000 characters
+	Instructions  string `protobuf:"bytes,16,opt,name=instructions,proto3" json:"instructions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyzeRequest) Reset() {
+	*x = AnalyzeRequest{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeRequest) ProtoMessage() {}
+
+func (x *AnalyzeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeRequest.ProtoReflect.Descriptor instead.
+func (*AnalyzeRequest) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AnalyzeRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *AnalyzeRequest) GetAbstract() string {
+	if x != nil {
+		return x.Abstract
+	}
+	return ""
+}
+
+func (x *AnalyzeRequest) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *AnalyzeRequest) GetAuthors() []string {
+	if x != nil {
+		return x.Authors
+	}
+	return nil
+}
+
+func (x *AnalyzeRequest) GetKeywords() []string {
+	if x != nil {
+		return x.Keywords
+	}
+	return nil
+}
+
+func (x *AnalyzeRequest) GetFullText() string {
+	if x != nil {
+		return x.FullText
+	}
+	return ""
+}
+
+func (x *AnalyzeRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *AnalyzeRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *AnalyzeRequest) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *AnalyzeRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *AnalyzeRequest) GetTranslateOutput() bool {
+	if x != nil {
+		return x.TranslateOutput
+	}
+	return false
+}
+
+func (x *AnalyzeRequest) GetMinConfidence() float64 {
+	if x != nil {
+		return x.MinConfidence
+	}
+	return 0
+}
+
+func (x *AnalyzeRequest) GetMaxGaps() int32 {
+	if x != nil {
+		return x.MaxGaps
+	}
+	return 0
+}
+
+func (x *AnalyzeRequest) GetMaxHypotheses() int32 {
+	if x != nil {
+		return x.MaxHypotheses
+	}
+	return 0
+}
+
+func (x *AnalyzeRequest) GetMaxFindings() int32 {
+	if x != nil {
+		return x.MaxFindings
+	}
+	return 0
+}
+
+func (x *AnalyzeRequest) GetInstructions() string {
+	if x != nil {
+		return x.Instructions
+	}
+	return ""
+}
+
+type TopicRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Topic string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	// Research field; defaults to "general"
+	Field string `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`
+	// Number of papers to analyze, 1 to 50; defaults to 10
+	MaxPapers int32 `protobuf:"varint,3,opt,name=max_papers,json=maxPapers,proto3" json:"max_papers,omitempty"`
+	// Number of individual results to return, 1 to 100; unset returns them
+	// all. The rest are served by GET /topic/results of the JSON API.
+	PageSize int32 `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// As in AnalyzeRequest
+	Model           string   `protobuf:"bytes,5,opt,name=model,proto3" json:"model,omitempty"`
+	Temperature     *float64 `protobuf:"fixed64,6,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	Language        string   `protobuf:"bytes,7,opt,name=language,proto3" json:"language,omitempty"`
+	TranslateOutput bool     `protobuf:"varint,8,opt,name=translate_output,json=translateOutput,proto3" json:"translate_output,omitempty"`
+	// As in AnalyzeRequest, applied to the common gaps and each paper's
+	MinConfidence float64 `protobuf:"fixed64,9,opt,name=min_confidence,json=minConfidence,proto3" json:"min_confidence,omitempty"`
+	MaxGaps       int32   `protobuf:"varint,10,opt,name=max_gaps,json=maxGaps,proto3" json:"max_gaps,omitempty"`
+	// Analyze the topic across these 2 to 4 distinct fields instead of field,
+	// reporting the gaps at their intersection
+	CrossFields []string `protobuf:"bytes,11,rep,name=cross_fields,json=crossFields,proto3" json:"cross_fields,omitempty"`
+	// How survey and review papers are treated: "include", the default,
+	// "exclude" or "downweight"
+	Surveys       string `protobuf:"bytes,12,opt,name=surveys,proto3" json:"surveys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopicRequest) Reset() {
+	*x = TopicRequest{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopicRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopicRequest) ProtoMessage() {}
+
+func (x *TopicRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopicRequest.ProtoReflect.Descriptor instead.
+func (*TopicRequest) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TopicRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *TopicRequest) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *TopicRequest) GetMaxPapers() int32 {
+	if x != nil {
+		return x.MaxPapers
+	}
+	return 0
+}
+
+func (x *TopicRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *TopicRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *TopicRequest) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *TopicRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *TopicRequest) GetTranslateOutput() bool {
+	if x != nil {
+		return x.TranslateOutput
+	}
+	return false
+}
+
+func (x *TopicRequest) GetMinConfidence() float64 {
+	if x != nil {
+		return x.MinConfidence
+	}
+	return 0
+}
+
+func (x *TopicRequest) GetMaxGaps() int32 {
+	if x != nil {
+		return x.MaxGaps
+	}
+	return 0
+}
+
+func (x *TopicRequest) GetCrossFields() []string {
+	if x != nil {
+		return x.CrossFields
+	}
+	return nil
+}
+
+func (x *TopicRequest) GetSurveys() string {
+	if x != nil {
+		return x.Surveys
+	}
+	return ""
+}
+
+type ResearchGap struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	GapDescription      string                 `protobuf:"bytes,1,opt,name=gap_description,json=gapDescription,proto3" json:"gap_description,omitempty"`
+	ConfidenceScore     float64                `protobuf:"fixed64,2,opt,name=confidence_score,json=confidenceScore,proto3" json:"confidence_score,omitempty"`
+	GapType             string                 `protobuf:"bytes,3,opt,name=gap_type,json=gapType,proto3" json:"gap_type,omitempty"`
+	PotentialImpact     string                 `protobuf:"bytes,4,opt,name=potential_impact,json=potentialImpact,proto3" json:"potential_impact,omitempty"`
+	SupportingCitations []*Citation            `protobuf:"bytes,5,rep,name=supporting_citations,json=supportingCitations,proto3" json:"supporting_citations,omitempty"`
+	ResearchGroups      []*AuthorGroup         `protobuf:"bytes,6,rep,name=research_groups,json=researchGroups,proto3" json:"research_groups,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ResearchGap) Reset() {
+	*x = ResearchGap{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResearchGap) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResearchGap) ProtoMessage() {}
+
+func (x *ResearchGap) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResearchGap.ProtoReflect.Descriptor instead.
+func (*ResearchGap) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ResearchGap) GetGapDescription() string {
+	if x != nil {
+		return x.GapDescription
+	}
+	return ""
+}
+
+func (x *ResearchGap) GetConfidenceScore() float64 {
+	if x != nil {
+		return x.ConfidenceScore
+	}
+	return 0
+}
+
+func (x *ResearchGap) GetGapType() string {
+	if x != nil {
+		return x.GapType
+	}
+	return ""
+}
+
+func (x *ResearchGap) GetPotentialImpact() string {
+	if x != nil {
+		return x.PotentialImpact
+	}
+	return ""
+}
+
+func (x *ResearchGap) GetSupportingCitations() []*Citation {
+	if x != nil {
+		return x.SupportingCitations
+	}
+	return nil
+}
+
+func (x *ResearchGap) GetResearchGroups() []*AuthorGroup {
+	if x != nil {
+		return x.ResearchGroups
+	}
+	return nil
+}
+
+type AuthorGroup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Authors       []string               `protobuf:"bytes,1,rep,name=authors,proto3" json:"authors,omitempty"`
+	Papers        []string               `protobuf:"bytes,2,rep,name=papers,proto3" json:"papers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthorGroup) Reset() {
+	*x = AuthorGroup{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthorGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthorGroup) ProtoMessage() {}
+
+func (x *AuthorGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthorGroup.ProtoReflect.Descriptor instead.
+func (*AuthorGroup) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AuthorGroup) GetAuthors() []string {
+	if x != nil {
+		return x.Authors
+	}
+	return nil
+}
+
+func (x *AuthorGroup) GetPapers() []string {
+	if x != nil {
+		return x.Papers
+	}
+	return nil
+}
+
+type Citation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Authors       []string               `protobuf:"bytes,2,rep,name=authors,proto3" json:"authors,omitempty"`
+	Url           string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Citation) Reset() {
+	*x = Citation{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Citation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Citation) ProtoMessage() {}
+
+func (x *Citation) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Citation.ProtoReflect.Descriptor instead.
+func (*Citation) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Citation) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Citation) GetAuthors() []string {
+	if x != nil {
+		return x.Authors
+	}
+	return nil
+}
+
+func (x *Citation) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Citation) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type Hypothesis struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Hypothesis       string                 `protobuf:"bytes,1,opt,name=hypothesis,proto3" json:"hypothesis,omitempty"`
+	Rationale        string                 `protobuf:"bytes,2,opt,name=rationale,proto3" json:"rationale,omitempty"`
+	FeasibilityScore float64                `protobuf:"fixed64,3,opt,name=feasibility_score,json=feasibilityScore,proto3" json:"feasibility_score,omitempty"`
+	RequiredMethods  []string               `protobuf:"bytes,4,rep,name=required_methods,json=requiredMethods,proto3" json:"required_methods,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Hypothesis) Reset() {
+	*x = Hypothesis{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hypothesis) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hypothesis) ProtoMessage() {}
+
+func (x *Hypothesis) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hypothesis.ProtoReflect.Descriptor instead.
+func (*Hypothesis) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Hypothesis) GetHypothesis() string {
+	if x != nil {
+		return x.Hypothesis
+	}
+	return ""
+}
+
+func (x *Hypothesis) GetRationale() string {
+	if x != nil {
+		return x.Rationale
+	}
+	return ""
+}
+
+func (x *Hypothesis) GetFeasibilityScore() float64 {
+	if x != nil {
+		return x.FeasibilityScore
+	}
+	return 0
+}
+
+func (x *Hypothesis) GetRequiredMethods() []string {
+	if x != nil {
+		return x.RequiredMethods
+	}
+	return nil
+}
+
+type AnalyzeResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	KeyFindings         []string               `protobuf:"bytes,1,rep,name=key_findings,json=keyFindings,proto3" json:"key_findings,omitempty"`
+	Gaps                []*ResearchGap         `protobuf:"bytes,2,rep,name=gaps,proto3" json:"gaps,omitempty"`
+	SuggestedHypotheses []*Hypothesis          `protobuf:"bytes,3,rep,name=suggested_hypotheses,json=suggestedHypotheses,proto3" json:"suggested_hypotheses,omitempty"`
+	Limitations         []string               `protobuf:"bytes,4,rep,name=limitations,proto3" json:"limitations,omitempty"`
+	MethodologyGaps     []string               `protobuf:"bytes,5,rep,name=methodology_gaps,json=methodologyGaps,proto3" json:"methodology_gaps,omitempty"`
+	FutureDirections    []string               `protobuf:"bytes,6,rep,name=future_directions,json=futureDirections,proto3" json:"future_directions,omitempty"`
+	ProcessingTime      float64                `protobuf:"fixed64,7,opt,name=processing_time,json=processingTime,proto3" json:"processing_time,omitempty"`
+	// The paper analyzed, for analyses by identifier such as a DOI
+	Paper         *PaperInfo `protobuf:"bytes,8,opt,name=paper,proto3" json:"paper,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyzeResponse) Reset() {
+	*x = AnalyzeResponse{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeResponse) ProtoMessage() {}
+
+func (x *AnalyzeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeResponse.ProtoReflect.Descriptor instead.
+func (*AnalyzeResponse) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AnalyzeResponse) GetKeyFindings() []string {
+	if x != nil {
+		return x.KeyFindings
+	}
+	return nil
+}
+
+func (x *AnalyzeResponse) GetGaps() []*ResearchGap {
+	if x != nil {
+		return x.Gaps
+	}
+	return nil
+}
+
+func (x *AnalyzeResponse) GetSuggestedHypotheses() []*Hypothesis {
+	if x != nil {
+		return x.SuggestedHypotheses
+	}
+	return nil
+}
+
+func (x *AnalyzeResponse) GetLimitations() []string {
+	if x != nil {
+		return x.Limitations
+	}
+	return nil
+}
+
+func (x *AnalyzeResponse) GetMethodologyGaps() []string {
+	if x != nil {
+		return x.MethodologyGaps
+	}
+	return nil
+}
+
+func (x *AnalyzeResponse) GetFutureDirections() []string {
+	if x != nil {
+		return x.FutureDirections
+	}
+	return nil
+}
+
+func (x *AnalyzeResponse) GetProcessingTime() float64 {
+	if x != nil {
+		return x.ProcessingTime
+	}
+	return 0
+}
+
+func (x *AnalyzeResponse) GetPaper() *PaperInfo {
+	if x != nil {
+		return x.Paper
+	}
+	return nil
+}
+
+// PaperInfo describes a paper looked up by identifier
+type PaperInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Where the paper was looked up, such as "crossref"
+	Source string `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	// The identifier it was looked up by
+	Id       string   `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Title    string   `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Authors  []string `protobuf:"bytes,4,rep,name=authors,proto3" json:"authors,omitempty"`
+	Abstract string   `protobuf:"bytes,5,opt,name=abstract,proto3" json:"abstract,omitempty"`
+	Url      string   `protobuf:"bytes,6,opt,name=url,proto3" json:"url,omitempty"`
+	// Zero if the source doesn't say
+	Year          int32 `protobuf:"varint,7,opt,name=year,proto3" json:"year,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PaperInfo) Reset() {
+	*x = PaperInfo{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaperInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaperInfo) ProtoMessage() {}
+
+func (x *PaperInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaperInfo.ProtoReflect.Descriptor instead.
+func (*PaperInfo) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PaperInfo) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *PaperInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PaperInfo) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *PaperInfo) GetAuthors() []string {
+	if x != nil {
+		return x.Authors
+	}
+	return nil
+}
+
+func (x *PaperInfo) GetAbstract() string {
+	if x != nil {
+		return x.Abstract
+	}
+	return ""
+}
+
+func (x *PaperInfo) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *PaperInfo) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+type TopicAnalysisResult struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	PaperTitle string                 `protobuf:"bytes,1,opt,name=paper_title,json=paperTitle,proto3" json:"paper_title,omitempty"`
+	Authors    []string               `protobuf:"bytes,2,rep,name=authors,proto3" json:"authors,omitempty"`
+	Abstract   string                 `protobuf:"bytes,3,opt,name=abstract,proto3" json:"abstract,omitempty"`
+	Gaps       []*ResearchGap         `protobuf:"bytes,4,rep,name=gaps,proto3" json:"gaps,omitempty"`
+	Url        string                 `protobuf:"bytes,5,opt,name=url,proto3" json:"url,omitempty"`
+	// Zero if the source doesn't say
+	Year          int32 `protobuf:"varint,6,opt,name=year,proto3" json:"year,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopicAnalysisResult) Reset() {
+	*x = TopicAnalysisResult{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopicAnalysisResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopicAnalysisResult) ProtoMessage() {}
+
+func (x *TopicAnalysisResult) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopicAnalysisResult.ProtoReflect.Descriptor instead.
+func (*TopicAnalysisResult) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TopicAnalysisResult) GetPaperTitle() string {
+	if x != nil {
+		return x.PaperTitle
+	}
+	return ""
+}
+
+func (x *TopicAnalysisResult) GetAuthors() []string {
+	if x != nil {
+		return x.Authors
+	}
+	return nil
+}
+
+func (x *TopicAnalysisResult) GetAbstract() string {
+	if x != nil {
+		return x.Abstract
+	}
+	return ""
+}
+
+func (x *TopicAnalysisResult) GetGaps() []*ResearchGap {
+	if x != nil {
+		return x.Gaps
+	}
+	return nil
+}
+
+func (x *TopicAnalysisResult) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *TopicAnalysisResult) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+type TopicResponse struct {
+	state                       protoimpl.MessageState `protogen:"open.v1"`
+	Topic                       string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	PapersAnalyzed              int32                  `protobuf:"varint,2,opt,name=papers_analyzed,json=papersAnalyzed,proto3" json:"papers_analyzed,omitempty"`
+	CommonGaps                  []*ResearchGap         `protobuf:"bytes,3,rep,name=common_gaps,json=commonGaps,proto3" json:"common_gaps,omitempty"`
+	IndividualResults           []*TopicAnalysisResult `protobuf:"bytes,4,rep,name=individual_results,json=individualResults,proto3" json:"individual_results,omitempty"`
+	SuggestedResearchDirections []string               `protobuf:"bytes,5,rep,name=suggested_research_directions,json=suggestedResearchDirections,proto3" json:"suggested_research_directions,omitempty"`
+	ProcessingTime              float64                `protobuf:"fixed64,6,opt,name=processing_time,json=processingTime,proto3" json:"processing_time,omitempty"`
+	// Set if more individual results are available
+	NextCursor string `protobuf:"bytes,7,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	// Gaps at the intersection of the request's cross_fields
+	IntersectionGaps []*ResearchGap `protobuf:"bytes,8,rep,name=intersection_gaps,json=intersectionGaps,proto3" json:"intersection_gaps,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *TopicResponse) Reset() {
+	*x = TopicResponse{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopicResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopicResponse) ProtoMessage() {}
+
+func (x *TopicResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopicResponse.ProtoReflect.Descriptor instead.
+func (*TopicResponse) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TopicResponse) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *TopicResponse) GetPapersAnalyzed() int32 {
+	if x != nil {
+		return x.PapersAnalyzed
+	}
+	return 0
+}
+
+func (x *TopicResponse) GetCommonGaps() []*ResearchGap {
+	if x != nil {
+		return x.CommonGaps
+	}
+	return nil
+}
+
+func (x *TopicResponse) GetIndividualResults() []*TopicAnalysisResult {
+	if x != nil {
+		return x.IndividualResults
+	}
+	return nil
+}
+
+func (x *TopicResponse) GetSuggestedResearchDirections() []string {
+	if x != nil {
+		return x.SuggestedResearchDirections
+	}
+	return nil
+}
+
+func (x *TopicResponse) GetProcessingTime() float64 {
+	if x != nil {
+		return x.ProcessingTime
+	}
+	return 0
+}
+
+func (x *TopicResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *TopicResponse) GetIntersectionGaps() []*ResearchGap {
+	if x != nil {
+		return x.IntersectionGaps
+	}
+	return nil
+}
+
+// TopicEvent is sent by AnalyzeTopicStream
+type TopicEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*TopicEvent_Result
+	//	*TopicEvent_Summary
+	Event         isTopicEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopicEvent) Reset() {
+	*x = TopicEvent{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopicEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopicEvent) ProtoMessage() {}
+
+func (x *TopicEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopicEvent.ProtoReflect.Descriptor instead.
+func (*TopicEvent) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TopicEvent) GetEvent() isTopicEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *TopicEvent) GetResult() *TopicAnalysisResult {
+	if x != nil {
+		if x, ok := x.Event.(*TopicEvent_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *TopicEvent) GetSummary() *TopicResponse {
+	if x != nil {
+		if x, ok := x.Event.(*TopicEvent_Summary); ok {
+			return x.Summary
+		}
+	}
+	return nil
+}
+
+type isTopicEvent_Event interface {
+	isTopicEvent_Event()
+}
+
+type TopicEvent_Result struct {
+	// The analysis of one paper
+	Result *TopicAnalysisResult `protobuf:"bytes,1,opt,name=result,proto3,oneof"`
+}
+
+type TopicEvent_Summary struct {
+	// The topic-wide results, sent last, without individual_results
+	Summary *TopicResponse `protobuf:"bytes,2,opt,name=summary,proto3,oneof"`
+}
+
+func (*TopicEvent_Result) isTopicEvent_Event() {}
+
+func (*TopicEvent_Summary) isTopicEvent_Event() {}
+
+type HealthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{11}
+}
+
+type HealthResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Timestamp     string                 `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gapfinder_v1_gapfinder_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_gapfinder_v1_gapfinder_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *HealthResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+var File_gapfinder_v1_gapfinder_proto protoreflect.FileDescriptor
+
+const file_gapfinder_v1_gapfinder_proto_rawDesc = "" +
+	"\n" +
+	"\x1cgapfinder/v1/gapfinder.proto\x12\fgapfinder.v1\"\x83\x04\n" +
+	"\x0eAnalyzeRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x1a\n" +
+	"\babstract\x18\x02 \x01(\tR\babstract\x12\x14\n" +
+	"\x05field\x18\x03 \x01(\tR\x05field\x12\x18\n" +
+	"\aauthors\x18\x04 \x03(\tR\aauthors\x12\x1a\n" +
+	"\bkeywords\x18\x05 \x03(\tR\bkeywords\x12\x1b\n" +
+	"\tfull_text\x18\x06 \x01(\tR\bfullText\x12\x12\n" +
+	"\x04mode\x18\a \x01(\tR\x04mode\x12\x14\n" +
+	"\x05model\x18\b \x01(\tR\x05model\x12%\n" +
+	"\vtemperature\x18\t \x01(\x01H\x00R\vtemperature\x88\x01\x01\x12\x1a\n" +
+	"\blanguage\x18\n" +
+	" \x01(\tR\blanguage\x12)\n" +
+	"\x10translate_output\x18\v \x01(\bR\x0ftranslateOutput\x12%\n" +
+	"\x0emin_confidence\x18\f \x01(\x01R\rminConfidence\x12\x19\n" +
+	"\bmax_gaps\x18\r \x01(\x05R\amaxGaps\x12%\n" +
+	"\x0emax_hypotheses\x18\x0e \x01(\x05R\rmaxHypotheses\x12!\n" +
+	"\fmax_findings\x18\x0f \x01(\x05R\vmaxFindings\x12\"\n" +
+	"\finstructions\x18\x10 \x01(\tR\finstructionsB\x0e\n" +
+	"\f_temperature\"\x89\x03\n" +
+	"\fTopicRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x14\n" +
+	"\x05field\x18\x02 \x01(\tR\x05field\x12\x1d\n" +
+	"\n" +
+	"max_papers\x18\x03 \x01(\x05R\tmaxPapers\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x14\n" +
+	"\x05model\x18\x05 \x01(\tR\x05model\x12%\n" +
+	"\vtemperature\x18\x06 \x01(\x01H\x00R\vtemperature\x88\x01\x01\x12\x1a\n" +
+	"\blanguage\x18\a \x01(\tR\blanguage\x12)\n" +
+	"\x10translate_output\x18\b \x01(\bR\x0ftranslateOutput\x12%\n" +
+	"\x0emin_confidence\x18\t \x01(\x01R\rminConfidence\x12\x19\n" +
+	"\bmax_gaps\x18\n" +
+	" \x01(\x05R\amaxGaps\x12!\n" +
+	"\fcross_fields\x18\v \x03(\tR\vcrossFields\x12\x18\n" +
+	"\asurveys\x18\f \x01(\tR\asurveysB\x0e\n" +
+	"\f_temperature\"\xb6\x02\n" +
+	"\vResearchGap\x12'\n" +
+	"\x0fgap_description\x18\x01 \x01(\tR\x0egapDescription\x12)\n" +
+	"\x10confidence_score\x18\x02 \x01(\x01R\x0fconfidenceScore\x12\x19\n" +
+	"\bgap_type\x18\x03 \x01(\tR\agapType\x12)\n" +
+	"\x10potential_impact\x18\x04 \x01(\tR\x0fpotentialImpact\x12I\n" +
+	"\x14supporting_citations\x18\x05 \x03(\v2\x16.gapfinder.v1.CitationR\x13supportingCitations\x12B\n" +
+	"\x0fresearch_groups\x18\x06 \x03(\v2\x19.gapfinder.v1.AuthorGroupR\x0eresearchGroups\"?\n" +
+	"\vAuthorGroup\x12\x18\n" +
+	"\aauthors\x18\x01 \x03(\tR\aauthors\x12\x16\n" +
+	"\x06papers\x18\x02 \x03(\tR\x06papers\"d\n" +
+	"\bCitation\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x18\n" +
+	"\aauthors\x18\x02 \x03(\tR\aauthors\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\xa2\x01\n" +
+	"\n" +
+	"Hypothesis\x12\x1e\n" +
+	"\n" +
+	"hypothesis\x18\x01 \x01(\tR\n" +
+	"hypothesis\x12\x1c\n" +
+	"\trationale\x18\x02 \x01(\tR\trationale\x12+\n" +
+	"\x11feasibility_score\x18\x03 \x01(\x01R\x10feasibilityScore\x12)\n" +
+	"\x10required_methods\x18\x04 \x03(\tR\x0frequiredMethods\"\x82\x03\n" +
+	"\x0fAnalyzeResponse\x12!\n" +
+	"\fkey_findings\x18\x01 \x03(\tR\vkeyFindings\x12-\n" +
+	"\x04gaps\x18\x02 \x03(\v2\x19.gapfinder.v1.ResearchGapR\x04gaps\x12K\n" +
+	"\x14suggested_hypotheses\x18\x03 \x03(\v2\x18.gapfinder.v1.HypothesisR\x13suggestedHypotheses\x12 \n" +
+	"\vlimitations\x18\x04 \x03(\tR\vlimitations\x12)\n" +
+	"\x10methodology_gaps\x18\x05 \x03(\tR\x0fmethodologyGaps\x12+\n" +
+	"\x11future_directions\x18\x06 \x03(\tR\x10futureDirections\x12'\n" +
+	"\x0fprocessing_time\x18\a \x01(\x01R\x0eprocessingTime\x12-\n" +
+	"\x05paper\x18\b \x01(\v2\x17.gapfinder.v1.PaperInfoR\x05paper\"\xa5\x01\n" +
+	"\tPaperInfo\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x18\n" +
+	"\aauthors\x18\x04 \x03(\tR\aauthors\x12\x1a\n" +
+	"\babstract\x18\x05 \x01(\tR\babstract\x12\x10\n" +
+	"\x03url\x18\x06 \x01(\tR\x03url\x12\x12\n" +
+	"\x04year\x18\a \x01(\x05R\x04year\"\xc1\x01\n" +
+	"\x13TopicAnalysisResult\x12\x1f\n" +
+	"\vpaper_title\x18\x01 \x01(\tR\n" +
+	"paperTitle\x12\x18\n" +
+	"\aauthors\x18\x02 \x03(\tR\aauthors\x12\x1a\n" +
+	"\babstract\x18\x03 \x01(\tR\babstract\x12-\n" +
+	"\x04gaps\x18\x04 \x03(\v2\x19.gapfinder.v1.ResearchGapR\x04gaps\x12\x10\n" +
+	"\x03url\x18\x05 \x01(\tR\x03url\x12\x12\n" +
+	"\x04year\x18\x06 \x01(\x05R\x04year\"\xb2\x03\n" +
+	"\rTopicResponse\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12'\n" +
+	"\x0fpapers_analyzed\x18\x02 \x01(\x05R\x0epapersAnalyzed\x12:\n" +
+	"\vcommon_gaps\x18\x03 \x03(\v2\x19.gapfinder.v1.ResearchGapR\n" +
+	"commonGaps\x12P\n" +
+	"\x12individual_results\x18\x04 \x03(\v2!.gapfinder.v1.TopicAnalysisResultR\x11individualResults\x12B\n" +
+	"\x1dsuggested_research_directions\x18\x05 \x03(\tR\x1bsuggestedResearchDirections\x12'\n" +
+	"\x0fprocessing_time\x18\x06 \x01(\x01R\x0eprocessingTime\x12\x1f\n" +
+	"\vnext_cursor\x18\a \x01(\tR\n" +
+	"nextCursor\x12F\n" +
+	"\x11intersection_gaps\x18\b \x03(\v2\x19.gapfinder.v1.ResearchGapR\x10intersectionGaps\"\x8b\x01\n" +
+	"\n" +
+	"TopicEvent\x12;\n" +
+	"\x06result\x18\x01 \x01(\v2!.gapfinder.v1.TopicAnalysisResultH\x00R\x06result\x127\n" +
+	"\asummary\x18\x02 \x01(\v2\x1b.gapfinder.v1.TopicResponseH\x00R\asummaryB\a\n" +
+	"\x05event\"\x0f\n" +
+	"\rHealthRequest\"`\n" +
+	"\x0eHealthResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\tR\ttimestamp2\xbc\x02\n" +
+	"\tGapFinder\x12N\n" +
+	"\x0fAnalyzeAbstract\x12\x1c.gapfinder.v1.AnalyzeRequest\x1a\x1d.gapfinder.v1.AnalyzeResponse\x12G\n" +
+	"\fAnalyzeTopic\x12\x1a.gapfinder.v1.TopicRequest\x1a\x1b.gapfinder.v1.TopicResponse\x12L\n" +
+	"\x12AnalyzeTopicStream\x12\x1a.gapfinder.v1.TopicRequest\x1a\x18.gapfinder.v1.TopicEvent0\x01\x12H\n" +
+	"\vHealthCheck\x12\x1b.gapfinder.v1.HealthRequest\x1a\x1c.gapfinder.v1.HealthResponseB@Z>github.com/aichain-lab/ai-gap-finder/gapfinder/rpc/gapfinderpbb\x06proto3"
+
+var (
+	file_gapfinder_v1_gapfinder_proto_rawDescOnce sync.Once
+	file_gapfinder_v1_gapfinder_proto_rawDescData []byte
+)
+
+func file_gapfinder_v1_gapfinder_proto_rawDescGZIP() []byte {
+	file_gapfinder_v1_gapfinder_proto_rawDescOnce.Do(func() {
+		file_gapfinder_v1_gapfinder_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gapfinder_v1_gapfinder_proto_rawDesc), len(file_gapfinder_v1_gapfinder_proto_rawDesc)))
+	})
+	return file_gapfinder_v1_gapfinder_proto_rawDescData
+}
+
+var file_gapfinder_v1_gapfinder_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_gapfinder_v1_gapfinder_proto_goTypes = []any{
+	(*AnalyzeRequest)(nil),      // 0: gapfinder.v1.AnalyzeRequest
+	(*TopicRequest)(nil),        // 1: gapfinder.v1.TopicRequest
+	(*ResearchGap)(nil),         // 2: gapfinder.v1.ResearchGap
+	(*AuthorGroup)(nil),         // 3: gapfinder.v1.AuthorGroup
+	(*Citation)(nil),            // 4: gapfinder.v1.Citation
+	(*Hypothesis)(nil),          // 5: gapfinder.v1.Hypothesis
+	(*AnalyzeResponse)(nil),     // 6: gapfinder.v1.AnalyzeResponse
+	(*PaperInfo)(nil),           // 7: gapfinder.v1.PaperInfo
+	(*TopicAnalysisResult)(nil), // 8: gapfinder.v1.TopicAnalysisResult
+	(*TopicResponse)(nil),       // 9: gapfinder.v1.TopicResponse
+	(*TopicEvent)(nil),          // 10: gapfinder.v1.TopicEvent
+	(*HealthRequest)(nil),       // 11: gapfinder.v1.HealthRequest
+	(*HealthResponse)(nil),      // 12: gapfinder.v1.HealthResponse
+}
+var file_gapfinder_v1_gapfinder_proto_depIdxs = []int32{
+	4,  // 0: gapfinder.v1.ResearchGap.supporting_citations:type_name -> gapfinder.v1.Citation
+	3,  // 1: gapfinder.v1.ResearchGap.research_groups:type_name -> gapfinder.v1.AuthorGroup
+	2,  // 2: gapfinder.v1.AnalyzeResponse.gaps:type_name -> gapfinder.v1.ResearchGap
+	5,  // 3: gapfinder.v1.AnalyzeResponse.suggested_hypotheses:type_name -> gapfinder.v1.Hypothesis
+	7,  // 4: gapfinder.v1.AnalyzeResponse.paper:type_name -> gapfinder.v1.PaperInfo
+	2,  // 5: gapfinder.v1.TopicAnalysisResult.gaps:type_name -> gapfinder.v1.ResearchGap
+	2,  // 6: gapfinder.v1.TopicResponse.common_gaps:type_name -> gapfinder.v1.ResearchGap
+	8,  // 7: gapfinder.v1.TopicResponse.individual_results:type_name -> gapfinder.v1.TopicAnalysisResult
+	2,  // 8: gapfinder.v1.TopicResponse.intersection_gaps:type_name -> gapfinder.v1.ResearchGap
+	8,  // 9: gapfinder.v1.TopicEvent.result:type_name -> gapfinder.v1.TopicAnalysisResult
+	9,  // 10: gapfinder.v1.TopicEvent.summary:type_name -> gapfinder.v1.TopicResponse
+	0,  // 11: gapfinder.v1.GapFinder.AnalyzeAbstract:input_type -> gapfinder.v1.AnalyzeRequest
+	1,  // 12: gapfinder.v1.GapFinder.AnalyzeTopic:input_type -> gapfinder.v1.TopicRequest
+	1,  // 13: gapfinder.v1.GapFinder.AnalyzeTopicStream:input_type -> gapfinder.v1.TopicRequest
+	11, // 14: gapfinder.v1.GapFinder.HealthCheck:input_type -> gapfinder.v1.HealthRequest
+	6,  // 15: gapfinder.v1.GapFinder.AnalyzeAbstract:output_type -> gapfinder.v1.AnalyzeResponse
+	9,  // 16: gapfinder.v1.GapFinder.AnalyzeTopic:output_type -> gapfinder.v1.TopicResponse
+	10, // 17: gapfinder.v1.GapFinder.AnalyzeTopicStream:output_type -> gapfinder.v1.TopicEvent
+	12, // 18: gapfinder.v1.GapFinder.HealthCheck:output_type -> gapfinder.v1.HealthResponse
+	15, // [15:19] is the sub-list for method output_type
+	11, // [11:15] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_gapfinder_v1_gapfinder_proto_init() }
+func file_gapfinder_v1_gapfinder_proto_init() {
+	if File_gapfinder_v1_gapfinder_proto != nil {
+		return
+	}
+	file_gapfinder_v1_gapfinder_proto_msgTypes[0].OneofWrappers = []any{}
+	file_gapfinder_v1_gapfinder_proto_msgTypes[1].OneofWrappers = []any{}
+	file_gapfinder_v1_gapfinder_proto_msgTypes[10].OneofWrappers = []any{
+		(*TopicEvent_Result)(nil),
+		(*TopicEvent_Summary)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gapfinder_v1_gapfinder_proto_rawDesc), len(file_gapfinder_v1_gapfinder_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gapfinder_v1_gapfinder_proto_goTypes,
+		DependencyIndexes: file_gapfinder_v1_gapfinder_proto_depIdxs,
+		MessageInfos:      file_gapfinder_v1_gapfinder_proto_msgTypes,
+	}.Build()
+	File_gapfinder_v1_gapfinder_proto = out.File
+	file_gapfinder_v1_gapfinder_proto_goTypes = nil
+	file_gapfinder_v1_gapfinder_proto_depIdxs = nil
+}
@@ -0,0 +1,252 @@
+// gRPC interface of the AI Gap Finder service. The messages mirror the JSON
+// API described in api/openapi.yaml field for field.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: gapfinder/v1/gapfinder.proto
+
+package gapfinderpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GapFinder_AnalyzeAbstract_FullMethodName    = "/gapfinder.v1.GapFinder/AnalyzeAbstract"
+	GapFinder_AnalyzeTopic_FullMethodName       = "/gapfinder.v1.GapFinder/AnalyzeTopic"
+	GapFinder_AnalyzeTopicStream_FullMethodName = "/gapfinder.v1.GapFinder/AnalyzeTopicStream"
+	GapFinder_HealthCheck_FullMethodName        = "/gapfinder.v1.GapFinder/HealthCheck"
+)
+
+// GapFinderClient is the client API for GapFinder service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GapFinderClient interface {
+	// AnalyzeAbstract identifies research gaps in a single abstract
+	AnalyzeAbstract(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*AnalyzeResponse, error)
+	// AnalyzeTopic analyzes the papers found for a research topic
+	AnalyzeTopic(ctx context.Context, in *TopicRequest, opts ...grpc.CallOption) (*TopicResponse, error)
+	// AnalyzeTopicStream analyzes a topic like AnalyzeTopic, sending each
+	// paper's result as an event followed by a summary event
+	AnalyzeTopicStream(ctx context.Context, in *TopicRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TopicEvent], error)
+	// HealthCheck reports whether the service is up
+	HealthCheck(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+}
+
+type gapFinderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGapFinderClient(cc grpc.ClientConnInterface) GapFinderClient {
+	return &gapFinderClient{cc}
+}
+
+func (c *gapFinderClient) AnalyzeAbstract(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*AnalyzeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnalyzeResponse)
+	err := c.cc.Invoke(ctx, GapFinder_AnalyzeAbstract_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gapFinderClient) AnalyzeTopic(ctx context.Context, in *TopicRequest, opts ...grpc.CallOption) (*TopicResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TopicResponse)
+	err := c.cc.Invoke(ctx, GapFinder_AnalyzeTopic_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gapFinderClient) AnalyzeTopicStream(ctx context.Context, in *TopicRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TopicEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GapFinder_ServiceDesc.Streams[0], GapFinder_AnalyzeTopicStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TopicRequest, TopicEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GapFinder_AnalyzeTopicStreamClient = grpc.ServerStreamingClient[TopicEvent]
+
+func (c *gapFinderClient) HealthCheck(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, GapFinder_HealthCheck_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GapFinderServer is the server API for GapFinder service.
+// All implementations must embed UnimplementedGapFinderServer
+// for forward compatibility.
+type GapFinderServer interface {
+	// AnalyzeAbstract identifies research gaps in a single abstract
+	AnalyzeAbstract(context.Context, *AnalyzeRequest) (*AnalyzeResponse, error)
+	// AnalyzeTopic analyzes the papers found for a research topic
+	AnalyzeTopic(context.Context, *TopicRequest) (*TopicResponse, error)
+	// AnalyzeTopicStream analyzes a topic like AnalyzeTopic, sending each
+	// paper's result as an event followed by a summary event
+	AnalyzeTopicStream(*TopicRequest, grpc.ServerStreamingServer[TopicEvent]) error
+	// HealthCheck reports whether the service is up
+	HealthCheck(context.Context, *HealthRequest) (*HealthResponse, error)
+	mustEmbedUnimplementedGapFinderServer()
+}
+
+// UnimplementedGapFinderServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGapFinderServer struct{}
+
+func (UnimplementedGapFinderServer) AnalyzeAbstract(context.Context, *AnalyzeRequest) (*AnalyzeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnalyzeAbstract not implemented")
+}
+func (UnimplementedGapFinderServer) AnalyzeTopic(context.Context, *TopicRequest) (*TopicResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnalyzeTopic not implemented")
+}
+func (UnimplementedGapFinderServer) AnalyzeTopicStream(*TopicRequest, grpc.ServerStreamingServer[TopicEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method AnalyzeTopicStream not implemented")
+}
+func (UnimplementedGapFinderServer) HealthCheck(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HealthCheck not implemented")
+}
+func (UnimplementedGapFinderServer) mustEmbedUnimplementedGapFinderServer() {}
+func (UnimplementedGapFinderServer) testEmbeddedByValue()                   {}
+
+// UnsafeGapFinderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GapFinderServer will
+// result in compilation errors.
+type UnsafeGapFinderServer interface {
+	mustEmbedUnimplementedGapFinderServer()
+}
+
+func RegisterGapFinderServer(s grpc.ServiceRegistrar, srv GapFinderServer) {
+	// If the following call pancis, it indicates UnimplementedGapFinderServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GapFinder_ServiceDesc, srv)
+}
+
+func _GapFinder_AnalyzeAbstract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyzeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GapFinderServer).AnalyzeAbstract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GapFinder_AnalyzeAbstract_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GapFinderServer).AnalyzeAbstract(ctx, req.(*AnalyzeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GapFinder_AnalyzeTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TopicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GapFinderServer).AnalyzeTopic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GapFinder_AnalyzeTopic_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GapFinderServer).AnalyzeTopic(ctx, req.(*TopicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GapFinder_AnalyzeTopicStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TopicRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GapFinderServer).AnalyzeTopicStream(m, &grpc.GenericServerStream[TopicRequest, TopicEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GapFinder_AnalyzeTopicStreamServer = grpc.ServerStreamingServer[TopicEvent]
+
+func _GapFinder_HealthCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GapFinderServer).HealthCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GapFinder_HealthCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GapFinderServer).HealthCheck(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GapFinder_ServiceDesc is the grpc.ServiceDesc for GapFinder service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GapFinder_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gapfinder.v1.GapFinder",
+	HandlerType: (*GapFinderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AnalyzeAbstract",
+			Handler:    _GapFinder_AnalyzeAbstract_Handler,
+		},
+		{
+			MethodName: "AnalyzeTopic",
+			Handler:    _GapFinder_AnalyzeTopic_Handler,
+		},
+		{
+			MethodName: "HealthCheck",
+			Handler:    _GapFinder_HealthCheck_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AnalyzeTopicStream",
+			Handler:       _GapFinder_AnalyzeTopicStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "gapfinder/v1/gapfinder.proto",
+}
@@ -0,0 +1,228 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/llm"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/server"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type stubPapers []server.Paper
+
+func (p stubPapers) SearchPapers(ctx context.Context, query string, maxResults int) ([]server.Paper, error) {
+	return p[:min(len(p), maxResults)], nil
+}
+
+const analyzeReply = `{"key_findings":["f"],"gaps":[{"gap_description":"g","confidence_score":0.9,"gap_type":"empirical","potential_impact":"i"}],
+	"limitations":[],"methodology_gaps":[],"suggested_hypotheses":[{"hypothesis":"h","rationale":"r","feasibility_score":0.5,"required_methods":null}],"future_directions":[]}`
+
+const topicReply = `{"common_gaps":[{"gap_description":"c","confidence_score":0.8,"gap_type":"theoretical","potential_impact":"i"}],
+	"individual_results":[{"paper_title":"P1","gaps":[]},{"paper_title":"P2","gaps":[]}],"suggested_research_directions":["d"]}`
+
+// newTestClient serves a gapfinder server using backend over an in-memory
+// connection and returns a client for it
+func newTestClient(t *testing.T, backend llm.Backend) *Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	papers := stubPapers{{Title: "P1", URL: "u1"}, {Title: "P2", URL: "u2"}}
+	NewServer(server.New(backend, server.WithPaperSource(papers))).Register(gs)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewClient(conn)
+}
+
+func reply(s string) llm.Backend {
+	return llm.BackendFunc(func(context.Context, string) (string, error) { return s, nil })
+}
+
+func TestAnalyzeAbstract(t *testing.T) {
+	c := newTestClient(t, reply(analyzeReply))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestIDHeader, "req-1")
+
+	result, err := c.AnalyzeAbstract(ctx, types.AnalyzeRequest{Title: "t", Abstract: "a"})
+	if err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	if len(result.Gaps) != 1 || result.Gaps[0].GapDescription != "g" || result.Gaps[0].ConfidenceScore != 0.9 {
+		t.Errorf("Gaps = %+v", result.Gaps)
+	}
+	if methods := result.SuggestedHypotheses[0].RequiredMethods; methods == nil || len(methods) != 0 {
+		t.Errorf("RequiredMethods = %#v, want empty slice", methods)
+	}
+	if result.RequestID != "req-1" {
+		t.Errorf("RequestID = %q, want %q", result.RequestID, "req-1")
+	}
+}
+
+func TestGapCitationsRoundTrip(t *testing.T) {
+	gaps := []types.ResearchGap{
+		{GapDescription: "g", SupportingCitations: []types.Citation{{Title: "P1", Authors: []string{}, URL: "u1", Reason: "r"}}},
+		{GapDescription: "f", ResearchGroups: []types.AuthorGroup{{Authors: []string{"A. Author"}, Papers: []string{}}}},
+		{GapDescription: "h"},
+	}
+	if got := gapsFromPB(gapsToPB(gaps)); !reflect.DeepEqual(got, gaps) {
+		t.Errorf("round trip = %+v, want %+v", got, gaps)
+	}
+}
+
+func TestRequestRoundTrip(t *testing.T) {
+	analyze := types.AnalyzeRequest{
+		Title: "t", Abstract: "a", Model: "gpt-4o-mini", Temperature: new(0.0),
+		Language: "de", TranslateOutput: true, MinConfidence: 0.5,
+		MaxGaps: 3, MaxHypotheses: 2, MaxFindings: 1, Instructions: "focus on reproducibility gaps",
+	}
+	if got := analyzeRequestFromPB(analyzeRequestToPB(analyze)); !reflect.DeepEqual(got, analyze) {
+		t.Errorf("AnalyzeRequest round trip = %+v, want %+v", got, analyze)
+	}
+	topic := types.TopicRequest{
+		Topic: "sleep", Model: "gpt-4o-mini", Temperature: new(0.3),
+		Language: "zh", TranslateOutput: true, MinConfidence: 0.7, MaxGaps: 5,
+		CrossFields: []types.Field{types.FieldBiology, types.FieldPhysics}, Surveys: types.SurveysExclude,
+	}
+	if got := topicRequestFromPB(topicRequestToPB(topic)); !reflect.DeepEqual(got, topic) {
+		t.Errorf("TopicRequest round trip = %+v, want %+v", got, topic)
+	}
+	if got := analyzeRequestFromPB(analyzeRequestToPB(types.AnalyzeRequest{})); got.Temperature != nil {
+		t.Errorf("Temperature = %v, want nil", *got.Temperature)
+	}
+}
+
+func TestPaperInfoRoundTrip(t *testing.T) {
+	resp := &types.AnalyzeResponse{
+		KeyFindings:         []string{},
+		Gaps:                []types.ResearchGap{},
+		SuggestedHypotheses: []types.Hypothesis{},
+		Limitations:         []string{},
+		MethodologyGaps:     []string{},
+		FutureDirections:    []string{},
+		Paper:               &types.PaperInfo{Source: "crossref", ID: "10.1/x", Title: "T", Authors: []string{"A"}, Abstract: "a", URL: "u"},
+	}
+	if got := analyzeResponseFromPB(analyzeResponseToPB(resp)); !reflect.DeepEqual(got, resp) {
+		t.Errorf("round trip = %+v, want %+v", got, resp)
+	}
+	resp.Paper = nil
+	if got := analyzeResponseFromPB(analyzeResponseToPB(resp)); got.Paper != nil {
+		t.Errorf("Paper = %+v, want nil", got.Paper)
+	}
+}
+
+func TestIntersectionGapsRoundTrip(t *testing.T) {
+	resp := &types.TopicResponse{
+		CommonGaps:                  []types.ResearchGap{},
+		IndividualResults:           []types.TopicAnalysisResult{},
+		SuggestedResearchDirections: []string{},
+		IntersectionGaps:            []types.ResearchGap{{GapDescription: "g"}},
+	}
+	if got := topicResponseFromPB(topicResponseToPB(resp)); !reflect.DeepEqual(got, resp) {
+		t.Errorf("round trip = %+v, want %+v", got, resp)
+	}
+	resp.IntersectionGaps = nil
+	if got := topicResponseFromPB(topicResponseToPB(resp)); got.IntersectionGaps != nil {
+		t.Errorf("IntersectionGaps = %+v, want nil", got.IntersectionGaps)
+	}
+}
+
+func TestAnalyzeTopicPage(t *testing.T) {
+	c := newTestClient(t, reply(topicReply))
+
+	result, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "sleep", PageSize: 1})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if len(result.IndividualResults) != 1 || result.NextCursor == "" {
+		t.Errorf("got %d results and cursor %q, want 1 result and a cursor", len(result.IndividualResults), result.NextCursor)
+	}
+}
+
+func TestAnalyzeTopicStream(t *testing.T) {
+	c := newTestClient(t, reply(topicReply))
+
+	var titles []string
+	result, err := c.AnalyzeTopicStream(context.Background(), types.TopicRequest{Topic: "sleep"}, func(r types.TopicAnalysisResult) error {
+		titles = append(titles, r.PaperTitle)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicStream() error = %v", err)
+	}
+	slices.Sort(titles)
+	if len(titles) != 2 || titles[0] != "P1" || titles[1] != "P2" {
+		t.Errorf("streamed results = %q, want P1 and P2", titles)
+	}
+	if result.Topic != "sleep" || result.PapersAnalyzed != 2 || len(result.CommonGaps) != 1 {
+		t.Errorf("summary = %+v", result)
+	}
+	if len(result.IndividualResults) != 2 || result.IndividualResults[0].URL == "" {
+		t.Errorf("IndividualResults = %+v", result.IndividualResults)
+	}
+	if result.RequestID == "" {
+		t.Error("RequestID is empty")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	failing := llm.BackendFunc(func(ctx context.Context, _ string) (string, error) {
+		return "", errors.New("secret backend failure")
+	})
+	c := newTestClient(t, failing)
+	ctx := context.Background()
+
+	_, err := c.AnalyzeAbstract(ctx, types.AnalyzeRequest{Title: "t", Abstract: "a"})
+	if status.Code(err) != codes.Internal || status.Convert(err).Message() != "An error occurred during analysis." {
+		t.Errorf("AnalyzeAbstract() error = %v, want generic Internal error", err)
+	}
+
+	// Requests that skip client-side validation are rejected by the server
+	_, err = c.pb.AnalyzeTopic(ctx, topicRequestToPB(types.TopicRequest{Topic: "t", MaxPapers: 500}))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("AnalyzeTopic() error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestDeadlinePropagates(t *testing.T) {
+	blocking := llm.BackendFunc(func(ctx context.Context, _ string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	c := newTestClient(t, blocking)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.AnalyzeAbstract(ctx, types.AnalyzeRequest{Title: "t", Abstract: "a"})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("AnalyzeAbstract() error = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	c := newTestClient(t, reply(""))
+	health, err := c.HealthCheck(context.Background())
+	if err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	if health.Status != "healthy" || health.Version != server.Version {
+		t.Errorf("HealthCheck() = %+v", health)
+	}
+}
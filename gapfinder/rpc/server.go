@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/rpc/gapfinderpb"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/server"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/internal/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDHeader carries the request ID in gRPC metadata, like the
+// X-Request-ID header of the HTTP API
+const requestIDHeader = "x-request-id"
+
+// Server serves the GapFinder gRPC service using a server.Server
+type Server struct {
+	gapfinderpb.UnimplementedGapFinderServer
+
+	srv    *server.Server
+	logger *slog.Logger
+}
+
+// ServerOption configures a Server
+type ServerOption func(*Server)
+
+// WithLogger makes the server log failed calls to logger. By default nothing
+// is logged.
+func WithLogger(logger *slog.Logger) ServerOption {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// NewServer creates a gRPC service backed by srv
+func NewServer(srv *server.Server, opts ...ServerOption) *Server {
+	s := &Server{srv: srv, logger: slog.New(slog.DiscardHandler)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register registers the service with gs
+func (s *Server) Register(gs grpc.ServiceRegistrar) {
+	gapfinderpb.RegisterGapFinderServer(gs, s)
+}
+
+func (s *Server) AnalyzeAbstract(ctx context.Context, req *gapfinderpb.AnalyzeRequest) (*gapfinderpb.AnalyzeResponse, error) {
+	ctx = s.tagRequest(ctx, grpc.SetHeader)
+	result, err := s.srv.Analyze(ctx, analyzeRequestFromPB(req))
+	if err != nil {
+		return nil, s.fail(ctx, err, "An error occurred during analysis.")
+	}
+	return analyzeResponseToPB(result), nil
+}
+
+func (s *Server) AnalyzeTopic(ctx context.Context, req *gapfinderpb.TopicRequest) (*gapfinderpb.TopicResponse, error) {
+	ctx = s.tagRequest(ctx, grpc.SetHeader)
+	result, err := s.srv.AnalyzeTopic(ctx, topicRequestFromPB(req))
+	if err != nil {
+		return nil, s.fail(ctx, err, "An error occurred during topic analysis.")
+	}
+	return topicResponseToPB(result), nil
+}
+
+func (s *Server) AnalyzeTopicStream(req *gapfinderpb.TopicRequest, stream gapfinderpb.GapFinder_AnalyzeTopicStreamServer) error {
+	ctx := s.tagRequest(stream.Context(), func(_ context.Context, md metadata.MD) error {
+		return stream.SetHeader(md)
+	})
+	result, err := s.srv.AnalyzeTopicStream(ctx, topicRequestFromPB(req), func(r types.TopicAnalysisResult) error {
+		return stream.Send(&gapfinderpb.TopicEvent{Event: &gapfinderpb.TopicEvent_Result{Result: topicResultToPB(r)}})
+	})
+	if err != nil {
+		return s.fail(ctx, err, "An error occurred during topic analysis.")
+	}
+	summary := topicResponseToPB(result)
+	summary.IndividualResults = nil
+	return stream.Send(&gapfinderpb.TopicEvent{Event: &gapfinderpb.TopicEvent_Summary{Summary: summary}})
+}
+
+func (s *Server) HealthCheck(ctx context.Context, req *gapfinderpb.HealthRequest) (*gapfinderpb.HealthResponse, error) {
+	return healthResponseToPB(s.srv.Health()), nil
+}
+
+type requestIDKey struct{}
+
+// tagRequest reuses the caller's request ID or creates one, sends it back
+// with setHeader and stores it in the returned context for logging
+func (s *Server) tagRequest(ctx context.Context, setHeader func(context.Context, metadata.MD) error) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDHeader); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		id = uuid.New()
+	}
+	_ = setHeader(ctx, metadata.Pairs(requestIDHeader, id))
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// fail converts err to a gRPC status. Invalid requests are reported to the
+// caller; other failures are logged and hidden behind msg.
+func (s *Server) fail(ctx context.Context, err error, msg string) error {
+	var verr *types.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return status.Error(codes.InvalidArgument, verr.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	s.logger.ErrorContext(ctx, "call failed", "request_id", ctx.Value(requestIDKey{}), "error", err)
+	return status.Error(codes.Internal, msg)
+}
@@ -0,0 +1,429 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/llm"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// defaultMaxPapers is used when a topic request doesn't set MaxPapers
+const defaultMaxPapers = 10
+
+// Analyze validates and analyzes a single abstract. It does the work of
+// POST /analyze, so the API can be served over other transports.
+func (s *Server) Analyze(ctx context.Context, req types.AnalyzeRequest) (*types.AnalyzeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, err := s.modelContext(ctx, req.Model, req.Temperature)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	result, err := s.analyzeText(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.MinConfidence > 0 {
+		result.FilterGaps(req.MinConfidence)
+	}
+	result.Truncate(req.MaxGaps, req.MaxHypotheses, req.MaxFindings)
+	result.ProcessingTime = elapsedSeconds(start)
+	s.recordAbstract(req.Title, req.Field, result)
+	return result, nil
+}
+
+// batchConcurrency is the number of abstracts of a batch analyzed at once
+const batchConcurrency = 5
+
+// AnalyzeBatch validates a batch and analyzes its abstracts concurrently.
+// Failed analyses are logged and reported in their item: validation errors,
+// such as for an unknown model, with their message and other errors with a
+// generic one. It does the work of POST /analyze/batch.
+func (s *Server) AnalyzeBatch(ctx context.Context, req types.BatchAnalyzeRequest) (*types.BatchAnalyzeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	results := make([]types.BatchItemResult, len(req.Requests))
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range req.Requests {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i].Index = i
+			result, err := s.Analyze(ctx, item)
+			var verr *types.ValidationError
+			if errors.As(err, &verr) {
+				results[i].Error = verr.Error()
+				return
+			}
+			if err != nil {
+				s.logger.ErrorContext(ctx, "batch item failed",
+					"index", i, "request_id", ctx.Value(requestIDKey{}), "error", err)
+				results[i].Error = "An error occurred during analysis."
+				return
+			}
+			results[i].Result = result
+		})
+	}
+	wg.Wait()
+	return &types.BatchAnalyzeResponse{Results: results, ProcessingTime: elapsedSeconds(start)}, nil
+}
+
+// AnalyzeTopic validates a topic request, then finds and analyzes papers on
+// the topic. If the request sets a PageSize, only the first page of
+// individual results is returned; TopicResults serves the rest. It does the
+// work of POST /topic.
+func (s *Server) AnalyzeTopic(ctx context.Context, req types.TopicRequest) (*types.TopicResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, err := s.modelContext(ctx, req.Model, req.Temperature)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	result, err := s.analyzeTopic(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.MinConfidence > 0 {
+		result.FilterGaps(req.MinConfidence)
+	}
+	result.TruncateGaps(req.MaxGaps)
+	all := result.IndividualResults
+	if req.PageSize > 0 {
+		result.IndividualResults, result.NextCursor = s.pages.paginate(result.IndividualResults, req.PageSize)
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	s.recordTopic(ctx, req.Field, result, all)
+	return result, nil
+}
+
+// Fields returns the response of GET /fields
+func (s *Server) Fields() *types.FieldsResponse {
+	return &types.FieldsResponse{Fields: types.Fields}
+}
+
+// Health returns the response of GET /health
+func (s *Server) Health() *types.HealthResponse {
+	return &types.HealthResponse{
+		Status:    "healthy",
+		Version:   Version,
+		Timestamp: strconv.FormatFloat(float64(time.Now().UnixMicro())/1e6, 'f', -1, 64),
+	}
+}
+
+// analyzeText analyzes a single abstract
+func (s *Server) analyzeText(ctx context.Context, req types.AnalyzeRequest) (*types.AnalyzeResponse, error) {
+	prompt, err := abstractPrompt(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.AnalyzeResponse
+	if err := s.complete(ctx, prompt, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// abstractPrompt returns the prompt analyzing an abstract sends, with the
+// sections of its full text that fit in ModeFullText
+func abstractPrompt(req types.AnalyzeRequest) (string, error) {
+	req.Field = cmp.Or(req.Field, types.FieldGeneral)
+	if req.Mode == types.ModeFullText {
+		req.FullText = selectSections(req.FullText, fullTextLimit)
+	} else {
+		req.FullText = ""
+	}
+	return render(gapAnalysisPrompt, req)
+}
+
+// analyzeTopic finds papers on a topic and analyzes them together
+func (s *Server) analyzeTopic(ctx context.Context, req types.TopicRequest) (*types.TopicResponse, error) {
+	req.Field = cmp.Or(req.Field, types.FieldGeneral)
+	req.MaxPapers = cmp.Or(req.MaxPapers, defaultMaxPapers)
+
+	papers, err := s.searchTopic(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(papers) == 0 {
+		s.logger.WarnContext(ctx, "no papers found", "topic", req.Topic)
+		return noPapersResponse(req.Topic), nil
+	}
+
+	var result types.TopicResponse
+	if err := s.completeTopic(ctx, req, papers, &result); err != nil {
+		return nil, err
+	}
+	result.IntersectionGaps = intersectionGaps(req, result.IntersectionGaps)
+	result.Topic = req.Topic
+	result.PapersAnalyzed = len(papers)
+	// The model only reports gaps; attach the paper metadata it was given
+	for i := range result.IndividualResults {
+		if i >= len(papers) {
+			break
+		}
+		r := &result.IndividualResults[i]
+		r.Authors = papers[i].Authors
+		r.Abstract = truncate(papers[i].Abstract, 500)
+		r.URL = papers[i].URL
+	}
+	return &result, nil
+}
+
+// AnalyzeTopicStream validates a topic request, then analyzes each paper on
+// the topic on its own and calls fn with its result as soon as the analysis
+// completes. The returned response holds the topic-wide gaps and all
+// results. Papers whose analysis failed are logged and left out. If fn
+// returns an error the analysis is abandoned and the error returned.
+//
+// Every paper gets its own LLM call, besides the one for the topic as a
+// whole, so results arrive early at the cost of more calls than
+// AnalyzeTopic.
+func (s *Server) AnalyzeTopicStream(ctx context.Context, req types.TopicRequest, fn func(types.TopicAnalysisResult) error) (*types.TopicResponse, error) {
+	return s.WatchTopic(ctx, req, func(p types.TopicProgress) error {
+		if p.Stage != types.ProgressDone {
+			return nil
+		}
+		return fn(*p.Result)
+	})
+}
+
+// WatchTopic analyzes a topic like AnalyzeTopicStream, but calls fn as each
+// paper is fetched, starts being analyzed, and is done or has failed. It does
+// the work of /topic/ws.
+func (s *Server) WatchTopic(ctx context.Context, req types.TopicRequest, fn func(types.TopicProgress) error) (*types.TopicResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, err := s.modelContext(ctx, req.Model, req.Temperature)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	req.Field = cmp.Or(req.Field, types.FieldGeneral)
+	req.MaxPapers = cmp.Or(req.MaxPapers, defaultMaxPapers)
+
+	papers, err := s.searchTopic(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(papers) == 0 {
+		s.logger.WarnContext(ctx, "no papers found", "topic", req.Topic)
+		result := noPapersResponse(req.Topic)
+		result.ProcessingTime = elapsedSeconds(start)
+		return result, nil
+	}
+	for i, paper := range papers {
+		err := fn(types.TopicProgress{PaperTitle: paper.Title, Index: i, Total: len(papers), Stage: types.ProgressFetched})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var summary types.TopicResponse
+	summaryErr := make(chan error, 1)
+	go func() {
+		summaryErr <- s.completeTopic(ctx, req, papers, &summary)
+	}()
+
+	// Each paper reports two stages; the buffer lets them finish even if
+	// the caller gives up early
+	updates := make(chan types.TopicProgress, 2*len(papers))
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i, paper := range papers {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			progress := types.TopicProgress{PaperTitle: paper.Title, Index: i, Total: len(papers), Stage: types.ProgressAnalyzing}
+			updates <- progress
+			analysis, err := s.analyzeText(ctx, types.AnalyzeRequest{
+				Title:    cmp.Or(paper.Title, "Unknown"),
+				Abstract: cmp.Or(paper.Abstract, "No abstract available"),
+				Field:    req.Field,
+				Authors:  paper.Authors,
+				// The papers are in English, but the analysis is in the
+				// caller's language if asked to
+				Language:        req.Language,
+				TranslateOutput: req.TranslateOutput,
+			})
+			if err != nil {
+				s.logger.ErrorContext(ctx, "paper analysis failed",
+					"title", paper.Title, "request_id", ctx.Value(requestIDKey{}), "error", err)
+				progress.Stage = types.ProgressFailed
+				updates <- progress
+				return
+			}
+			if req.MinConfidence > 0 {
+				analysis.FilterGaps(req.MinConfidence)
+			}
+			analysis.Truncate(req.MaxGaps, 0, 0)
+			progress.Stage = types.ProgressDone
+			progress.Result = &types.TopicAnalysisResult{
+				PaperTitle: paper.Title,
+				Authors:    paper.Authors,
+				Abstract:   truncate(paper.Abstract, 500),
+				Gaps:       analysis.Gaps,
+				URL:        paper.URL,
+			}
+			if !paper.Published.IsZero() {
+				progress.Result.Year = paper.Published.Year()
+			}
+			updates <- progress
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(updates)
+	}()
+
+	analyzed := []types.TopicAnalysisResult{}
+	for p := range updates {
+		if p.Stage == types.ProgressDone {
+			analyzed = append(analyzed, *p.Result)
+		}
+		if err := fn(p); err != nil {
+			return nil, err
+		}
+	}
+	if err := <-summaryErr; err != nil {
+		return nil, err
+	}
+	result := &types.TopicResponse{
+		Topic:                       req.Topic,
+		PapersAnalyzed:              len(papers),
+		CommonGaps:                  types.FilterGaps(summary.CommonGaps, req.MinConfidence),
+		IndividualResults:           analyzed,
+		SuggestedResearchDirections: summary.SuggestedResearchDirections,
+		ProcessingTime:              elapsedSeconds(start),
+	}
+	if len(req.CrossFields) > 0 {
+		result.IntersectionGaps = types.FilterGaps(summary.IntersectionGaps, req.MinConfidence)
+	}
+	result.TruncateGaps(req.MaxGaps)
+	return result, nil
+}
+
+// searchTopic finds up to req.MaxPapers papers on the topic. A cross-field
+// request shares them out between its fields, searching the topic in each,
+// so that every field's literature is represented.
+func (s *Server) searchTopic(ctx context.Context, req types.TopicRequest) ([]Paper, error) {
+	if len(req.CrossFields) == 0 {
+		return s.search(ctx, req.Topic, req.MaxPapers, req.Surveys)
+	}
+	var papers []Paper
+	seen := map[string]bool{}
+	for i, field := range req.CrossFields {
+		// Earlier fields get the papers left over by an uneven split
+		n := req.MaxPapers / len(req.CrossFields)
+		if i < req.MaxPapers%len(req.CrossFields) {
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		query := req.Topic
+		if field != types.FieldGeneral {
+			query += " " + strings.ReplaceAll(string(field), "_", " ")
+		}
+		found, err := s.search(ctx, query, n, req.Surveys)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range found {
+			// Papers spanning the fields are found by more than one search
+			key := strings.ToLower(cmp.Or(p.URL, p.Title))
+			if !seen[key] {
+				seen[key] = true
+				papers = append(papers, p)
+			}
+		}
+	}
+	return papers, nil
+}
+
+// intersectionGaps returns the intersection gaps the model reported, which
+// are nil unless the request is cross-field
+func intersectionGaps(req types.TopicRequest, gaps []types.ResearchGap) []types.ResearchGap {
+	if len(req.CrossFields) == 0 {
+		return nil
+	}
+	return nonNil(gaps)
+}
+
+// completeTopic asks the backend to analyze papers on a topic together
+func (s *Server) completeTopic(ctx context.Context, req types.TopicRequest, papers []Paper, out *types.TopicResponse) error {
+	prompt, err := topicPrompt(req, papers)
+	if err != nil {
+		return err
+	}
+	return s.complete(ctx, prompt, out)
+}
+
+// topicPrompt returns the prompt analyzing papers on a topic together
+func topicPrompt(req types.TopicRequest, papers []Paper) (string, error) {
+	// Surveys are marked for the model to keep them in the background
+	var surveys []bool
+	if req.Surveys == types.SurveysDownweight && slices.ContainsFunc(papers, isSurvey) {
+		for _, p := range papers {
+			surveys = append(surveys, isSurvey(p))
+		}
+	}
+	return render(topicAnalysisPrompt, struct {
+		Topic           string
+		Field           types.Field
+		CrossFields     []string
+		Papers          []Paper
+		Surveys         []bool
+		Language        string
+		TranslateOutput bool
+	}{req.Topic, req.Field, crossFieldNames(req.CrossFields), papers, surveys, req.Language, req.TranslateOutput})
+}
+
+// crossFieldNames returns the fields' names as written in a prompt
+func crossFieldNames(fields []types.Field) []string {
+	var names []string
+	for _, f := range fields {
+		names = append(names, strings.ReplaceAll(string(f), "_", " "))
+	}
+	return names
+}
+
+func noPapersResponse(topic string) *types.TopicResponse {
+	return &types.TopicResponse{
+		Topic:                       topic,
+		CommonGaps:                  []types.ResearchGap{},
+		IndividualResults:           []types.TopicAnalysisResult{},
+		SuggestedResearchDirections: []string{"No papers found for this topic. Try refining your search terms."},
+	}
+}
+
+// complete sends prompt to the backend and decodes its JSON reply into out
+func (s *Server) complete(ctx context.Context, prompt string, out any) error {
+	reply, err := s.backend.Complete(ctx, prompt)
+	if err != nil {
+		return fmt.Errorf("LLM request failed: %w", err)
+	}
+	if err := llm.DecodeJSON(reply, out); err != nil {
+		return fmt.Errorf("error parsing LLM reply: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/sources"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/sources/arxiv"
+)
+
+// Paper is a paper found for a topic
+type Paper struct {
+	Title     string
+	Authors   []string
+	Abstract  string
+	URL       string
+	Published time.Time // zero if the source doesn't say
+}
+
+// PaperSource finds papers on a topic
+type PaperSource interface {
+	SearchPapers(ctx context.Context, query string, maxResults int) ([]Paper, error)
+}
+
+// DatedPaperSource is a PaperSource that can restrict a search to papers
+// published in a range of years, so that the range doesn't have to be picked
+// out of the papers most relevant to the query
+type DatedPaperSource interface {
+	PaperSource
+	SearchPapersPublished(ctx context.Context, query string, fromYear, toYear, maxResults int) ([]Paper, error)
+}
+
+// ArxivResolver looks up papers by arXiv ID and downloads their PDFs. Both
+// return an error wrapping ErrPaperNotFound for unknown IDs.
+type ArxivResolver interface {
+	LookupArxiv(ctx context.Context, id string) (Paper, error)
+	FetchArxivPDF(ctx context.Context, id string) ([]byte, error)
+}
+
+const (
+	// DefaultArxivURL is the arXiv API query endpoint
+	DefaultArxivURL = arxiv.DefaultURL
+	// DefaultArxivPDFURL is where arXiv serves paper PDFs, by ID
+	DefaultArxivPDFURL = "https://arxiv.org/pdf"
+)
+
+// ArxivSource searches papers through the arXiv API
+type ArxivSource struct {
+	BaseURL    string // defaults to DefaultArxivURL
+	PDFURL     string // defaults to DefaultArxivPDFURL
+	HTTPClient *http.Client
+}
+
+// NewArxivSource returns a source for the public arXiv API. A nil hc means
+// http.DefaultClient.
+func NewArxivSource(hc *http.Client) *ArxivSource {
+	return &ArxivSource{BaseURL: DefaultArxivURL, PDFURL: DefaultArxivPDFURL, HTTPClient: hc}
+}
+
+// SearchPapers returns up to maxResults papers matching query, ordered by
+// relevance
+func (a *ArxivSource) SearchPapers(ctx context.Context, query string, maxResults int) ([]Paper, error) {
+	return a.search(ctx, "all:"+query, maxResults)
+}
+
+// SearchPapersPublished returns up to maxResults papers matching query and
+// submitted from fromYear to toYear, ordered by relevance
+func (a *ArxivSource) SearchPapersPublished(ctx context.Context, query string, fromYear, toYear, maxResults int) ([]Paper, error) {
+	return a.search(ctx, fmt.Sprintf("all:%s AND submittedDate:[%04d01010000 TO %04d12312359]", query, fromYear, toYear), maxResults)
+}
+
+// LookupArxiv returns the paper with an arXiv ID
+func (a *ArxivSource) LookupArxiv(ctx context.Context, id string) (Paper, error) {
+	p, err := a.client().Get(ctx, id)
+	if errors.Is(err, sources.ErrNotFound) {
+		return Paper{}, fmt.Errorf("arXiv paper %s: %w", id, ErrPaperNotFound)
+	}
+	if err != nil {
+		return Paper{}, err
+	}
+	return arxivPaper(p), nil
+}
+
+// FetchArxivPDF downloads the PDF of the paper with an arXiv ID
+func (a *ArxivSource) FetchArxivPDF(ctx context.Context, id string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cmp.Or(a.PDFURL, DefaultArxivPDFURL)+"/"+id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating arXiv request: %w", err)
+	}
+	resp, err := a.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading PDF from arXiv: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("arXiv PDF %s: %w", id, ErrPaperNotFound)
+	default:
+		return nil, fmt.Errorf("arXiv returned status %d for PDF %s", resp.StatusCode, id)
+	}
+	pdf, err := io.ReadAll(io.LimitReader(resp.Body, maxPDFSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error downloading PDF from arXiv: %w", err)
+	}
+	return pdf, nil
+}
+
+// search runs an arXiv search query
+func (a *ArxivSource) search(ctx context.Context, search string, maxResults int) ([]Paper, error) {
+	found, err := a.client().Search(ctx, arxiv.Query{Search: search, SortBy: arxiv.SortRelevance}, maxResults)
+	if err != nil {
+		return nil, err
+	}
+	papers := make([]Paper, len(found))
+	for i, p := range found {
+		papers[i] = arxivPaper(p)
+	}
+	return papers, nil
+}
+
+// client returns an arXiv client for the source's settings. Requests are
+// not spaced out, as each is made on behalf of a call to the service.
+func (a *ArxivSource) client() *arxiv.Client {
+	return arxiv.New(arxiv.WithBaseURL(cmp.Or(a.BaseURL, DefaultArxivURL)), arxiv.WithHTTPClient(a.httpClient()), arxiv.WithInterval(0))
+}
+
+func (a *ArxivSource) httpClient() *http.Client {
+	if a.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return a.HTTPClient
+}
+
+func arxivPaper(p sources.Paper) Paper {
+	return Paper{Title: p.Title, Authors: p.Authors, Abstract: p.Abstract, URL: p.URL, Published: p.Published}
+}
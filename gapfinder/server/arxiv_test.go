@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const arxivFeedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>http://arxiv.org/abs/2101.00001v1</id>
+    <title>Quantum Key
+      Distribution at Scale</title>
+    <summary>  We study QKD
+      networks.  </summary>
+    <published>2021-01-01T18:00:00Z</published>
+    <author><name>Alice</name></author>
+    <author><name>Bob</name></author>
+  </entry>
+</feed>`
+
+func TestArxivSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("search_query"); got != "all:quantum cryptography" {
+			t.Errorf("search_query = %q", got)
+		}
+		if got := r.URL.Query().Get("max_results"); got != "3" {
+			t.Errorf("max_results = %q", got)
+		}
+		w.Write([]byte(arxivFeedXML))
+	}))
+	defer srv.Close()
+
+	src := &ArxivSource{BaseURL: srv.URL}
+	papers, err := src.SearchPapers(context.Background(), "quantum cryptography", 3)
+	if err != nil {
+		t.Fatalf("SearchPapers() error = %v", err)
+	}
+	want := Paper{
+		Title:    "Quantum Key Distribution at Scale",
+		Abstract: "We study QKD networks.",
+		URL:      "http://arxiv.org/abs/2101.00001v1",
+	}
+	if len(papers) != 1 || papers[0].Title != want.Title || papers[0].Abstract != want.Abstract ||
+		papers[0].URL != want.URL || len(papers[0].Authors) != 2 || papers[0].Published.Year() != 2021 {
+		t.Errorf("papers = %+v", papers)
+	}
+}
+
+func TestArxivSourcePublished(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want := "all:quantum cryptography AND submittedDate:[201901010000 TO 202112312359]"
+		if got := r.URL.Query().Get("search_query"); got != want {
+			t.Errorf("search_query = %q, want %q", got, want)
+		}
+		w.Write([]byte(arxivFeedXML))
+	}))
+	defer srv.Close()
+
+	src := &ArxivSource{BaseURL: srv.URL}
+	papers, err := src.SearchPapersPublished(context.Background(), "quantum cryptography", 2019, 2021, 3)
+	if err != nil || len(papers) != 1 {
+		t.Errorf("SearchPapersPublished() = %+v, %v", papers, err)
+	}
+}
+
+func TestArxivSourceLookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/pdf/2101.00001v1":
+			w.Write([]byte("%PDF-1.4"))
+		case r.URL.Path == "/api/query" && r.URL.Query().Get("id_list") == "2101.00001v1":
+			w.Write([]byte(arxivFeedXML))
+		case r.URL.Path == "/api/query":
+			w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom"></feed>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	src := &ArxivSource{BaseURL: srv.URL + "/api/query", PDFURL: srv.URL + "/pdf"}
+	ctx := context.Background()
+	paper, err := src.LookupArxiv(ctx, "2101.00001v1")
+	if err != nil {
+		t.Fatalf("LookupArxiv() error = %v", err)
+	}
+	if paper.Title != "Quantum Key Distribution at Scale" || len(paper.Authors) != 2 {
+		t.Errorf("paper = %+v", paper)
+	}
+	if _, err := src.LookupArxiv(ctx, "2101.99999"); !errors.Is(err, ErrPaperNotFound) {
+		t.Errorf("LookupArxiv() error = %v, want ErrPaperNotFound", err)
+	}
+
+	pdf, err := src.FetchArxivPDF(ctx, "2101.00001v1")
+	if err != nil || string(pdf) != "%PDF-1.4" {
+		t.Errorf("FetchArxivPDF() = %q, %v", pdf, err)
+	}
+	if _, err := src.FetchArxivPDF(ctx, "2101.99999"); !errors.Is(err, ErrPaperNotFound) {
+		t.Errorf("FetchArxivPDF() error = %v, want ErrPaperNotFound", err)
+	}
+}
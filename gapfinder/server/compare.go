@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// ComparePapers validates a request and compares its two papers. Papers of
+// different fields are compared in general terms. It does the work of
+// POST /compare.
+func (s *Server) ComparePapers(ctx context.Context, req types.CompareRequest) (*types.ComparisonResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	field := types.FieldGeneral
+	if req.PaperA.Field == req.PaperB.Field && req.PaperA.Field != "" {
+		field = req.PaperA.Field
+	}
+	prompt, err := render(comparisonPrompt, struct {
+		Field          types.Field
+		PaperA, PaperB types.AnalyzeRequest
+	}{field, req.PaperA, req.PaperB})
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.ComparisonResponse
+	if err := s.complete(ctx, prompt, &result); err != nil {
+		return nil, err
+	}
+	// Keep the arrays the model left out as [] in the response
+	result.OverlappingFindings = nonNil(result.OverlappingFindings)
+	result.ConflictingConclusions = nonNil(result.ConflictingConclusions)
+	result.GapsUniqueToA = nonNil(result.GapsUniqueToA)
+	result.GapsUniqueToB = nonNil(result.GapsUniqueToB)
+	result.ProcessingTime = elapsedSeconds(start)
+	return &result, nil
+}
+
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	var req types.CompareRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.ComparePapers(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during comparison.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// nonNil returns s, or an empty slice if s is nil
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DOIResolver looks up the paper with a DOI. It returns an error wrapping
+// ErrPaperNotFound if there is none.
+type DOIResolver interface {
+	LookupDOI(ctx context.Context, doi string) (Paper, error)
+}
+
+// DefaultCrossRefURL is the CrossRef API works endpoint
+const DefaultCrossRefURL = "https://api.crossref.org/works"
+
+// CrossRefSource looks up papers through the CrossRef API
+type CrossRefSource struct {
+	BaseURL    string // defaults to DefaultCrossRefURL
+	HTTPClient *http.Client
+	// Mailto is a contact address sent with each request, which gets them
+	// into CrossRef's more reliable "polite" pool
+	Mailto string
+}
+
+// NewCrossRefSource returns a resolver for the public CrossRef API. A nil hc
+// means http.DefaultClient.
+func NewCrossRefSource(hc *http.Client) *CrossRefSource {
+	return &CrossRefSource{BaseURL: DefaultCrossRefURL, HTTPClient: hc}
+}
+
+type crossRefWork struct {
+	Message struct {
+		Title    []string `json:"title"`
+		Abstract string   `json:"abstract"`
+		URL      string   `json:"URL"`
+		Author   []struct {
+			Given  string `json:"given"`
+			Family string `json:"family"`
+		} `json:"author"`
+		Issued struct {
+			DateParts [][]int `json:"date-parts"` // year, month and day, as far as known
+		} `json:"issued"`
+	} `json:"message"`
+}
+
+// LookupDOI returns the paper with a DOI. Its abstract is empty if CrossRef
+// has none.
+func (c *CrossRefSource) LookupDOI(ctx context.Context, doi string) (Paper, error) {
+	u := cmp.Or(c.BaseURL, DefaultCrossRefURL) + "/" + url.PathEscape(doi)
+	// PathEscape escapes the slash every DOI has, which CrossRef expects as is
+	u = strings.ReplaceAll(u, "%2F", "/")
+	if c.Mailto != "" {
+		u += "?" + url.Values{"mailto": {c.Mailto}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Paper{}, fmt.Errorf("error creating CrossRef request: %w", err)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return Paper{}, fmt.Errorf("error fetching DOI from CrossRef: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return Paper{}, fmt.Errorf("DOI %s: %w", doi, ErrPaperNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Paper{}, fmt.Errorf("CrossRef API returned status %d", resp.StatusCode)
+	}
+
+	var work crossRefWork
+	if err := json.NewDecoder(resp.Body).Decode(&work); err != nil {
+		return Paper{}, fmt.Errorf("error parsing CrossRef response: %w", err)
+	}
+	p := Paper{
+		Abstract: stripJATS(work.Message.Abstract),
+		URL:      work.Message.URL,
+	}
+	if parts := work.Message.Issued.DateParts; len(parts) > 0 && len(parts[0]) > 0 && parts[0][0] > 0 {
+		p.Published = time.Date(parts[0][0], time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if len(work.Message.Title) > 0 {
+		p.Title = collapseSpace(work.Message.Title[0])
+	}
+	for _, author := range work.Message.Author {
+		if name := strings.TrimSpace(author.Given + " " + author.Family); name != "" {
+			p.Authors = append(p.Authors, name)
+		}
+	}
+	return p, nil
+}
+
+var (
+	jatsTitle              = regexp.MustCompile(`(?s)<jats:title>.*?</jats:title>`)
+	xmlTag                 = regexp.MustCompile(`<[^>]+>`)
+	spaceBeforePunctuation = regexp.MustCompile(`\s+([.,;:!?)])`)
+)
+
+// stripJATS returns the plain text of the JATS XML fragment CrossRef
+// abstracts are written in, dropping their "Abstract" heading
+func stripJATS(s string) string {
+	return stripXML(jatsTitle.ReplaceAllString(s, ""))
+}
+
+// collapseSpace joins the lines of a text field
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// stripXML returns the text of an XML fragment on a single line
+func stripXML(s string) string {
+	s = collapseSpace(html.UnescapeString(xmlTag.ReplaceAllString(s, " ")))
+	return spaceBeforePunctuation.ReplaceAllString(s, "$1")
+}
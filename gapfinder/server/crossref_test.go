@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+const crossRefWorkJSON = `{"status":"ok","message":{
+	"title":["Sleep and\n  memory"],
+	"abstract":"<jats:title>Abstract</jats:title><jats:p>Sleep &amp; memory are <jats:italic>linked</jats:italic>.</jats:p>",
+	"URL":"https://doi.org/10.1000/xyz",
+	"issued":{"date-parts":[[2019,3]]},
+	"author":[{"given":"Ada","family":"Lovelace"},{"family":"Hopper"}]}}`
+
+func TestCrossRefSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/works/10.1000/xyz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if got := r.URL.Query().Get("mailto"); got != "lab@example.org" {
+			t.Errorf("mailto = %q", got)
+		}
+		w.Write([]byte(crossRefWorkJSON))
+	}))
+	defer srv.Close()
+
+	src := &CrossRefSource{BaseURL: srv.URL + "/works", Mailto: "lab@example.org"}
+	paper, err := src.LookupDOI(context.Background(), "10.1000/xyz")
+	if err != nil {
+		t.Fatalf("LookupDOI() error = %v", err)
+	}
+	want := Paper{
+		Title:    "Sleep and memory",
+		Authors:  []string{"Ada Lovelace", "Hopper"},
+		Abstract: "Sleep & memory are linked.",
+		URL:      "https://doi.org/10.1000/xyz",
+	}
+	if paper.Title != want.Title || paper.Abstract != want.Abstract || paper.URL != want.URL ||
+		!slices.Equal(paper.Authors, want.Authors) || paper.Published.Year() != 2019 {
+		t.Errorf("paper = %+v, want %+v", paper, want)
+	}
+
+	if _, err := src.LookupDOI(context.Background(), "10.1000/missing"); !errors.Is(err, ErrPaperNotFound) {
+		t.Errorf("LookupDOI() error = %v, want ErrPaperNotFound", err)
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// Pricing is the price of a model's tokens in US dollars per million
+type Pricing struct {
+	Input  float64
+	Output float64
+}
+
+// DefaultPricing holds the list prices of OpenAI's models. Models missing
+// from it, such as those served by Ollama, are estimated without a price.
+var DefaultPricing = map[string]Pricing{
+	"gpt-4":         {Input: 30, Output: 60},
+	"gpt-4-turbo":   {Input: 10, Output: 30},
+	"gpt-4o":        {Input: 2.5, Output: 10},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.6},
+	"gpt-3.5-turbo": {Input: 0.5, Output: 1.5},
+}
+
+// WithPricing sets the prices cost estimates are made with, by model. By
+// default DefaultPricing is used.
+func WithPricing(prices map[string]Pricing) Option {
+	return func(s *Server) {
+		s.pricing = prices
+	}
+}
+
+// Rules of thumb for estimates, shared with the Python service: a token is
+// about four characters of English, the model writes a few hundred tokens
+// of summary plus the gaps of each paper, and generates them at about 40
+// tokens a second
+const (
+	charsPerToken        = 4
+	outputTokensBase     = 400
+	outputTokensPerPaper = 150
+	tokensPerSecond      = 40
+)
+
+// EstimateCost validates a topic request and estimates what analyzing it
+// would cost, without calling the backend. The papers are searched for, so
+// that the prompt the analysis would send can be measured, and the search
+// time is part of the expected duration. It does the work of POST
+// /topic/estimate.
+func (s *Server) EstimateCost(ctx context.Context, req types.TopicRequest) (*types.CostEstimate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.modelContext(ctx, req.Model, req.Temperature); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	req.Field = cmp.Or(req.Field, types.FieldGeneral)
+	req.MaxPapers = cmp.Or(req.MaxPapers, defaultMaxPapers)
+
+	papers, err := s.searchTopic(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	result := &types.CostEstimate{Model: cmp.Or(req.Model, s.Models().DefaultModel), Papers: len(papers)}
+	if len(papers) > 0 {
+		prompt, err := topicPrompt(req, papers)
+		if err != nil {
+			return nil, err
+		}
+		s.price(result, prompt)
+	}
+	result.DurationSeconds = elapsedSeconds(start) + math.Round(float64(result.OutputTokens)/tokensPerSecond*100)/100
+	result.ProcessingTime = elapsedSeconds(start)
+	return result, nil
+}
+
+// EstimateAnalysis validates an abstract request and estimates what
+// analyzing it would cost, without calling the backend. It does the work of
+// POST /analyze/estimate.
+func (s *Server) EstimateAnalysis(ctx context.Context, req types.AnalyzeRequest) (*types.CostEstimate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.modelContext(ctx, req.Model, req.Temperature); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	prompt, err := abstractPrompt(req)
+	if err != nil {
+		return nil, err
+	}
+	result := &types.CostEstimate{Model: cmp.Or(req.Model, s.Models().DefaultModel), Papers: 1}
+	s.price(result, prompt)
+	result.DurationSeconds = math.Round(float64(result.OutputTokens)/tokensPerSecond*100) / 100
+	result.ProcessingTime = elapsedSeconds(start)
+	return result, nil
+}
+
+// price sets the tokens of an analysis of result.Papers papers that sends
+// prompt in a single LLM call, and their cost if the model's price is known
+func (s *Server) price(result *types.CostEstimate, prompt string) {
+	result.LLMCalls = 1
+	result.InputTokens = (utf8.RuneCountInString(prompt) + charsPerToken - 1) / charsPerToken
+	result.OutputTokens = outputTokensBase + outputTokensPerPaper*result.Papers
+	if price, ok := s.pricing[result.Model]; ok {
+		cost := (float64(result.InputTokens)*price.Input + float64(result.OutputTokens)*price.Output) / 1e6
+		cost = math.Round(cost*1e4) / 1e4
+		result.CostUSD = &cost
+	}
+}
+
+func (s *Server) handleEstimateAnalysis(w http.ResponseWriter, r *http.Request) {
+	var req types.AnalyzeRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.EstimateAnalysis(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during cost estimation.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleEstimate(w http.ResponseWriter, r *http.Request) {
+	var req types.TopicRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.EstimateCost(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during cost estimation.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
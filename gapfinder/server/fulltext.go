@@ -0,0 +1,48 @@
+package server
+
+import (
+	"regexp"
+	"strings"
+)
+
+// fullTextLimit is how many characters of a paper's full text are sent to
+// the model in full_text mode
+const fullTextLimit = 24000
+
+// sectionHeading matches a line holding a section heading of a paper,
+// optionally numbered like "3." or "IV"
+var sectionHeading = regexp.MustCompile(`(?im)^[ \t]*(?:[0-9IVX]+(?:\.[0-9]+)*\.?[ \t]+)?` +
+	`(abstract|introduction|background|related work|materials and methods|methods|methodology` +
+	`|experimental setup|experiments|results|results and discussion|discussion|limitations` +
+	`|conclusions?|references|acknowledgements?|acknowledgments?|appendix)[ \t]*:?[ \t]*$`)
+
+// analyzedSections are the sections kept by selectSections, where most
+// methodological gaps show
+var analyzedSections = map[string]bool{
+	"materials and methods": true, "methods": true, "methodology": true, "experimental setup": true,
+	"experiments": true, "results": true, "results and discussion": true, "discussion": true,
+	"limitations": true,
+}
+
+// selectSections returns the methods, results and discussion sections of a
+// paper's text, or the beginning of the text if it has no such headings,
+// shortened to at most limit runes
+func selectSections(text string, limit int) string {
+	headings := sectionHeading.FindAllStringSubmatchIndex(text, -1)
+	var sections []string
+	for i, h := range headings {
+		if !analyzedSections[strings.ToLower(text[h[2]:h[3]])] {
+			continue
+		}
+		end := len(text)
+		if i+1 < len(headings) {
+			end = headings[i+1][0]
+		}
+		sections = append(sections, strings.TrimSpace(text[h[0]:end]))
+	}
+	selected := strings.Join(sections, "\n\n")
+	if selected == "" {
+		selected = strings.TrimSpace(text)
+	}
+	return truncate(selected, limit)
+}
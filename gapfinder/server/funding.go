@@ -0,0 +1,376 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// FundingSource lists open funding calls
+type FundingSource interface {
+	OpenCalls(ctx context.Context) ([]types.FundingCall, error)
+}
+
+// FundingFeed is a feed of one agency's funding calls: an RSS feed, or the
+// search API of the EU Funding & Tenders portal, whose URLs have a path under
+// /search-api/
+type FundingFeed struct {
+	Agency string
+	URL    string
+}
+
+// DefaultEUFundingURL is the search API of the EU Funding & Tenders portal,
+// which has no RSS feed of its calls. SEDIA is the portal's public API key.
+const DefaultEUFundingURL = "https://api.tech.ec.europa.eu/search-api/prod/rest/search?apiKey=SEDIA&text=***&pageSize=100&pageNumber=1"
+
+// DefaultFundingFeeds are the funding opportunity feeds of NSF, NIH and the
+// EU Funding & Tenders portal
+var DefaultFundingFeeds = []FundingFeed{
+	{Agency: "NSF", URL: "https://www.nsf.gov/rss/rss_www_funding_pgm_annc_inf.xml"},
+	{Agency: "NIH", URL: "https://grants.nih.gov/grants/guide/newsfeed/fundingopps.xml"},
+	{Agency: "EU", URL: DefaultEUFundingURL},
+}
+
+// FeedFundingSource reads open funding calls from RSS feeds
+type FeedFundingSource struct {
+	Feeds      []FundingFeed // defaults to DefaultFundingFeeds
+	HTTPClient *http.Client
+}
+
+// NewFeedFundingSource returns a source reading feeds, or
+// DefaultFundingFeeds if there are none. A nil hc means http.DefaultClient.
+func NewFeedFundingSource(hc *http.Client, feeds ...FundingFeed) *FeedFundingSource {
+	return &FeedFundingSource{Feeds: feeds, HTTPClient: hc}
+}
+
+type rssFeed struct {
+	Items []struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+	} `xml:"channel>item"`
+}
+
+// OpenCalls returns the calls of every feed. Agencies' feeds go down
+// independently, so a feed that fails is skipped unless they all do.
+func (f *FeedFundingSource) OpenCalls(ctx context.Context) ([]types.FundingCall, error) {
+	feeds := f.Feeds
+	if len(feeds) == 0 {
+		feeds = DefaultFundingFeeds
+	}
+	var calls []types.FundingCall
+	var errs []error
+	for _, feed := range feeds {
+		found, err := f.read(ctx, feed)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		calls = append(calls, found...)
+	}
+	if len(errs) == len(feeds) {
+		return nil, errors.Join(errs...)
+	}
+	return calls, nil
+}
+
+// read returns the calls of one feed
+func (f *FeedFundingSource) read(ctx context.Context, feed FundingFeed) ([]types.FundingCall, error) {
+	if isEUPortal(feed.URL) {
+		return f.readEUPortal(ctx, feed)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feed.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s feed request: %w", feed.Agency, err)
+	}
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s funding feed: %w", feed.Agency, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s funding feed returned status %d", feed.Agency, resp.StatusCode)
+	}
+
+	var rss rssFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
+		return nil, fmt.Errorf("error parsing %s funding feed: %w", feed.Agency, err)
+	}
+	calls := make([]types.FundingCall, 0, len(rss.Items))
+	for _, item := range rss.Items {
+		call := types.FundingCall{
+			Agency:  feed.Agency,
+			Title:   collapseSpace(item.Title),
+			URL:     strings.TrimSpace(item.Link),
+			Summary: stripXML(item.Description),
+		}
+		if call.Title != "" {
+			calls = append(calls, call)
+		}
+	}
+	return calls, nil
+}
+
+// euPortalQuery asks the EU Funding & Tenders portal's search API for the
+// calls for proposals that are open or forthcoming, leaving out its closed
+// calls, tenders and news
+const euPortalQuery = `{"bool":{"must":[{"terms":{"type":["1","2","8"]}},{"terms":{"status":["31094501","31094502"]}}]}}`
+
+// euTopicURL is where the portal shows a call, by its lowercased identifier
+const euTopicURL = "https://ec.europa.eu/info/funding-tenders/opportunities/portal/screen/opportunities/topic-details/"
+
+type euPortalResults struct {
+	Results []struct {
+		URL      string `json:"url"`
+		Summary  string `json:"summary"`
+		Metadata struct {
+			Identifier  []string `json:"identifier"`
+			Title       []string `json:"title"`
+			Description []string `json:"descriptionByte"`
+		} `json:"metadata"`
+	} `json:"results"`
+}
+
+// isEUPortal tells whether a feed URL is that of the EU Funding & Tenders
+// portal's search API
+func isEUPortal(feedURL string) bool {
+	u, err := url.Parse(feedURL)
+	return err == nil && strings.Contains(u.Path, "/search-api/")
+}
+
+// readEUPortal returns the open calls of the EU Funding & Tenders portal,
+// searched for with a multipart POST as the portal's own pages do
+func (f *FeedFundingSource) readEUPortal(ctx context.Context, feed FundingFeed) ([]types.FundingCall, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, field := range []struct{ name, value string }{
+		{"query", euPortalQuery},
+		{"languages", `["en"]`},
+	} {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Disposition": {fmt.Sprintf(`form-data; name="%s"; filename="blob"`, field.name)},
+			"Content-Type":        {"application/json"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		part.Write([]byte(field.value))
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, feed.URL, &body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s feed request: %w", feed.Agency, err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s funding feed: %w", feed.Agency, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s funding feed returned status %d", feed.Agency, resp.StatusCode)
+	}
+
+	var results euPortalResults
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("error parsing %s funding feed: %w", feed.Agency, err)
+	}
+	calls := make([]types.FundingCall, 0, len(results.Results))
+	for _, r := range results.Results {
+		m := r.Metadata
+		call := types.FundingCall{
+			Agency: feed.Agency,
+			Title:  collapseSpace(cmp.Or(first(m.Title), r.Summary)),
+			URL:    strings.TrimSpace(r.URL),
+		}
+		if id := strings.TrimSpace(first(m.Identifier)); id != "" {
+			call.URL = euTopicURL + strings.ToLower(id)
+		}
+		call.Summary = stripXML(first(m.Description))
+		if call.Title != "" {
+			calls = append(calls, call)
+		}
+	}
+	return calls, nil
+}
+
+// first returns the first of values, which the portal gives every metadata
+// field as, or ""
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (f *FeedFundingSource) client() *http.Client {
+	if f.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return f.HTTPClient
+}
+
+// WithFundingSource sets where /gaps/funding finds open calls. By default
+// DefaultFundingFeeds are read.
+func WithFundingSource(funding FundingSource) Option {
+	return func(s *Server) {
+		s.funding = funding
+	}
+}
+
+const (
+	// defaultMaxMatches is used when a funding request doesn't set
+	// MaxMatches
+	defaultMaxMatches = 10
+	// maxFundingCandidates is the number of calls shown to the model, out
+	// of the often hundreds of open ones
+	maxFundingCandidates = 40
+)
+
+// MatchFunding validates a request and matches its gaps with open funding
+// calls. The calls sharing the most words with the gaps are shown to the
+// model, which picks those that would fund them; calls and gaps it invents
+// are dropped. It does the work of POST /gaps/funding.
+func (s *Server) MatchFunding(ctx context.Context, req types.FundingRequest) (*types.FundingResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	req.MaxMatches = cmp.Or(req.MaxMatches, defaultMaxMatches)
+	calls, err := s.funding.OpenCalls(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &types.FundingResponse{Matches: []types.FundingMatch{}, CallsSearched: len(calls)}
+	candidates := candidateCalls(calls, req.Gaps, maxFundingCandidates)
+	if len(candidates) == 0 {
+		s.logger.WarnContext(ctx, "no candidate funding calls found", "calls", len(calls))
+		result.ProcessingTime = elapsedSeconds(start)
+		return result, nil
+	}
+	prompt, err := render(fundingPrompt, struct {
+		Field      types.Field
+		Gaps       []types.ResearchGap
+		Calls      []types.FundingCall
+		MaxMatches int
+	}{cmp.Or(req.Field, types.FieldGeneral), req.Gaps, candidates, req.MaxMatches})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply struct {
+		Matches []struct {
+			Call           int     `json:"call"`
+			Gaps           []int   `json:"gaps"`
+			RelevanceScore float64 `json:"relevance_score"`
+			Reason         string  `json:"reason"`
+		} `json:"matches"`
+	}
+	if err := s.complete(ctx, prompt, &reply); err != nil {
+		return nil, err
+	}
+	seen := map[int]bool{}
+	for _, m := range reply.Matches {
+		if m.Call < 1 || m.Call > len(candidates) || seen[m.Call] {
+			continue
+		}
+		gaps := []int{}
+		for _, g := range m.Gaps {
+			if g >= 1 && g <= len(req.Gaps) && !slices.Contains(gaps, g-1) {
+				gaps = append(gaps, g-1)
+			}
+		}
+		if len(gaps) == 0 {
+			continue
+		}
+		seen[m.Call] = true
+		slices.Sort(gaps)
+		result.Matches = append(result.Matches, types.FundingMatch{
+			Call:           candidates[m.Call-1],
+			Gaps:           gaps,
+			RelevanceScore: min(max(m.RelevanceScore, 0), 1),
+			Reason:         m.Reason,
+		})
+	}
+	slices.SortStableFunc(result.Matches, func(a, b types.FundingMatch) int {
+		return cmp.Compare(b.RelevanceScore, a.RelevanceScore)
+	})
+	if len(result.Matches) > req.MaxMatches {
+		result.Matches = result.Matches[:req.MaxMatches]
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	return result, nil
+}
+
+// candidateCalls returns up to n of calls sharing words with the gaps'
+// descriptions, those sharing the most first
+func candidateCalls(calls []types.FundingCall, gaps []types.ResearchGap, n int) []types.FundingCall {
+	gapWords := map[string]bool{}
+	for _, g := range gaps {
+		for _, w := range significantWords(g.GapDescription) {
+			gapWords[w] = true
+		}
+	}
+	type scored struct {
+		call  types.FundingCall
+		score int
+	}
+	var found []scored
+	for _, c := range calls {
+		words := significantWords(c.Title + " " + c.Summary)
+		slices.Sort(words)
+		score := 0
+		for _, w := range slices.Compact(words) {
+			if gapWords[w] {
+				score++
+			}
+		}
+		if score > 0 {
+			found = append(found, scored{c, score})
+		}
+	}
+	slices.SortStableFunc(found, func(a, b scored) int { return cmp.Compare(b.score, a.score) })
+	candidates := []types.FundingCall{}
+	for _, f := range found[:min(n, len(found))] {
+		candidates = append(candidates, f.call)
+	}
+	return candidates
+}
+
+// significantWords returns the lowercased words of s long enough to carry
+// meaning, leaving out "and", "the" and the like
+func significantWords(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return slices.DeleteFunc(words, func(w string) bool { return len(w) < 4 })
+}
+
+func (s *Server) handleFunding(w http.ResponseWriter, r *http.Request) {
+	var req types.FundingRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.MatchFunding(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during funding matching.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+const fundingFeedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Funding Opportunities</title>
+    <item>
+      <title>Sleep and
+        Circadian Research</title>
+      <link> https://grants.example.org/rfa-1 </link>
+      <description>&lt;p&gt;Supports studies of &lt;b&gt;sleep&lt;/b&gt; .&lt;/p&gt;</description>
+    </item>
+    <item>
+      <title> </title>
+    </item>
+  </channel>
+</rss>`
+
+func TestEUFundingFeed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Query().Get("apiKey") != "SEDIA" {
+			t.Errorf("request = %s %s", r.Method, r.URL)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("ParseMultipartForm() error = %v", err)
+		}
+		if q := r.MultipartForm.File["query"]; len(q) != 1 || q[0].Header.Get("Content-Type") != "application/json" {
+			t.Errorf("query part = %+v", q)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[
+			{"url":"https://ec.europa.eu/x.json","summary":"HORIZON-HLTH-2025-01",
+			 "metadata":{"identifier":["HORIZON-HLTH-2025-01"],"title":["Sleep and  health"],"descriptionByte":["<p>Sleep &amp; ageing.</p>"]}},
+			{"url":"https://ec.europa.eu/y.json","summary":"Brain research","metadata":{}},
+			{"metadata":{}}]}`))
+	}))
+	defer srv.Close()
+
+	src := NewFeedFundingSource(nil, FundingFeed{"EU", srv.URL + "/search-api/prod/rest/search?apiKey=SEDIA"})
+	calls, err := src.OpenCalls(context.Background())
+	if err != nil {
+		t.Fatalf("OpenCalls() error = %v", err)
+	}
+	want := []types.FundingCall{
+		{Agency: "EU", Title: "Sleep and health", URL: euTopicURL + "horizon-hlth-2025-01", Summary: "Sleep & ageing."},
+		{Agency: "EU", Title: "Brain research", URL: "https://ec.europa.eu/y.json"},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %+v, want %+v", calls, want)
+	}
+}
+
+func TestFeedFundingSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nih" {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(fundingFeedXML))
+	}))
+	defer srv.Close()
+
+	src := NewFeedFundingSource(nil, FundingFeed{"NSF", srv.URL + "/nsf"}, FundingFeed{"NIH", srv.URL + "/nih"})
+	calls, err := src.OpenCalls(context.Background())
+	if err != nil {
+		t.Fatalf("OpenCalls() error = %v", err)
+	}
+	want := []types.FundingCall{{
+		Agency:  "NIH",
+		Title:   "Sleep and Circadian Research",
+		URL:     "https://grants.example.org/rfa-1",
+		Summary: "Supports studies of sleep.",
+	}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %+v, want %+v", calls, want)
+	}
+
+	src = NewFeedFundingSource(nil, FundingFeed{"NSF", srv.URL + "/nsf"})
+	if _, err := src.OpenCalls(context.Background()); err == nil {
+		t.Error("OpenCalls() with every feed down succeeded")
+	}
+}
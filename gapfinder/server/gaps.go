@@ -0,0 +1,505 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// DeduplicateGaps validates a request and clusters its gaps that describe
+// the same problem. Every gap of the request ends up in exactly one cluster:
+// members the model invents or repeats are dropped, and gaps it leaves out
+// become clusters of their own. A cluster is as confident as its most
+// confident member. It does the work of POST /gaps/deduplicate.
+func (s *Server) DeduplicateGaps(ctx context.Context, req types.DeduplicateRequest) (*types.DeduplicateResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	prompt, err := render(deduplicationPrompt, req.Analyses)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.DeduplicateResponse
+	if err := s.complete(ctx, prompt, &result); err != nil {
+		return nil, err
+	}
+	result.Clusters = normalizeClusters(req.Analyses, result.Clusters)
+	result.ProcessingTime = elapsedSeconds(start)
+	return &result, nil
+}
+
+// normalizeClusters makes the model's clusters cover every gap of analyses
+// exactly once
+func normalizeClusters(analyses [][]types.ResearchGap, clusters []types.GapCluster) []types.GapCluster {
+	seen := map[types.GapRef]bool{}
+	gap := func(ref types.GapRef) types.ResearchGap { return analyses[ref.Analysis][ref.Gap] }
+
+	out := []types.GapCluster{}
+	for _, c := range clusters {
+		var members []types.GapRef
+		for _, ref := range c.Members {
+			valid := ref.Analysis >= 0 && ref.Analysis < len(analyses) && ref.Gap >= 0 && ref.Gap < len(analyses[ref.Analysis])
+			if valid && !seen[ref] {
+				seen[ref] = true
+				members = append(members, ref)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		first := gap(members[0])
+		c.Gap.GapDescription = cmp.Or(c.Gap.GapDescription, first.GapDescription)
+		c.Gap.GapType = cmp.Or(c.Gap.GapType, first.GapType)
+		c.Gap.PotentialImpact = cmp.Or(c.Gap.PotentialImpact, first.PotentialImpact)
+		c.Gap.ConfidenceScore = 0
+		for _, ref := range members {
+			c.Gap.ConfidenceScore = max(c.Gap.ConfidenceScore, gap(ref).ConfidenceScore)
+		}
+		out = append(out, types.GapCluster{Gap: c.Gap, Members: members})
+	}
+	for i, gaps := range analyses {
+		for j, g := range gaps {
+			if ref := (types.GapRef{Analysis: i, Gap: j}); !seen[ref] {
+				out = append(out, types.GapCluster{Gap: g, Members: []types.GapRef{ref}})
+			}
+		}
+	}
+	return out
+}
+
+// GenerateHypotheses validates a request and proposes hypotheses for its
+// gaps. It does the work of POST /hypotheses.
+func (s *Server) GenerateHypotheses(ctx context.Context, req types.HypothesesRequest) (*types.HypothesesResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	prompt, err := render(hypothesisPrompt, struct {
+		Field types.Field
+		Gaps  []types.ResearchGap
+	}{cmp.Or(req.Field, types.FieldGeneral), req.Gaps})
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.HypothesesResponse
+	if err := s.complete(ctx, prompt, &result); err != nil {
+		return nil, err
+	}
+	result.Hypotheses = nonNil(result.Hypotheses)
+	result.ProcessingTime = elapsedSeconds(start)
+	return &result, nil
+}
+
+// RecommendMethods validates a request and expands its hypothesis' required
+// methods into experimental designs, with sample-size guidance, and
+// candidate datasets. Designs and datasets the model leaves unnamed are
+// dropped, as are dataset URLs that aren't web addresses. It does the work
+// of POST /hypotheses/methods.
+func (s *Server) RecommendMethods(ctx context.Context, req types.MethodsRequest) (*types.MethodsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	prompt, err := render(methodsPrompt, struct {
+		Field      types.Field
+		Hypothesis types.Hypothesis
+	}{cmp.Or(req.Field, types.FieldGeneral), req.Hypothesis})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply types.MethodsResponse
+	if err := s.complete(ctx, prompt, &reply); err != nil {
+		return nil, err
+	}
+	result := &types.MethodsResponse{Designs: []types.ExperimentalDesign{}, Datasets: []types.Dataset{}}
+	for _, d := range reply.Designs {
+		if strings.TrimSpace(d.Name) == "" {
+			continue
+		}
+		d.Methods = nonNil(d.Methods)
+		d.Limitations = nonNil(d.Limitations)
+		result.Designs = append(result.Designs, d)
+	}
+	for _, d := range reply.Datasets {
+		if strings.TrimSpace(d.Name) == "" {
+			continue
+		}
+		if !strings.HasPrefix(d.URL, "https://") && !strings.HasPrefix(d.URL, "http://") {
+			d.URL = ""
+		}
+		result.Datasets = append(result.Datasets, d)
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	return result, nil
+}
+
+// defaultMaxDatasets is used when a datasets request doesn't set MaxDatasets
+const defaultMaxDatasets = 5
+
+// SuggestDatasets validates a request and suggests public datasets to
+// investigate its gap or test its hypothesis with. A dataset is only
+// suggested with a web address to get it from, and at most once. It does the
+// work of POST /gaps/datasets.
+func (s *Server) SuggestDatasets(ctx context.Context, req types.DatasetsRequest) (*types.DatasetsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	req.MaxDatasets = cmp.Or(req.MaxDatasets, defaultMaxDatasets)
+	req.Field = cmp.Or(req.Field, types.FieldGeneral)
+	prompt, err := render(datasetsPrompt, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply types.DatasetsResponse
+	if err := s.complete(ctx, prompt, &reply); err != nil {
+		return nil, err
+	}
+	result := &types.DatasetsResponse{Datasets: []types.Dataset{}}
+	seen := map[string]bool{}
+	for _, d := range reply.Datasets {
+		name := strings.ToLower(strings.TrimSpace(d.Name))
+		if name == "" || seen[name] || !strings.HasPrefix(d.URL, "https://") && !strings.HasPrefix(d.URL, "http://") {
+			continue
+		}
+		seen[name] = true
+		result.Datasets = append(result.Datasets, d)
+	}
+	if len(result.Datasets) > req.MaxDatasets {
+		result.Datasets = result.Datasets[:req.MaxDatasets]
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	return result, nil
+}
+
+// defaultMaxCitations is used when a citations request doesn't set
+// MaxCitations
+const defaultMaxCitations = 5
+
+// SuggestCitations validates a request and suggests papers to cite about its
+// gap. Papers are searched for with the gap's description and the model
+// chooses among them, so every suggestion is a paper that exists. It does
+// the work of POST /gaps/citations.
+func (s *Server) SuggestCitations(ctx context.Context, req types.CitationsRequest) (*types.CitationsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	req.MaxCitations = cmp.Or(req.MaxCitations, defaultMaxCitations)
+	papers, err := s.papers.SearchPapers(ctx, req.Gap.GapDescription, 2*req.MaxCitations)
+	if err != nil {
+		return nil, err
+	}
+	if len(papers) == 0 {
+		s.logger.WarnContext(ctx, "no candidate papers found", "gap", req.Gap.GapDescription)
+		return &types.CitationsResponse{Citations: []types.Citation{}, ProcessingTime: elapsedSeconds(start)}, nil
+	}
+	prompt, err := render(citationPrompt, struct {
+		Field        types.Field
+		Gap          string
+		Papers       []Paper
+		MaxCitations int
+	}{cmp.Or(req.Field, types.FieldGeneral), req.Gap.GapDescription, papers, req.MaxCitations})
+	if err != nil {
+		return nil, err
+	}
+
+	var chosen struct {
+		Citations []struct {
+			Paper  int    `json:"paper"`
+			Reason string `json:"reason"`
+		} `json:"citations"`
+	}
+	if err := s.complete(ctx, prompt, &chosen); err != nil {
+		return nil, err
+	}
+	result := types.CitationsResponse{Citations: []types.Citation{}}
+	seen := map[int]bool{}
+	for _, c := range chosen.Citations {
+		if c.Paper < 1 || c.Paper > len(papers) || seen[c.Paper] || len(result.Citations) == req.MaxCitations {
+			continue
+		}
+		seen[c.Paper] = true
+		paper := papers[c.Paper-1]
+		result.Citations = append(result.Citations, types.Citation{
+			Title:   paper.Title,
+			Authors: nonNil(paper.Authors),
+			URL:     paper.URL,
+			Reason:  c.Reason,
+		})
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	return &result, nil
+}
+
+// defaultRelatedPapers is used when a related work request doesn't set
+// MaxPapers
+const defaultRelatedPapers = 5
+
+// GetRelatedWork validates a request and returns the existing papers closest
+// to its gap. Papers are searched for with the gap's description and the
+// model scores how close each is and whether it already addresses the gap,
+// so every paper returned exists. It does the work of POST /gaps/related.
+func (s *Server) GetRelatedWork(ctx context.Context, req types.RelatedWorkRequest) (*types.RelatedWorkResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	req.MaxPapers = cmp.Or(req.MaxPapers, defaultRelatedPapers)
+	papers, err := s.papers.SearchPapers(ctx, req.Gap.GapDescription, 2*req.MaxPapers)
+	if err != nil {
+		return nil, err
+	}
+	if len(papers) == 0 {
+		s.logger.WarnContext(ctx, "no related papers found", "gap", req.Gap.GapDescription)
+		return &types.RelatedWorkResponse{Papers: []types.RelatedPaper{}, ProcessingTime: elapsedSeconds(start)}, nil
+	}
+	prompt, err := render(relatedWorkPrompt, struct {
+		Field     types.Field
+		Gap       string
+		Papers    []Paper
+		MaxPapers int
+	}{cmp.Or(req.Field, types.FieldGeneral), req.Gap.GapDescription, papers, req.MaxPapers})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply struct {
+		Papers []struct {
+			Paper        int     `json:"paper"`
+			Similarity   float64 `json:"similarity"`
+			AddressesGap bool    `json:"addresses_gap"`
+			Reason       string  `json:"reason"`
+		} `json:"papers"`
+	}
+	if err := s.complete(ctx, prompt, &reply); err != nil {
+		return nil, err
+	}
+	result := types.RelatedWorkResponse{Papers: []types.RelatedPaper{}}
+	seen := map[int]bool{}
+	for _, p := range reply.Papers {
+		if p.Paper < 1 || p.Paper > len(papers) || seen[p.Paper] {
+			continue
+		}
+		seen[p.Paper] = true
+		paper := papers[p.Paper-1]
+		related := types.RelatedPaper{
+			Title:        paper.Title,
+			Authors:      nonNil(paper.Authors),
+			URL:          paper.URL,
+			Similarity:   min(max(p.Similarity, 0), 1),
+			AddressesGap: p.AddressesGap,
+			Reason:       p.Reason,
+		}
+		if !paper.Published.IsZero() {
+			related.Year = paper.Published.Year()
+		}
+		result.Papers = append(result.Papers, related)
+	}
+	slices.SortStableFunc(result.Papers, func(a, b types.RelatedPaper) int { return cmp.Compare(b.Similarity, a.Similarity) })
+	if len(result.Papers) > req.MaxPapers {
+		result.Papers = result.Papers[:req.MaxPapers]
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	return &result, nil
+}
+
+// FindResearchGroups validates a request and asks the model which papers
+// work near each of its common gaps. The authors of those papers are
+// grouped by co-authorship: authors of a shared paper, directly or through
+// other co-authors, form one group. Papers without authors are left out. It
+// does the work of POST /gaps/groups.
+func (s *Server) FindResearchGroups(ctx context.Context, req types.ResearchGroupsRequest) (*types.ResearchGroupsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	prompt, err := render(groupsPrompt, struct {
+		types.ResearchGroupsRequest
+		Field types.Field
+	}{req, cmp.Or(req.Field, types.FieldGeneral)})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply struct {
+		Gaps []struct {
+			Gap    int   `json:"gap"`
+			Papers []int `json:"papers"`
+		} `json:"gaps"`
+	}
+	if err := s.complete(ctx, prompt, &reply); err != nil {
+		return nil, err
+	}
+	near := make([][]int, len(req.CommonGaps))
+	for _, g := range reply.Gaps {
+		if g.Gap < 1 || g.Gap > len(req.CommonGaps) {
+			continue
+		}
+		for _, n := range g.Papers {
+			if n >= 1 && n <= len(req.Papers) && !slices.Contains(near[g.Gap-1], n-1) {
+				near[g.Gap-1] = append(near[g.Gap-1], n-1)
+			}
+		}
+	}
+	result := types.ResearchGroupsResponse{CommonGaps: slices.Clone(req.CommonGaps)}
+	for i := range result.CommonGaps {
+		slices.Sort(near[i])
+		result.CommonGaps[i].ResearchGroups = authorGroups(req.Papers, near[i])
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	return &result, nil
+}
+
+// authorGroups groups the authors of the papers at indexes by
+// co-authorship, the group with the most papers first
+func authorGroups(papers []types.TopicAnalysisResult, indexes []int) []types.AuthorGroup {
+	groupOf := map[string]int{}
+	var groups []*types.AuthorGroup
+	for _, i := range indexes {
+		paper := papers[i]
+		var authors []string
+		for _, a := range paper.Authors {
+			if a = strings.TrimSpace(a); a != "" && !slices.Contains(authors, a) {
+				authors = append(authors, a)
+			}
+		}
+		if len(authors) == 0 {
+			continue
+		}
+		// Merge the groups of the paper's authors, oldest first, with the
+		// paper and its new authors
+		var joined []int
+		for _, a := range authors {
+			if g, ok := groupOf[a]; ok && !slices.Contains(joined, g) {
+				joined = append(joined, g)
+			}
+		}
+		slices.Sort(joined)
+		merged := &types.AuthorGroup{Authors: []string{}, Papers: []string{}}
+		for _, g := range joined {
+			merged.Authors = append(merged.Authors, groups[g].Authors...)
+			merged.Papers = append(merged.Papers, groups[g].Papers...)
+			groups[g] = nil
+		}
+		for _, a := range authors {
+			if !slices.Contains(merged.Authors, a) {
+				merged.Authors = append(merged.Authors, a)
+			}
+		}
+		merged.Papers = append(merged.Papers, paper.PaperTitle)
+		groups = append(groups, merged)
+		for _, a := range merged.Authors {
+			groupOf[a] = len(groups) - 1
+		}
+	}
+	result := []types.AuthorGroup{}
+	for _, g := range groups {
+		if g != nil {
+			result = append(result, *g)
+		}
+	}
+	slices.SortStableFunc(result, func(a, b types.AuthorGroup) int { return cmp.Compare(len(b.Papers), len(a.Papers)) })
+	return result
+}
+
+func (s *Server) handleDeduplicate(w http.ResponseWriter, r *http.Request) {
+	var req types.DeduplicateRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.DeduplicateGaps(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during deduplication.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleHypotheses(w http.ResponseWriter, r *http.Request) {
+	var req types.HypothesesRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.GenerateHypotheses(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during hypothesis generation.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleMethods(w http.ResponseWriter, r *http.Request) {
+	var req types.MethodsRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.RecommendMethods(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during methodology recommendation.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleDatasets(w http.ResponseWriter, r *http.Request) {
+	var req types.DatasetsRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.SuggestDatasets(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during dataset suggestion.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleGroups(w http.ResponseWriter, r *http.Request) {
+	var req types.ResearchGroupsRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.FindResearchGroups(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred while finding research groups.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleRelatedWork(w http.ResponseWriter, r *http.Request) {
+	var req types.RelatedWorkRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.GetRelatedWork(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during related work retrieval.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleCitations(w http.ResponseWriter, r *http.Request) {
+	var req types.CitationsRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.SuggestCitations(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during citation suggestion.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
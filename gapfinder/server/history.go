@@ -0,0 +1,212 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/internal/uuid"
+)
+
+// The latest maxAnalyses analyses are kept. ListAnalyses returns
+// defaultAnalysesLimit of them unless asked for another number.
+const (
+	maxAnalyses          = 1000
+	defaultAnalysesLimit = 50
+)
+
+// history keeps the results of abstract and topic analyses in memory, so
+// they can be fetched again without re-running them
+type history struct {
+	mu       sync.Mutex
+	analyses []*types.Analysis // oldest first
+	jobs     map[string]string // IDs of the jobs analyses ran in, by analysis ID
+}
+
+// add keeps an analysis that ran in job, if not empty, forgetting the oldest
+// once there are too many
+func (h *history) add(a types.Analysis, job string) {
+	a.AnalysisID = uuid.New()
+	a.CreatedAt = time.Now().UTC()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.analyses) >= maxAnalyses {
+		for _, old := range h.analyses[:len(h.analyses)-maxAnalyses+1] {
+			delete(h.jobs, old.AnalysisID)
+		}
+		h.analyses = slices.Delete(h.analyses, 0, len(h.analyses)-maxAnalyses+1)
+	}
+	h.analyses = append(h.analyses, &a)
+	if job != "" {
+		if h.jobs == nil {
+			h.jobs = make(map[string]string)
+		}
+		h.jobs[a.AnalysisID] = job
+	}
+}
+
+// list returns the summaries of the analyses matching q, newest first
+func (h *history) list(q types.AnalysesQuery) []types.AnalysisSummary {
+	topic := strings.ToLower(q.Topic)
+	summaries := []types.AnalysisSummary{}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, a := range slices.Backward(h.analyses) {
+		if len(summaries) == q.Limit {
+			break
+		}
+		if topic != "" && !strings.Contains(strings.ToLower(a.Title), topic) ||
+			q.Field != "" && a.Field != q.Field ||
+			!q.Since.IsZero() && a.CreatedAt.Before(q.Since) ||
+			!q.Until.IsZero() && !a.CreatedAt.Before(q.Until) {
+			continue
+		}
+		summaries = append(summaries, a.Summary())
+	}
+	return summaries
+}
+
+// get returns a kept analysis
+func (h *history) get(id string) (*types.Analysis, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, a := range h.analyses {
+		if a.AnalysisID == id {
+			found := *a
+			return &found, true
+		}
+	}
+	return nil, false
+}
+
+// delete forgets a kept analysis, reporting whether it was kept and the ID
+// of the job it ran in, if any
+func (h *history) delete(id string) (job string, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := slices.IndexFunc(h.analyses, func(a *types.Analysis) bool { return a.AnalysisID == id })
+	if i < 0 {
+		return "", false
+	}
+	h.analyses = slices.Delete(h.analyses, i, i+1)
+	job = h.jobs[id]
+	delete(h.jobs, id)
+	return job, true
+}
+
+// recordAbstract keeps the analysis of an abstract
+func (s *Server) recordAbstract(title string, field types.Field, result *types.AnalyzeResponse) {
+	stored := *result
+	s.history.add(types.Analysis{
+		Kind:   types.AnalysisAbstract,
+		Title:  title,
+		Field:  cmp.Or(field, types.FieldGeneral),
+		Result: &stored,
+	}, "")
+}
+
+// recordTopic keeps the analysis of a topic with all its individual
+// results, even if only the first page of them was returned, along with the
+// job it ran in, if ctx is a job's
+func (s *Server) recordTopic(ctx context.Context, field types.Field, result *types.TopicResponse, all []types.TopicAnalysisResult) {
+	stored := *result
+	stored.IndividualResults, stored.NextCursor = all, ""
+	job, _ := ctx.Value(jobIDKey{}).(string)
+	s.history.add(types.Analysis{
+		Kind:        types.AnalysisTopic,
+		Title:       result.Topic,
+		Field:       cmp.Or(field, types.FieldGeneral),
+		TopicResult: &stored,
+	}, job)
+}
+
+// ListAnalyses returns the analyses kept that match q, newest first. It does
+// the work of GET /analyses.
+func (s *Server) ListAnalyses(q types.AnalysesQuery) (*types.AnalysesResponse, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	q.Limit = cmp.Or(q.Limit, defaultAnalysesLimit)
+	return &types.AnalysesResponse{Analyses: s.history.list(q)}, nil
+}
+
+// Analysis returns a kept analysis with its result, or false if it is unknown
+// or was forgotten. It does the work of GET /analyses/{analysis_id}.
+func (s *Server) Analysis(id string) (*types.Analysis, bool) {
+	return s.history.get(id)
+}
+
+// DeleteAnalysis forgets a kept analysis and its result, along with the
+// background job it ran in, or returns false if it is unknown or was already
+// forgotten. Pages of a paged topic result still expire on their own, after
+// an hour. It does the work of DELETE /analyses/{analysis_id}.
+func (s *Server) DeleteAnalysis(id string) bool {
+	job, ok := s.history.delete(id)
+	if job != "" {
+		s.jobs.delete(job)
+	}
+	return ok
+}
+
+func (s *Server) handleListAnalyses(w http.ResponseWriter, r *http.Request) {
+	q, err := analysesQuery(r.URL.Query())
+	if err != nil {
+		s.fail(w, r, err, "An error occurred while listing analyses.")
+		return
+	}
+	result, err := s.ListAnalyses(q)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred while listing analyses.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// analysesQuery parses the query parameters of GET /analyses
+func analysesQuery(v url.Values) (types.AnalysesQuery, error) {
+	q := types.AnalysesQuery{Topic: v.Get("topic"), Field: types.Field(v.Get("field"))}
+	for name, t := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if s := v.Get(name); s != "" {
+			parsed, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return q, &types.ValidationError{Field: name, Message: "must be an RFC 3339 date-time"}
+			}
+			*t = parsed
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit == 0 {
+			return q, &types.ValidationError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", types.MaxAnalysesLimit)}
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
+
+func (s *Server) handleAnalysis(w http.ResponseWriter, r *http.Request) {
+	a, ok := s.Analysis(r.PathValue("analysis_id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Analysis not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+func (s *Server) handleDeleteAnalysis(w http.ResponseWriter, r *http.Request) {
+	if !s.DeleteAnalysis(r.PathValue("analysis_id")) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Analysis not found"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
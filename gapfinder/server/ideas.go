@@ -0,0 +1,222 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// defaultNoveltyPapers is used when a novelty request doesn't set MaxPapers
+const defaultNoveltyPapers = 10
+
+// CheckNovelty validates a request, then searches for papers related to its
+// idea and asks the model whether they already address it. A status the
+// model gets wrong is derived from its novelty score, and supporting papers
+// are always papers the search found. It does the work of POST
+// /ideas/novelty.
+func (s *Server) CheckNovelty(ctx context.Context, req types.NoveltyRequest) (*types.NoveltyResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	papers, err := s.papers.SearchPapers(ctx, req.Idea, cmp.Or(req.MaxPapers, defaultNoveltyPapers))
+	if err != nil {
+		return nil, err
+	}
+	if len(papers) == 0 {
+		s.logger.WarnContext(ctx, "no related papers found", "idea", req.Idea)
+		return &types.NoveltyResponse{
+			Status:           types.NoveltyOpen,
+			NoveltyScore:     1,
+			Summary:          "No related papers were found.",
+			SupportingPapers: []types.Citation{},
+			ProcessingTime:   elapsedSeconds(start),
+		}, nil
+	}
+	prompt, err := render(noveltyPrompt, struct {
+		Field  types.Field
+		Idea   string
+		Papers []Paper
+	}{cmp.Or(req.Field, types.FieldGeneral), req.Idea, papers})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply struct {
+		Status       string  `json:"status"`
+		NoveltyScore float64 `json:"novelty_score"`
+		Summary      string  `json:"summary"`
+		Papers       []struct {
+			Paper  int    `json:"paper"`
+			Reason string `json:"reason"`
+		} `json:"papers"`
+	}
+	if err := s.complete(ctx, prompt, &reply); err != nil {
+		return nil, err
+	}
+	result := &types.NoveltyResponse{
+		Status:           reply.Status,
+		NoveltyScore:     min(max(reply.NoveltyScore, 0), 1),
+		Summary:          reply.Summary,
+		SupportingPapers: []types.Citation{},
+		PapersSearched:   len(papers),
+	}
+	if !slices.Contains([]string{types.NoveltyAddressed, types.NoveltyPartiallyAddressed, types.NoveltyOpen}, result.Status) {
+		result.Status = noveltyStatus(result.NoveltyScore)
+	}
+	seen := map[int]bool{}
+	for _, p := range reply.Papers {
+		if p.Paper < 1 || p.Paper > len(papers) || seen[p.Paper] {
+			continue
+		}
+		seen[p.Paper] = true
+		paper := papers[p.Paper-1]
+		result.SupportingPapers = append(result.SupportingPapers, types.Citation{
+			Title:   paper.Title,
+			Authors: nonNil(paper.Authors),
+			URL:     paper.URL,
+			Reason:  p.Reason,
+		})
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	return result, nil
+}
+
+// noveltyStatus is the status of an idea with a novelty score, splitting the
+// scores in thirds
+func noveltyStatus(score float64) string {
+	switch {
+	case score < 1.0/3:
+		return types.NoveltyAddressed
+	case score < 2.0/3:
+		return types.NoveltyPartiallyAddressed
+	default:
+		return types.NoveltyOpen
+	}
+}
+
+// Defaults of a ProposalRequest
+const (
+	defaultProposalPapers = 10
+	// maxProposalQuery is the longest default search query, in characters,
+	// taken from a proposal's first line
+	maxProposalQuery = 200
+)
+
+// AnalyzeProposal validates a request, then searches for papers related to
+// its proposal and asks the model how well the proposal addresses the known
+// gaps, the request's or else those the papers raise, and which of its
+// claims of novelty the papers contradict. A conflict is only reported with
+// papers the search found. It does the work of POST /ideas/proposal.
+func (s *Server) AnalyzeProposal(ctx context.Context, req types.ProposalRequest) (*types.ProposalResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	query := strings.TrimSpace(req.Topic)
+	if query == "" {
+		line, _, _ := strings.Cut(strings.TrimSpace(req.Proposal), "\n")
+		query = truncate(strings.TrimSpace(line), maxProposalQuery)
+	}
+	papers, err := s.papers.SearchPapers(ctx, query, cmp.Or(req.MaxPapers, defaultProposalPapers))
+	if err != nil {
+		return nil, err
+	}
+	result := &types.ProposalResponse{
+		Gaps:             []types.GapAlignment{},
+		NoveltyConflicts: []types.NoveltyConflict{},
+		PapersSearched:   len(papers),
+	}
+	if len(papers) == 0 && len(req.Gaps) == 0 {
+		s.logger.WarnContext(ctx, "no related papers or known gaps", "query", query)
+		result.Summary = "No related papers were found, and no known gaps were given."
+		result.ProcessingTime = elapsedSeconds(start)
+		return result, nil
+	}
+	prompt, err := render(proposalPrompt, struct {
+		Field    types.Field
+		Proposal string
+		Gaps     []types.ResearchGap
+		Papers   []Paper
+	}{cmp.Or(req.Field, types.FieldGeneral), req.Proposal, req.Gaps, papers})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply struct {
+		AlignmentScore   float64              `json:"alignment_score"`
+		Gaps             []types.GapAlignment `json:"gaps"`
+		NoveltyConflicts []struct {
+			Claim  string `json:"claim"`
+			Papers []struct {
+				Paper  int    `json:"paper"`
+				Reason string `json:"reason"`
+			} `json:"papers"`
+		} `json:"novelty_conflicts"`
+		Summary string `json:"summary"`
+	}
+	if err := s.complete(ctx, prompt, &reply); err != nil {
+		return nil, err
+	}
+	result.AlignmentScore = min(max(reply.AlignmentScore, 0), 1)
+	result.Summary = reply.Summary
+	for _, g := range reply.Gaps {
+		if strings.TrimSpace(g.GapDescription) != "" {
+			g.Coverage = min(max(g.Coverage, 0), 1)
+			result.Gaps = append(result.Gaps, g)
+		}
+	}
+	for _, c := range reply.NoveltyConflicts {
+		conflict := types.NoveltyConflict{Claim: c.Claim, Papers: []types.Citation{}}
+		seen := map[int]bool{}
+		for _, p := range c.Papers {
+			if p.Paper < 1 || p.Paper > len(papers) || seen[p.Paper] {
+				continue
+			}
+			seen[p.Paper] = true
+			paper := papers[p.Paper-1]
+			conflict.Papers = append(conflict.Papers, types.Citation{
+				Title:   paper.Title,
+				Authors: nonNil(paper.Authors),
+				URL:     paper.URL,
+				Reason:  p.Reason,
+			})
+		}
+		if strings.TrimSpace(c.Claim) != "" && len(conflict.Papers) > 0 {
+			result.NoveltyConflicts = append(result.NoveltyConflicts, conflict)
+		}
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	return result, nil
+}
+
+func (s *Server) handleNovelty(w http.ResponseWriter, r *http.Request) {
+	var req types.NoveltyRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.CheckNovelty(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during novelty checking.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleProposal(w http.ResponseWriter, r *http.Request) {
+	var req types.ProposalRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.AnalyzeProposal(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during proposal analysis.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
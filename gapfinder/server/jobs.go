@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/internal/uuid"
+)
+
+// Finished jobs are forgotten after jobTTL, and the oldest finished jobs
+// once there are more than maxJobs
+const (
+	jobTTL  = time.Hour
+	maxJobs = 1000
+)
+
+// ErrTooManyJobs is returned when a job is submitted while the server is
+// already running as many as it keeps
+var ErrTooManyJobs = errors.New("too many jobs are running, try again later")
+
+// jobIDKey is the context key of the ID of the job an analysis runs in
+type jobIDKey struct{}
+
+// jobStore runs topic analyses in the background and keeps their results in
+// memory
+type jobStore struct {
+	mu      sync.Mutex
+	max     int
+	jobs    map[string]*types.Job
+	order   []string                      // job IDs, oldest first
+	cancels map[string]context.CancelFunc // of the jobs still running
+}
+
+func newJobStore(max int) *jobStore {
+	return &jobStore{max: max, jobs: make(map[string]*types.Job), cancels: make(map[string]context.CancelFunc)}
+}
+
+// submit starts run in the background with a context derived from ctx,
+// which is canceled if the job is, and returns a snapshot of its job. done,
+// if not nil, is called with the finished job.
+func (st *jobStore) submit(ctx context.Context, run func(context.Context) (*types.TopicResponse, error), done func(types.Job)) (types.Job, error) {
+	now := time.Now().UTC()
+	job := &types.Job{JobID: uuid.New(), Status: types.JobPending, CreatedAt: now, UpdatedAt: now}
+
+	st.mu.Lock()
+	if !st.evict(now) {
+		st.mu.Unlock()
+		return types.Job{}, ErrTooManyJobs
+	}
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, jobIDKey{}, job.JobID))
+	st.jobs[job.JobID] = job
+	st.order = append(st.order, job.JobID)
+	st.cancels[job.JobID] = cancel
+	snapshot := *job
+	st.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		st.update(job.JobID, func(j *types.Job) {
+			if j.Status == types.JobPending {
+				j.Status = types.JobRunning
+			}
+		})
+		result, err := run(ctx)
+		st.update(job.JobID, func(j *types.Job) {
+			delete(st.cancels, j.JobID)
+			switch {
+			case j.Status == types.JobCancelled:
+				return
+			case err != nil:
+				j.Status = types.JobFailed
+				j.Error = "An error occurred during topic analysis."
+			default:
+				j.Status = types.JobSucceeded
+				j.Result = result
+			}
+		})
+		if done != nil {
+			if finished, ok := st.get(job.JobID); ok {
+				done(finished)
+			}
+		}
+	}()
+	return snapshot, nil
+}
+
+// get returns a snapshot of a job
+func (st *jobStore) get(id string) (types.Job, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	job, ok := st.jobs[id]
+	if !ok {
+		return types.Job{}, false
+	}
+	return *job, true
+}
+
+// cancel stops a job that is still running and returns a snapshot of it.
+// Finished jobs are returned as they are.
+func (st *jobStore) cancel(id string) (types.Job, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	job, ok := st.jobs[id]
+	if !ok {
+		return types.Job{}, false
+	}
+	if cancel, running := st.cancels[id]; running {
+		cancel()
+		delete(st.cancels, id)
+		job.Status = types.JobCancelled
+		job.UpdatedAt = time.Now().UTC()
+	}
+	return *job, true
+}
+
+// delete forgets a job, stopping it if it is still running
+func (st *jobStore) delete(id string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if cancel, running := st.cancels[id]; running {
+		cancel()
+		delete(st.cancels, id)
+	}
+	delete(st.jobs, id)
+	st.order = slices.DeleteFunc(st.order, func(o string) bool { return o == id })
+}
+
+func (st *jobStore) update(id string, fn func(*types.Job)) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if job, ok := st.jobs[id]; ok {
+		fn(job)
+		job.UpdatedAt = time.Now().UTC()
+	}
+}
+
+// evict forgets expired jobs and, if the store is full, the oldest finished
+// ones to make room for a new job. It reports false if every job kept is
+// still running. The caller must hold st.mu.
+func (st *jobStore) evict(now time.Time) bool {
+	excess := len(st.order) - st.max + 1
+	kept := st.order[:0]
+	for _, id := range st.order {
+		job := st.jobs[id]
+		if job.Done() && (excess > 0 || now.Sub(job.UpdatedAt) > jobTTL) {
+			delete(st.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	st.order = kept
+	return len(st.order) < st.max
+}
+
+// JobOption configures a job submitted with SubmitTopic
+type JobOption func(*jobConfig)
+
+type jobConfig struct {
+	callbackURL string
+}
+
+// WithCallback makes the finished job be POSTed to url, signed as described
+// by package webhook. It needs a secret set with WithWebhooks.
+func WithCallback(url string) JobOption {
+	return func(jc *jobConfig) {
+		jc.callbackURL = url
+	}
+}
+
+// SubmitTopic validates a topic request and starts analyzing it in the
+// background. It does the work of POST /topic/jobs.
+func (s *Server) SubmitTopic(ctx context.Context, req types.TopicRequest, opts ...JobOption) (*types.Job, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.modelContext(ctx, req.Model, req.Temperature); err != nil {
+		return nil, err
+	}
+	var jc jobConfig
+	for _, opt := range opts {
+		opt(&jc)
+	}
+	if jc.callbackURL != "" {
+		if err := s.checkCallback(jc.callbackURL); err != nil {
+			return nil, err
+		}
+	}
+	// The job outlives the request but keeps its values, like the request ID
+	ctx = context.WithoutCancel(ctx)
+	var done func(types.Job)
+	if jc.callbackURL != "" {
+		done = func(job types.Job) { s.sendCallback(ctx, jc.callbackURL, job) }
+	}
+	job, err := s.jobs.submit(ctx, func(ctx context.Context) (*types.TopicResponse, error) {
+		result, err := s.AnalyzeTopic(ctx, req)
+		if err != nil && ctx.Err() == nil {
+			s.logger.ErrorContext(ctx, "job failed", "request_id", ctx.Value(requestIDKey{}), "error", err)
+		}
+		return result, err
+	}, done)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Job returns the state of a background job, or false if it is unknown or
+// expired. It does the work of GET /jobs/{job_id}.
+func (s *Server) Job(id string) (*types.Job, bool) {
+	job, ok := s.jobs.get(id)
+	return &job, ok
+}
+
+// CancelJob stops a background job that is still running, so that it makes
+// no further LLM calls, and returns it. Finished jobs are returned as they
+// are; unknown or expired ones report false. It does the work of POST
+// /jobs/{job_id}/cancel.
+func (s *Server) CancelJob(id string) (*types.Job, bool) {
+	job, ok := s.jobs.cancel(id)
+	return &job, ok
+}
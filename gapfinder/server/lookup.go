@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// ErrPaperNotFound is wrapped by the errors of lookups of unknown papers
+var ErrPaperNotFound = errors.New("paper not found")
+
+// MissingAbstractError reports a paper whose metadata has no abstract to
+// analyze
+type MissingAbstractError struct {
+	Message string
+}
+
+func (e *MissingAbstractError) Error() string {
+	return e.Message
+}
+
+// WithDOIResolver sets where /analyze/doi looks up papers. By default the
+// CrossRef API is queried.
+func WithDOIResolver(r DOIResolver) Option {
+	return func(s *Server) {
+		s.dois = r
+	}
+}
+
+// AnalyzeDOI looks up the paper with a DOI and analyzes its abstract. The
+// response's Paper holds the metadata found. It does the work of
+// POST /analyze/doi.
+func (s *Server) AnalyzeDOI(ctx context.Context, req types.DOIRequest) (*types.AnalyzeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	doi := types.NormalizeDOI(req.DOI)
+	start := time.Now()
+	paper, err := s.dois.LookupDOI(ctx, doi)
+	if err != nil {
+		return nil, err
+	}
+	if paper.Abstract == "" {
+		return nil, &MissingAbstractError{"CrossRef has no abstract for DOI " + doi}
+	}
+	result, err := s.analyzePaper(ctx, paper, req.Field, "")
+	if err != nil {
+		return nil, err
+	}
+	result.Paper = paperInfo("crossref", doi, paper)
+	result.ProcessingTime = elapsedSeconds(start)
+	s.recordAbstract(paper.Title, req.Field, result)
+	return result, nil
+}
+
+// WithPMIDResolver sets where /analyze/pmid looks up papers. By default NCBI
+// E-utilities are queried.
+func WithPMIDResolver(r PMIDResolver) Option {
+	return func(s *Server) {
+		s.pmids = r
+	}
+}
+
+// AnalyzePMID looks up the paper with a PubMed ID and analyzes its abstract.
+// The response's Paper holds the metadata found. It does the work of
+// POST /analyze/pmid.
+func (s *Server) AnalyzePMID(ctx context.Context, req types.PMIDRequest) (*types.AnalyzeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	pmid := types.NormalizePMID(req.PMID)
+	start := time.Now()
+	paper, err := s.pmids.LookupPMID(ctx, pmid)
+	if err != nil {
+		return nil, err
+	}
+	if paper.Abstract == "" {
+		return nil, &MissingAbstractError{"PubMed has no abstract for PMID " + pmid}
+	}
+	result, err := s.analyzePaper(ctx, paper, req.Field, "")
+	if err != nil {
+		return nil, err
+	}
+	result.Paper = paperInfo("pubmed", pmid, paper)
+	result.ProcessingTime = elapsedSeconds(start)
+	s.recordAbstract(paper.Title, req.Field, result)
+	return result, nil
+}
+
+// WithArxivResolver sets where /analyze/arxiv looks up papers and their
+// PDFs. By default the arXiv API is queried.
+func WithArxivResolver(r ArxivResolver) Option {
+	return func(s *Server) {
+		s.arxiv = r
+	}
+}
+
+// AnalyzeArxiv looks up the paper with an arXiv ID and analyzes its
+// abstract. With req.FullText set its PDF is downloaded and analyzed in
+// ModeFullText, which needs a PDFExtractor. The response's Paper holds the
+// metadata found. It does the work of POST /analyze/arxiv.
+func (s *Server) AnalyzeArxiv(ctx context.Context, req types.ArxivRequest) (*types.AnalyzeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.FullText && s.pdf == nil {
+		return nil, ErrPDFUnsupported
+	}
+	id := types.NormalizeArxivID(req.ID)
+	start := time.Now()
+	paper, err := s.arxiv.LookupArxiv(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if paper.Abstract == "" {
+		return nil, &MissingAbstractError{"arXiv has no abstract for " + id}
+	}
+
+	var fullText string
+	if req.FullText {
+		pdf, err := s.arxiv.FetchArxivPDF(ctx, id)
+		if err != nil {
+			s.logger.WarnContext(ctx, "arXiv PDF download failed",
+				"arxiv_id", id, "request_id", ctx.Value(requestIDKey{}), "error", err)
+			return nil, &PDFError{"Could not download the PDF of arXiv paper " + id}
+		}
+		if _, fullText, err = s.extractPDF(ctx, pdf, id+".pdf"); err != nil {
+			return nil, err
+		}
+	}
+	result, err := s.analyzePaper(ctx, paper, req.Field, fullText)
+	if err != nil {
+		return nil, err
+	}
+	result.Paper = paperInfo("arxiv", id, paper)
+	result.ProcessingTime = elapsedSeconds(start)
+	s.recordAbstract(paper.Title, req.Field, result)
+	return result, nil
+}
+
+// analyzePaper analyzes the abstract of a paper looked up by identifier,
+// along with its full text if there is one
+func (s *Server) analyzePaper(ctx context.Context, paper Paper, field types.Field, fullText string) (*types.AnalyzeResponse, error) {
+	req := types.AnalyzeRequest{
+		Title:    paper.Title,
+		Abstract: paper.Abstract,
+		Field:    field,
+		Authors:  paper.Authors,
+	}
+	if fullText != "" {
+		req.FullText, req.Mode = fullText, types.ModeFullText
+	}
+	return s.analyzeText(ctx, req)
+}
+
+func paperInfo(source, id string, paper Paper) *types.PaperInfo {
+	info := &types.PaperInfo{
+		Source:   source,
+		ID:       id,
+		Title:    paper.Title,
+		Authors:  paper.Authors,
+		Abstract: paper.Abstract,
+		URL:      paper.URL,
+	}
+	if !paper.Published.IsZero() {
+		info.Year = paper.Published.Year()
+	}
+	if info.Authors == nil {
+		info.Authors = []string{}
+	}
+	return info
+}
+
+func (s *Server) handleDOI(w http.ResponseWriter, r *http.Request) {
+	var req types.DOIRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.AnalyzeDOI(r.Context(), req)
+	s.writeLookupResult(w, r, "DOI "+types.NormalizeDOI(req.DOI), result, err)
+}
+
+func (s *Server) handlePMID(w http.ResponseWriter, r *http.Request) {
+	var req types.PMIDRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.AnalyzePMID(r.Context(), req)
+	s.writeLookupResult(w, r, "PMID "+types.NormalizePMID(req.PMID), result, err)
+}
+
+func (s *Server) handleArxiv(w http.ResponseWriter, r *http.Request) {
+	var req types.ArxivRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.AnalyzeArxiv(r.Context(), req)
+	s.writeLookupResult(w, r, "arXiv paper "+types.NormalizeArxivID(req.ID), result, err)
+}
+
+// writeLookupResult answers a request to analyze the paper named by id, such
+// as "DOI 10.1038/nature12373"
+func (s *Server) writeLookupResult(w http.ResponseWriter, r *http.Request, id string, result *types.AnalyzeResponse, err error) {
+	var missing *MissingAbstractError
+	var pdfErr *PDFError
+	switch {
+	case errors.Is(err, ErrPaperNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": fmt.Sprintf("%s not found", id)})
+	case errors.As(err, &missing):
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"detail": missing.Message})
+	case errors.As(err, &pdfErr):
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"detail": pdfErr.Message})
+	case errors.Is(err, ErrPDFUnsupported):
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"detail": err.Error()})
+	case err != nil:
+		s.fail(w, r, err, "An error occurred during analysis.")
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
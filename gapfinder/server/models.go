@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/llm"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// WithModels lets requests choose one of models with their model field. The
+// first is reported as the default, so it should be the backend's own model.
+// By default requests can't choose a model.
+func WithModels(models ...string) Option {
+	return func(s *Server) {
+		s.models = models
+	}
+}
+
+// Models returns the response of GET /models
+func (s *Server) Models() *types.ModelsResponse {
+	resp := &types.ModelsResponse{Models: nonNil(s.models)}
+	if len(s.models) > 0 {
+		resp.DefaultModel = s.models[0]
+	}
+	return resp
+}
+
+// modelContext checks that the server offers model and returns a context
+// that makes the backend use model and temperature, where set
+func (s *Server) modelContext(ctx context.Context, model string, temperature *float64) (context.Context, error) {
+	if model != "" && !slices.Contains(s.models, model) {
+		msg := "model selection is not available"
+		if len(s.models) > 0 {
+			msg = fmt.Sprintf("unknown model %q, want one of %s", model, strings.Join(s.models, ", "))
+		}
+		return nil, &types.ValidationError{Field: "model", Message: msg}
+	}
+	if model == "" && temperature == nil {
+		return ctx, nil
+	}
+	return llm.ContextWithParams(ctx, llm.Params{Model: model, Temperature: temperature}), nil
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Models())
+}
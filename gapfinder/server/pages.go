@@ -0,0 +1,103 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/internal/uuid"
+)
+
+// Paged results are forgotten after pagesTTL, and the oldest once there are
+// more than maxPagedResults
+const (
+	pagesTTL        = time.Hour
+	maxPagedResults = 1000
+)
+
+// resultPages keeps the individual results of paged topic analyses in memory
+// so they can be fetched a page at a time. A cursor names a stored result
+// list and the offset of the next page.
+type resultPages struct {
+	mu      sync.Mutex
+	results map[string]*pagedResults
+	order   []string // IDs, oldest first
+}
+
+type pagedResults struct {
+	stored   time.Time
+	pageSize int
+	results  []types.TopicAnalysisResult
+}
+
+func newResultPages() *resultPages {
+	return &resultPages{results: make(map[string]*pagedResults)}
+}
+
+// paginate returns the first page of results and the cursor of the next, if
+// any
+func (p *resultPages) paginate(results []types.TopicAnalysisResult, pageSize int) ([]types.TopicAnalysisResult, string) {
+	if len(results) <= pageSize {
+		return results, ""
+	}
+	now := time.Now()
+	id := strings.ReplaceAll(uuid.New(), "-", "")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.evict(now)
+	p.results[id] = &pagedResults{stored: now, pageSize: pageSize, results: results}
+	p.order = append(p.order, id)
+	return results[:pageSize], id + "." + strconv.Itoa(pageSize)
+}
+
+// page returns the page a cursor points to, or false if the cursor is
+// invalid or expired
+func (p *resultPages) page(cursor string) (*types.TopicResultsPage, bool) {
+	id, offset, _ := strings.Cut(cursor, ".")
+	start, err := strconv.Atoi(offset)
+	if err != nil || start < 0 {
+		return nil, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.results[id]
+	if !ok || time.Since(entry.stored) > pagesTTL {
+		return nil, false
+	}
+	start = min(start, len(entry.results))
+	end := min(start+entry.pageSize, len(entry.results))
+	page := &types.TopicResultsPage{IndividualResults: entry.results[start:end]}
+	if end < len(entry.results) {
+		page.NextCursor = id + "." + strconv.Itoa(end)
+	}
+	return page, true
+}
+
+// evict forgets expired results and makes room for new ones. The caller must
+// hold p.mu.
+func (p *resultPages) evict(now time.Time) {
+	kept := p.order[:0]
+	for _, id := range p.order {
+		if now.Sub(p.results[id].stored) > pagesTTL {
+			delete(p.results, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	p.order = kept
+	for len(p.order) >= maxPagedResults {
+		delete(p.results, p.order[0])
+		p.order = p.order[1:]
+	}
+}
+
+// TopicResults returns the page of a topic's individual results a cursor
+// points to, or false if the cursor is unknown or expired. It does the work
+// of GET /topic/results.
+func (s *Server) TopicResults(cursor string) (*types.TopicResultsPage, bool) {
+	return s.pages.page(cursor)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+const (
+	// maxPDFSize is the largest PDF accepted, like the Python service's
+	// default pdf.max_file_size
+	maxPDFSize = 10 << 20
+
+	// pdfTextLimit is the number of characters of a PDF's text analyzed.
+	// Papers start with their abstract and introduction, which is what the
+	// gap analysis prompt expects.
+	pdfTextLimit = 8000
+)
+
+// PDFExtractor extracts the text of a paper PDF, and its title if the PDF's
+// metadata has one
+type PDFExtractor interface {
+	ExtractPDF(ctx context.Context, pdf []byte) (title, text string, err error)
+}
+
+// PDFExtractorFunc adapts a function to the PDFExtractor interface
+type PDFExtractorFunc func(ctx context.Context, pdf []byte) (title, text string, err error)
+
+// ExtractPDF calls f
+func (f PDFExtractorFunc) ExtractPDF(ctx context.Context, pdf []byte) (title, text string, err error) {
+	return f(ctx, pdf)
+}
+
+// WithPDFExtractor enables POST /analyze/pdf, extracting text with ex. The
+// server has no PDF parser of its own, so without one the endpoint answers
+// 501 Not Implemented.
+func WithPDFExtractor(ex PDFExtractor) Option {
+	return func(s *Server) {
+		s.pdf = ex
+	}
+}
+
+// ErrPDFUnsupported is returned by AnalyzePDF when the server has no
+// PDFExtractor
+var ErrPDFUnsupported = errors.New("PDF analysis is not available on this server")
+
+// PDFError reports a PDF that is too large or has no extractable text
+type PDFError struct {
+	Message string
+}
+
+func (e *PDFError) Error() string {
+	return e.Message
+}
+
+// AnalyzePDF extracts the text of a paper PDF and analyzes its beginning
+// like an abstract, along with its methods, results and discussion in
+// ModeFullText. It does the work of POST /analyze/pdf.
+func (s *Server) AnalyzePDF(ctx context.Context, pdf []byte, meta types.PDFMetadata) (*types.AnalyzeResponse, error) {
+	if s.pdf == nil {
+		return nil, ErrPDFUnsupported
+	}
+	if err := meta.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	title, text, err := s.extractPDF(ctx, pdf, meta.Filename)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(meta.Filename, path.Ext(meta.Filename))
+	title = cmp.Or(meta.Title, strings.TrimSpace(title), name, "Untitled")
+	result, err := s.analyzeText(ctx, types.AnalyzeRequest{
+		Title:    title,
+		Abstract: truncate(text, pdfTextLimit),
+		Field:    meta.Field,
+		Authors:  meta.Authors,
+		FullText: text,
+		Mode:     meta.Mode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	s.recordAbstract(title, meta.Field, result)
+	return result, nil
+}
+
+// extractPDF extracts the title and text of the PDF named name, reporting
+// oversized and unreadable PDFs with a *PDFError
+func (s *Server) extractPDF(ctx context.Context, pdf []byte, name string) (title, text string, err error) {
+	if len(pdf) > maxPDFSize {
+		return "", "", &PDFError{fmt.Sprintf("PDF exceeds %d bytes", maxPDFSize)}
+	}
+	title, text, err = s.pdf.ExtractPDF(ctx, pdf)
+	text = strings.TrimSpace(text)
+	if err != nil || text == "" {
+		s.logger.WarnContext(ctx, "PDF text extraction failed",
+			"file", name, "request_id", ctx.Value(requestIDKey{}), "error", err)
+		return "", "", &PDFError{"Could not extract text from the PDF"}
+	}
+	return title, text, nil
+}
+
+func (s *Server) handlePDF(w http.ResponseWriter, r *http.Request) {
+	// Leave room for the form fields around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxPDFSize+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeValidationError(w, "file", fmt.Sprintf("a PDF file is required: %v", err))
+		return
+	}
+	defer file.Close()
+	pdf, err := io.ReadAll(file)
+	if err != nil {
+		writeValidationError(w, "file", fmt.Sprintf("error reading file: %v", err))
+		return
+	}
+	meta := types.PDFMetadata{
+		Filename: header.Filename,
+		Title:    r.FormValue("title"),
+		Field:    types.Field(r.FormValue("field")),
+		Authors:  r.Form["authors"],
+		Mode:     r.FormValue("mode"),
+	}
+
+	result, err := s.AnalyzePDF(r.Context(), pdf, meta)
+	var verr *types.ValidationError
+	var pdfErr *PDFError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(w, verr.Field, verr.Message)
+	case errors.Is(err, ErrPDFUnsupported):
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"detail": err.Error()})
+	case errors.As(err, &pdfErr):
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"detail": pdfErr.Message})
+	case err != nil:
+		s.fail(w, r, err, "An error occurred during analysis.")
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+var (
+	reviewAspects = []string{
+		types.AspectSoundness, types.AspectNovelty, types.AspectBaselines,
+		types.AspectClarity, types.AspectSignificance,
+	}
+	reviewRecommendations = []string{
+		types.RecommendAccept, types.RecommendMinorRevision,
+		types.RecommendMajorRevision, types.RecommendReject,
+	}
+)
+
+// SimulateReview validates a request, analyzes its paper as Analyze does and
+// has the model critique it the way a peer reviewer would, building on the
+// gaps and limitations the analysis found. Critiques of unknown aspects are
+// dropped and major ones are listed first; a recommendation the model gets
+// wrong is derived from them. It does the work of POST /analyze/peer-review.
+func (s *Server) SimulateReview(ctx context.Context, req types.AnalyzeRequest) (*types.PeerReviewResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, err := s.modelContext(ctx, req.Model, req.Temperature)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	analysis, err := s.analyzeText(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.MinConfidence > 0 {
+		analysis.FilterGaps(req.MinConfidence)
+	}
+	analysis.Truncate(req.MaxGaps, req.MaxHypotheses, req.MaxFindings)
+
+	req.Field = cmp.Or(req.Field, types.FieldGeneral)
+	if req.Mode == types.ModeFullText {
+		req.FullText = selectSections(req.FullText, fullTextLimit)
+	} else {
+		req.FullText = ""
+	}
+	prompt, err := render(peerReviewPrompt, struct {
+		types.AnalyzeRequest
+		Analysis *types.AnalyzeResponse
+	}{req, analysis})
+	if err != nil {
+		return nil, err
+	}
+	var reply struct {
+		Recommendation   string           `json:"recommendation"`
+		Summary          string           `json:"summary"`
+		Critiques        []types.Critique `json:"critiques"`
+		MissingBaselines []string         `json:"missing_baselines"`
+	}
+	if err := s.complete(ctx, prompt, &reply); err != nil {
+		return nil, err
+	}
+
+	result := &types.PeerReviewResponse{
+		Recommendation:   reply.Recommendation,
+		Summary:          reply.Summary,
+		Critiques:        []types.Critique{},
+		MissingBaselines: nonNil(reply.MissingBaselines),
+		Gaps:             nonNil(analysis.Gaps),
+	}
+	for _, severity := range []string{types.SeverityMajor, types.SeverityMinor} {
+		for _, c := range reply.Critiques {
+			if c.Severity != types.SeverityMajor {
+				c.Severity = types.SeverityMinor
+			}
+			if c.Severity == severity && slices.Contains(reviewAspects, c.Aspect) && strings.TrimSpace(c.Comment) != "" {
+				result.Critiques = append(result.Critiques, c)
+			}
+		}
+	}
+	if !slices.Contains(reviewRecommendations, result.Recommendation) {
+		result.Recommendation = types.RecommendMinorRevision
+		if len(result.Critiques) > 0 && result.Critiques[0].Severity == types.SeverityMajor {
+			result.Recommendation = types.RecommendMajorRevision
+		}
+	}
+	result.ProcessingTime = elapsedSeconds(start)
+	return result, nil
+}
+
+func (s *Server) handlePeerReview(w http.ResponseWriter, r *http.Request) {
+	var req types.AnalyzeRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.SimulateReview(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during peer review simulation.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,714 @@
+package server
+
+import (
+	"strings"
+	"text/template"
+)
+
+// The prompts match app/core/prompts.py, so both implementations of the
+// service produce comparable analyses.
+
+var promptFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"inc":      func(i int) int { return i + 1 },
+	"truncate": truncate,
+}
+
+var gapAnalysisPrompt = template.Must(template.New("gap").Funcs(promptFuncs).Parse(`
+You are a research assistant specializing in identifying gaps, limitations, and potential future research directions in scientific papers.
+
+Given the following research paper details:
+Title: {{.Title}}
+Abstract: {{.Abstract}}
+Field: {{.Field}}
+{{with .Authors}}Authors: {{join . ", "}}{{end}}
+{{with .FullText}}
+Full text (methods, results and discussion):
+{{.}}
+
+Base the analysis on the full text as well as the abstract. The methods,
+results and discussion reveal methodological gaps and limitations that the
+abstract leaves out.
+{{end}}{{with .Language}}
+The input is written in the language with ISO 639-1 code "{{.}}". {{if $.TranslateOutput}}Write the analysis in that language, but keep the JSON keys and gap types in English.{{else}}Write the analysis in English.{{end}}
+{{end}}{{with .Instructions}}
+Additional instructions from the researcher:
+{{.}}
+{{end}}
+Please analyze this research and provide:
+
+1. KEY FINDINGS: List 3-5 main findings or contributions from this work.
+
+2. RESEARCH GAPS: Identify specific gaps in the research. For each gap, provide:
+   - Gap description
+   - Gap type (methodological, theoretical, empirical, technical, conceptual)
+   - Confidence score (0.0-1.0)
+   - Potential impact of addressing this gap
+
+3. LIMITATIONS: List specific limitations mentioned or implied in the research.
+
+4. METHODOLOGY GAPS: Identify gaps or issues in the research methodology.
+
+5. SUGGESTED HYPOTHESES: Generate 2-3 novel research hypotheses based on the gaps identified. For each hypothesis:
+   - The hypothesis statement
+   - Rationale for the hypothesis
+   - Feasibility score (0.0-1.0)
+   - Required research methods
+
+6. FUTURE DIRECTIONS: Suggest 3-5 concrete future research directions.
+
+Format your response as valid JSON with the following structure:
+{
+  "key_findings": ["finding1", "finding2", ...],
+  "gaps": [
+    {
+      "gap_description": "description",
+      "confidence_score": 0.8,
+      "gap_type": "methodological",
+      "potential_impact": "impact description"
+    }
+  ],
+  "limitations": ["limitation1", "limitation2", ...],
+  "methodology_gaps": ["gap1", "gap2", ...],
+  "suggested_hypotheses": [
+    {
+      "hypothesis": "hypothesis statement",
+      "rationale": "rationale",
+      "feasibility_score": 0.7,
+      "required_methods": ["method1", "method2"]
+    }
+  ],
+  "future_directions": ["direction1", "direction2", ...]
+}
+
+Be specific, actionable, and avoid generic statements. Focus on gaps that could lead to meaningful research contributions.
+`))
+
+var topicAnalysisPrompt = template.Must(template.New("topic").Funcs(promptFuncs).Parse(`
+You are analyzing multiple research papers on the topic: {{.Topic}} {{with .CrossFields}}across the fields of {{join . ", "}}. The papers come from each of these fields, and the most valuable gaps lie where the fields meet: questions that none of them addresses alone, methods of one field not yet applied to the problems of another, and findings that have not crossed between them.{{else}}in the field of {{.Field}}.{{end}}
+
+Here are the papers to analyze:
+{{range $i, $p := .Papers}}
+Paper {{inc $i}}{{if and $.Surveys (index $.Surveys $i)}} (survey){{end}}:
+Title: {{$p.Title}}
+Authors: {{join $p.Authors ", "}}
+Abstract: {{truncate $p.Abstract 1000}}...
+{{end}}{{with .Language}}
+The input is written in the language with ISO 639-1 code "{{.}}". {{if $.TranslateOutput}}Write the analysis in that language, but keep the JSON keys and gap types in English.{{else}}Write the analysis in English.{{end}}
+{{end}}{{if .Surveys}}
+Papers marked as surveys review other work. Use them as background only: base the common gaps on the primary studies, not on the surveys' observations about the state of the literature, such as calls for more research or for standard benchmarks.
+{{end}}
+Please provide:
+
+1. COMMON GAPS: Identify gaps that appear across multiple papers or are systematic in the field.
+
+2. INDIVIDUAL PAPER GAPS: For each paper, identify specific gaps.
+
+3. RESEARCH DIRECTIONS: Suggest overall research directions for this topic area.
+{{if .CrossFields}}
+4. INTERSECTION GAPS: Identify gaps at the intersection of {{join .CrossFields ", "}} that an analysis of any one of these fields would miss.
+{{end}}
+Format your response as valid JSON:
+{
+  "common_gaps": [
+    {
+      "gap_description": "description",
+      "confidence_score": 0.8,
+      "gap_type": "systematic",
+      "potential_impact": "field-wide impact"
+    }
+  ],
+  "individual_results": [
+    {
+      "paper_title": "title",
+      "gaps": [
+        {
+          "gap_description": "description",
+          "confidence_score": 0.7,
+          "gap_type": "methodological",
+          "potential_impact": "impact"
+        }
+      ]
+    }
+  ],
+  "suggested_research_directions": ["direction1", "direction2", ...]{{if .CrossFields}},
+  "intersection_gaps": [
+    {
+      "gap_description": "description",
+      "confidence_score": 0.7,
+      "gap_type": "conceptual",
+      "potential_impact": "impact across the fields"
+    }
+  ]{{end}}
+}
+`))
+
+var peerReviewPrompt = template.Must(template.New("peer-review").Funcs(promptFuncs).Parse(`
+You are an experienced peer reviewer in the field of {{.Field}} reviewing a manuscript submitted for publication.
+
+Title: {{.Title}}
+{{with .Authors}}Authors: {{join . ", "}}
+{{end}}Abstract: {{.Abstract}}
+{{with .FullText}}
+Full text (methods, results and discussion):
+{{.}}
+{{end}}
+A prior analysis of the manuscript found:
+{{with .Analysis.KeyFindings}}Key findings: {{join . "; "}}
+{{end}}{{with .Analysis.Limitations}}Limitations: {{join . "; "}}
+{{end}}{{with .Analysis.MethodologyGaps}}Methodology gaps: {{join . "; "}}
+{{end}}{{range .Analysis.Gaps}}- Research gap ({{.GapType}}): {{.GapDescription}}
+{{end}}{{with .Language}}
+The input is written in the language with ISO 639-1 code "{{.}}". {{if $.TranslateOutput}}Write the review in that language, but keep the JSON keys, aspects, severities and recommendation in English.{{else}}Write the review in English.{{end}}
+{{end}}{{with .Instructions}}
+Additional instructions from the researcher:
+{{.}}
+{{end}}
+Please review the manuscript as you would for a journal, building on the analysis:
+
+1. SUMMARY: Summarize the manuscript and its merits in two or three sentences.
+
+2. CRITIQUES: Critique the manuscript's soundness, novelty, baselines, clarity and significance.
+   For each critique, give the aspect, whether it is major (must be addressed before
+   acceptance) or minor, and a specific comment.
+
+3. MISSING BASELINES: List the methods or prior results the manuscript should compare against
+   but doesn't.
+
+4. RECOMMENDATION: Recommend accept, minor_revision, major_revision or reject.
+
+Format your response as valid JSON:
+{
+  "summary": "summary of the manuscript and its merits",
+  "critiques": [
+    {
+      "aspect": "soundness",
+      "severity": "major",
+      "comment": "specific comment"
+    }
+  ],
+  "missing_baselines": ["baseline1", "baseline2", ...],
+  "recommendation": "major_revision"
+}
+
+Be specific and constructive, as a reviewer helping the authors improve the manuscript.
+`))
+
+var comparisonPrompt = template.Must(template.New("compare").Funcs(promptFuncs).Parse(`
+You are a research assistant comparing two scientific papers in the field of {{.Field}}.
+{{define "paper"}}Title: {{.Title}}
+{{with .Authors}}Authors: {{join . ", "}}{{end}}
+Abstract: {{.Abstract}}{{end}}
+Paper A:
+{{template "paper" .PaperA}}
+
+Paper B:
+{{template "paper" .PaperB}}
+
+Please compare the papers and provide:
+
+1. OVERLAPPING FINDINGS: Findings or contributions both papers support.
+
+2. CONFLICTING CONCLUSIONS: Points on which the papers disagree. For each, state the
+   topic and the position of each paper.
+
+3. GAPS UNIQUE TO PAPER A: Research gaps in Paper A that Paper B addresses or doesn't share.
+
+4. GAPS UNIQUE TO PAPER B: Research gaps in Paper B that Paper A addresses or doesn't share.
+
+Format your response as valid JSON:
+{
+  "overlapping_findings": ["finding1", "finding2", ...],
+  "conflicting_conclusions": [
+    {
+      "topic": "what the papers disagree on",
+      "paper_a_position": "conclusion of Paper A",
+      "paper_b_position": "conclusion of Paper B"
+    }
+  ],
+  "gaps_unique_to_a": [
+    {
+      "gap_description": "description",
+      "confidence_score": 0.8,
+      "gap_type": "methodological",
+      "potential_impact": "impact description"
+    }
+  ],
+  "gaps_unique_to_b": [
+    {
+      "gap_description": "description",
+      "confidence_score": 0.8,
+      "gap_type": "empirical",
+      "potential_impact": "impact description"
+    }
+  ]
+}
+
+Be specific, and only report conflicts the papers actually support.
+`))
+
+var deduplicationPrompt = template.Must(template.New("deduplicate").Funcs(promptFuncs).Parse(`
+You are a research assistant consolidating research gaps identified across several paper analyses.
+
+Each gap below is labelled [analysis.gap] with its analysis and gap number:
+{{range $a, $gaps := .}}{{range $g, $gap := $gaps}}[{{$a}}.{{$g}}] ({{$gap.GapType}}) {{$gap.GapDescription}}
+{{end}}{{end}}
+Please group gaps that describe the same underlying problem, even if they are worded
+differently. For each group, write one canonical gap that covers all of its members,
+and list the members by their analysis and gap numbers. A gap unlike any other forms
+a group of its own. Every gap belongs to exactly one group.
+
+Format your response as valid JSON:
+{
+  "clusters": [
+    {
+      "gap": {
+        "gap_description": "canonical description",
+        "confidence_score": 0.8,
+        "gap_type": "methodological",
+        "potential_impact": "impact description"
+      },
+      "members": [{"analysis": 0, "gap": 1}, {"analysis": 2, "gap": 0}]
+    }
+  ]
+}
+`))
+
+var hypothesisPrompt = template.Must(template.New("hypotheses").Funcs(promptFuncs).Parse(`
+You are a research assistant proposing research hypotheses in the field of {{.Field}}.
+
+The following research gaps have been identified:
+{{range $i, $g := .Gaps}}{{inc $i}}. ({{$g.GapType}}) {{$g.GapDescription}}{{with $g.PotentialImpact}} Potential impact: {{.}}{{end}}
+{{end}}
+Please generate 1-2 novel research hypotheses for each gap. For each hypothesis:
+   - The hypothesis statement
+   - Rationale for the hypothesis, naming the gap it addresses
+   - Feasibility score (0.0-1.0)
+   - Required research methods
+
+Format your response as valid JSON:
+{
+  "hypotheses": [
+    {
+      "hypothesis": "hypothesis statement",
+      "rationale": "rationale",
+      "feasibility_score": 0.7,
+      "required_methods": ["method1", "method2"]
+    }
+  ]
+}
+
+Make each hypothesis specific and testable.
+`))
+
+var methodsPrompt = template.Must(template.New("methods").Funcs(promptFuncs).Parse(`
+You are a research methodologist in the field of {{.Field}} planning how to test a research hypothesis.
+
+Hypothesis: {{.Hypothesis.Hypothesis}}
+{{with .Hypothesis.Rationale}}Rationale: {{.}}
+{{end}}{{with .Hypothesis.RequiredMethods}}Required methods: {{join . ", "}}
+{{end}}
+Please expand the required methods into 2-3 concrete experimental designs that could test the
+hypothesis, the most suitable first. For each design, provide:
+   - A name, such as "randomized controlled trial"
+   - How the design tests the hypothesis, including its conditions and measures
+   - The methods it uses
+   - Sample-size guidance, such as the sample needed for adequate statistical power and the
+     assumptions behind it
+   - Its limitations
+
+Then name publicly available datasets that could serve as a starting point, with what they hold
+and their URL if you are certain of it.
+
+Format your response as valid JSON:
+{
+  "designs": [
+    {
+      "name": "design name",
+      "description": "how the design tests the hypothesis",
+      "methods": ["method1", "method2"],
+      "sample_size": "sample-size guidance",
+      "limitations": ["limitation1", "limitation2"]
+    }
+  ],
+  "datasets": [
+    {
+      "name": "dataset name",
+      "description": "what the dataset holds and why it suits the work",
+      "url": "https://..."
+    }
+  ]
+}
+
+Be specific enough that a researcher could start planning the study from your answer.
+`))
+
+var datasetsPrompt = template.Must(template.New("datasets").Funcs(promptFuncs).Parse(`
+You are a research data librarian in the field of {{.Field}}.
+{{with .Gap}}
+A researcher wants to investigate the following research gap:
+({{.GapType}}) {{.GapDescription}}{{with .PotentialImpact}}
+Potential impact: {{.}}{{end}}
+{{end}}{{with .Hypothesis}}
+A researcher wants to test the following hypothesis:
+{{.Hypothesis}}{{with .RequiredMethods}}
+Required methods: {{join . ", "}}{{end}}
+{{end}}
+Please suggest up to {{.MaxDatasets}} publicly available datasets suitable for this work, the most
+suitable first. For each dataset, provide its name, what it holds and why it suits the work, the
+URL it can be downloaded or requested from, and the license it is published under.
+
+Format your response as valid JSON:
+{
+  "datasets": [
+    {
+      "name": "dataset name",
+      "description": "what the dataset holds and why it suits the work",
+      "url": "https://...",
+      "license": "CC BY 4.0"
+    }
+  ]
+}
+
+Only suggest datasets you are certain exist, with their real URL. Leave the license empty if you
+don't know it.
+`))
+
+var reviewPrompt = template.Must(template.New("review").Funcs(promptFuncs).Parse(`
+You are a research assistant drafting a literature review on the topic: {{.Topic}} in the field of {{.Field}}.
+
+Papers, numbered for citation:
+{{range $i, $p := .Papers}}{{if $i}}
+
+{{end}}[{{inc $i}}] {{$p.PaperTitle}}
+Authors: {{join $p.Authors ", "}}
+Abstract: {{truncate $p.Abstract 1000}}{{with $p.Gaps}}
+Gaps: {{range $j, $g := .}}{{if $j}}; {{end}}{{$g.GapDescription}}{{end}}{{end}}{{end}}
+
+Gaps common to the papers:
+{{range $i, $g := .CommonGaps}}{{if $i}}
+{{end}}- {{$g.GapDescription}}{{else}}None identified{{end}}
+
+Please write a draft literature review organized in sections:
+
+1. INTRODUCTION: Introduce the topic and the scope of the review.
+
+2. THEMES: One section per major theme of the papers, discussing what they found and how they relate.
+
+3. RESEARCH GAPS: Discuss the gaps the papers leave open.
+
+4. CONCLUSION: Summarize the state of the topic and directions for future work.
+
+Cite papers by their number in square brackets, such as [2], and list the numbers each section cites.
+
+Format your response as valid JSON:
+{
+  "title": "review title",
+  "sections": [
+    {
+      "kind": "theme",
+      "heading": "section heading",
+      "paragraphs": ["paragraph citing [1] and [3]", ...],
+      "citations": [1, 3]
+    }
+  ]
+}
+
+The kind of each section is introduction, theme, gaps or conclusion. Only cite the numbered papers.
+`))
+
+var citationPrompt = template.Must(template.New("citations").Funcs(promptFuncs).Parse(`
+You are a research assistant helping a researcher write about a research gap in the field of {{.Field}}.
+
+Research gap: {{.Gap}}
+
+Candidate papers:
+{{range $i, $p := .Papers}}{{if $i}}
+
+{{end}}[{{inc $i}}] {{$p.Title}}
+Authors: {{join $p.Authors ", "}}
+Abstract: {{truncate $p.Abstract 500}}{{end}}
+
+Please choose up to {{.MaxCitations}} of the candidate papers the researcher should cite when
+writing about this gap, most relevant first. For each, explain in one sentence how it relates
+to the gap: evidence of the gap, prior attempts to address it, or methods that could close it.
+
+Format your response as valid JSON:
+{
+  "citations": [
+    {
+      "paper": 2,
+      "reason": "how the paper relates to the gap"
+    }
+  ]
+}
+
+Only choose from the numbered candidates, and leave out papers unrelated to the gap.
+`))
+
+var fundingPrompt = template.Must(template.New("funding").Funcs(promptFuncs).Parse(`
+You are a research assistant helping a researcher in the field of {{.Field}} find funding for open research gaps.
+
+Research gaps:
+{{range $i, $g := .Gaps}}{{if $i}}
+{{end}}[{{inc $i}}] {{$g.GapDescription}}{{with $g.PotentialImpact}} Potential impact: {{.}}{{end}}{{end}}
+
+Open funding calls:
+{{range $i, $c := .Calls}}{{if $i}}
+
+{{end}}[{{inc $i}}] {{$c.Title}} ({{$c.Agency}}){{with $c.Summary}}
+Summary: {{truncate . 500}}{{end}}{{end}}
+
+Please choose up to {{.MaxMatches}} of the funding calls that would fund work on the research gaps.
+For each, list the gaps it would fund, score its relevance between 0 and 1, and explain in one
+sentence why it fits.
+
+Format your response as valid JSON:
+{
+  "matches": [
+    {
+      "call": 3,
+      "gaps": [1, 2],
+      "relevance_score": 0.8,
+      "reason": "why the call fits the gaps"
+    }
+  ]
+}
+
+Only choose from the numbered calls and gaps, and leave out calls that fit none of the gaps.
+`))
+
+var noveltyPrompt = template.Must(template.New("novelty").Funcs(promptFuncs).Parse(`
+You are a research assistant checking whether a proposed research idea in the field of {{.Field}} is novel.
+
+Proposed idea: {{.Idea}}
+
+Related papers:
+{{range $i, $p := .Papers}}{{if $i}}
+
+{{end}}[{{inc $i}}] {{$p.Title}}{{if not $p.Published.IsZero}} ({{$p.Published.Year}}){{end}}
+Abstract: {{truncate $p.Abstract 500}}{{end}}
+
+Please judge whether these papers already address the idea. Answer with a status: "addressed"
+if the idea has already been carried out, "partially_addressed" if parts of it have, or "open"
+if it has not. Score its novelty between 0 (already addressed) and 1 (entirely new), summarize
+in two or three sentences what the papers cover and what remains open, and list the papers
+supporting your verdict, most relevant first, with one sentence on how each relates to the idea.
+
+Format your response as valid JSON:
+{
+  "status": "partially_addressed",
+  "novelty_score": 0.5,
+  "summary": "what the papers cover and what remains open",
+  "papers": [
+    {
+      "paper": 2,
+      "reason": "how the paper relates to the idea"
+    }
+  ]
+}
+
+Only list numbered papers, and leave out papers unrelated to the idea.
+`))
+
+var relatedWorkPrompt = template.Must(template.New("related").Funcs(promptFuncs).Parse(`
+You are a research assistant helping a researcher verify that a research gap in the field of {{.Field}} is real.
+
+Research gap: {{.Gap}}
+
+Candidate papers:
+{{range $i, $p := .Papers}}{{if $i}}
+
+{{end}}[{{inc $i}}] {{$p.Title}}{{if not $p.Published.IsZero}} ({{$p.Published.Year}}){{end}}
+Abstract: {{truncate $p.Abstract 500}}{{end}}
+
+Please choose up to {{.MaxPapers}} of the candidate papers closest to the research gap. For each,
+score its similarity to the gap between 0 and 1, tell whether it already addresses the gap,
+and explain in one sentence how it relates to the gap.
+
+Format your response as valid JSON:
+{
+  "papers": [
+    {
+      "paper": 2,
+      "similarity": 0.8,
+      "addresses_gap": false,
+      "reason": "how the paper relates to the gap"
+    }
+  ]
+}
+
+Only choose from the numbered candidates, and leave out papers unrelated to the gap.
+`))
+
+var proposalPrompt = template.Must(template.New("proposal").Funcs(promptFuncs).Parse(`
+You are an experienced grant reviewer in the field of {{.Field}} assessing a draft research proposal.
+
+Proposal:
+{{.Proposal}}
+{{with .Gaps}}
+Known research gaps:
+{{range $i, $g := .}}{{if $i}}
+{{end}}[{{inc $i}}] {{$g.GapDescription}}{{end}}
+{{end}}
+Related papers:
+{{range $i, $p := .Papers}}{{if $i}}
+
+{{end}}[{{inc $i}}] {{$p.Title}}{{if not $p.Published.IsZero}} ({{$p.Published.Year}}){{end}}
+Abstract: {{truncate $p.Abstract 500}}{{else}}None found.{{end}}
+
+Please assess how well the proposal addresses the {{if .Gaps}}known research gaps{{else}}research gaps the related papers raise{{end}}.
+For each gap, score between 0 and 1 how fully the proposal addresses it and comment in one sentence.
+Score the proposal's overall alignment with the gaps between 0 and 1. Then list the proposal's
+claims of novelty that the related papers contradict, each with the conflicting papers and one
+sentence on how each conflicts. Finally, summarize your assessment in two or three sentences.
+
+Format your response as valid JSON:
+{
+  "alignment_score": 0.7,
+  "gaps": [
+    {
+      "gap_description": "the gap{{if .Gaps}}, as written in the list{{end}}",
+      "coverage": 0.8,
+      "comment": "how the proposal addresses the gap"
+    }
+  ],
+  "novelty_conflicts": [
+    {
+      "claim": "the proposal's claim of novelty",
+      "papers": [
+        {
+          "paper": 2,
+          "reason": "how the paper contradicts the claim"
+        }
+      ]
+    }
+  ],
+  "summary": "overall assessment"
+}
+
+Only cite numbered papers, and leave out claims no related paper contradicts.
+`))
+
+var groupsPrompt = template.Must(template.New("groups").Funcs(promptFuncs).Parse(`
+You are a research assistant mapping who works on the open problems of the topic: {{.Topic}} in the field of {{.Field}}.
+
+Research gaps:
+{{range $i, $g := .CommonGaps}}{{if $i}}
+{{end}}[{{inc $i}}] {{$g.GapDescription}}{{end}}
+
+Papers:
+{{range $i, $p := .Papers}}{{if $i}}
+
+{{end}}[{{inc $i}}] {{$p.PaperTitle}}
+Abstract: {{truncate $p.Abstract 500}}{{with $p.Gaps}}
+Gaps: {{range $j, $g := .}}{{if $j}}; {{end}}{{$g.GapDescription}}{{end}}{{end}}{{end}}
+
+For each research gap, list the papers working near it: papers that address the gap, raise it,
+or develop methods or data that could close it.
+
+Format your response as valid JSON:
+{
+  "gaps": [
+    {
+      "gap": 1,
+      "papers": [2, 5]
+    }
+  ]
+}
+
+Only use the numbered gaps and papers, and leave out papers unrelated to a gap.
+`))
+
+var trendsPrompt = template.Must(template.New("trends").Funcs(promptFuncs).Parse(`
+You are analyzing how the research gaps on the topic: {{.Topic}} in the field of {{.Field}} have evolved over the years.
+
+Here are the papers, numbered and oldest first:
+{{range $i, $p := .Papers}}
+[{{inc $i}}] {{$p.Published.Year}}: {{$p.Title}}
+Abstract: {{truncate $p.Abstract 500}}
+{{end}}
+Please identify the research gaps these papers raise, as limitations of their own work or as open
+problems they point out. Merge gaps raised by several papers into one, and list the numbers of every
+paper raising it, so that it shows which gaps persist, which are being closed and which are new.
+
+Format your response as valid JSON:
+{
+  "gaps": [
+    {
+      "gap_description": "description",
+      "gap_type": "methodological",
+      "papers": [1, 4]
+    }
+  ]
+}
+
+The gap type is one of methodological, theoretical, empirical, technical, conceptual, data, population or systematic. Only use the numbered papers.
+`))
+
+// render executes a prompt template
+func render(t *template.Template, data any) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+var refinePrompt = template.Must(template.New("refine").Funcs(promptFuncs).Parse(`
+You are a research assistant refining an analysis of a scientific paper in the field of {{.Field}} with the researcher who asked for it.
+{{with .Previous.Paper}}
+Title: {{.Title}}
+Abstract: {{.Abstract}}
+{{end}}
+Your previous analysis:
+{{with .Previous.KeyFindings}}Key findings:
+{{range .}}- {{.}}
+{{end}}{{end}}{{with .Previous.Gaps}}Research gaps:
+{{range $i, $g := .}}{{inc $i}}. {{$g.GapDescription}} ({{$g.GapType}}, confidence {{$g.ConfidenceScore}}): {{$g.PotentialImpact}}
+{{end}}{{end}}{{with .Previous.Limitations}}Limitations:
+{{range .}}- {{.}}
+{{end}}{{end}}{{with .Previous.MethodologyGaps}}Methodology gaps:
+{{range .}}- {{.}}
+{{end}}{{end}}{{with .Previous.SuggestedHypotheses}}Suggested hypotheses:
+{{range $i, $h := .}}{{inc $i}}. {{$h.Hypothesis}} (feasibility {{$h.FeasibilityScore}}): {{$h.Rationale}}
+{{end}}{{end}}{{with .Previous.FutureDirections}}Future directions:
+{{range .}}- {{.}}
+{{end}}{{end}}
+The researcher's feedback:
+{{.Feedback}}
+
+Update the analysis following the feedback. Drop the gaps and other items the researcher rejects, and when they ask for a deeper look at an item, expand it with more specific gaps, hypotheses or directions. Keep everything the feedback doesn't concern as it was, worded the same way.
+
+Format your response as valid JSON with the structure of the previous analysis:
+{
+  "key_findings": ["finding1", "finding2", ...],
+  "gaps": [
+    {
+      "gap_description": "description",
+      "confidence_score": 0.8,
+      "gap_type": "methodological",
+      "potential_impact": "impact description"
+    }
+  ],
+  "limitations": ["limitation1", "limitation2", ...],
+  "methodology_gaps": ["gap1", "gap2", ...],
+  "suggested_hypotheses": [
+    {
+      "hypothesis": "hypothesis statement",
+      "rationale": "rationale",
+      "feasibility_score": 0.7,
+      "required_methods": ["method1", "method2"]
+    }
+  ],
+  "future_directions": ["direction1", "direction2", ...]
+}
+`))
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PMIDResolver looks up the paper with a PubMed ID. It returns an error
+// wrapping ErrPaperNotFound if there is none.
+type PMIDResolver interface {
+	LookupPMID(ctx context.Context, pmid string) (Paper, error)
+}
+
+// DefaultPubMedURL is the base URL of NCBI E-utilities
+const DefaultPubMedURL = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils"
+
+// PubMedSource looks up papers through NCBI E-utilities
+type PubMedSource struct {
+	BaseURL    string // defaults to DefaultPubMedURL
+	HTTPClient *http.Client
+	// APIKey is an NCBI API key, which raises the rate limit from 3 to 10
+	// requests a second
+	APIKey string
+}
+
+// NewPubMedSource returns a resolver for the public E-utilities. A nil hc
+// means http.DefaultClient.
+func NewPubMedSource(hc *http.Client) *PubMedSource {
+	return &PubMedSource{BaseURL: DefaultPubMedURL, HTTPClient: hc}
+}
+
+// pubMedText is an element whose text may be marked up, like <i> in titles
+type pubMedText struct {
+	Label string `xml:"Label,attr"`
+	Inner string `xml:",innerxml"`
+}
+
+type pubMedArticleSet struct {
+	Articles []struct {
+		Title    pubMedText   `xml:"MedlineCitation>Article>ArticleTitle"`
+		Abstract []pubMedText `xml:"MedlineCitation>Article>Abstract>AbstractText"`
+		Year     string       `xml:"MedlineCitation>Article>Journal>JournalIssue>PubDate>Year"`
+		Authors  []struct {
+			ForeName       string `xml:"ForeName"`
+			LastName       string `xml:"LastName"`
+			CollectiveName string `xml:"CollectiveName"`
+		} `xml:"MedlineCitation>Article>AuthorList>Author"`
+	} `xml:"PubmedArticle"`
+}
+
+// LookupPMID returns the paper with a PubMed ID. Labelled sections of
+// structured abstracts are joined as "LABEL: text".
+func (p *PubMedSource) LookupPMID(ctx context.Context, pmid string) (Paper, error) {
+	params := url.Values{"db": {"pubmed"}, "id": {pmid}, "retmode": {"xml"}}
+	if p.APIKey != "" {
+		params.Set("api_key", p.APIKey)
+	}
+	u := cmp.Or(p.BaseURL, DefaultPubMedURL) + "/efetch.fcgi?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Paper{}, fmt.Errorf("error creating PubMed request: %w", err)
+	}
+
+	hc := p.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return Paper{}, fmt.Errorf("error fetching PMID from PubMed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Paper{}, fmt.Errorf("PubMed API returned status %d", resp.StatusCode)
+	}
+
+	var set pubMedArticleSet
+	if err := xml.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return Paper{}, fmt.Errorf("error parsing PubMed response: %w", err)
+	}
+	if len(set.Articles) == 0 {
+		return Paper{}, fmt.Errorf("PMID %s: %w", pmid, ErrPaperNotFound)
+	}
+	a := set.Articles[0]
+	paper := Paper{
+		Title: stripXML(a.Title.Inner),
+		URL:   "https://pubmed.ncbi.nlm.nih.gov/" + pmid + "/",
+	}
+	if year, err := strconv.Atoi(strings.TrimSpace(a.Year)); err == nil {
+		paper.Published = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	var sections []string
+	for _, text := range a.Abstract {
+		section := stripXML(text.Inner)
+		if text.Label != "" {
+			section = text.Label + ": " + section
+		}
+		sections = append(sections, section)
+	}
+	paper.Abstract = strings.Join(sections, " ")
+	for _, author := range a.Authors {
+		name := cmp.Or(author.CollectiveName, strings.TrimSpace(author.ForeName+" "+author.LastName))
+		if name != "" {
+			paper.Authors = append(paper.Authors, name)
+		}
+	}
+	return paper, nil
+}
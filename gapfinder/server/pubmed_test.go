@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+const pubMedArticleXML = `<?xml version="1.0"?>
+<PubmedArticleSet>
+  <PubmedArticle>
+    <MedlineCitation>
+      <PMID>31452104</PMID>
+      <Article>
+        <Journal><JournalIssue><PubDate><Year>2019</Year><Month>Aug</Month></PubDate></JournalIssue></Journal>
+        <ArticleTitle>Sleep <i>and</i> memory in mice.</ArticleTitle>
+        <Abstract>
+          <AbstractText Label="BACKGROUND">Sleep &amp; memory
+            are linked.</AbstractText>
+          <AbstractText Label="RESULTS">Memory improved.</AbstractText>
+        </Abstract>
+        <AuthorList>
+          <Author><LastName>Lovelace</LastName><ForeName>Ada</ForeName></Author>
+          <Author><CollectiveName>Sleep Consortium</CollectiveName></Author>
+        </AuthorList>
+      </Article>
+    </MedlineCitation>
+  </PubmedArticle>
+</PubmedArticleSet>`
+
+func TestPubMedSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/efetch.fcgi" || q.Get("db") != "pubmed" || q.Get("api_key") != "key" {
+			t.Errorf("request = %s", r.URL)
+		}
+		if q.Get("id") != "31452104" {
+			w.Write([]byte(`<PubmedArticleSet></PubmedArticleSet>`))
+			return
+		}
+		w.Write([]byte(pubMedArticleXML))
+	}))
+	defer srv.Close()
+
+	src := &PubMedSource{BaseURL: srv.URL, APIKey: "key"}
+	paper, err := src.LookupPMID(context.Background(), "31452104")
+	if err != nil {
+		t.Fatalf("LookupPMID() error = %v", err)
+	}
+	want := Paper{
+		Title:    "Sleep and memory in mice.",
+		Authors:  []string{"Ada Lovelace", "Sleep Consortium"},
+		Abstract: "BACKGROUND: Sleep & memory are linked. RESULTS: Memory improved.",
+		URL:      "https://pubmed.ncbi.nlm.nih.gov/31452104/",
+	}
+	if paper.Title != want.Title || paper.Abstract != want.Abstract || paper.URL != want.URL ||
+		!slices.Equal(paper.Authors, want.Authors) || paper.Published.Year() != 2019 {
+		t.Errorf("paper = %+v, want %+v", paper, want)
+	}
+
+	if _, err := src.LookupPMID(context.Background(), "1"); !errors.Is(err, ErrPaperNotFound) {
+		t.Errorf("LookupPMID() error = %v, want ErrPaperNotFound", err)
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// Refine validates a request and has the model update the previous analysis
+// following the researcher's feedback, keeping what the feedback doesn't
+// concern. The paper metadata of an analysis by identifier is carried over.
+// It does the work of POST /analyze/refine.
+func (s *Server) Refine(ctx context.Context, req types.RefineRequest) (*types.AnalyzeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	req.Field = cmp.Or(req.Field, types.FieldGeneral)
+	prompt, err := render(refinePrompt, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.AnalyzeResponse
+	if err := s.complete(ctx, prompt, &result); err != nil {
+		return nil, err
+	}
+	result.KeyFindings = nonNil(result.KeyFindings)
+	result.Gaps = nonNil(result.Gaps)
+	result.SuggestedHypotheses = nonNil(result.SuggestedHypotheses)
+	result.Limitations = nonNil(result.Limitations)
+	result.MethodologyGaps = nonNil(result.MethodologyGaps)
+	result.FutureDirections = nonNil(result.FutureDirections)
+	result.Paper = req.Previous.Paper
+	result.ProcessingTime = elapsedSeconds(start)
+	return &result, nil
+}
+
+func (s *Server) handleRefine(w http.ResponseWriter, r *http.Request) {
+	var req types.RefineRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.Refine(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during refinement.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// GenerateReview validates a request and drafts a literature review of its
+// papers. Citations of numbers other than those of the papers are dropped,
+// sections of an unknown kind are treated as themes, and the cited papers
+// are listed as references. It does the work of POST /review.
+func (s *Server) GenerateReview(ctx context.Context, req types.ReviewRequest) (*types.ReviewResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	prompt, err := render(reviewPrompt, struct {
+		types.ReviewRequest
+		Field types.Field
+	}{req, cmp.Or(req.Field, types.FieldGeneral)})
+	if err != nil {
+		return nil, err
+	}
+
+	var result types.ReviewResponse
+	if err := s.complete(ctx, prompt, &result); err != nil {
+		return nil, err
+	}
+	cited := map[int]bool{}
+	for i := range result.Sections {
+		section := &result.Sections[i]
+		if !slices.Contains(reviewSectionKinds, section.Kind) {
+			section.Kind = types.SectionTheme
+		}
+		section.Paragraphs = nonNil(section.Paragraphs)
+		section.Citations = slices.DeleteFunc(nonNil(section.Citations), func(n int) bool {
+			return n < 1 || n > len(req.Papers)
+		})
+		for _, n := range section.Citations {
+			cited[n] = true
+		}
+	}
+	result.Sections = nonNil(result.Sections)
+	result.References = []types.ReviewReference{}
+	for n := 1; n <= len(req.Papers); n++ {
+		if cited[n] {
+			paper := req.Papers[n-1]
+			result.References = append(result.References, types.ReviewReference{
+				Number:  n,
+				Title:   paper.PaperTitle,
+				Authors: nonNil(paper.Authors),
+				URL:     paper.URL,
+			})
+		}
+	}
+	result.Title = cmp.Or(result.Title, "Literature review: "+req.Topic)
+	result.ProcessingTime = elapsedSeconds(start)
+	return &result, nil
+}
+
+var reviewSectionKinds = []string{types.SectionIntroduction, types.SectionTheme, types.SectionGaps, types.SectionConclusion}
+
+func (s *Server) handleReview(w http.ResponseWriter, r *http.Request) {
+	var req types.ReviewRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.GenerateReview(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during review generation.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,345 @@
+// Package server implements the AI Gap Finder HTTP API in Go. It serves the
+// same endpoints as the Python microservice, so existing clients work against
+// either implementation.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/llm"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/internal/uuid"
+)
+
+// Version is reported by /health
+const Version = "1.0.0"
+
+// Server serves the gap analysis API
+type Server struct {
+	backend llm.Backend
+	papers  PaperSource
+	logger  *slog.Logger
+	mux     *http.ServeMux
+	jobs    *jobStore
+	pages   *resultPages
+	history history
+	pdf     PDFExtractor
+	dois    DOIResolver
+	arxiv   ArxivResolver
+	pmids   PMIDResolver
+	funding FundingSource
+	models  []string
+	usage   *usageTracker
+	pricing map[string]Pricing
+
+	webhookSecret    []byte
+	webhookClient    *http.Client
+	privateCallbacks bool
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithPaperSource sets where /topic finds papers. By default the arXiv API is
+// searched.
+func WithPaperSource(papers PaperSource) Option {
+	return func(s *Server) {
+		s.papers = papers
+	}
+}
+
+// WithLogger makes the server log requests and failures to logger. By
+// default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// New creates a server that analyzes papers with backend
+func New(backend llm.Backend, opts ...Option) *Server {
+	s := &Server{
+		backend: backend,
+		papers:  NewArxivSource(nil),
+		dois:    NewCrossRefSource(nil),
+		arxiv:   NewArxivSource(nil),
+		pmids:   NewPubMedSource(nil),
+		funding: NewFeedFundingSource(nil),
+		logger:  slog.New(slog.DiscardHandler),
+		mux:     http.NewServeMux(),
+		jobs:    newJobStore(maxJobs),
+		pages:   newResultPages(),
+		usage:   newUsageTracker(time.Now()),
+		pricing: DefaultPricing,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.webhookClient == nil {
+		s.webhookClient = s.defaultCallbackClient()
+	}
+	s.mux.HandleFunc("POST /analyze", s.handleAnalyze)
+	s.mux.HandleFunc("POST /analyze/batch", s.handleBatch)
+	s.mux.HandleFunc("POST /analyze/estimate", s.handleEstimateAnalysis)
+	s.mux.HandleFunc("POST /analyze/pdf", s.handlePDF)
+	s.mux.HandleFunc("POST /analyze/doi", s.handleDOI)
+	s.mux.HandleFunc("POST /analyze/arxiv", s.handleArxiv)
+	s.mux.HandleFunc("POST /analyze/pmid", s.handlePMID)
+	s.mux.HandleFunc("POST /analyze/peer-review", s.handlePeerReview)
+	s.mux.HandleFunc("POST /analyze/refine", s.handleRefine)
+	s.mux.HandleFunc("POST /compare", s.handleCompare)
+	s.mux.HandleFunc("POST /gaps/deduplicate", s.handleDeduplicate)
+	s.mux.HandleFunc("POST /gaps/citations", s.handleCitations)
+	s.mux.HandleFunc("POST /gaps/related", s.handleRelatedWork)
+	s.mux.HandleFunc("POST /gaps/datasets", s.handleDatasets)
+	s.mux.HandleFunc("POST /gaps/groups", s.handleGroups)
+	s.mux.HandleFunc("POST /gaps/funding", s.handleFunding)
+	s.mux.HandleFunc("POST /hypotheses", s.handleHypotheses)
+	s.mux.HandleFunc("POST /hypotheses/methods", s.handleMethods)
+	s.mux.HandleFunc("POST /ideas/novelty", s.handleNovelty)
+	s.mux.HandleFunc("POST /ideas/proposal", s.handleProposal)
+	s.mux.HandleFunc("POST /review", s.handleReview)
+	s.mux.HandleFunc("POST /topic", s.handleTopic)
+	s.mux.HandleFunc("POST /topic/estimate", s.handleEstimate)
+	s.mux.HandleFunc("GET /topic/results", s.handleTopicResults)
+	s.mux.HandleFunc("POST /topic/stream", s.handleTopicStream)
+	s.mux.HandleFunc("POST /topic/trends", s.handleTrends)
+	s.mux.HandleFunc("GET /topic/ws", s.handleTopicWS)
+	s.mux.HandleFunc("POST /topic/jobs", s.handleSubmitTopic)
+	s.mux.HandleFunc("GET /jobs/{job_id}", s.handleJob)
+	s.mux.HandleFunc("POST /jobs/{job_id}/cancel", s.handleCancelJob)
+	s.mux.HandleFunc("GET /fields", s.handleFields)
+	s.mux.HandleFunc("GET /models", s.handleModels)
+	s.mux.HandleFunc("GET /usage", s.handleUsage)
+	s.mux.HandleFunc("GET /analyses", s.handleListAnalyses)
+	s.mux.HandleFunc("GET /analyses/{analysis_id}", s.handleAnalysis)
+	s.mux.HandleFunc("DELETE /analyses/{analysis_id}", s.handleDeleteAnalysis)
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	return s
+}
+
+type requestIDKey struct{}
+
+// ServeHTTP tags each request with an X-Request-ID, reusing the caller's so
+// failures can be traced across services, and echoes it in the response.
+// Requests are counted for /usage, and refused once a quota set by
+// WithQuota is used up.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get("X-Request-ID")
+	if id == "" {
+		id = uuid.New()
+	}
+	w.Header().Set("X-Request-ID", id)
+	ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+
+	start := time.Now()
+	if s.admit(w, r) {
+		s.mux.ServeHTTP(w, r.WithContext(ctx))
+	}
+	s.logger.InfoContext(ctx, "handled request",
+		"method", r.Method, "path", r.URL.Path, "request_id", id, "duration", time.Since(start))
+}
+
+func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	var req types.AnalyzeRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.Analyze(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during analysis.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req types.BatchAnalyzeRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.AnalyzeBatch(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during batch analysis.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleTopic(w http.ResponseWriter, r *http.Request) {
+	var req types.TopicRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.AnalyzeTopic(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during topic analysis.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleTopicStream sends each paper's result as a server-sent event as soon
+// as it is ready, followed by a summary event
+func (s *Server) handleTopicStream(w http.ResponseWriter, r *http.Request) {
+	var req types.TopicRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	// An unknown model is reported before the stream starts, like other
+	// invalid requests
+	if _, err := s.modelContext(r.Context(), req.Model, req.Temperature); err != nil {
+		s.fail(w, r, err, "An error occurred during topic analysis.")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	send := func(event string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	result, err := s.AnalyzeTopicStream(r.Context(), req, func(res types.TopicAnalysisResult) error {
+		return send("result", res)
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "request failed",
+			"path", r.URL.Path, "request_id", r.Context().Value(requestIDKey{}), "error", err)
+		_ = send("error", map[string]string{"detail": "An error occurred during topic analysis."})
+		return
+	}
+	result.IndividualResults = []types.TopicAnalysisResult{}
+	_ = send("summary", result)
+}
+
+func (s *Server) handleTopicResults(w http.ResponseWriter, r *http.Request) {
+	page, ok := s.TopicResults(r.URL.Query().Get("cursor"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Cursor not found or expired"})
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) handleSubmitTopic(w http.ResponseWriter, r *http.Request) {
+	var req types.TopicRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	var opts []JobOption
+	if u := r.Header.Get("Callback-URL"); u != "" {
+		opts = append(opts, WithCallback(u))
+	}
+	job, err := s.SubmitTopic(r.Context(), req, opts...)
+	if errors.Is(err, ErrTooManyJobs) {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"detail": "Too many jobs are running, try again later"})
+		return
+	}
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during topic analysis.")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.Job(r.PathValue("job_id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Job not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.CancelJob(r.PathValue("job_id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Job not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleFields(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Fields())
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Health())
+}
+
+// validator is implemented by the request types
+type validator interface {
+	Validate() error
+}
+
+// decodeRequest decodes and validates a JSON request body. It reports
+// problems the way FastAPI does, so clients see the same errors from both
+// implementations.
+func (s *Server) decodeRequest(w http.ResponseWriter, r *http.Request, req validator) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(req); err != nil {
+		writeValidationError(w, "body", fmt.Sprintf("invalid JSON body: %v", err))
+		return false
+	}
+	if err := req.Validate(); err != nil {
+		var verr *types.ValidationError
+		if errors.As(err, &verr) {
+			writeValidationError(w, verr.Field, verr.Message)
+			return false
+		}
+		writeValidationError(w, "body", err.Error())
+		return false
+	}
+	return true
+}
+
+func writeValidationError(w http.ResponseWriter, field, msg string) {
+	loc := []string{"body"}
+	if field != "body" {
+		loc = append(loc, strings.Split(field, ".")...)
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+		"detail": []map[string]any{{"loc": loc, "msg": msg, "type": "value_error"}},
+	})
+}
+
+// fail logs err and responds with a generic message, keeping internal
+// details such as backend errors out of the response. Validation errors,
+// such as for a model the server doesn't offer, are reported as such.
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	var verr *types.ValidationError
+	if errors.As(err, &verr) {
+		writeValidationError(w, verr.Field, verr.Message)
+		return
+	}
+	s.logger.ErrorContext(r.Context(), "request failed",
+		"path", r.URL.Path, "request_id", r.Context().Value(requestIDKey{}), "error", err)
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"detail": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// elapsedSeconds returns the time since start in seconds, rounded to two
+// decimals like the Python service
+func elapsedSeconds(start time.Time) float64 {
+	return float64(time.Since(start).Milliseconds()/10) / 100
+}
@@ -0,0 +1,1777 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/fixtures"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/llm"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/webhook"
+)
+
+type stubPapers []Paper
+
+// stubLookup resolves the identifiers it holds
+type stubLookup map[string]Paper
+
+func (l stubLookup) lookup(id string) (Paper, error) {
+	p, ok := l[id]
+	if !ok {
+		return Paper{}, ErrPaperNotFound
+	}
+	return p, nil
+}
+
+func (l stubLookup) LookupDOI(ctx context.Context, doi string) (Paper, error) { return l.lookup(doi) }
+
+func (l stubLookup) LookupPMID(ctx context.Context, pmid string) (Paper, error) {
+	return l.lookup(pmid)
+}
+
+func (p stubPapers) SearchPapers(ctx context.Context, query string, maxResults int) ([]Paper, error) {
+	return p[:min(len(p), maxResults)], nil
+}
+
+// searchFunc is a PaperSource calling a function
+type searchFunc func(query string, maxResults int) ([]Paper, error)
+
+func (f searchFunc) SearchPapers(ctx context.Context, query string, maxResults int) ([]Paper, error) {
+	return f(query, maxResults)
+}
+
+// newTestServer serves a Server using backend and returns a client for it
+func newTestServer(t *testing.T, backend llm.Backend, opts ...Option) *client.Client {
+	t.Helper()
+	srv := httptest.NewServer(New(backend, opts...))
+	t.Cleanup(srv.Close)
+	c, err := client.New(client.WithBaseURL(srv.URL), client.WithStrictDecoding())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestAnalyze(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return "```json\n" + `{"key_findings":["f"],"gaps":[{"gap_description":"g","confidence_score":0.9,"gap_type":"empirical","potential_impact":"i"}],
+			"limitations":[],"methodology_gaps":[],"suggested_hypotheses":[],"future_directions":[]}` + "\n```", nil
+	})
+	c := newTestServer(t, backend)
+
+	result, err := c.AnalyzeAbstract(context.Background(), types.AnalyzeRequest{
+		Title: "Sleep and memory", Abstract: "We studied sleep.", Authors: []string{"Ada", "Grace"},
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	if len(result.Gaps) != 1 || result.Gaps[0].GapDescription != "g" {
+		t.Errorf("Gaps = %+v", result.Gaps)
+	}
+	for _, want := range []string{"Title: Sleep and memory", "Field: general", "Authors: Ada, Grace"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestAnalyzeModel(t *testing.T) {
+	var params llm.Params
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		params = llm.ParamsFromContext(ctx)
+		return `{"key_findings":[],"gaps":[],"limitations":[],"methodology_gaps":[],"suggested_hypotheses":[],"future_directions":[]}`, nil
+	})
+	c := newTestServer(t, backend, WithModels("gpt-4", "gpt-4o-mini"))
+
+	req := types.AnalyzeRequest{Title: "T", Abstract: "A", Model: "gpt-4o-mini", Temperature: new(0.2)}
+	if _, err := c.AnalyzeAbstract(context.Background(), req); err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	if params.Model != "gpt-4o-mini" || params.Temperature == nil || *params.Temperature != 0.2 {
+		t.Errorf("backend params = %+v", params)
+	}
+
+	// An explicit zero temperature reaches the backend
+	req.Temperature = new(0.0)
+	if _, err := c.AnalyzeAbstract(context.Background(), req); err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	if params.Temperature == nil || *params.Temperature != 0 {
+		t.Errorf("backend temperature = %v, want 0", params.Temperature)
+	}
+
+	req.Model = "gpt-5"
+	_, err := c.AnalyzeAbstract(context.Background(), req)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 422 || !strings.Contains(apiErr.Message, "gpt-5") {
+		t.Errorf("error = %v, want 422 for the unknown model", err)
+	}
+
+	models, err := c.ListModels(context.Background())
+	if err != nil || models.DefaultModel != "gpt-4" || len(models.Models) != 2 {
+		t.Errorf("ListModels() = %+v, %v", models, err)
+	}
+}
+
+func TestAnalyzeLanguage(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"key_findings":[],"gaps":[],"limitations":[],"methodology_gaps":[],"suggested_hypotheses":[],"future_directions":[]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	req := types.AnalyzeRequest{Title: "Schlaf", Abstract: "Wir untersuchen Schlaf.", Language: "de"}
+	if _, err := c.AnalyzeAbstract(context.Background(), req); err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	if !strings.Contains(prompt, `ISO 639-1 code "de". Write the analysis in English.`) {
+		t.Errorf("prompt doesn't name the input language:\n%s", prompt)
+	}
+
+	req.TranslateOutput = true
+	if _, err := c.AnalyzeAbstract(context.Background(), req); err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	if !strings.Contains(prompt, "Write the analysis in that language") {
+		t.Errorf("prompt doesn't ask for a translated analysis:\n%s", prompt)
+	}
+}
+
+func TestAnalyzeInstructions(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"key_findings":[],"gaps":[],"limitations":[],"methodology_gaps":[],"suggested_hypotheses":[],"future_directions":[]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	req := types.AnalyzeRequest{Title: "Sleep", Abstract: "We study sleep.", Instructions: "Ignore funding limitations."}
+	if _, err := c.AnalyzeAbstract(context.Background(), req); err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	if !strings.Contains(prompt, "Additional instructions from the researcher:\nIgnore funding limitations.") {
+		t.Errorf("prompt doesn't hold the instructions:\n%s", prompt)
+	}
+}
+
+func TestTopicUnknownModel(t *testing.T) {
+	c := newTestServer(t, nil, WithPaperSource(stubPapers{}))
+	_, err := c.AnalyzeTopicAsync(context.Background(), types.TopicRequest{Topic: "sleep", Model: "gpt-4"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 422 {
+		t.Errorf("error = %v, want 422 as the server offers no models", err)
+	}
+}
+
+func TestAnalyzeFullText(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	fullText := "1. Introduction\nSleep matters.\n2. Methods\nWe recorded 12 mice.\n3. Results\nMemory improved.\nReferences\n[1] Someone"
+	_, err := c.AnalyzeAbstract(context.Background(), types.AnalyzeRequest{
+		Title: "Sleep and memory", Abstract: "We studied sleep.", FullText: fullText, Mode: types.ModeFullText,
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeAbstract() error = %v", err)
+	}
+	for _, want := range []string{"2. Methods\nWe recorded 12 mice.", "3. Results\nMemory improved."} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	for _, unwanted := range []string{"Sleep matters.", "[1] Someone"} {
+		if strings.Contains(prompt, unwanted) {
+			t.Errorf("prompt has %q outside the analyzed sections", unwanted)
+		}
+	}
+}
+
+func TestComparePapers(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"overlapping_findings":["Sleep matters"],"conflicting_conclusions":[{"topic":"Recall","paper_a_position":"Improves","paper_b_position":"No effect"}]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	result, err := c.ComparePapers(context.Background(), types.CompareRequest{
+		PaperA: types.AnalyzeRequest{Title: "Sleep helps memory", Abstract: "Sleep improves recall.", Field: types.FieldNeuroscience},
+		PaperB: types.AnalyzeRequest{Title: "Sleep and recall", Abstract: "Sleep has no effect on recall.", Field: types.FieldNeuroscience},
+	})
+	if err != nil {
+		t.Fatalf("ComparePapers() error = %v", err)
+	}
+	if len(result.ConflictingConclusions) != 1 || result.GapsUniqueToB == nil {
+		t.Errorf("result = %+v", result)
+	}
+	for _, want := range []string{"field of neuroscience", "Sleep improves recall.", "Sleep has no effect on recall."} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestSimulateReview(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		if !strings.Contains(p, "peer reviewer") {
+			return `{"key_findings":["Sleep improves recall"],"gaps":[{"gap_description":"Small sample","confidence_score":0.9,"gap_type":"empirical","potential_impact":"i"}],
+				"limitations":["Only young adults"],"methodology_gaps":[],"suggested_hypotheses":[],"future_directions":[]}`, nil
+		}
+		prompt = p
+		return `{"summary":"A small study.","recommendation":"maybe",
+			"critiques":[{"aspect":"clarity","severity":"minor","comment":"Define recall"},{"aspect":"style","severity":"major","comment":"Unknown aspect"},
+				{"aspect":"soundness","severity":"major","comment":"Twelve participants"},{"aspect":"novelty","severity":"","comment":" "}]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	result, err := c.SimulateReview(context.Background(), types.AnalyzeRequest{
+		Title: "Sleep and memory", Abstract: "We studied sleep.", Field: types.FieldNeuroscience,
+	})
+	if err != nil {
+		t.Fatalf("SimulateReview() error = %v", err)
+	}
+	want := types.PeerReviewResponse{
+		Recommendation: types.RecommendMajorRevision,
+		Summary:        "A small study.",
+		Critiques: []types.Critique{
+			{Aspect: types.AspectSoundness, Severity: types.SeverityMajor, Comment: "Twelve participants"},
+			{Aspect: types.AspectClarity, Severity: types.SeverityMinor, Comment: "Define recall"},
+		},
+		MissingBaselines: []string{},
+		Gaps:             []types.ResearchGap{{GapDescription: "Small sample", ConfidenceScore: 0.9, GapType: types.GapEmpirical, PotentialImpact: "i"}},
+	}
+	result.ProcessingTime, result.RequestID = 0, ""
+	if !reflect.DeepEqual(*result, want) {
+		t.Errorf("result = %+v, want %+v", *result, want)
+	}
+	for _, want := range []string{"field of neuroscience", "Abstract: We studied sleep.", "Limitations: Only young adults", "- Research gap (empirical): Small sample"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestRefine(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"key_findings":["Sleep improves recall"],"gaps":[{"gap_description":"Small sample of young adults","confidence_score":0.9,"gap_type":"empirical","potential_impact":"i"}]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	paper := &types.PaperInfo{Source: "crossref", ID: "10.1/x", Title: "Sleep and memory", Authors: []string{"Ann"}, Abstract: "We studied sleep."}
+	previous := &types.AnalyzeResponse{
+		KeyFindings: []string{"Sleep improves recall"},
+		Gaps: []types.ResearchGap{
+			{GapDescription: "Small sample", ConfidenceScore: 0.9, GapType: types.GapEmpirical, PotentialImpact: "i"},
+			{GapDescription: "No funding", ConfidenceScore: 0.3, GapType: types.GapTechnical, PotentialImpact: "none"},
+		},
+		Paper: paper,
+	}
+	result, err := c.Refine(context.Background(), previous, "Gap 2 is irrelevant; expand on the sample")
+	if err != nil {
+		t.Fatalf("Refine() error = %v", err)
+	}
+	want := types.AnalyzeResponse{
+		KeyFindings:         []string{"Sleep improves recall"},
+		Gaps:                []types.ResearchGap{{GapDescription: "Small sample of young adults", ConfidenceScore: 0.9, GapType: types.GapEmpirical, PotentialImpact: "i"}},
+		SuggestedHypotheses: []types.Hypothesis{},
+		Limitations:         []string{},
+		MethodologyGaps:     []string{},
+		FutureDirections:    []string{},
+		Paper:               paper,
+	}
+	result.ProcessingTime, result.RequestID = 0, ""
+	if !reflect.DeepEqual(*result, want) {
+		t.Errorf("result = %+v, want %+v", *result, want)
+	}
+	for _, want := range []string{"Abstract: We studied sleep.", "2. No funding (technical, confidence 0.3): none", "feedback:\nGap 2 is irrelevant"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestDeduplicateGaps(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		// Clusters two gaps, refers to one that doesn't exist and repeats one
+		return `{"clusters":[
+			{"gap":{"gap_description":"Samples are small","confidence_score":0.5,"gap_type":"empirical"},
+			 "members":[{"analysis":0,"gap":0},{"analysis":1,"gap":0},{"analysis":4,"gap":0}]},
+			{"gap":{"gap_description":"Repeated"},"members":[{"analysis":0,"gap":0}]}]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	result, err := c.DeduplicateGaps(context.Background(), types.DeduplicateRequest{Analyses: [][]types.ResearchGap{
+		{{GapDescription: "Small sample", ConfidenceScore: 0.6, GapType: "empirical", PotentialImpact: "High"}, {GapDescription: "No replication", ConfidenceScore: 0.7}},
+		{{GapDescription: "Few participants", ConfidenceScore: 0.9, GapType: "empirical"}},
+	}})
+	if err != nil {
+		t.Fatalf("DeduplicateGaps() error = %v", err)
+	}
+	want := []types.GapCluster{
+		{
+			Gap:     types.ResearchGap{GapDescription: "Samples are small", ConfidenceScore: 0.9, GapType: "empirical", PotentialImpact: "High"},
+			Members: []types.GapRef{{Analysis: 0, Gap: 0}, {Analysis: 1, Gap: 0}},
+		},
+		{
+			Gap:     types.ResearchGap{GapDescription: "No replication", ConfidenceScore: 0.7},
+			Members: []types.GapRef{{Analysis: 0, Gap: 1}},
+		},
+	}
+	if !reflect.DeepEqual(result.Clusters, want) {
+		t.Errorf("Clusters = %+v, want %+v", result.Clusters, want)
+	}
+	if !strings.Contains(prompt, "[1.0] (empirical) Few participants") {
+		t.Errorf("prompt does not label the gaps:\n%s", prompt)
+	}
+}
+
+func TestDeduplicateFixtures(t *testing.T) {
+	for _, name := range fixtures.DeduplicateNames() {
+		t.Run(name, func(t *testing.T) {
+			fixture, err := fixtures.Deduplicate(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+				return string(fixture.Reply), nil
+			})
+			c := newTestServer(t, backend)
+
+			result, err := c.DeduplicateGaps(context.Background(), fixture.Request)
+			if err != nil {
+				t.Fatalf("DeduplicateGaps() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Clusters, fixture.Clusters) {
+				t.Errorf("Clusters = %+v, want %+v", result.Clusters, fixture.Clusters)
+			}
+		})
+	}
+}
+
+func TestSuggestCitations(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"citations":[{"paper":2,"reason":"Reports a null result"},{"paper":9,"reason":"Does not exist"},{"paper":2,"reason":"Repeated"}]}`, nil
+	})
+	papers := stubPapers{
+		{Title: "Sleep helps memory", Authors: []string{"A. Author"}, Abstract: "Sleep improves recall.", URL: "http://arxiv.org/abs/1"},
+		{Title: "Sleep and recall", Abstract: "No effect.", URL: "http://arxiv.org/abs/2"},
+	}
+	c := newTestServer(t, backend, WithPaperSource(papers))
+
+	result, err := c.SuggestCitations(context.Background(), types.ResearchGap{GapDescription: "Small samples in sleep studies", ConfidenceScore: 0.8})
+	if err != nil {
+		t.Fatalf("SuggestCitations() error = %v", err)
+	}
+	want := []types.Citation{{Title: "Sleep and recall", Authors: []string{}, URL: "http://arxiv.org/abs/2", Reason: "Reports a null result"}}
+	if !reflect.DeepEqual(result.Citations, want) {
+		t.Errorf("Citations = %+v, want %+v", result.Citations, want)
+	}
+	for _, want := range []string{"Research gap: Small samples in sleep studies", "[1] Sleep helps memory", "up to 5"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestSuggestCitationsWithoutCandidates(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		t.Error("backend called without candidate papers")
+		return "", nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{}))
+
+	result, err := c.SuggestCitations(context.Background(), types.ResearchGap{GapDescription: "Small samples"})
+	if err != nil {
+		t.Fatalf("SuggestCitations() error = %v", err)
+	}
+	if result.Citations == nil || len(result.Citations) != 0 {
+		t.Errorf("Citations = %#v, want empty", result.Citations)
+	}
+}
+
+func TestCheckNovelty(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"status":"mostly new","novelty_score":0.5,"summary":"Spindles were linked to recall in adults only.",
+			"papers":[{"paper":1,"reason":"Links spindles to recall"},{"paper":1,"reason":"Repeated"},{"paper":7,"reason":"Does not exist"}]}`, nil
+	})
+	papers := stubPapers{
+		{Title: "Spindles and memory", Authors: []string{"Ann"}, Abstract: "Spindles predict recall.", URL: "http://arxiv.org/abs/1",
+			Published: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Title: "Sleep in children", Abstract: "Children sleep more."},
+	}
+	c := newTestServer(t, backend, WithPaperSource(papers))
+
+	result, err := c.CheckNovelty(context.Background(), "Sleep spindles predict recall in children", types.FieldNeuroscience)
+	if err != nil {
+		t.Fatalf("CheckNovelty() error = %v", err)
+	}
+	want := []types.Citation{{Title: "Spindles and memory", Authors: []string{"Ann"}, URL: "http://arxiv.org/abs/1", Reason: "Links spindles to recall"}}
+	// The status the model made up is derived from the score
+	if result.Status != types.NoveltyPartiallyAddressed || result.NoveltyScore != 0.5 || result.PapersSearched != 2 ||
+		!reflect.DeepEqual(result.SupportingPapers, want) {
+		t.Errorf("result = %+v, want partially addressed with papers %+v", result, want)
+	}
+	for _, want := range []string{"Proposed idea: Sleep spindles predict recall in children", "[1] Spindles and memory (2024)", "[2] Sleep in children\n"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestCheckNoveltyWithoutPapers(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		t.Error("backend called without related papers")
+		return "", nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{}))
+
+	result, err := c.CheckNovelty(context.Background(), "Sleep spindles predict recall", "")
+	if err != nil {
+		t.Fatalf("CheckNovelty() error = %v", err)
+	}
+	if result.Status != types.NoveltyOpen || result.NoveltyScore != 1 || result.SupportingPapers == nil {
+		t.Errorf("result = %+v, want open", result)
+	}
+}
+
+func TestAnalyzeProposal(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"alignment_score":1.4,"gaps":[{"gap_description":"Small samples","coverage":0.8,"comment":"Recruits 500 children"},{"gap_description":" ","coverage":1,"comment":"Blank"}],
+			"novelty_conflicts":[
+				{"claim":"First study of spindles in children","papers":[{"paper":1,"reason":"Studied children in 2024"},{"paper":1,"reason":"Repeated"},{"paper":8,"reason":"Does not exist"}]},
+				{"claim":"Invented conflict","papers":[{"paper":8,"reason":"Does not exist"}]}],
+			"summary":"Well aligned."}`, nil
+	})
+	var query string
+	papers := searchFunc(func(q string, n int) ([]Paper, error) {
+		query = q
+		return []Paper{{Title: "Spindles in children", Authors: []string{"Ann"}, Published: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}}, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(papers))
+
+	gaps := []types.ResearchGap{{GapDescription: "Small samples", ConfidenceScore: 0.8}}
+	var result types.ProposalResponse
+	err := c.Do(context.Background(), http.MethodPost, "/ideas/proposal",
+		types.ProposalRequest{Proposal: "  Sleep spindles in children\nWe are the first to study spindles in children.", Gaps: gaps}, &result)
+	if err != nil {
+		t.Fatalf("POST /ideas/proposal error = %v", err)
+	}
+	want := types.ProposalResponse{
+		AlignmentScore: 1,
+		Gaps:           []types.GapAlignment{{GapDescription: "Small samples", Coverage: 0.8, Comment: "Recruits 500 children"}},
+		NoveltyConflicts: []types.NoveltyConflict{{
+			Claim:  "First study of spindles in children",
+			Papers: []types.Citation{{Title: "Spindles in children", Authors: []string{"Ann"}, Reason: "Studied children in 2024"}},
+		}},
+		Summary:        "Well aligned.",
+		PapersSearched: 1,
+	}
+	result.ProcessingTime = 0
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+	if query != "Sleep spindles in children" {
+		t.Errorf("query = %q, want the proposal's first line", query)
+	}
+	for _, want := range []string{"Known research gaps:\n[1] Small samples", "[1] Spindles in children (2024)", "as written in the list"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+// stubFunding is a FundingSource listing the calls it holds
+type stubFunding []types.FundingCall
+
+func (f stubFunding) OpenCalls(ctx context.Context) ([]types.FundingCall, error) {
+	return f, nil
+}
+
+func TestMatchFunding(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"matches":[
+			{"call":2,"gaps":[2],"relevance_score":0.4,"reason":"Funds replications"},
+			{"call":1,"gaps":[1,1,3],"relevance_score":1.5,"reason":"Funds sleep cohorts"},
+			{"call":2,"gaps":[1],"relevance_score":0.9,"reason":"Repeated"},
+			{"call":9,"gaps":[1],"relevance_score":0.9,"reason":"Does not exist"}]}`, nil
+	})
+	funding := stubFunding{
+		{Agency: "NSF", Title: "Replication in the social sciences", URL: "https://nsf.gov/1"},
+		{Agency: "NIH", Title: "Sleep cohort studies", URL: "https://nih.gov/2", Summary: "Large samples of sleepers."},
+		{Agency: "NIH", Title: "Cancer imaging", URL: "https://nih.gov/3"},
+	}
+	c := newTestServer(t, backend, WithFundingSource(funding))
+
+	gaps := []types.ResearchGap{
+		{GapDescription: "Small samples in sleep studies", ConfidenceScore: 0.8},
+		{GapDescription: "No replication of findings", ConfidenceScore: 0.6},
+	}
+	result, err := c.MatchFunding(context.Background(), gaps)
+	if err != nil {
+		t.Fatalf("MatchFunding() error = %v", err)
+	}
+	want := []types.FundingMatch{
+		{Call: funding[1], Gaps: []int{0}, RelevanceScore: 1, Reason: "Funds sleep cohorts"},
+		{Call: funding[0], Gaps: []int{1}, RelevanceScore: 0.4, Reason: "Funds replications"},
+	}
+	if !reflect.DeepEqual(result.Matches, want) || result.CallsSearched != 3 {
+		t.Errorf("result = %+v, want matches %+v of 3 calls", result, want)
+	}
+	// Calls are shown sharing the most words with the gaps first, and the
+	// imaging call shares none
+	for _, want := range []string{"[2] No replication of findings", "[1] Sleep cohort studies (NIH)", "Summary: Large samples of sleepers.", "up to 10"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	if strings.Contains(prompt, "Cancer imaging") {
+		t.Error("prompt holds a call unrelated to the gaps")
+	}
+}
+
+func TestGetRelatedWork(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"papers":[
+			{"paper":2,"similarity":0.4,"addresses_gap":false,"reason":"Same population"},
+			{"paper":1,"similarity":1.3,"addresses_gap":true,"reason":"Runs a large sample"},
+			{"paper":2,"similarity":0.9,"addresses_gap":true,"reason":"Repeated"},
+			{"paper":5,"similarity":0.9,"addresses_gap":true,"reason":"Does not exist"}]}`, nil
+	})
+	papers := stubPapers{
+		{Title: "A large sleep cohort", Authors: []string{"Ann"}, URL: "http://arxiv.org/abs/1", Published: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{Title: "Sleep in students", Abstract: "We study 20 students."},
+	}
+	c := newTestServer(t, backend, WithPaperSource(papers))
+
+	result, err := c.GetRelatedWork(context.Background(), types.ResearchGap{GapDescription: "Small samples in sleep studies", ConfidenceScore: 0.8})
+	if err != nil {
+		t.Fatalf("GetRelatedWork() error = %v", err)
+	}
+	want := []types.RelatedPaper{
+		{Title: "A large sleep cohort", Authors: []string{"Ann"}, URL: "http://arxiv.org/abs/1", Year: 2025, Similarity: 1, AddressesGap: true, Reason: "Runs a large sample"},
+		{Title: "Sleep in students", Authors: []string{}, Similarity: 0.4, Reason: "Same population"},
+	}
+	if !reflect.DeepEqual(result.Papers, want) {
+		t.Errorf("Papers = %+v, want %+v", result.Papers, want)
+	}
+	for _, want := range []string{"Research gap: Small samples in sleep studies", "[1] A large sleep cohort (2025)", "up to 5"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestFindResearchGroups(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"gaps":[{"gap":1,"papers":[1,2,3,4,4,9]},{"gap":5,"papers":[1]}]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	topic := &types.TopicResponse{
+		Topic: "sleep",
+		CommonGaps: []types.ResearchGap{
+			{GapDescription: "Small samples", ConfidenceScore: 0.8},
+			{GapDescription: "No replication", ConfidenceScore: 0.6},
+		},
+		IndividualResults: []types.TopicAnalysisResult{
+			{PaperTitle: "P1", Authors: []string{"Ann", "Bob"}},
+			{PaperTitle: "P2", Authors: []string{"Cem"}},
+			{PaperTitle: "P3", Authors: []string{"Cem", "Ann"}},
+			{PaperTitle: "P4", Authors: []string{"Dee"}},
+			{PaperTitle: "P5"},
+		},
+	}
+	result, err := c.FindResearchGroups(context.Background(), topic)
+	if err != nil {
+		t.Fatalf("FindResearchGroups() error = %v", err)
+	}
+	want := []types.AuthorGroup{
+		{Authors: []string{"Ann", "Bob", "Cem"}, Papers: []string{"P1", "P2", "P3"}},
+		{Authors: []string{"Dee"}, Papers: []string{"P4"}},
+	}
+	if len(result.CommonGaps) != 2 || !reflect.DeepEqual(result.CommonGaps[0].ResearchGroups, want) {
+		t.Errorf("CommonGaps = %+v, want groups %+v for the first", result.CommonGaps, want)
+	}
+	if result.CommonGaps[1].GapDescription != "No replication" || len(result.CommonGaps[1].ResearchGroups) != 0 {
+		t.Errorf("CommonGaps[1] = %+v, want no groups", result.CommonGaps[1])
+	}
+	for _, want := range []string{"[2] No replication", "[5] P5"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestAnalyzeTrends(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"gaps":[
+			{"gap_description":"Small samples","gap_type":"empirical","papers":[1,2]},
+			{"gap_description":"No replication","gap_type":"Methodological","papers":[3,1,1]},
+			{"gap_description":"Noisy sensors","gap_type":"data","papers":[3]},
+			{"gap_description":"Unknown paper","gap_type":"data","papers":[9]}]}`, nil
+	})
+	year := time.Now().UTC().Year()
+	published := func(year int) time.Time { return time.Date(year, time.March, 1, 0, 0, 0, 0, time.UTC) }
+	papers := stubPapers{
+		{Title: "Recent", Published: published(year)},
+		{Title: "Old", Published: published(year - 2)},
+		{Title: "Undated"},
+		{Title: "Too old", Published: published(year - 3)},
+		{Title: "Middle", Published: published(year - 1)},
+	}
+	c := newTestServer(t, backend, WithPaperSource(papers))
+
+	result, err := c.AnalyzeTrends(context.Background(), "sleep", 3)
+	if err != nil {
+		t.Fatalf("AnalyzeTrends() error = %v", err)
+	}
+	if result.FromYear != year-2 || result.ToYear != year || result.PapersAnalyzed != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	wantGaps := []types.GapTrend{
+		{GapDescription: "Small samples", GapType: types.GapEmpirical, Years: []int{year - 2, year - 1}, Status: types.TrendClosing},
+		{GapDescription: "No replication", GapType: types.GapMethodological, Years: []int{year - 2, year}, Status: types.TrendPersistent},
+		{GapDescription: "Noisy sensors", GapType: types.GapData, Years: []int{year}, Status: types.TrendEmerging},
+	}
+	if !reflect.DeepEqual(result.Gaps, wantGaps) {
+		t.Errorf("Gaps = %+v, want %+v", result.Gaps, wantGaps)
+	}
+	wantYears := []types.TrendYear{
+		{Year: year - 2, PaperCount: 1, GapTypes: []types.GapTypeCount{{GapType: types.GapEmpirical, Count: 1}, {GapType: types.GapMethodological, Count: 1}}},
+		{Year: year - 1, PaperCount: 1, GapTypes: []types.GapTypeCount{{GapType: types.GapEmpirical, Count: 1}}},
+		{Year: year, PaperCount: 1, GapTypes: []types.GapTypeCount{{GapType: types.GapData, Count: 1}, {GapType: types.GapMethodological, Count: 1}}},
+	}
+	if !reflect.DeepEqual(result.Years, wantYears) {
+		t.Errorf("Years = %+v, want %+v", result.Years, wantYears)
+	}
+	if want := fmt.Sprintf("[1] %d: Old", year-2); !strings.Contains(prompt, want) || strings.Contains(prompt, "Too old") {
+		t.Errorf("prompt = %q, want it to start with %q and leave out older papers", prompt, want)
+	}
+}
+
+// datedPapers is a DatedPaperSource recording the years searched
+type datedPapers struct {
+	stubPapers
+	from, to *int
+}
+
+func (p datedPapers) SearchPapersPublished(ctx context.Context, query string, fromYear, toYear, maxResults int) ([]Paper, error) {
+	*p.from, *p.to = fromYear, toYear
+	return p.SearchPapers(ctx, query, maxResults)
+}
+
+func TestAnalyzeTrendsSearchesYears(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return `{"gaps":[]}`, nil
+	})
+	year := time.Now().UTC().Year()
+	var from, to int
+	papers := datedPapers{stubPapers{{Title: "Old", Published: time.Date(year-9, time.May, 1, 0, 0, 0, 0, time.UTC)}}, &from, &to}
+	c := newTestServer(t, backend, WithPaperSource(papers))
+
+	result, err := c.AnalyzeTrends(context.Background(), "sleep", 10)
+	if err != nil {
+		t.Fatalf("AnalyzeTrends() error = %v", err)
+	}
+	if from != year-9 || to != year || result.PapersAnalyzed != 1 {
+		t.Errorf("searched %d to %d, analyzed %d papers; want %d to %d and 1", from, to, result.PapersAnalyzed, year-9, year)
+	}
+}
+
+func TestAnalyzeTrendsWithoutPapers(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		t.Error("backend called without papers")
+		return "", nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "Undated"}}))
+
+	result, err := c.AnalyzeTrends(context.Background(), "sleep", 0)
+	if err != nil {
+		t.Fatalf("AnalyzeTrends() error = %v", err)
+	}
+	if len(result.Years) != defaultYearsBack || result.Gaps == nil || len(result.Gaps) != 0 || result.PapersAnalyzed != 0 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestGenerateHypotheses(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"hypotheses":[{"hypothesis":"Larger samples","rationale":"Small sample","feasibility_score":0.7,"required_methods":["RCT"]}]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	result, err := c.GenerateHypotheses(context.Background(), []types.ResearchGap{
+		{GapDescription: "Small sample", ConfidenceScore: 0.8, GapType: "empirical", PotentialImpact: "Better estimates"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateHypotheses() error = %v", err)
+	}
+	if len(result.Hypotheses) != 1 || result.Hypotheses[0].FeasibilityScore != 0.7 {
+		t.Errorf("result = %+v", result)
+	}
+	for _, want := range []string{"field of general", "1. (empirical) Small sample Potential impact: Better estimates"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestRecommendMethods(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"designs":[{"name":"Crossover trial","description":"d","sample_size":"40 participants for 80% power"},{"name":" ","description":"Unnamed"}],
+			"datasets":[{"name":"Sleep-EDF","description":"Polysomnography","url":"https://physionet.org/content/sleep-edfx/"},{"name":"Local data","description":"d","url":"ask the authors"}]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	result, err := c.RecommendMethods(context.Background(), types.Hypothesis{
+		Hypothesis: "Longer sleep improves recall", FeasibilityScore: 0.7, RequiredMethods: []string{"RCT", "EEG"},
+	})
+	if err != nil {
+		t.Fatalf("RecommendMethods() error = %v", err)
+	}
+	want := types.MethodsResponse{
+		Designs: []types.ExperimentalDesign{{
+			Name: "Crossover trial", Description: "d", Methods: []string{}, SampleSize: "40 participants for 80% power", Limitations: []string{},
+		}},
+		Datasets: []types.Dataset{
+			{Name: "Sleep-EDF", Description: "Polysomnography", URL: "https://physionet.org/content/sleep-edfx/"},
+			{Name: "Local data", Description: "d"},
+		},
+	}
+	result.ProcessingTime, result.RequestID = 0, ""
+	if !reflect.DeepEqual(*result, want) {
+		t.Errorf("result = %+v, want %+v", *result, want)
+	}
+	for _, want := range []string{"field of general", "Hypothesis: Longer sleep improves recall", "Required methods: RCT, EEG"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestSuggestDatasets(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"datasets":[
+			{"name":"Sleep-EDF","description":"Polysomnography","url":"https://physionet.org/content/sleep-edfx/","license":"ODC-By 1.0"},
+			{"name":"sleep-edf ","description":"Repeated","url":"https://physionet.org/"},
+			{"name":"Local data","description":"No URL"},
+			{"name":"MASS","description":"Montreal archive","url":"http://ceams-carsm.ca/mass/"}]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	result, err := c.SuggestDatasets(context.Background(), types.DatasetsRequest{
+		Hypothesis:  &types.Hypothesis{Hypothesis: "Longer sleep improves recall", RequiredMethods: []string{"EEG"}},
+		MaxDatasets: 1,
+	})
+	if err != nil {
+		t.Fatalf("SuggestDatasets() error = %v", err)
+	}
+	want := []types.Dataset{{Name: "Sleep-EDF", Description: "Polysomnography", URL: "https://physionet.org/content/sleep-edfx/", License: "ODC-By 1.0"}}
+	if !reflect.DeepEqual(result.Datasets, want) {
+		t.Errorf("Datasets = %+v, want %+v", result.Datasets, want)
+	}
+	for _, want := range []string{"test the following hypothesis:\nLonger sleep improves recall", "Required methods: EEG", "up to 1 publicly"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	if strings.Contains(prompt, "research gap:") {
+		t.Error("prompt describes a gap that wasn't given")
+	}
+}
+
+func TestGenerateReview(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"title":"Sleep and memory: a review","sections":[
+			{"kind":"theme","heading":"Recall","paragraphs":["Sleep helps [1]."],"citations":[1,7]},
+			{"kind":"summary","heading":"Outlook","paragraphs":["More work is needed."]}]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	result, err := c.GenerateReview(context.Background(), &types.TopicResponse{
+		Topic: "sleep and memory",
+		IndividualResults: []types.TopicAnalysisResult{
+			{PaperTitle: "Sleep helps memory", Authors: []string{"A. Author"}, Abstract: "Sleep improves recall.", URL: "http://arxiv.org/abs/1",
+				Gaps: []types.ResearchGap{{GapDescription: "Small sample"}}},
+			{PaperTitle: "Sleep and recall", Abstract: "No effect."},
+		},
+	})
+	if err != nil {
+		t.Fatalf("GenerateReview() error = %v", err)
+	}
+	if !slices.Equal(result.Sections[0].Citations, []int{1}) || result.Sections[1].Kind != types.SectionTheme || result.Sections[1].Citations == nil {
+		t.Errorf("Sections = %+v", result.Sections)
+	}
+	want := []types.ReviewReference{{Number: 1, Title: "Sleep helps memory", Authors: []string{"A. Author"}, URL: "http://arxiv.org/abs/1"}}
+	if !reflect.DeepEqual(result.References, want) {
+		t.Errorf("References = %+v, want %+v", result.References, want)
+	}
+	for _, want := range []string{"[2] Sleep and recall", "Gaps: Small sample", "None identified"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+}
+
+func TestBatchReportsFailuresPerItem(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		if strings.Contains(p, "Title: bad") {
+			return "not json", nil
+		}
+		return `{"key_findings":["f"],"gaps":[],"limitations":[],"methodology_gaps":[],"suggested_hypotheses":[],"future_directions":[]}`, nil
+	})
+	c := newTestServer(t, backend)
+
+	reqs := []types.AnalyzeRequest{{Title: "good", Abstract: "A"}, {Title: "bad", Abstract: "A"}, {Title: "good", Abstract: "A"}}
+	batch, err := c.AnalyzeBatch(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("AnalyzeBatch() error = %v", err)
+	}
+	if len(batch.Results) != 3 {
+		t.Fatalf("got %d results, want 3", len(batch.Results))
+	}
+	for i, r := range batch.Results {
+		failed := i == 1
+		if r.Index != i || (r.Result == nil) != failed || (r.Error != "") != failed {
+			t.Errorf("Results[%d] = %+v", i, r)
+		}
+	}
+}
+
+func TestBatchReportsValidationErrors(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return `{"key_findings":["f"],"gaps":[],"limitations":[],"methodology_gaps":[],"suggested_hypotheses":[],"future_directions":[]}`, nil
+	})
+	c := newTestServer(t, backend, WithModels("gpt-4"))
+
+	reqs := []types.AnalyzeRequest{{Title: "good", Abstract: "A"}, {Title: "other model", Abstract: "A", Model: "claude"}}
+	batch, err := c.AnalyzeBatch(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("AnalyzeBatch() error = %v", err)
+	}
+	if r := batch.Results[0]; r.Result == nil || r.Error != "" {
+		t.Errorf("Results[0] = %+v", r)
+	}
+	if r := batch.Results[1]; r.Result != nil || !strings.Contains(r.Error, `unknown model "claude"`) {
+		t.Errorf("Results[1] = %+v, want unknown model error", r)
+	}
+}
+
+func TestAnalyzePDF(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[]}`, nil
+	})
+	extractor := PDFExtractorFunc(func(ctx context.Context, pdf []byte) (string, string, error) {
+		if string(pdf) != "%PDF-1.4" {
+			return "", "", errors.New("not a PDF")
+		}
+		return "", "We study sleep in mice.", nil
+	})
+	c := newTestServer(t, backend, WithPDFExtractor(extractor))
+	ctx := context.Background()
+
+	if _, err := c.AnalyzePDF(ctx, strings.NewReader("%PDF-1.4"), types.PDFMetadata{Filename: "sleep-study.pdf"}); err != nil {
+		t.Fatalf("AnalyzePDF() error = %v", err)
+	}
+	if !strings.Contains(prompt, "sleep-study") || !strings.Contains(prompt, "We study sleep in mice.") {
+		t.Errorf("prompt lacks the file name or text:\n%s", prompt)
+	}
+
+	_, err := c.AnalyzePDF(ctx, strings.NewReader("garbage"), types.PDFMetadata{})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("AnalyzePDF() error = %v, want 422", err)
+	}
+}
+
+func TestAnalyzeDOI(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[]}`, nil
+	})
+	dois := stubLookup{
+		"10.1000/xyz":        {Title: "Sleep and memory", Abstract: "We study sleep.", Authors: []string{"Ada"}},
+		"10.1000/noabstract": {Title: "Editorial"},
+	}
+	c := newTestServer(t, backend, WithDOIResolver(dois))
+	ctx := context.Background()
+
+	result, err := c.AnalyzeDOI(ctx, "https://doi.org/10.1000/xyz")
+	if err != nil {
+		t.Fatalf("AnalyzeDOI() error = %v", err)
+	}
+	if !strings.Contains(prompt, "Title: Sleep and memory") || !strings.Contains(prompt, "We study sleep.") {
+		t.Errorf("prompt lacks the paper's metadata:\n%s", prompt)
+	}
+	if p := result.Paper; p == nil || p.Source != "crossref" || p.ID != "10.1000/xyz" || p.Title != "Sleep and memory" {
+		t.Errorf("Paper = %+v", result.Paper)
+	}
+
+	for doi, status := range map[string]int{"10.1000/missing": http.StatusNotFound, "10.1000/noabstract": http.StatusUnprocessableEntity} {
+		_, err := c.AnalyzeDOI(ctx, doi)
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != status {
+			t.Errorf("AnalyzeDOI(%q) error = %v, want %d", doi, err, status)
+		}
+	}
+}
+
+func TestAnalyzePMID(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[]}`, nil
+	})
+	pmids := stubLookup{"31452104": {Title: "Sleep and memory", Abstract: "BACKGROUND: Sleep matters."}}
+	c := newTestServer(t, backend, WithPMIDResolver(pmids))
+	ctx := context.Background()
+
+	result, err := c.AnalyzePMID(ctx, "https://pubmed.ncbi.nlm.nih.gov/31452104/")
+	if err != nil {
+		t.Fatalf("AnalyzePMID() error = %v", err)
+	}
+	if !strings.Contains(prompt, "BACKGROUND: Sleep matters.") {
+		t.Errorf("prompt lacks the abstract:\n%s", prompt)
+	}
+	if p := result.Paper; p == nil || p.Source != "pubmed" || p.ID != "31452104" || len(p.Authors) != 0 {
+		t.Errorf("Paper = %+v", result.Paper)
+	}
+
+	_, err = c.AnalyzePMID(ctx, "1")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("AnalyzePMID() error = %v, want 404", err)
+	}
+}
+
+// stubArxiv resolves the arXiv IDs it holds, serving fakePDF for each
+type stubArxiv map[string]Paper
+
+const fakePDF = "%PDF-1.4"
+
+func (a stubArxiv) LookupArxiv(ctx context.Context, id string) (Paper, error) {
+	p, ok := a[id]
+	if !ok {
+		return Paper{}, ErrPaperNotFound
+	}
+	return p, nil
+}
+
+func (a stubArxiv) FetchArxivPDF(ctx context.Context, id string) ([]byte, error) {
+	if _, ok := a[id]; !ok {
+		return nil, ErrPaperNotFound
+	}
+	return []byte(fakePDF), nil
+}
+
+func TestAnalyzeArxiv(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"key_findings":[],"gaps":[],"suggested_hypotheses":[],"limitations":[],"methodology_gaps":[],"future_directions":[]}`, nil
+	})
+	extractor := PDFExtractorFunc(func(ctx context.Context, pdf []byte) (string, string, error) {
+		return "", "Methods\nWe built a 100-node network.", nil
+	})
+	papers := stubArxiv{"2101.00001": {Title: "QKD at Scale", Abstract: "We study QKD networks.", URL: "http://arxiv.org/abs/2101.00001"}}
+	c := newTestServer(t, backend, WithArxivResolver(papers), WithPDFExtractor(extractor))
+	ctx := context.Background()
+
+	result, err := c.AnalyzeArxiv(ctx, "arXiv:2101.00001")
+	if err != nil {
+		t.Fatalf("AnalyzeArxiv() error = %v", err)
+	}
+	if p := result.Paper; p == nil || p.Source != "arxiv" || p.ID != "2101.00001" || p.URL != "http://arxiv.org/abs/2101.00001" {
+		t.Errorf("Paper = %+v", result.Paper)
+	}
+	if strings.Contains(prompt, "100-node") {
+		t.Error("abstract analysis read the PDF")
+	}
+
+	if _, err := c.AnalyzeArxiv(ctx, "2101.00001", client.WithFullText()); err != nil {
+		t.Fatalf("AnalyzeArxiv() with FullText error = %v", err)
+	}
+	if !strings.Contains(prompt, "We built a 100-node network.") {
+		t.Errorf("prompt lacks the PDF's text:\n%s", prompt)
+	}
+
+	_, err = c.AnalyzeArxiv(ctx, "2101.99999")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("AnalyzeArxiv() error = %v, want 404", err)
+	}
+}
+
+func TestAnalyzePDFUnsupported(t *testing.T) {
+	c := newTestServer(t, llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		t.Error("backend called without a PDF extractor")
+		return "", nil
+	}))
+
+	_, err := c.AnalyzePDF(context.Background(), strings.NewReader("%PDF-1.4"), types.PDFMetadata{})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotImplemented {
+		t.Errorf("AnalyzePDF() error = %v, want 501", err)
+	}
+}
+
+func TestTopicEnrichesResults(t *testing.T) {
+	papers := stubPapers{
+		{Title: "P1", Authors: []string{"A"}, Abstract: strings.Repeat("x", 600), URL: "http://arxiv.org/abs/1"},
+		{Title: "P2", Authors: []string{"B"}, Abstract: "short", URL: "http://arxiv.org/abs/2"},
+	}
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return `{"common_gaps":[],"individual_results":[{"paper_title":"P1","gaps":[]},{"paper_title":"P2","gaps":[]}],"suggested_research_directions":["d"]}`, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(papers))
+
+	result, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "sleep", MaxPapers: 2})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if result.Topic != "sleep" || result.PapersAnalyzed != 2 {
+		t.Errorf("Topic = %q, PapersAnalyzed = %d", result.Topic, result.PapersAnalyzed)
+	}
+	first := result.IndividualResults[0]
+	if first.URL != "http://arxiv.org/abs/1" || len(first.Abstract) != 500 || first.Authors[0] != "A" {
+		t.Errorf("IndividualResults[0] = %+v, want paper metadata attached", first)
+	}
+}
+
+func TestTopicGapLimits(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return `{"common_gaps":[{"gap_description":"weak","confidence_score":0.2,"gap_type":"empirical","potential_impact":"i"},
+			{"gap_description":"strong","confidence_score":0.9,"gap_type":"empirical","potential_impact":"i"}],
+			"individual_results":[{"paper_title":"P1","gaps":[{"gap_description":"weak","confidence_score":0.4,"gap_type":"empirical","potential_impact":"i"}]}],
+			"suggested_research_directions":[]}`, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}}))
+
+	result, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "sleep", MinConfidence: 0.5})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if len(result.CommonGaps) != 1 || result.CommonGaps[0].GapDescription != "strong" || len(result.IndividualResults[0].Gaps) != 0 {
+		t.Errorf("result = %+v, want only gaps from 0.5", result)
+	}
+
+	result, err = c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "sleep", MaxGaps: 1})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if len(result.CommonGaps) != 1 || result.CommonGaps[0].GapDescription != "weak" || len(result.IndividualResults[0].Gaps) != 1 {
+		t.Errorf("result = %+v, want the first gap only", result)
+	}
+}
+
+func TestTopicCrossFields(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"common_gaps":[],"individual_results":[],"suggested_research_directions":[],
+			"intersection_gaps":[{"gap_description":"weak","confidence_score":0.2,"gap_type":"conceptual","potential_impact":"i"},
+			{"gap_description":"Spiking models of gene circuits","confidence_score":0.8,"gap_type":"conceptual","potential_impact":"i"}]}`, nil
+	})
+	var queries []string
+	var sizes []int
+	papers := searchFunc(func(q string, n int) ([]Paper, error) {
+		queries = append(queries, q)
+		sizes = append(sizes, n)
+		// Both searches find the shared paper
+		return []Paper{{Title: "Shared", URL: "http://arxiv.org/abs/1"}, {Title: q}}, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(papers))
+
+	result, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{
+		Topic:         "oscillations",
+		MaxPapers:     5,
+		CrossFields:   []types.Field{types.FieldNeuroscience, types.FieldComputerScience},
+		MinConfidence: 0.5,
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	wantGaps := []types.ResearchGap{{GapDescription: "Spiking models of gene circuits", ConfidenceScore: 0.8, GapType: types.GapConceptual, PotentialImpact: "i"}}
+	if !reflect.DeepEqual(result.IntersectionGaps, wantGaps) {
+		t.Errorf("IntersectionGaps = %+v, want %+v", result.IntersectionGaps, wantGaps)
+	}
+	if result.PapersAnalyzed != 3 {
+		t.Errorf("PapersAnalyzed = %d, want the shared paper counted once", result.PapersAnalyzed)
+	}
+	wantQueries := []string{"oscillations neuroscience", "oscillations computer science"}
+	if !reflect.DeepEqual(queries, wantQueries) || !reflect.DeepEqual(sizes, []int{3, 2}) {
+		t.Errorf("searched %q for %v papers, want %q for [3 2]", queries, sizes, wantQueries)
+	}
+	for _, want := range []string{"across the fields of neuroscience, computer science", "INTERSECTION GAPS", `"intersection_gaps"`} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+
+	result, err = c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "oscillations"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if result.IntersectionGaps != nil || strings.Contains(prompt, "intersection") {
+		t.Errorf("IntersectionGaps = %+v, want none without cross fields", result.IntersectionGaps)
+	}
+}
+
+func TestTopicSurveys(t *testing.T) {
+	var prompt string
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return `{"common_gaps":[],"individual_results":[],"suggested_research_directions":[]}`, nil
+	})
+	var searched int
+	papers := searchFunc(func(q string, n int) ([]Paper, error) {
+		searched = n
+		return []Paper{
+			{Title: "Deep Learning for Sleep Staging: A Survey"},
+			{Title: "Spindle detection with transformers"},
+			{Title: "Sleep staging in children", Abstract: "This review covers a decade of work."},
+			{Title: "Wearable EEG staging"},
+		}, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(papers))
+
+	result, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "sleep staging", MaxPapers: 2, Surveys: types.SurveysExclude})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if searched != 4 || result.PapersAnalyzed != 2 || strings.Contains(prompt, "Survey") || !strings.Contains(prompt, "Wearable EEG staging") {
+		t.Errorf("searched %d papers and analyzed %d, want 4 searched and the 2 primary studies analyzed:\n%s", searched, result.PapersAnalyzed, prompt)
+	}
+
+	_, err = c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "sleep staging", MaxPapers: 4, Surveys: types.SurveysDownweight})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	for _, want := range []string{"Paper 1 (survey):", "Paper 2:", "Paper 3 (survey):", "Use them as background only"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+
+	_, err = c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "sleep staging", MaxPapers: 4})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if strings.Contains(prompt, "(survey)") {
+		t.Error("surveys marked without SurveysDownweight")
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		t.Error("estimate called the backend")
+		return "", errors.New("unexpected call")
+	})
+	papers := stubPapers{{Title: "P1", Abstract: strings.Repeat("word ", 200)}, {Title: "P2", Abstract: "A2"}}
+	c := newTestServer(t, backend, WithPaperSource(papers), WithModels("gpt-4", "llama3"))
+	ctx := context.Background()
+
+	est, err := c.EstimateCost(ctx, types.TopicRequest{Topic: "sleep"})
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	if est.Model != "gpt-4" || est.Papers != 2 || est.LLMCalls != 1 || est.InputTokens < 250 || est.OutputTokens != 700 {
+		t.Errorf("estimate = %+v", est)
+	}
+	want := math.Round((float64(est.InputTokens)*30+700*60)/1e6*1e4) / 1e4
+	if est.CostUSD == nil || *est.CostUSD != want || est.DurationSeconds < 17.5 {
+		t.Errorf("cost = %v, duration = %v, want cost %v and at least 17.5s", est.CostUSD, est.DurationSeconds, want)
+	}
+
+	est, err = c.EstimateCost(ctx, types.TopicRequest{Topic: "sleep", Model: "llama3"})
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	if est.Model != "llama3" || est.CostUSD != nil {
+		t.Errorf("estimate for a model without a price = %+v", est)
+	}
+
+	_, err = c.EstimateCost(ctx, types.TopicRequest{Topic: "sleep", Model: "gpt-5"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("EstimateCost() error = %v, want 422 for an unknown model", err)
+	}
+
+	est, err = c.EstimateAnalysis(ctx, types.AnalyzeRequest{Title: "Sleep", Abstract: strings.Repeat("word ", 200)})
+	if err != nil {
+		t.Fatalf("EstimateAnalysis() error = %v", err)
+	}
+	if est.Model != "gpt-4" || est.Papers != 1 || est.LLMCalls != 1 || est.InputTokens < 250 || est.OutputTokens != 550 ||
+		est.CostUSD == nil || est.DurationSeconds != 13.75 {
+		t.Errorf("abstract estimate = %+v", est)
+	}
+
+	c = newTestServer(t, backend, WithPaperSource(stubPapers{}))
+	est, err = c.EstimateCost(ctx, types.TopicRequest{Topic: "sleep"})
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	if est.Papers != 0 || est.LLMCalls != 0 || est.InputTokens != 0 || est.CostUSD != nil {
+		t.Errorf("estimate without papers = %+v", est)
+	}
+}
+
+func TestTopicJob(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return `{"common_gaps":[],"individual_results":[],"suggested_research_directions":["d"]}`, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}}))
+	ctx := context.Background()
+
+	job, err := c.AnalyzeTopicAsync(ctx, types.TopicRequest{Topic: "sleep"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicAsync() error = %v", err)
+	}
+	job, err = c.WaitForJob(ctx, job.JobID, client.WaitOptions{Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("WaitForJob() error = %v", err)
+	}
+	if job.Result == nil || job.Result.Topic != "sleep" || job.Result.PapersAnalyzed != 1 {
+		t.Errorf("Result = %+v", job.Result)
+	}
+
+	if _, err := c.GetJob(ctx, "unknown"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetJob() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTopicJobCallback(t *testing.T) {
+	secret := []byte("s3cret")
+	jobs := make(chan *types.Job, 1)
+	receiver := httptest.NewServer(webhook.Handler(secret, func(ctx context.Context, job *types.Job) error {
+		jobs <- job
+		return nil
+	}))
+	t.Cleanup(receiver.Close)
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return `{"common_gaps":[],"individual_results":[],"suggested_research_directions":["d"]}`, nil
+	})
+	ctx := context.Background()
+	req := types.TopicRequest{Topic: "sleep"}
+
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}}), WithWebhooks(secret, nil), WithPrivateCallbacks())
+	job, err := c.AnalyzeTopicAsync(ctx, req, client.WithCallbackURL(receiver.URL))
+	if err != nil {
+		t.Fatalf("AnalyzeTopicAsync() error = %v", err)
+	}
+	select {
+	case got := <-jobs:
+		if got.JobID != job.JobID || got.Status != types.JobSucceeded || got.Result == nil || got.Result.Topic != "sleep" {
+			t.Errorf("callback job = %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no callback")
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.AnalyzeTopicAsync(ctx, req, client.WithCallbackURL("/relative")); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("AnalyzeTopicAsync(relative callback) error = %v, want 422", err)
+	}
+	c = newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}}), WithWebhooks(secret, nil))
+	if _, err := c.AnalyzeTopicAsync(ctx, req, client.WithCallbackURL(receiver.URL)); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("AnalyzeTopicAsync(loopback callback) error = %v, want 422", err)
+	}
+	c = newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}}))
+	if _, err := c.AnalyzeTopicAsync(ctx, req, client.WithCallbackURL(receiver.URL)); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("AnalyzeTopicAsync() without webhooks error = %v, want 422", err)
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		close(started)
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return "", ctx.Err()
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}}))
+	ctx := context.Background()
+
+	job, err := c.AnalyzeTopicAsync(ctx, types.TopicRequest{Topic: "sleep"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicAsync() error = %v", err)
+	}
+	<-started
+	job, err = c.CancelJob(ctx, job.JobID)
+	if err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+	if job.Status != types.JobCancelled {
+		t.Errorf("Status = %q, want cancelled", job.Status)
+	}
+	if err := <-stopped; !errors.Is(err, context.Canceled) {
+		t.Errorf("backend context error = %v, want context.Canceled", err)
+	}
+	// The analysis failing as it stops doesn't overwrite the cancellation
+	if _, err := c.WaitForJob(ctx, job.JobID, client.WaitOptions{Interval: time.Millisecond}); !errors.Is(err, client.ErrJobCancelled) {
+		t.Errorf("WaitForJob() error = %v, want ErrJobCancelled", err)
+	}
+
+	if _, err := c.CancelJob(ctx, "unknown"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("CancelJob() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAnalysisHistory(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		if strings.Contains(p, "Sleep and memory") {
+			return `{"key_findings":["f"],"gaps":[],"limitations":[],"methodology_gaps":[],"suggested_hypotheses":[],"future_directions":[]}`, nil
+		}
+		return `{"common_gaps":[],"individual_results":[{"paper_title":"P1","gaps":[]},{"paper_title":"P2","gaps":[]}],"suggested_research_directions":["d"]}`, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}, {Title: "P2"}}))
+	ctx := context.Background()
+	start := time.Now().Add(-time.Second)
+
+	if _, err := c.AnalyzeAbstract(ctx, types.AnalyzeRequest{Title: "Sleep and memory", Abstract: "We studied sleep."}); err != nil {
+		t.Fatal(err)
+	}
+	topic, err := c.AnalyzeTopic(ctx, types.TopicRequest{Topic: "CRISPR", Field: types.FieldBiology, MaxPapers: 2, PageSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := c.ListAnalyses(ctx, types.AnalysesQuery{})
+	if err != nil {
+		t.Fatalf("ListAnalyses() error = %v", err)
+	}
+	if len(all.Analyses) != 2 || all.Analyses[0].Title != "CRISPR" || all.Analyses[1].Kind != types.AnalysisAbstract ||
+		all.Analyses[1].Field != types.FieldGeneral || all.Analyses[1].CreatedAt.Before(start) {
+		t.Errorf("Analyses = %+v, want the topic then the abstract", all.Analyses)
+	}
+
+	for name, tt := range map[string]struct {
+		q    types.AnalysesQuery
+		want int
+	}{
+		"topic":  {types.AnalysesQuery{Topic: "crispr"}, 1},
+		"field":  {types.AnalysesQuery{Field: types.FieldBiology}, 1},
+		"since":  {types.AnalysesQuery{Since: time.Now().Add(time.Hour)}, 0},
+		"until":  {types.AnalysesQuery{Until: start}, 0},
+		"period": {types.AnalysesQuery{Since: start, Until: time.Now().Add(time.Hour)}, 2},
+		"limit":  {types.AnalysesQuery{Limit: 1}, 1},
+	} {
+		resp, err := c.ListAnalyses(ctx, tt.q)
+		if err != nil || len(resp.Analyses) != tt.want {
+			t.Errorf("%s: ListAnalyses() = %+v, %v; want %d analyses", name, resp, err, tt.want)
+		}
+	}
+
+	a, err := c.GetAnalysis(ctx, all.Analyses[0].AnalysisID)
+	if err != nil {
+		t.Fatalf("GetAnalysis() error = %v", err)
+	}
+	// The paged topic response held one result; the kept one holds both
+	if len(topic.IndividualResults) != 1 || a.TopicResult == nil || len(a.TopicResult.IndividualResults) != 2 || a.TopicResult.NextCursor != "" {
+		t.Errorf("TopicResult = %+v, want every individual result", a.TopicResult)
+	}
+	if _, err := c.GetAnalysis(ctx, "unknown"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetAnalysis() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteAnalysis(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return `{"key_findings":["f"],"gaps":[],"limitations":[],"methodology_gaps":[],"suggested_hypotheses":[],"future_directions":[]}`, nil
+	})
+	c := newTestServer(t, backend)
+	ctx := context.Background()
+
+	for _, title := range []string{"Unpublished", "Published"} {
+		if _, err := c.AnalyzeAbstract(ctx, types.AnalyzeRequest{Title: title, Abstract: "We studied sleep."}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := c.ListAnalyses(ctx, types.AnalysesQuery{Topic: "unpublished"})
+	if err != nil || len(list.Analyses) != 1 {
+		t.Fatalf("ListAnalyses() = %+v, %v", list, err)
+	}
+	id := list.Analyses[0].AnalysisID
+
+	if err := c.DeleteAnalysis(ctx, id); err != nil {
+		t.Fatalf("DeleteAnalysis() error = %v", err)
+	}
+	if _, err := c.GetAnalysis(ctx, id); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetAnalysis() after delete error = %v, want ErrNotFound", err)
+	}
+	if list, err := c.ListAnalyses(ctx, types.AnalysesQuery{}); err != nil || len(list.Analyses) != 1 || list.Analyses[0].Title != "Published" {
+		t.Errorf("ListAnalyses() after delete = %+v, %v; want only the other analysis", list, err)
+	}
+	if err := c.DeleteAnalysis(ctx, id); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("second DeleteAnalysis() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteAnalysisForgetsJob(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return `{"common_gaps":[],"individual_results":[],"suggested_research_directions":["d"]}`, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}}))
+	ctx := context.Background()
+
+	job, err := c.AnalyzeTopicAsync(ctx, types.TopicRequest{Topic: "unpublished"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicAsync() error = %v", err)
+	}
+	if _, err := c.WaitForJob(ctx, job.JobID, client.WaitOptions{Interval: time.Millisecond}); err != nil {
+		t.Fatalf("WaitForJob() error = %v", err)
+	}
+	list, err := c.ListAnalyses(ctx, types.AnalysesQuery{})
+	if err != nil || len(list.Analyses) != 1 {
+		t.Fatalf("ListAnalyses() = %+v, %v", list, err)
+	}
+
+	if err := c.DeleteAnalysis(ctx, list.Analyses[0].AnalysisID); err != nil {
+		t.Fatalf("DeleteAnalysis() error = %v", err)
+	}
+	if _, err := c.GetJob(ctx, job.JobID); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetJob() after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestJobStoreKeepsRunningJobs(t *testing.T) {
+	st := newJobStore(2)
+	release := make(chan struct{})
+	running := func(context.Context) (*types.TopicResponse, error) {
+		<-release
+		return &types.TopicResponse{}, nil
+	}
+	first, err := st.submit(context.Background(), running, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	second, err := st.submit(context.Background(), func(context.Context) (*types.TopicResponse, error) {
+		defer close(done)
+		return &types.TopicResponse{}, nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	for {
+		if job, _ := st.get(second.JobID); job.Done() {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The finished job makes room; the running one is kept
+	if _, err := st.submit(context.Background(), running, nil); err != nil {
+		t.Fatalf("submit() error = %v", err)
+	}
+	if _, ok := st.get(second.JobID); ok {
+		t.Error("finished job was kept")
+	}
+	if _, ok := st.get(first.JobID); !ok {
+		t.Error("running job was evicted")
+	}
+	if _, err := st.submit(context.Background(), running, nil); !errors.Is(err, ErrTooManyJobs) {
+		t.Errorf("submit() error = %v, want ErrTooManyJobs", err)
+	}
+	close(release)
+}
+
+func TestTopicStream(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return `{"gaps":[{"gap_description":"g","confidence_score":0.7}],"common_gaps":[],"individual_results":[],"suggested_research_directions":["d"]}`, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}, {Title: "P2"}}))
+
+	stream, err := c.AnalyzeTopicStream(context.Background(), types.TopicRequest{Topic: "sleep", MaxPapers: 2})
+	if err != nil {
+		t.Fatalf("AnalyzeTopicStream() error = %v", err)
+	}
+	var titles []string
+	for result := range stream.Results {
+		titles = append(titles, result.PaperTitle)
+	}
+	result, err := stream.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	slices.Sort(titles)
+	if !slices.Equal(titles, []string{"P1", "P2"}) {
+		t.Errorf("streamed titles = %q, want [P1 P2]", titles)
+	}
+	if result.Topic != "sleep" || result.PapersAnalyzed != 2 || len(result.IndividualResults) != 2 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestWatchTopic(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		if strings.Contains(p, "P2") && !strings.Contains(p, "P1") {
+			return "", errors.New("model overloaded")
+		}
+		return `{"gaps":[],"common_gaps":[],"individual_results":[],"suggested_research_directions":["d"]}`, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}, {Title: "P2"}}))
+
+	stages := map[string][]string{}
+	result, err := c.WatchTopic(context.Background(), types.TopicRequest{Topic: "sleep"}, func(p types.TopicProgress) {
+		stages[p.PaperTitle] = append(stages[p.PaperTitle], p.Stage)
+	})
+	if err != nil {
+		t.Fatalf("WatchTopic() error = %v", err)
+	}
+	want := map[string][]string{
+		"P1": {types.ProgressFetched, types.ProgressAnalyzing, types.ProgressDone},
+		"P2": {types.ProgressFetched, types.ProgressAnalyzing, types.ProgressFailed},
+	}
+	for title, w := range want {
+		if !slices.Equal(stages[title], w) {
+			t.Errorf("stages of %s = %q, want %q", title, stages[title], w)
+		}
+	}
+	if result.PapersAnalyzed != 2 || len(result.IndividualResults) != 1 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestTopicPaged(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return `{"common_gaps":[],"individual_results":[{"paper_title":"P1","gaps":[]},{"paper_title":"P2","gaps":[]},{"paper_title":"P3","gaps":[]}],"suggested_research_directions":[]}`, nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{{Title: "P1"}, {Title: "P2"}, {Title: "P3"}}))
+	ctx := context.Background()
+
+	result, err := c.AnalyzeTopic(ctx, types.TopicRequest{Topic: "sleep", PageSize: 2})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if len(result.IndividualResults) != 2 || result.NextCursor == "" {
+		t.Fatalf("first page = %+v, cursor %q", result.IndividualResults, result.NextCursor)
+	}
+	page, err := c.GetTopicResults(ctx, result.NextCursor)
+	if err != nil {
+		t.Fatalf("GetTopicResults() error = %v", err)
+	}
+	if len(page.IndividualResults) != 1 || page.IndividualResults[0].PaperTitle != "P3" || page.NextCursor != "" {
+		t.Errorf("second page = %+v", page)
+	}
+
+	if _, err := c.GetTopicResults(ctx, "unknown.2"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetTopicResults() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTopicWithoutPapers(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		t.Error("backend called without papers")
+		return "", nil
+	})
+	c := newTestServer(t, backend, WithPaperSource(stubPapers{}))
+
+	result, err := c.AnalyzeTopic(context.Background(), types.TopicRequest{Topic: "nothing"})
+	if err != nil {
+		t.Fatalf("AnalyzeTopic() error = %v", err)
+	}
+	if result.PapersAnalyzed != 0 || len(result.SuggestedResearchDirections) != 1 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	srv := httptest.NewServer(New(nil))
+	defer srv.Close()
+	c, _ := client.New(client.WithBaseURL(srv.URL))
+
+	// Bypass client-side validation to exercise the server's
+	err := c.Do(context.Background(), "POST", "/analyze", map[string]string{"title": "T", "abstract": " "}, nil)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 422 || apiErr.ErrorCode != "validation_error" {
+		t.Fatalf("error = %v, want 422 validation error", err)
+	}
+}
+
+func TestBackendFailure(t *testing.T) {
+	backend := llm.BackendFunc(func(ctx context.Context, p string) (string, error) {
+		return "", errors.New("quota exceeded")
+	})
+	c := newTestServer(t, backend)
+
+	_, err := c.AnalyzeAbstract(context.Background(), types.AnalyzeRequest{Title: "T", Abstract: "A"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 || strings.Contains(apiErr.Message, "quota") {
+		t.Fatalf("error = %v, want generic 500", err)
+	}
+}
+
+func TestHealthEchoesRequestID(t *testing.T) {
+	c := newTestServer(t, nil)
+	ctx := client.ContextWithRequestID(context.Background(), "req-1")
+	health, err := c.HealthCheck(ctx)
+	if err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	if health.Status != "healthy" || health.Version != Version || health.RequestID != "req-1" {
+		t.Errorf("health = %+v", health)
+	}
+}
+
+func TestUsageQuota(t *testing.T) {
+	c := newTestServer(t, nil, WithQuota(2, time.Hour))
+	ctx := context.Background()
+	for range 2 {
+		if _, err := c.ListFields(ctx); err != nil {
+			t.Fatalf("ListFields() error = %v", err)
+		}
+	}
+	_, err := c.ListModels(ctx)
+	var apiErr *client.APIError
+	if !errors.Is(err, client.ErrRateLimited) || !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 || apiErr.RetryAfter > time.Hour {
+		t.Fatalf("ListModels() error = %v, want rate limited until the window ends", err)
+	}
+
+	// Checking usage is always allowed, and doesn't count
+	for range 2 {
+		usage, err := c.GetUsage(ctx)
+		if err != nil {
+			t.Fatalf("GetUsage() error = %v", err)
+		}
+		want := []types.EndpointUsage{{Endpoint: "GET /fields", Requests: 2}}
+		if usage.Requests != 2 || !reflect.DeepEqual(usage.Endpoints, want) || usage.Quota == nil ||
+			usage.Quota.Limit != 2 || usage.Quota.Remaining != 0 || !usage.Quota.ResetAt.Equal(usage.Since.Add(time.Hour)) {
+			t.Errorf("usage = %+v, quota = %+v", usage, usage.Quota)
+		}
+	}
+}
+
+func TestUsageWindows(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC)
+	u := newUsageTracker(start)
+	u.limit, u.window = 1, time.Hour
+	if ok, _ := u.record("a", "POST /analyze", start); !ok {
+		t.Fatal("first request refused")
+	}
+	if ok, resetAt := u.record("a", "POST /analyze", start.Add(29*time.Minute)); ok || !resetAt.Equal(start.Add(30*time.Minute)) {
+		t.Errorf("second request: ok = %v, reset at %v", ok, resetAt)
+	}
+	if ok, _ := u.record("b", "POST /analyze", start); !ok {
+		t.Error("other caller's request refused")
+	}
+	if ok, _ := u.record("a", "POST /analyze", start.Add(30*time.Minute)); !ok {
+		t.Error("request in the next window refused")
+	}
+	usage := u.report("b", start.Add(30*time.Minute))
+	if usage.Requests != 0 || !usage.Since.Equal(start.Add(30*time.Minute)) || usage.Quota.Remaining != 1 {
+		t.Errorf("usage = %+v, quota = %+v", usage, usage.Quota)
+	}
+
+	// Without a quota counts are still forgotten each window
+	u = newUsageTracker(start)
+	u.record("a", "POST /analyze", start)
+	if usage := u.report("a", start.Add(30*time.Minute)); usage.Requests != 0 || len(u.callers) != 0 {
+		t.Errorf("usage in the next window = %+v, %d callers kept", usage, len(u.callers))
+	}
+}
+
+func TestUsageCallers(t *testing.T) {
+	srv := httptest.NewServer(New(nil, WithQuotaKeys("alice", "bob")))
+	t.Cleanup(srv.Close)
+	alice, _ := client.New(client.WithBaseURL(srv.URL), client.WithAPIKey("alice"))
+	bob, _ := client.New(client.WithBaseURL(srv.URL), client.WithAPIKey("bob"))
+	mallory, _ := client.New(client.WithBaseURL(srv.URL), client.WithAPIKey("made-up"))
+	ctx := context.Background()
+	if _, err := alice.ListFields(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.GetJob(ctx, "missing"); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+
+	usage, err := alice.GetUsage(ctx)
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	want := []types.EndpointUsage{{Endpoint: "GET /fields", Requests: 1}, {Endpoint: "GET /jobs/{job_id}", Requests: 1}}
+	if usage.Requests != 2 || !reflect.DeepEqual(usage.Endpoints, want) || usage.Quota != nil {
+		t.Errorf("alice's usage = %+v", usage)
+	}
+	usage, err = bob.GetUsage(ctx)
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if usage.Requests != 0 || len(usage.Endpoints) != 0 {
+		t.Errorf("bob's usage = %+v", usage)
+	}
+
+	// Unknown keys are counted by address, like no key at all
+	if _, err := mallory.ListFields(ctx); err != nil {
+		t.Fatal(err)
+	}
+	anonymous, _ := client.New(client.WithBaseURL(srv.URL))
+	usage, err = anonymous.GetUsage(ctx)
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if usage.Requests != 1 {
+		t.Errorf("usage by address = %+v, want the unknown key's request", usage)
+	}
+}
+
+func TestListFields(t *testing.T) {
+	c := newTestServer(t, nil)
+	result, err := c.ListFields(context.Background())
+	if err != nil {
+		t.Fatalf("ListFields() error = %v", err)
+	}
+	if !slices.Equal(result.Fields, types.Fields) {
+		t.Errorf("Fields = %v, want %v", result.Fields, types.Fields)
+	}
+}
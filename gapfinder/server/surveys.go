@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"regexp"
+	"slices"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+var (
+	// surveyTitle matches the titles of survey and review papers, such as
+	// "A Survey of Graph Neural Networks" or "Sleep and Memory: A Review"
+	surveyTitle = regexp.MustCompile(`(?i)\b(survey|review|overview|meta-analysis|tutorial|state of the art)\b`)
+	// surveyAbstract matches abstracts introducing a survey, such as "This
+	// survey covers..." or "The present article reviews..."
+	surveyAbstract = regexp.MustCompile(`(?i)\b(this|our|the present) (survey|review|overview|meta-analysis)\b|\b(this|the present) (paper|article) (surveys|reviews)\b`)
+)
+
+// isSurvey reports whether a paper looks like a survey or review of other
+// work rather than a primary study
+func isSurvey(p Paper) bool {
+	return surveyTitle.MatchString(p.Title) || surveyAbstract.MatchString(p.Abstract)
+}
+
+// search finds up to n papers matching query. With SurveysExclude twice as
+// many are searched for, to make up for the surveys left out.
+func (s *Server) search(ctx context.Context, query string, n int, surveys string) ([]Paper, error) {
+	if surveys != types.SurveysExclude {
+		return s.papers.SearchPapers(ctx, query, n)
+	}
+	papers, err := s.papers.SearchPapers(ctx, query, 2*n)
+	if err != nil {
+		return nil, err
+	}
+	papers = slices.DeleteFunc(papers, isSurvey)
+	return papers[:min(n, len(papers))], nil
+}
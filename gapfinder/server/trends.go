@@ -0,0 +1,155 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// Defaults of a TrendsRequest
+const (
+	defaultYearsBack   = 5
+	defaultTrendPapers = 30
+)
+
+// AnalyzeTrends validates a request, then finds papers on its topic
+// published in the covered years, searching only those years if the paper
+// source can, and asks the model which of them raise
+// which gaps. A gap still raised in the latest year with papers is
+// persistent, or emerging if no earlier paper raised it; other gaps are
+// closing. It does the work of POST /topic/trends.
+func (s *Server) AnalyzeTrends(ctx context.Context, req types.TrendsRequest) (*types.TrendsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	result := &types.TrendsResponse{Topic: req.Topic, ToYear: start.UTC().Year(), Gaps: []types.GapTrend{}}
+	result.FromYear = result.ToYear - cmp.Or(req.YearsBack, defaultYearsBack) + 1
+
+	var found []Paper
+	var err error
+	maxPapers := cmp.Or(req.MaxPapers, defaultTrendPapers)
+	if dated, ok := s.papers.(DatedPaperSource); ok {
+		found, err = dated.SearchPapersPublished(ctx, req.Topic, result.FromYear, result.ToYear, maxPapers)
+	} else {
+		found, err = s.papers.SearchPapers(ctx, req.Topic, maxPapers)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Sources may date papers differently from their search, so the years
+	// are checked either way
+	var papers []Paper
+	for _, p := range found {
+		if year := p.Published.Year(); !p.Published.IsZero() && year >= result.FromYear && year <= result.ToYear {
+			papers = append(papers, p)
+		}
+	}
+	slices.SortStableFunc(papers, func(a, b Paper) int { return a.Published.Compare(b.Published) })
+	result.PapersAnalyzed = len(papers)
+
+	if len(papers) == 0 {
+		s.logger.WarnContext(ctx, "no dated papers found", "topic", req.Topic, "from_year", result.FromYear)
+	} else {
+		prompt, err := render(trendsPrompt, struct {
+			Topic  string
+			Field  types.Field
+			Papers []Paper
+		}{req.Topic, cmp.Or(req.Field, types.FieldGeneral), papers})
+		if err != nil {
+			return nil, err
+		}
+		var reply struct {
+			Gaps []struct {
+				GapDescription string        `json:"gap_description"`
+				GapType        types.GapType `json:"gap_type"`
+				Papers         []int         `json:"papers"`
+			} `json:"gaps"`
+		}
+		if err := s.complete(ctx, prompt, &reply); err != nil {
+			return nil, err
+		}
+		first, latest := papers[0].Published.Year(), papers[len(papers)-1].Published.Year()
+		for _, g := range reply.Gaps {
+			var years []int
+			for _, n := range g.Papers {
+				if n >= 1 && n <= len(papers) {
+					years = append(years, papers[n-1].Published.Year())
+				}
+			}
+			if len(years) == 0 || strings.TrimSpace(g.GapDescription) == "" {
+				continue
+			}
+			slices.Sort(years)
+			years = slices.Compact(years)
+			result.Gaps = append(result.Gaps, types.GapTrend{
+				GapDescription: g.GapDescription,
+				GapType:        types.GapType(strings.ToLower(strings.TrimSpace(string(g.GapType)))),
+				Years:          years,
+				Status:         trendStatus(years, first, latest),
+			})
+		}
+	}
+	result.Years = trendYears(result.FromYear, result.ToYear, papers, result.Gaps)
+	result.ProcessingTime = elapsedSeconds(start)
+	return result, nil
+}
+
+// trendStatus tells whether a gap raised in years, in ascending order, is
+// still raised by the papers, published from first to latest
+func trendStatus(years []int, first, latest int) string {
+	switch {
+	case years[len(years)-1] < latest:
+		return types.TrendClosing
+	case years[0] == latest && first < latest:
+		return types.TrendEmerging
+	default:
+		return types.TrendPersistent
+	}
+}
+
+// trendYears counts the papers and the gaps of each type of every year from
+// from to to
+func trendYears(from, to int, papers []Paper, gaps []types.GapTrend) []types.TrendYear {
+	years := make([]types.TrendYear, 0, to-from+1)
+	for year := from; year <= to; year++ {
+		ty := types.TrendYear{Year: year, GapTypes: []types.GapTypeCount{}}
+		for _, p := range papers {
+			if p.Published.Year() == year {
+				ty.PaperCount++
+			}
+		}
+		counts := map[types.GapType]int{}
+		for _, g := range gaps {
+			if slices.Contains(g.Years, year) {
+				counts[g.GapType]++
+			}
+		}
+		for gapType, count := range counts {
+			ty.GapTypes = append(ty.GapTypes, types.GapTypeCount{GapType: gapType, Count: count})
+		}
+		slices.SortFunc(ty.GapTypes, func(a, b types.GapTypeCount) int {
+			return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.GapType, b.GapType))
+		})
+		years = append(years, ty)
+	}
+	return years
+}
+
+func (s *Server) handleTrends(w http.ResponseWriter, r *http.Request) {
+	var req types.TrendsRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	result, err := s.AnalyzeTrends(r.Context(), req)
+	if err != nil {
+		s.fail(w, r, err, "An error occurred during trend analysis.")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// uncounted are the endpoints that neither count against a quota nor are
+// refused once it is used up, so callers can always check on themselves
+var uncounted = map[string]bool{
+	"GET /usage":  true,
+	"GET /health": true,
+}
+
+// WithQuota allows each caller limit requests per window, refusing the rest
+// with 429 and a Retry-After header. Windows are aligned to multiples of
+// window, e.g. to the hour. Callers are told apart by address, or by the keys
+// given with WithQuotaKeys. By default requests are only counted, for /usage,
+// in windows of an hour. Requests made through the gRPC server are not
+// counted.
+func WithQuota(limit int, window time.Duration) Option {
+	return func(s *Server) {
+		s.usage.limit = limit
+		s.usage.window = window
+	}
+}
+
+// WithQuotaKeys tells callers apart by the API keys or bearer tokens they
+// send, if these are among keys, rather than by address. Any other key is
+// ignored, so that a caller can't get a fresh quota by making one up.
+func WithQuotaKeys(keys ...string) Option {
+	return func(s *Server) {
+		for _, key := range keys {
+			s.usage.keys[hashKey(key)] = true
+		}
+	}
+}
+
+// usageTracker counts requests by caller and endpoint in the current window
+type usageTracker struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	keys    map[string]bool // hashed keys that tell callers apart
+	since   time.Time       // start of the current window
+	callers map[string]*callerUsage
+}
+
+type callerUsage struct {
+	requests  int
+	endpoints map[string]int
+}
+
+func newUsageTracker(now time.Time) *usageTracker {
+	return &usageTracker{
+		window:  time.Hour,
+		keys:    make(map[string]bool),
+		since:   now.UTC(),
+		callers: make(map[string]*callerUsage),
+	}
+}
+
+func (u *usageTracker) hasQuota() bool {
+	return u.limit > 0 && u.window > 0
+}
+
+// roll starts a new window, forgetting all counts, once now is outside the
+// current one, whether or not there is a quota. The caller must hold u.mu.
+func (u *usageTracker) roll(now time.Time) {
+	if u.window <= 0 {
+		return
+	}
+	if start := now.Truncate(u.window).UTC(); !start.Equal(u.since) {
+		u.since = start
+		clear(u.callers)
+	}
+}
+
+// record counts a request by caller to endpoint. If the caller's quota is
+// used up the request isn't counted, and record returns false and when the
+// quota resets.
+func (u *usageTracker) record(caller, endpoint string, now time.Time) (bool, time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.roll(now)
+	usage := u.callers[caller]
+	if usage == nil {
+		usage = &callerUsage{endpoints: make(map[string]int)}
+		u.callers[caller] = usage
+	}
+	if u.hasQuota() && usage.requests >= u.limit {
+		return false, u.since.Add(u.window)
+	}
+	usage.requests++
+	usage.endpoints[endpoint]++
+	return true, time.Time{}
+}
+
+// report returns the usage of caller
+func (u *usageTracker) report(caller string, now time.Time) *types.UsageResponse {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.roll(now)
+	result := &types.UsageResponse{Since: u.since, Endpoints: []types.EndpointUsage{}}
+	if usage := u.callers[caller]; usage != nil {
+		result.Requests = usage.requests
+		for endpoint, n := range usage.endpoints {
+			result.Endpoints = append(result.Endpoints, types.EndpointUsage{Endpoint: endpoint, Requests: n})
+		}
+	}
+	slices.SortFunc(result.Endpoints, func(a, b types.EndpointUsage) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), strings.Compare(a.Endpoint, b.Endpoint))
+	})
+	if u.hasQuota() {
+		result.Quota = &types.Quota{
+			Limit:     u.limit,
+			Remaining: max(u.limit-result.Requests, 0),
+			ResetAt:   u.since.Add(u.window),
+		}
+	}
+	return result
+}
+
+// hashKey hashes an API key or bearer token, so it isn't kept in memory
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// caller identifies the caller of r by its API key or bearer token if that is
+// a known key, or else by its address
+func (u *usageTracker) caller(r *http.Request) string {
+	credential := r.Header.Get("X-API-Key")
+	if credential == "" {
+		credential, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if credential != "" {
+		if hash := hashKey(credential); u.keys[hash] {
+			return "key:" + hash
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
+
+// admit counts r against its caller's quota. Once the quota is used up it
+// responds with 429 and returns false. Requests for unknown paths aren't
+// counted.
+func (s *Server) admit(w http.ResponseWriter, r *http.Request) bool {
+	_, pattern := s.mux.Handler(r)
+	if pattern == "" || uncounted[pattern] {
+		return true
+	}
+	now := time.Now()
+	ok, resetAt := s.usage.record(s.usage.caller(r), pattern, now)
+	if ok {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(resetAt.Sub(now).Seconds()))))
+	writeJSON(w, http.StatusTooManyRequests, map[string]string{"detail": "Quota exceeded, try again later"})
+	return false
+}
+
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.usage.report(s.usage.caller(r), time.Now()))
+}
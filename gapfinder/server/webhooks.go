@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/webhook"
+)
+
+const (
+	// callbackAttempts is the number of times a callback is sent before it
+	// is given up on, waiting callbackBackoff, then twice that, and so on
+	// between attempts
+	callbackAttempts = 4
+	callbackBackoff  = 2 * time.Second
+)
+
+// WithWebhooks enables callbacks of finished jobs, signed with secret as
+// described by package webhook. A nil hc means a client with a 10s timeout
+// that, unless WithPrivateCallbacks is given, refuses to connect to
+// loopback, private or link-local addresses. By default jobs with a callback
+// URL are refused.
+func WithWebhooks(secret []byte, hc *http.Client) Option {
+	return func(s *Server) {
+		s.webhookSecret = secret
+		s.webhookClient = hc
+	}
+}
+
+// WithPrivateCallbacks allows callback URLs of localhost and of loopback,
+// private and link-local addresses, for receivers on the service's own
+// network. Without it they are refused, so that callers can't use the
+// service to reach that network.
+func WithPrivateCallbacks() Option {
+	return func(s *Server) {
+		s.privateCallbacks = true
+	}
+}
+
+// defaultCallbackClient returns the client callbacks are sent with if
+// WithWebhooks wasn't given one
+func (s *Server) defaultCallbackClient() *http.Client {
+	if s.privateCallbacks {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	return webhook.NewClient(10 * time.Second)
+}
+
+// checkCallback reports whether jobs can be called back at u
+func (s *Server) checkCallback(u string) error {
+	if len(s.webhookSecret) == 0 {
+		return &types.ValidationError{Field: "callback_url", Message: "callbacks are not enabled on this server"}
+	}
+	err := webhook.CheckURL(u)
+	switch {
+	case errors.Is(err, webhook.ErrPrivateAddress) && !s.privateCallbacks:
+		return &types.ValidationError{Field: "callback_url", Message: "must not be a loopback or private address"}
+	case err != nil && !errors.Is(err, webhook.ErrPrivateAddress):
+		return &types.ValidationError{Field: "callback_url", Message: "must be an absolute http or https URL"}
+	}
+	return nil
+}
+
+// sendCallback POSTs a finished job to u, retrying failed attempts. Each
+// attempt is signed anew, so that retries aren't taken for replays.
+func (s *Server) sendCallback(ctx context.Context, u string, job types.Job) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "error encoding callback", "job_id", job.JobID, "error", err)
+		return
+	}
+	backoff := callbackBackoff
+	for attempt := 1; ; attempt++ {
+		err := s.postCallback(ctx, u, body)
+		if err == nil {
+			s.logger.InfoContext(ctx, "sent callback", "job_id", job.JobID, "attempt", attempt)
+			return
+		}
+		if attempt == callbackAttempts {
+			s.logger.ErrorContext(ctx, "callback failed", "job_id", job.JobID, "attempts", attempt, "error", err)
+			return
+		}
+		s.logger.WarnContext(ctx, "callback attempt failed", "job_id", job.JobID, "attempt", attempt, "error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (s *Server) postCallback(ctx context.Context, u string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(s.webhookSecret, time.Now(), body))
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		req.Header.Set("X-Request-ID", id)
+	}
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+	"golang.org/x/net/websocket"
+)
+
+// handleTopicWS reads a topic request from a WebSocket and reports each
+// paper's progress on it, followed by the result or an error
+func (s *Server) handleTopicWS(w http.ResponseWriter, r *http.Request) {
+	// websocket.Server, unlike websocket.Handler, accepts connections
+	// without an Origin header, as sent by non-browser clients
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		send := func(msg types.ProgressMessage) error {
+			return websocket.JSON.Send(ws, msg)
+		}
+
+		var req types.TopicRequest
+		if err := websocket.JSON.Receive(ws, &req); err != nil {
+			_ = send(types.ProgressMessage{Type: types.MessageError, Detail: "Invalid topic request."})
+			return
+		}
+		// The client sends nothing more; a failed read means it has left
+		go func() {
+			_, _ = io.Copy(io.Discard, ws)
+			cancel()
+		}()
+
+		result, err := s.WatchTopic(ctx, req, func(p types.TopicProgress) error {
+			return send(types.ProgressMessage{Type: types.MessageProgress, Progress: &p})
+		})
+		var verr *types.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			_ = send(types.ProgressMessage{Type: types.MessageError, Detail: verr.Error()})
+		case err != nil:
+			s.logger.ErrorContext(ctx, "request failed",
+				"path", r.URL.Path, "request_id", ctx.Value(requestIDKey{}), "error", err)
+			_ = send(types.ProgressMessage{Type: types.MessageError, Detail: "An error occurred during topic analysis."})
+		default:
+			_ = send(types.ProgressMessage{Type: types.MessageResult, Result: result})
+		}
+	}}.ServeHTTP(w, r)
+}
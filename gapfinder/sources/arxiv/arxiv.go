@@ -0,0 +1,284 @@
+// Package arxiv searches arXiv and fetches the metadata and abstracts of its
+// papers through the arXiv API, at the pace the API's terms of use ask for.
+// Results are paged through as they are read:
+//
+//	c := arxiv.New()
+//	papers, err := c.Search(ctx, arxiv.Query{Search: "cat:cs.LG AND all:\"graph neural\"", SortBy: arxiv.SortSubmitted}, 50)
+//
+// The papers can be analyzed with sources.Analyze.
+package arxiv
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/sources"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultURL is the arXiv API query endpoint
+	DefaultURL = "https://export.arxiv.org/api/query"
+	// DefaultInterval is the time between requests the arXiv API's terms of
+	// use ask for
+	DefaultInterval = 3 * time.Second
+	// MaxPageSize is the most results arXiv returns for a request
+	MaxPageSize = 2000
+
+	defaultPageSize = 100
+)
+
+// SortBy is the order of search results
+type SortBy string
+
+const (
+	SortRelevance   SortBy = "relevance"
+	SortLastUpdated SortBy = "lastUpdatedDate"
+	SortSubmitted   SortBy = "submittedDate"
+)
+
+// Query is an arXiv search. At least one of Search and IDs must be set; with
+// both, the papers with the IDs that match Search are found.
+type Query struct {
+	// Search is in the arXiv API's query syntax, such as "cat:q-bio.NC" or
+	// "all:sleep AND au:walker"
+	Search    string
+	IDs       []string
+	SortBy    SortBy // defaults to SortRelevance
+	Ascending bool   // oldest or least relevant first
+}
+
+// Client fetches papers from the arXiv API. It is safe for concurrent use,
+// and its requests are spaced out across goroutines.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	limiter    *rate.Limiter
+	pageSize   int
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithBaseURL sets the address of the arXiv API, such as that of a mirror or
+// a test server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client requests are sent with
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithInterval sets the time between requests; zero sends them as fast as
+// arXiv answers, which only a mirror or test server should be asked to
+func WithInterval(d time.Duration) Option {
+	return func(c *Client) {
+		if d <= 0 {
+			c.limiter = rate.NewLimiter(rate.Inf, 1)
+			return
+		}
+		c.limiter = rate.NewLimiter(rate.Every(d), 1)
+	}
+}
+
+// WithPageSize sets how many results are fetched by each request, up to
+// MaxPageSize
+func WithPageSize(n int) Option {
+	return func(c *Client) {
+		c.pageSize = min(max(n, 1), MaxPageSize)
+	}
+}
+
+// New returns a client of the public arXiv API, making a request every
+// DefaultInterval and fetching 100 results at a time
+func New(opts ...Option) *Client {
+	c := &Client{
+		baseURL:    DefaultURL,
+		httpClient: http.DefaultClient,
+		limiter:    rate.NewLimiter(rate.Every(DefaultInterval), 1),
+		pageSize:   defaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Search returns up to limit papers found by q, fetching as many pages as
+// that takes
+func (c *Client) Search(ctx context.Context, q Query, limit int) ([]sources.Paper, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("limit must be at least 1, got %d", limit)
+	}
+	var papers []sources.Paper
+	for p, err := range c.all(ctx, q, min(limit, c.pageSize)) {
+		if err != nil {
+			return papers, err
+		}
+		papers = append(papers, p)
+		if len(papers) == limit {
+			break
+		}
+	}
+	return papers, nil
+}
+
+// All iterates over every paper found by q, fetching the next page when the
+// previous one has been read. Iteration stops after the first error.
+func (c *Client) All(ctx context.Context, q Query) iter.Seq2[sources.Paper, error] {
+	return c.all(ctx, q, c.pageSize)
+}
+
+// Get returns the paper with an arXiv ID, such as "2401.00001" or
+// "2401.00001v2". Unknown IDs fail with an error wrapping
+// sources.ErrNotFound.
+func (c *Client) Get(ctx context.Context, id string) (sources.Paper, error) {
+	papers, _, err := c.page(ctx, Query{IDs: []string{id}}, 0, 1)
+	if err != nil {
+		return sources.Paper{}, err
+	}
+	if len(papers) == 0 || papers[0].Title == "" {
+		return sources.Paper{}, fmt.Errorf("arXiv paper %s: %w", id, sources.ErrNotFound)
+	}
+	return papers[0], nil
+}
+
+func (c *Client) all(ctx context.Context, q Query, pageSize int) iter.Seq2[sources.Paper, error] {
+	return func(yield func(sources.Paper, error) bool) {
+		for start := 0; ; start += pageSize {
+			papers, total, err := c.page(ctx, q, start, pageSize)
+			if err != nil {
+				yield(sources.Paper{}, err)
+				return
+			}
+			for _, p := range papers {
+				if !yield(p, nil) {
+					return
+				}
+			}
+			if len(papers) < pageSize || (total > 0 && start+pageSize >= total) {
+				return
+			}
+		}
+	}
+}
+
+// feed is an arXiv API response
+type feed struct {
+	TotalResults int `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+	Entries      []struct {
+		ID         string `xml:"id"`
+		Title      string `xml:"title"`
+		Summary    string `xml:"summary"`
+		Published  string `xml:"published"`
+		DOI        string `xml:"http://arxiv.org/schemas/atom doi"`
+		Categories []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+		Authors []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+	} `xml:"entry"`
+}
+
+// page fetches a page of the results of q, and the total number of results
+// if arXiv tells it
+func (c *Client) page(ctx context.Context, q Query, start, size int) ([]sources.Paper, int, error) {
+	if q.Search == "" && len(q.IDs) == 0 {
+		return nil, 0, errors.New("arXiv query has neither a search nor IDs")
+	}
+	params := url.Values{
+		"start":       {strconv.Itoa(start)},
+		"max_results": {strconv.Itoa(size)},
+	}
+	if q.Search != "" {
+		params.Set("search_query", q.Search)
+	}
+	if len(q.IDs) > 0 {
+		params.Set("id_list", strings.Join(q.IDs, ","))
+	}
+	if q.SortBy != "" {
+		params.Set("sortBy", string(q.SortBy))
+	}
+	if q.Ascending {
+		params.Set("sortOrder", "ascending")
+	} else if q.SortBy != "" {
+		params.Set("sortOrder", "descending")
+	}
+
+	var f feed
+	if err := c.get(ctx, c.baseURL+"?"+params.Encode(), &f); err != nil {
+		return nil, 0, err
+	}
+	papers := make([]sources.Paper, 0, len(f.Entries))
+	for _, e := range f.Entries {
+		id := strings.TrimSpace(e.ID)
+		// Malformed queries are answered with a single entry describing the
+		// error
+		if strings.Contains(id, "arxiv.org/api/errors") {
+			return nil, 0, fmt.Errorf("arXiv API: %s", collapseSpace(e.Summary))
+		}
+		p := sources.Paper{
+			ID:       id,
+			DOI:      strings.TrimSpace(e.DOI),
+			Title:    collapseSpace(e.Title),
+			Abstract: collapseSpace(e.Summary),
+			URL:      id,
+		}
+		if _, abs, ok := strings.Cut(id, "/abs/"); ok {
+			p.ID = abs
+		}
+		// A malformed date only costs the paper its date
+		p.Published, _ = time.Parse(time.RFC3339, strings.TrimSpace(e.Published))
+		for _, a := range e.Authors {
+			p.Authors = append(p.Authors, strings.TrimSpace(a.Name))
+		}
+		for _, cat := range e.Categories {
+			p.Keywords = append(p.Keywords, cat.Term)
+		}
+		papers = append(papers, p)
+	}
+	return papers, f.TotalResults, nil
+}
+
+// get fetches and decodes a feed, waiting for its turn and retrying when
+// arXiv answers with 503, its way of asking clients to slow down
+func (c *Client) get(ctx context.Context, u string, f *feed) error {
+	resp, err := sources.Fetch(ctx, c.httpClient, c.limiter, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating arXiv request: %w", err)
+		}
+		return req, nil
+	}, http.StatusServiceUnavailable)
+	if err != nil {
+		return fmt.Errorf("error fetching papers from arXiv: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("arXiv API returned status %d", resp.StatusCode)
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(f); err != nil {
+		return fmt.Errorf("error parsing arXiv response: %w", err)
+	}
+	return nil
+}
+
+// collapseSpace joins the lines of an arXiv text field
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
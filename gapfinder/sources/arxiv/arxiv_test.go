@@ -0,0 +1,187 @@
+package arxiv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/sources"
+)
+
+// feedXML returns a feed of the papers numbered from start, n of them, out
+// of total
+func feedXML(start, n, total int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <opensearch:totalResults>%d</opensearch:totalResults>`, total)
+	for i := start; i < start+n; i++ {
+		fmt.Fprintf(&b, `
+  <entry>
+    <id>http://arxiv.org/abs/2401.%05dv1</id>
+    <title>Paper
+      %d</title>
+    <summary>  Abstract of
+      paper %d.  </summary>
+    <published>2024-01-02T18:00:00Z</published>
+    <arxiv:doi>10.1000/%d</arxiv:doi>
+    <category term="q-bio.NC"/>
+    <category term="cs.LG"/>
+    <author><name>Ada</name></author>
+    <author><name>Grace</name></author>
+  </entry>`, i, i, i, i)
+	}
+	b.WriteString("\n</feed>")
+	return b.String()
+}
+
+// newTestClient returns a client of a fake arXiv API serving total papers
+func newTestClient(t *testing.T, total int, requests *atomic.Int32, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		q := r.URL.Query()
+		start, _ := strconv.Atoi(q.Get("start"))
+		size, _ := strconv.Atoi(q.Get("max_results"))
+		w.Write([]byte(feedXML(start, max(min(size, total-start), 0), total)))
+	}))
+	t.Cleanup(srv.Close)
+	return New(append([]Option{WithBaseURL(srv.URL), WithInterval(0)}, opts...)...)
+}
+
+func TestSearch(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, 25, &requests, WithPageSize(10))
+
+	papers, err := c.Search(context.Background(), Query{Search: "cat:q-bio.NC"}, 15)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(papers) != 15 || requests.Load() != 2 {
+		t.Fatalf("got %d papers in %d requests, want 15 in 2", len(papers), requests.Load())
+	}
+	want := sources.Paper{
+		ID:       "2401.00000v1",
+		DOI:      "10.1000/0",
+		Title:    "Paper 0",
+		Abstract: "Abstract of paper 0.",
+		URL:      "http://arxiv.org/abs/2401.00000v1",
+	}
+	p := papers[0]
+	if p.ID != want.ID || p.DOI != want.DOI || p.Title != want.Title || p.Abstract != want.Abstract || p.URL != want.URL ||
+		len(p.Authors) != 2 || len(p.Keywords) != 2 || p.Keywords[0] != "q-bio.NC" || p.Published.Year() != 2024 {
+		t.Errorf("papers[0] = %+v", p)
+	}
+	if papers[14].Title != "Paper 14" {
+		t.Errorf("papers[14].Title = %q", papers[14].Title)
+	}
+}
+
+func TestAll(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, 25, &requests, WithPageSize(10))
+
+	n := 0
+	for p, err := range c.All(context.Background(), Query{Search: "all:sleep", SortBy: SortSubmitted}) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		if want := fmt.Sprintf("Paper %d", n); p.Title != want {
+			t.Errorf("paper %d title = %q, want %q", n, p.Title, want)
+		}
+		n++
+	}
+	if n != 25 || requests.Load() != 3 {
+		t.Errorf("got %d papers in %d requests, want 25 in 3", n, requests.Load())
+	}
+}
+
+func TestQueryParameters(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("search_query") != "cat:cs.LG" || q.Get("id_list") != "2401.00001,2401.00002" ||
+			q.Get("sortBy") != "lastUpdatedDate" || q.Get("sortOrder") != "ascending" {
+			t.Errorf("query = %v", q)
+		}
+		w.Write([]byte(feedXML(0, 0, 0)))
+	}))
+	defer srv.Close()
+	c := New(WithBaseURL(srv.URL), WithInterval(0))
+	q := Query{Search: "cat:cs.LG", IDs: []string{"2401.00001", "2401.00002"}, SortBy: SortLastUpdated, Ascending: true}
+	if _, err := c.Search(context.Background(), q, 10); err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if _, err := c.Search(context.Background(), Query{}, 10); err == nil {
+		t.Error("Search() of an empty query error = nil")
+	}
+}
+
+func TestGet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("id_list") {
+		case "2401.00007":
+			w.Write([]byte(feedXML(7, 1, 1)))
+		case "bad":
+			w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom"><entry>
+				<id>http://arxiv.org/api/errors#incorrect_id_format_for_bad</id>
+				<title>Error</title><summary>incorrect id format for bad</summary></entry></feed>`))
+		default:
+			w.Write([]byte(feedXML(0, 0, 0)))
+		}
+	}))
+	defer srv.Close()
+	c := New(WithBaseURL(srv.URL), WithInterval(0))
+	ctx := context.Background()
+
+	if p, err := c.Get(ctx, "2401.00007"); err != nil || p.Title != "Paper 7" {
+		t.Errorf("Get() = %+v, %v", p, err)
+	}
+	if _, err := c.Get(ctx, "2401.99999"); !errors.Is(err, sources.ErrNotFound) {
+		t.Errorf("Get() of an unknown ID error = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Get(ctx, "bad"); err == nil || !strings.Contains(err.Error(), "incorrect id format") {
+		t.Errorf("Get() of a malformed ID error = %v", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, 3, &requests, WithPageSize(1), WithInterval(40*time.Millisecond))
+
+	start := time.Now()
+	papers, err := c.Search(context.Background(), Query{Search: "all:sleep"}, 3)
+	if err != nil || len(papers) != 3 {
+		t.Fatalf("Search() = %d papers, %v", len(papers), err)
+	}
+	// The first request goes at once, the next two wait their turn
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("3 requests took %s, want at least 80ms", elapsed)
+	}
+}
+
+func TestRetryOnUnavailable(t *testing.T) {
+	defer func(d time.Duration) { sources.RetryWait = d }(sources.RetryWait)
+	sources.RetryWait = time.Millisecond
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(feedXML(0, 1, 1)))
+	}))
+	defer srv.Close()
+	c := New(WithBaseURL(srv.URL), WithInterval(0))
+
+	papers, err := c.Search(context.Background(), Query{Search: "all:sleep"}, 5)
+	if err != nil || len(papers) != 1 || requests.Load() != 2 {
+		t.Errorf("Search() = %d papers, %v after %d requests", len(papers), err, requests.Load())
+	}
+}
@@ -0,0 +1,67 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// MaxAttempts bounds the requests Fetch sends for a request that the source
+// keeps asking to slow down
+const MaxAttempts = 3
+
+// RetryWait is the first wait before retrying a request that the source
+// asked to slow down without saying for how long; later waits are longer
+var RetryWait = time.Second
+
+// Fetch sends a request of a source once limiter allows it, and sends it
+// again, up to MaxAttempts times in all, while the source answers with one
+// of the retry statuses, such as 429, its way of asking clients to slow
+// down. Before a retry it waits as long as the response's Retry-After says,
+// or else RetryWait times the attempts made so far. newRequest makes the
+// request afresh for each attempt.
+//
+// The response of the last attempt is returned whatever its status, and the
+// caller must close its body.
+func Fetch(ctx context.Context, hc *http.Client, limiter *rate.Limiter, newRequest func() (*http.Request, error), retry ...int) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := hc.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(retry, resp.StatusCode) || attempt == MaxAttempts {
+			return resp, nil
+		}
+		resp.Body.Close()
+		wait := time.Duration(attempt) * RetryWait
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			wait = time.Duration(secs) * time.Second
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
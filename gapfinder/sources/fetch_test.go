@@ -0,0 +1,64 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestFetch(t *testing.T) {
+	defer func(d time.Duration) { RetryWait = d }(RetryWait)
+	RetryWait = time.Millisecond
+	var requests atomic.Int32
+	status := http.StatusTooManyRequests
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	fetch := func() int {
+		t.Helper()
+		requests.Store(0)
+		resp, err := Fetch(context.Background(), srv.Client(), rate.NewLimiter(rate.Inf, 1), func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, srv.URL, nil)
+		}, http.StatusTooManyRequests)
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("Fetch() status = %d, want %d", resp.StatusCode, status)
+		}
+		return int(requests.Load())
+	}
+
+	if n := fetch(); n != MaxAttempts {
+		t.Errorf("Fetch() of a 429 sent %d requests, want %d", n, MaxAttempts)
+	}
+	status = http.StatusServiceUnavailable
+	if n := fetch(); n != 1 {
+		t.Errorf("Fetch() of a 503 sent %d requests, want 1 as it isn't a retry status", n)
+	}
+}
+
+func TestFetchCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := Fetch(ctx, srv.Client(), rate.NewLimiter(rate.Inf, 1), func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	}, http.StatusTooManyRequests)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Fetch() error = %v, want the deadline rather than the minute Retry-After asks", err)
+	}
+}
@@ -0,0 +1,460 @@
+// Package pubmed searches PubMed and fetches the metadata and abstracts of
+// its papers through the NCBI Entrez E-utilities, ESearch and EFetch, at the
+// rate NCBI allows with or without an API key. Searches can be by MeSH term:
+//
+//	c := pubmed.New(pubmed.WithAPIKey(os.Getenv("NCBI_API_KEY")))
+//	papers, err := c.Search(ctx, pubmed.Query{MeSH: []string{"Sleep Deprivation", "Memory"}}, 50)
+//
+// The papers can be analyzed with sources.Analyze.
+package pubmed
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/sources"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultURL is the base of the Entrez E-utilities
+	DefaultURL = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils"
+	// DefaultRate is the most requests a second NCBI allows without an API
+	// key
+	DefaultRate = 3
+	// KeyRate is the most requests a second NCBI allows with an API key
+	KeyRate = 10
+	// MaxResults is the most results of a search ESearch can page through
+	MaxResults = 10000
+
+	// pageSize is the number of papers fetched by a request
+	pageSize = 200
+)
+
+// Sort is the order of search results
+type Sort string
+
+const (
+	SortRelevance Sort = "relevance"
+	SortPubDate   Sort = "pub_date" // newest first
+)
+
+// Query is a PubMed search. At least one of Term and MeSH must be set; the
+// papers found match all of them.
+type Query struct {
+	// Term is in PubMed's query syntax, such as "sleep[tiab] AND
+	// hippocampus"
+	Term string
+	// MeSH are Medical Subject Headings the papers are indexed with, such as
+	// "Sleep Deprivation"
+	MeSH []string
+	// From and To limit the results to papers published in a date range;
+	// either can be zero
+	From, To time.Time
+	Sort     Sort // defaults to SortRelevance
+}
+
+// term returns the ESearch term of q
+func (q Query) term() string {
+	var parts []string
+	if q.Term != "" {
+		parts = append(parts, "("+q.Term+")")
+	}
+	for _, m := range q.MeSH {
+		parts = append(parts, strconv.Quote(m)+"[MeSH Terms]")
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// Client fetches papers from PubMed. It is safe for concurrent use, and its
+// requests are spaced out across goroutines.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	tool       string
+	email      string
+	httpClient *http.Client
+	limiter    *rate.Limiter
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithBaseURL sets the address of the E-utilities, such as that of a test
+// server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithAPIKey sets the NCBI API key sent with each request, raising the rate
+// of requests from DefaultRate to KeyRate a second. An empty key sends none.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithTool sets the tool name and contact email NCBI asks E-utilities users
+// to identify themselves with
+func WithTool(tool, email string) Option {
+	return func(c *Client) {
+		c.tool, c.email = tool, email
+	}
+}
+
+// WithHTTPClient sets the HTTP client requests are sent with
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithRate sets the most requests made a second, instead of the rate NCBI
+// allows; zero or less sends them without waiting
+func WithRate(perSecond float64) Option {
+	return func(c *Client) {
+		if perSecond <= 0 {
+			c.limiter = rate.NewLimiter(rate.Inf, 1)
+			return
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(perSecond), 1)
+	}
+}
+
+// New returns a client of the E-utilities, making up to DefaultRate requests
+// a second, or KeyRate with an API key
+func New(opts ...Option) *Client {
+	c := &Client{
+		baseURL:    DefaultURL,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.limiter == nil {
+		r := rate.Limit(DefaultRate)
+		if c.apiKey != "" {
+			r = KeyRate
+		}
+		c.limiter = rate.NewLimiter(r, 1)
+	}
+	return c
+}
+
+// Search returns up to limit papers found by q, fetching as many pages as
+// that takes
+func (c *Client) Search(ctx context.Context, q Query, limit int) ([]sources.Paper, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("limit must be at least 1, got %d", limit)
+	}
+	var papers []sources.Paper
+	for p, err := range c.all(ctx, q, min(limit, pageSize)) {
+		if err != nil {
+			return papers, err
+		}
+		papers = append(papers, p)
+		if len(papers) == limit {
+			break
+		}
+	}
+	return papers, nil
+}
+
+// All iterates over the papers found by q, up to MaxResults of them,
+// fetching the next page when the previous one has been read. Iteration
+// stops after the first error.
+func (c *Client) All(ctx context.Context, q Query) iter.Seq2[sources.Paper, error] {
+	return c.all(ctx, q, pageSize)
+}
+
+func (c *Client) all(ctx context.Context, q Query, size int) iter.Seq2[sources.Paper, error] {
+	return func(yield func(sources.Paper, error) bool) {
+		for start := 0; start < MaxResults; start += size {
+			ids, count, err := c.search(ctx, q, start, min(size, MaxResults-start))
+			if err != nil {
+				yield(sources.Paper{}, err)
+				return
+			}
+			papers, err := c.Fetch(ctx, ids)
+			if err != nil {
+				yield(sources.Paper{}, err)
+				return
+			}
+			for _, p := range papers {
+				if !yield(p, nil) {
+					return
+				}
+			}
+			if len(ids) < size || start+size >= count {
+				return
+			}
+		}
+	}
+}
+
+// Get returns the paper with a PMID. Unknown PMIDs fail with an error
+// wrapping sources.ErrNotFound.
+func (c *Client) Get(ctx context.Context, pmid string) (sources.Paper, error) {
+	papers, err := c.Fetch(ctx, []string{pmid})
+	if err != nil {
+		return sources.Paper{}, err
+	}
+	if len(papers) == 0 {
+		return sources.Paper{}, fmt.Errorf("PubMed paper %s: %w", pmid, sources.ErrNotFound)
+	}
+	return papers[0], nil
+}
+
+// Fetch returns the papers with PMIDs, in a request for each 200. PMIDs
+// PubMed doesn't know are left out.
+func (c *Client) Fetch(ctx context.Context, pmids []string) ([]sources.Paper, error) {
+	var papers []sources.Paper
+	for start := 0; start < len(pmids); start += pageSize {
+		params := url.Values{
+			"db":      {"pubmed"},
+			"id":      {strings.Join(pmids[start:min(start+pageSize, len(pmids))], ",")},
+			"retmode": {"xml"},
+		}
+		var set articleSet
+		if err := c.get(ctx, "efetch.fcgi", params, func(r io.Reader) error {
+			return xml.NewDecoder(r).Decode(&set)
+		}); err != nil {
+			return papers, err
+		}
+		for _, a := range set.Articles {
+			papers = append(papers, a.paper())
+		}
+	}
+	return papers, nil
+}
+
+// search returns a page of the PMIDs found by q, and how many there are in
+// all
+func (c *Client) search(ctx context.Context, q Query, start, size int) ([]string, int, error) {
+	term := q.term()
+	if term == "" {
+		return nil, 0, errors.New("PubMed query has neither a term nor MeSH terms")
+	}
+	params := url.Values{
+		"db":       {"pubmed"},
+		"term":     {term},
+		"retstart": {strconv.Itoa(start)},
+		"retmax":   {strconv.Itoa(size)},
+		"retmode":  {"json"},
+	}
+	if q.Sort != "" {
+		params.Set("sort", string(q.Sort))
+	}
+	if !q.From.IsZero() || !q.To.IsZero() {
+		params.Set("datetype", "pdat")
+		// ESearch needs both ends of the range
+		from, to := q.From, q.To
+		if from.IsZero() {
+			from = time.Date(1800, time.January, 1, 0, 0, 0, 0, time.UTC)
+		}
+		if to.IsZero() {
+			to = time.Date(3000, time.December, 31, 0, 0, 0, 0, time.UTC)
+		}
+		params.Set("mindate", from.Format("2006/01/02"))
+		params.Set("maxdate", to.Format("2006/01/02"))
+	}
+	var resp struct {
+		Result struct {
+			Count string   `json:"count"`
+			IDs   []string `json:"idlist"`
+			Error string   `json:"ERROR"`
+		} `json:"esearchresult"`
+		Error string `json:"error"`
+	}
+	if err := c.get(ctx, "esearch.fcgi", params, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&resp)
+	}); err != nil {
+		return nil, 0, err
+	}
+	if msg := cmp.Or(resp.Result.Error, resp.Error); msg != "" {
+		return nil, 0, fmt.Errorf("PubMed search: %s", msg)
+	}
+	count, _ := strconv.Atoi(resp.Result.Count)
+	return resp.Result.IDs, count, nil
+}
+
+// get sends a request to an E-utility and decodes its response, waiting for
+// its turn and retrying when NCBI answers with 429, as it does clients going
+// over their rate
+func (c *Client) get(ctx context.Context, utility string, params url.Values, decode func(io.Reader) error) error {
+	if c.apiKey != "" {
+		params.Set("api_key", c.apiKey)
+	}
+	if c.tool != "" {
+		params.Set("tool", c.tool)
+	}
+	if c.email != "" {
+		params.Set("email", c.email)
+	}
+	u := c.baseURL + "/" + utility + "?" + params.Encode()
+	resp, err := sources.Fetch(ctx, c.httpClient, c.limiter, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating PubMed request: %w", err)
+		}
+		return req, nil
+	}, http.StatusTooManyRequests)
+	if err != nil {
+		return fmt.Errorf("error fetching from PubMed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PubMed %s returned status %d", utility, resp.StatusCode)
+	}
+	if err := decode(resp.Body); err != nil {
+		return fmt.Errorf("error parsing PubMed response: %w", err)
+	}
+	return nil
+}
+
+// articleSet is an EFetch response
+type articleSet struct {
+	Articles []article `xml:"PubmedArticle"`
+}
+
+type article struct {
+	PMID    string `xml:"MedlineCitation>PMID"`
+	Article struct {
+		Title    text      `xml:"ArticleTitle"`
+		Abstract []section `xml:"Abstract>AbstractText"`
+		Authors  []struct {
+			LastName       string `xml:"LastName"`
+			ForeName       string `xml:"ForeName"`
+			CollectiveName string `xml:"CollectiveName"`
+		} `xml:"AuthorList>Author"`
+		PubDate struct {
+			Year        string `xml:"Year"`
+			Month       string `xml:"Month"`
+			Day         string `xml:"Day"`
+			MedlineDate string `xml:"MedlineDate"`
+		} `xml:"Journal>JournalIssue>PubDate"`
+	} `xml:"MedlineCitation>Article"`
+	MeSH       []string `xml:"MedlineCitation>MeshHeadingList>MeshHeading>DescriptorName"`
+	ArticleIDs []struct {
+		Type string `xml:"IdType,attr"`
+		ID   string `xml:",chardata"`
+	} `xml:"PubmedData>ArticleIdList>ArticleId"`
+}
+
+func (a *article) paper() sources.Paper {
+	p := sources.Paper{
+		ID:        a.PMID,
+		Title:     string(a.Article.Title),
+		URL:       "https://pubmed.ncbi.nlm.nih.gov/" + a.PMID + "/",
+		Published: a.published(),
+		Keywords:  a.MeSH,
+	}
+	for _, id := range a.ArticleIDs {
+		if id.Type == "doi" {
+			p.DOI = strings.TrimSpace(id.ID)
+		}
+	}
+	for _, au := range a.Article.Authors {
+		if au.CollectiveName != "" {
+			p.Authors = append(p.Authors, au.CollectiveName)
+			continue
+		}
+		p.Authors = append(p.Authors, strings.TrimSpace(au.ForeName+" "+au.LastName))
+	}
+	// Structured abstracts come in labeled sections
+	var sections []string
+	for _, s := range a.Article.Abstract {
+		if s.Label != "" {
+			sections = append(sections, s.Label+": "+s.Text)
+			continue
+		}
+		sections = append(sections, s.Text)
+	}
+	p.Abstract = strings.Join(sections, "\n")
+	return p
+}
+
+// published returns the publication date of the article, as precisely as
+// PubMed gives it, or zero
+func (a *article) published() time.Time {
+	d := a.Article.PubDate
+	year := d.Year
+	if year == "" && len(d.MedlineDate) >= 4 {
+		// Such as "1998 Dec-1999 Jan"
+		year = d.MedlineDate[:4]
+	}
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return time.Time{}
+	}
+	month := time.January
+	if m, err := strconv.Atoi(d.Month); err == nil && m >= 1 && m <= 12 {
+		month = time.Month(m)
+	} else if t, err := time.Parse("Jan", d.Month); err == nil {
+		month = t.Month()
+	}
+	day, err := strconv.Atoi(d.Day)
+	if err != nil || day < 1 {
+		day = 1
+	}
+	return time.Date(y, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// text is an element's text, including that of the markup inside it, such
+// as the <i> of species names in titles
+type text string
+
+func (t *text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var b strings.Builder
+	for depth := 0; ; {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.CharData:
+			b.Write(tok)
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				*t = text(strings.Join(strings.Fields(b.String()), " "))
+				return nil
+			}
+			depth--
+		}
+	}
+}
+
+// section is a section of an abstract, labeled in structured abstracts
+type section struct {
+	Label string
+	Text  string
+}
+
+func (s *section) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "Label" {
+			s.Label = attr.Value
+		}
+	}
+	var t text
+	if err := t.UnmarshalXML(d, start); err != nil {
+		return err
+	}
+	s.Text = string(t)
+	return nil
+}
@@ -0,0 +1,225 @@
+package pubmed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/sources"
+)
+
+// articleXML returns the EFetch XML of the paper with PMID n
+func articleXML(n int) string {
+	return fmt.Sprintf(`
+  <PubmedArticle>
+    <MedlineCitation>
+      <PMID Version="1">%d</PMID>
+      <Article>
+        <Journal><JournalIssue><PubDate><Year>2022</Year><Month>Mar</Month><Day>09</Day></PubDate></JournalIssue></Journal>
+        <ArticleTitle>Sleep in <i>Drosophila</i>
+          paper %d.</ArticleTitle>
+        <Abstract>
+          <AbstractText Label="BACKGROUND">Flies sleep.</AbstractText>
+          <AbstractText Label="RESULTS">They <b>really</b> do.</AbstractText>
+        </Abstract>
+        <AuthorList>
+          <Author><LastName>Lovelace</LastName><ForeName>Ada</ForeName></Author>
+          <Author><CollectiveName>Sleep Consortium</CollectiveName></Author>
+        </AuthorList>
+      </Article>
+      <MeshHeadingList>
+        <MeshHeading><DescriptorName UI="D012890">Sleep</DescriptorName></MeshHeading>
+        <MeshHeading><DescriptorName UI="D004331">Drosophila</DescriptorName></MeshHeading>
+      </MeshHeadingList>
+    </MedlineCitation>
+    <PubmedData>
+      <ArticleIdList>
+        <ArticleId IdType="pubmed">%d</ArticleId>
+        <ArticleId IdType="doi">10.1000/%d</ArticleId>
+      </ArticleIdList>
+    </PubmedData>
+  </PubmedArticle>`, n, n, n, n)
+}
+
+// newTestClient returns a client of fake E-utilities finding the PMIDs from 1
+// to total
+func newTestClient(t *testing.T, total int, requests *atomic.Int32, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		q := r.URL.Query()
+		if q.Get("db") != "pubmed" {
+			t.Errorf("db = %q", q.Get("db"))
+		}
+		switch r.URL.Path {
+		case "/esearch.fcgi":
+			start, _ := strconv.Atoi(q.Get("retstart"))
+			size, _ := strconv.Atoi(q.Get("retmax"))
+			var ids []string
+			for i := start + 1; i <= min(start+size, total); i++ {
+				ids = append(ids, strconv.Quote(strconv.Itoa(i)))
+			}
+			fmt.Fprintf(w, `{"header":{},"esearchresult":{"count":"%d","retmax":"%d","retstart":"%d","idlist":[%s]}}`,
+				total, len(ids), start, strings.Join(ids, ","))
+		case "/efetch.fcgi":
+			fmt.Fprint(w, `<?xml version="1.0" ?><PubmedArticleSet>`)
+			for _, id := range strings.Split(q.Get("id"), ",") {
+				if n, err := strconv.Atoi(id); err == nil && n >= 1 && n <= total {
+					fmt.Fprint(w, articleXML(n))
+				}
+			}
+			fmt.Fprint(w, `</PubmedArticleSet>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return New(append([]Option{WithBaseURL(srv.URL), WithRate(0)}, opts...)...)
+}
+
+func TestSearch(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, 5, &requests)
+
+	papers, err := c.Search(context.Background(), Query{MeSH: []string{"Sleep"}}, 3)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(papers) != 3 || requests.Load() != 2 {
+		t.Fatalf("got %d papers in %d requests, want 3 in 2", len(papers), requests.Load())
+	}
+	p := papers[0]
+	if p.ID != "1" || p.DOI != "10.1000/1" || p.Title != "Sleep in Drosophila paper 1." ||
+		p.URL != "https://pubmed.ncbi.nlm.nih.gov/1/" || p.Published != time.Date(2022, time.March, 9, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("papers[0] = %+v", p)
+	}
+	if p.Abstract != "BACKGROUND: Flies sleep.\nRESULTS: They really do." {
+		t.Errorf("papers[0].Abstract = %q", p.Abstract)
+	}
+	if fmt.Sprint(p.Authors) != "[Ada Lovelace Sleep Consortium]" || fmt.Sprint(p.Keywords) != "[Sleep Drosophila]" {
+		t.Errorf("papers[0] authors = %v, keywords = %v", p.Authors, p.Keywords)
+	}
+}
+
+func TestAll(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, 450, &requests)
+
+	n := 0
+	for p, err := range c.All(context.Background(), Query{Term: "sleep"}) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		n++
+		if p.ID != strconv.Itoa(n) {
+			t.Fatalf("paper %d has PMID %s", n, p.ID)
+		}
+	}
+	// Three pages of 200, each searched then fetched
+	if n != 450 || requests.Load() != 6 {
+		t.Errorf("got %d papers in %d requests, want 450 in 6", n, requests.Load())
+	}
+}
+
+func TestQueryParameters(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		want := map[string]string{
+			"term":     `(sleep[tiab]) AND "Sleep Deprivation"[MeSH Terms] AND "Memory"[MeSH Terms]`,
+			"sort":     "pub_date",
+			"datetype": "pdat",
+			"mindate":  "2020/01/01",
+			"maxdate":  "3000/12/31",
+			"api_key":  "secret",
+			"tool":     "gapfinder",
+			"email":    "dev@example.com",
+		}
+		for k, v := range want {
+			if q.Get(k) != v {
+				t.Errorf("%s = %q, want %q", k, q.Get(k), v)
+			}
+		}
+		fmt.Fprint(w, `{"esearchresult":{"count":"0","idlist":[]}}`)
+	}))
+	defer srv.Close()
+	c := New(WithBaseURL(srv.URL), WithAPIKey("secret"), WithTool("gapfinder", "dev@example.com"), WithRate(0))
+	q := Query{
+		Term: "sleep[tiab]",
+		MeSH: []string{"Sleep Deprivation", "Memory"},
+		From: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Sort: SortPubDate,
+	}
+
+	if papers, err := c.Search(context.Background(), q, 10); err != nil || len(papers) != 0 {
+		t.Errorf("Search() = %v, %v", papers, err)
+	}
+	if _, err := c.Search(context.Background(), Query{}, 10); err == nil {
+		t.Error("Search() of an empty query error = nil")
+	}
+}
+
+func TestRate(t *testing.T) {
+	for _, tc := range []struct {
+		opts []Option
+		want float64
+	}{
+		{nil, DefaultRate},
+		{[]Option{WithAPIKey("secret")}, KeyRate},
+		{[]Option{WithAPIKey("secret"), WithRate(2)}, 2},
+	} {
+		if got := float64(New(tc.opts...).limiter.Limit()); got != tc.want {
+			t.Errorf("rate = %v, want %v", got, tc.want)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, 5, &requests)
+
+	if p, err := c.Get(context.Background(), "4"); err != nil || p.Title != "Sleep in Drosophila paper 4." {
+		t.Errorf("Get() = %+v, %v", p, err)
+	}
+	if _, err := c.Get(context.Background(), "99"); !errors.Is(err, sources.ErrNotFound) {
+		t.Errorf("Get() of an unknown PMID error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSearchError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"esearchresult":{"ERROR":"Invalid query"}}`)
+	}))
+	defer srv.Close()
+	c := New(WithBaseURL(srv.URL), WithRate(0))
+
+	if _, err := c.Search(context.Background(), Query{Term: "(("}, 10); err == nil || !strings.Contains(err.Error(), "Invalid query") {
+		t.Errorf("Search() error = %v", err)
+	}
+}
+
+func TestRetryOnTooManyRequests(t *testing.T) {
+	defer func(d time.Duration) { sources.RetryWait = d }(sources.RetryWait)
+	sources.RetryWait = time.Millisecond
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":"API rate limit exceeded"}`)
+			return
+		}
+		fmt.Fprint(w, `<PubmedArticleSet>`+articleXML(1)+`</PubmedArticleSet>`)
+	}))
+	defer srv.Close()
+	c := New(WithBaseURL(srv.URL), WithRate(0))
+
+	if _, err := c.Get(context.Background(), "1"); err != nil || requests.Load() != 2 {
+		t.Errorf("Get() error = %v after %d requests", err, requests.Load())
+	}
+}
@@ -0,0 +1,444 @@
+// Package semanticscholar looks up papers, their citations and references,
+// and searches for papers through the Semantic Scholar Academic Graph API:
+//
+//	c := semanticscholar.New(semanticscholar.WithAPIKey(os.Getenv("S2_API_KEY")))
+//	p, err := c.GetDOI(ctx, "10.1038/nature14539")
+//	for citing, err := range c.Citations(ctx, p.ID) {
+//		...
+//	}
+//
+// Its papers carry citation counts, and Enrich adds them to the papers of
+// other sources.
+package semanticscholar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/sources"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultURL is the Semantic Scholar Academic Graph API
+	DefaultURL = "https://api.semanticscholar.org/graph/v1"
+	// DefaultInterval is the time between requests allowed to a Semantic
+	// Scholar API key; clients without one share a pool that is often
+	// exhausted
+	DefaultInterval = time.Second
+	// MaxBatch is the most papers looked up by a request of Enrich or
+	// GetBatch
+	MaxBatch = 500
+
+	// fields are the paper fields requested, those of a sources.Paper
+	fields = "paperId,externalIds,title,abstract,authors,year,publicationDate,url,citationCount,fieldsOfStudy"
+	// graphPageSize is the most citations or references fetched by a request
+	graphPageSize = 1000
+)
+
+// Query is a bulk search of Semantic Scholar
+type Query struct {
+	// Search is in Semantic Scholar's bulk search syntax, which supports
+	// quoted phrases, + for AND, | for OR and - for NOT
+	Search string
+	// Year limits the results to a year or range, such as "2019" or
+	// "2019-2023"
+	Year          string
+	FieldsOfStudy []string // such as "Medicine" or "Computer Science"
+	MinCitations  int
+	// Sort orders the results, such as "citationCount:desc"; the default is
+	// by paper ID
+	Sort string
+}
+
+// Client fetches papers from the Semantic Scholar API. It is safe for
+// concurrent use, and its requests are spaced out across goroutines.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	limiter    *rate.Limiter
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithBaseURL sets the address of the API, such as that of a test server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithAPIKey sets the API key sent with each request. An empty key sends
+// none.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithHTTPClient sets the HTTP client requests are sent with
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithInterval sets the time between requests, such as a shorter one an API
+// key has been granted; zero sends them without waiting
+func WithInterval(d time.Duration) Option {
+	return func(c *Client) {
+		if d <= 0 {
+			c.limiter = rate.NewLimiter(rate.Inf, 1)
+			return
+		}
+		c.limiter = rate.NewLimiter(rate.Every(d), 1)
+	}
+}
+
+// New returns a client of the public Semantic Scholar API, making a request
+// every DefaultInterval
+func New(opts ...Option) *Client {
+	c := &Client{
+		baseURL:    DefaultURL,
+		httpClient: http.DefaultClient,
+		limiter:    rate.NewLimiter(rate.Every(DefaultInterval), 1),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Get returns a paper by any identifier Semantic Scholar knows, such as its
+// own paper ID, "DOI:10.1038/nature14539", "ARXIV:1706.03762" or
+// "PMID:19872477". Unknown papers fail with an error wrapping
+// sources.ErrNotFound.
+func (c *Client) Get(ctx context.Context, id string) (sources.Paper, error) {
+	var p paper
+	if err := c.do(ctx, http.MethodGet, "/paper/"+url.PathEscape(id), url.Values{"fields": {fields}}, nil, &p); err != nil {
+		return sources.Paper{}, fmt.Errorf("Semantic Scholar paper %s: %w", id, err)
+	}
+	return p.paper(), nil
+}
+
+// GetDOI returns the paper with a DOI
+func (c *Client) GetDOI(ctx context.Context, doi string) (sources.Paper, error) {
+	return c.Get(ctx, "DOI:"+doi)
+}
+
+// GetBatch looks up papers by the identifiers Get takes, up to MaxBatch of
+// them in a request. The papers are in the order of ids, with OK false for
+// those Semantic Scholar doesn't know.
+func (c *Client) GetBatch(ctx context.Context, ids []string) ([]BatchResult, error) {
+	results := make([]BatchResult, 0, len(ids))
+	for start := 0; start < len(ids); start += MaxBatch {
+		chunk := ids[start:min(start+MaxBatch, len(ids))]
+		body, err := json.Marshal(map[string][]string{"ids": chunk})
+		if err != nil {
+			return nil, err
+		}
+		// Unknown papers are null
+		var papers []*paper
+		if err := c.do(ctx, http.MethodPost, "/paper/batch", url.Values{"fields": {fields}}, body, &papers); err != nil {
+			return nil, err
+		}
+		if len(papers) != len(chunk) {
+			return nil, fmt.Errorf("Semantic Scholar returned %d papers for %d IDs", len(papers), len(chunk))
+		}
+		for _, p := range papers {
+			if p == nil {
+				results = append(results, BatchResult{})
+				continue
+			}
+			results = append(results, BatchResult{Paper: p.paper(), OK: true})
+		}
+	}
+	return results, nil
+}
+
+// BatchResult is a paper looked up by GetBatch
+type BatchResult struct {
+	Paper sources.Paper
+	OK    bool // whether Semantic Scholar knows the paper
+}
+
+// Enrich adds the citation counts Semantic Scholar knows to papers, looking
+// them up by DOI, or by arXiv ID or PMID for papers from arXiv or PubMed.
+// Papers that can't be looked up, or that Semantic Scholar doesn't know, are
+// left as they are. A DOI or abstract a paper lacks is filled in too.
+func (c *Client) Enrich(ctx context.Context, papers []sources.Paper) error {
+	var ids []string
+	var at []int // index in papers of each ID
+	for i, p := range papers {
+		if id := lookupID(p); id != "" {
+			ids = append(ids, id)
+			at = append(at, i)
+		}
+	}
+	results, err := c.GetBatch(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for j, r := range results {
+		if !r.OK {
+			continue
+		}
+		p := &papers[at[j]]
+		p.Citations = r.Paper.Citations
+		if p.DOI == "" {
+			p.DOI = r.Paper.DOI
+		}
+		if p.Abstract == "" {
+			p.Abstract = r.Paper.Abstract
+		}
+	}
+	return nil
+}
+
+var (
+	arxivURL  = regexp.MustCompile(`arxiv\.org/abs/(.+?)(v\d+)?$`)
+	pubmedURL = regexp.MustCompile(`pubmed\.ncbi\.nlm\.nih\.gov/(\d+)`)
+)
+
+// lookupID returns the identifier Semantic Scholar knows a paper by, or ""
+func lookupID(p sources.Paper) string {
+	switch {
+	case p.DOI != "":
+		return "DOI:" + p.DOI
+	case strings.Contains(p.URL, "semanticscholar.org/") && p.ID != "":
+		return p.ID
+	}
+	if m := arxivURL.FindStringSubmatch(p.URL); m != nil {
+		return "ARXIV:" + m[1]
+	}
+	if m := pubmedURL.FindStringSubmatch(p.URL); m != nil {
+		return "PMID:" + m[1]
+	}
+	return ""
+}
+
+// Citations iterates over the papers citing the paper with an identifier Get
+// takes. Iteration stops after the first error.
+func (c *Client) Citations(ctx context.Context, id string) iter.Seq2[sources.Paper, error] {
+	return c.graph(ctx, id, "citations", func(e edge) *paper { return e.CitingPaper })
+}
+
+// References iterates over the papers cited by the paper with an identifier
+// Get takes. Iteration stops after the first error.
+func (c *Client) References(ctx context.Context, id string) iter.Seq2[sources.Paper, error] {
+	return c.graph(ctx, id, "references", func(e edge) *paper { return e.CitedPaper })
+}
+
+// edge is a citation of a paper, or a reference of it
+type edge struct {
+	CitingPaper *paper `json:"citingPaper"`
+	CitedPaper  *paper `json:"citedPaper"`
+}
+
+func (c *Client) graph(ctx context.Context, id, kind string, end func(edge) *paper) iter.Seq2[sources.Paper, error] {
+	return func(yield func(sources.Paper, error) bool) {
+		offset := 0
+		for {
+			params := url.Values{
+				"fields": {fields},
+				"offset": {strconv.Itoa(offset)},
+				"limit":  {strconv.Itoa(graphPageSize)},
+			}
+			var page struct {
+				Next *int   `json:"next"`
+				Data []edge `json:"data"`
+			}
+			if err := c.do(ctx, http.MethodGet, "/paper/"+url.PathEscape(id)+"/"+kind, params, nil, &page); err != nil {
+				yield(sources.Paper{}, fmt.Errorf("%s of Semantic Scholar paper %s: %w", kind, id, err))
+				return
+			}
+			for _, e := range page.Data {
+				// Papers Semantic Scholar knows only from a reference list
+				// have no ID
+				p := end(e)
+				if p == nil || p.PaperID == "" {
+					continue
+				}
+				if !yield(p.paper(), nil) {
+					return
+				}
+			}
+			if page.Next == nil || len(page.Data) == 0 {
+				return
+			}
+			offset = *page.Next
+		}
+	}
+}
+
+// Search returns up to limit papers found by q, fetching as many pages as
+// that takes
+func (c *Client) Search(ctx context.Context, q Query, limit int) ([]sources.Paper, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("limit must be at least 1, got %d", limit)
+	}
+	var papers []sources.Paper
+	for p, err := range c.All(ctx, q) {
+		if err != nil {
+			return papers, err
+		}
+		papers = append(papers, p)
+		if len(papers) == limit {
+			break
+		}
+	}
+	return papers, nil
+}
+
+// All iterates over every paper found by q, fetching the next page of up to
+// 1000 when the previous one has been read. Iteration stops after the first
+// error.
+func (c *Client) All(ctx context.Context, q Query) iter.Seq2[sources.Paper, error] {
+	return func(yield func(sources.Paper, error) bool) {
+		if q.Search == "" {
+			yield(sources.Paper{}, errors.New("Semantic Scholar query has no search"))
+			return
+		}
+		params := url.Values{"query": {q.Search}, "fields": {fields}}
+		if q.Year != "" {
+			params.Set("year", q.Year)
+		}
+		if len(q.FieldsOfStudy) > 0 {
+			params.Set("fieldsOfStudy", strings.Join(q.FieldsOfStudy, ","))
+		}
+		if q.MinCitations > 0 {
+			params.Set("minCitationCount", strconv.Itoa(q.MinCitations))
+		}
+		if q.Sort != "" {
+			params.Set("sort", q.Sort)
+		}
+		for {
+			var page struct {
+				Token string  `json:"token"`
+				Data  []paper `json:"data"`
+			}
+			if err := c.do(ctx, http.MethodGet, "/paper/search/bulk", params, nil, &page); err != nil {
+				yield(sources.Paper{}, err)
+				return
+			}
+			for _, p := range page.Data {
+				if !yield(p.paper(), nil) {
+					return
+				}
+			}
+			if page.Token == "" {
+				return
+			}
+			params.Set("token", page.Token)
+		}
+	}
+}
+
+// paper is a paper of the Semantic Scholar API with the fields requested
+type paper struct {
+	PaperID     string            `json:"paperId"`
+	ExternalIDs map[string]string `json:"externalIds"`
+	Title       string            `json:"title"`
+	Abstract    string            `json:"abstract"`
+	Authors     []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Year            int      `json:"year"`
+	PublicationDate string   `json:"publicationDate"`
+	URL             string   `json:"url"`
+	CitationCount   int      `json:"citationCount"`
+	FieldsOfStudy   []string `json:"fieldsOfStudy"`
+}
+
+func (p *paper) paper() sources.Paper {
+	sp := sources.Paper{
+		ID:        p.PaperID,
+		DOI:       p.ExternalIDs["DOI"],
+		Title:     p.Title,
+		Abstract:  p.Abstract,
+		URL:       p.URL,
+		Keywords:  p.FieldsOfStudy,
+		Citations: p.CitationCount,
+	}
+	for _, a := range p.Authors {
+		sp.Authors = append(sp.Authors, a.Name)
+	}
+	// Older papers often have only a year
+	if t, err := time.Parse(time.DateOnly, p.PublicationDate); err == nil {
+		sp.Published = t
+	} else if p.Year > 0 {
+		sp.Published = time.Date(p.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return sp
+}
+
+// do sends a request and decodes its JSON response into v, waiting for its
+// turn and retrying when Semantic Scholar asks to slow down
+func (c *Client) do(ctx context.Context, method, path string, params url.Values, body []byte, v any) error {
+	u := c.baseURL + path + "?" + params.Encode()
+	resp, err := sources.Fetch(ctx, c.httpClient, c.limiter, func() (*http.Request, error) {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, u, r)
+		if err != nil {
+			return nil, fmt.Errorf("error creating Semantic Scholar request: %w", err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.apiKey != "" {
+			req.Header.Set("x-api-key", c.apiKey)
+		}
+		return req, nil
+	}, http.StatusTooManyRequests, http.StatusServiceUnavailable)
+	if err != nil {
+		return fmt.Errorf("error fetching from Semantic Scholar: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error parsing Semantic Scholar response: %w", err)
+	}
+	return nil
+}
+
+// statusError returns the error of a response that isn't 200 OK, with the
+// message Semantic Scholar gives
+func statusError(resp *http.Response) error {
+	var e struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&e)
+	msg := e.Error
+	if msg == "" {
+		msg = e.Message
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		if msg == "" {
+			return sources.ErrNotFound
+		}
+		return fmt.Errorf("%w: %s", sources.ErrNotFound, msg)
+	}
+	if msg == "" {
+		return fmt.Errorf("Semantic Scholar API returned status %d", resp.StatusCode)
+	}
+	return fmt.Errorf("Semantic Scholar API returned status %d: %s", resp.StatusCode, msg)
+}
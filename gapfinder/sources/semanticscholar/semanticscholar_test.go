@@ -0,0 +1,223 @@
+package semanticscholar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/sources"
+)
+
+// testPaper returns the API's JSON of paper n
+func testPaper(n int) map[string]any {
+	return map[string]any{
+		"paperId":         fmt.Sprintf("s2-%d", n),
+		"externalIds":     map[string]string{"DOI": fmt.Sprintf("10.1000/%d", n)},
+		"title":           fmt.Sprintf("Paper %d", n),
+		"abstract":        fmt.Sprintf("Abstract %d", n),
+		"authors":         []map[string]string{{"name": "Ada"}},
+		"year":            2023,
+		"publicationDate": "2023-05-06",
+		"url":             fmt.Sprintf("https://www.semanticscholar.org/paper/s2-%d", n),
+		"citationCount":   n * 10,
+		"fieldsOfStudy":   []string{"Medicine"},
+	}
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return New(append([]Option{WithBaseURL(srv.URL), WithInterval(0)}, opts...)...)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestGet(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "secret" {
+			t.Errorf("x-api-key = %q", r.Header.Get("x-api-key"))
+		}
+		switch r.URL.Path {
+		case "/paper/DOI:10.1000/3":
+			writeJSON(w, testPaper(3))
+		case "/paper/old":
+			writeJSON(w, map[string]any{"paperId": "old", "title": "Old", "year": 1998})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(w, map[string]string{"error": "Paper with id " + r.URL.Path + " not found"})
+		}
+	}, WithAPIKey("secret"))
+	ctx := context.Background()
+
+	p, err := c.GetDOI(ctx, "10.1000/3")
+	if err != nil {
+		t.Fatalf("GetDOI() error = %v", err)
+	}
+	if p.ID != "s2-3" || p.DOI != "10.1000/3" || p.Title != "Paper 3" || p.Abstract != "Abstract 3" ||
+		len(p.Authors) != 1 || p.Citations != 30 || p.Keywords[0] != "Medicine" || p.Published.Month() != 5 {
+		t.Errorf("GetDOI() = %+v", p)
+	}
+	if p, err := c.Get(ctx, "old"); err != nil || p.Published.Year() != 1998 {
+		t.Errorf("Get() of a paper with only a year = %+v, %v", p, err)
+	}
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, sources.ErrNotFound) {
+		t.Errorf("Get() of an unknown paper error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Method != http.MethodPost || r.URL.Path != "/paper/batch" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		var body struct{ IDs []string }
+		json.NewDecoder(r.Body).Decode(&body)
+		want := []string{"DOI:10.1000/1", "ARXIV:2401.00002", "PMID:123"}
+		if fmt.Sprint(body.IDs) != fmt.Sprint(want) {
+			t.Errorf("ids = %v, want %v", body.IDs, want)
+		}
+		// The PMID is unknown
+		writeJSON(w, []any{testPaper(1), testPaper(2), nil})
+	})
+	papers := []sources.Paper{
+		{Title: "by DOI", DOI: "10.1000/1", Abstract: "own"},
+		{Title: "from arXiv", URL: "http://arxiv.org/abs/2401.00002v3"},
+		{Title: "from PubMed", URL: "https://pubmed.ncbi.nlm.nih.gov/123/"},
+		{Title: "unknown source", URL: "https://example.com/paper"},
+	}
+
+	if err := c.Enrich(context.Background(), papers); err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+	if papers[0].Citations != 10 || papers[0].Abstract != "own" {
+		t.Errorf("papers[0] = %+v, want 10 citations and its own abstract", papers[0])
+	}
+	if papers[1].Citations != 20 || papers[1].DOI != "10.1000/2" || papers[1].Abstract != "Abstract 2" {
+		t.Errorf("papers[1] = %+v, want 20 citations with the DOI and abstract filled in", papers[1])
+	}
+	if papers[2].Citations != 0 || papers[3].Citations != 0 {
+		t.Errorf("papers not found were changed: %+v, %+v", papers[2], papers[3])
+	}
+	if requests.Load() != 1 {
+		t.Errorf("made %d requests, want 1", requests.Load())
+	}
+}
+
+func TestCitations(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		switch r.URL.Path {
+		case "/paper/s2-1/citations":
+			// Two pages: papers 0 and 1, then paper 2 and one without an ID
+			if offset == 0 {
+				writeJSON(w, map[string]any{"offset": 0, "next": 2, "data": []any{
+					map[string]any{"citingPaper": testPaper(0)},
+					map[string]any{"citingPaper": testPaper(1)},
+				}})
+				return
+			}
+			writeJSON(w, map[string]any{"offset": 2, "data": []any{
+				map[string]any{"citingPaper": testPaper(2)},
+				map[string]any{"citingPaper": map[string]any{"paperId": nil, "title": "Unresolved"}},
+			}})
+		case "/paper/s2-1/references":
+			writeJSON(w, map[string]any{"offset": 0, "data": []any{map[string]any{"citedPaper": testPaper(9)}}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	var titles []string
+	for p, err := range c.Citations(ctx, "s2-1") {
+		if err != nil {
+			t.Fatalf("Citations() error = %v", err)
+		}
+		titles = append(titles, p.Title)
+	}
+	if fmt.Sprint(titles) != "[Paper 0 Paper 1 Paper 2]" {
+		t.Errorf("Citations() = %v", titles)
+	}
+	for p, err := range c.References(ctx, "s2-1") {
+		if err != nil || p.Title != "Paper 9" {
+			t.Errorf("References() = %+v, %v", p, err)
+		}
+	}
+	for _, err := range c.Citations(ctx, "missing") {
+		if !errors.Is(err, sources.ErrNotFound) {
+			t.Errorf("Citations() of an unknown paper error = %v, want ErrNotFound", err)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		q := r.URL.Query()
+		if q.Get("query") != `"sleep spindles"` || q.Get("year") != "2019-2023" ||
+			q.Get("fieldsOfStudy") != "Medicine,Biology" || q.Get("minCitationCount") != "5" {
+			t.Errorf("query = %v", q)
+		}
+		// Pages of two papers, three pages in all
+		page, _ := strconv.Atoi(q.Get("token"))
+		resp := map[string]any{"total": 6, "data": []any{testPaper(2 * page), testPaper(2*page + 1)}}
+		if page < 2 {
+			resp["token"] = strconv.Itoa(page + 1)
+		}
+		writeJSON(w, resp)
+	})
+	q := Query{Search: `"sleep spindles"`, Year: "2019-2023", FieldsOfStudy: []string{"Medicine", "Biology"}, MinCitations: 5}
+
+	papers, err := c.Search(context.Background(), q, 3)
+	if err != nil || len(papers) != 3 || papers[2].Title != "Paper 2" || requests.Load() != 2 {
+		t.Errorf("Search() = %d papers, %v after %d requests", len(papers), err, requests.Load())
+	}
+	n := 0
+	for _, err := range c.All(context.Background(), q) {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		n++
+	}
+	if n != 6 {
+		t.Errorf("All() yielded %d papers, want 6", n)
+	}
+	if _, err := c.Search(context.Background(), Query{}, 3); err == nil {
+		t.Error("Search() without a search error = nil")
+	}
+}
+
+func TestRetryOnTooManyRequests(t *testing.T) {
+	defer func(d time.Duration) { sources.RetryWait = d }(sources.RetryWait)
+	sources.RetryWait = time.Millisecond
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < sources.MaxAttempts {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		writeJSON(w, testPaper(1))
+	})
+
+	if _, err := c.Get(context.Background(), "s2-1"); err != nil || requests.Load() != sources.MaxAttempts {
+		t.Errorf("Get() error = %v after %d requests", err, requests.Load())
+	}
+	requests.Store(-10)
+	if _, err := c.Get(context.Background(), "s2-1"); err == nil {
+		t.Error("Get() error = nil after too many 429s")
+	}
+}
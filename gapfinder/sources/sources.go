@@ -0,0 +1,92 @@
+// Package sources holds what the paper sources in its subpackages have in
+// common: the Paper they find, Fetch, which sends their requests at the
+// pace the sources allow, and Analyze, which feeds papers to the service's
+// AnalyzeAbstract so that a topic pipeline can be built in Go instead of
+// relying on the service's own search:
+//
+//	c := arxiv.New()
+//	papers := c.All(ctx, arxiv.Query{Search: "cat:q-bio.NC AND all:sleep"})
+//	for a, err := range sources.Analyze(ctx, gapClient, papers, types.AnalyzeRequest{Field: types.FieldNeuroscience}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(a.Paper.Title, len(a.Result.Gaps))
+//	}
+package sources
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// ErrNotFound is wrapped by the errors of sources asked for papers they
+// don't have
+var ErrNotFound = errors.New("paper not found")
+
+// Paper is a paper found by a source. Fields the source doesn't know are
+// left empty.
+type Paper struct {
+	ID        string // the source's identifier, such as an arXiv ID or PMID
+	DOI       string
+	Title     string
+	Authors   []string
+	Abstract  string
+	URL       string
+	Published time.Time
+	Keywords  []string // such as arXiv categories or MeSH terms
+	Citations int      // number of papers citing it, if the source counts them
+}
+
+// AnalyzeRequest returns the request analyzing the paper's abstract with the
+// settings of tmpl, such as its Field and Model
+func (p Paper) AnalyzeRequest(tmpl types.AnalyzeRequest) types.AnalyzeRequest {
+	req := tmpl
+	req.Title, req.Abstract = p.Title, p.Abstract
+	req.Authors = p.Authors
+	req.Keywords = slices.Concat(tmpl.Keywords, p.Keywords)
+	return req
+}
+
+// AbstractAnalyzer analyzes abstracts; *client.Client implements it
+type AbstractAnalyzer interface {
+	AnalyzeAbstract(ctx context.Context, req types.AnalyzeRequest, opts ...client.RequestOption) (*types.AnalyzeResponse, error)
+}
+
+// Analyzed is a paper with the analysis of its abstract
+type Analyzed struct {
+	Paper  Paper
+	Result *types.AnalyzeResponse
+}
+
+// Analyze analyzes the abstract of each paper of papers, with the settings
+// of tmpl, one at a time. Papers without an abstract are skipped. A paper
+// whose analysis fails is yielded with the error and a nil Result, and
+// iteration goes on unless the loop breaks; an error of papers itself ends
+// the iteration, as does ctx ending.
+func Analyze(ctx context.Context, a AbstractAnalyzer, papers iter.Seq2[Paper, error], tmpl types.AnalyzeRequest, opts ...client.RequestOption) iter.Seq2[Analyzed, error] {
+	return func(yield func(Analyzed, error) bool) {
+		for p, err := range papers {
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				yield(Analyzed{}, err)
+				return
+			}
+			if strings.TrimSpace(p.Abstract) == "" {
+				continue
+			}
+			result, err := a.AnalyzeAbstract(ctx, p.AnalyzeRequest(tmpl), opts...)
+			if !yield(Analyzed{Paper: p, Result: result}, err) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/client"
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// seq yields papers, then err if it isn't nil
+func seq(papers []Paper, err error) iter.Seq2[Paper, error] {
+	return func(yield func(Paper, error) bool) {
+		for _, p := range papers {
+			if !yield(p, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(Paper{}, err)
+		}
+	}
+}
+
+func TestAnalyzeRequest(t *testing.T) {
+	p := Paper{Title: "T", Abstract: "A", Authors: []string{"Ada"}, Keywords: []string{"cs.LG"}}
+	tmpl := types.AnalyzeRequest{Field: types.FieldNeuroscience, Keywords: []string{"sleep"}}
+
+	req := p.AnalyzeRequest(tmpl)
+	if req.Title != "T" || req.Abstract != "A" || req.Field != types.FieldNeuroscience ||
+		!slices.Equal(req.Authors, []string{"Ada"}) || !slices.Equal(req.Keywords, []string{"sleep", "cs.LG"}) {
+		t.Errorf("AnalyzeRequest() = %+v", req)
+	}
+	if len(tmpl.Keywords) != 1 {
+		t.Errorf("AnalyzeRequest() changed the template's keywords to %v", tmpl.Keywords)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	failed := errors.New("analysis failed")
+	mock := &client.AnalyzerMock{
+		AnalyzeAbstractFunc: func(ctx context.Context, req types.AnalyzeRequest, opts ...client.RequestOption) (*types.AnalyzeResponse, error) {
+			if req.Title == "bad" {
+				return nil, failed
+			}
+			return &types.AnalyzeResponse{KeyFindings: []string{req.Title}}, nil
+		},
+	}
+	papers := []Paper{
+		{Title: "one", Abstract: "first"},
+		{Title: "no abstract", Abstract: "  "},
+		{Title: "bad", Abstract: "fails"},
+		{Title: "two", Abstract: "second"},
+	}
+	sourceErr := errors.New("source failed")
+
+	var got []string
+	var errs []error
+	for a, err := range Analyze(context.Background(), mock, seq(papers, sourceErr), types.AnalyzeRequest{}) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		got = append(got, a.Result.KeyFindings[0])
+	}
+	if !slices.Equal(got, []string{"one", "two"}) {
+		t.Errorf("analyzed %v, want [one two]", got)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], failed) || !errors.Is(errs[1], sourceErr) {
+		t.Errorf("errors = %v, want the failed analysis then the source's error", errs)
+	}
+	if n := len(mock.AnalyzeAbstractCalls()); n != 3 {
+		t.Errorf("AnalyzeAbstract called %d times, want 3", n)
+	}
+}
+
+func TestAnalyzeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	mock := &client.AnalyzerMock{
+		AnalyzeAbstractFunc: func(ctx context.Context, req types.AnalyzeRequest, opts ...client.RequestOption) (*types.AnalyzeResponse, error) {
+			cancel()
+			return &types.AnalyzeResponse{}, nil
+		},
+	}
+	papers := []Paper{{Abstract: "first"}, {Abstract: "second"}}
+
+	var errs []error
+	for _, err := range Analyze(ctx, mock, seq(papers, nil), types.AnalyzeRequest{}) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 2 || errs[0] != nil || !errors.Is(errs[1], context.Canceled) {
+		t.Errorf("errors = %v, want nil then context.Canceled", errs)
+	}
+	if n := len(mock.AnalyzeAbstractCalls()); n != 1 {
+		t.Errorf("AnalyzeAbstract called %d times, want 1", n)
+	}
+}
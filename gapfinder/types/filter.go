@@ -0,0 +1,91 @@
+package types
+
+import (
+	"slices"
+	"strings"
+)
+
+// FilterGaps returns the gaps whose ConfidenceScore is at least
+// minConfidence, for callers that want less noise than a request's
+// MinConfidence gave them. The gaps are not modified.
+func FilterGaps(gaps []ResearchGap, minConfidence float64) []ResearchGap {
+	kept := []ResearchGap{}
+	for _, g := range gaps {
+		if g.ConfidenceScore >= minConfidence {
+			kept = append(kept, g)
+		}
+	}
+	return kept
+}
+
+// FilterGaps drops the gaps with a ConfidenceScore below minConfidence
+func (r *AnalyzeResponse) FilterGaps(minConfidence float64) {
+	r.Gaps = FilterGaps(r.Gaps, minConfidence)
+}
+
+// FilterGaps drops the common, intersection and individual gaps with a
+// ConfidenceScore below minConfidence
+func (r *TopicResponse) FilterGaps(minConfidence float64) {
+	r.CommonGaps = FilterGaps(r.CommonGaps, minConfidence)
+	if r.IntersectionGaps != nil {
+		r.IntersectionGaps = FilterGaps(r.IntersectionGaps, minConfidence)
+	}
+	for i := range r.IndividualResults {
+		r.IndividualResults[i].Gaps = FilterGaps(r.IndividualResults[i].Gaps, minConfidence)
+	}
+}
+
+// FilterGapsByType returns the gaps of one of the given types, compared
+// ignoring case since models don't always answer in lower case. The gaps are
+// not modified.
+func FilterGapsByType(gaps []ResearchGap, types ...GapType) []ResearchGap {
+	kept := []ResearchGap{}
+	for _, g := range gaps {
+		if slices.ContainsFunc(types, func(t GapType) bool { return strings.EqualFold(string(g.GapType), string(t)) }) {
+			kept = append(kept, g)
+		}
+	}
+	return kept
+}
+
+// FilterGapsByType drops the gaps not of one of the given types
+func (r *AnalyzeResponse) FilterGapsByType(types ...GapType) {
+	r.Gaps = FilterGapsByType(r.Gaps, types...)
+}
+
+// FilterGapsByType drops the common, intersection and individual gaps not of
+// one of the given types
+func (r *TopicResponse) FilterGapsByType(types ...GapType) {
+	r.CommonGaps = FilterGapsByType(r.CommonGaps, types...)
+	if r.IntersectionGaps != nil {
+		r.IntersectionGaps = FilterGapsByType(r.IntersectionGaps, types...)
+	}
+	for i := range r.IndividualResults {
+		r.IndividualResults[i].Gaps = FilterGapsByType(r.IndividualResults[i].Gaps, types...)
+	}
+}
+
+// Truncate keeps the first maxGaps gaps, maxHypotheses hypotheses and
+// maxFindings key findings; zero means no limit
+func (r *AnalyzeResponse) Truncate(maxGaps, maxHypotheses, maxFindings int) {
+	r.Gaps = truncate(r.Gaps, maxGaps)
+	r.SuggestedHypotheses = truncate(r.SuggestedHypotheses, maxHypotheses)
+	r.KeyFindings = truncate(r.KeyFindings, maxFindings)
+}
+
+// TruncateGaps keeps the first maxGaps common gaps, intersection gaps and
+// gaps of each individual result; zero means no limit
+func (r *TopicResponse) TruncateGaps(maxGaps int) {
+	r.CommonGaps = truncate(r.CommonGaps, maxGaps)
+	r.IntersectionGaps = truncate(r.IntersectionGaps, maxGaps)
+	for i := range r.IndividualResults {
+		r.IndividualResults[i].Gaps = truncate(r.IndividualResults[i].Gaps, maxGaps)
+	}
+}
+
+func truncate[T any](items []T, n int) []T {
+	if n > 0 && len(items) > n {
+		return items[:n]
+	}
+	return items
+}
@@ -0,0 +1,59 @@
+package types
+
+import "testing"
+
+func TestFilterGaps(t *testing.T) {
+	gaps := []ResearchGap{
+		{GapDescription: "weak", ConfidenceScore: 0.3},
+		{GapDescription: "at threshold", ConfidenceScore: 0.6},
+		{GapDescription: "strong", ConfidenceScore: 0.9},
+	}
+	resp := TopicResponse{
+		CommonGaps:        gaps,
+		IndividualResults: []TopicAnalysisResult{{Gaps: gaps[:1]}, {Gaps: gaps}},
+	}
+	resp.FilterGaps(0.6)
+
+	if len(resp.CommonGaps) != 2 || resp.CommonGaps[0].GapDescription != "at threshold" {
+		t.Errorf("CommonGaps = %+v", resp.CommonGaps)
+	}
+	if got := resp.IndividualResults[0].Gaps; got == nil || len(got) != 0 {
+		t.Errorf("IndividualResults[0].Gaps = %#v, want empty", got)
+	}
+	if len(resp.IndividualResults[1].Gaps) != 2 || gaps[0].GapDescription != "weak" {
+		t.Errorf("IndividualResults[1].Gaps = %+v, input gaps = %+v", resp.IndividualResults[1].Gaps, gaps)
+	}
+}
+
+func TestFilterGapsByType(t *testing.T) {
+	resp := AnalyzeResponse{Gaps: []ResearchGap{
+		{GapDescription: "sample", GapType: GapEmpirical},
+		{GapDescription: "design", GapType: "Methodological"},
+		{GapDescription: "model", GapType: GapTheoretical},
+		{GapDescription: "other", GapType: "system"},
+	}}
+	resp.FilterGapsByType(GapMethodological, GapEmpirical)
+
+	if len(resp.Gaps) != 2 || resp.Gaps[0].GapDescription != "sample" || resp.Gaps[1].GapDescription != "design" {
+		t.Errorf("Gaps = %+v, want the empirical and methodological gaps", resp.Gaps)
+	}
+	if got := FilterGapsByType(resp.Gaps); got == nil || len(got) != 0 {
+		t.Errorf("FilterGapsByType() with no types = %#v, want empty", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	resp := AnalyzeResponse{
+		KeyFindings:         []string{"a", "b", "c"},
+		Gaps:                []ResearchGap{{GapDescription: "first"}, {GapDescription: "second"}},
+		SuggestedHypotheses: []Hypothesis{{Hypothesis: "h"}},
+	}
+	resp.Truncate(1, 5, 0)
+
+	if len(resp.Gaps) != 1 || resp.Gaps[0].GapDescription != "first" {
+		t.Errorf("Gaps = %+v, want the first gap", resp.Gaps)
+	}
+	if len(resp.SuggestedHypotheses) != 1 || len(resp.KeyFindings) != 3 {
+		t.Errorf("response = %+v, want hypotheses and findings unchanged", resp)
+	}
+}
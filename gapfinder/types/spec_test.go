@@ -0,0 +1,101 @@
+package types
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/aichain-lab/ai-gap-finder/api"
+	"github.com/aichain-lab/ai-gap-finder/internal/openapi"
+)
+
+func loadSpec(t *testing.T) *openapi.Spec {
+	t.Helper()
+	spec, err := openapi.Load(api.OpenAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// TestTypesMatchSpec fails when a struct here drifts from api/openapi.yaml.
+// Update both together.
+func TestTypesMatchSpec(t *testing.T) {
+	spec := loadSpec(t)
+	types := map[string]any{
+		"AnalyzeRequest":         AnalyzeRequest{},
+		"AnalyzeResponse":        AnalyzeResponse{},
+		"TopicRequest":           TopicRequest{},
+		"DOIRequest":             DOIRequest{},
+		"ArxivRequest":           ArxivRequest{},
+		"PMIDRequest":            PMIDRequest{},
+		"CompareRequest":         CompareRequest{},
+		"ComparisonResponse":     ComparisonResponse{},
+		"PeerReviewResponse":     PeerReviewResponse{},
+		"Critique":               Critique{},
+		"DeduplicateRequest":     DeduplicateRequest{},
+		"DeduplicateResponse":    DeduplicateResponse{},
+		"CitationsRequest":       CitationsRequest{},
+		"CitationsResponse":      CitationsResponse{},
+		"FundingRequest":         FundingRequest{},
+		"RelatedWorkRequest":     RelatedWorkRequest{},
+		"RelatedWorkResponse":    RelatedWorkResponse{},
+		"NoveltyRequest":         NoveltyRequest{},
+		"NoveltyResponse":        NoveltyResponse{},
+		"ProposalRequest":        ProposalRequest{},
+		"ProposalResponse":       ProposalResponse{},
+		"FundingResponse":        FundingResponse{},
+		"HypothesesRequest":      HypothesesRequest{},
+		"ResearchGroupsRequest":  ResearchGroupsRequest{},
+		"ResearchGroupsResponse": ResearchGroupsResponse{},
+		"HypothesesResponse":     HypothesesResponse{},
+		"MethodsRequest":         MethodsRequest{},
+		"RefineRequest":          RefineRequest{},
+		"MethodsResponse":        MethodsResponse{},
+		"ExperimentalDesign":     ExperimentalDesign{},
+		"Dataset":                Dataset{},
+		"DatasetsRequest":        DatasetsRequest{},
+		"DatasetsResponse":       DatasetsResponse{},
+		"ReviewRequest":          ReviewRequest{},
+		"ReviewResponse":         ReviewResponse{},
+		"TrendsRequest":          TrendsRequest{},
+		"TrendsResponse":         TrendsResponse{},
+		"BatchAnalyzeRequest":    BatchAnalyzeRequest{},
+		"BatchAnalyzeResponse":   BatchAnalyzeResponse{},
+		"TopicResponse":          TopicResponse{},
+		"Job":                    Job{},
+		"TopicResultsPage":       TopicResultsPage{},
+		"TopicProgress":          TopicProgress{},
+		"ProgressMessage":        ProgressMessage{},
+		"FieldsResponse":         FieldsResponse{},
+		"ModelsResponse":         ModelsResponse{},
+		"HealthResponse":         HealthResponse{},
+		"UsageResponse":          UsageResponse{},
+		"CostEstimate":           CostEstimate{},
+		"AnalysisSummary":        AnalysisSummary{},
+		"AnalysesResponse":       AnalysesResponse{},
+		"Analysis":               Analysis{},
+	}
+	for name, v := range types {
+		t.Run(name, func(t *testing.T) {
+			for _, problem := range spec.Check(name, reflect.TypeOf(v)) {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+func TestSpecGenUpToDate(t *testing.T) {
+	want, err := loadSpec(t).GenerateConstants("types")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("spec_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("spec_gen.go is stale; run go generate ./gapfinder/types")
+	}
+}
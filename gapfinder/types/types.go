@@ -0,0 +1,772 @@
+// Package types defines the request and response structures of the AI Gap
+// Finder API. Requests can be checked with Validate before they are sent, so
+// malformed input is reported without a round trip to the service.
+package types
+
+//go:generate go run ../../internal/cmd/specgen -out spec_gen.go
+
+import "time"
+
+// Request structures matching the Python microservice
+type AnalyzeRequest struct {
+	Title    string   `json:"title"`
+	Abstract string   `json:"abstract"`
+	Field    Field    `json:"field,omitempty"` // defaults to FieldGeneral
+	Authors  []string `json:"authors,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+	FullText string   `json:"full_text,omitempty"` // required if Mode is ModeFullText
+	Mode     string   `json:"mode,omitempty"`      // defaults to ModeAbstract
+
+	// Model selects one of the models listed by ListModels, trading cost
+	// for quality; empty means the service's default model
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"` // 0 to MaxTemperature; nil means the service's default
+
+	// Language is the ISO 639-1 code of the language the abstract is
+	// written in, such as "de" or "zh"; empty means English. The analysis
+	// is written in English unless TranslateOutput is set, in which case
+	// it is written in Language.
+	Language        string `json:"language,omitempty"`
+	TranslateOutput bool   `json:"translate_output,omitempty"`
+
+	// MinConfidence leaves gaps with a lower ConfidenceScore out of the
+	// response, from 0 to 1
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+
+	// MaxGaps, MaxHypotheses and MaxFindings bound the number of Gaps,
+	// SuggestedHypotheses and KeyFindings in the response, from 1 to
+	// MaxResultsLimit; zero means no limit
+	MaxGaps       int `json:"max_gaps,omitempty"`
+	MaxHypotheses int `json:"max_hypotheses,omitempty"`
+	MaxFindings   int `json:"max_findings,omitempty"`
+
+	// Instructions steer the analysis, such as "focus on reproducibility
+	// gaps"; at most MaxInstructionsLength characters
+	Instructions string `json:"instructions,omitempty"`
+}
+
+type TopicRequest struct {
+	Topic     string `json:"topic"`
+	Field     Field  `json:"field,omitempty"`      // defaults to FieldGeneral
+	MaxPapers int    `json:"max_papers,omitempty"` // 1 to MaxPapersLimit, defaults to 10
+	PageSize  int    `json:"page_size,omitempty"`  // 1 to MaxPageSize; pages IndividualResults if set
+
+	// Model, Temperature, Language, TranslateOutput, MinConfidence and
+	// MaxGaps are as in AnalyzeRequest; Language is that of the topic, and
+	// MinConfidence and MaxGaps apply to the common gaps and each paper's
+	Model           string   `json:"model,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	Language        string   `json:"language,omitempty"`
+	TranslateOutput bool     `json:"translate_output,omitempty"`
+	MinConfidence   float64  `json:"min_confidence,omitempty"`
+	MaxGaps         int      `json:"max_gaps,omitempty"`
+
+	// CrossFields analyzes the topic across 2 to MaxCrossFields distinct
+	// fields instead of Field. Papers of each field are searched for, and
+	// the gaps at the fields' intersection, which an analysis of a single
+	// field misses, are reported in the response's IntersectionGaps.
+	CrossFields []Field `json:"cross_fields,omitempty"`
+
+	// Surveys is how survey and review papers are treated, since their
+	// observations about the literature crowd out concrete gaps: one of
+	// SurveysInclude, the default, SurveysExclude or SurveysDownweight
+	Surveys string `json:"surveys,omitempty"`
+}
+
+// PDFMetadata describes a paper PDF uploaded for analysis. It is sent as form
+// fields alongside the file.
+type PDFMetadata struct {
+	Filename string // name the file is uploaded under, defaults to "paper.pdf"
+	Title    string // defaults to the PDF's title or file name
+	Field    Field  // defaults to FieldGeneral
+	Authors  []string
+	Mode     string // defaults to ModeAbstract; ModeFullText analyzes the whole paper
+}
+
+// DOIRequest analyzes the paper with a DOI, whose title, abstract and
+// authors the service looks up on CrossRef
+type DOIRequest struct {
+	DOI   string `json:"doi"`             // such as "10.1038/nature12373"; doi.org URLs are accepted
+	Field Field  `json:"field,omitempty"` // defaults to FieldGeneral
+}
+
+// PMIDRequest analyzes the paper with a PubMed ID, whose title, abstract and
+// authors the service looks up on PubMed
+type PMIDRequest struct {
+	PMID  string `json:"pmid"`            // such as "31452104"; PubMed URLs are accepted
+	Field Field  `json:"field,omitempty"` // defaults to FieldGeneral
+}
+
+// ArxivRequest analyzes the paper with an arXiv ID, whose title, abstract
+// and authors the service looks up on arXiv
+type ArxivRequest struct {
+	ID    string `json:"arxiv_id"`        // such as "2101.00001" or "hep-th/9901001"; arxiv.org URLs are accepted
+	Field Field  `json:"field,omitempty"` // defaults to FieldGeneral
+	// FullText makes the service download the paper's PDF and analyze its
+	// methods, results and discussion too, as in ModeFullText
+	FullText bool `json:"full_text,omitempty"`
+}
+
+// CompareRequest compares two papers
+type CompareRequest struct {
+	PaperA AnalyzeRequest `json:"paper_a"`
+	PaperB AnalyzeRequest `json:"paper_b"`
+}
+
+// DeduplicateRequest clusters the gaps of up to MaxDeduplicateAnalyses
+// analyses, such as the IndividualResults of a TopicResponse
+type DeduplicateRequest struct {
+	Analyses [][]ResearchGap `json:"analyses"` // the gaps of each analysis
+}
+
+// HypothesesRequest generates hypotheses for up to MaxHypothesisGaps gaps
+type HypothesesRequest struct {
+	Gaps  []ResearchGap `json:"gaps"`
+	Field Field         `json:"field,omitempty"` // defaults to FieldGeneral
+}
+
+// RefineRequest asks for a previous analysis to be updated following the
+// researcher's feedback, such as "gap 2 is out of scope" or "expand on the
+// sampling gap"
+type RefineRequest struct {
+	Previous AnalyzeResponse `json:"previous"`
+	Feedback string          `json:"feedback"`
+	Field    Field           `json:"field,omitempty"` // defaults to FieldGeneral
+}
+
+// MethodsRequest asks for concrete experimental designs to test a hypothesis
+// with, such as one of a HypothesesResponse
+type MethodsRequest struct {
+	Hypothesis Hypothesis `json:"hypothesis"`
+	Field      Field      `json:"field,omitempty"` // defaults to FieldGeneral
+}
+
+// ResearchGroupsRequest finds the research groups among the authors of a
+// topic's papers working near each of up to MaxGroupGaps common gaps
+type ResearchGroupsRequest struct {
+	Topic      string                `json:"topic"`
+	Papers     []TopicAnalysisResult `json:"papers"`
+	CommonGaps []ResearchGap         `json:"common_gaps"`
+	Field      Field                 `json:"field,omitempty"` // defaults to FieldGeneral
+}
+
+// BatchAnalyzeRequest analyzes up to MaxBatchSize abstracts in one call
+type BatchAnalyzeRequest struct {
+	Requests []AnalyzeRequest `json:"requests"`
+}
+
+// CitationsRequest asks for papers to cite when writing about a gap
+type CitationsRequest struct {
+	Gap          ResearchGap `json:"gap"`
+	Field        Field       `json:"field,omitempty"`         // defaults to FieldGeneral
+	MaxCitations int         `json:"max_citations,omitempty"` // 1 to MaxCitationsLimit, defaults to 5
+}
+
+// DatasetsRequest asks for public datasets to investigate a gap or test a
+// hypothesis with. Exactly one of Gap and Hypothesis is set.
+type DatasetsRequest struct {
+	Gap         *ResearchGap `json:"gap,omitempty"`
+	Hypothesis  *Hypothesis  `json:"hypothesis,omitempty"`
+	Field       Field        `json:"field,omitempty"`        // defaults to FieldGeneral
+	MaxDatasets int          `json:"max_datasets,omitempty"` // 1 to MaxDatasetsLimit, defaults to 5
+}
+
+// RelatedWorkRequest asks for the existing papers closest to a gap
+type RelatedWorkRequest struct {
+	Gap       ResearchGap `json:"gap"`
+	Field     Field       `json:"field,omitempty"`      // defaults to FieldGeneral
+	MaxPapers int         `json:"max_papers,omitempty"` // 1 to MaxRelatedPapersLimit, defaults to 5
+}
+
+// FundingRequest asks for the open funding calls that fit a list of gaps
+type FundingRequest struct {
+	Gaps       []ResearchGap `json:"gaps"`                  // 1 to MaxFundingGaps, each with a description
+	Field      Field         `json:"field,omitempty"`       // defaults to FieldGeneral
+	MaxMatches int           `json:"max_matches,omitempty"` // 1 to MaxMatchesLimit, defaults to 10
+}
+
+// Response structures
+type ResearchGap struct {
+	GapDescription  string  `json:"gap_description"`
+	ConfidenceScore float64 `json:"confidence_score"`
+	GapType         GapType `json:"gap_type"` // usually one of GapTypes
+	PotentialImpact string  `json:"potential_impact"`
+	// SupportingCitations are papers to cite about the gap, such as those
+	// suggested by the service's /gaps/citations endpoint
+	SupportingCitations []Citation `json:"supporting_citations,omitempty"`
+	// ResearchGroups are the groups of co-authors working near the gap,
+	// such as those found by the service's /gaps/groups endpoint
+	ResearchGroups []AuthorGroup `json:"research_groups,omitempty"`
+}
+
+// AuthorGroup is a group of authors linked by co-authorship, and their
+// papers near a gap
+type AuthorGroup struct {
+	Authors []string `json:"authors"`
+	Papers  []string `json:"papers"` // titles
+}
+
+// Citation is a paper worth citing when writing about a gap
+type Citation struct {
+	Title   string   `json:"title"`
+	Authors []string `json:"authors"`
+	URL     string   `json:"url,omitempty"`
+	Reason  string   `json:"reason"` // how the paper relates to the gap
+}
+
+// RelatedPaper is an existing paper close to a gap
+type RelatedPaper struct {
+	Title        string   `json:"title"`
+	Authors      []string `json:"authors"`
+	URL          string   `json:"url,omitempty"`
+	Year         int      `json:"year,omitempty"` // zero if the source doesn't say
+	Similarity   float64  `json:"similarity"`     // from 0 to 1
+	AddressesGap bool     `json:"addresses_gap"`  // the paper already closes the gap
+	Reason       string   `json:"reason"`         // how the paper relates to the gap
+}
+
+// FundingCall is an open call for grant proposals
+type FundingCall struct {
+	Agency  string `json:"agency"` // of the feed the call came from, such as "NSF"
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Summary string `json:"summary,omitempty"`
+}
+
+// FundingMatch is a funding call that fits some of a FundingRequest's gaps
+type FundingMatch struct {
+	Call           FundingCall `json:"call"`
+	Gaps           []int       `json:"gaps"` // indexes of the request's gaps the call would fund
+	RelevanceScore float64     `json:"relevance_score"`
+	Reason         string      `json:"reason"`
+}
+
+type Hypothesis struct {
+	Hypothesis       string   `json:"hypothesis"`
+	Rationale        string   `json:"rationale"`
+	FeasibilityScore float64  `json:"feasibility_score"`
+	RequiredMethods  []string `json:"required_methods"`
+}
+
+type AnalyzeResponse struct {
+	KeyFindings         []string      `json:"key_findings"`
+	Gaps                []ResearchGap `json:"gaps"`
+	SuggestedHypotheses []Hypothesis  `json:"suggested_hypotheses"`
+	Limitations         []string      `json:"limitations"`
+	MethodologyGaps     []string      `json:"methodology_gaps"`
+	FutureDirections    []string      `json:"future_directions"`
+	ProcessingTime      float64       `json:"processing_time"`
+	Paper               *PaperInfo    `json:"paper,omitempty"` // set for analyses by identifier, such as AnalyzeDOI
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// PaperInfo is the metadata of a paper the service looked up by identifier
+type PaperInfo struct {
+	Source   string   `json:"source"` // such as "crossref"
+	ID       string   `json:"id"`     // the identifier it was looked up by
+	Title    string   `json:"title"`
+	Authors  []string `json:"authors"`
+	Abstract string   `json:"abstract"`
+	URL      string   `json:"url,omitempty"`
+	Year     int      `json:"year,omitempty"` // zero if the source doesn't say
+}
+
+// ComparisonResponse reports how two papers relate
+type ComparisonResponse struct {
+	OverlappingFindings    []string             `json:"overlapping_findings"`
+	ConflictingConclusions []ConclusionConflict `json:"conflicting_conclusions"`
+	GapsUniqueToA          []ResearchGap        `json:"gaps_unique_to_a"`
+	GapsUniqueToB          []ResearchGap        `json:"gaps_unique_to_b"`
+	ProcessingTime         float64              `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// ConclusionConflict is a point two papers reach different conclusions on
+type ConclusionConflict struct {
+	Topic          string `json:"topic"`
+	PaperAPosition string `json:"paper_a_position"`
+	PaperBPosition string `json:"paper_b_position"`
+}
+
+// PeerReviewResponse is a simulated peer review of a paper, built on its
+// gap analysis
+type PeerReviewResponse struct {
+	Recommendation   string        `json:"recommendation"` // RecommendAccept, RecommendMinorRevision, RecommendMajorRevision or RecommendReject
+	Summary          string        `json:"summary"`
+	Critiques        []Critique    `json:"critiques"`         // major ones first
+	MissingBaselines []string      `json:"missing_baselines"` // methods or results the paper should compare against
+	Gaps             []ResearchGap `json:"gaps"`              // found by the analysis
+	ProcessingTime   float64       `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// Critique is a reviewer's comment on one aspect of a paper
+type Critique struct {
+	Aspect   string `json:"aspect"`   // AspectSoundness, AspectNovelty, AspectBaselines, AspectClarity or AspectSignificance
+	Severity string `json:"severity"` // SeverityMinor or SeverityMajor
+	Comment  string `json:"comment"`
+}
+
+// DeduplicateResponse holds the clusters of a DeduplicateRequest's gaps.
+// Every gap of the request is a member of exactly one cluster.
+type DeduplicateResponse struct {
+	Clusters       []GapCluster `json:"clusters"`
+	ProcessingTime float64      `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// CitationsResponse holds the citations suggested for a CitationsRequest
+type CitationsResponse struct {
+	Citations      []Citation `json:"citations"` // most relevant first
+	ProcessingTime float64    `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// RelatedWorkResponse holds the papers closest to a RelatedWorkRequest's gap
+type RelatedWorkResponse struct {
+	Papers         []RelatedPaper `json:"papers"` // most similar first
+	ProcessingTime float64        `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// FundingResponse holds the funding calls matched with a FundingRequest's
+// gaps
+type FundingResponse struct {
+	Matches        []FundingMatch `json:"matches"`        // most relevant first
+	CallsSearched  int            `json:"calls_searched"` // open calls read from the feeds
+	ProcessingTime float64        `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// ResearchGroupsResponse holds the requested gaps, in order, with their
+// ResearchGroups set, largest first
+type ResearchGroupsResponse struct {
+	CommonGaps     []ResearchGap `json:"common_gaps"`
+	ProcessingTime float64       `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// HypothesesResponse holds the hypotheses generated for a HypothesesRequest
+type HypothesesResponse struct {
+	Hypotheses     []Hypothesis `json:"hypotheses"`
+	ProcessingTime float64      `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// MethodsResponse holds the ways to test the hypothesis of a MethodsRequest
+type MethodsResponse struct {
+	Designs        []ExperimentalDesign `json:"designs"` // the most suitable first
+	Datasets       []Dataset            `json:"datasets"`
+	ProcessingTime float64              `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// ExperimentalDesign is a concrete way to test a hypothesis
+type ExperimentalDesign struct {
+	Name        string   `json:"name"` // such as "randomized controlled trial"
+	Description string   `json:"description"`
+	Methods     []string `json:"methods"`
+	SampleSize  string   `json:"sample_size"` // guidance such as the sample needed for adequate power
+	Limitations []string `json:"limitations"`
+}
+
+// Dataset is a dataset to start work on a gap or hypothesis from
+type Dataset struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	URL         string `json:"url,omitempty"`
+	License     string `json:"license,omitempty"` // such as "CC BY 4.0"; empty if unknown
+}
+
+// DatasetsResponse holds the datasets suggested for a DatasetsRequest
+type DatasetsResponse struct {
+	Datasets       []Dataset `json:"datasets"` // the most suitable first; each has a URL
+	ProcessingTime float64   `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// GapCluster is a group of gaps describing the same problem
+type GapCluster struct {
+	Gap     ResearchGap `json:"gap"` // canonical gap, as confident as the most confident member
+	Members []GapRef    `json:"members"`
+}
+
+// GapRef refers to a gap of a DeduplicateRequest
+type GapRef struct {
+	Analysis int `json:"analysis"` // index into DeduplicateRequest.Analyses
+	Gap      int `json:"gap"`      // index into the analysis's gaps
+}
+
+// BatchItemResult is the outcome of one abstract in a batch. Exactly one of
+// Result and Error is set.
+type BatchItemResult struct {
+	Index  int              `json:"index"` // position in BatchAnalyzeRequest.Requests
+	Result *AnalyzeResponse `json:"result"`
+	Error  string           `json:"error"`
+}
+
+type BatchAnalyzeResponse struct {
+	Results        []BatchItemResult `json:"results"` // in request order
+	ProcessingTime float64           `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+type TopicAnalysisResult struct {
+	PaperTitle string        `json:"paper_title"`
+	Authors    []string      `json:"authors"`
+	Abstract   string        `json:"abstract"`
+	Gaps       []ResearchGap `json:"gaps"`
+	URL        string        `json:"url"`
+	Year       int           `json:"year,omitempty"` // zero if the source doesn't say
+}
+
+type TopicResponse struct {
+	Topic                       string                `json:"topic"`
+	PapersAnalyzed              int                   `json:"papers_analyzed"`
+	CommonGaps                  []ResearchGap         `json:"common_gaps"`
+	IndividualResults           []TopicAnalysisResult `json:"individual_results"`
+	SuggestedResearchDirections []string              `json:"suggested_research_directions"`
+	ProcessingTime              float64               `json:"processing_time"`
+	NextCursor                  string                `json:"next_cursor,omitempty"`       // set if more IndividualResults are available
+	IntersectionGaps            []ResearchGap         `json:"intersection_gaps,omitempty"` // set if the request set CrossFields
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// ReviewRequest drafts a literature review of up to MaxPapersLimit papers
+// on a topic, such as those of a TopicResponse
+type ReviewRequest struct {
+	Topic      string                `json:"topic"`
+	Papers     []TopicAnalysisResult `json:"papers"` // cited by position, starting at 1
+	CommonGaps []ResearchGap         `json:"common_gaps,omitempty"`
+	Field      Field                 `json:"field,omitempty"` // defaults to FieldGeneral
+}
+
+// ReviewResponse is a literature review draft, as sections to render in
+// order
+type ReviewResponse struct {
+	Title          string            `json:"title"`
+	Sections       []ReviewSection   `json:"sections"`
+	References     []ReviewReference `json:"references"` // the cited papers, by Number
+	ProcessingTime float64           `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// ReviewSection is a section of a literature review draft. Its paragraphs
+// cite papers as [n], where n is the Number of a ReviewReference.
+type ReviewSection struct {
+	Kind       string   `json:"kind"` // one of the Section constants
+	Heading    string   `json:"heading"`
+	Paragraphs []string `json:"paragraphs"`
+	Citations  []int    `json:"citations"`
+}
+
+// ReviewReference is a paper cited by a literature review draft
+type ReviewReference struct {
+	Number  int      `json:"number"` // position in ReviewRequest.Papers, starting at 1
+	Title   string   `json:"title"`
+	Authors []string `json:"authors"`
+	URL     string   `json:"url,omitempty"`
+}
+
+// NoveltyRequest asks whether the literature already addresses a proposed
+// research idea
+type NoveltyRequest struct {
+	Idea      string `json:"idea"`                 // at most MaxIdeaLength characters
+	Field     Field  `json:"field,omitempty"`      // defaults to FieldGeneral
+	MaxPapers int    `json:"max_papers,omitempty"` // 1 to MaxPapersLimit, defaults to 10
+}
+
+// NoveltyResponse tells whether an idea is already addressed, partially
+// addressed or open
+type NoveltyResponse struct {
+	Status           string     `json:"status"`        // one of the Novelty constants
+	NoveltyScore     float64    `json:"novelty_score"` // from 0, addressed, to 1, entirely new
+	Summary          string     `json:"summary"`
+	SupportingPapers []Citation `json:"supporting_papers"` // most relevant first
+	PapersSearched   int        `json:"papers_searched"`
+	ProcessingTime   float64    `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// ProposalRequest asks how well a draft grant proposal addresses the known
+// gaps of its field
+type ProposalRequest struct {
+	Proposal string `json:"proposal"`        // at most MaxProposalLength characters
+	Field    Field  `json:"field,omitempty"` // defaults to FieldGeneral
+	// Topic is searched for existing work; it defaults to the proposal's
+	// first line
+	Topic string `json:"topic,omitempty"`
+	// Gaps are the known gaps to score the proposal against, up to
+	// MaxProposalGaps. They default to the gaps raised by the related papers.
+	Gaps      []ResearchGap `json:"gaps,omitempty"`
+	MaxPapers int           `json:"max_papers,omitempty"` // 1 to MaxPapersLimit, defaults to 10
+}
+
+// ProposalResponse scores how well a proposal addresses the known gaps and
+// flags its claims of novelty that conflict with existing work
+type ProposalResponse struct {
+	AlignmentScore   float64           `json:"alignment_score"` // from 0 to 1
+	Gaps             []GapAlignment    `json:"gaps"`
+	NoveltyConflicts []NoveltyConflict `json:"novelty_conflicts"`
+	Summary          string            `json:"summary"`
+	PapersSearched   int               `json:"papers_searched"`
+	ProcessingTime   float64           `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// GapAlignment tells how fully a proposal addresses a known gap
+type GapAlignment struct {
+	GapDescription string  `json:"gap_description"`
+	Coverage       float64 `json:"coverage"` // from 0 to 1
+	Comment        string  `json:"comment"`
+}
+
+// NoveltyConflict is a claim of novelty of a proposal that existing papers
+// contradict
+type NoveltyConflict struct {
+	Claim  string     `json:"claim"`
+	Papers []Citation `json:"papers"` // Reason tells how each conflicts
+}
+
+// TrendsRequest asks how the gaps raised by papers on a topic have evolved
+// over the last YearsBack publication years
+type TrendsRequest struct {
+	Topic     string `json:"topic"`
+	YearsBack int    `json:"years_back,omitempty"` // 1 to MaxYearsBack, defaults to 5
+	MaxPapers int    `json:"max_papers,omitempty"` // 1 to MaxPapersLimit, defaults to 30
+	Field     Field  `json:"field,omitempty"`      // defaults to FieldGeneral
+}
+
+// TrendsResponse tracks a topic's gaps year by year, from FromYear to
+// ToYear
+type TrendsResponse struct {
+	Topic          string      `json:"topic"`
+	FromYear       int         `json:"from_year"`
+	ToYear         int         `json:"to_year"`
+	PapersAnalyzed int         `json:"papers_analyzed"`
+	Years          []TrendYear `json:"years"` // every covered year, in order
+	Gaps           []GapTrend  `json:"gaps"`
+	ProcessingTime float64     `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// TrendYear counts the gaps of each type raised by a year's papers
+type TrendYear struct {
+	Year       int            `json:"year"`
+	PaperCount int            `json:"paper_count"`
+	GapTypes   []GapTypeCount `json:"gap_types"` // most frequent first
+}
+
+// GapTypeCount is the number of gaps of a type
+type GapTypeCount struct {
+	GapType GapType `json:"gap_type"`
+	Count   int     `json:"count"`
+}
+
+// GapTrend is a gap and the years of the papers raising it
+type GapTrend struct {
+	GapDescription string  `json:"gap_description"`
+	GapType        GapType `json:"gap_type"`
+	Years          []int   `json:"years"`  // in ascending order
+	Status         string  `json:"status"` // one of the Trend constants
+}
+
+// TopicResultsPage is a page of a topic's IndividualResults, fetched with the
+// NextCursor of a TopicResponse or of the previous page
+type TopicResultsPage struct {
+	IndividualResults []TopicAnalysisResult `json:"individual_results"`
+	NextCursor        string                `json:"next_cursor,omitempty"` // empty on the last page
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// Job is a topic analysis running in the background
+type Job struct {
+	JobID     string         `json:"job_id"`
+	Status    string         `json:"status"` // one of JobPending, JobRunning, JobSucceeded, JobFailed, JobCancelled
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Result    *TopicResponse `json:"result"` // set once the job succeeded
+	Error     string         `json:"error"`  // set if the job failed
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// Done reports whether the job has finished, successfully or not, or was
+// cancelled
+func (j *Job) Done() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled
+}
+
+// TopicProgress reports how far the analysis of one paper of a topic got
+type TopicProgress struct {
+	PaperTitle string               `json:"paper_title"`
+	Index      int                  `json:"index"`            // position among the papers found
+	Total      int                  `json:"total"`            // number of papers found
+	Stage      string               `json:"stage"`            // one of ProgressFetched, ProgressAnalyzing, ProgressDone, ProgressFailed
+	Result     *TopicAnalysisResult `json:"result,omitempty"` // set once the paper is done
+}
+
+// ProgressMessage is sent by the service on the /topic/ws WebSocket
+type ProgressMessage struct {
+	Type     string         `json:"type"` // one of MessageProgress, MessageResult, MessageError
+	Progress *TopicProgress `json:"progress,omitempty"`
+	Result   *TopicResponse `json:"result,omitempty"`
+	Detail   string         `json:"detail,omitempty"`
+}
+
+// FieldsResponse lists the research fields accepted by the service
+type FieldsResponse struct {
+	Fields []Field `json:"fields"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// ModelsResponse lists the models requests may select
+type ModelsResponse struct {
+	Models       []string `json:"models"`
+	DefaultModel string   `json:"default_model"` // used when a request doesn't set Model
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// CostEstimate is the expected cost of an analysis, of an abstract or a
+// topic, for deciding whether to run it. Token counts and duration are
+// approximate.
+type CostEstimate struct {
+	Model           string   `json:"model,omitempty"` // empty if the service doesn't name its default model
+	Papers          int      `json:"papers"`
+	LLMCalls        int      `json:"llm_calls"`
+	InputTokens     int      `json:"input_tokens"`
+	OutputTokens    int      `json:"output_tokens"`
+	CostUSD         *float64 `json:"cost_usd,omitempty"` // nil if the model's price isn't known
+	DurationSeconds float64  `json:"duration_seconds"`
+	ProcessingTime  float64  `json:"processing_time"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// UsageResponse is a caller's use of the service in the current quota
+// window, for pacing batch work instead of running into 429 responses
+type UsageResponse struct {
+	Requests  int             `json:"requests"`
+	Since     time.Time       `json:"since"`           // start of the current window
+	Endpoints []EndpointUsage `json:"endpoints"`       // most used first
+	Quota     *Quota          `json:"quota,omitempty"` // nil if the service has no quota
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// EndpointUsage is the number of requests a caller made to one endpoint
+type EndpointUsage struct {
+	Endpoint string `json:"endpoint"` // method and path pattern, such as "POST /analyze"
+	Requests int    `json:"requests"`
+}
+
+// Quota is the number of requests a caller may still make in the current
+// window
+type Quota struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// AnalysisSummary describes an analysis the service has kept, without its
+// result
+type AnalysisSummary struct {
+	AnalysisID string    `json:"analysis_id"`
+	Kind       string    `json:"kind"`  // AnalysisAbstract or AnalysisTopic
+	Title      string    `json:"title"` // of the paper, or the topic
+	Field      Field     `json:"field"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AnalysesResponse lists the analyses kept that match a query, newest first
+type AnalysesResponse struct {
+	Analyses []AnalysisSummary `json:"analyses"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// Analysis is an analysis the service has kept, with its result
+type Analysis struct {
+	AnalysisID  string           `json:"analysis_id"`
+	Kind        string           `json:"kind"`  // AnalysisAbstract or AnalysisTopic
+	Title       string           `json:"title"` // of the paper, or the topic
+	Field       Field            `json:"field"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Result      *AnalyzeResponse `json:"result,omitempty"`       // set for AnalysisAbstract
+	TopicResult *TopicResponse   `json:"topic_result,omitempty"` // set for AnalysisTopic, with every individual result
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
+
+// Summary returns the summary of a listed analysis
+func (a *Analysis) Summary() AnalysisSummary {
+	return AnalysisSummary{AnalysisID: a.AnalysisID, Kind: a.Kind, Title: a.Title, Field: a.Field, CreatedAt: a.CreatedAt}
+}
+
+// AnalysesQuery selects the analyses ListAnalyses returns. The zero value
+// selects the most recent ones.
+type AnalysesQuery struct {
+	Topic string    // matches titles and topics containing it, ignoring case
+	Field Field     // matches analyses in the field
+	Since time.Time // matches analyses made at or after it
+	Until time.Time // matches analyses made before it
+	Limit int       // most analyses returned, up to MaxAnalysesLimit; zero means 50
+}
+
+// MaxAnalysesLimit is the largest Limit of an AnalysesQuery the service
+// accepts
+const MaxAnalysesLimit = 100
+
+type HealthResponse struct {
+	Status    string `json:"status"`
+	Version   string `json:"version"`
+	Timestamp string `json:"timestamp"`
+
+	// RequestID identifies the call in the service's logs
+	RequestID string `json:"-"`
+}
@@ -0,0 +1,645 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError reports a request field the service would reject
+type ValidationError struct {
+	Field   string // JSON name of the offending field
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r AnalyzeRequest) Validate() error {
+	if strings.TrimSpace(r.Title) == "" {
+		return &ValidationError{Field: "title", Message: "must not be empty"}
+	}
+	if strings.TrimSpace(r.Abstract) == "" {
+		return &ValidationError{Field: "abstract", Message: "must not be empty"}
+	}
+	if err := validateMode(r.Mode); err != nil {
+		return err
+	}
+	if r.Mode == ModeFullText && strings.TrimSpace(r.FullText) == "" {
+		return &ValidationError{Field: "full_text", Message: "must not be empty in full_text mode"}
+	}
+	if err := validateTemperature(r.Temperature); err != nil {
+		return err
+	}
+	if err := validateLanguage(r.Language, r.TranslateOutput); err != nil {
+		return err
+	}
+	if err := validateMinConfidence(r.MinConfidence); err != nil {
+		return err
+	}
+	if err := validateResultLimit("max_gaps", r.MaxGaps); err != nil {
+		return err
+	}
+	if err := validateResultLimit("max_hypotheses", r.MaxHypotheses); err != nil {
+		return err
+	}
+	if err := validateResultLimit("max_findings", r.MaxFindings); err != nil {
+		return err
+	}
+	if n := utf8.RuneCountInString(r.Instructions); n > MaxInstructionsLength {
+		return &ValidationError{
+			Field:   "instructions",
+			Message: fmt.Sprintf("must be at most %d characters, got %d", MaxInstructionsLength, n),
+		}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r TopicRequest) Validate() error {
+	if strings.TrimSpace(r.Topic) == "" {
+		return &ValidationError{Field: "topic", Message: "must not be empty"}
+	}
+	if r.MaxPapers < 0 || r.MaxPapers > MaxPapersLimit {
+		return &ValidationError{
+			Field:   "max_papers",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxPapersLimit, r.MaxPapers),
+		}
+	}
+	if r.PageSize < 0 || r.PageSize > MaxPageSize {
+		return &ValidationError{
+			Field:   "page_size",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxPageSize, r.PageSize),
+		}
+	}
+	if err := validateTemperature(r.Temperature); err != nil {
+		return err
+	}
+	if err := validateLanguage(r.Language, r.TranslateOutput); err != nil {
+		return err
+	}
+	if err := validateMinConfidence(r.MinConfidence); err != nil {
+		return err
+	}
+	if err := validateResultLimit("max_gaps", r.MaxGaps); err != nil {
+		return err
+	}
+	if err := validateCrossFields(r.CrossFields); err != nil {
+		return err
+	}
+	if err := validateSurveys(r.Surveys); err != nil {
+		return err
+	}
+	return validateField(r.Field)
+}
+
+// validateCrossFields accepts no fields, or 2 to MaxCrossFields distinct
+// known ones
+func validateCrossFields(fields []Field) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if len(fields) < 2 || len(fields) > MaxCrossFields {
+		return &ValidationError{
+			Field:   "cross_fields",
+			Message: fmt.Sprintf("must hold between 2 and %d fields, got %d", MaxCrossFields, len(fields)),
+		}
+	}
+	for i, f := range fields {
+		if !f.Valid() {
+			return &ValidationError{Field: fmt.Sprintf("cross_fields.%d", i), Message: fmt.Sprintf("unknown research field %q", f)}
+		}
+		if slices.Contains(fields[:i], f) {
+			return &ValidationError{Field: fmt.Sprintf("cross_fields.%d", i), Message: fmt.Sprintf("repeats %q", f)}
+		}
+	}
+	return nil
+}
+
+// validateSurveys accepts a known survey handling or the empty string, for
+// which the service falls back to SurveysInclude
+func validateSurveys(surveys string) error {
+	if surveys == "" || surveys == SurveysInclude || surveys == SurveysExclude || surveys == SurveysDownweight {
+		return nil
+	}
+	return &ValidationError{
+		Field:   "surveys",
+		Message: fmt.Sprintf("unknown survey handling %q, want %s, %s or %s", surveys, SurveysInclude, SurveysExclude, SurveysDownweight),
+	}
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r ReviewRequest) Validate() error {
+	if strings.TrimSpace(r.Topic) == "" {
+		return &ValidationError{Field: "topic", Message: "must not be empty"}
+	}
+	if len(r.Papers) == 0 || len(r.Papers) > MaxPapersLimit {
+		return &ValidationError{
+			Field:   "papers",
+			Message: fmt.Sprintf("must hold between 1 and %d papers, got %d", MaxPapersLimit, len(r.Papers)),
+		}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r NoveltyRequest) Validate() error {
+	if strings.TrimSpace(r.Idea) == "" {
+		return &ValidationError{Field: "idea", Message: "must not be empty"}
+	}
+	if n := utf8.RuneCountInString(r.Idea); n > MaxIdeaLength {
+		return &ValidationError{
+			Field:   "idea",
+			Message: fmt.Sprintf("must be at most %d characters, got %d", MaxIdeaLength, n),
+		}
+	}
+	if r.MaxPapers < 0 || r.MaxPapers > MaxPapersLimit {
+		return &ValidationError{
+			Field:   "max_papers",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxPapersLimit, r.MaxPapers),
+		}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r RefineRequest) Validate() error {
+	if strings.TrimSpace(r.Feedback) == "" {
+		return &ValidationError{Field: "feedback", Message: "must not be empty"}
+	}
+	if n := utf8.RuneCountInString(r.Feedback); n > MaxFeedbackLength {
+		return &ValidationError{
+			Field:   "feedback",
+			Message: fmt.Sprintf("must be at most %d characters, got %d", MaxFeedbackLength, n),
+		}
+	}
+	p := r.Previous
+	if len(p.KeyFindings)+len(p.Gaps)+len(p.SuggestedHypotheses)+len(p.Limitations)+len(p.MethodologyGaps)+len(p.FutureDirections) == 0 {
+		return &ValidationError{Field: "previous", Message: "must hold the analysis to refine"}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r.
+// Problems with a gap are reported for fields such as
+// "gaps.3.gap_description".
+func (r ProposalRequest) Validate() error {
+	if strings.TrimSpace(r.Proposal) == "" {
+		return &ValidationError{Field: "proposal", Message: "must not be empty"}
+	}
+	if n := utf8.RuneCountInString(r.Proposal); n > MaxProposalLength {
+		return &ValidationError{
+			Field:   "proposal",
+			Message: fmt.Sprintf("must be at most %d characters, got %d", MaxProposalLength, n),
+		}
+	}
+	if len(r.Gaps) > MaxProposalGaps {
+		return &ValidationError{
+			Field:   "gaps",
+			Message: fmt.Sprintf("must hold at most %d gaps, got %d", MaxProposalGaps, len(r.Gaps)),
+		}
+	}
+	if err := validateGaps("gaps", r.Gaps); err != nil {
+		return err
+	}
+	if r.MaxPapers < 0 || r.MaxPapers > MaxPapersLimit {
+		return &ValidationError{
+			Field:   "max_papers",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxPapersLimit, r.MaxPapers),
+		}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r TrendsRequest) Validate() error {
+	if strings.TrimSpace(r.Topic) == "" {
+		return &ValidationError{Field: "topic", Message: "must not be empty"}
+	}
+	if r.YearsBack < 0 || r.YearsBack > MaxYearsBack {
+		return &ValidationError{
+			Field:   "years_back",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxYearsBack, r.YearsBack),
+		}
+	}
+	if r.MaxPapers < 0 || r.MaxPapers > MaxPapersLimit {
+		return &ValidationError{
+			Field:   "max_papers",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxPapersLimit, r.MaxPapers),
+		}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject m
+func (m PDFMetadata) Validate() error {
+	if err := validateMode(m.Mode); err != nil {
+		return err
+	}
+	return validateField(m.Field)
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r DOIRequest) Validate() error {
+	if !doiPattern.MatchString(NormalizeDOI(r.DOI)) {
+		return &ValidationError{Field: "doi", Message: fmt.Sprintf("must look like 10.1234/abc, got %q", r.DOI)}
+	}
+	return validateField(r.Field)
+}
+
+var (
+	doiPrefix  = regexp.MustCompile(`(?i)^(?:https?://(?:dx\.)?doi\.org/|doi:)`)
+	doiPattern = regexp.MustCompile(`^10\.\d{4,9}/\S+$`)
+)
+
+// NormalizeDOI strips the doi.org URL or "doi:" prefix a DOI is often
+// written with, as the service does
+func NormalizeDOI(doi string) string {
+	return doiPrefix.ReplaceAllString(strings.TrimSpace(doi), "")
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r PMIDRequest) Validate() error {
+	if !pmidPattern.MatchString(NormalizePMID(r.PMID)) {
+		return &ValidationError{Field: "pmid", Message: fmt.Sprintf("must be a number such as 31452104, got %q", r.PMID)}
+	}
+	return validateField(r.Field)
+}
+
+var (
+	pmidPrefix  = regexp.MustCompile(`(?i)^(?:https?://pubmed\.ncbi\.nlm\.nih\.gov/|pmid:\s*)`)
+	pmidPattern = regexp.MustCompile(`^\d{1,9}$`)
+)
+
+// NormalizePMID strips the PubMed URL or "PMID:" prefix a PubMed ID is often
+// written with, as the service does
+func NormalizePMID(pmid string) string {
+	return strings.TrimSuffix(pmidPrefix.ReplaceAllString(strings.TrimSpace(pmid), ""), "/")
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r ArxivRequest) Validate() error {
+	if !arxivIDPattern.MatchString(NormalizeArxivID(r.ID)) {
+		return &ValidationError{
+			Field:   "arxiv_id",
+			Message: fmt.Sprintf("must look like 2101.00001 or hep-th/9901001, got %q", r.ID),
+		}
+	}
+	return validateField(r.Field)
+}
+
+var (
+	arxivIDPrefix  = regexp.MustCompile(`(?i)^(?:https?://(?:www\.)?arxiv\.org/(?:abs|pdf)/|arxiv:)`)
+	arxivIDPattern = regexp.MustCompile(`^(?:\d{4}\.\d{4,5}|[a-z][a-z.-]*/\d{7})(?:v\d+)?$`)
+)
+
+// NormalizeArxivID strips the arxiv.org URL or "arXiv:" prefix an arXiv ID
+// is often written with, as the service does
+func NormalizeArxivID(id string) string {
+	id = arxivIDPrefix.ReplaceAllString(strings.TrimSpace(id), "")
+	return strings.TrimSuffix(id, ".pdf")
+}
+
+// Validate reports the first problem that would make the service reject r.
+// Problems with a paper are reported for fields such as "paper_b.title".
+func (r CompareRequest) Validate() error {
+	for _, p := range []struct {
+		name string
+		req  AnalyzeRequest
+	}{{"paper_a", r.PaperA}, {"paper_b", r.PaperB}} {
+		if err := p.req.Validate(); err != nil {
+			verr := err.(*ValidationError)
+			return &ValidationError{Field: p.name + "." + verr.Field, Message: verr.Message}
+		}
+	}
+	return nil
+}
+
+// Validate reports the first problem that would make the service reject r.
+// Problems with a gap are reported for fields such as
+// "analyses.2.0.confidence_score".
+func (r DeduplicateRequest) Validate() error {
+	if len(r.Analyses) == 0 || len(r.Analyses) > MaxDeduplicateAnalyses {
+		return &ValidationError{
+			Field:   "analyses",
+			Message: fmt.Sprintf("must hold between 1 and %d analyses, got %d", MaxDeduplicateAnalyses, len(r.Analyses)),
+		}
+	}
+	total := 0
+	for i, gaps := range r.Analyses {
+		for j, gap := range gaps {
+			if gap.ConfidenceScore < 0 || gap.ConfidenceScore > 1 {
+				return &ValidationError{
+					Field:   fmt.Sprintf("analyses.%d.%d.confidence_score", i, j),
+					Message: fmt.Sprintf("must be between 0 and 1, got %v", gap.ConfidenceScore),
+				}
+			}
+		}
+		total += len(gaps)
+	}
+	if total == 0 {
+		return &ValidationError{Field: "analyses", Message: "must hold at least one gap"}
+	}
+	return nil
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r CitationsRequest) Validate() error {
+	if strings.TrimSpace(r.Gap.GapDescription) == "" {
+		return &ValidationError{Field: "gap.gap_description", Message: "must not be empty"}
+	}
+	if r.Gap.ConfidenceScore < 0 || r.Gap.ConfidenceScore > 1 {
+		return &ValidationError{
+			Field:   "gap.confidence_score",
+			Message: fmt.Sprintf("must be between 0 and 1, got %v", r.Gap.ConfidenceScore),
+		}
+	}
+	if r.MaxCitations < 0 || r.MaxCitations > MaxCitationsLimit {
+		return &ValidationError{
+			Field:   "max_citations",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxCitationsLimit, r.MaxCitations),
+		}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r.
+// Problems with a gap are reported for fields such as
+// "gaps.3.gap_description".
+func (r HypothesesRequest) Validate() error {
+	if len(r.Gaps) == 0 || len(r.Gaps) > MaxHypothesisGaps {
+		return &ValidationError{
+			Field:   "gaps",
+			Message: fmt.Sprintf("must hold between 1 and %d gaps, got %d", MaxHypothesisGaps, len(r.Gaps)),
+		}
+	}
+	if err := validateGaps("gaps", r.Gaps); err != nil {
+		return err
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r MethodsRequest) Validate() error {
+	if strings.TrimSpace(r.Hypothesis.Hypothesis) == "" {
+		return &ValidationError{Field: "hypothesis.hypothesis", Message: "must not be empty"}
+	}
+	if r.Hypothesis.FeasibilityScore < 0 || r.Hypothesis.FeasibilityScore > 1 {
+		return &ValidationError{
+			Field:   "hypothesis.feasibility_score",
+			Message: fmt.Sprintf("must be between 0 and 1, got %v", r.Hypothesis.FeasibilityScore),
+		}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r DatasetsRequest) Validate() error {
+	switch {
+	case (r.Gap == nil) == (r.Hypothesis == nil):
+		return &ValidationError{Field: "gap", Message: "exactly one of gap and hypothesis must be set"}
+	case r.Gap != nil && strings.TrimSpace(r.Gap.GapDescription) == "":
+		return &ValidationError{Field: "gap.gap_description", Message: "must not be empty"}
+	case r.Gap != nil && (r.Gap.ConfidenceScore < 0 || r.Gap.ConfidenceScore > 1):
+		return &ValidationError{
+			Field:   "gap.confidence_score",
+			Message: fmt.Sprintf("must be between 0 and 1, got %v", r.Gap.ConfidenceScore),
+		}
+	case r.Hypothesis != nil && strings.TrimSpace(r.Hypothesis.Hypothesis) == "":
+		return &ValidationError{Field: "hypothesis.hypothesis", Message: "must not be empty"}
+	case r.Hypothesis != nil && (r.Hypothesis.FeasibilityScore < 0 || r.Hypothesis.FeasibilityScore > 1):
+		return &ValidationError{
+			Field:   "hypothesis.feasibility_score",
+			Message: fmt.Sprintf("must be between 0 and 1, got %v", r.Hypothesis.FeasibilityScore),
+		}
+	case r.MaxDatasets < 0 || r.MaxDatasets > MaxDatasetsLimit:
+		return &ValidationError{
+			Field:   "max_datasets",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxDatasetsLimit, r.MaxDatasets),
+		}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r
+func (r RelatedWorkRequest) Validate() error {
+	if strings.TrimSpace(r.Gap.GapDescription) == "" {
+		return &ValidationError{Field: "gap.gap_description", Message: "must not be empty"}
+	}
+	if r.Gap.ConfidenceScore < 0 || r.Gap.ConfidenceScore > 1 {
+		return &ValidationError{
+			Field:   "gap.confidence_score",
+			Message: fmt.Sprintf("must be between 0 and 1, got %v", r.Gap.ConfidenceScore),
+		}
+	}
+	if r.MaxPapers < 0 || r.MaxPapers > MaxRelatedPapersLimit {
+		return &ValidationError{
+			Field:   "max_papers",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxRelatedPapersLimit, r.MaxPapers),
+		}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r.
+// Problems with a gap are reported for fields such as
+// "gaps.3.gap_description".
+func (r FundingRequest) Validate() error {
+	if len(r.Gaps) == 0 || len(r.Gaps) > MaxFundingGaps {
+		return &ValidationError{
+			Field:   "gaps",
+			Message: fmt.Sprintf("must hold between 1 and %d gaps, got %d", MaxFundingGaps, len(r.Gaps)),
+		}
+	}
+	if err := validateGaps("gaps", r.Gaps); err != nil {
+		return err
+	}
+	if r.MaxMatches < 0 || r.MaxMatches > MaxMatchesLimit {
+		return &ValidationError{
+			Field:   "max_matches",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxMatchesLimit, r.MaxMatches),
+		}
+	}
+	return validateField(r.Field)
+}
+
+// Validate reports the first problem that would make the service reject r.
+// Problems with a gap are reported for fields such as
+// "common_gaps.3.gap_description".
+func (r ResearchGroupsRequest) Validate() error {
+	if strings.TrimSpace(r.Topic) == "" {
+		return &ValidationError{Field: "topic", Message: "must not be empty"}
+	}
+	if len(r.Papers) == 0 || len(r.Papers) > MaxPapersLimit {
+		return &ValidationError{
+			Field:   "papers",
+			Message: fmt.Sprintf("must hold between 1 and %d papers, got %d", MaxPapersLimit, len(r.Papers)),
+		}
+	}
+	if len(r.CommonGaps) == 0 || len(r.CommonGaps) > MaxGroupGaps {
+		return &ValidationError{
+			Field:   "common_gaps",
+			Message: fmt.Sprintf("must hold between 1 and %d gaps, got %d", MaxGroupGaps, len(r.CommonGaps)),
+		}
+	}
+	if err := validateGaps("common_gaps", r.CommonGaps); err != nil {
+		return err
+	}
+	return validateField(r.Field)
+}
+
+// validateGaps checks that each gap of the named list is described and has a
+// valid confidence score
+func validateGaps(name string, gaps []ResearchGap) error {
+	for i, gap := range gaps {
+		if strings.TrimSpace(gap.GapDescription) == "" {
+			return &ValidationError{Field: fmt.Sprintf("%s.%d.gap_description", name, i), Message: "must not be empty"}
+		}
+		if gap.ConfidenceScore < 0 || gap.ConfidenceScore > 1 {
+			return &ValidationError{
+				Field:   fmt.Sprintf("%s.%d.confidence_score", name, i),
+				Message: fmt.Sprintf("must be between 0 and 1, got %v", gap.ConfidenceScore),
+			}
+		}
+	}
+	return nil
+}
+
+// Validate reports the first problem that would make the service reject r.
+// Problems with an abstract are reported for fields such as
+// "requests.3.title".
+func (r BatchAnalyzeRequest) Validate() error {
+	if len(r.Requests) == 0 || len(r.Requests) > MaxBatchSize {
+		return &ValidationError{
+			Field:   "requests",
+			Message: fmt.Sprintf("must hold between 1 and %d abstracts, got %d", MaxBatchSize, len(r.Requests)),
+		}
+	}
+	for i, req := range r.Requests {
+		if err := req.Validate(); err != nil {
+			verr := err.(*ValidationError)
+			return &ValidationError{Field: fmt.Sprintf("requests.%d.%s", i, verr.Field), Message: verr.Message}
+		}
+	}
+	return nil
+}
+
+// Valid reports whether f is one of Fields
+func (f Field) Valid() bool {
+	return slices.Contains(Fields, f)
+}
+
+// Valid reports whether t is one of GapTypes. Models sometimes answer with
+// other types, which ResearchGap keeps as they are.
+func (t GapType) Valid() bool {
+	return slices.Contains(GapTypes, t)
+}
+
+// ParseGapType returns the GapType named by s, ignoring case and surrounding
+// space
+func ParseGapType(s string) (GapType, error) {
+	t := GapType(strings.ToLower(strings.TrimSpace(s)))
+	if !t.Valid() {
+		names := make([]string, len(GapTypes))
+		for i, t := range GapTypes {
+			names[i] = string(t)
+		}
+		return "", fmt.Errorf("unknown gap type %q, want one of %s", s, strings.Join(names, ", "))
+	}
+	return t, nil
+}
+
+// validateField accepts a known field or the empty string, for which the
+// service falls back to FieldGeneral
+// Validate reports the first problem that would make the service reject q
+func (q AnalysesQuery) Validate() error {
+	if q.Limit < 0 || q.Limit > MaxAnalysesLimit {
+		return &ValidationError{
+			Field:   "limit",
+			Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxAnalysesLimit, q.Limit),
+		}
+	}
+	return validateField(q.Field)
+}
+
+func validateField(field Field) error {
+	if field == "" || field.Valid() {
+		return nil
+	}
+	names := make([]string, len(Fields))
+	for i, f := range Fields {
+		names[i] = string(f)
+	}
+	return &ValidationError{
+		Field:   "field",
+		Message: fmt.Sprintf("unknown research field %q, want one of %s", field, strings.Join(names, ", ")),
+	}
+}
+
+// validateMode accepts a known analysis mode or the empty string, for which
+// the service falls back to ModeAbstract
+func validateMode(mode string) error {
+	if mode == "" || mode == ModeAbstract || mode == ModeFullText {
+		return nil
+	}
+	return &ValidationError{
+		Field:   "mode",
+		Message: fmt.Sprintf("unknown analysis mode %q, want %s or %s", mode, ModeAbstract, ModeFullText),
+	}
+}
+
+// validateTemperature accepts no sampling temperature, for which the service
+// falls back to its default, or one from 0 to MaxTemperature
+func validateTemperature(t *float64) error {
+	if t == nil || *t >= 0 && *t <= MaxTemperature {
+		return nil
+	}
+	return &ValidationError{
+		Field:   "temperature",
+		Message: fmt.Sprintf("must be between 0 and %g, got %g", float64(MaxTemperature), *t),
+	}
+}
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// validateLanguage accepts an ISO 639-1 language code or the empty string,
+// for English, which output can't be translated to
+func validateLanguage(language string, translate bool) error {
+	if language != "" && !languagePattern.MatchString(language) {
+		return &ValidationError{
+			Field:   "language",
+			Message: fmt.Sprintf("must be an ISO 639-1 code such as de or zh, got %q", language),
+		}
+	}
+	if translate && language == "" {
+		return &ValidationError{Field: "translate_output", Message: "requires language"}
+	}
+	return nil
+}
+
+// validateMinConfidence accepts a confidence threshold from 0, for none, to 1
+func validateMinConfidence(c float64) error {
+	if c >= 0 && c <= 1 {
+		return nil
+	}
+	return &ValidationError{
+		Field:   "min_confidence",
+		Message: fmt.Sprintf("must be between 0 and 1, got %g", c),
+	}
+}
+
+// validateResultLimit accepts a bound on the number of results from 1 to
+// MaxResultsLimit, or zero for none
+func validateResultLimit(name string, n int) error {
+	if n >= 0 && n <= MaxResultsLimit {
+		return nil
+	}
+	return &ValidationError{
+		Field:   name,
+		Message: fmt.Sprintf("must be between 1 and %d, got %d", MaxResultsLimit, n),
+	}
+}
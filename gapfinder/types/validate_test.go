@@ -0,0 +1,502 @@
+package types
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeRequestValidate(t *testing.T) {
+	valid := AnalyzeRequest{Title: "Title", Abstract: "Abstract", Field: FieldBiology}
+	tests := []struct {
+		name      string
+		modify    func(*AnalyzeRequest)
+		wantField string
+	}{
+		{"valid", func(*AnalyzeRequest) {}, ""},
+		{"default field", func(r *AnalyzeRequest) { r.Field = "" }, ""},
+		{"blank title", func(r *AnalyzeRequest) { r.Title = "  " }, "title"},
+		{"empty abstract", func(r *AnalyzeRequest) { r.Abstract = "" }, "abstract"},
+		{"unknown field", func(r *AnalyzeRequest) { r.Field = "astrology" }, "field"},
+		{"full text", func(r *AnalyzeRequest) { r.Mode, r.FullText = ModeFullText, "Methods" }, ""},
+		{"full text missing", func(r *AnalyzeRequest) { r.Mode = ModeFullText }, "full_text"},
+		{"unknown mode", func(r *AnalyzeRequest) { r.Mode = "sections" }, "mode"},
+		{"model", func(r *AnalyzeRequest) { r.Model, r.Temperature = "gpt-4o-mini", new(0.2) }, ""},
+		{"zero temperature", func(r *AnalyzeRequest) { r.Temperature = new(0.0) }, ""},
+		{"negative temperature", func(r *AnalyzeRequest) { r.Temperature = new(-0.1) }, "temperature"},
+		{"temperature too high", func(r *AnalyzeRequest) { r.Temperature = new(MaxTemperature + 0.5) }, "temperature"},
+		{"language", func(r *AnalyzeRequest) { r.Language, r.TranslateOutput = "de", true }, ""},
+		{"language name", func(r *AnalyzeRequest) { r.Language = "German" }, "language"},
+		{"translation without language", func(r *AnalyzeRequest) { r.TranslateOutput = true }, "translate_output"},
+		{"min confidence", func(r *AnalyzeRequest) { r.MinConfidence = 0.5 }, ""},
+		{"min confidence above 1", func(r *AnalyzeRequest) { r.MinConfidence = 1.5 }, "min_confidence"},
+		{"result limits", func(r *AnalyzeRequest) { r.MaxGaps, r.MaxHypotheses, r.MaxFindings = 3, 1, MaxResultsLimit }, ""},
+		{"max hypotheses above limit", func(r *AnalyzeRequest) { r.MaxHypotheses = MaxResultsLimit + 1 }, "max_hypotheses"},
+		{"negative max findings", func(r *AnalyzeRequest) { r.MaxFindings = -1 }, "max_findings"},
+		{"instructions", func(r *AnalyzeRequest) { r.Instructions = strings.Repeat("é", MaxInstructionsLength) }, ""},
+		{"instructions too long", func(r *AnalyzeRequest) { r.Instructions = strings.Repeat("x", MaxInstructionsLength+1) }, "instructions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			checkValidationError(t, req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestTopicRequestValidate(t *testing.T) {
+	valid := TopicRequest{Topic: "CRISPR", Field: FieldBiology, MaxPapers: 5}
+	tests := []struct {
+		name      string
+		modify    func(*TopicRequest)
+		wantField string
+	}{
+		{"valid", func(*TopicRequest) {}, ""},
+		{"default max papers", func(r *TopicRequest) { r.MaxPapers = 0 }, ""},
+		{"max papers at limit", func(r *TopicRequest) { r.MaxPapers = MaxPapersLimit }, ""},
+		{"max papers above limit", func(r *TopicRequest) { r.MaxPapers = MaxPapersLimit + 1 }, "max_papers"},
+		{"negative max papers", func(r *TopicRequest) { r.MaxPapers = -1 }, "max_papers"},
+		{"page size above limit", func(r *TopicRequest) { r.PageSize = MaxPageSize + 1 }, "page_size"},
+		{"blank topic", func(r *TopicRequest) { r.Topic = "\t" }, "topic"},
+		{"unknown field", func(r *TopicRequest) { r.Field = "Biology" }, "field"},
+		{"temperature at limit", func(r *TopicRequest) { r.Temperature = new(float64(MaxTemperature)) }, ""},
+		{"temperature above limit", func(r *TopicRequest) { r.Temperature = new(3.0) }, "temperature"},
+		{"upper-case language", func(r *TopicRequest) { r.Language = "ZH" }, "language"},
+		{"negative min confidence", func(r *TopicRequest) { r.MinConfidence = -0.1 }, "min_confidence"},
+		{"max gaps above limit", func(r *TopicRequest) { r.MaxGaps = MaxResultsLimit + 1 }, "max_gaps"},
+		{"cross fields", func(r *TopicRequest) { r.CrossFields = []Field{FieldBiology, FieldPhysics} }, ""},
+		{"one cross field", func(r *TopicRequest) { r.CrossFields = []Field{FieldBiology} }, "cross_fields"},
+		{"too many cross fields", func(r *TopicRequest) { r.CrossFields = Fields[:MaxCrossFields+1] }, "cross_fields"},
+		{"unknown cross field", func(r *TopicRequest) { r.CrossFields = []Field{FieldBiology, "alchemy"} }, "cross_fields.1"},
+		{"repeated cross field", func(r *TopicRequest) { r.CrossFields = []Field{FieldBiology, FieldPhysics, FieldBiology} }, "cross_fields.2"},
+		{"surveys excluded", func(r *TopicRequest) { r.Surveys = SurveysExclude }, ""},
+		{"unknown surveys", func(r *TopicRequest) { r.Surveys = "ignore" }, "surveys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			checkValidationError(t, req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestReviewRequestValidate(t *testing.T) {
+	paper := TopicAnalysisResult{PaperTitle: "Title"}
+	tests := []struct {
+		name      string
+		req       ReviewRequest
+		wantField string
+	}{
+		{"valid", ReviewRequest{Topic: "sleep", Papers: []TopicAnalysisResult{paper}}, ""},
+		{"at limit", ReviewRequest{Topic: "sleep", Papers: slices.Repeat([]TopicAnalysisResult{paper}, MaxPapersLimit)}, ""},
+		{"empty topic", ReviewRequest{Topic: " ", Papers: []TopicAnalysisResult{paper}}, "topic"},
+		{"no papers", ReviewRequest{Topic: "sleep"}, "papers"},
+		{"above limit", ReviewRequest{Topic: "sleep", Papers: slices.Repeat([]TopicAnalysisResult{paper}, MaxPapersLimit+1)}, "papers"},
+		{"unknown field", ReviewRequest{Topic: "sleep", Papers: []TopicAnalysisResult{paper}, Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestResearchGroupsRequestValidate(t *testing.T) {
+	paper := TopicAnalysisResult{PaperTitle: "Title"}
+	gap := ResearchGap{GapDescription: "Small samples", ConfidenceScore: 0.8}
+	valid := func(mutate func(*ResearchGroupsRequest)) ResearchGroupsRequest {
+		r := ResearchGroupsRequest{Topic: "sleep", Papers: []TopicAnalysisResult{paper}, CommonGaps: []ResearchGap{gap}}
+		mutate(&r)
+		return r
+	}
+	tests := []struct {
+		name      string
+		req       ResearchGroupsRequest
+		wantField string
+	}{
+		{"valid", valid(func(r *ResearchGroupsRequest) {}), ""},
+		{"gaps at limit", valid(func(r *ResearchGroupsRequest) { r.CommonGaps = slices.Repeat(r.CommonGaps, MaxGroupGaps) }), ""},
+		{"empty topic", valid(func(r *ResearchGroupsRequest) { r.Topic = "" }), "topic"},
+		{"no papers", valid(func(r *ResearchGroupsRequest) { r.Papers = nil }), "papers"},
+		{"no gaps", valid(func(r *ResearchGroupsRequest) { r.CommonGaps = nil }), "common_gaps"},
+		{"gaps above limit", valid(func(r *ResearchGroupsRequest) { r.CommonGaps = slices.Repeat(r.CommonGaps, MaxGroupGaps+1) }), "common_gaps"},
+		{"undescribed gap", valid(func(r *ResearchGroupsRequest) { r.CommonGaps = append(r.CommonGaps, ResearchGap{}) }), "common_gaps.1.gap_description"},
+		{"unknown field", valid(func(r *ResearchGroupsRequest) { r.Field = "alchemy" }), "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestRefineRequestValidate(t *testing.T) {
+	prev := AnalyzeResponse{Gaps: []ResearchGap{{GapDescription: "Small samples"}}}
+	tests := []struct {
+		name      string
+		req       RefineRequest
+		wantField string
+	}{
+		{"valid", RefineRequest{Previous: prev, Feedback: "Drop gap 1"}, ""},
+		{"feedback at limit", RefineRequest{Previous: prev, Feedback: strings.Repeat("é", MaxFeedbackLength)}, ""},
+		{"feedback above limit", RefineRequest{Previous: prev, Feedback: strings.Repeat("é", MaxFeedbackLength+1)}, "feedback"},
+		{"blank feedback", RefineRequest{Previous: prev, Feedback: "\n"}, "feedback"},
+		{"empty previous", RefineRequest{Feedback: "Drop gap 1"}, "previous"},
+		{"unknown field", RefineRequest{Previous: prev, Feedback: "Drop gap 1", Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestMethodsRequestValidate(t *testing.T) {
+	h := Hypothesis{Hypothesis: "Longer sleep improves recall", FeasibilityScore: 0.7}
+	tests := []struct {
+		name      string
+		req       MethodsRequest
+		wantField string
+	}{
+		{"valid", MethodsRequest{Hypothesis: h}, ""},
+		{"empty hypothesis", MethodsRequest{Hypothesis: Hypothesis{Hypothesis: " "}}, "hypothesis.hypothesis"},
+		{"bad feasibility", MethodsRequest{Hypothesis: Hypothesis{Hypothesis: "h", FeasibilityScore: -1}}, "hypothesis.feasibility_score"},
+		{"unknown field", MethodsRequest{Hypothesis: h, Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestDatasetsRequestValidate(t *testing.T) {
+	gap := &ResearchGap{GapDescription: "Small samples", ConfidenceScore: 0.8}
+	h := &Hypothesis{Hypothesis: "Longer sleep improves recall", FeasibilityScore: 0.7}
+	tests := []struct {
+		name      string
+		req       DatasetsRequest
+		wantField string
+	}{
+		{"gap", DatasetsRequest{Gap: gap}, ""},
+		{"hypothesis at limit", DatasetsRequest{Hypothesis: h, MaxDatasets: MaxDatasetsLimit}, ""},
+		{"neither", DatasetsRequest{}, "gap"},
+		{"both", DatasetsRequest{Gap: gap, Hypothesis: h}, "gap"},
+		{"undescribed gap", DatasetsRequest{Gap: &ResearchGap{}}, "gap.gap_description"},
+		{"bad confidence", DatasetsRequest{Gap: &ResearchGap{GapDescription: "d", ConfidenceScore: 2}}, "gap.confidence_score"},
+		{"empty hypothesis", DatasetsRequest{Hypothesis: &Hypothesis{}}, "hypothesis.hypothesis"},
+		{"bad feasibility", DatasetsRequest{Hypothesis: &Hypothesis{Hypothesis: "h", FeasibilityScore: 2}}, "hypothesis.feasibility_score"},
+		{"datasets above limit", DatasetsRequest{Gap: gap, MaxDatasets: MaxDatasetsLimit + 1}, "max_datasets"},
+		{"unknown field", DatasetsRequest{Gap: gap, Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestRelatedWorkRequestValidate(t *testing.T) {
+	gap := ResearchGap{GapDescription: "Small samples", ConfidenceScore: 0.8}
+	tests := []struct {
+		name      string
+		req       RelatedWorkRequest
+		wantField string
+	}{
+		{"defaults", RelatedWorkRequest{Gap: gap}, ""},
+		{"at limit", RelatedWorkRequest{Gap: gap, MaxPapers: MaxRelatedPapersLimit}, ""},
+		{"undescribed gap", RelatedWorkRequest{}, "gap.gap_description"},
+		{"bad confidence", RelatedWorkRequest{Gap: ResearchGap{GapDescription: "d", ConfidenceScore: 2}}, "gap.confidence_score"},
+		{"papers above limit", RelatedWorkRequest{Gap: gap, MaxPapers: MaxRelatedPapersLimit + 1}, "max_papers"},
+		{"unknown field", RelatedWorkRequest{Gap: gap, Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestFundingRequestValidate(t *testing.T) {
+	gaps := []ResearchGap{{GapDescription: "Small samples", ConfidenceScore: 0.8}}
+	tests := []struct {
+		name      string
+		req       FundingRequest
+		wantField string
+	}{
+		{"defaults", FundingRequest{Gaps: gaps}, ""},
+		{"at limits", FundingRequest{Gaps: slices.Repeat(gaps, MaxFundingGaps), MaxMatches: MaxMatchesLimit}, ""},
+		{"no gaps", FundingRequest{}, "gaps"},
+		{"gaps above limit", FundingRequest{Gaps: slices.Repeat(gaps, MaxFundingGaps+1)}, "gaps"},
+		{"undescribed gap", FundingRequest{Gaps: append(slices.Clone(gaps), ResearchGap{})}, "gaps.1.gap_description"},
+		{"matches above limit", FundingRequest{Gaps: gaps, MaxMatches: MaxMatchesLimit + 1}, "max_matches"},
+		{"negative matches", FundingRequest{Gaps: gaps, MaxMatches: -1}, "max_matches"},
+		{"unknown field", FundingRequest{Gaps: gaps, Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestNoveltyRequestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       NoveltyRequest
+		wantField string
+	}{
+		{"defaults", NoveltyRequest{Idea: "Sleep spindles predict recall"}, ""},
+		{"at limits", NoveltyRequest{Idea: strings.Repeat("é", MaxIdeaLength), MaxPapers: MaxPapersLimit}, ""},
+		{"empty idea", NoveltyRequest{Idea: " "}, "idea"},
+		{"idea too long", NoveltyRequest{Idea: strings.Repeat("a", MaxIdeaLength+1)}, "idea"},
+		{"papers above limit", NoveltyRequest{Idea: "idea", MaxPapers: MaxPapersLimit + 1}, "max_papers"},
+		{"unknown field", NoveltyRequest{Idea: "idea", Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestProposalRequestValidate(t *testing.T) {
+	gaps := []ResearchGap{{GapDescription: "Small samples", ConfidenceScore: 0.8}}
+	tests := []struct {
+		name      string
+		req       ProposalRequest
+		wantField string
+	}{
+		{"defaults", ProposalRequest{Proposal: "We will study sleep."}, ""},
+		{"at limits", ProposalRequest{Proposal: strings.Repeat("é", MaxProposalLength), Gaps: slices.Repeat(gaps, MaxProposalGaps), MaxPapers: MaxPapersLimit}, ""},
+		{"empty proposal", ProposalRequest{Proposal: "\n"}, "proposal"},
+		{"proposal too long", ProposalRequest{Proposal: strings.Repeat("a", MaxProposalLength+1)}, "proposal"},
+		{"gaps above limit", ProposalRequest{Proposal: "p", Gaps: slices.Repeat(gaps, MaxProposalGaps+1)}, "gaps"},
+		{"undescribed gap", ProposalRequest{Proposal: "p", Gaps: append(slices.Clone(gaps), ResearchGap{})}, "gaps.1.gap_description"},
+		{"papers above limit", ProposalRequest{Proposal: "p", MaxPapers: MaxPapersLimit + 1}, "max_papers"},
+		{"unknown field", ProposalRequest{Proposal: "p", Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestTrendsRequestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       TrendsRequest
+		wantField string
+	}{
+		{"defaults", TrendsRequest{Topic: "sleep"}, ""},
+		{"at limits", TrendsRequest{Topic: "sleep", YearsBack: MaxYearsBack, MaxPapers: MaxPapersLimit}, ""},
+		{"empty topic", TrendsRequest{Topic: " "}, "topic"},
+		{"years above limit", TrendsRequest{Topic: "sleep", YearsBack: MaxYearsBack + 1}, "years_back"},
+		{"negative years", TrendsRequest{Topic: "sleep", YearsBack: -1}, "years_back"},
+		{"papers above limit", TrendsRequest{Topic: "sleep", MaxPapers: MaxPapersLimit + 1}, "max_papers"},
+		{"unknown field", TrendsRequest{Topic: "sleep", Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestDOIRequestValidate(t *testing.T) {
+	tests := []struct {
+		doi       string
+		wantField string
+	}{
+		{"10.1038/nature12373", ""},
+		{"https://doi.org/10.1038/nature12373", ""},
+		{"doi:10.1038/nature12373", ""},
+		{"", "doi"},
+		{"nature12373", "doi"},
+		{"10.1038/", "doi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.doi, func(t *testing.T) {
+			checkValidationError(t, DOIRequest{DOI: tt.doi}.Validate(), tt.wantField)
+		})
+	}
+	if got := NormalizeDOI(" https://dx.doi.org/10.1038/nature12373"); got != "10.1038/nature12373" {
+		t.Errorf("NormalizeDOI() = %q", got)
+	}
+}
+
+func TestPMIDRequestValidate(t *testing.T) {
+	tests := []struct {
+		pmid      string
+		wantField string
+	}{
+		{"31452104", ""},
+		{"PMID: 31452104", ""},
+		{"https://pubmed.ncbi.nlm.nih.gov/31452104/", ""},
+		{"", "pmid"},
+		{"PMC6710543", "pmid"},
+		{"1234567890", "pmid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pmid, func(t *testing.T) {
+			checkValidationError(t, PMIDRequest{PMID: tt.pmid}.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestArxivRequestValidate(t *testing.T) {
+	tests := []struct {
+		id        string
+		wantField string
+	}{
+		{"2101.00001", ""},
+		{"2101.00001v2", ""},
+		{"hep-th/9901001", ""},
+		{"arXiv:1501.0001", ""},
+		{"https://arxiv.org/pdf/2101.00001v1.pdf", ""},
+		{"", "arxiv_id"},
+		{"quantum", "arxiv_id"},
+		{"2101.001", "arxiv_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			checkValidationError(t, ArxivRequest{ID: tt.id}.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestCompareRequestValidate(t *testing.T) {
+	paper := AnalyzeRequest{Title: "Title", Abstract: "Abstract"}
+	checkValidationError(t, CompareRequest{PaperA: paper, PaperB: paper}.Validate(), "")
+	checkValidationError(t, CompareRequest{PaperA: paper, PaperB: AnalyzeRequest{Title: "Title"}}.Validate(), "paper_b.abstract")
+	checkValidationError(t, CompareRequest{PaperB: paper}.Validate(), "paper_a.title")
+}
+
+func TestBatchAnalyzeRequestValidate(t *testing.T) {
+	item := AnalyzeRequest{Title: "Title", Abstract: "Abstract"}
+	tests := []struct {
+		name      string
+		requests  []AnalyzeRequest
+		wantField string
+	}{
+		{"valid", []AnalyzeRequest{item, item}, ""},
+		{"at limit", slices.Repeat([]AnalyzeRequest{item}, MaxBatchSize), ""},
+		{"empty", nil, "requests"},
+		{"above limit", slices.Repeat([]AnalyzeRequest{item}, MaxBatchSize+1), "requests"},
+		{"invalid item", []AnalyzeRequest{item, {Title: "Title"}}, "requests.1.abstract"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, BatchAnalyzeRequest{Requests: tt.requests}.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestDeduplicateRequestValidate(t *testing.T) {
+	gaps := []ResearchGap{{GapDescription: "Small sample", ConfidenceScore: 0.8}}
+	tests := []struct {
+		name      string
+		analyses  [][]ResearchGap
+		wantField string
+	}{
+		{"valid", [][]ResearchGap{gaps, nil}, ""},
+		{"at limit", slices.Repeat([][]ResearchGap{gaps}, MaxDeduplicateAnalyses), ""},
+		{"empty", nil, "analyses"},
+		{"no gaps", [][]ResearchGap{nil, {}}, "analyses"},
+		{"above limit", slices.Repeat([][]ResearchGap{gaps}, MaxDeduplicateAnalyses+1), "analyses"},
+		{"invalid gap", [][]ResearchGap{gaps, {gaps[0], {ConfidenceScore: 1.5}}}, "analyses.1.1.confidence_score"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, DeduplicateRequest{Analyses: tt.analyses}.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestCitationsRequestValidate(t *testing.T) {
+	gap := ResearchGap{GapDescription: "Small sample", ConfidenceScore: 0.8}
+	tests := []struct {
+		name      string
+		req       CitationsRequest
+		wantField string
+	}{
+		{"valid", CitationsRequest{Gap: gap}, ""},
+		{"at limit", CitationsRequest{Gap: gap, MaxCitations: MaxCitationsLimit}, ""},
+		{"undescribed gap", CitationsRequest{Gap: ResearchGap{GapDescription: " "}}, "gap.gap_description"},
+		{"above limit", CitationsRequest{Gap: gap, MaxCitations: MaxCitationsLimit + 1}, "max_citations"},
+		{"unknown field", CitationsRequest{Gap: gap, Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestHypothesesRequestValidate(t *testing.T) {
+	gap := ResearchGap{GapDescription: "Small sample", ConfidenceScore: 0.8}
+	tests := []struct {
+		name      string
+		req       HypothesesRequest
+		wantField string
+	}{
+		{"valid", HypothesesRequest{Gaps: []ResearchGap{gap}, Field: FieldMedicine}, ""},
+		{"at limit", HypothesesRequest{Gaps: slices.Repeat([]ResearchGap{gap}, MaxHypothesisGaps)}, ""},
+		{"empty", HypothesesRequest{}, "gaps"},
+		{"above limit", HypothesesRequest{Gaps: slices.Repeat([]ResearchGap{gap}, MaxHypothesisGaps+1)}, "gaps"},
+		{"undescribed gap", HypothesesRequest{Gaps: []ResearchGap{gap, {GapDescription: " "}}}, "gaps.1.gap_description"},
+		{"unknown field", HypothesesRequest{Gaps: []ResearchGap{gap}, Field: "alchemy"}, "field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.req.Validate(), tt.wantField)
+		})
+	}
+}
+
+func TestFieldValid(t *testing.T) {
+	for _, f := range Fields {
+		if !f.Valid() {
+			t.Errorf("%q.Valid() = false, want true", f)
+		}
+	}
+	for _, f := range []Field{"", "General", "astrology"} {
+		if f.Valid() {
+			t.Errorf("%q.Valid() = true, want false", f)
+		}
+	}
+}
+
+func TestParseGapType(t *testing.T) {
+	for in, want := range map[string]GapType{"empirical": GapEmpirical, " Methodological ": GapMethodological, "DATA": GapData} {
+		if got, err := ParseGapType(in); got != want || err != nil {
+			t.Errorf("ParseGapType(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "system", "empirical gap"} {
+		if got, err := ParseGapType(in); err == nil {
+			t.Errorf("ParseGapType(%q) = %q, want an error", in, got)
+		}
+	}
+}
+
+func checkValidationError(t *testing.T, err error, wantField string) {
+	t.Helper()
+	if wantField == "" {
+		if err != nil {
+			t.Errorf("Validate() error = %v, want nil", err)
+		}
+		return
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+	if verr.Field != wantField {
+		t.Errorf("ValidationError.Field = %q, want %q", verr.Field, wantField)
+	}
+}
@@ -0,0 +1,206 @@
+// Package webhook verifies and decodes the callbacks the AI Gap Finder
+// service sends when a background job finishes, and signs them for the
+// service.
+//
+// A job submitted with a callback URL is POSTed there as JSON once it has
+// succeeded, failed or been cancelled. The service signs each callback with
+// a secret shared with the receiver, so the receiver can tell callbacks from
+// forgeries:
+//
+//	http.Handle("/gapfinder/jobs", webhook.Handler(secret, func(ctx context.Context, job *types.Job) error {
+//		return store(ctx, job)
+//	}))
+//
+// Callback URLs must be public: the service refuses to call back loopback,
+// private and link-local addresses, so that callers can't use it to reach
+// its own network, unless it is told to allow them.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+// SignatureHeader is the header holding a callback's signature, in the form
+// "t=<unix time>,v1=<hex HMAC-SHA256 of the time, a dot and the body>".
+// Several v1 signatures may be sent while a secret is being rotated.
+const SignatureHeader = "X-Gapfinder-Signature"
+
+// DefaultTolerance is how old a callback's signature may be before Verify
+// takes it for a replay
+const DefaultTolerance = 5 * time.Minute
+
+// maxBodyBytes bounds the callbacks Decode reads; a job holds a whole topic
+// analysis
+const maxBodyBytes = 8 << 20
+
+var (
+	// ErrNoSignature is returned for a callback without a signature
+	ErrNoSignature = errors.New("webhook: missing signature")
+	// ErrInvalidSignature is returned for a callback whose signature
+	// doesn't match its body, or which is too old
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	// ErrPrivateAddress is returned for a callback URL or connection to a
+	// loopback, private or link-local address
+	ErrPrivateAddress = errors.New("webhook: loopback or private address")
+)
+
+// CheckURL checks that u is an absolute http or https URL whose host isn't
+// localhost or a loopback, private or link-local address. The addresses of
+// other host names can change, so clients from NewClient check them again
+// as they connect.
+func CheckURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("webhook: not an absolute http or https URL")
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateAddress
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && private(addr) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// private reports whether addr is one that callbacks mustn't reach
+func private(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsUnspecified()
+}
+
+// NewClient returns a client for sending callbacks that refuses to connect
+// to loopback, private or link-local addresses, whatever host name they were
+// reached by, redirects included. It connects directly, without a proxy.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if private(addr.Addr()) {
+				return ErrPrivateAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// Sign returns the SignatureHeader value for body sent at t
+func Sign(secret []byte, t time.Time, body []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", t.Unix(), hex.EncodeToString(mac(secret, t.Unix(), body)))
+}
+
+func mac(secret []byte, timestamp int64, body []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	fmt.Fprintf(h, "%d.", timestamp)
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// Verify checks that header, the SignatureHeader of a callback, signs body
+// with secret and was made within tolerance of now. A tolerance of zero
+// means DefaultTolerance.
+func Verify(secret []byte, header string, body []byte, tolerance time.Duration) error {
+	if header == "" {
+		return ErrNoSignature
+	}
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	var timestamp int64
+	var signatures [][]byte
+	for part := range strings.SplitSeq(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			if sig, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+	want := mac(secret, timestamp, body)
+	for _, sig := range signatures {
+		if hmac.Equal(sig, want) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// Decode reads the body of a callback request, verifies its signature with
+// secret and returns the job it reports
+func Decode(r *http.Request, secret []byte) (*types.Job, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("webhook: error reading body: %w", err)
+	}
+	if err := Verify(secret, r.Header.Get(SignatureHeader), body, 0); err != nil {
+		return nil, err
+	}
+	var job types.Job
+	if err := json.Unmarshal(body, &job); err != nil {
+		return nil, fmt.Errorf("webhook: error decoding job: %w", err)
+	}
+	return &job, nil
+}
+
+// Handler returns a handler calling fn with the job of each callback whose
+// signature is valid. It answers 401 to callbacks that aren't signed with
+// secret, 400 to those it can't decode, and 500 if fn fails, which makes the
+// service send the callback again.
+func Handler(secret []byte, fn func(ctx context.Context, job *types.Job) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		job, err := Decode(r, secret)
+		switch {
+		case errors.Is(err, ErrNoSignature) || errors.Is(err, ErrInvalidSignature):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := fn(r.Context(), job); err != nil {
+			http.Error(w, "error handling callback", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aichain-lab/ai-gap-finder/gapfinder/types"
+)
+
+var secret = []byte("s3cret")
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"job_id":"j1"}`)
+	now := time.Now()
+	// While a secret is rotated, the service signs with both
+	_, current, _ := strings.Cut(Sign(secret, now, body), ",")
+	tests := []struct {
+		name   string
+		header string
+		body   []byte
+		want   error
+	}{
+		{"valid", Sign(secret, now, body), body, nil},
+		{"missing", "", body, ErrNoSignature},
+		{"tampered body", Sign(secret, now, body), []byte(`{"job_id":"j2"}`), ErrInvalidSignature},
+		{"other secret", Sign([]byte("other"), now, body), body, ErrInvalidSignature},
+		{"too old", Sign(secret, now.Add(-time.Hour), body), body, ErrInvalidSignature},
+		{"rotated secret", Sign([]byte("old"), now, body) + "," + current, body, nil},
+		{"malformed", "v1=zz", body, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(secret, tt.header, tt.body, 0); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var got *types.Job
+	h := Handler(secret, func(ctx context.Context, job *types.Job) error {
+		got = job
+		if job.Status == types.JobFailed {
+			return errors.New("storage down")
+		}
+		return nil
+	})
+	send := func(body, signature string) int {
+		req := httptest.NewRequest(http.MethodPost, "/callback", bytes.NewBufferString(body))
+		if signature != "" {
+			req.Header.Set(SignatureHeader, signature)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	body := `{"job_id":"j1","status":"succeeded","created_at":"2025-01-01T10:00:00Z","updated_at":"2025-01-01T10:01:00Z","result":null,"error":""}`
+	if code := send(body, Sign(secret, time.Now(), []byte(body))); code != http.StatusNoContent || got == nil || got.JobID != "j1" {
+		t.Errorf("valid callback: status %d, job %+v", code, got)
+	}
+	got = nil
+	if code := send(body, ""); code != http.StatusUnauthorized || got != nil {
+		t.Errorf("unsigned callback: status %d, job %+v", code, got)
+	}
+	if code := send("not json", Sign(secret, time.Now(), []byte("not json"))); code != http.StatusBadRequest || got != nil {
+		t.Errorf("undecodable callback: status %d, job %+v", code, got)
+	}
+	failed := `{"job_id":"j2","status":"failed","created_at":"2025-01-01T10:00:00Z","updated_at":"2025-01-01T10:01:00Z","result":null,"error":"e"}`
+	if code := send(failed, Sign(secret, time.Now(), []byte(failed))); code != http.StatusInternalServerError {
+		t.Errorf("callback fn failed: status %d, want 500 so that it is retried", code)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"https://example.com/hook", nil},
+		{"http://93.184.216.34:8080/hook", nil},
+		{"http://localhost:8080/hook", ErrPrivateAddress},
+		{"http://127.0.0.1/hook", ErrPrivateAddress},
+		{"http://10.1.2.3/hook", ErrPrivateAddress},
+		{"http://169.254.169.254/latest/meta-data", ErrPrivateAddress},
+		{"http://[::1]/hook", ErrPrivateAddress},
+		{"http://[::ffff:192.168.0.1]/hook", ErrPrivateAddress},
+	}
+	for _, tt := range tests {
+		if err := CheckURL(tt.url); !errors.Is(err, tt.want) {
+			t.Errorf("CheckURL(%q) = %v, want %v", tt.url, err, tt.want)
+		}
+	}
+	for _, u := range []string{"/hook", "ftp://example.com/hook", "file:///etc/passwd"} {
+		if err := CheckURL(u); err == nil || errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("CheckURL(%q) = %v, want an invalid URL", u, err)
+		}
+	}
+}
+
+func TestNewClientRefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("callback reached a loopback address")
+	}))
+	defer srv.Close()
+	// A host name that isn't an address is only checked as it is dialed
+	u := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	if _, err := NewClient(time.Second).Post(u, "application/json", nil); !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("Post() error = %v, want ErrPrivateAddress", err)
+	}
+}
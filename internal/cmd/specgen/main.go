@@ -0,0 +1,35 @@
+// Command specgen generates Go declarations from api/openapi.yaml. It is run
+// by go generate in gapfinder/types.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/aichain-lab/ai-gap-finder/api"
+	"github.com/aichain-lab/ai-gap-finder/internal/openapi"
+)
+
+func main() {
+	out := flag.String("out", "spec_gen.go", "output file")
+	pkg := flag.String("package", "types", "package name of the output file")
+	flag.Parse()
+
+	if err := run(*out, *pkg); err != nil {
+		fmt.Fprintf(os.Stderr, "specgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(out, pkg string) error {
+	spec, err := openapi.Load(api.OpenAPI)
+	if err != nil {
+		return err
+	}
+	src, err := spec.GenerateConstants(pkg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
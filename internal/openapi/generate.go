@@ -0,0 +1,127 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// enums are the string enums declared as Go constants, named Prefix+value.
+// Enums with a typ are declared as constants of that string type, and those
+// with a list also get a slice variable of every value.
+var enums = []struct {
+	schema, prefix, doc, typ, list, listDoc string
+}{
+	{"Field", "Field", "Research fields accepted by the service", "Field", "Fields", "Fields lists every research field accepted by the service"},
+	{"GapType", "Gap", "Kinds of research gap the analysis prompts ask for", "GapType", "GapTypes", "GapTypes lists every known kind of research gap"},
+	{"AnalysisMode", "Mode", "Parts of a paper that can be analyzed", "", "", ""},
+	{"SurveyHandling", "Surveys", "How a topic analysis treats survey and review papers", "", "", ""},
+	{"JobStatus", "Job", "Statuses of a background job", "", "", ""},
+	{"AnalysisKind", "Analysis", "Kinds of analysis the service keeps", "", "", ""},
+	{"ProgressStage", "Progress", "Stages of a paper in a topic analysis", "", "", ""},
+	{"ProgressMessageType", "Message", "Types of the messages sent on /topic/ws", "", "", ""},
+	{"ReviewSectionKind", "Section", "Kinds of section of a literature review draft", "", "", ""},
+	{"TrendStatus", "Trend", "Whether papers still raise a gap, in a trend analysis", "", "", ""},
+	{"NoveltyStatus", "Novelty", "Whether the literature already addresses an idea", "", "", ""},
+	{"ReviewAspect", "Aspect", "Aspects of a paper a simulated reviewer critiques", "", "", ""},
+	{"CritiqueSeverity", "Severity", "Whether a critique must be addressed before acceptance", "", "", ""},
+	{"ReviewRecommendation", "Recommend", "Decisions a simulated reviewer recommends", "", "", ""},
+}
+
+// limits are the request limits declared as Go constants, taken from the
+// named keyword (maximum, maxItems or maxLength) of a schema property
+var limits = []struct {
+	name, schema, property, keyword, doc string
+}{
+	{"MaxPapersLimit", "TopicRequest", "max_papers", "maximum", "MaxPapersLimit is the largest MaxPapers the service accepts"},
+	{"MaxPageSize", "TopicRequest", "page_size", "maximum", "MaxPageSize is the largest PageSize the service accepts"},
+	{"MaxBatchSize", "BatchAnalyzeRequest", "requests", "maxItems", "MaxBatchSize is the largest number of abstracts in a batch"},
+	{"MaxCrossFields", "TopicRequest", "cross_fields", "maxItems", "MaxCrossFields is the largest number of fields a topic is analyzed across"},
+	{"MaxDeduplicateAnalyses", "DeduplicateRequest", "analyses", "maxItems", "MaxDeduplicateAnalyses is the largest number of analyses whose gaps are\ndeduplicated together"},
+	{"MaxHypothesisGaps", "HypothesesRequest", "gaps", "maxItems", "MaxHypothesisGaps is the largest number of gaps hypotheses are generated\nfor in one call"},
+	{"MaxGroupGaps", "ResearchGroupsRequest", "common_gaps", "maxItems", "MaxGroupGaps is the largest number of gaps research groups are found for\nin one call"},
+	{"MaxFundingGaps", "FundingRequest", "gaps", "maxItems", "MaxFundingGaps is the largest number of gaps matched with funding calls in\none call"},
+	{"MaxMatchesLimit", "FundingRequest", "max_matches", "maximum", "MaxMatchesLimit is the largest MaxMatches the service accepts"},
+	{"MaxCitationsLimit", "CitationsRequest", "max_citations", "maximum", "MaxCitationsLimit is the largest MaxCitations the service accepts"},
+	{"MaxDatasetsLimit", "DatasetsRequest", "max_datasets", "maximum", "MaxDatasetsLimit is the largest MaxDatasets the service accepts"},
+	{"MaxRelatedPapersLimit", "RelatedWorkRequest", "max_papers", "maximum", "MaxRelatedPapersLimit is the largest MaxPapers of a RelatedWorkRequest the\nservice accepts"},
+	{"MaxYearsBack", "TrendsRequest", "years_back", "maximum", "MaxYearsBack is the largest YearsBack the service accepts"},
+	{"MaxTemperature", "AnalyzeRequest", "temperature", "maximum", "MaxTemperature is the largest Temperature the service accepts"},
+	{"MaxResultsLimit", "AnalyzeRequest", "max_gaps", "maximum", "MaxResultsLimit is the largest MaxGaps, MaxHypotheses or MaxFindings the\nservice accepts"},
+	{"MaxIdeaLength", "NoveltyRequest", "idea", "maxLength", "MaxIdeaLength is the largest number of characters of an idea description\nthe service accepts"},
+	{"MaxFeedbackLength", "RefineRequest", "feedback", "maxLength", "MaxFeedbackLength is the largest number of characters of refinement feedback\nthe service accepts"},
+	{"MaxProposalLength", "ProposalRequest", "proposal", "maxLength", "MaxProposalLength is the largest number of characters of a proposal the\nservice accepts"},
+	{"MaxProposalGaps", "ProposalRequest", "gaps", "maxItems", "MaxProposalGaps is the largest number of known gaps a proposal is scored\nagainst"},
+	{"MaxInstructionsLength", "AnalyzeRequest", "instructions", "maxLength", "MaxInstructionsLength is the largest number of characters of Instructions\nthe service accepts"},
+}
+
+// GenerateConstants returns the Go source of package pkg declaring the enums
+// and request limits from the spec, so they can't drift from what the service
+// accepts
+func (s *Spec) GenerateConstants(pkg string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by specgen from api/openapi.yaml; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	for _, e := range enums {
+		schema := s.Schema(e.schema)
+		if schema == nil || len(schema.Enum) == 0 {
+			return nil, fmt.Errorf("spec has no %s enum", e.schema)
+		}
+		if e.typ != "" {
+			fmt.Fprintf(&b, "// %s is one of the values of the %s enum\ntype %s string\n\n", e.typ, e.schema, e.typ)
+		}
+		fmt.Fprintf(&b, "// %s\nconst (\n", e.doc)
+		for _, v := range schema.Enum {
+			fmt.Fprintf(&b, "\t%s%s %s = %q\n", e.prefix, camelCase(v), e.typ, v)
+		}
+		b.WriteString(")\n\n")
+	}
+	for _, e := range enums {
+		if e.list == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "// %s\nvar %s = []%s{\n", e.listDoc, e.list, e.typ)
+		for _, v := range s.Schema(e.schema).Enum {
+			fmt.Fprintf(&b, "\t%s%s,\n", e.prefix, camelCase(v))
+		}
+		b.WriteString("}\n\n")
+	}
+	for _, l := range limits {
+		value, err := s.limit(l.schema, l.property, l.keyword)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "\n// %s\nconst %s = %g\n", strings.ReplaceAll(l.doc, "\n", "\n// "), l.name, value)
+	}
+	return format.Source(b.Bytes())
+}
+
+// limit returns the value of a numeric keyword of a schema property
+func (s *Spec) limit(schema, property, keyword string) (float64, error) {
+	var prop *Schema
+	if sch := s.Schema(schema); sch != nil {
+		prop = sch.Properties[property]
+	}
+	if prop != nil {
+		switch {
+		case keyword == "maximum" && prop.Maximum != nil:
+			return *prop.Maximum, nil
+		case keyword == "maxItems" && prop.MaxItems != nil:
+			return float64(*prop.MaxItems), nil
+		case keyword == "maxLength" && prop.MaxLength != nil:
+			return float64(*prop.MaxLength), nil
+		}
+	}
+	return 0, fmt.Errorf("spec has no %s for %s.%s", keyword, schema, property)
+}
+
+// camelCase turns "computer_science" into "ComputerScience"
+func camelCase(s string) string {
+	var b strings.Builder
+	for part := range strings.SplitSeq(s, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
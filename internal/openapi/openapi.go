@@ -0,0 +1,176 @@
+// Package openapi reads the service's OpenAPI document and checks Go types
+// against it. Only the parts of OpenAPI the spec actually uses are modelled.
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is an OpenAPI document
+type Spec struct {
+	Components struct {
+		Schemas map[string]*Schema `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+// Schema is an OpenAPI schema object
+type Schema struct {
+	Ref        string             `yaml:"$ref"`
+	Type       string             `yaml:"type"`
+	Format     string             `yaml:"format"`
+	Properties map[string]*Schema `yaml:"properties"`
+	Required   []string           `yaml:"required"`
+	Items      *Schema            `yaml:"items"`
+	AllOf      []*Schema          `yaml:"allOf"`
+	Enum       []string           `yaml:"enum"`
+	Nullable   bool               `yaml:"nullable"`
+	Minimum    *float64           `yaml:"minimum"`
+	Maximum    *float64           `yaml:"maximum"`
+	MaxItems   *int               `yaml:"maxItems"`
+	MaxLength  *int               `yaml:"maxLength"`
+}
+
+// Load parses an OpenAPI document
+func Load(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("error parsing OpenAPI document: %w", err)
+	}
+	return &spec, nil
+}
+
+// Schema returns the named component schema, or nil
+func (s *Spec) Schema(name string) *Schema {
+	return s.Components.Schemas[name]
+}
+
+// resolve follows a $ref to a component schema. A single allOf entry, used
+// to make a reference nullable, is followed too.
+func (s *Spec) resolve(schema *Schema) (*Schema, string) {
+	if len(schema.AllOf) == 1 {
+		schema = schema.AllOf[0]
+	}
+	if schema.Ref == "" {
+		return schema, ""
+	}
+	name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+	return s.Schema(name), name
+}
+
+// Check compares the JSON shape of the Go struct type t with the named
+// schema and describes every difference: properties missing on either side,
+// mismatched types, and required properties whose Go field is omitempty (or
+// the other way round). Nested structs are checked against the schemas they
+// reference.
+func (s *Spec) Check(name string, t reflect.Type) []string {
+	var problems []string
+	s.check(name, t, map[string]bool{}, &problems)
+	return problems
+}
+
+func (s *Spec) check(name string, t reflect.Type, seen map[string]bool, problems *[]string) {
+	if seen[name] {
+		return
+	}
+	seen[name] = true
+
+	schema := s.Schema(name)
+	if schema == nil {
+		*problems = append(*problems, fmt.Sprintf("%s: no schema in spec", name))
+		return
+	}
+	fields := jsonFields(t)
+	for prop := range schema.Properties {
+		if _, ok := fields[prop]; !ok {
+			*problems = append(*problems, fmt.Sprintf("%s.%s: in spec but not in Go type %s", name, prop, t.Name()))
+		}
+	}
+	for prop, f := range fields {
+		propSchema, ok := schema.Properties[prop]
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s.%s: field %s not in spec", name, prop, f.Name))
+			continue
+		}
+		required := slices.Contains(schema.Required, prop)
+		if omitempty := strings.Contains(f.Tag.Get("json"), ",omitempty"); required == omitempty {
+			*problems = append(*problems, fmt.Sprintf("%s.%s: required is %v in spec but omitempty is %v in Go", name, prop, required, omitempty))
+		}
+		s.checkType(name+"."+prop, propSchema, f.Type, seen, problems)
+	}
+}
+
+func (s *Spec) checkType(path string, schema *Schema, t reflect.Type, seen map[string]bool, problems *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	resolved, refName := s.resolve(schema)
+	if resolved == nil {
+		*problems = append(*problems, fmt.Sprintf("%s: unresolved reference %s", path, schema.Ref))
+		return
+	}
+
+	mismatch := func() {
+		*problems = append(*problems, fmt.Sprintf("%s: spec type %s does not match Go type %s", path, resolved.Type, t))
+	}
+	switch resolved.Type {
+	case "string":
+		if resolved.Format == "date-time" {
+			if t != reflect.TypeFor[time.Time]() {
+				mismatch()
+			}
+		} else if t.Kind() != reflect.String {
+			mismatch()
+		}
+	case "integer":
+		if !slices.Contains([]reflect.Kind{reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64}, t.Kind()) {
+			mismatch()
+		}
+	case "number":
+		if t.Kind() != reflect.Float64 && t.Kind() != reflect.Float32 {
+			mismatch()
+		}
+	case "boolean":
+		if t.Kind() != reflect.Bool {
+			mismatch()
+		}
+	case "array":
+		if t.Kind() != reflect.Slice || resolved.Items == nil {
+			mismatch()
+			return
+		}
+		s.checkType(path+"[]", resolved.Items, t.Elem(), seen, problems)
+	case "object":
+		if t.Kind() != reflect.Struct {
+			mismatch()
+			return
+		}
+		if refName != "" {
+			s.check(refName, t, seen, problems)
+		}
+	default:
+		*problems = append(*problems, fmt.Sprintf("%s: unsupported spec type %q", path, resolved.Type))
+	}
+}
+
+// jsonFields maps the JSON names of t's exported fields to the fields
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
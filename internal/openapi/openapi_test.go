@@ -0,0 +1,63 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSpec = `
+components:
+  schemas:
+    Paper:
+      type: object
+      required: [title, scores]
+      properties:
+        title: {type: string}
+        year: {type: integer}
+        scores: {type: array, items: {type: number}}
+        author: {$ref: '#/components/schemas/Author'}
+    Author:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+`
+
+func TestCheck(t *testing.T) {
+	spec, err := Load([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type author struct {
+		Name string `json:"name"`
+	}
+	type paper struct {
+		Title  string    `json:"title"`
+		Year   int       `json:"year,omitempty"`
+		Scores []float64 `json:"scores"`
+		Author *author   `json:"author,omitempty"`
+		ID     string    `json:"-"`
+	}
+	if problems := spec.Check("Paper", reflect.TypeOf(paper{})); len(problems) > 0 {
+		t.Errorf("Check() = %q, want no problems", problems)
+	}
+
+	type drifted struct {
+		Title  int      `json:"title"`
+		Scores []string `json:"scores,omitempty"`
+		Venue  string   `json:"venue"`
+	}
+	problems := strings.Join(spec.Check("Paper", reflect.TypeOf(drifted{})), "\n")
+	for _, want := range []string{"Paper.title: spec type string", "Paper.scores[]", "Paper.scores: required", "Paper.venue: field Venue not in spec", "Paper.year: in spec"} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("Check() problems missing %q:\n%s", want, problems)
+		}
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	if got := camelCase("computer_science"); got != "ComputerScience" {
+		t.Errorf("camelCase() = %q, want %q", got, "ComputerScience")
+	}
+}
@@ -0,0 +1,17 @@
+package uuid
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, b := New(), New()
+	if !pattern.MatchString(a) {
+		t.Errorf("New() = %q, want a version 4 UUID", a)
+	}
+	if a == b {
+		t.Errorf("New() returned %q twice", a)
+	}
+}
@@ -1,8 +1,14 @@
 """Comprehensive tests for API endpoints"""
 
+import json
+import time
 import pytest
-from unittest.mock import patch, Mock
+from pathlib import Path
+from unittest.mock import patch, Mock, AsyncMock
 from fastapi.testclient import TestClient
+from app.schema.models import FieldEnum
+from app.api.app import create_app
+from app.utils.usage import UsageTracker
 
 
 class TestAnalyzeEndpoint:
@@ -178,6 +184,78 @@ class TestTopicEndpoint:
         response = client.post("/topic", json=sample_topic_request)
         assert response.status_code == 500
 
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_topic_cross_fields(self, mock_fetch, mock_llm, client):
+        """Test that papers of each field are analyzed for gaps at their intersection"""
+        mock_fetch.side_effect = lambda query, max_results: [
+            {"title": "Shared", "url": "http://arxiv.org/abs/1"}, {"title": query}
+        ]
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={
+            "common_gaps": [], "individual_results": [], "suggested_research_directions": [],
+            "intersection_gaps": [
+                {"gap_description": "Weak", "confidence_score": 0.2, "gap_type": "conceptual", "potential_impact": "i"},
+                {"gap_description": "Spiking gene circuits", "confidence_score": 0.8, "gap_type": "conceptual", "potential_impact": "i"}
+            ]
+        })
+
+        response = client.post("/topic", json={
+            "topic": "oscillations", "max_papers": 5, "min_confidence": 0.5,
+            "cross_fields": ["neuroscience", "computer_science"]
+        })
+
+        assert response.status_code == 200
+        data = response.json()
+        assert [g["gap_description"] for g in data["intersection_gaps"]] == ["Spiking gene circuits"]
+        assert data["papers_analyzed"] == 3
+        assert [c.args for c in mock_fetch.call_args_list] == [
+            ("oscillations neuroscience",), ("oscillations computer science",)
+        ]
+        assert [c.kwargs["max_results"] for c in mock_fetch.call_args_list] == [3, 2]
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "across the fields of neuroscience, computer science" in prompt
+        assert '"intersection_gaps"' in prompt
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_topic_surveys(self, mock_fetch, mock_llm, client):
+        """Test that survey papers can be left out or kept in the background"""
+        mock_fetch.return_value = [
+            {"title": "Deep Learning for Sleep Staging: A Survey"},
+            {"title": "Spindle detection with transformers"},
+            {"title": "Sleep staging in children", "abstract": "This review covers a decade of work."},
+            {"title": "Wearable EEG staging"}
+        ]
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={
+            "common_gaps": [], "individual_results": [], "suggested_research_directions": []
+        })
+
+        response = client.post("/topic", json={"topic": "sleep staging", "max_papers": 2, "surveys": "exclude"})
+
+        assert response.status_code == 200
+        assert response.json()["papers_analyzed"] == 2
+        assert mock_fetch.call_args.kwargs["max_results"] == 4
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "Survey" not in prompt and "Wearable EEG staging" in prompt
+
+        response = client.post("/topic", json={"topic": "sleep staging", "max_papers": 4, "surveys": "downweight"})
+
+        assert response.status_code == 200
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "Paper 1 (survey):" in prompt and "Paper 2:" in prompt and "Paper 3 (survey):" in prompt
+        assert "Use them as background only" in prompt
+
+    def test_topic_unknown_surveys(self, client):
+        """Test that surveys must be a known handling"""
+        response = client.post("/topic", json={"topic": "sleep staging", "surveys": "ignore"})
+        assert response.status_code == 422
+
+    @pytest.mark.parametrize("cross_fields", [["biology"], ["biology", "biology"], ["biology", "alchemy"]])
+    def test_topic_invalid_cross_fields(self, client, cross_fields):
+        """Test that cross fields must be 2 or more distinct known fields"""
+        response = client.post("/topic", json={"topic": "oscillations", "cross_fields": cross_fields})
+        assert response.status_code == 422
+
 
 class TestHealthEndpoint:
     """Test the /health endpoint"""
@@ -201,6 +279,180 @@ class TestHealthEndpoint:
         data = response.json()
         assert isinstance(data["version"], str)
         assert len(data["version"]) > 0
+    
+    def test_health_endpoint_echoes_request_id(self, client):
+        """Test that the caller's X-Request-ID is returned"""
+        response = client.get("/health", headers={"X-Request-ID": "test-request-id"})
+        
+        assert response.status_code == 200
+        assert response.headers["X-Request-ID"] == "test-request-id"
+    
+    def test_health_endpoint_generates_request_id(self, client):
+        """Test that a request ID is generated when none is sent"""
+        response = client.get("/health")
+        
+        assert response.status_code == 200
+        assert len(response.headers["X-Request-ID"]) > 0
+
+
+class TestFieldsEndpoint:
+    """Test the /fields endpoint"""
+
+    def test_fields(self, client):
+        """Test that every research field is listed"""
+        response = client.get("/fields")
+        assert response.status_code == 200
+        fields = response.json()["fields"]
+        assert "medicine" in fields
+        assert len(fields) == len(FieldEnum)
+
+
+class TestEstimateEndpoint:
+    """Test the /topic/estimate and /analyze/estimate endpoints"""
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_estimates_without_llm_call(self, mock_fetch, mock_llm, client):
+        """Test that the papers' prompt is measured and priced without analyzing them"""
+        mock_fetch.return_value = [
+            {"title": "Paper 1", "abstract": "word " * 200, "authors": ["A"], "url": "http://arxiv.org/abs/1"},
+            {"title": "Paper 2", "abstract": "Abstract 2", "authors": ["B"], "url": "http://arxiv.org/abs/2"}
+        ]
+        mock_llm.analyze_with_prompt = AsyncMock()
+
+        response = client.post("/topic/estimate", json={"topic": "sleep staging", "max_papers": 2})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert data["papers"] == 2
+        assert data["llm_calls"] == 1
+        assert data["input_tokens"] > 250
+        assert data["output_tokens"] == 700
+        assert data["model"] == "gpt-4"
+        assert data["cost_usd"] == round((data["input_tokens"] * 30 + 700 * 60) / 1e6, 4)
+        assert data["duration_seconds"] >= 17.5
+        mock_llm.analyze_with_prompt.assert_not_called()
+
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_no_papers(self, mock_fetch, client):
+        """Test that a topic without papers costs nothing"""
+        mock_fetch.return_value = []
+
+        response = client.post("/topic/estimate", json={"topic": "nothing"})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert data["papers"] == 0
+        assert data["llm_calls"] == 0
+        assert data["cost_usd"] is None
+
+    @patch('app.service.analysis.llm_service')
+    def test_estimates_abstract(self, mock_llm, client):
+        """Test that /analyze/estimate prices an abstract's prompt without analyzing it"""
+        mock_llm.analyze_with_prompt = AsyncMock()
+
+        response = client.post("/analyze/estimate", json={"title": "Sleep", "abstract": "word " * 200})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert data["papers"] == 1
+        assert data["llm_calls"] == 1
+        assert data["input_tokens"] > 250
+        assert data["output_tokens"] == 550
+        assert data["duration_seconds"] == 13.75
+        mock_llm.analyze_with_prompt.assert_not_called()
+
+
+class TestUsageEndpoint:
+    """Test the /usage endpoint and quotas"""
+
+    def test_counts_requests(self, client):
+        """Test that the caller's requests are reported by endpoint"""
+        with patch('app.utils.usage.usage_tracker', UsageTracker(keys=["k1", "k2"])):
+            client.get("/fields", headers={"X-API-Key": "k1"})
+            client.get("/jobs/missing", headers={"X-API-Key": "k1"})
+            client.get("/health", headers={"X-API-Key": "k1"})
+            response = client.get("/usage", headers={"X-API-Key": "k1"})
+            other = client.get("/usage", headers={"X-API-Key": "k2"})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert data["requests"] == 2
+        assert {e["endpoint"] for e in data["endpoints"]} == {"GET /fields", "GET /jobs/{job_id}"}
+        assert data.get("quota") is None
+        assert other.json()["requests"] == 0
+
+    def test_quota_exceeded(self, client):
+        """Test that requests beyond the quota are refused with Retry-After"""
+        with patch('app.utils.usage.usage_tracker', UsageTracker(limit=2, window=3600)):
+            for _ in range(2):
+                assert client.get("/fields").status_code == 200
+            refused = client.get("/models")
+            usage = client.get("/usage").json()
+
+        assert refused.status_code == 429
+        assert 0 < int(refused.headers["Retry-After"]) <= 3600
+        assert refused.headers["X-Request-ID"]
+        assert usage["requests"] == 2
+        assert usage["quota"]["limit"] == 2
+        assert usage["quota"]["remaining"] == 0
+
+
+class TestJobCallbacks:
+    """Test callbacks of /topic/jobs"""
+
+    def test_callbacks_disabled(self, client):
+        """Test that callbacks are refused without a webhook secret"""
+        response = client.post(
+            "/topic/jobs", json={"topic": "sleep"}, headers={"Callback-URL": "https://example.com/hook"}
+        )
+
+        assert response.status_code == 422
+        assert response.json()["detail"][0]["loc"] == ["header", "callback-url"]
+
+    def test_relative_callback_url(self, client, mock_settings):
+        """Test that callback URLs must be absolute"""
+        mock_settings.webhook_secret = "s3cret"
+        with patch('app.api.app.get_settings', return_value=mock_settings):
+            app = create_app()
+        response = TestClient(app).post(
+            "/topic/jobs", json={"topic": "sleep"}, headers={"Callback-URL": "/hook"}
+        )
+
+        assert response.status_code == 422
+        assert "absolute" in response.json()["detail"][0]["msg"]
+
+
+class TestModelSelection:
+    """Test choosing the model of an analysis"""
+
+    def test_models(self, client):
+        """Test that the default model is listed first"""
+        response = client.get("/models")
+        assert response.status_code == 200
+        data = response.json()
+        assert data["models"][0] == data["default_model"]
+
+    def test_unknown_model(self, client):
+        """Test that a model the service doesn't offer is rejected"""
+        response = client.post("/analyze", json={"title": "T", "abstract": "A", "model": "no-such-model"})
+        assert response.status_code == 422
+
+    def test_temperature_out_of_range(self, client):
+        """Test that the temperature is limited"""
+        response = client.post("/topic", json={"topic": "sleep", "temperature": 3})
+        assert response.status_code == 422
+
+    @patch('app.service.analysis.llm_service')
+    def test_model_reaches_llm(self, mock_llm, client):
+        """Test that the model and temperature are passed to the LLM"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value=dict(TestPDFEndpoint.ANALYSIS))
+        model = client.get("/models").json()["default_model"]
+
+        response = client.post("/analyze", json={"title": "T", "abstract": "A", "model": model, "temperature": 0.2})
+
+        assert response.status_code == 200
+        assert mock_llm.analyze_with_prompt.call_args.args[1:] == (model, 0.2)
 
 
 class TestAPIErrorHandling:
@@ -323,3 +575,1187 @@ class TestRequestValidation:
             
             response = client.post("/analyze", json=unicode_request)
             assert response.status_code == 200
+
+
+class TestFullTextMode:
+    """Test analysis of a paper's full text"""
+
+    def test_full_text_required(self, client):
+        """Test that full_text mode needs the full text"""
+        response = client.post("/analyze", json={"title": "T", "abstract": "A", "mode": "full_text"})
+        assert response.status_code == 422
+
+    @patch('app.service.analysis.llm_service')
+    def test_prompt_holds_analyzed_sections(self, mock_llm, client):
+        """Test that the methods and results reach the prompt, but not the other sections"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value=dict(TestPDFEndpoint.ANALYSIS))
+        full_text = (
+            "1. Introduction\nSleep matters.\n2. Methods\nWe recorded 12 mice.\n"
+            "3. Results\nMemory improved.\nReferences\n[1] Someone"
+        )
+
+        response = client.post("/analyze", json={
+            "title": "Sleep", "abstract": "We study sleep.", "full_text": full_text, "mode": "full_text"
+        })
+
+        assert response.status_code == 200
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "We recorded 12 mice." in prompt
+        assert "Memory improved." in prompt
+        assert "Sleep matters." not in prompt
+        assert "[1] Someone" not in prompt
+
+    @patch('app.service.analysis.llm_service')
+    def test_prompt_names_language(self, mock_llm, client):
+        """Test that the input language and the wanted output language reach the prompt"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value=dict(TestPDFEndpoint.ANALYSIS))
+
+        response = client.post("/analyze", json={
+            "title": "Schlaf", "abstract": "Wir untersuchen Schlaf.", "language": "de", "translate_output": True
+        })
+
+        assert response.status_code == 200
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert 'ISO 639-1 code "de"' in prompt
+        assert "Write the analysis in that language" in prompt
+
+    def test_translation_requires_language(self, client):
+        """Test that translate_output needs a language"""
+        response = client.post("/analyze", json={"title": "T", "abstract": "A", "translate_output": True})
+        assert response.status_code == 422
+
+    @patch('app.service.analysis.llm_service')
+    def test_prompt_holds_instructions(self, mock_llm, client):
+        """Test that instructions steering the analysis reach the prompt"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value=dict(TestPDFEndpoint.ANALYSIS))
+
+        response = client.post("/analyze", json={
+            "title": "Sleep", "abstract": "We study sleep.", "instructions": "Ignore funding limitations."
+        })
+
+        assert response.status_code == 200
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "Additional instructions from the researcher:\nIgnore funding limitations." in prompt
+
+    def test_instructions_too_long(self, client):
+        """Test that instructions are bounded"""
+        response = client.post("/analyze", json={"title": "T", "abstract": "A", "instructions": "x" * 1001})
+        assert response.status_code == 422
+
+    def test_sections_fall_back_to_text(self):
+        """Test that text without known headings is kept whole"""
+        from app.service.analysis import select_sections
+        assert select_sections("Just some text", limit=4) == "Just"
+
+
+class TestResultLimits:
+    """Test bounding the gaps of a response by confidence and number"""
+
+    GAPS = [
+        {"gap_description": "Weak", "confidence_score": 0.3, "gap_type": "empirical", "potential_impact": "Low"},
+        {"gap_description": "Strong", "confidence_score": 0.9, "gap_type": "empirical", "potential_impact": "High"}
+    ]
+
+    @patch('app.service.analysis.llm_service')
+    def test_weak_gaps_left_out(self, mock_llm, client):
+        """Test that only gaps from min_confidence are returned"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value=dict(TestPDFEndpoint.ANALYSIS, gaps=list(self.GAPS)))
+
+        response = client.post("/analyze", json={"title": "T", "abstract": "A", "min_confidence": 0.5})
+
+        assert response.status_code == 200
+        assert [g["gap_description"] for g in response.json()["gaps"]] == ["Strong"]
+
+    @patch('app.service.analysis.llm_service')
+    def test_result_limits(self, mock_llm, client):
+        """Test that max_gaps and max_findings bound the response"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value=dict(
+            TestPDFEndpoint.ANALYSIS, gaps=list(self.GAPS), key_findings=["a", "b", "c"]
+        ))
+
+        response = client.post("/analyze", json={"title": "T", "abstract": "A", "max_gaps": 1, "max_findings": 2})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert [g["gap_description"] for g in data["gaps"]] == ["Weak"]
+        assert data["key_findings"] == ["a", "b"]
+
+    def test_min_confidence_out_of_range(self, client):
+        """Test that min_confidence is a confidence score"""
+        response = client.post("/topic", json={"topic": "sleep", "min_confidence": 1.5})
+        assert response.status_code == 422
+
+
+class TestCompareEndpoint:
+    """Test the /compare endpoint"""
+
+    REQUEST = {
+        "paper_a": {"title": "Sleep helps memory", "abstract": "Sleep improves recall.", "field": "neuroscience"},
+        "paper_b": {"title": "Sleep and recall", "abstract": "Sleep has no effect on recall.", "field": "neuroscience"}
+    }
+
+    @patch('app.service.analysis.llm_service')
+    def test_comparison(self, mock_llm, client):
+        """Test that both papers reach the prompt and the comparison is returned"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={
+            "overlapping_findings": ["Sleep matters"],
+            "conflicting_conclusions": [
+                {"topic": "Recall", "paper_a_position": "Improves", "paper_b_position": "No effect"}
+            ],
+            "gaps_unique_to_a": [
+                {"gap_description": "Small sample", "confidence_score": 0.8, "gap_type": "empirical", "potential_impact": "High"}
+            ]
+        })
+
+        response = client.post("/compare", json=self.REQUEST)
+
+        assert response.status_code == 200
+        data = response.json()
+        assert data["conflicting_conclusions"][0]["topic"] == "Recall"
+        assert data["gaps_unique_to_b"] == []
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "Sleep improves recall." in prompt
+        assert "Sleep has no effect on recall." in prompt
+        assert "field of neuroscience" in prompt
+
+    def test_invalid_paper(self, client):
+        """Test that both papers are validated"""
+        request = dict(self.REQUEST, paper_b={"title": "", "abstract": "A"})
+        response = client.post("/compare", json=request)
+        assert response.status_code == 422
+
+
+class TestPeerReviewEndpoint:
+    """Test the /analyze/peer-review endpoint"""
+
+    ANALYSIS = {
+        "key_findings": ["Sleep improves recall"],
+        "gaps": [{"gap_description": "Small sample", "confidence_score": 0.9, "gap_type": "empirical", "potential_impact": "i"}],
+        "limitations": ["Only young adults"], "methodology_gaps": [], "suggested_hypotheses": [], "future_directions": []
+    }
+
+    @patch('app.service.analysis.llm_service')
+    def test_peer_review(self, mock_llm, client):
+        """Test that the review builds on the analysis and drops unknown critiques"""
+        mock_llm.analyze_with_prompt = AsyncMock(side_effect=[self.ANALYSIS, {
+            "summary": "A small study.", "recommendation": "maybe",
+            "critiques": [
+                {"aspect": "clarity", "severity": "minor", "comment": "Define recall"},
+                {"aspect": "style", "severity": "major", "comment": "Unknown aspect"},
+                {"aspect": "soundness", "severity": "major", "comment": "Twelve participants"},
+                {"aspect": "novelty", "severity": "", "comment": " "}
+            ]
+        }])
+
+        response = client.post("/analyze/peer-review", json={
+            "title": "Sleep and memory", "abstract": "We studied sleep.", "field": "neuroscience"
+        })
+
+        assert response.status_code == 200
+        data = response.json()
+        assert data["recommendation"] == "major_revision"
+        assert [c["aspect"] for c in data["critiques"]] == ["soundness", "clarity"]
+        assert data["missing_baselines"] == []
+        assert data["gaps"][0]["gap_description"] == "Small sample"
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "Limitations: Only young adults" in prompt
+        assert "- Research gap (empirical): Small sample" in prompt
+
+    def test_invalid_request(self, client):
+        """Test that the paper is validated like /analyze"""
+        response = client.post("/analyze/peer-review", json={"title": "Sleep and memory", "abstract": ""})
+        assert response.status_code == 422
+
+
+class TestRefineEndpoint:
+    """Test the /analyze/refine endpoint"""
+
+    PREVIOUS = {
+        "key_findings": ["Sleep improves recall"],
+        "gaps": [
+            {"gap_description": "Small sample", "confidence_score": 0.9, "gap_type": "empirical", "potential_impact": "i"},
+            {"gap_description": "No funding", "confidence_score": 0.3, "gap_type": "technical", "potential_impact": "none"}
+        ],
+        "suggested_hypotheses": [], "limitations": [], "methodology_gaps": [], "future_directions": [],
+        "processing_time": 1.5,
+        "paper": {"source": "crossref", "id": "10.1/x", "title": "Sleep and memory", "authors": ["Ann"], "abstract": "We studied sleep."}
+    }
+
+    @patch('app.service.analysis.llm_service')
+    def test_refine(self, mock_llm, client):
+        """Test that the analysis is updated following the feedback"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={
+            "key_findings": ["Sleep improves recall"],
+            "gaps": [{"gap_description": "Small sample of young adults", "confidence_score": 0.9, "gap_type": "empirical", "potential_impact": "i"}]
+        })
+
+        response = client.post("/analyze/refine", json={
+            "previous": self.PREVIOUS, "feedback": "Gap 2 is irrelevant; expand on the sample"
+        })
+
+        assert response.status_code == 200
+        data = response.json()
+        assert [g["gap_description"] for g in data["gaps"]] == ["Small sample of young adults"]
+        assert data["future_directions"] == []
+        assert data["paper"]["id"] == "10.1/x"
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "Abstract: We studied sleep." in prompt
+        assert "2. No funding (technical, confidence 0.3): none" in prompt
+        assert "feedback:\nGap 2 is irrelevant" in prompt
+
+    def test_empty_feedback(self, client):
+        """Test that feedback is required"""
+        response = client.post("/analyze/refine", json={"previous": self.PREVIOUS, "feedback": " "})
+        assert response.status_code == 422
+
+    def test_empty_previous(self, client):
+        """Test that the previous analysis must hold something to refine"""
+        previous = dict(self.PREVIOUS, key_findings=[], gaps=[])
+        response = client.post("/analyze/refine", json={"previous": previous, "feedback": "More gaps"})
+        assert response.status_code == 422
+
+
+class TestDeduplicateEndpoint:
+    """Test the /gaps/deduplicate endpoint"""
+
+    @staticmethod
+    def gap(description, confidence):
+        return {"gap_description": description, "confidence_score": confidence,
+                "gap_type": "empirical", "potential_impact": "High"}
+
+    @patch('app.service.analysis.llm_service')
+    def test_deduplication(self, mock_llm, client):
+        """Test that every gap ends up in exactly one cluster"""
+        request = {"analyses": [
+            [self.gap("Small sample", 0.6), self.gap("No replication", 0.7)],
+            [self.gap("Few participants", 0.9)]
+        ]}
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={"clusters": [
+            {
+                "gap": self.gap("Samples are small", 0.5),
+                "members": [{"analysis": 0, "gap": 0}, {"analysis": 1, "gap": 0}, {"analysis": 4, "gap": 0}]
+            },
+            {"gap": self.gap("Repeated", 0.5), "members": [{"analysis": 0, "gap": 0}]}
+        ]})
+
+        response = client.post("/gaps/deduplicate", json=request)
+
+        assert response.status_code == 200
+        clusters = response.json()["clusters"]
+        assert len(clusters) == 2
+        assert clusters[0]["gap"]["gap_description"] == "Samples are small"
+        assert clusters[0]["gap"]["confidence_score"] == 0.9
+        assert clusters[0]["members"] == [{"analysis": 0, "gap": 0}, {"analysis": 1, "gap": 0}]
+        assert clusters[1]["gap"]["gap_description"] == "No replication"
+        assert clusters[1]["members"] == [{"analysis": 0, "gap": 1}]
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "[1.0] (empirical) Few participants" in prompt
+
+    # Shared with the Go service's tests, see gapfinder/fixtures
+    FIXTURES = sorted((Path(__file__).parent.parent / "gapfinder/fixtures/data/deduplicate").glob("*.json"))
+
+    @pytest.mark.parametrize("path", FIXTURES, ids=lambda p: p.stem)
+    @patch('app.service.analysis.llm_service')
+    def test_shared_fixtures(self, mock_llm, client, path):
+        """Test that the service clusters the shared fixtures like the Go service"""
+        fixture = json.loads(path.read_text())
+        mock_llm.analyze_with_prompt = AsyncMock(return_value=fixture["reply"])
+
+        response = client.post("/gaps/deduplicate", json=fixture["request"])
+
+        assert response.status_code == 200
+        clusters = response.json()["clusters"]
+        assert len(clusters) == len(fixture["clusters"])
+        for got, want in zip(clusters, fixture["clusters"]):
+            assert got["members"] == want["members"]
+            assert {k: got["gap"][k] for k in want["gap"]} == want["gap"]
+
+    def test_no_gaps(self, client):
+        """Test that a request without gaps is rejected"""
+        response = client.post("/gaps/deduplicate", json={"analyses": [[], []]})
+        assert response.status_code == 422
+
+
+class TestTrendsEndpoint:
+    """Test the /topic/trends endpoint"""
+
+    @staticmethod
+    def paper(title, year):
+        return {"title": title, "authors": [], "abstract": "Abstract", "published": f"{year}-03-01T00:00:00Z"}
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_trends(self, mock_fetch, mock_llm, client):
+        """Test that gaps are followed over the years of the papers raising them"""
+        year = time.gmtime().tm_year
+        mock_fetch.return_value = [
+            self.paper("Recent", year), self.paper("Old", year - 2), {"title": "Undated"},
+            self.paper("Too old", year - 3), self.paper("Middle", year - 1)
+        ]
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={"gaps": [
+            {"gap_description": "Small samples", "gap_type": "empirical", "papers": [1, 2]},
+            {"gap_description": "No replication", "gap_type": "Methodological", "papers": [3, 1, 1]},
+            {"gap_description": "Noisy sensors", "gap_type": "data", "papers": [3]},
+            {"gap_description": "Unknown paper", "gap_type": "data", "papers": [9]}
+        ]})
+
+        response = client.post("/topic/trends", json={"topic": "sleep", "years_back": 3})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert (data["from_year"], data["to_year"], data["papers_analyzed"]) == (year - 2, year, 3)
+        assert [(g["gap_type"], g["years"], g["status"]) for g in data["gaps"]] == [
+            ("empirical", [year - 2, year - 1], "closing"),
+            ("methodological", [year - 2, year], "persistent"),
+            ("data", [year], "emerging")
+        ]
+        assert data["years"][2] == {"year": year, "paper_count": 1, "gap_types": [
+            {"gap_type": "data", "count": 1}, {"gap_type": "methodological", "count": 1}
+        ]}
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert f"[1] {year - 2}: Old" in prompt
+        assert "Too old" not in prompt
+        assert mock_fetch.call_args.kwargs["years"] == (year - 2, year)
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_no_papers(self, mock_fetch, mock_llm, client):
+        """Test that every covered year is reported without papers"""
+        mock_fetch.return_value = []
+        mock_llm.analyze_with_prompt = AsyncMock()
+
+        response = client.post("/topic/trends", json={"topic": "sleep"})
+
+        assert response.status_code == 200
+        assert len(response.json()["years"]) == 5
+        assert response.json()["gaps"] == []
+        mock_llm.analyze_with_prompt.assert_not_called()
+
+    def test_too_many_years(self, client):
+        """Test that years_back is limited"""
+        response = client.post("/topic/trends", json={"topic": "sleep", "years_back": 21})
+        assert response.status_code == 422
+
+
+class TestCitationsEndpoint:
+    """Test the /gaps/citations endpoint"""
+
+    GAP = {"gap_description": "Small samples in sleep studies", "confidence_score": 0.8,
+           "gap_type": "empirical", "potential_impact": "High"}
+
+    PAPERS = [
+        {"title": "Sleep helps memory", "authors": ["A. Author"], "abstract": "Sleep improves recall.",
+         "url": "http://arxiv.org/abs/1"},
+        {"title": "Sleep and recall", "authors": [], "abstract": "No effect.", "url": "http://arxiv.org/abs/2"}
+    ]
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_citations(self, mock_fetch, mock_llm, client):
+        """Test that citations are drawn from the papers found for the gap"""
+        mock_fetch.return_value = self.PAPERS
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={"citations": [
+            {"paper": 2, "reason": "Reports a null result"},
+            {"paper": 9, "reason": "Does not exist"},
+            {"paper": 2, "reason": "Repeated"}
+        ]})
+
+        response = client.post("/gaps/citations", json={"gap": self.GAP, "max_citations": 3})
+
+        assert response.status_code == 200
+        assert response.json()["citations"] == [{
+            "title": "Sleep and recall", "authors": [], "url": "http://arxiv.org/abs/2",
+            "reason": "Reports a null result"
+        }]
+        mock_fetch.assert_called_once_with("Small samples in sleep studies", 6)
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "[1] Sleep helps memory" in prompt
+        assert "up to 3" in prompt
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_no_candidates(self, mock_fetch, mock_llm, client):
+        """Test that no citations are suggested without candidate papers"""
+        mock_fetch.return_value = []
+        mock_llm.analyze_with_prompt = AsyncMock()
+
+        response = client.post("/gaps/citations", json={"gap": self.GAP})
+
+        assert response.status_code == 200
+        assert response.json()["citations"] == []
+        mock_llm.analyze_with_prompt.assert_not_called()
+
+    def test_too_many_citations(self, client):
+        """Test that max_citations is limited"""
+        response = client.post("/gaps/citations", json={"gap": self.GAP, "max_citations": 11})
+        assert response.status_code == 422
+
+
+class TestNoveltyEndpoint:
+    """Test the /ideas/novelty endpoint"""
+
+    PAPERS = [
+        {"title": "Spindles and memory", "authors": ["Ann"], "abstract": "Spindles predict recall.",
+         "url": "http://arxiv.org/abs/1", "published": "2024-03-01T00:00:00Z"},
+        {"title": "Sleep in children", "authors": [], "abstract": "Children sleep more."}
+    ]
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_novelty(self, mock_fetch, mock_llm, client):
+        """Test that a made-up status is derived from the score and invented papers are dropped"""
+        mock_fetch.return_value = self.PAPERS
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={
+            "status": "mostly new", "novelty_score": 0.5, "summary": "Adults only.",
+            "papers": [{"paper": 1, "reason": "Links spindles to recall"}, {"paper": 1, "reason": "Repeated"},
+                       {"paper": 7, "reason": "Does not exist"}]
+        })
+
+        response = client.post("/ideas/novelty", json={"idea": "Spindles predict recall in children"})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert data["status"] == "partially_addressed"
+        assert data["papers_searched"] == 2
+        assert [p["title"] for p in data["supporting_papers"]] == ["Spindles and memory"]
+        mock_fetch.assert_called_once_with("Spindles predict recall in children", 10)
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "[1] Spindles and memory (2024)" in prompt
+        assert "[2] Sleep in children\n" in prompt
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_no_papers(self, mock_fetch, mock_llm, client):
+        """Test that an idea without related papers is open"""
+        mock_fetch.return_value = []
+        mock_llm.analyze_with_prompt = AsyncMock()
+
+        response = client.post("/ideas/novelty", json={"idea": "Spindles predict recall"})
+
+        assert response.status_code == 200
+        assert response.json()["status"] == "open"
+        mock_llm.analyze_with_prompt.assert_not_called()
+
+    def test_empty_idea(self, client):
+        """Test that the idea must not be empty"""
+        response = client.post("/ideas/novelty", json={"idea": " "})
+        assert response.status_code == 422
+
+
+class TestProposalEndpoint:
+    """Test the /ideas/proposal endpoint"""
+
+    GAP = {"gap_description": "Small samples", "confidence_score": 0.8,
+           "gap_type": "empirical", "potential_impact": "High"}
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_proposal(self, mock_fetch, mock_llm, client):
+        """Test that conflicts only cite papers the search found"""
+        mock_fetch.return_value = [{"title": "Spindles in children", "authors": ["Ann"], "abstract": "",
+                                    "published": "2024-03-01T00:00:00Z"}]
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={
+            "alignment_score": 1.4,
+            "gaps": [{"gap_description": "Small samples", "coverage": 0.8, "comment": "Recruits 500 children"},
+                     {"gap_description": " ", "coverage": 1, "comment": "Blank"}],
+            "novelty_conflicts": [
+                {"claim": "First study of spindles in children", "papers": [
+                    {"paper": 1, "reason": "Studied children in 2024"}, {"paper": 1, "reason": "Repeated"},
+                    {"paper": 8, "reason": "Does not exist"}]},
+                {"claim": "Invented conflict", "papers": [{"paper": 8, "reason": "Does not exist"}]}
+            ],
+            "summary": "Well aligned."
+        })
+
+        response = client.post("/ideas/proposal", json={
+            "proposal": "  Sleep spindles in children\nWe are the first to study spindles in children.",
+            "gaps": [self.GAP]
+        })
+
+        assert response.status_code == 200
+        data = response.json()
+        assert data["alignment_score"] == 1.0
+        assert [g["gap_description"] for g in data["gaps"]] == ["Small samples"]
+        assert len(data["novelty_conflicts"]) == 1
+        assert [p["title"] for p in data["novelty_conflicts"][0]["papers"]] == ["Spindles in children"]
+        mock_fetch.assert_called_once_with("Sleep spindles in children", 10)
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "Known research gaps:\n[1] Small samples" in prompt
+        assert "[1] Spindles in children (2024)" in prompt
+
+    def test_empty_proposal(self, client):
+        """Test that the proposal must not be empty"""
+        response = client.post("/ideas/proposal", json={"proposal": "\n"})
+        assert response.status_code == 422
+
+
+class TestFundingEndpoint:
+    """Test the /gaps/funding endpoint"""
+
+    GAPS = [
+        {"gap_description": "Small samples in sleep studies", "confidence_score": 0.8,
+         "gap_type": "empirical", "potential_impact": "High"},
+        {"gap_description": "No replication of findings", "confidence_score": 0.6,
+         "gap_type": "methodological", "potential_impact": "Medium"}
+    ]
+
+    CALLS = [
+        {"agency": "NSF", "title": "Replication in the social sciences", "url": "https://nsf.gov/1", "summary": None},
+        {"agency": "NIH", "title": "Sleep cohort studies", "url": "https://nih.gov/2",
+         "summary": "Large samples of sleepers."},
+        {"agency": "NIH", "title": "Cancer imaging", "url": "https://nih.gov/3", "summary": None}
+    ]
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.funding_service')
+    def test_funding(self, mock_funding, mock_llm, client):
+        """Test that calls are ranked and the calls and gaps the LLM invents are dropped"""
+        mock_funding.open_calls = AsyncMock(return_value=self.CALLS)
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={"matches": [
+            {"call": 2, "gaps": [2], "relevance_score": 0.4, "reason": "Funds replications"},
+            {"call": 1, "gaps": [1, 1, 3], "relevance_score": 1.5, "reason": "Funds sleep cohorts"},
+            {"call": 2, "gaps": [1], "relevance_score": 0.9, "reason": "Repeated"},
+            {"call": 9, "gaps": [1], "relevance_score": 0.9, "reason": "Does not exist"}
+        ]})
+
+        response = client.post("/gaps/funding", json={"gaps": self.GAPS})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert data["calls_searched"] == 3
+        assert [(m["call"]["url"], m["gaps"], m["relevance_score"]) for m in data["matches"]] == [
+            ("https://nih.gov/2", [0], 1.0),
+            ("https://nsf.gov/1", [1], 0.4)
+        ]
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "[1] Sleep cohort studies (NIH)\nSummary: Large samples of sleepers." in prompt
+        assert "Cancer imaging" not in prompt
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.funding_service')
+    def test_no_candidates(self, mock_funding, mock_llm, client):
+        """Test that the LLM is not asked without calls related to the gaps"""
+        mock_funding.open_calls = AsyncMock(return_value=self.CALLS[2:])
+        mock_llm.analyze_with_prompt = AsyncMock()
+
+        response = client.post("/gaps/funding", json={"gaps": self.GAPS})
+
+        assert response.status_code == 200
+        assert response.json()["matches"] == []
+        mock_llm.analyze_with_prompt.assert_not_called()
+
+    def test_too_many_matches(self, client):
+        """Test that max_matches is limited"""
+        response = client.post("/gaps/funding", json={"gaps": self.GAPS, "max_matches": 21})
+        assert response.status_code == 422
+
+
+class TestDatasetsEndpoint:
+    """Test the /gaps/datasets endpoint"""
+
+    HYPOTHESIS = {"hypothesis": "Longer sleep improves recall", "rationale": "r",
+                  "feasibility_score": 0.7, "required_methods": ["EEG"]}
+
+    @patch('app.service.analysis.llm_service')
+    def test_datasets(self, mock_llm, client):
+        """Test that datasets without URLs and repeated ones are dropped"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={"datasets": [
+            {"name": "Sleep-EDF", "description": "Polysomnography",
+             "url": "https://physionet.org/content/sleep-edfx/", "license": "ODC-By 1.0"},
+            {"name": "sleep-edf ", "description": "Repeated", "url": "https://physionet.org/"},
+            {"name": "Local data", "description": "No URL"},
+            {"name": "MASS", "description": "Montreal archive", "url": "http://ceams-carsm.ca/mass/"}
+        ]})
+
+        response = client.post("/gaps/datasets", json={"hypothesis": self.HYPOTHESIS, "max_datasets": 1})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert [d["name"] for d in data["datasets"]] == ["Sleep-EDF"]
+        assert data["datasets"][0]["license"] == "ODC-By 1.0"
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "test the following hypothesis:\nLonger sleep improves recall" in prompt
+        assert "up to 1 publicly" in prompt
+
+    def test_requires_one_subject(self, client):
+        """Test that exactly one of gap and hypothesis must be set"""
+        gap = {"gap_description": "Small samples", "confidence_score": 0.8,
+               "gap_type": "empirical", "potential_impact": "High"}
+        assert client.post("/gaps/datasets", json={}).status_code == 422
+        response = client.post("/gaps/datasets", json={"gap": gap, "hypothesis": self.HYPOTHESIS})
+        assert response.status_code == 422
+
+
+class TestRelatedWorkEndpoint:
+    """Test the /gaps/related endpoint"""
+
+    GAP = {"gap_description": "Small samples in sleep studies", "confidence_score": 0.8,
+           "gap_type": "empirical", "potential_impact": "High"}
+
+    PAPERS = [
+        {"title": "A large sleep cohort", "authors": ["Ann"], "abstract": "", "url": "http://arxiv.org/abs/1",
+         "published": "2025-01-02T00:00:00Z"},
+        {"title": "Sleep in students", "authors": [], "abstract": "We study 20 students."}
+    ]
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_related_work(self, mock_fetch, mock_llm, client):
+        """Test that related papers are ranked by similarity and invented ones dropped"""
+        mock_fetch.return_value = self.PAPERS
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={"papers": [
+            {"paper": 2, "similarity": 0.4, "addresses_gap": False, "reason": "Same population"},
+            {"paper": 1, "similarity": 1.3, "addresses_gap": True, "reason": "Runs a large sample"},
+            {"paper": 2, "similarity": 0.9, "addresses_gap": True, "reason": "Repeated"},
+            {"paper": 5, "similarity": 0.9, "addresses_gap": True, "reason": "Does not exist"}
+        ]})
+
+        response = client.post("/gaps/related", json={"gap": self.GAP})
+
+        assert response.status_code == 200
+        papers = response.json()["papers"]
+        assert [(p["title"], p["year"], p["similarity"], p["addresses_gap"]) for p in papers] == [
+            ("A large sleep cohort", 2025, 1.0, True),
+            ("Sleep in students", None, 0.4, False)
+        ]
+        mock_fetch.assert_called_once_with("Small samples in sleep studies", 10)
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "[1] A large sleep cohort (2025)" in prompt
+
+    def test_too_many_papers(self, client):
+        """Test that max_papers is limited"""
+        response = client.post("/gaps/related", json={"gap": self.GAP, "max_papers": 21})
+        assert response.status_code == 422
+
+
+class TestResearchGroupsEndpoint:
+    """Test the /gaps/groups endpoint"""
+
+    GAP = {"gap_description": "Small samples", "confidence_score": 0.8,
+           "gap_type": "empirical", "potential_impact": "High"}
+
+    PAPERS = [
+        {"paper_title": "P1", "authors": ["Ann", "Bob"], "abstract": "A1", "gaps": []},
+        {"paper_title": "P2", "authors": ["Cem"], "abstract": "A2", "gaps": []},
+        {"paper_title": "P3", "authors": ["Cem", "Ann"], "abstract": "A3", "gaps": []},
+        {"paper_title": "P4", "authors": ["Dee"], "abstract": "A4", "gaps": []},
+        {"paper_title": "P5", "authors": [], "abstract": "A5", "gaps": []}
+    ]
+
+    @patch('app.service.analysis.llm_service')
+    def test_groups(self, mock_llm, client):
+        """Test that the authors of the papers near a gap are grouped by co-authorship"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={"gaps": [
+            {"gap": 1, "papers": [1, 2, 3, 4, 4, 5, 9]},
+            {"gap": 5, "papers": [1]}
+        ]})
+
+        response = client.post("/gaps/groups", json={
+            "topic": "sleep", "papers": self.PAPERS,
+            "common_gaps": [self.GAP, dict(self.GAP, gap_description="No replication")]
+        })
+
+        assert response.status_code == 200
+        gaps = response.json()["common_gaps"]
+        assert gaps[0]["research_groups"] == [
+            {"authors": ["Ann", "Bob", "Cem"], "papers": ["P1", "P2", "P3"]},
+            {"authors": ["Dee"], "papers": ["P4"]}
+        ]
+        assert gaps[1]["research_groups"] == []
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "[2] No replication" in prompt
+        assert "[4] P4\nAbstract: A4" in prompt
+
+    def test_no_gaps(self, client):
+        """Test that common_gaps must not be empty"""
+        response = client.post("/gaps/groups", json={"topic": "sleep", "papers": self.PAPERS, "common_gaps": []})
+        assert response.status_code == 422
+
+
+class TestHypothesesEndpoint:
+    """Test the /hypotheses endpoint"""
+
+    GAP = {"gap_description": "Small sample", "confidence_score": 0.8,
+           "gap_type": "empirical", "potential_impact": "Better estimates"}
+
+    @patch('app.service.analysis.llm_service')
+    def test_hypotheses(self, mock_llm, client):
+        """Test that the gaps reach the prompt and the hypotheses are returned"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={"hypotheses": [
+            {"hypothesis": "Larger samples", "rationale": "Small sample", "feasibility_score": 0.7,
+             "required_methods": ["RCT"]}
+        ]})
+
+        response = client.post("/hypotheses", json={"gaps": [self.GAP], "field": "medicine"})
+
+        assert response.status_code == 200
+        assert response.json()["hypotheses"][0]["hypothesis"] == "Larger samples"
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "1. (empirical) Small sample Potential impact: Better estimates" in prompt
+        assert "field of medicine" in prompt
+
+    def test_undescribed_gap(self, client):
+        """Test that gaps without a description are rejected"""
+        response = client.post("/hypotheses", json={"gaps": [dict(self.GAP, gap_description=" ")]})
+        assert response.status_code == 422
+
+
+class TestMethodsEndpoint:
+    """Test the /hypotheses/methods endpoint"""
+
+    HYPOTHESIS = {"hypothesis": "Longer sleep improves recall", "rationale": "r",
+                  "feasibility_score": 0.7, "required_methods": ["RCT", "EEG"]}
+
+    @patch('app.service.analysis.llm_service')
+    def test_methods(self, mock_llm, client):
+        """Test that unnamed designs and invalid dataset URLs are dropped"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={
+            "designs": [
+                {"name": "Crossover trial", "description": "d", "sample_size": "40 participants for 80% power"},
+                {"name": " ", "description": "Unnamed"}
+            ],
+            "datasets": [
+                {"name": "Sleep-EDF", "description": "Polysomnography", "url": "https://physionet.org/content/sleep-edfx/"},
+                {"name": "Local data", "description": "d", "url": "ask the authors"}
+            ]
+        })
+
+        response = client.post("/hypotheses/methods", json={"hypothesis": self.HYPOTHESIS})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert [d["name"] for d in data["designs"]] == ["Crossover trial"]
+        assert data["designs"][0]["methods"] == []
+        assert [d["url"] for d in data["datasets"]] == ["https://physionet.org/content/sleep-edfx/", None]
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "Required methods: RCT, EEG" in prompt
+
+    def test_empty_hypothesis(self, client):
+        """Test that the hypothesis must not be empty"""
+        response = client.post("/hypotheses/methods", json={"hypothesis": dict(self.HYPOTHESIS, hypothesis=" ")})
+        assert response.status_code == 422
+
+
+class TestReviewEndpoint:
+    """Test the /review endpoint"""
+
+    REQUEST = {
+        "topic": "sleep and memory",
+        "papers": [
+            {"paper_title": "Sleep helps memory", "authors": ["A. Author"], "abstract": "Sleep improves recall.",
+             "gaps": [{"gap_description": "Small sample", "confidence_score": 0.8, "gap_type": "empirical",
+                       "potential_impact": "High"}],
+             "url": "http://arxiv.org/abs/1"},
+            {"paper_title": "Sleep and recall", "authors": [], "abstract": "No effect.", "gaps": [], "url": None}
+        ]
+    }
+
+    @patch('app.service.analysis.llm_service')
+    def test_review(self, mock_llm, client):
+        """Test that citations are resolved to the request's papers"""
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={
+            "title": "Sleep and memory: a review",
+            "sections": [
+                {"kind": "theme", "heading": "Recall", "paragraphs": ["Sleep helps [1]."], "citations": [1, 7]},
+                {"kind": "summary", "heading": "Outlook", "paragraphs": ["More work is needed."]}
+            ]
+        })
+
+        response = client.post("/review", json=self.REQUEST)
+
+        assert response.status_code == 200
+        data = response.json()
+        assert data["sections"][0]["citations"] == [1]
+        assert data["sections"][1]["kind"] == "theme"
+        assert data["references"] == [
+            {"number": 1, "title": "Sleep helps memory", "authors": ["A. Author"], "url": "http://arxiv.org/abs/1"}
+        ]
+        prompt = mock_llm.analyze_with_prompt.call_args.args[0]
+        assert "[2] Sleep and recall" in prompt
+        assert "Gaps: Small sample" in prompt
+        assert "None identified" in prompt
+
+    def test_no_papers(self, client):
+        """Test that a review needs papers"""
+        response = client.post("/review", json=dict(self.REQUEST, papers=[]))
+        assert response.status_code == 422
+
+
+class TestBatchEndpoint:
+    """Test the /analyze/batch endpoint"""
+
+    @patch('app.service.analysis.analyze_text')
+    def test_batch_reports_errors_per_item(self, mock_analyze, client, sample_analyze_request):
+        """Test that one failed abstract doesn't fail the batch"""
+        result = {
+            "key_findings": ["Finding 1"],
+            "gaps": [],
+            "suggested_hypotheses": [],
+            "limitations": [],
+            "methodology_gaps": [],
+            "future_directions": []
+        }
+        mock_analyze.side_effect = [dict(result), Exception("LLM error"), dict(result)]
+
+        response = client.post("/analyze/batch", json={"requests": [sample_analyze_request] * 3})
+
+        assert response.status_code == 200
+        data = response.json()
+        assert [r["index"] for r in data["results"]] == [0, 1, 2]
+        assert data["results"][0]["result"]["key_findings"] == ["Finding 1"]
+        assert data["results"][1]["result"] is None
+        assert data["results"][1]["error"]
+        assert "processing_time" in data
+
+    def test_batch_invalid_item(self, client, sample_analyze_request):
+        """Test that an invalid abstract rejects the whole batch"""
+        invalid = dict(sample_analyze_request, title="")
+        response = client.post("/analyze/batch", json={"requests": [sample_analyze_request, invalid]})
+        assert response.status_code == 422
+
+    def test_batch_empty(self, client):
+        """Test batch without abstracts"""
+        response = client.post("/analyze/batch", json={"requests": []})
+        assert response.status_code == 422
+
+
+class TestPDFEndpoint:
+    """Test the /analyze/pdf endpoint"""
+
+    ANALYSIS = {
+        "key_findings": [],
+        "gaps": [],
+        "suggested_hypotheses": [],
+        "limitations": [],
+        "methodology_gaps": [],
+        "future_directions": []
+    }
+
+    @patch('app.service.analysis.pdf_extractor')
+    @patch('app.service.analysis.analyze_text', new_callable=AsyncMock)
+    def test_pdf_text_is_analyzed(self, mock_analyze, mock_extractor, client):
+        """Test that the extracted text is analyzed under the given title"""
+        mock_extractor.max_file_size = 1024
+        mock_extractor.extract_text.return_value = {"text": "Abstract. We study sleep.", "metadata": {}, "success": True}
+        mock_analyze.return_value = dict(self.ANALYSIS)
+
+        response = client.post(
+            "/analyze/pdf",
+            files={"file": ("paper.pdf", b"%PDF-1.4", "application/pdf")},
+            data={"title": "Sleep", "field": "neuroscience", "authors": ["A", "B"]}
+        )
+
+        assert response.status_code == 200
+        assert "processing_time" in response.json()
+        request = mock_analyze.call_args.args[0]
+        assert request.title == "Sleep"
+        assert request.abstract == "Abstract. We study sleep."
+        assert request.field == "neuroscience"
+        assert request.authors == ["A", "B"]
+
+    @patch('app.service.analysis.pdf_extractor')
+    @patch('app.service.analysis.analyze_text', new_callable=AsyncMock)
+    def test_title_defaults_to_file_name(self, mock_analyze, mock_extractor, client):
+        """Test the title fallback when the PDF has no title metadata"""
+        mock_extractor.max_file_size = 1024
+        mock_extractor.extract_text.return_value = {"text": "Text", "metadata": {}, "success": True}
+        mock_extractor.extract_metadata_only.return_value = {"title": ""}
+        mock_analyze.return_value = dict(self.ANALYSIS)
+
+        response = client.post("/analyze/pdf", files={"file": ("sleep-study.pdf", b"%PDF-1.4", "application/pdf")})
+
+        assert response.status_code == 200
+        assert mock_analyze.call_args.args[0].title == "sleep-study"
+
+    @patch('app.service.analysis.pdf_extractor')
+    def test_unreadable_pdf(self, mock_extractor, client):
+        """Test that a PDF without text is rejected"""
+        mock_extractor.max_file_size = 1024
+        mock_extractor.extract_text.return_value = {"text": "", "metadata": {}, "success": False}
+
+        response = client.post("/analyze/pdf", files={"file": ("scan.pdf", b"%PDF-1.4", "application/pdf")})
+
+        assert response.status_code == 422
+        assert "extract" in response.json()["detail"]
+
+    @patch('app.service.analysis.pdf_extractor')
+    def test_oversized_pdf(self, mock_extractor, client):
+        """Test that PDFs above the size limit are rejected"""
+        mock_extractor.max_file_size = 4
+
+        response = client.post("/analyze/pdf", files={"file": ("big.pdf", b"%PDF-1.4", "application/pdf")})
+
+        assert response.status_code == 422
+
+    def test_missing_file(self, client):
+        """Test that the file is required"""
+        response = client.post("/analyze/pdf", data={"title": "Sleep"})
+        assert response.status_code == 422
+
+    @patch('app.service.analysis.pdf_extractor')
+    @patch('app.service.analysis.analyze_text', new_callable=AsyncMock)
+    def test_full_text_mode(self, mock_analyze, mock_extractor, client):
+        """Test that full_text mode passes the whole text along"""
+        text = "Abstract. We study sleep.\nMethods\nWe recorded 12 mice."
+        mock_extractor.max_file_size = 1024
+        mock_extractor.extract_text.return_value = {"text": text, "metadata": {}, "success": True}
+        mock_analyze.return_value = dict(self.ANALYSIS)
+
+        response = client.post(
+            "/analyze/pdf",
+            files={"file": ("paper.pdf", b"%PDF-1.4", "application/pdf")},
+            data={"title": "Sleep", "mode": "full_text"}
+        )
+
+        assert response.status_code == 200
+        request = mock_analyze.call_args.args[0]
+        assert request.mode == "full_text"
+        assert request.full_text == text
+
+
+class TestDOIEndpoint:
+    """Test the /analyze/doi endpoint"""
+
+    PAPER = {
+        "title": "Sleep and memory",
+        "abstract": "We study sleep.",
+        "authors": ["Ada Lovelace"],
+        "url": "https://doi.org/10.1000/xyz"
+    }
+
+    @patch('app.service.analysis.crossref_service')
+    @patch('app.service.analysis.analyze_text', new_callable=AsyncMock)
+    def test_doi_is_resolved_and_analyzed(self, mock_analyze, mock_crossref, client):
+        """Test that the CrossRef metadata is analyzed and returned"""
+        mock_crossref.get_work = AsyncMock(return_value=dict(self.PAPER))
+        mock_analyze.return_value = dict(TestPDFEndpoint.ANALYSIS)
+
+        response = client.post("/analyze/doi", json={"doi": "https://doi.org/10.1000/xyz", "field": "neuroscience"})
+
+        assert response.status_code == 200
+        mock_crossref.get_work.assert_awaited_once_with("10.1000/xyz")
+        request = mock_analyze.call_args.args[0]
+        assert request.title == "Sleep and memory"
+        assert request.abstract == "We study sleep."
+        assert request.field == "neuroscience"
+        paper = response.json()["paper"]
+        assert paper["source"] == "crossref"
+        assert paper["id"] == "10.1000/xyz"
+        assert paper["authors"] == ["Ada Lovelace"]
+
+    @patch('app.service.analysis.crossref_service')
+    def test_unknown_doi(self, mock_crossref, client):
+        """Test that an unknown DOI is answered with 404"""
+        mock_crossref.get_work = AsyncMock(return_value=None)
+
+        response = client.post("/analyze/doi", json={"doi": "10.1000/missing"})
+
+        assert response.status_code == 404
+
+    @patch('app.service.analysis.crossref_service')
+    def test_doi_without_abstract(self, mock_crossref, client):
+        """Test that a paper without an abstract is rejected"""
+        mock_crossref.get_work = AsyncMock(return_value=dict(self.PAPER, abstract=""))
+
+        response = client.post("/analyze/doi", json={"doi": "10.1000/xyz"})
+
+        assert response.status_code == 422
+        assert "abstract" in response.json()["detail"]
+
+    def test_invalid_doi(self, client):
+        """Test that malformed DOIs are rejected"""
+        response = client.post("/analyze/doi", json={"doi": "not-a-doi"})
+        assert response.status_code == 422
+
+    def test_strip_jats(self):
+        """Test that CrossRef's JATS abstracts become plain text"""
+        from app.service.crossref_service import strip_jats
+        jats = "<jats:title>Abstract</jats:title><jats:p>Sleep &amp; memory\n are <jats:italic>linked</jats:italic>.</jats:p>"
+        assert strip_jats(jats) == "Sleep & memory are linked."
+
+
+class TestPMIDEndpoint:
+    """Test the /analyze/pmid endpoint"""
+
+    @patch('app.service.analysis.pubmed_service')
+    @patch('app.service.analysis.analyze_text', new_callable=AsyncMock)
+    def test_pmid_is_resolved_and_analyzed(self, mock_analyze, mock_pubmed, client):
+        """Test that the PubMed metadata is analyzed and returned"""
+        mock_pubmed.get_article = AsyncMock(return_value={
+            "title": "Sleep and memory",
+            "abstract": "BACKGROUND: Sleep matters.",
+            "authors": ["Ada Lovelace"],
+            "url": "https://pubmed.ncbi.nlm.nih.gov/31452104/"
+        })
+        mock_analyze.return_value = dict(TestPDFEndpoint.ANALYSIS)
+
+        response = client.post("/analyze/pmid", json={"pmid": "PMID: 31452104", "field": "medicine"})
+
+        assert response.status_code == 200
+        mock_pubmed.get_article.assert_awaited_once_with("31452104")
+        assert mock_analyze.call_args.args[0].abstract == "BACKGROUND: Sleep matters."
+        paper = response.json()["paper"]
+        assert paper["source"] == "pubmed"
+        assert paper["id"] == "31452104"
+
+    @patch('app.service.analysis.pubmed_service')
+    def test_unknown_pmid(self, mock_pubmed, client):
+        """Test that an unknown PMID is answered with 404"""
+        mock_pubmed.get_article = AsyncMock(return_value=None)
+
+        response = client.post("/analyze/pmid", json={"pmid": "999999999"})
+
+        assert response.status_code == 404
+
+    def test_invalid_pmid(self, client):
+        """Test that non-numeric PMIDs are rejected"""
+        response = client.post("/analyze/pmid", json={"pmid": "PMC123"})
+        assert response.status_code == 422
+
+
+class TestArxivEndpoint:
+    """Test the /analyze/arxiv endpoint"""
+
+    PAPER = {
+        "title": "Quantum Key Distribution at Scale",
+        "abstract": "We study QKD networks.",
+        "authors": ["Alice", "Bob"],
+        "url": "http://arxiv.org/abs/2101.00001v1"
+    }
+
+    @patch('app.service.analysis.arxiv_service')
+    @patch('app.service.analysis.analyze_text', new_callable=AsyncMock)
+    def test_abstract_is_analyzed(self, mock_analyze, mock_arxiv, client):
+        """Test that the arXiv metadata is analyzed and returned"""
+        mock_arxiv.get_paper_by_id = AsyncMock(return_value=dict(self.PAPER))
+        mock_analyze.return_value = dict(TestPDFEndpoint.ANALYSIS)
+
+        response = client.post("/analyze/arxiv", json={"arxiv_id": "https://arxiv.org/abs/2101.00001v1"})
+
+        assert response.status_code == 200
+        mock_arxiv.get_paper_by_id.assert_awaited_once_with("2101.00001v1")
+        request = mock_analyze.call_args.args[0]
+        assert request.abstract == "We study QKD networks."
+        assert request.mode == "abstract"
+        paper = response.json()["paper"]
+        assert paper["source"] == "arxiv"
+        assert paper["id"] == "2101.00001v1"
+
+    @patch('app.service.analysis.pdf_extractor')
+    @patch('app.service.analysis.arxiv_service')
+    @patch('app.service.analysis.analyze_text', new_callable=AsyncMock)
+    def test_full_text(self, mock_analyze, mock_arxiv, mock_extractor, client):
+        """Test that the PDF is fetched and analyzed in full_text mode"""
+        mock_arxiv.get_paper_by_id = AsyncMock(return_value=dict(self.PAPER))
+        mock_arxiv.download_pdf = AsyncMock(return_value=b"%PDF-1.4")
+        mock_extractor.extract_text.return_value = {"text": "Methods\nWe built a network.", "metadata": {}, "success": True}
+        mock_analyze.return_value = dict(TestPDFEndpoint.ANALYSIS)
+
+        response = client.post("/analyze/arxiv", json={"arxiv_id": "arXiv:2101.00001", "full_text": True})
+
+        assert response.status_code == 200
+        mock_arxiv.download_pdf.assert_awaited_once_with("2101.00001")
+        request = mock_analyze.call_args.args[0]
+        assert request.mode == "full_text"
+        assert request.full_text == "Methods\nWe built a network."
+
+    @patch('app.service.analysis.arxiv_service')
+    def test_unknown_id(self, mock_arxiv, client):
+        """Test that an unknown arXiv ID is answered with 404"""
+        mock_arxiv.get_paper_by_id = AsyncMock(return_value=None)
+
+        response = client.post("/analyze/arxiv", json={"arxiv_id": "2101.99999"})
+
+        assert response.status_code == 404
+
+    @pytest.mark.parametrize("arxiv_id", ["hep-th/9901001", "2101.00001", "1501.0001v2"])
+    def test_id_formats(self, arxiv_id):
+        """Test that old and new style IDs are accepted"""
+        from app.schema.models import ArxivRequest
+        assert ArxivRequest(arxiv_id=arxiv_id).arxiv_id == arxiv_id
+
+    def test_invalid_id(self, client):
+        """Test that malformed IDs are rejected"""
+        response = client.post("/analyze/arxiv", json={"arxiv_id": "quantum"})
+        assert response.status_code == 422
+
+
+class TestTopicStreamEndpoint:
+    """Test the /topic/stream endpoint"""
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.analyze_text', new_callable=AsyncMock)
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_stream_sends_results_then_summary(self, mock_fetch, mock_analyze, mock_llm, client, sample_topic_request):
+        """Test that each paper's result is streamed before the summary"""
+        mock_fetch.return_value = [
+            {"title": "Paper 1", "abstract": "Abstract 1", "authors": ["A"], "url": "http://arxiv.org/abs/1"},
+            {"title": "Paper 2", "abstract": "Abstract 2", "authors": ["B"], "url": "http://arxiv.org/abs/2"}
+        ]
+        mock_analyze.return_value = {"gaps": []}
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={
+            "common_gaps": [],
+            "suggested_research_directions": ["Direction 1"]
+        })
+
+        response = client.post("/topic/stream", json=sample_topic_request)
+
+        assert response.status_code == 200
+        assert response.headers["content-type"].startswith("text/event-stream")
+        events = [line[len("event: "):] for line in response.text.splitlines() if line.startswith("event: ")]
+        assert events == ["result", "result", "summary"]
+        assert '"papers_analyzed": 2' in response.text
+
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_stream_reports_errors_as_events(self, mock_fetch, client, sample_topic_request):
+        """Test that a failure after the stream started is sent as an event"""
+        mock_fetch.side_effect = Exception("arXiv unavailable")
+
+        response = client.post("/topic/stream", json=sample_topic_request)
+
+        assert response.status_code == 200
+        assert "event: error" in response.text
+        assert "arXiv" not in response.text
+
+
+class TestTopicProgressWebSocket:
+    """Test the /topic/ws endpoint"""
+
+    @patch('app.service.analysis.llm_service')
+    @patch('app.service.analysis.analyze_text', new_callable=AsyncMock)
+    @patch('app.service.analysis.fetch_papers_by_topic', new_callable=AsyncMock)
+    def test_reports_progress_then_result(self, mock_fetch, mock_analyze, mock_llm, client, sample_topic_request):
+        """Test that each paper's stages are reported before the result"""
+        mock_fetch.return_value = [
+            {"title": "Paper 1", "abstract": "Abstract 1", "authors": ["A"], "url": "http://arxiv.org/abs/1"},
+            {"title": "Paper 2", "abstract": "Abstract 2", "authors": ["B"], "url": "http://arxiv.org/abs/2"}
+        ]
+        mock_analyze.side_effect = [{"gaps": []}, Exception("LLM error")]
+        mock_llm.analyze_with_prompt = AsyncMock(return_value={
+            "common_gaps": [],
+            "suggested_research_directions": ["Direction 1"]
+        })
+
+        with client.websocket_connect("/topic/ws") as ws:
+            ws.send_json(sample_topic_request)
+            messages = []
+            while not messages or messages[-1]["type"] == "progress":
+                messages.append(ws.receive_json())
+
+        stages = [m["progress"]["stage"] for m in messages[:-1]]
+        assert stages[:2] == ["fetched", "fetched"]
+        assert sorted(stages[2:]) == ["analyzing", "analyzing", "done", "failed"]
+        assert all(m["progress"]["total"] == 2 for m in messages[:-1])
+        result = messages[-1]
+        assert result["type"] == "result"
+        assert result["result"]["papers_analyzed"] == 2
+        assert len(result["result"]["individual_results"]) == 1
+
+    def test_rejects_invalid_request(self, client):
+        """Test that an invalid request is answered with an error message"""
+        with client.websocket_connect("/topic/ws") as ws:
+            ws.send_json({"topic": "", "max_papers": 500})
+            message = ws.receive_json()
+
+        assert message["type"] == "error"
+        assert "topic" in message["detail"] or "max_papers" in message["detail"]
@@ -0,0 +1,127 @@
+"""Tests for the history of analyses"""
+
+from datetime import datetime, timedelta, timezone
+from unittest.mock import AsyncMock, patch
+from app.service.history import AnalysisHistory
+
+
+def _result(finding):
+    return {"key_findings": [finding], "gaps": [], "suggested_hypotheses": [], "limitations": [],
+            "methodology_gaps": [], "future_directions": [], "processing_time": 0.1}
+
+
+class TestAnalysisHistory:
+    """Test analysis history behaviour"""
+
+    def test_list_filters(self):
+        """Test that analyses are listed newest first and filtered"""
+        history = AnalysisHistory()
+        first = history.add_abstract("Protein folding", "biology", _result("f1"))
+        history.add_topic("Graph neural networks", "computer_science", {"topic": "Graph neural networks"})
+        last = history.add_abstract("Folding at home", "computer_science", _result("f2"))
+
+        assert [a["analysis_id"] for a in history.list(topic="FOLDING")] == [last, first]
+        assert [a["analysis_id"] for a in history.list(field="biology")] == [first]
+        assert len(history.list(limit=2)) == 2
+        assert "result" not in history.list()[0]
+
+        now = datetime.now(timezone.utc)
+        assert history.list(since=now + timedelta(minutes=1)) == []
+        assert history.list(until=now - timedelta(minutes=1)) == []
+
+    def test_get(self):
+        """Test that a kept analysis holds its result, unaffected by later changes"""
+        history = AnalysisHistory()
+        result = _result("f1")
+        analysis_id = history.add_abstract("Paper", "general", result)
+        result["key_findings"].append("changed")
+
+        analysis = history.get(analysis_id)
+        assert analysis["kind"] == "abstract"
+        assert analysis["result"]["key_findings"] == ["f1"]
+        assert history.get("unknown") is None
+
+    def test_delete(self):
+        """Test that a deleted analysis is neither listed nor fetched"""
+        history = AnalysisHistory()
+        analysis_id = history.add_abstract("Unpublished", "general", _result("f1"))
+        kept = history.add_abstract("Published", "general", _result("f2"))
+
+        assert history.delete(analysis_id)
+        assert history.get(analysis_id) is None
+        assert [a["analysis_id"] for a in history.list()] == [kept]
+        assert not history.delete(analysis_id)
+
+    def test_delete_returns_origin(self):
+        """Test that deleting tells where else the result is held"""
+        history = AnalysisHistory()
+        analysis_id = history.add_topic("Sleep", "general", {"topic": "Sleep"}, "topic-job:k1", "job-1")
+
+        assert history.delete(analysis_id) == {"idempotency_key": "topic-job:k1", "job_id": "job-1"}
+
+    def test_oldest_forgotten(self):
+        """Test that the oldest analyses are forgotten past the limit"""
+        history = AnalysisHistory(max_entries=2)
+        first = history.add_abstract("A", "general", _result("a"))
+        history.add_abstract("B", "general", _result("b"))
+        history.add_abstract("C", "general", _result("c"))
+
+        assert history.get(first) is None
+        assert len(history.list()) == 2
+
+
+class TestAnalysesEndpoints:
+    """Test /analyses"""
+
+    @patch('app.api.app.analyze_topic', new_callable=AsyncMock)
+    def test_topic_kept(self, mock_analyze, client):
+        """Test that a topic analysis is kept with all its individual results"""
+        mock_analyze.return_value = {
+            "topic": "History of kept topics",
+            "papers_analyzed": 3,
+            "common_gaps": [],
+            "individual_results": [{"paper_title": f"Paper {i}", "gaps": []} for i in range(3)],
+            "suggested_research_directions": []
+        }
+        response = client.post("/topic", json={"topic": "History of kept topics", "page_size": 1})
+        assert response.status_code == 200
+
+        response = client.get("/analyses", params={"topic": "kept topics", "field": "general"})
+        assert response.status_code == 200
+        analyses = response.json()["analyses"]
+        assert len(analyses) == 1
+        assert analyses[0]["kind"] == "topic"
+
+        response = client.get(f"/analyses/{analyses[0]['analysis_id']}")
+        assert response.status_code == 200
+        analysis = response.json()
+        assert len(analysis["topic_result"]["individual_results"]) == 3
+        assert analysis["topic_result"]["next_cursor"] is None
+        assert analysis["result"] is None
+
+        response = client.delete(f"/analyses/{analysis['analysis_id']}")
+        assert response.status_code == 204
+        assert client.get(f"/analyses/{analysis['analysis_id']}").status_code == 404
+        assert client.delete(f"/analyses/{analysis['analysis_id']}").status_code == 404
+
+    @patch('app.api.app.analyze_text', new_callable=AsyncMock)
+    def test_delete_forgets_idempotent_result(self, mock_analyze, client):
+        """Test that a repeated request doesn't get a deleted analysis back"""
+        mock_analyze.return_value = _result("f1")
+        headers = {"Idempotency-Key": "unpublished-1"}
+        assert client.post("/analyze", json={"title": "Unpublished work", "abstract": "A"}, headers=headers).status_code == 200
+        analysis_id = client.get("/analyses", params={"topic": "unpublished work"}).json()["analyses"][0]["analysis_id"]
+
+        assert client.delete(f"/analyses/{analysis_id}").status_code == 204
+        assert client.post("/analyze", json={"title": "Unpublished work", "abstract": "A"}, headers=headers).status_code == 200
+        assert mock_analyze.await_count == 2
+
+    def test_unknown_analysis(self, client):
+        """Test that an unknown analysis is a 404"""
+        response = client.get("/analyses/unknown")
+        assert response.status_code == 404
+
+    def test_limit(self, client):
+        """Test that oversized limits are rejected"""
+        response = client.get("/analyses", params={"limit": 1000})
+        assert response.status_code == 422
@@ -0,0 +1,77 @@
+"""Tests for Idempotency-Key handling"""
+
+import asyncio
+import pytest
+from app.utils.idempotency import IdempotencyCache
+
+
+class TestIdempotencyCache:
+    """Test idempotency cache behaviour"""
+
+    @pytest.mark.asyncio
+    async def test_same_key_runs_once(self):
+        """Test that a repeated key reuses the first result"""
+        cache = IdempotencyCache()
+        calls = []
+
+        async def analyze():
+            calls.append(1)
+            return {"gaps": []}
+
+        first = await cache.run("analyze:abc", analyze)
+        second = await cache.run("analyze:abc", analyze)
+
+        assert first == second == {"gaps": []}
+        assert len(calls) == 1
+
+    @pytest.mark.asyncio
+    async def test_concurrent_requests_share_run(self):
+        """Test that a retry arriving mid-analysis waits for the first run"""
+        cache = IdempotencyCache()
+        calls = []
+
+        async def analyze():
+            calls.append(1)
+            await asyncio.sleep(0.01)
+            return {"gaps": []}
+
+        results = await asyncio.gather(
+            cache.run("analyze:abc", analyze),
+            cache.run("analyze:abc", analyze)
+        )
+
+        assert results[0] == results[1]
+        assert len(calls) == 1
+
+    @pytest.mark.asyncio
+    async def test_failed_run_is_not_stored(self):
+        """Test that a failed analysis can be retried with the same key"""
+        cache = IdempotencyCache()
+        attempts = []
+
+        async def analyze():
+            attempts.append(1)
+            if len(attempts) == 1:
+                raise RuntimeError("LLM unavailable")
+            return {"gaps": []}
+
+        with pytest.raises(RuntimeError):
+            await cache.run("analyze:abc", analyze)
+
+        assert await cache.run("analyze:abc", analyze) == {"gaps": []}
+        assert len(attempts) == 2
+
+    @pytest.mark.asyncio
+    async def test_no_key_always_runs(self):
+        """Test that requests without a key are never deduplicated"""
+        cache = IdempotencyCache()
+        calls = []
+
+        async def analyze():
+            calls.append(1)
+            return {}
+
+        await cache.run(None, analyze)
+        await cache.run(None, analyze)
+
+        assert len(calls) == 2
@@ -0,0 +1,132 @@
+"""Tests for background jobs"""
+
+import asyncio
+import pytest
+from app.service.jobs import JobStore, JobStoreFullError
+
+
+class TestJobStore:
+    """Test job store behaviour"""
+
+    @pytest.mark.asyncio
+    async def test_job_succeeds(self):
+        """Test that a finished job holds its result"""
+        store = JobStore()
+
+        async def analyze():
+            await asyncio.sleep(0.01)
+            return {"topic": "t"}
+
+        job = store.submit(analyze)
+        assert job["status"] == "pending"
+
+        await asyncio.sleep(0.05)
+        job = store.get(job["job_id"])
+        assert job["status"] == "succeeded"
+        assert job["result"]["topic"] == "t"
+        assert "processing_time" in job["result"]
+        assert job["error"] is None
+
+    @pytest.mark.asyncio
+    async def test_job_fails(self):
+        """Test that a failed job reports a generic error"""
+        store = JobStore()
+
+        async def analyze():
+            raise RuntimeError("LLM quota exceeded")
+
+        job = store.submit(analyze)
+        await asyncio.sleep(0.01)
+        job = store.get(job["job_id"])
+        assert job["status"] == "failed"
+        assert job["result"] is None
+        assert "quota" not in job["error"]
+
+    @pytest.mark.asyncio
+    async def test_oldest_jobs_evicted(self):
+        """Test that the store stays bounded"""
+        store = JobStore(max_entries=2)
+
+        async def analyze():
+            return {}
+
+        first = store.submit(analyze)
+        store.submit(analyze)
+        await asyncio.sleep(0.01)
+        store.submit(analyze)
+        assert store.get(first["job_id"]) is None
+
+    @pytest.mark.asyncio
+    async def test_running_jobs_kept(self):
+        """Test that only finished jobs are evicted and a full store refuses jobs"""
+        store = JobStore(max_entries=2)
+        release = asyncio.Event()
+
+        async def slow():
+            await release.wait()
+            return {}
+
+        async def fast():
+            return {}
+
+        running = store.submit(slow)
+        finished = store.submit(fast)
+        await asyncio.sleep(0.01)
+
+        store.submit(slow)
+        assert store.get(finished["job_id"]) is None
+        assert store.get(running["job_id"])["status"] == "running"
+        with pytest.raises(JobStoreFullError):
+            store.submit(slow)
+        release.set()
+
+    @pytest.mark.asyncio
+    async def test_on_done(self):
+        """Test that on_done gets the finished job"""
+        store = JobStore()
+        finished = []
+
+        async def analyze():
+            return {"topic": "t"}
+
+        async def on_done(job):
+            finished.append(job)
+
+        job = store.submit(analyze, on_done)
+        await asyncio.sleep(0.01)
+        assert len(finished) == 1
+        assert finished[0]["job_id"] == job["job_id"]
+        assert finished[0]["status"] == "succeeded"
+
+    @pytest.mark.asyncio
+    async def test_cancel(self):
+        """Test that a cancelled job stops and reports it"""
+        store = JobStore()
+        stopped = asyncio.Event()
+        finished = []
+
+        async def slow():
+            try:
+                await asyncio.sleep(10)
+            finally:
+                stopped.set()
+            return {}
+
+        async def on_done(job):
+            finished.append(job)
+
+        job = store.submit(slow, on_done)
+        await asyncio.sleep(0.01)
+        assert store.cancel(job["job_id"])["status"] == "cancelled"
+        await asyncio.sleep(0.01)
+        assert stopped.is_set()
+        assert store.get(job["job_id"])["status"] == "cancelled"
+        assert finished[0]["status"] == "cancelled"
+
+        # Finished jobs are left as they are
+        assert store.cancel(job["job_id"])["status"] == "cancelled"
+        assert store.cancel("nope") is None
+
+    def test_unknown_job(self):
+        """Test lookup of an unknown job"""
+        assert JobStore().get("nope") is None
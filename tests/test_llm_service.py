@@ -60,6 +60,14 @@ class TestLLMService:
             api_key=mock_settings.openai_api_key
         )
     
+    def test_zero_temperature_bound(self, llm_service):
+        """Test that an explicit zero temperature overrides the default"""
+        llm_service._client = Mock()
+
+        llm_service._client_for(None, 0.0)
+
+        llm_service._client.bind.assert_called_once_with(temperature=0.0)
+
     def test_client_creation_no_api_key(self, mock_settings):
         """Test client creation fails without API key"""
         mock_settings.openai_api_key = None
@@ -0,0 +1,79 @@
+"""Tests for pagination of topic results"""
+
+from unittest.mock import AsyncMock, patch
+from app.service.pages import ResultPages
+
+
+def _results(n):
+    return [{"paper_title": f"Paper {i}", "gaps": []} for i in range(n)]
+
+
+class TestResultPages:
+    """Test result page store behaviour"""
+
+    def test_pages_until_exhausted(self):
+        """Test that following cursors returns every result once"""
+        pages = ResultPages()
+        page, cursor = pages.paginate(_results(5), 2)
+        titles = [r["paper_title"] for r in page]
+        while cursor:
+            page, cursor = pages.page(cursor)
+            titles += [r["paper_title"] for r in page]
+        assert titles == [f"Paper {i}" for i in range(5)]
+
+    def test_single_page_has_no_cursor(self):
+        """Test that results fitting one page are not stored"""
+        page, cursor = ResultPages().paginate(_results(2), 2)
+        assert len(page) == 2
+        assert cursor is None
+
+    def test_invalid_cursor(self):
+        """Test lookup of unknown and malformed cursors"""
+        pages = ResultPages()
+        _, cursor = pages.paginate(_results(3), 1)
+        assert pages.page("nope.1") is None
+        assert pages.page(cursor.split(".")[0] + ".x") is None
+
+    def test_oldest_results_evicted(self):
+        """Test that the store stays bounded"""
+        pages = ResultPages(max_entries=1)
+        _, first = pages.paginate(_results(3), 1)
+        pages.paginate(_results(3), 1)
+        assert pages.page(first) is None
+
+
+class TestTopicPagination:
+    """Test pagination of /topic responses"""
+
+    @patch('app.api.app.analyze_topic', new_callable=AsyncMock)
+    def test_topic_results_paged(self, mock_analyze, client):
+        """Test that a page size splits individual results across requests"""
+        mock_analyze.return_value = {
+            "topic": "t",
+            "papers_analyzed": 3,
+            "common_gaps": [],
+            "individual_results": _results(3),
+            "suggested_research_directions": []
+        }
+
+        response = client.post("/topic", json={"topic": "t", "page_size": 2})
+        assert response.status_code == 200
+        data = response.json()
+        assert len(data["individual_results"]) == 2
+        assert data["next_cursor"]
+
+        response = client.get("/topic/results", params={"cursor": data["next_cursor"]})
+        assert response.status_code == 200
+        page = response.json()
+        assert [r["paper_title"] for r in page["individual_results"]] == ["Paper 2"]
+        assert page["next_cursor"] is None
+
+    def test_unknown_cursor(self, client):
+        """Test that an unknown cursor is a 404"""
+        response = client.get("/topic/results", params={"cursor": "nope.1"})
+        assert response.status_code == 404
+
+    def test_page_size_limit(self, client):
+        """Test that oversized pages are rejected"""
+        response = client.post("/topic", json={"topic": "t", "page_size": 1000})
+        assert response.status_code == 422
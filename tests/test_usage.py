@@ -0,0 +1,61 @@
+"""Tests for per-caller usage counts and quotas"""
+
+from app.utils.usage import UsageTracker
+
+
+class TestUsageTracker:
+    """Test usage tracker behaviour"""
+
+    def test_counts_without_quota(self):
+        """Test that requests are counted by endpoint, most used first"""
+        tracker = UsageTracker()
+        for endpoint in ["POST /analyze", "GET /fields", "POST /analyze"]:
+            assert tracker.record("a", endpoint) is None
+
+        usage = tracker.report("a")
+        assert usage["requests"] == 3
+        assert usage["endpoints"] == [
+            {"endpoint": "POST /analyze", "requests": 2},
+            {"endpoint": "GET /fields", "requests": 1},
+        ]
+        assert "quota" not in usage
+        assert tracker.report("b")["requests"] == 0
+
+    def test_quota_per_window(self):
+        """Test that a used-up quota refuses requests until the next window"""
+        tracker = UsageTracker(limit=1, window=3600)
+        start = 36000 + 1800
+        assert tracker.record("a", "POST /analyze", now=start) is None
+        assert tracker.record("a", "POST /analyze", now=start + 60) == 1740
+        assert tracker.record("b", "POST /analyze", now=start) is None
+
+        usage = tracker.report("a", now=start + 60)
+        assert usage["quota"]["remaining"] == 0
+        assert usage["quota"]["reset_at"] == "1970-01-01T11:00:00+00:00"
+
+        assert tracker.record("a", "POST /analyze", now=start + 1800) is None
+        assert tracker.report("b", now=start + 1800)["requests"] == 0
+
+    def test_forgets_callers_without_quota(self):
+        """Test that counts are forgotten each window even without a quota"""
+        tracker = UsageTracker(window=3600)
+        assert tracker.record("a", "POST /analyze", now=36000) is None
+        assert tracker.report("a", now=36000 + 3600)["requests"] == 0
+        assert tracker._callers == {}
+
+
+class TestCallerKey:
+    """Test how callers are told apart"""
+
+    def test_prefers_known_keys_to_address(self):
+        """Test that known API keys and bearer tokens identify callers without being kept"""
+        tracker = UsageTracker(keys=["secret"])
+        by_key = tracker.caller_key({"x-api-key": "secret"}, "10.0.0.1")
+        assert by_key == tracker.caller_key({"authorization": "Bearer secret"}, "10.0.0.2")
+        assert "secret" not in by_key
+        assert tracker.caller_key({}, "10.0.0.1") == "addr:10.0.0.1"
+
+    def test_ignores_unknown_keys(self):
+        """Test that made-up keys don't give a caller a fresh quota"""
+        tracker = UsageTracker(keys=["secret"])
+        assert tracker.caller_key({"x-api-key": "made-up"}, "10.0.0.1") == "addr:10.0.0.1"
@@ -0,0 +1,70 @@
+"""Tests for signed callbacks of finished jobs"""
+
+import pytest
+from unittest.mock import patch, MagicMock, AsyncMock
+from app.utils.webhook import SIGNATURE_HEADER, check_callback_url, deliver, sign
+
+
+class TestSign:
+    """Test callback signatures"""
+
+    def test_matches_go_package(self):
+        """Test that signatures verify with the Go webhook package"""
+        header = sign("s3cret", b'{"job_id":"j1"}', timestamp=1700000000)
+        assert header == "t=1700000000,v1=271bb4fac600ca48407330517fd381763babe4fcf10e52f5094dad9bfbab8085"
+
+    def test_check_callback_url(self):
+        """Test which callback URLs are accepted"""
+        assert check_callback_url("https://example.com/hook", "s3cret") is None
+        assert check_callback_url("https://example.com/hook", None) == "callbacks are not enabled on this server"
+        assert "absolute" in check_callback_url("/hook", "s3cret")
+        assert "absolute" in check_callback_url("ftp://example.com/hook", "s3cret")
+        for url in ["http://localhost:8080/hook", "http://127.0.0.1/hook", "http://10.0.0.5/hook",
+                    "http://169.254.169.254/latest/meta-data", "http://[::1]/hook"]:
+            assert "private" in check_callback_url(url, "s3cret")
+        assert check_callback_url("http://localhost:8080/hook", "s3cret", allow_private=True) is None
+
+
+def _session(statuses):
+    """A mocked aiohttp.ClientSession answering posts with statuses in turn"""
+    responses = iter(statuses)
+    posts = []
+
+    def post(url, data, headers):
+        posts.append(headers)
+        response = MagicMock(status=next(responses))
+        context = MagicMock()
+        context.__aenter__ = AsyncMock(return_value=response)
+        context.__aexit__ = AsyncMock(return_value=False)
+        return context
+
+    session = MagicMock()
+    session.__aenter__ = AsyncMock(return_value=MagicMock(post=post))
+    session.__aexit__ = AsyncMock(return_value=False)
+    return session, posts
+
+
+class TestDeliver:
+    """Test delivery of callbacks"""
+
+    @pytest.mark.asyncio
+    async def test_retries_until_delivered(self):
+        """Test that failed attempts are retried, each signed"""
+        session, posts = _session([500, 204])
+        with patch('app.utils.webhook.aiohttp.ClientSession', return_value=session):
+            delivered = await deliver("https://example.com/hook", {"job_id": "j1"}, "s3cret", "req-1", backoff=0)
+
+        assert delivered
+        assert len(posts) == 2
+        assert posts[1][SIGNATURE_HEADER].startswith("t=")
+        assert posts[1]["X-Request-ID"] == "req-1"
+
+    @pytest.mark.asyncio
+    async def test_gives_up(self):
+        """Test that delivery stops after the last attempt"""
+        session, posts = _session([500] * 4)
+        with patch('app.utils.webhook.aiohttp.ClientSession', return_value=session):
+            delivered = await deliver("https://example.com/hook", {"job_id": "j1"}, "s3cret", backoff=0)
+
+        assert not delivered
+        assert len(posts) == 4